@@ -23,6 +23,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
@@ -33,6 +34,18 @@ import (
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate-only", false, "仅执行数据库迁移后退出")
+	flag.Parse()
+
+	// 仅执行迁移，供CI/CD在部署前调用
+	if *migrateOnly {
+		if err := app.Migrate(); err != nil {
+			slog.Error("执行数据库迁移失败", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// 创建应用实例
 	application, err := app.New()
 	if err != nil {
@@ -40,6 +53,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	// seed 子命令：初始化基础数据后退出
+	if flag.Arg(0) == "seed" {
+		os.Exit(runSeed(application))
+	}
+
 	// 启动HTTP服务器
 	serverErrCh := application.StartServer()
 
@@ -55,7 +73,7 @@ func main() {
 	}
 
 	// 优雅关闭应用
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), application.Config.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := application.Shutdown(ctx); err != nil {
@@ -63,3 +81,20 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runSeed 执行初始化数据并关闭应用，返回进程退出码
+func runSeed(application *app.App) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	code := 0
+	if err := application.Seed(ctx); err != nil {
+		slog.Error("初始化数据失败", "error", err)
+		code = 1
+	}
+	if err := application.Shutdown(ctx); err != nil {
+		slog.Error("应用关闭失败", "error", err)
+		code = 1
+	}
+	return code
+}
@@ -2,12 +2,13 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -18,10 +19,18 @@ import (
 
 	"github.com/vadxq/go-rest-starter/internal/app/config"
 	"github.com/vadxq/go-rest-starter/internal/app/db"
+	"github.com/vadxq/go-rest-starter/internal/app/grpcserver"
 	"github.com/vadxq/go-rest-starter/internal/app/injection"
+	"github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/internal/app/seed"
 	api "github.com/vadxq/go-rest-starter/internal/app/router"
+	"github.com/vadxq/go-rest-starter/migrations"
 	"github.com/vadxq/go-rest-starter/pkg/cache"
 	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/migrate"
+	"github.com/vadxq/go-rest-starter/pkg/storage"
+	"github.com/vadxq/go-rest-starter/pkg/tracing"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
 )
 
 // App 应用结构体
@@ -33,30 +42,30 @@ type App struct {
 	Validator *validator.Validate
 	Deps      *injection.Dependencies
 	Server    *http.Server
+	GRPC      *grpcserver.Server // 未启用gRPC时为空
 	Config    *config.AppConfig
 	logger    *slog.Logger
+	logLevel  *slog.LevelVar // 全局日志级别，配置文件变更时调整
+
+	// shutdownTracing 刷新并关闭链路追踪导出器
+	shutdownTracing tracing.ShutdownFunc
+
+	// configWatcher 监听配置文件变化并应用到运行中的组件，监听失败时为空
+	configWatcher *config.ConfigWatcher
 }
 
 // New 创建新的应用实例
 func New() (*App, error) {
-	// 配置日志输出
-	configPath := getConfigPath()
-	programLevel := setupLogger(configPath)
-
-	// 加载配置
-	cfg, err := config.LoadConfig(configPath)
+	cfg, logLevel, err := loadConfig()
 	if err != nil {
-		return nil, fmt.Errorf("加载配置失败: %w", err)
+		return nil, err
 	}
 
-	// 设置日志级别
-	setLogLevel(cfg.Log.Level, programLevel)
-	slog.Info("配置加载完成", "config_path", configPath)
-
 	// 创建应用实例
 	app := &App{
-		Config: cfg,
-		logger: slog.Default(),
+		Config:   cfg,
+		logger:   slog.Default(),
+		logLevel: logLevel,
 	}
 
 	// 初始化应用
@@ -67,15 +76,81 @@ func New() (*App, error) {
 	return app, nil
 }
 
+// Migrate 仅连接数据库并执行迁移，不初始化其他组件，供CI/CD在部署前调用
+func Migrate() error {
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	app := &App{
+		Config: cfg,
+		logger: slog.Default(),
+	}
+	if err := app.initDatabase(); err != nil {
+		return fmt.Errorf("初始化数据库失败: %w", err)
+	}
+	defer func() {
+		if sqlDB, err := app.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	return app.runMigrations()
+}
+
+// Seed 按配置创建默认管理员，邮箱已存在时跳过
+func (app *App) Seed(ctx context.Context) error {
+	created, err := seed.Admin(ctx, app.Deps.Services.UserService, app.Config.Seed.Admin)
+	if err != nil {
+		return fmt.Errorf("创建默认管理员失败: %w", err)
+	}
+
+	if created {
+		slog.Info("默认管理员已创建", "email", app.Config.Seed.Admin.Email)
+	} else {
+		slog.Info("默认管理员已存在，跳过创建", "email", app.Config.Seed.Admin.Email)
+	}
+	return nil
+}
+
+// loadConfig 配置日志输出并加载应用配置，返回的日志级别可在运行时调整
+func loadConfig() (*config.AppConfig, *slog.LevelVar, error) {
+	// 配置日志输出
+	configPath := getConfigPath()
+	programLevel := setupLogger(configPath)
+
+	// 加载配置
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	// 设置日志级别
+	setLogLevel(cfg.Log.Level, programLevel)
+	slog.Info("配置加载完成", "config_path", configPath)
+	return cfg, programLevel, nil
+}
+
 // initialize 初始化应用组件
 func (app *App) initialize() error {
 	slog.Info("开始初始化应用...")
 
+	// 初始化链路追踪，需在创建数据库和Redis连接前完成
+	if err := app.initTracing(); err != nil {
+		return fmt.Errorf("初始化链路追踪失败: %w", err)
+	}
+
 	// 初始化数据库连接
 	if err := app.initDatabase(); err != nil {
 		return fmt.Errorf("初始化数据库失败: %w", err)
 	}
 
+	// 执行数据库迁移，需在接收请求前完成
+	if err := app.runMigrations(); err != nil {
+		return fmt.Errorf("执行数据库迁移失败: %w", err)
+	}
+
 	// 初始化Redis连接
 	if err := app.initRedis(); err != nil {
 		return fmt.Errorf("初始化Redis失败: %w", err)
@@ -87,7 +162,7 @@ func (app *App) initialize() error {
 	}
 
 	// 初始化验证器
-	app.Validator = validator.New()
+	app.Validator = utils.NewValidator()
 
 	// 初始化依赖注入
 	if err := app.initDependencies(); err != nil {
@@ -99,7 +174,70 @@ func (app *App) initialize() error {
 		return fmt.Errorf("初始化路由失败: %w", err)
 	}
 
+	// 监听配置文件变化
+	app.watchConfig()
+
+	// 开始调度定时任务
+	app.Deps.Scheduler.Start()
+
 	slog.Info("应用初始化完成")
+
+	// 后台预热缓存，不阻塞启动；预热结束前就绪检查返回503
+	if warmed := app.warmCache(); warmed != nil {
+		go func() {
+			<-warmed
+			app.markStarted()
+		}()
+	} else {
+		app.markStarted()
+	}
+	return nil
+}
+
+// watchConfig 监听配置文件变化，变更后调整日志级别、数据库连接池和功能开关，见 configReloader
+// 监听失败（如配置文件不存在、只使用环境变量）时记录警告，不影响启动
+func (app *App) watchConfig() {
+	watcher, err := config.NewConfigWatcher(getConfigPath())
+	if err != nil {
+		slog.Warn("监听配置文件失败，配置变更不会自动生效", "error", err)
+		return
+	}
+
+	sqlDB, err := app.DB.DB()
+	if err != nil {
+		slog.Warn("获取数据库连接失败，连接池配置变更不会自动生效", "error", err)
+		sqlDB = nil
+	}
+	reloader := newConfigReloader(app.Config, app.logLevel, sqlDB, app.Deps.ReloadFeatureFlags)
+	watcher.OnConfigChange(reloader.Apply)
+	app.configWatcher = watcher
+}
+
+// markStarted 标记应用启动完成，就绪检查开始返回依赖状态
+func (app *App) markStarted() {
+	if app.Deps != nil && app.Deps.Handlers != nil && app.Deps.Handlers.HealthHandler != nil {
+		app.Deps.Handlers.HealthHandler.SetStarted()
+	}
+	slog.Info("应用已就绪")
+}
+
+// initTracing 初始化OpenTelemetry链路追踪
+func (app *App) initTracing() error {
+	shutdown, err := tracing.Init(context.Background(), &tracing.Config{
+		Enabled:     app.Config.Tracing.Enabled,
+		ServiceName: app.Config.Tracing.ServiceName,
+		Endpoint:    app.Config.Tracing.Endpoint,
+		Insecure:    app.Config.Tracing.Insecure,
+		SampleRatio: app.Config.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+
+	app.shutdownTracing = shutdown
+	if app.Config.Tracing.Enabled {
+		slog.Info("链路追踪已启用", "endpoint", app.Config.Tracing.Endpoint, "sample_ratio", app.Config.Tracing.SampleRatio)
+	}
 	return nil
 }
 
@@ -112,11 +250,49 @@ func (app *App) initDatabase() error {
 		return err
 	}
 	
+	if err := db.EnableCircuitBreaker(database, &app.Config.Database.CircuitBreaker); err != nil {
+		return fmt.Errorf("启用数据库断路器失败: %w", err)
+	}
+
+	if app.Config.Tracing.Enabled {
+		if err := db.EnableTracing(database); err != nil {
+			return fmt.Errorf("启用数据库追踪失败: %w", err)
+		}
+	}
+
 	app.DB = database
 	slog.Info("数据库连接成功")
 	return nil
 }
 
+// runMigrations 执行内置的数据库迁移，已执行的版本自动跳过
+func (app *App) runMigrations() error {
+	slog.Info("执行数据库迁移...")
+
+	list, err := migrations.App()
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := app.DB.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	runner, err := migrate.NewRunner(sqlDB, list, app.logger)
+	if err != nil {
+		return err
+	}
+
+	applied, err := runner.Up(context.Background())
+	if err != nil {
+		return err
+	}
+
+	slog.Info("数据库迁移完成", "applied", len(applied), "total", len(list))
+	return nil
+}
+
 // initRedis 初始化Redis连接
 func (app *App) initRedis() error {
 	slog.Info("连接Redis...")
@@ -126,6 +302,12 @@ func (app *App) initRedis() error {
 		return err
 	}
 	
+	if app.Config.Tracing.Enabled {
+		if err := db.EnableRedisTracing(redisClient); err != nil {
+			return fmt.Errorf("启用Redis追踪失败: %w", err)
+		}
+	}
+
 	app.Redis = redisClient
 	slog.Info("Redis连接成功")
 	return nil
@@ -135,9 +317,10 @@ func (app *App) initRedis() error {
 func (app *App) initCache() error {
 	slog.Info("初始化缓存...")
 	
-	// 缓存服务必须依赖Redis
+	// 缓存服务必须依赖Redis，不可用时使用空缓存，服务降级为直接访问数据库
 	if app.Redis == nil {
 		slog.Warn("Redis未配置，缓存服务将不可用")
+		app.Cache = cache.NewNullCache()
 		return nil
 	}
 	
@@ -147,14 +330,16 @@ func (app *App) initCache() error {
 		RedisAddress:      fmt.Sprintf("%s:%d", app.Config.Redis.Host, app.Config.Redis.Port),
 		RedisPassword:     app.Config.Redis.Password,
 		RedisDB:           app.Config.Redis.DB,
+		Tracing:           app.Config.Tracing.Enabled,
 	}
 
 	slog.Info("使用Redis作为缓存存储")
 	
 	cacheInstance, err := cache.NewCache(cacheOpts)
 	if err != nil {
-		slog.Error("初始化Redis缓存失败", "error", err)
+		slog.Error("初始化Redis缓存失败，降级为不使用缓存", "error", err)
 		// 缓存不是必需的，可以继续运行
+		app.Cache = cache.NewNullCache()
 		return nil
 	}
 	
@@ -168,11 +353,7 @@ func (app *App) initDependencies() error {
 	slog.Info("初始化依赖注入系统...")
 	
 	// 创建结构化日志器
-	structuredLogger, err := logger.NewLogger(&logger.LogConfig{
-		Level:   app.Config.Log.Level,
-		File:    app.Config.Log.File,
-		Console: app.Config.Log.Console,
-	})
+	structuredLogger, err := logger.NewLogger(loggerConfig(&app.Config.Log))
 	if err != nil {
 		return fmt.Errorf("创建结构化日志器失败: %w", err)
 	}
@@ -184,6 +365,7 @@ func (app *App) initDependencies() error {
 		app.Config,
 		app.Cache,
 		structuredLogger,
+		app.logLevel,
 	)
 	
 	app.Deps = deps
@@ -191,17 +373,146 @@ func (app *App) initDependencies() error {
 	return nil
 }
 
+// warmCache 在后台预热缓存，需在配置中启用且缓存可用
+// 返回在预热结束后关闭的通道，未启用预热时返回nil
+func (app *App) warmCache() <-chan struct{} {
+	if !app.Config.Cache.Warmup || !cache.Available(app.Cache) || app.Deps.CacheWarmer == nil {
+		return nil
+	}
+
+	slog.Info("开始后台预热缓存...", "tasks", app.Deps.CacheWarmer.Len(), "timeout", app.Config.Cache.WarmupTimeout)
+	start := time.Now()
+	done := app.Deps.CacheWarmer.Start(context.Background())
+	warmed := make(chan struct{})
+	go func() {
+		defer close(warmed)
+		if err := <-done; err != nil {
+			slog.Warn("缓存预热未全部完成", "error", err, "duration", time.Since(start))
+			return
+		}
+		slog.Info("缓存预热完成", "duration", time.Since(start))
+	}()
+	return warmed
+}
+
+// corsConfig 将应用配置转换为跨域中间件配置，未配置的字段沿用默认值
+func (app *App) corsConfig() *middleware.CORSConfig {
+	cfg := middleware.DefaultCORSConfig
+	if len(app.Config.CORS.AllowedOrigins) > 0 {
+		cfg.AllowedOrigins = app.Config.CORS.AllowedOrigins
+	}
+	if len(app.Config.CORS.AllowedMethods) > 0 {
+		cfg.AllowedMethods = app.Config.CORS.AllowedMethods
+	}
+	if len(app.Config.CORS.AllowedHeaders) > 0 {
+		cfg.AllowedHeaders = app.Config.CORS.AllowedHeaders
+	}
+	if len(app.Config.CORS.ExposedHeaders) > 0 {
+		cfg.ExposedHeaders = app.Config.CORS.ExposedHeaders
+	}
+	if app.Config.CORS.MaxAge > 0 {
+		cfg.MaxAge = app.Config.CORS.MaxAge
+	}
+	cfg.AllowCredentials = app.Config.CORS.AllowCredentials
+	return &cfg
+}
+
+// apiVersionConfig 将应用配置转换为API版本协商配置，未配置的字段沿用默认值
+func (app *App) apiVersionConfig() *middleware.APIVersionConfig {
+	cfg := middleware.DefaultAPIVersionConfig
+	if app.Config.API.Vendor != "" {
+		cfg.Vendor = app.Config.API.Vendor
+	}
+	if app.Config.API.DefaultVersion != "" {
+		cfg.Default = app.Config.API.DefaultVersion
+	}
+	return &cfg
+}
+
+// accessLogConfig 将应用配置转换为访问日志配置
+func (app *App) accessLogConfig() *middleware.AccessLogConfig {
+	cfg := middleware.DefaultAccessLogConfig
+	if app.Config.Log.AccessFormat != "" {
+		cfg.Format = app.Config.Log.AccessFormat
+	}
+	cfg.Redactor = logger.NewRedactor(app.Config.Log.RedactKeys...)
+	return &cfg
+}
+
+// recoveryConfig 将应用配置转换为恢复中间件配置
+func (app *App) recoveryConfig() *middleware.RecoveryConfig {
+	cfg := middleware.DefaultRecoveryConfig
+	if app.Config.Log.StackSize > 0 {
+		cfg.StackSize = app.Config.Log.StackSize
+	}
+	if app.Config.Log.MaxStackSize > 0 {
+		cfg.MaxStackSize = app.Config.Log.MaxStackSize
+	}
+	return &cfg
+}
+
+// bodyLoggingConfig 将应用配置转换为请求和响应体日志配置，未启用时返回nil
+func (app *App) bodyLoggingConfig() *middleware.BodyLoggingConfig {
+	if !app.Config.Log.Body.Enabled {
+		return nil
+	}
+	cfg := middleware.DefaultBodyLoggingConfig
+	cfg.Paths = app.Config.Log.Body.Paths
+	cfg.MaxBodySize = app.Config.Log.Body.MaxSize
+	cfg.Redactor = logger.NewRedactor(app.Config.Log.RedactKeys...)
+	return &cfg
+}
+
 // initRouter 初始化路由
 func (app *App) initRouter() error {
 	slog.Info("配置API路由...")
 	
 	router := chi.NewRouter()
+
+	sqlDB, err := app.DB.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+
+	clientIP, err := middleware.NewClientIPResolver(app.Config.Server.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("配置可信代理失败: %w", err)
+	}
+
+	// 本地存储的访问地址为站内路径时由本服务提供文件访问
+	var uploads http.Handler
+	var uploadPath string
+	if local, ok := app.Deps.Infrastructure.Storage.(*storage.LocalStorage); ok && strings.HasPrefix(app.Config.Storage.Local.BaseURL, "/") {
+		uploads, uploadPath = local.Handler(), app.Config.Storage.Local.BaseURL
+	}
 	
 	api.Setup(router, api.RouterConfig{
 		UserHandler:   app.Deps.Handlers.UserHandler,
+		UserV2Handler: app.Deps.Handlers.UserV2Handler,
 		AuthHandler:   app.Deps.Handlers.AuthHandler,
 		HealthHandler: app.Deps.Handlers.HealthHandler,
-		JWTSecret:     app.Deps.Config.JWT.Secret,
+		JWKSHandler:   app.Deps.Handlers.JWKSHandler,
+		AuditHandler:  app.Deps.Handlers.AuditHandler,
+		AvatarHandler: app.Deps.Handlers.AvatarHandler,
+		EventsHandler: app.Deps.Handlers.EventsHandler,
+		WebSocketHandler: app.Deps.Handlers.WebSocketHandler,
+		GraphQLHandler: app.Deps.Handlers.GraphQLHandler,
+		LogLevelHandler: app.Deps.Handlers.LogLevelHandler,
+		JWT:           app.Deps.JWT,
+		Redis:         app.Redis,
+		Cache:         app.Cache,
+		CORS:          app.corsConfig(),
+		APIVersion:    app.apiVersionConfig(),
+		BodyLogging:   app.bodyLoggingConfig(),
+		AccessLog:     app.accessLogConfig(),
+		Recovery:      app.recoveryConfig(),
+		Timeout:       app.Config.Server.Timeout,
+		DB:            sqlDB,
+		ClientIP:      clientIP,
+		Uploads:       uploads,
+		UploadPath:    uploadPath,
+		FeatureFlags:  app.Deps.FeatureFlags,
+		Pprof:         app.Config.Debug.Pprof,
 	})
 	
 	app.Router = router
@@ -209,9 +520,9 @@ func (app *App) initRouter() error {
 	return nil
 }
 
-// StartServer 启动HTTP服务器
+// StartServer 启动HTTP服务器，启用gRPC时同时启动gRPC服务器
 func (app *App) StartServer() <-chan error {
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -231,27 +542,122 @@ func (app *App) StartServer() <-chan error {
 		}
 	}()
 
+	if app.Config.GRPC.Enabled {
+		app.startGRPCServer(errCh)
+	}
+
 	return errCh
 }
 
+// startGRPCServer 启动gRPC服务器，与HTTP接口共用用户和认证服务、令牌配置及令牌黑名单
+func (app *App) startGRPCServer(errCh chan<- error) {
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", app.Config.GRPC.Port))
+	if err != nil {
+		errCh <- fmt.Errorf("gRPC服务器监听失败: %w", err)
+		return
+	}
+
+	services := app.Deps.Services
+	app.GRPC = grpcserver.NewServer(services.UserService, services.AuthService, app.Validator, app.Deps.JWT, app.Cache, app.logger)
+
+	go func() {
+		slog.Info("gRPC服务器启动", "port", app.Config.GRPC.Port)
+		if err := app.GRPC.Serve(lis); err != nil {
+			errCh <- err
+		}
+	}()
+}
+
 // Shutdown 优雅关闭应用
 func (app *App) Shutdown(ctx context.Context) error {
 	slog.Info("开始优雅关闭应用...")
+
+	// 标记关闭状态，就绪检查随即返回503，负载均衡器停止转发新请求
+	if app.Deps != nil && app.Deps.Handlers != nil && app.Deps.Handlers.HealthHandler != nil {
+		app.Deps.Handlers.HealthHandler.SetShuttingDown()
+	}
+
+	// 等待负载均衡器发现就绪检查失败并摘除本实例，期间仍正常处理新请求，避免转发到已停止监听的实例
+	if delay := app.Config.Server.ShutdownDelay; app.Server != nil && delay > 0 {
+		slog.Info("等待负载均衡器摘除实例...", "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	// 停止监听配置文件
+	if app.configWatcher != nil {
+		if err := app.configWatcher.Stop(); err != nil {
+			slog.Warn("停止配置文件监听失败", "error", err)
+		}
+	}
 	
 	// 使用channel收集错误
-	errChan := make(chan error, 3)
+	errChan := make(chan error, 6)
 	
 	// 并发关闭各个组件
+	httpDone := make(chan struct{})
 	go func() {
+		defer close(httpDone)
 		if app.Server != nil {
-			slog.Info("关闭HTTP服务器...")
-			errChan <- app.Server.Shutdown(ctx)
+			slog.Info("关闭HTTP服务器...", "active_requests", middleware.GlobalMetrics.ActiveRequests.Load())
+			// SSE和WebSocket长连接不会自行结束，先通知其关闭
+			streamsErr := app.closeStreams(ctx)
+			errChan <- errors.Join(streamsErr, app.shutdownServer(ctx))
+		} else {
+			errChan <- nil
+		}
+	}()
+	
+	// gRPC服务器与HTTP服务器同时停止接受新请求，等待进行中的请求完成
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
+		if app.GRPC != nil {
+			slog.Info("关闭gRPC服务器...")
+			errChan <- app.GRPC.Shutdown(ctx)
 		} else {
 			errChan <- nil
 		}
 	}()
 	
+	// 队列停止领取新消息并等待处理中的消息完成，其处理器依赖数据库和Redis，完成后才关闭连接
+	queueDone := make(chan struct{})
 	go func() {
+		defer close(queueDone)
+		if app.Deps != nil && app.Deps.Infrastructure.Queue != nil {
+			slog.Info("关闭消息队列...")
+			errChan <- app.Deps.Infrastructure.Queue.Shutdown(ctx)
+		} else {
+			errChan <- nil
+		}
+	}()
+	
+	// 定时任务停止调度并等待执行中的任务结束，任务依赖数据库和Redis，结束后才关闭连接
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		if app.Deps != nil && app.Deps.Scheduler != nil {
+			slog.Info("停止定时任务...")
+			errChan <- app.Deps.Scheduler.Stop(ctx)
+		} else {
+			errChan <- nil
+		}
+	}()
+	
+	// 数据库和Redis连接在所有使用方（进行中的HTTP和gRPC请求、队列消息、定时任务）结束后才关闭
+	dependentsDone := func() {
+		<-httpDone
+		<-grpcDone
+		<-queueDone
+		<-schedulerDone
+	}
+
+	go func() {
+		dependentsDone()
 		if app.DB != nil {
 			slog.Info("关闭数据库连接...")
 			if sqlDB, err := app.DB.DB(); err == nil {
@@ -265,6 +671,7 @@ func (app *App) Shutdown(ctx context.Context) error {
 	}()
 	
 	go func() {
+		dependentsDone()
 		if app.Redis != nil {
 			slog.Info("关闭Redis连接...")
 			errChan <- app.Redis.Close()
@@ -275,13 +682,21 @@ func (app *App) Shutdown(ctx context.Context) error {
 	
 	// 等待所有关闭操作完成
 	var hasError bool
-	for i := 0; i < 3; i++ {
+	for i := 0; i < 6; i++ {
 		if err := <-errChan; err != nil {
 			slog.Error("关闭组件失败", "error", err)
 			hasError = true
 		}
 	}
 	
+	// 其他组件关闭后刷新剩余的追踪数据
+	if app.shutdownTracing != nil {
+		if err := app.shutdownTracing(ctx); err != nil {
+			slog.Error("关闭链路追踪失败", "error", err)
+			hasError = true
+		}
+	}
+	
 	if hasError {
 		slog.Warn("应用关闭时出现错误")
 	} else {
@@ -291,6 +706,43 @@ func (app *App) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// closeStreams 结束SSE事件流，向WebSocket连接发送关闭帧并等待其关闭
+// WebSocket连接被劫持后不受 http.Server.Shutdown 管理，SSE事件流则会让 Shutdown 一直等到超时
+func (app *App) closeStreams(ctx context.Context) error {
+	if app.Deps == nil || app.Deps.Handlers == nil {
+		return nil
+	}
+	if app.Deps.Handlers.EventsHandler != nil {
+		app.Deps.Handlers.EventsHandler.Close()
+	}
+	if app.Deps.Handlers.WebSocketHandler != nil {
+		slog.Info("关闭WebSocket连接...")
+		return app.Deps.Handlers.WebSocketHandler.Shutdown(ctx)
+	}
+	return nil
+}
+
+// shutdownServer 关闭HTTP服务器，等待期间定期记录仍在处理的请求数
+func (app *App) shutdownServer(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Server.Shutdown(ctx)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			slog.Info("HTTP服务器已关闭", "active_requests", middleware.GlobalMetrics.ActiveRequests.Load())
+			return err
+		case <-ticker.C:
+			slog.Info("等待进行中的请求完成", "active_requests", middleware.GlobalMetrics.ActiveRequests.Load())
+		}
+	}
+}
+
 // 设置日志配置
 func setupLogger(configPath string) *slog.LevelVar {
 	programLevel := new(slog.LevelVar)
@@ -299,36 +751,27 @@ func setupLogger(configPath string) *slog.LevelVar {
 	cfg, err := config.LoadConfig(configPath)
 	var output io.Writer = os.Stdout
 	handlerOptions := &slog.HandlerOptions{
-		AddSource: true,
-		Level:     programLevel,
+		AddSource:   true,
+		Level:       programLevel,
+		ReplaceAttr: logger.NewRedactor().ReplaceAttr,
 	}
 
 	if err != nil {
 		slog.Warn("加载日志配置失败，使用默认控制台文本输出", "error", err)
 	} else {
+		handlerOptions.ReplaceAttr = logger.NewRedactor(cfg.Log.RedactKeys...).ReplaceAttr
+
 		if cfg.Log.File != "" {
-			currentDate := time.Now().Format("2006-01-02")
-			logDir := filepath.Dir(cfg.Log.File)
-			logFileName := filepath.Base(cfg.Log.File)
-			ext := filepath.Ext(logFileName)
-			nameWithoutExt := strings.TrimSuffix(logFileName, ext)
-			newLogFileName := fmt.Sprintf("%s-%s%s", nameWithoutExt, currentDate, ext)
-			logFilePath := filepath.Join(logDir, newLogFileName)
-
-			if mkDirErr := os.MkdirAll(logDir, 0755); mkDirErr != nil {
-				slog.Error("无法创建日志目录，使用控制台输出", "dir", logDir, "error", mkDirErr)
+			// 与结构化日志器共享同一个滚动文件写入器
+			writer, writerErr := logger.NewWriter(loggerConfig(&cfg.Log))
+			if writerErr != nil {
+				slog.Error("无法创建日志文件，使用控制台输出", "path", cfg.Log.File, "error", writerErr)
 			} else {
-				logFile, openErr := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-				if openErr != nil {
-					slog.Error("无法创建日志文件，使用控制台输出", "path", logFilePath, "error", openErr)
+				output = writer
+				if cfg.Log.Console {
+					slog.Info("日志将同时输出到控制台和文件", "file", cfg.Log.File)
 				} else {
-					if cfg.Log.Console {
-						output = io.MultiWriter(os.Stdout, logFile)
-						slog.Info("日志将同时输出到控制台和文件", "file", logFilePath)
-					} else {
-						output = logFile
-						slog.Info("日志将输出到文件", "file", logFilePath)
-					}
+					slog.Info("日志将输出到文件", "file", cfg.Log.File)
 				}
 			}
 		} else {
@@ -336,11 +779,42 @@ func setupLogger(configPath string) *slog.LevelVar {
 		}
 	}
 
-	logger := slog.New(slog.NewTextHandler(output, handlerOptions))
-	slog.SetDefault(logger)
+	// 配置加载失败时保持文本输出，格式无效时同样回退到文本输出
+	var handler slog.Handler = slog.NewTextHandler(output, handlerOptions)
+	if err == nil {
+		configured, formatErr := logger.NewHandler(output, cfg.Log.Format, handlerOptions)
+		if formatErr != nil {
+			slog.Warn("日志格式无效，使用文本输出", "format", cfg.Log.Format, "error", formatErr)
+		} else {
+			handler = configured
+		}
+		handler = logger.NewSamplingHandler(handler, loggerConfig(&cfg.Log).Sampling)
+	}
+
+	slog.SetDefault(slog.New(handler))
 	return programLevel
 }
 
+// loggerConfig 将应用日志配置转换为日志包配置
+func loggerConfig(c *config.LogConfig) *logger.LogConfig {
+	return &logger.LogConfig{
+		Level:      c.Level,
+		Format:     c.Format,
+		File:       c.File,
+		Console:    c.Console,
+		MaxSize:    c.MaxSize,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     c.MaxAge,
+		Compress:   c.Compress,
+		RedactKeys: c.RedactKeys,
+		Sampling: logger.SamplingConfig{
+			Initial:    c.Sampling.Initial,
+			Thereafter: c.Sampling.Thereafter,
+			Tick:       c.Sampling.Tick,
+		},
+	}
+}
+
 // 获取配置文件路径
 func getConfigPath() string {
 	configPath := os.Getenv("CONFIG_PATH")
@@ -352,19 +826,9 @@ func getConfigPath() string {
 
 // 设置日志级别
 func setLogLevel(level string, programLevel *slog.LevelVar) {
-	var l slog.Level
-	switch level {
-	case "debug":
-		l = slog.LevelDebug
-	case "info":
-		l = slog.LevelInfo
-	case "warn":
-		l = slog.LevelWarn
-	case "error":
-		l = slog.LevelError
-	default:
+	l, err := logger.ParseLevel(level)
+	if err != nil {
 		slog.Warn("未知的日志级别，将使用Info级别", "configured_level", level)
-		l = slog.LevelInfo
 	}
 	programLevel.Set(l)
 	slog.Info("日志级别设置为", "level", l.String())
@@ -3,12 +3,35 @@ package config
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// EnvVar 选择环境配置文件的环境变量，如 APP_ENV=staging 时合并 config.staging.yaml
+const EnvVar = "APP_ENV"
+
+// ConfigFiles 返回按合并顺序排列的配置文件：基础配置文件，以及 APP_ENV 指定环境的配置文件（存在时）
+// 环境配置文件与基础配置文件位于同一目录，文件名为基础文件名加环境名，如 configs/config.yaml 对应 configs/config.staging.yaml
+func ConfigFiles(path string) []string {
+	files := []string{path}
+
+	env := os.Getenv(EnvVar)
+	if env == "" {
+		return files
+	}
+
+	ext := filepath.Ext(path)
+	overlay := strings.TrimSuffix(path, ext) + "." + env + ext
+	if _, err := os.Stat(overlay); err == nil {
+		files = append(files, overlay)
+	}
+	return files
+}
+
 // 配置验证错误
 var (
 	ErrInvalidPort         = errors.New("invalid server port")
@@ -17,11 +40,27 @@ var (
 
 // AppConfig 顶层配置结构，匹配yaml文件中的app键
 type AppConfig struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Log      LogConfig      `mapstructure:"log"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	CORS      CORSConfig      `mapstructure:"cors"`
+	API       APIConfig       `mapstructure:"api"`
+	Log       LogConfig       `mapstructure:"log"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Users     UsersConfig     `mapstructure:"users"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	Templates TemplatesConfig `mapstructure:"templates"`
+	Events    EventsConfig    `mapstructure:"events"`
+	GraphQL   GraphQLConfig   `mapstructure:"graphql"`
+	GRPC      GRPCConfig      `mapstructure:"grpc"`
+	Seed      SeedConfig      `mapstructure:"seed"`
+
+	FeatureFlags FeatureFlagsConfig `mapstructure:"feature_flags"`
+	Debug        DebugConfig        `mapstructure:"debug"`
 }
 
 // Config 应用配置结构
@@ -35,6 +74,16 @@ type ServerConfig struct {
 	Timeout      time.Duration `mapstructure:"timeout" env:"SERVER_TIMEOUT"`
 	ReadTimeout  time.Duration `mapstructure:"read_timeout" env:"SERVER_READ_TIMEOUT"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+
+	// ShutdownDelay 开始关闭后就绪检查即返回503，等待该时间让负载均衡器摘除实例后再停止接受新请求；为0时立即关闭
+	// 等待时间计入 ShutdownTimeout，不能超过其一半，保证留有足够时间等待进行中的请求完成
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay" env:"SERVER_SHUTDOWN_DELAY"`
+	// ShutdownTimeout 优雅关闭的总超时，超时后不再等待未完成的请求和任务
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+
+	// TrustedProxies 可信代理的CIDR或IP，只有来自这些地址的 X-Forwarded-For/X-Real-IP 才用于确定客户端IP；
+	// 为空时不信任任何代理，客户端IP取连接的远端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
 }
 
 // DatabaseConfig 数据库配置
@@ -49,6 +98,31 @@ type DatabaseConfig struct {
 	MaxOpenConns    int           `mapstructure:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
 	MaxIdleConns    int           `mapstructure:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	SlowThreshold   time.Duration `mapstructure:"slow_threshold" env:"DB_SLOW_THRESHOLD"` // 超过该耗时的SQL以warn级别记录
+
+	// Replicas 只读副本，查询路由到副本，写操作和事务使用主库；
+	// 副本未配置的端口、用户名、密码、库名和SSL模式沿用主库配置
+	Replicas []DatabaseConfig `mapstructure:"replicas"`
+
+	// CircuitBreaker 数据库断路器，数据库不可用时快速失败，避免请求堆积占满连接池
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+
+	// TxRetry 事务因序列化失败或死锁回滚时重新执行整个事务的策略
+	TxRetry TxRetryConfig `mapstructure:"tx_retry"`
+}
+
+// TxRetryConfig 事务重试配置
+type TxRetryConfig struct {
+	MaxAttempts  int           `mapstructure:"max_attempts" env:"DB_TX_RETRY_MAX_ATTEMPTS"`   // 包括首次在内的最大执行次数，为1时不重试
+	InitialDelay time.Duration `mapstructure:"initial_delay" env:"DB_TX_RETRY_INITIAL_DELAY"` // 首次重试前的等待时间，之后按指数退避
+	MaxDelay     time.Duration `mapstructure:"max_delay" env:"DB_TX_RETRY_MAX_DELAY"`         // 最长等待时间
+}
+
+// CircuitBreakerConfig 断路器配置
+type CircuitBreakerConfig struct {
+	MaxFailures      int           `mapstructure:"max_failures" env:"DB_CIRCUIT_BREAKER_MAX_FAILURES"`             // 连续失败多少次后打开
+	ResetTimeout     time.Duration `mapstructure:"reset_timeout" env:"DB_CIRCUIT_BREAKER_RESET_TIMEOUT"`           // 打开后经过多久放行试探请求
+	HalfOpenRequests int           `mapstructure:"half_open_requests" env:"DB_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS"` // 半开状态允许的试探请求数
 }
 
 // RedisConfig Redis配置
@@ -59,11 +133,64 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db" env:"REDIS_DB"`
 }
 
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Warmup        bool          `mapstructure:"warmup" env:"CACHE_WARMUP"`                 // 启动时是否预热缓存
+	WarmupTimeout time.Duration `mapstructure:"warmup_timeout" env:"CACHE_WARMUP_TIMEOUT"` // 预热总超时
+}
+
+// CORSConfig 跨域配置，未配置的字段使用中间件默认值
+type CORSConfig struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"` // 允许的来源，"*" 表示任意来源
+	AllowedMethods   []string      `mapstructure:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `mapstructure:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `mapstructure:"max_age" env:"CORS_MAX_AGE"` // 预检结果缓存时间
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level   string `mapstructure:"level" env:"LOG_LEVEL"`
+	Format  string `mapstructure:"format" env:"LOG_FORMAT"` // 输出格式: json, text
 	File    string `mapstructure:"file" env:"LOG_FILE"`
 	Console bool   `mapstructure:"console" env:"LOG_CONSOLE"`
+
+	// AccessFormat 访问日志格式: structured（结构化日志）、combined（Combined Log Format，输出到标准输出）、both
+	AccessFormat string `mapstructure:"access_format" env:"LOG_ACCESS_FORMAT"`
+
+	// panic 堆栈的初始缓冲区大小和最大记录字节数，堆栈超出初始大小时按倍数扩大缓冲区直到最大值
+	StackSize    int `mapstructure:"stack_size" env:"LOG_STACK_SIZE"`
+	MaxStackSize int `mapstructure:"max_stack_size" env:"LOG_MAX_STACK_SIZE"`
+
+	// 日志文件滚动，超过MaxSize(MB)后备份，按数量和天数清理
+	MaxSize    int  `mapstructure:"max_size" env:"LOG_MAX_SIZE"`
+	MaxBackups int  `mapstructure:"max_backups" env:"LOG_MAX_BACKUPS"`
+	MaxAge     int  `mapstructure:"max_age" env:"LOG_MAX_AGE"` // 天
+	Compress   bool `mapstructure:"compress" env:"LOG_COMPRESS"`
+
+	// RedactKeys 额外需要脱敏的属性名和查询参数，password、authorization、token、secret 始终脱敏
+	RedactKeys []string `mapstructure:"redact_keys" env:"LOG_REDACT_KEYS"`
+
+	// Sampling 高频日志采样，只对 Info 及以下级别生效，thereafter为0时不采样
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
+
+	// Body 调试用的请求和响应体日志，认证接口始终不记录
+	Body LogBodyConfig `mapstructure:"body"`
+}
+
+// LogBodyConfig 请求和响应体日志配置
+type LogBodyConfig struct {
+	Enabled bool     `mapstructure:"enabled" env:"LOG_BODY_ENABLED"`   // 是否记录请求和响应体，生产环境不建议开启
+	Paths   []string `mapstructure:"paths" env:"LOG_BODY_PATHS"`       // 记录的路径前缀，为空时不记录
+	MaxSize int      `mapstructure:"max_size" env:"LOG_BODY_MAX_SIZE"` // 每个请求体或响应体最多记录的字节数
+}
+
+// LogSamplingConfig 日志采样配置，每个周期内相同消息先记录initial条，之后每thereafter条记录1条
+type LogSamplingConfig struct {
+	Initial    int           `mapstructure:"initial" env:"LOG_SAMPLING_INITIAL"`
+	Thereafter int           `mapstructure:"thereafter" env:"LOG_SAMPLING_THEREAFTER"`
+	Tick       time.Duration `mapstructure:"tick" env:"LOG_SAMPLING_TICK"`
 }
 
 // JWTConfig JWT配置
@@ -72,12 +199,197 @@ type JWTConfig struct {
 	AccessTokenExp  time.Duration `mapstructure:"access_token_exp" env:"JWT_ACCESS_TOKEN_EXP"`
 	RefreshTokenExp time.Duration `mapstructure:"refresh_token_exp" env:"JWT_REFRESH_TOKEN_EXP"`
 	Issuer          string        `mapstructure:"issuer" env:"JWT_ISSUER"`
+	Audience        string        `mapstructure:"audience" env:"JWT_AUDIENCE"` // 访问令牌的受众，为空时不写入也不校验
+	Leeway          time.Duration `mapstructure:"leeway" env:"JWT_LEEWAY"`     // 校验令牌时间声明时容忍的时钟偏差
+	Algorithm       string        `mapstructure:"algorithm" env:"JWT_ALGORITHM"`
+	PrivateKeyFile  string        `mapstructure:"private_key_file" env:"JWT_PRIVATE_KEY_FILE"`
+	PublicKeyFile   string        `mapstructure:"public_key_file" env:"JWT_PUBLIC_KEY_FILE"`
+	KeyID           string        `mapstructure:"key_id" env:"JWT_KEY_ID"`
+	// 轮换前的公钥文件，环境变量中以逗号分隔
+	PreviousPublicKeyFiles []string `mapstructure:"previous_public_key_files" env:"JWT_PREVIOUS_PUBLIC_KEY_FILES"`
+	// 各角色拥有的权限范围，写入访问令牌的scope声明，供 RequireScope 校验
+	RoleScopes map[string][]string `mapstructure:"role_scopes"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	LockoutThreshold int           `mapstructure:"lockout_threshold" env:"AUTH_LOCKOUT_THRESHOLD"` // 窗口期内允许的登录失败次数
+	LockoutWindow    time.Duration `mapstructure:"lockout_window" env:"AUTH_LOCKOUT_WINDOW"`       // 失败次数统计窗口
+	LockoutDuration  time.Duration `mapstructure:"lockout_duration" env:"AUTH_LOCKOUT_DURATION"`   // 达到阈值后的锁定时长
+
+	Password          PasswordConfig          `mapstructure:"password"`           // 密码强度策略
+	PasswordHash      PasswordHashConfig      `mapstructure:"password_hash"`      // 密码哈希算法
+	EmailVerification EmailVerificationConfig `mapstructure:"email_verification"` // 邮箱验证
+	PasswordReset     PasswordResetConfig     `mapstructure:"password_reset"`     // 忘记密码
+}
+
+// EmailVerificationConfig 邮箱验证配置，验证邮件通过消息队列投递，需要配置Redis
+type EmailVerificationConfig struct {
+	Enabled  bool          `mapstructure:"enabled" env:"AUTH_EMAIL_VERIFICATION_ENABLED"`     // 创建用户后发送验证邮件
+	Required bool          `mapstructure:"required" env:"AUTH_EMAIL_VERIFICATION_REQUIRED"`   // 邮箱未验证的用户不能登录
+	TokenExp time.Duration `mapstructure:"token_exp" env:"AUTH_EMAIL_VERIFICATION_TOKEN_EXP"` // 验证令牌有效期
+	URL      string        `mapstructure:"url" env:"AUTH_EMAIL_VERIFICATION_URL"`             // 验证链接地址，令牌作为token查询参数追加
+}
+
+// PasswordResetConfig 密码重置配置，重置邮件通过消息队列投递，需要配置Redis
+type PasswordResetConfig struct {
+	TokenExp time.Duration `mapstructure:"token_exp" env:"AUTH_PASSWORD_RESET_TOKEN_EXP"` // 重置令牌有效期
+	URL      string        `mapstructure:"url" env:"AUTH_PASSWORD_RESET_URL"`             // 重置页面地址，令牌作为token查询参数追加
+}
+
+// PasswordHashConfig 密码哈希算法配置
+// 切换算法只影响新设置的密码，已存储的哈希按前缀识别算法，仍可正常校验
+type PasswordHashConfig struct {
+	Algorithm         string `mapstructure:"algorithm" env:"AUTH_PASSWORD_HASH_ALGORITHM"`                   // bcrypt 或 argon2id
+	BcryptCost        int    `mapstructure:"bcrypt_cost" env:"AUTH_PASSWORD_HASH_BCRYPT_COST"`               // bcrypt计算成本（4-31）
+	Argon2Memory      uint32 `mapstructure:"argon2_memory" env:"AUTH_PASSWORD_HASH_ARGON2_MEMORY"`           // Argon2id内存开销（KiB）
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations" env:"AUTH_PASSWORD_HASH_ARGON2_ITERATIONS"`   // Argon2id迭代次数
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism" env:"AUTH_PASSWORD_HASH_ARGON2_PARALLELISM"` // Argon2id并行度
+}
+
+// PasswordConfig 密码强度策略配置
+type PasswordConfig struct {
+	MinLength        int      `mapstructure:"min_length" env:"AUTH_PASSWORD_MIN_LENGTH"`
+	RequireUppercase bool     `mapstructure:"require_uppercase" env:"AUTH_PASSWORD_REQUIRE_UPPERCASE"`
+	RequireLowercase bool     `mapstructure:"require_lowercase" env:"AUTH_PASSWORD_REQUIRE_LOWERCASE"`
+	RequireDigit     bool     `mapstructure:"require_digit" env:"AUTH_PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol    bool     `mapstructure:"require_symbol" env:"AUTH_PASSWORD_REQUIRE_SYMBOL"`
+	Denylist         []string `mapstructure:"denylist" env:"AUTH_PASSWORD_DENYLIST"` // 额外禁止的密码，内置常见密码列表始终生效
 }
 
-// LoadConfig 加载配置
+// UsersConfig 用户管理配置
+type UsersConfig struct {
+	BulkMaxSize        int      `mapstructure:"bulk_max_size" env:"USERS_BULK_MAX_SIZE"`               // 单次批量创建的最大用户数
+	AvatarMaxSize      int64    `mapstructure:"avatar_max_size" env:"USERS_AVATAR_MAX_SIZE"`           // 头像文件大小上限（字节）
+	AvatarAllowedTypes []string `mapstructure:"avatar_allowed_types" env:"USERS_AVATAR_ALLOWED_TYPES"` // 允许的头像内容类型，按文件内容识别
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Driver string             `mapstructure:"driver" env:"STORAGE_DRIVER"` // 存储驱动，local或s3
+	Local  LocalStorageConfig `mapstructure:"local"`
+	S3     S3StorageConfig    `mapstructure:"s3"`
+}
+
+// LocalStorageConfig 本地磁盘存储配置
+type LocalStorageConfig struct {
+	Dir        string `mapstructure:"dir" env:"STORAGE_LOCAL_DIR"`                 // 存储根目录
+	BaseURL    string `mapstructure:"base_url" env:"STORAGE_LOCAL_BASE_URL"`       // 访问地址前缀，以/开头时由本服务在该路径下提供文件访问
+	SigningKey string `mapstructure:"signing_key" env:"STORAGE_LOCAL_SIGNING_KEY"` // 签名地址的HMAC密钥，为空时不能生成签名地址；配置后文件只能通过签名地址访问
+}
+
+// S3StorageConfig S3兼容对象存储配置
+type S3StorageConfig struct {
+	Endpoint        string `mapstructure:"endpoint" env:"STORAGE_S3_ENDPOINT"`                   // 服务地址，如 https://s3.us-east-1.amazonaws.com
+	Region          string `mapstructure:"region" env:"STORAGE_S3_REGION"`                       // 区域
+	Bucket          string `mapstructure:"bucket" env:"STORAGE_S3_BUCKET"`                       // 存储桶
+	AccessKeyID     string `mapstructure:"access_key_id" env:"STORAGE_S3_ACCESS_KEY_ID"`         // 访问密钥ID
+	SecretAccessKey string `mapstructure:"secret_access_key" env:"STORAGE_S3_SECRET_ACCESS_KEY"` // 访问密钥
+	PathStyle       bool   `mapstructure:"path_style" env:"STORAGE_S3_PATH_STYLE"`               // 使用路径形式的对象地址，MinIO等需要开启
+	BaseURL         string `mapstructure:"base_url" env:"STORAGE_S3_BASE_URL"`                   // 公开访问地址前缀，如CDN地址，为空时使用对象地址
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	SMTP SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig SMTP服务器配置，未配置Host时不发送邮件，验证和密码重置邮件只会留在队列中
+type SMTPConfig struct {
+	Host     string        `mapstructure:"host" env:"MAIL_SMTP_HOST"`         // SMTP服务器地址
+	Port     int           `mapstructure:"port" env:"MAIL_SMTP_PORT"`         // 端口
+	Username string        `mapstructure:"username" env:"MAIL_SMTP_USERNAME"` // 用户名，为空时不认证
+	Password string        `mapstructure:"password" env:"MAIL_SMTP_PASSWORD"` // 密码
+	From     string        `mapstructure:"from" env:"MAIL_SMTP_FROM"`         // 发件人，如 "Go Rest Starter <noreply@example.com>"
+	TLS      string        `mapstructure:"tls" env:"MAIL_SMTP_TLS"`           // TLS模式，starttls、tls或none
+	Timeout  time.Duration `mapstructure:"timeout" env:"MAIL_SMTP_TIMEOUT"`   // 单封邮件的发送超时
+}
+
+// TemplatesConfig 邮件和页面模板配置
+type TemplatesConfig struct {
+	Dir    string `mapstructure:"dir" env:"TEMPLATES_DIR"`       // 模板目录，为空时使用随程序编译的内置模板（web/templates）
+	Reload bool   `mapstructure:"reload" env:"TEMPLATES_RELOAD"` // 每次渲染时重新读取模板文件，仅用于开发
+}
+
+// EventsConfig 实时事件推送（SSE和WebSocket）配置
+type EventsConfig struct {
+	Topics    []string        `mapstructure:"topics" env:"EVENTS_TOPICS"`       // 转发给SSE和WebSocket客户端的队列主题，为空时不开放订阅
+	Heartbeat time.Duration   `mapstructure:"heartbeat" env:"EVENTS_HEARTBEAT"` // SSE心跳间隔
+	WebSocket WebSocketConfig `mapstructure:"websocket"`
+}
+
+// WebSocketConfig WebSocket连接配置
+type WebSocketConfig struct {
+	PublishTopics  []string      `mapstructure:"publish_topics" env:"EVENTS_WEBSOCKET_PUBLISH_TOPICS"`     // 允许客户端发布消息的队列主题，为空时客户端只能接收
+	PingInterval   time.Duration `mapstructure:"ping_interval" env:"EVENTS_WEBSOCKET_PING_INTERVAL"`       // ping间隔，应小于PongWait
+	PongWait       time.Duration `mapstructure:"pong_wait" env:"EVENTS_WEBSOCKET_PONG_WAIT"`               // 等待客户端pong的最长时间
+	MaxMessageSize int64         `mapstructure:"max_message_size" env:"EVENTS_WEBSOCKET_MAX_MESSAGE_SIZE"` // 客户端消息大小上限（字节）
+}
+
+// APIConfig API版本协商配置
+type APIConfig struct {
+	Vendor         string `mapstructure:"vendor" env:"API_VENDOR"`                   // 厂商媒体类型中的名称，如 application/vnd.myapp.v2+json 中的 myapp
+	DefaultVersion string `mapstructure:"default_version" env:"API_DEFAULT_VERSION"` // Accept 请求头未指定版本时使用的版本
+}
+
+// GraphQLConfig GraphQL接口配置
+type GraphQLConfig struct {
+	Enabled        bool `mapstructure:"enabled" env:"GRAPHQL_ENABLED"`                   // 是否开放 /api/v1/graphql 接口
+	MaxDepth       int  `mapstructure:"max_depth" env:"GRAPHQL_MAX_DEPTH"`               // 查询的最大嵌套深度
+	MaxQueryLength int  `mapstructure:"max_query_length" env:"GRAPHQL_MAX_QUERY_LENGTH"` // 查询语句的最大长度（字节）
+	Introspection  bool `mapstructure:"introspection" env:"GRAPHQL_INTROSPECTION"`       // 是否允许内省查询，生产环境建议关闭
+}
+
+// GRPCConfig gRPC服务器配置
+type GRPCConfig struct {
+	Enabled bool `mapstructure:"enabled" env:"GRPC_ENABLED"` // 是否与HTTP服务器一起启动gRPC服务器
+	Port    int  `mapstructure:"port" env:"GRPC_PORT"`       // 监听端口
+}
+
+// FeatureFlagsConfig 功能开关配置，配置文件变更时自动重新加载 Flags
+type FeatureFlagsConfig struct {
+	Redis bool                         `mapstructure:"redis" env:"FEATURE_FLAGS_REDIS"` // 是否从Redis哈希 feature_flags 读取开关，Redis中的开关覆盖配置文件中的同名开关
+	Flags map[string]FeatureFlagConfig `mapstructure:"flags"`                           // 默认开关，键为开关名
+}
+
+// FeatureFlagConfig 单个功能开关的配置
+type FeatureFlagConfig struct {
+	Enabled    bool     `mapstructure:"enabled"`    // 是否开启
+	Percentage *int     `mapstructure:"percentage"` // 灰度发布的用户百分比（0-100），未设置时对所有用户开启，0表示不对任何用户开启
+	Users      []string `mapstructure:"users"`      // 始终开启的用户ID
+}
+
+// DebugConfig 调试接口配置
+type DebugConfig struct {
+	Pprof bool `mapstructure:"pprof" env:"DEBUG_PPROF"` // 是否在 /debug/pprof 开放性能分析接口，需管理员令牌
+}
+
+// SeedConfig 初始化数据配置，供 seed 子命令使用
+type SeedConfig struct {
+	Admin SeedAdminConfig `mapstructure:"admin"`
+}
+
+// SeedAdminConfig 默认管理员账号，密码必须显式配置
+type SeedAdminConfig struct {
+	Name     string `mapstructure:"name" env:"SEED_ADMIN_NAME"`
+	Email    string `mapstructure:"email" env:"SEED_ADMIN_EMAIL"`
+	Password string `mapstructure:"password" env:"SEED_ADMIN_PASSWORD"`
+}
+
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled" env:"TRACING_ENABLED"`           // 是否导出OpenTelemetry追踪数据
+	ServiceName string  `mapstructure:"service_name" env:"TRACING_SERVICE_NAME"` // 上报的服务名称
+	Endpoint    string  `mapstructure:"endpoint" env:"TRACING_ENDPOINT"`         // OTLP HTTP接收端地址，如 localhost:4318
+	Insecure    bool    `mapstructure:"insecure" env:"TRACING_INSECURE"`         // 是否使用明文HTTP连接接收端
+	SampleRatio float64 `mapstructure:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // 采样比例（0-1）
+}
+
+// LoadConfig 加载配置，优先级从低到高为基础配置文件、环境配置文件（见 ConfigFiles）和环境变量
 func LoadConfig(path string) (*AppConfig, error) {
 	// 初始化 viper
-	viper.SetConfigFile(path)
+	files := ConfigFiles(path)
+	viper.SetConfigFile(files[0])
 
 	// 设置环境变量前缀和分隔符
 	viper.SetEnvPrefix("APP")
@@ -89,10 +401,16 @@ func LoadConfig(path string) (*AppConfig, error) {
 	// 启用环境变量支持
 	viper.AutomaticEnv()
 
-	// 先读取配置文件
+	// 先读取基础配置文件，再依次合并环境配置文件，环境变量的优先级最高
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
+	for _, overlay := range files[1:] {
+		viper.SetConfigFile(overlay)
+		if err := viper.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("合并环境配置文件失败: %w", err)
+		}
+	}
 
 	// 解析配置到结构体
 	var config Config
@@ -100,9 +418,19 @@ func LoadConfig(path string) (*AppConfig, error) {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
 
+	// 解析密钥引用，如 ${env:JWT_SECRET} 和 file:/run/secrets/db_pass
+	if err := resolveSecrets(&config.App); err != nil {
+		return nil, fmt.Errorf("解析密钥引用失败: %w", err)
+	}
+
 	// 设置默认值
 	setDefaults(&config.App)
 
+	// 校验配置，启动前报告所有无效的配置项
+	if err := config.App.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &config.App, nil
 }
 
@@ -113,6 +441,9 @@ func bindEnvVariables() {
 	viper.BindEnv("app.server.timeout", "APP_SERVER_TIMEOUT")
 	viper.BindEnv("app.server.read_timeout", "APP_SERVER_READ_TIMEOUT")
 	viper.BindEnv("app.server.write_timeout", "APP_SERVER_WRITE_TIMEOUT")
+	viper.BindEnv("app.server.trusted_proxies", "APP_SERVER_TRUSTED_PROXIES")
+	viper.BindEnv("app.server.shutdown_delay", "APP_SERVER_SHUTDOWN_DELAY")
+	viper.BindEnv("app.server.shutdown_timeout", "APP_SERVER_SHUTDOWN_TIMEOUT")
 
 	// 数据库配置环境变量
 	viper.BindEnv("app.database.driver", "APP_DB_DRIVER")
@@ -125,6 +456,13 @@ func bindEnvVariables() {
 	viper.BindEnv("app.database.max_open_conns", "APP_DB_MAX_OPEN_CONNS")
 	viper.BindEnv("app.database.max_idle_conns", "APP_DB_MAX_IDLE_CONNS")
 	viper.BindEnv("app.database.conn_max_lifetime", "APP_DB_CONN_MAX_LIFETIME")
+	viper.BindEnv("app.database.slow_threshold", "APP_DB_SLOW_THRESHOLD")
+	viper.BindEnv("app.database.circuit_breaker.max_failures", "APP_DB_CIRCUIT_BREAKER_MAX_FAILURES")
+	viper.BindEnv("app.database.circuit_breaker.reset_timeout", "APP_DB_CIRCUIT_BREAKER_RESET_TIMEOUT")
+	viper.BindEnv("app.database.circuit_breaker.half_open_requests", "APP_DB_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS")
+	viper.BindEnv("app.database.tx_retry.max_attempts", "APP_DB_TX_RETRY_MAX_ATTEMPTS")
+	viper.BindEnv("app.database.tx_retry.initial_delay", "APP_DB_TX_RETRY_INITIAL_DELAY")
+	viper.BindEnv("app.database.tx_retry.max_delay", "APP_DB_TX_RETRY_MAX_DELAY")
 
 	// Redis配置环境变量
 	viper.BindEnv("app.redis.host", "APP_REDIS_HOST")
@@ -132,8 +470,42 @@ func bindEnvVariables() {
 	viper.BindEnv("app.redis.password", "APP_REDIS_PASSWORD")
 	viper.BindEnv("app.redis.db", "APP_REDIS_DB")
 
+	// 缓存配置环境变量
+	viper.BindEnv("app.cache.warmup", "APP_CACHE_WARMUP")
+	viper.BindEnv("app.cache.warmup_timeout", "APP_CACHE_WARMUP_TIMEOUT")
+
+	// 跨域配置环境变量，列表以逗号分隔
+	viper.BindEnv("app.cors.allowed_origins", "APP_CORS_ALLOWED_ORIGINS")
+	viper.BindEnv("app.cors.allowed_methods", "APP_CORS_ALLOWED_METHODS")
+	viper.BindEnv("app.cors.allowed_headers", "APP_CORS_ALLOWED_HEADERS")
+	viper.BindEnv("app.cors.exposed_headers", "APP_CORS_EXPOSED_HEADERS")
+	viper.BindEnv("app.cors.allow_credentials", "APP_CORS_ALLOW_CREDENTIALS")
+	viper.BindEnv("app.cors.max_age", "APP_CORS_MAX_AGE")
+
+	// 链路追踪配置环境变量
+	viper.BindEnv("app.tracing.enabled", "APP_TRACING_ENABLED")
+	viper.BindEnv("app.tracing.service_name", "APP_TRACING_SERVICE_NAME")
+	viper.BindEnv("app.tracing.endpoint", "APP_TRACING_ENDPOINT")
+	viper.BindEnv("app.tracing.insecure", "APP_TRACING_INSECURE")
+	viper.BindEnv("app.tracing.sample_ratio", "APP_TRACING_SAMPLE_RATIO")
+
 	// 日志配置环境变量
 	viper.BindEnv("app.log.level", "APP_LOG_LEVEL")
+	viper.BindEnv("app.log.format", "APP_LOG_FORMAT")
+	viper.BindEnv("app.log.access_format", "APP_LOG_ACCESS_FORMAT")
+	viper.BindEnv("app.log.stack_size", "APP_LOG_STACK_SIZE")
+	viper.BindEnv("app.log.max_stack_size", "APP_LOG_MAX_STACK_SIZE")
+	viper.BindEnv("app.log.max_size", "APP_LOG_MAX_SIZE")
+	viper.BindEnv("app.log.max_backups", "APP_LOG_MAX_BACKUPS")
+	viper.BindEnv("app.log.max_age", "APP_LOG_MAX_AGE")
+	viper.BindEnv("app.log.compress", "APP_LOG_COMPRESS")
+	viper.BindEnv("app.log.redact_keys", "APP_LOG_REDACT_KEYS")
+	viper.BindEnv("app.log.sampling.initial", "APP_LOG_SAMPLING_INITIAL")
+	viper.BindEnv("app.log.sampling.thereafter", "APP_LOG_SAMPLING_THEREAFTER")
+	viper.BindEnv("app.log.sampling.tick", "APP_LOG_SAMPLING_TICK")
+	viper.BindEnv("app.log.body.enabled", "APP_LOG_BODY_ENABLED")
+	viper.BindEnv("app.log.body.paths", "APP_LOG_BODY_PATHS")
+	viper.BindEnv("app.log.body.max_size", "APP_LOG_BODY_MAX_SIZE")
 	viper.BindEnv("app.log.file", "APP_LOG_FILE")
 	viper.BindEnv("app.log.console", "APP_LOG_CONSOLE")
 
@@ -142,6 +514,99 @@ func bindEnvVariables() {
 	viper.BindEnv("app.jwt.access_token_exp", "APP_JWT_ACCESS_TOKEN_EXP")
 	viper.BindEnv("app.jwt.refresh_token_exp", "APP_JWT_REFRESH_TOKEN_EXP")
 	viper.BindEnv("app.jwt.issuer", "APP_JWT_ISSUER")
+	viper.BindEnv("app.jwt.audience", "APP_JWT_AUDIENCE")
+	viper.BindEnv("app.jwt.leeway", "APP_JWT_LEEWAY")
+	viper.BindEnv("app.jwt.algorithm", "APP_JWT_ALGORITHM")
+	viper.BindEnv("app.jwt.private_key_file", "APP_JWT_PRIVATE_KEY_FILE")
+	viper.BindEnv("app.jwt.public_key_file", "APP_JWT_PUBLIC_KEY_FILE")
+	viper.BindEnv("app.jwt.key_id", "APP_JWT_KEY_ID")
+	viper.BindEnv("app.jwt.previous_public_key_files", "APP_JWT_PREVIOUS_PUBLIC_KEY_FILES")
+
+	// 认证配置环境变量
+	viper.BindEnv("app.auth.lockout_threshold", "APP_AUTH_LOCKOUT_THRESHOLD")
+	viper.BindEnv("app.auth.lockout_window", "APP_AUTH_LOCKOUT_WINDOW")
+	viper.BindEnv("app.auth.lockout_duration", "APP_AUTH_LOCKOUT_DURATION")
+	viper.BindEnv("app.auth.password.min_length", "APP_AUTH_PASSWORD_MIN_LENGTH")
+	viper.BindEnv("app.auth.password.require_uppercase", "APP_AUTH_PASSWORD_REQUIRE_UPPERCASE")
+	viper.BindEnv("app.auth.password.require_lowercase", "APP_AUTH_PASSWORD_REQUIRE_LOWERCASE")
+	viper.BindEnv("app.auth.password.require_digit", "APP_AUTH_PASSWORD_REQUIRE_DIGIT")
+	viper.BindEnv("app.auth.password.require_symbol", "APP_AUTH_PASSWORD_REQUIRE_SYMBOL")
+	viper.BindEnv("app.auth.password.denylist", "APP_AUTH_PASSWORD_DENYLIST")
+	viper.BindEnv("app.auth.password_hash.algorithm", "APP_AUTH_PASSWORD_HASH_ALGORITHM")
+	viper.BindEnv("app.auth.password_hash.bcrypt_cost", "APP_AUTH_PASSWORD_HASH_BCRYPT_COST")
+	viper.BindEnv("app.auth.password_hash.argon2_memory", "APP_AUTH_PASSWORD_HASH_ARGON2_MEMORY")
+	viper.BindEnv("app.auth.password_hash.argon2_iterations", "APP_AUTH_PASSWORD_HASH_ARGON2_ITERATIONS")
+	viper.BindEnv("app.auth.password_hash.argon2_parallelism", "APP_AUTH_PASSWORD_HASH_ARGON2_PARALLELISM")
+	viper.BindEnv("app.auth.email_verification.enabled", "APP_AUTH_EMAIL_VERIFICATION_ENABLED")
+	viper.BindEnv("app.auth.email_verification.required", "APP_AUTH_EMAIL_VERIFICATION_REQUIRED")
+	viper.BindEnv("app.auth.email_verification.token_exp", "APP_AUTH_EMAIL_VERIFICATION_TOKEN_EXP")
+	viper.BindEnv("app.auth.email_verification.url", "APP_AUTH_EMAIL_VERIFICATION_URL")
+	viper.BindEnv("app.auth.password_reset.token_exp", "APP_AUTH_PASSWORD_RESET_TOKEN_EXP")
+	viper.BindEnv("app.auth.password_reset.url", "APP_AUTH_PASSWORD_RESET_URL")
+
+	// 用户管理配置环境变量
+	viper.BindEnv("app.users.bulk_max_size", "APP_USERS_BULK_MAX_SIZE")
+	viper.BindEnv("app.users.avatar_max_size", "APP_USERS_AVATAR_MAX_SIZE")
+	viper.BindEnv("app.users.avatar_allowed_types", "APP_USERS_AVATAR_ALLOWED_TYPES")
+
+	// 文件存储配置环境变量
+	viper.BindEnv("app.storage.driver", "APP_STORAGE_DRIVER")
+	viper.BindEnv("app.storage.local.dir", "APP_STORAGE_LOCAL_DIR")
+	viper.BindEnv("app.storage.local.base_url", "APP_STORAGE_LOCAL_BASE_URL")
+	viper.BindEnv("app.storage.local.signing_key", "APP_STORAGE_LOCAL_SIGNING_KEY")
+	viper.BindEnv("app.storage.s3.endpoint", "APP_STORAGE_S3_ENDPOINT")
+	viper.BindEnv("app.storage.s3.region", "APP_STORAGE_S3_REGION")
+	viper.BindEnv("app.storage.s3.bucket", "APP_STORAGE_S3_BUCKET")
+	viper.BindEnv("app.storage.s3.access_key_id", "APP_STORAGE_S3_ACCESS_KEY_ID")
+	viper.BindEnv("app.storage.s3.secret_access_key", "APP_STORAGE_S3_SECRET_ACCESS_KEY")
+	viper.BindEnv("app.storage.s3.path_style", "APP_STORAGE_S3_PATH_STYLE")
+	viper.BindEnv("app.storage.s3.base_url", "APP_STORAGE_S3_BASE_URL")
+
+	// 邮件发送配置环境变量
+	viper.BindEnv("app.mail.smtp.host", "APP_MAIL_SMTP_HOST")
+	viper.BindEnv("app.mail.smtp.port", "APP_MAIL_SMTP_PORT")
+	viper.BindEnv("app.mail.smtp.username", "APP_MAIL_SMTP_USERNAME")
+	viper.BindEnv("app.mail.smtp.password", "APP_MAIL_SMTP_PASSWORD")
+	viper.BindEnv("app.mail.smtp.from", "APP_MAIL_SMTP_FROM")
+	viper.BindEnv("app.mail.smtp.tls", "APP_MAIL_SMTP_TLS")
+	viper.BindEnv("app.mail.smtp.timeout", "APP_MAIL_SMTP_TIMEOUT")
+
+	// 模板配置环境变量
+	viper.BindEnv("app.templates.dir", "APP_TEMPLATES_DIR")
+	viper.BindEnv("app.templates.reload", "APP_TEMPLATES_RELOAD")
+
+	// 实时事件推送配置环境变量
+	viper.BindEnv("app.events.topics", "APP_EVENTS_TOPICS")
+	viper.BindEnv("app.events.heartbeat", "APP_EVENTS_HEARTBEAT")
+	viper.BindEnv("app.events.websocket.publish_topics", "APP_EVENTS_WEBSOCKET_PUBLISH_TOPICS")
+	viper.BindEnv("app.events.websocket.ping_interval", "APP_EVENTS_WEBSOCKET_PING_INTERVAL")
+	viper.BindEnv("app.events.websocket.pong_wait", "APP_EVENTS_WEBSOCKET_PONG_WAIT")
+	viper.BindEnv("app.events.websocket.max_message_size", "APP_EVENTS_WEBSOCKET_MAX_MESSAGE_SIZE")
+
+	// API版本协商配置环境变量
+	viper.BindEnv("app.api.vendor", "APP_API_VENDOR")
+	viper.BindEnv("app.api.default_version", "APP_API_DEFAULT_VERSION")
+
+	// GraphQL接口配置环境变量
+	viper.BindEnv("app.graphql.enabled", "APP_GRAPHQL_ENABLED")
+	viper.BindEnv("app.graphql.max_depth", "APP_GRAPHQL_MAX_DEPTH")
+	viper.BindEnv("app.graphql.max_query_length", "APP_GRAPHQL_MAX_QUERY_LENGTH")
+	viper.BindEnv("app.graphql.introspection", "APP_GRAPHQL_INTROSPECTION")
+
+	// 功能开关配置环境变量
+	viper.BindEnv("app.feature_flags.redis", "APP_FEATURE_FLAGS_REDIS")
+
+	// 调试接口配置环境变量
+	viper.BindEnv("app.debug.pprof", "APP_DEBUG_PPROF")
+
+	// gRPC服务器配置环境变量
+	viper.BindEnv("app.grpc.enabled", "APP_GRPC_ENABLED")
+	viper.BindEnv("app.grpc.port", "APP_GRPC_PORT")
+
+	// 初始化数据配置环境变量
+	viper.BindEnv("app.seed.admin.name", "APP_SEED_ADMIN_NAME")
+	viper.BindEnv("app.seed.admin.email", "APP_SEED_ADMIN_EMAIL")
+	viper.BindEnv("app.seed.admin.password", "APP_SEED_ADMIN_PASSWORD")
 }
 
 // 设置默认值
@@ -159,6 +624,9 @@ func setDefaults(config *AppConfig) {
 	if config.Server.WriteTimeout == 0 {
 		config.Server.WriteTimeout = 15 * time.Second
 	}
+	if config.Server.ShutdownTimeout == 0 {
+		config.Server.ShutdownTimeout = 30 * time.Second
+	}
 
 	// 数据库连接池默认值
 	if config.Database.MaxOpenConns == 0 {
@@ -170,6 +638,79 @@ func setDefaults(config *AppConfig) {
 	if config.Database.ConnMaxLifetime == 0 {
 		config.Database.ConnMaxLifetime = 1 * time.Hour
 	}
+	if config.Database.SlowThreshold == 0 {
+		config.Database.SlowThreshold = 200 * time.Millisecond
+	}
+
+	// 事务重试默认值
+	if config.Database.TxRetry.MaxAttempts == 0 {
+		config.Database.TxRetry.MaxAttempts = 3
+	}
+	if config.Database.TxRetry.InitialDelay == 0 {
+		config.Database.TxRetry.InitialDelay = 20 * time.Millisecond
+	}
+	if config.Database.TxRetry.MaxDelay == 0 {
+		config.Database.TxRetry.MaxDelay = 500 * time.Millisecond
+	}
+
+	// 数据库断路器默认值
+	if config.Database.CircuitBreaker.MaxFailures == 0 {
+		config.Database.CircuitBreaker.MaxFailures = 5
+	}
+	if config.Database.CircuitBreaker.ResetTimeout == 0 {
+		config.Database.CircuitBreaker.ResetTimeout = 30 * time.Second
+	}
+	if config.Database.CircuitBreaker.HalfOpenRequests == 0 {
+		config.Database.CircuitBreaker.HalfOpenRequests = 1
+	}
+
+	// 缓存默认值
+	if config.Cache.WarmupTimeout == 0 {
+		config.Cache.WarmupTimeout = 30 * time.Second
+	}
+
+	// 日志默认值，采样未配置时不启用
+	if config.Log.Format == "" {
+		config.Log.Format = "json"
+	}
+	if config.Log.AccessFormat == "" {
+		config.Log.AccessFormat = "structured"
+	}
+	if config.Log.StackSize == 0 {
+		config.Log.StackSize = 4 << 10
+	}
+	if config.Log.MaxStackSize == 0 {
+		config.Log.MaxStackSize = 64 << 10
+	}
+	if config.Log.MaxSize == 0 {
+		config.Log.MaxSize = 100
+	}
+	if config.Log.MaxBackups == 0 {
+		config.Log.MaxBackups = 10
+	}
+	if config.Log.MaxAge == 0 {
+		config.Log.MaxAge = 30
+	}
+	if config.Log.Sampling.Initial == 0 {
+		config.Log.Sampling.Initial = 100
+	}
+	if config.Log.Sampling.Tick == 0 {
+		config.Log.Sampling.Tick = time.Second
+	}
+	if config.Log.Body.MaxSize == 0 {
+		config.Log.Body.MaxSize = 4 << 10
+	}
+
+	// 链路追踪默认值
+	if config.Tracing.ServiceName == "" {
+		config.Tracing.ServiceName = "go-rest-starter"
+	}
+	if config.Tracing.Endpoint == "" {
+		config.Tracing.Endpoint = "localhost:4318"
+	}
+	if config.Tracing.SampleRatio == 0 {
+		config.Tracing.SampleRatio = 1
+	}
 
 	// JWT默认值
 	if config.JWT.AccessTokenExp == 0 {
@@ -181,6 +722,118 @@ func setDefaults(config *AppConfig) {
 	if config.JWT.Issuer == "" {
 		config.JWT.Issuer = "go-rest-starter"
 	}
+	if config.JWT.Algorithm == "" {
+		config.JWT.Algorithm = "HS256"
+	}
+
+	// 登录锁定默认值
+	if config.Auth.LockoutThreshold == 0 {
+		config.Auth.LockoutThreshold = 5
+	}
+	if config.Auth.LockoutWindow == 0 {
+		config.Auth.LockoutWindow = 15 * time.Minute
+	}
+	if config.Auth.LockoutDuration == 0 {
+		config.Auth.LockoutDuration = 15 * time.Minute
+	}
+
+	// 密码策略默认值，字符类别要求未配置时不启用
+	if config.Auth.Password.MinLength == 0 {
+		config.Auth.Password.MinLength = 8
+	}
+
+	// 密码哈希默认值，Argon2id参数未配置时使用 utils.DefaultArgon2idParams
+	if config.Auth.PasswordHash.Algorithm == "" {
+		config.Auth.PasswordHash.Algorithm = "bcrypt"
+	}
+	if config.Auth.PasswordHash.BcryptCost == 0 {
+		config.Auth.PasswordHash.BcryptCost = 10
+	}
+
+	// 邮箱验证令牌有效期默认值
+	if config.Auth.EmailVerification.TokenExp == 0 {
+		config.Auth.EmailVerification.TokenExp = 24 * time.Hour
+	}
+
+	// 密码重置令牌有效期默认值
+	if config.Auth.PasswordReset.TokenExp == 0 {
+		config.Auth.PasswordReset.TokenExp = 30 * time.Minute
+	}
+
+	// 批量创建用户上限默认值
+	if config.Users.BulkMaxSize == 0 {
+		config.Users.BulkMaxSize = 100
+	}
+
+	// 头像上传默认值，允许的类型为空时使用处理器的默认列表
+	if config.Users.AvatarMaxSize == 0 {
+		config.Users.AvatarMaxSize = 2 << 20
+	}
+
+	// 文件存储默认值
+	if config.Storage.Driver == "" {
+		config.Storage.Driver = "local"
+	}
+	if config.Storage.Local.Dir == "" {
+		config.Storage.Local.Dir = "uploads"
+	}
+	if config.Storage.Local.BaseURL == "" {
+		config.Storage.Local.BaseURL = "/uploads"
+	}
+
+	// SMTP默认值
+	if config.Mail.SMTP.Port == 0 {
+		config.Mail.SMTP.Port = 587
+	}
+	if config.Mail.SMTP.TLS == "" {
+		config.Mail.SMTP.TLS = "starttls"
+	}
+	if config.Mail.SMTP.Timeout == 0 {
+		config.Mail.SMTP.Timeout = 10 * time.Second
+	}
+
+	// 实时事件心跳和WebSocket默认值
+	if config.Events.Heartbeat == 0 {
+		config.Events.Heartbeat = 15 * time.Second
+	}
+	if config.Events.WebSocket.PingInterval == 0 {
+		config.Events.WebSocket.PingInterval = 30 * time.Second
+	}
+	if config.Events.WebSocket.PongWait == 0 {
+		config.Events.WebSocket.PongWait = 60 * time.Second
+	}
+	if config.Events.WebSocket.MaxMessageSize == 0 {
+		config.Events.WebSocket.MaxMessageSize = 64 << 10
+	}
+
+	// API版本协商默认值
+	if config.API.Vendor == "" {
+		config.API.Vendor = "myapp"
+	}
+	if config.API.DefaultVersion == "" {
+		config.API.DefaultVersion = "v1"
+	}
+
+	// GraphQL查询限制默认值
+	if config.GraphQL.MaxDepth == 0 {
+		config.GraphQL.MaxDepth = 10
+	}
+	if config.GraphQL.MaxQueryLength == 0 {
+		config.GraphQL.MaxQueryLength = 10 << 10
+	}
+
+	// gRPC服务器端口默认值
+	if config.GRPC.Port == 0 {
+		config.GRPC.Port = 9090
+	}
+
+	// 默认管理员默认值，密码不提供默认值
+	if config.Seed.Admin.Name == "" {
+		config.Seed.Admin.Name = "Administrator"
+	}
+	if config.Seed.Admin.Email == "" {
+		config.Seed.Admin.Email = "admin@example.com"
+	}
 }
 
 // GetDSN 获取数据库连接字符串
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLayeredConfigs 写入基础配置文件和 staging 环境配置文件，返回基础配置文件路径
+func writeLayeredConfigs(t *testing.T) string {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(base, []byte(`app:
+  server:
+    port: 7001
+    timeout: 10s
+  database:
+    host: localhost
+    port: 5432
+    username: postgres
+    dbname: myapp
+    sslmode: disable
+  log:
+    level: debug
+  jwt:
+    secret: base-secret
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.staging.yaml"), []byte(`app:
+  database:
+    host: staging-db
+    sslmode: require
+  log:
+    level: info
+`), 0o600))
+	return base
+}
+
+func TestConfigFiles(t *testing.T) {
+	base := writeLayeredConfigs(t)
+
+	t.Setenv(EnvVar, "")
+	assert.Equal(t, []string{base}, ConfigFiles(base))
+
+	t.Setenv(EnvVar, "staging")
+	assert.Equal(t, []string{base, filepath.Join(filepath.Dir(base), "config.staging.yaml")}, ConfigFiles(base))
+
+	// 环境配置文件不存在时只使用基础配置文件
+	t.Setenv(EnvVar, "production")
+	assert.Equal(t, []string{base}, ConfigFiles(base))
+}
+
+func TestLoadConfig_Layering(t *testing.T) {
+	base := writeLayeredConfigs(t)
+
+	// 未指定环境时只读取基础配置
+	t.Setenv(EnvVar, "")
+	cfg, err := LoadConfig(base)
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, "debug", cfg.Log.Level)
+
+	// 环境配置覆盖同名配置项，未覆盖的配置项沿用基础配置
+	t.Setenv(EnvVar, "staging")
+	cfg, err = LoadConfig(base)
+	require.NoError(t, err)
+	assert.Equal(t, "staging-db", cfg.Database.Host)
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+	assert.Equal(t, "info", cfg.Log.Level)
+	assert.Equal(t, 5432, cfg.Database.Port)
+	assert.Equal(t, 10*time.Second, cfg.Server.Timeout)
+	assert.Equal(t, "base-secret", cfg.JWT.Secret)
+
+	// 环境变量的优先级最高
+	t.Setenv("APP_DB_HOST", "env-db")
+	t.Setenv("APP_LOG_LEVEL", "warn")
+	cfg, err = LoadConfig(base)
+	require.NoError(t, err)
+	assert.Equal(t, "env-db", cfg.Database.Host)
+	assert.Equal(t, "warn", cfg.Log.Level)
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// 密钥引用的形式，配置值整体为引用时才解析
+const (
+	envRefPrefix  = "${env:" // ${env:JWT_SECRET} 读取环境变量
+	envRefSuffix  = "}"
+	fileRefPrefix = "file:" // file:/run/secrets/db_pass 读取文件内容，去掉末尾换行
+)
+
+// resolveSecrets 将配置中的密钥引用替换为环境变量或文件中的值，避免在配置文件中保存明文密钥
+// 遍历所有字符串字段，包括嵌套结构体和切片中的字符串；映射中的值不解析
+// 引用的环境变量未设置或文件无法读取时返回错误，错误中包含配置项的键路径
+func resolveSecrets(cfg *AppConfig) error {
+	var errs []error
+	resolveValue(reflect.ValueOf(cfg).Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+// resolveValue 递归解析结构体、切片和字符串中的密钥引用
+func resolveValue(v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			resolveValue(v.Field(i), name, errs)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.String:
+		resolved, err := resolveSecret(v.String())
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		v.SetString(resolved)
+	}
+}
+
+// resolveSecret 解析单个配置值，不是引用时原样返回
+func resolveSecret(value string) (string, error) {
+	if name, ok := strings.CutPrefix(value, envRefPrefix); ok && strings.HasSuffix(name, envRefSuffix) {
+		name = strings.TrimSuffix(name, envRefSuffix)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("引用的环境变量 %s 未设置", name)
+		}
+		return secret, nil
+	}
+
+	if path, ok := strings.CutPrefix(value, fileRefPrefix); ok {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取引用的密钥文件失败: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	return value, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "db_pass")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("s3cret-db\n"), 0o600))
+	t.Setenv("TEST_JWT_SECRET", "s3cret-jwt")
+	t.Setenv("TEST_REPLICA_PASSWORD", "s3cret-replica")
+
+	cfg := &AppConfig{}
+	cfg.JWT.Secret = "${env:TEST_JWT_SECRET}"
+	cfg.Database.Password = "file:" + passwordFile
+	cfg.Database.Replicas = []DatabaseConfig{{Host: "replica", Password: "${env:TEST_REPLICA_PASSWORD}"}}
+	cfg.Database.Host = "localhost"
+
+	require.NoError(t, resolveSecrets(cfg))
+	assert.Equal(t, "s3cret-jwt", cfg.JWT.Secret)
+	assert.Equal(t, "s3cret-db", cfg.Database.Password)
+	assert.Equal(t, "s3cret-replica", cfg.Database.Replicas[0].Password)
+	// 不是引用的值保持不变
+	assert.Equal(t, "localhost", cfg.Database.Host)
+}
+
+func TestResolveSecrets_MissingReference(t *testing.T) {
+	cfg := &AppConfig{}
+	cfg.JWT.Secret = "${env:TEST_UNSET_SECRET}"
+	cfg.Redis.Password = "file:" + filepath.Join(t.TempDir(), "missing")
+
+	err := resolveSecrets(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jwt.secret: 引用的环境变量 TEST_UNSET_SECRET 未设置")
+	assert.Contains(t, err.Error(), "redis.password: 读取引用的密钥文件失败")
+}
+
+func TestLoadConfig_ResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "db_pass")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from-file"), 0o600))
+	t.Setenv("TEST_JWT_SECRET", "from-env")
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`app:
+  database:
+    host: localhost
+    port: 5432
+    username: postgres
+    password: file:`+passwordFile+`
+    dbname: myapp
+    sslmode: disable
+  jwt:
+    secret: ${env:TEST_JWT_SECRET}
+`), 0o600))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", cfg.Database.Password)
+	assert.Equal(t, "from-env", cfg.JWT.Secret)
+}
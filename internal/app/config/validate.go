@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// 各配置项的可选值
+var (
+	validSSLModes       = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	validLogLevels      = []string{"debug", "info", "warn", "error"}
+	validLogFormats     = []string{"json", "text"}
+	validAccessFormats  = []string{"structured", "combined", "both"}
+	validJWTAlgorithms  = []string{"HS256", "RS256"}
+	validHashAlgorithms = []string{"bcrypt", "argon2id"}
+	validStorageDrivers = []string{"local", "s3"}
+	validSMTPTLSModes   = []string{"starttls", "tls", "none"}
+)
+
+// FieldError 单个配置项的校验错误，Field为配置文件中的键路径，如 database.host
+type FieldError struct {
+	Field   string
+	Message string
+	Err     error // 对应的哨兵错误（如 ErrInvalidPort），可为空
+}
+
+// Error 实现 error 接口
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Unwrap 返回对应的哨兵错误
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError 配置校验错误，包含所有无效的配置项，便于一次修正
+type ValidationError struct {
+	Fields []*FieldError
+}
+
+// Error 实现 error 接口，每个无效配置项占一行
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Fields)+1)
+	lines = append(lines, fmt.Sprintf("配置无效（%d项）:", len(e.Fields)))
+	for _, field := range e.Fields {
+		lines = append(lines, "  "+field.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap 返回所有配置项错误，支持 errors.Is(err, ErrInvalidPort) 等判断
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, field := range e.Fields {
+		errs[i] = field
+	}
+	return errs
+}
+
+// Has 判断指定配置项是否无效
+func (e *ValidationError) Has(field string) bool {
+	return slices.ContainsFunc(e.Fields, func(f *FieldError) bool { return f.Field == field })
+}
+
+// fieldErrors 收集配置项错误
+type fieldErrors []*FieldError
+
+func (errs *fieldErrors) add(field, format string, args ...any) {
+	*errs = append(*errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (errs *fieldErrors) required(field, value string) {
+	if value == "" {
+		errs.add(field, "不能为空")
+	}
+}
+
+func (errs *fieldErrors) port(field string, value int, sentinel error) {
+	if value < 1 || value > 65535 {
+		*errs = append(*errs, &FieldError{Field: field, Message: fmt.Sprintf("必须在1-65535之间，当前为%d", value), Err: sentinel})
+	}
+}
+
+func (errs *fieldErrors) oneOf(field, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		errs.add(field, "必须为 %s 之一，当前为%q", strings.Join(allowed, "、"), value)
+	}
+}
+
+func (errs *fieldErrors) between(field string, value, lo, hi int) {
+	if value < lo || value > hi {
+		errs.add(field, "必须在%d-%d之间，当前为%d", lo, hi, value)
+	}
+}
+
+// Validate 校验配置的必填项、取值范围和可选值，在设置默认值之后调用
+// 返回 *ValidationError，包含所有无效的配置项；配置有效时返回nil
+func (c *AppConfig) Validate() error {
+	var errs fieldErrors
+
+	// 服务器
+	errs.port("server.port", c.Server.Port, ErrInvalidPort)
+	if c.Server.ShutdownTimeout <= 0 {
+		errs.add("server.shutdown_timeout", "必须大于0，当前为%s", c.Server.ShutdownTimeout)
+	}
+	if c.Server.ShutdownDelay < 0 {
+		errs.add("server.shutdown_delay", "不能为负数，当前为%s", c.Server.ShutdownDelay)
+	} else if c.Server.ShutdownTimeout > 0 && c.Server.ShutdownDelay > c.Server.ShutdownTimeout/2 {
+		// 等待时间计入关闭超时，需留出时间等待进行中的请求完成
+		errs.add("server.shutdown_delay", "不能超过shutdown_timeout（%s）的一半，当前为%s", c.Server.ShutdownTimeout, c.Server.ShutdownDelay)
+	}
+
+	// 数据库
+	if c.Database.Host == "" {
+		errs = append(errs, &FieldError{Field: "database.host", Message: "不能为空", Err: ErrMissingDatabaseHost})
+	}
+	errs.port("database.port", c.Database.Port, nil)
+	errs.required("database.username", c.Database.Username)
+	errs.required("database.dbname", c.Database.DBName)
+	errs.oneOf("database.sslmode", c.Database.SSLMode, validSSLModes)
+	if c.Database.MaxOpenConns < 1 {
+		errs.add("database.max_open_conns", "必须大于0，当前为%d", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs.add("database.max_idle_conns", "必须在0-%d（max_open_conns）之间，当前为%d", c.Database.MaxOpenConns, c.Database.MaxIdleConns)
+	}
+	for i, replica := range c.Database.Replicas {
+		field := fmt.Sprintf("database.replicas[%d]", i)
+		errs.required(field+".host", replica.Host)
+		if replica.SSLMode != "" {
+			errs.oneOf(field+".sslmode", replica.SSLMode, validSSLModes)
+		}
+	}
+
+	// Redis，未配置主机时不使用Redis
+	if c.Redis.Host != "" {
+		errs.port("redis.port", c.Redis.Port, nil)
+	}
+
+	// 日志，级别为空时使用info
+	if c.Log.Level != "" {
+		errs.oneOf("log.level", c.Log.Level, validLogLevels)
+	}
+	errs.oneOf("log.format", c.Log.Format, validLogFormats)
+	errs.oneOf("log.access_format", c.Log.AccessFormat, validAccessFormats)
+
+	// JWT
+	errs.oneOf("jwt.algorithm", c.JWT.Algorithm, validJWTAlgorithms)
+	switch c.JWT.Algorithm {
+	case "HS256":
+		errs.required("jwt.secret", c.JWT.Secret)
+	case "RS256":
+		errs.required("jwt.public_key_file", c.JWT.PublicKeyFile)
+	}
+	if c.JWT.AccessTokenExp <= 0 {
+		errs.add("jwt.access_token_exp", "必须大于0，当前为%s", c.JWT.AccessTokenExp)
+	}
+	if c.JWT.RefreshTokenExp < c.JWT.AccessTokenExp {
+		errs.add("jwt.refresh_token_exp", "不能小于access_token_exp（%s），当前为%s", c.JWT.AccessTokenExp, c.JWT.RefreshTokenExp)
+	}
+	if c.JWT.Leeway < 0 {
+		errs.add("jwt.leeway", "不能为负数，当前为%s", c.JWT.Leeway)
+	}
+
+	// 密码哈希
+	errs.oneOf("auth.password_hash.algorithm", c.Auth.PasswordHash.Algorithm, validHashAlgorithms)
+	if c.Auth.PasswordHash.Algorithm == "bcrypt" {
+		errs.between("auth.password_hash.bcrypt_cost", c.Auth.PasswordHash.BcryptCost, 4, 31)
+	}
+
+	// 文件存储
+	errs.oneOf("storage.driver", c.Storage.Driver, validStorageDrivers)
+	if c.Storage.Driver == "s3" {
+		errs.required("storage.s3.bucket", c.Storage.S3.Bucket)
+	}
+
+	// SMTP，未配置主机时不发送邮件
+	if c.Mail.SMTP.Host != "" {
+		errs.port("mail.smtp.port", c.Mail.SMTP.Port, nil)
+		errs.required("mail.smtp.from", c.Mail.SMTP.From)
+		errs.oneOf("mail.smtp.tls", c.Mail.SMTP.TLS, validSMTPTLSModes)
+	}
+
+	// gRPC
+	if c.GRPC.Enabled {
+		errs.port("grpc.port", c.GRPC.Port, nil)
+		if c.GRPC.Port == c.Server.Port {
+			errs.add("grpc.port", "不能与server.port相同（%d）", c.Server.Port)
+		}
+	}
+
+	// 链路追踪
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs.add("tracing.sample_ratio", "必须在0-1之间，当前为%g", c.Tracing.SampleRatio)
+	}
+
+	// 功能开关，按名称排序使错误信息的顺序保持稳定
+	for _, name := range slices.Sorted(maps.Keys(c.FeatureFlags.Flags)) {
+		if percentage := c.FeatureFlags.Flags[name].Percentage; percentage != nil {
+			errs.between("feature_flags.flags."+name+".percentage", *percentage, 0, 100)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: errs}
+}
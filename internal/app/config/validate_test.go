@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validConfig 返回通过校验的最小配置
+func validConfig() *AppConfig {
+	cfg := &AppConfig{}
+	cfg.Database.Host = "localhost"
+	cfg.Database.Port = 5432
+	cfg.Database.Username = "postgres"
+	cfg.Database.DBName = "myapp"
+	cfg.Database.SSLMode = "disable"
+	cfg.JWT.Secret = "secret"
+	setDefaults(cfg)
+	return cfg
+}
+
+func TestValidate_Valid(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*AppConfig)
+		field   string
+		message string
+	}{
+		{
+			name:    "empty database host",
+			modify:  func(c *AppConfig) { c.Database.Host = "" },
+			field:   "database.host",
+			message: "database.host: 不能为空",
+		},
+		{
+			name:    "empty jwt secret",
+			modify:  func(c *AppConfig) { c.JWT.Secret = "" },
+			field:   "jwt.secret",
+			message: "jwt.secret: 不能为空",
+		},
+		{
+			name:    "invalid ssl mode",
+			modify:  func(c *AppConfig) { c.Database.SSLMode = "on" },
+			field:   "database.sslmode",
+			message: `database.sslmode: 必须为 disable、allow、prefer、require、verify-ca、verify-full 之一，当前为"on"`,
+		},
+		{
+			name:    "port out of range",
+			modify:  func(c *AppConfig) { c.Server.Port = 70000 },
+			field:   "server.port",
+			message: "server.port: 必须在1-65535之间，当前为70000",
+		},
+		{
+			name:    "negative shutdown delay",
+			modify:  func(c *AppConfig) { c.Server.ShutdownDelay = -time.Second },
+			field:   "server.shutdown_delay",
+			message: "server.shutdown_delay: 不能为负数，当前为-1s",
+		},
+		{
+			name:    "shutdown delay consumes shutdown timeout",
+			modify:  func(c *AppConfig) { c.Server.ShutdownDelay = 20 * time.Second },
+			field:   "server.shutdown_delay",
+			message: "server.shutdown_delay: 不能超过shutdown_timeout（30s）的一半，当前为20s",
+		},
+		{
+			name:    "non-positive shutdown timeout",
+			modify:  func(c *AppConfig) { c.Server.ShutdownTimeout = -time.Second },
+			field:   "server.shutdown_timeout",
+			message: "server.shutdown_timeout: 必须大于0，当前为-1s",
+		},
+		{
+			name:    "invalid log level",
+			modify:  func(c *AppConfig) { c.Log.Level = "verbose" },
+			field:   "log.level",
+			message: `log.level: 必须为 debug、info、warn、error 之一，当前为"verbose"`,
+		},
+		{
+			name:    "idle connections above open connections",
+			modify:  func(c *AppConfig) { c.Database.MaxIdleConns = 50 },
+			field:   "database.max_idle_conns",
+			message: "database.max_idle_conns: 必须在0-20（max_open_conns）之间，当前为50",
+		},
+		{
+			name:    "rs256 without public key",
+			modify:  func(c *AppConfig) { c.JWT.Algorithm = "RS256" },
+			field:   "jwt.public_key_file",
+			message: "jwt.public_key_file: 不能为空",
+		},
+		{
+			name:    "bcrypt cost out of range",
+			modify:  func(c *AppConfig) { c.Auth.PasswordHash.BcryptCost = 40 },
+			field:   "auth.password_hash.bcrypt_cost",
+			message: "auth.password_hash.bcrypt_cost: 必须在4-31之间，当前为40",
+		},
+		{
+			name: "grpc port same as server port",
+			modify: func(c *AppConfig) {
+				c.GRPC.Enabled = true
+				c.GRPC.Port = c.Server.Port
+			},
+			field:   "grpc.port",
+			message: "grpc.port: 不能与server.port相同（7001）",
+		},
+		{
+			name: "feature flag percentage",
+			modify: func(c *AppConfig) {
+				percentage := 120
+				c.FeatureFlags.Flags = map[string]FeatureFlagConfig{"beta": {Percentage: &percentage}}
+			},
+			field:   "feature_flags.flags.beta.percentage",
+			message: "feature_flags.flags.beta.percentage: 必须在0-100之间，当前为120",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			require.Len(t, validationErr.Fields, 1, err.Error())
+			assert.True(t, validationErr.Has(tt.field))
+			assert.Equal(t, tt.message, validationErr.Fields[0].Error())
+			assert.Contains(t, err.Error(), tt.message)
+		})
+	}
+}
+
+func TestValidate_Aggregated(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = 0
+	cfg.Database.Host = ""
+	cfg.Database.SSLMode = ""
+	cfg.JWT.Secret = ""
+
+	err := cfg.Validate()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Fields, 4)
+	assert.Contains(t, err.Error(), "配置无效（4项）")
+
+	// 保留哨兵错误
+	assert.True(t, errors.Is(err, ErrInvalidPort))
+	assert.True(t, errors.Is(err, ErrMissingDatabaseHost))
+}
+
+func TestLoadConfig_Validates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`app:
+  database:
+    host: localhost
+    port: 5432
+    username: postgres
+    dbname: myapp
+    sslmode: maybe
+  jwt:
+    secret: secret
+`), 0o600))
+
+	_, err := LoadConfig(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `database.sslmode: 必须为 disable、allow、prefer、require、verify-ca、verify-full 之一，当前为"maybe"`)
+}
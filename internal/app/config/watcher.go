@@ -32,10 +32,12 @@ func NewConfigWatcher(configPath string) (*ConfigWatcher, error) {
 		return nil, err
 	}
 
-	// 添加配置文件到监听
-	if err := watcher.Add(configPath); err != nil {
-		watcher.Close()
-		return nil, err
+	// 添加配置文件到监听，包括环境配置文件
+	for _, file := range ConfigFiles(configPath) {
+		if err := watcher.Add(file); err != nil {
+			watcher.Close()
+			return nil, err
+		}
 	}
 
 	cw := &ConfigWatcher{
@@ -120,20 +122,9 @@ func (cw *ConfigWatcher) reloadConfig(configPath string) {
 	cw.notifyCallbacks(oldCfg, newCfg)
 }
 
-// validateConfig 验证配置
+// validateConfig 验证配置，LoadConfig 已校验，这里保留作为重新加载前的最后检查
 func (cw *ConfigWatcher) validateConfig(cfg *AppConfig) error {
-	// 这里可以添加配置验证逻辑
-	// 例如：检查必填字段、验证格式等
-	
-	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
-		return ErrInvalidPort
-	}
-
-	if cfg.Database.Host == "" {
-		return ErrMissingDatabaseHost
-	}
-
-	return nil
+	return cfg.Validate()
 }
 
 // notifyCallbacks 通知所有回调函数
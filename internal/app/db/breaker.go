@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// circuitBreakerPluginName 断路器插件名称
+const circuitBreakerPluginName = "app:circuit_breaker"
+
+// pgQueryCanceled PostgreSQL语句被取消的SQLSTATE
+const pgQueryCanceled = "57014"
+
+// gormBreakerDoneKey 在GORM语句实例中保存断路器结果回调的键
+const gormBreakerDoneKey = "app:circuit_breaker:done"
+
+// EnableCircuitBreaker 为数据库操作启用断路器
+// 数据库连续执行失败达到阈值后断路器打开，打开期间的操作直接返回 *errors.CircuitOpenError，
+// 不再占用连接等待超时；事务的开始和提交不经过断路器
+func EnableCircuitBreaker(db *gorm.DB, cfg *config.CircuitBreakerConfig) error {
+	breaker := apperrors.NewCircuitBreakerWithConfig(&apperrors.CircuitBreakerConfig{
+		MaxFailures:      cfg.MaxFailures,
+		ResetTimeout:     cfg.ResetTimeout,
+		HalfOpenRequests: cfg.HalfOpenRequests,
+		OnStateChange:    logBreakerStateChange,
+	})
+	return db.Use(&circuitBreakerPlugin{breaker: breaker})
+}
+
+// logBreakerStateChange 记录断路器状态变化，打开时记录为警告
+func logBreakerStateChange(from, to apperrors.CircuitState) {
+	level := slog.LevelInfo
+	if to == apperrors.StateOpen {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "数据库断路器状态变化", "from", from.String(), "to", to.String())
+}
+
+// CircuitBreakerOf 返回数据库上启用的断路器，未启用时返回nil
+func CircuitBreakerOf(db *gorm.DB) *apperrors.CircuitBreaker {
+	if db == nil {
+		return nil
+	}
+	plugin, ok := db.Config.Plugins[circuitBreakerPluginName].(*circuitBreakerPlugin)
+	if !ok {
+		return nil
+	}
+	return plugin.breaker
+}
+
+// circuitBreakerPlugin GORM断路器插件
+type circuitBreakerPlugin struct {
+	breaker *apperrors.CircuitBreaker
+}
+
+// Name 插件名称
+func (p *circuitBreakerPlugin) Name() string {
+	return circuitBreakerPluginName
+}
+
+// Initialize 在各类操作执行前检查断路器，执行后记录结果
+// 写操作在开启默认事务前检查，断路器打开时不再获取连接
+func (p *circuitBreakerPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:begin_transaction").Register("app:before_create_breaker", p.before),
+		cb.Create().After("gorm:create").Register("app:after_create_breaker", p.after),
+		cb.Query().Before("gorm:query").Register("app:before_query_breaker", p.before),
+		cb.Query().After("gorm:query").Register("app:after_query_breaker", p.after),
+		cb.Update().Before("gorm:begin_transaction").Register("app:before_update_breaker", p.before),
+		cb.Update().After("gorm:update").Register("app:after_update_breaker", p.after),
+		cb.Delete().Before("gorm:begin_transaction").Register("app:before_delete_breaker", p.before),
+		cb.Delete().After("gorm:delete").Register("app:after_delete_breaker", p.after),
+		cb.Row().Before("gorm:row").Register("app:before_row_breaker", p.before),
+		cb.Row().After("gorm:row").Register("app:after_row_breaker", p.after),
+		cb.Raw().Before("gorm:raw").Register("app:before_raw_breaker", p.before),
+		cb.Raw().After("gorm:raw").Register("app:after_raw_breaker", p.after),
+	)
+}
+
+// before 断路器不放行时设置错误，GORM随后跳过SQL执行
+func (p *circuitBreakerPlugin) before(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+
+	done, err := p.breaker.Allow()
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(gormBreakerDoneKey, done)
+}
+
+// after 记录执行结果，只有数据库本身不可用的错误计为失败
+func (p *circuitBreakerPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormBreakerDoneKey)
+	if !ok {
+		return
+	}
+	done, ok := value.(func(error))
+	if !ok {
+		return
+	}
+
+	if isDatabaseFailure(db.Error) {
+		done(db.Error)
+	} else {
+		done(nil)
+	}
+}
+
+// gormUsageErrors GORM在执行SQL前返回的使用错误，说明调用方式有误，与数据库是否可用无关
+var gormUsageErrors = []error{
+	gorm.ErrRecordNotFound,
+	gorm.ErrMissingWhereClause,
+	gorm.ErrPrimaryKeyRequired,
+	gorm.ErrInvalidData,
+	gorm.ErrInvalidField,
+	gorm.ErrInvalidValue,
+	gorm.ErrInvalidValueOfLength,
+	gorm.ErrUnsupportedRelation,
+	gorm.ErrModelValueRequired,
+}
+
+// isDatabaseFailure 判断错误是否说明数据库不可用
+// 数据库返回的约束冲突、语法错误等说明数据库工作正常，GORM使用错误和调用方取消也不计为失败；
+// 连接失败、超时以及连接异常、资源不足、管理员关闭等类别的SQLSTATE计为失败
+func isDatabaseFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	for _, target := range gormUsageErrors {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// 57014 为语句被取消，通常是请求上下文结束导致
+		if len(pgErr.Code) < 2 || pgErr.Code == pgQueryCanceled {
+			return false
+		}
+		switch pgErr.Code[:2] {
+		case "08", // connection_exception
+			"53", // insufficient_resources
+			"57", // operator_intervention
+			"58": // system_error
+			return true
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// newBreakerDB 创建启用断路器的sqlmock数据库
+func newBreakerDB(t *testing.T, cfg config.CircuitBreakerConfig) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock := newMockConn(t)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, EnableCircuitBreaker(db, &cfg))
+	return db, mock
+}
+
+func TestCircuitBreakerPlugin(t *testing.T) {
+	// 连续的数据库故障使断路器打开，之后的操作不再访问数据库
+	t.Run("OpensOnFailures", func(t *testing.T) {
+		db, mock := newBreakerDB(t, config.CircuitBreakerConfig{MaxFailures: 2, ResetTimeout: time.Minute})
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+
+		var user testUser
+		require.Error(t, db.First(&user).Error)
+		require.Error(t, db.First(&user).Error)
+		assert.Equal(t, apperrors.StateOpen, CircuitBreakerOf(db).State())
+
+		var openErr *apperrors.CircuitOpenError
+		assert.ErrorAs(t, db.First(&user).Error, &openErr)
+		assert.ErrorAs(t, db.Create(&testUser{Name: "alice"}).Error, &openErr)
+		assert.ErrorAs(t, db.Exec("UPDATE test_users SET name = ?", "bob").Error, &openErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 半开状态的试探请求成功后恢复访问
+	t.Run("RecoversAfterReset", func(t *testing.T) {
+		db, mock := newBreakerDB(t, config.CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: 20 * time.Millisecond})
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		var user testUser
+		require.Error(t, db.First(&user).Error)
+		time.Sleep(30 * time.Millisecond)
+
+		require.NoError(t, db.First(&user).Error)
+		assert.Equal(t, apperrors.StateClosed, CircuitBreakerOf(db).State())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 记录不存在、约束冲突和调用方取消说明数据库正常，不计为失败
+	t.Run("IgnoresNonFailures", func(t *testing.T) {
+		db, mock := newBreakerDB(t, config.CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Minute})
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		mock.ExpectQuery(`SELECT`).WillReturnError(&pgconn.PgError{Code: "23505"})
+		mock.ExpectQuery(`SELECT`).WillReturnError(&pgconn.PgError{Code: pgQueryCanceled})
+		mock.ExpectQuery(`SELECT`).WillReturnError(context.Canceled)
+
+		for i := 0; i < 4; i++ {
+			var user testUser
+			require.Error(t, db.First(&user).Error)
+		}
+		assert.Equal(t, apperrors.StateClosed, CircuitBreakerOf(db).State())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotEnabled", func(t *testing.T) {
+		conn, _ := newMockConn(t)
+		db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{})
+		require.NoError(t, err)
+		assert.Nil(t, CircuitBreakerOf(db))
+		assert.Nil(t, CircuitBreakerOf(nil))
+	})
+}
+
+func TestIsDatabaseFailure(t *testing.T) {
+	assert.False(t, isDatabaseFailure(nil))
+	assert.False(t, isDatabaseFailure(gorm.ErrRecordNotFound))
+	assert.False(t, isDatabaseFailure(context.Canceled))
+	assert.False(t, isDatabaseFailure(&pgconn.PgError{Code: "42P01"}))
+	assert.True(t, isDatabaseFailure(context.DeadlineExceeded))
+	assert.True(t, isDatabaseFailure(&pgconn.PgError{Code: "08006"}))
+	assert.True(t, isDatabaseFailure(&pgconn.PgError{Code: "53300"}))
+	assert.True(t, isDatabaseFailure(&pgconn.PgError{Code: "57P01"}))
+	assert.True(t, isDatabaseFailure(errors.New("dial tcp: connection refused")))
+}
@@ -9,6 +9,7 @@ import (
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 
 	"github.com/vadxq/go-rest-starter/internal/app/config"
 )
@@ -22,7 +23,7 @@ func InitDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	}
 	
 	db, err := gorm.Open(postgres.Open(cfg.GetDSN()), &gorm.Config{
-		Logger:                 logger.Default.LogMode(logLevel),
+		Logger:                 NewQueryLogger(logLevel, cfg.SlowThreshold, nil),
 		PrepareStmt:            true,  // 预编译语句，提升性能
 		DisableForeignKeyConstraintWhenMigrating: true,
 	})
@@ -46,9 +47,63 @@ func InitDB(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("数据库ping失败: %w", err)
 	}
 
+	// 配置只读副本
+	if len(cfg.Replicas) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(cfg.Replicas))
+		for i, replica := range cfg.Replicas {
+			if replica.Host == "" {
+				return nil, fmt.Errorf("第%d个只读副本缺少主机地址", i+1)
+			}
+			replicaCfg := replicaConfig(cfg, replica)
+			replicas = append(replicas, postgres.Open(replicaCfg.GetDSN()))
+		}
+		if err := RegisterReplicas(db, cfg, replicas); err != nil {
+			return nil, err
+		}
+	}
+
 	return db, nil
 }
 
+// RegisterReplicas 注册读写分离插件
+// 查询（SELECT）随机路由到只读副本，写操作和事务内的所有操作仍使用主库；
+// 需要读取刚写入的数据时，使用 dbresolver.Write 子句强制查询主库
+func RegisterReplicas(db *gorm.DB, cfg *config.DatabaseConfig, replicas []gorm.Dialector) error {
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxOpenConns(cfg.MaxOpenConns).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetConnMaxLifetime(cfg.ConnMaxLifetime).
+		SetConnMaxIdleTime(cfg.ConnMaxLifetime / 2)
+
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("注册只读副本失败: %w", err)
+	}
+	return nil
+}
+
+// replicaConfig 合并副本配置，副本未配置的连接参数沿用主库配置
+func replicaConfig(primary *config.DatabaseConfig, replica config.DatabaseConfig) config.DatabaseConfig {
+	if replica.Port == 0 {
+		replica.Port = primary.Port
+	}
+	if replica.Username == "" {
+		replica.Username = primary.Username
+	}
+	if replica.Password == "" {
+		replica.Password = primary.Password
+	}
+	if replica.DBName == "" {
+		replica.DBName = primary.DBName
+	}
+	if replica.SSLMode == "" {
+		replica.SSLMode = primary.SSLMode
+	}
+	return replica
+}
+
 // InitRedis 初始化Redis连接
 func InitRedis(cfg *config.RedisConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
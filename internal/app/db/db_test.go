@@ -0,0 +1,133 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+)
+
+type testUser struct {
+	ID   uint
+	Name string
+}
+
+// newMockConn 创建sqlmock连接
+func newMockConn(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, mock
+}
+
+// newResolvedDB 创建注册了只读副本的数据库，主库和副本均为sqlmock
+func newResolvedDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+	t.Helper()
+
+	primaryConn, primary := newMockConn(t)
+	replicaConn, replica := newMockConn(t)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: primaryConn}), &gorm.Config{})
+	require.NoError(t, err)
+
+	cfg := &config.DatabaseConfig{MaxOpenConns: 5, MaxIdleConns: 1}
+	replicas := []gorm.Dialector{postgres.New(postgres.Config{Conn: replicaConn})}
+	require.NoError(t, RegisterReplicas(db, cfg, replicas))
+
+	return db, primary, replica
+}
+
+func TestRegisterReplicas(t *testing.T) {
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test")
+	}
+
+	// 查询路由到只读副本
+	t.Run("ReadFromReplica", func(t *testing.T) {
+		db, primary, replica := newResolvedDB(t)
+		replica.ExpectQuery(`SELECT \* FROM "test_users"`).WillReturnRows(rows())
+
+		var users []testUser
+		require.NoError(t, db.Find(&users).Error)
+		assert.Len(t, users, 1)
+		assert.NoError(t, replica.ExpectationsWereMet())
+		assert.NoError(t, primary.ExpectationsWereMet())
+	})
+
+	// 写操作使用主库
+	t.Run("WriteToPrimary", func(t *testing.T) {
+		db, primary, replica := newResolvedDB(t)
+		primary.ExpectBegin()
+		primary.ExpectQuery(`INSERT INTO "test_users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		primary.ExpectCommit()
+
+		require.NoError(t, db.Create(&testUser{Name: "test"}).Error)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	// 事务内的查询使用主库
+	t.Run("TransactionOnPrimary", func(t *testing.T) {
+		db, primary, replica := newResolvedDB(t)
+		primary.ExpectBegin()
+		primary.ExpectQuery(`SELECT \* FROM "test_users"`).WillReturnRows(rows())
+		primary.ExpectCommit()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var users []testUser
+			return tx.Find(&users).Error
+		})
+		require.NoError(t, err)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+
+	// Write子句强制查询主库
+	t.Run("ForcePrimary", func(t *testing.T) {
+		db, primary, replica := newResolvedDB(t)
+		primary.ExpectQuery(`SELECT \* FROM "test_users"`).WillReturnRows(rows())
+
+		var users []testUser
+		require.NoError(t, db.Clauses(dbresolver.Write).Find(&users).Error)
+		assert.NoError(t, primary.ExpectationsWereMet())
+		assert.NoError(t, replica.ExpectationsWereMet())
+	})
+}
+
+func TestReplicaConfig(t *testing.T) {
+	primary := &config.DatabaseConfig{
+		Host:     "primary",
+		Port:     5432,
+		Username: "postgres",
+		Password: "secret",
+		DBName:   "myapp",
+		SSLMode:  "disable",
+	}
+
+	// 未配置的连接参数沿用主库
+	t.Run("InheritFromPrimary", func(t *testing.T) {
+		replica := replicaConfig(primary, config.DatabaseConfig{Host: "replica"})
+		assert.Equal(t, "replica", replica.Host)
+		assert.Equal(t, 5432, replica.Port)
+		assert.Equal(t, "postgres", replica.Username)
+		assert.Equal(t, "secret", replica.Password)
+		assert.Equal(t, "myapp", replica.DBName)
+		assert.Equal(t, "disable", replica.SSLMode)
+	})
+
+	// 副本自身的配置优先
+	t.Run("Override", func(t *testing.T) {
+		replica := replicaConfig(primary, config.DatabaseConfig{Host: "replica", Port: 6432, Username: "reader"})
+		assert.Equal(t, 6432, replica.Port)
+		assert.Equal(t, "reader", replica.Username)
+	})
+}
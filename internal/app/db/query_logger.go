@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// queryLogger 基于 slog 的GORM日志器，超过慢查询阈值的SQL以Warn级别记录
+// 日志带上请求上下文中的链路追踪ID和请求ID，SQL只记录占位符不记录参数
+type queryLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+	log           *slog.Logger // 为空时使用 slog.Default()
+}
+
+// NewQueryLogger 创建GORM日志器，slowThreshold<=0时不记录慢查询，log为空时使用 slog 的默认日志器
+func NewQueryLogger(level gormlogger.LogLevel, slowThreshold time.Duration, log *slog.Logger) gormlogger.Interface {
+	return &queryLogger{level: level, slowThreshold: slowThreshold, log: log}
+}
+
+// LogMode 返回指定级别的日志器副本
+func (l *queryLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 输出信息日志
+func (l *queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger().InfoContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Warn 输出警告日志
+func (l *queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger().WarnContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Error 输出错误日志
+func (l *queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger().ErrorContext(ctx, fmt.Sprintf(msg, args...), l.contextAttrs(ctx)...)
+	}
+}
+
+// Trace 记录SQL执行结果：失败的SQL记录为Error，慢查询记录为Warn，Info级别记录所有SQL
+func (l *queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.logger().ErrorContext(ctx, "SQL执行失败", l.traceAttrs(ctx, elapsed, fc, "error", err)...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		l.logger().WarnContext(ctx, "慢查询", l.traceAttrs(ctx, elapsed, fc, "threshold_ms", l.slowThreshold.Milliseconds())...)
+	case l.level >= gormlogger.Info:
+		l.logger().DebugContext(ctx, "SQL执行", l.traceAttrs(ctx, elapsed, fc)...)
+	}
+}
+
+// ParamsFilter 不记录SQL参数，以免泄露密码哈希等敏感数据
+func (l *queryLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *queryLogger) logger() *slog.Logger {
+	if l.log != nil {
+		return l.log
+	}
+	return slog.Default()
+}
+
+// traceAttrs SQL、耗时、影响行数、调用位置及上下文属性
+func (l *queryLogger) traceAttrs(ctx context.Context, elapsed time.Duration, fc func() (string, int64), extra ...any) []any {
+	sql, rows := fc()
+	attrs := append([]any{
+		"sql", sql,
+		"duration_ms", float64(elapsed.Nanoseconds()) / 1e6,
+		"rows", rows,
+		"caller", utils.FileWithLineNum(),
+	}, extra...)
+	return append(attrs, l.contextAttrs(ctx)...)
+}
+
+// contextAttrs 请求上下文中的链路追踪ID和请求ID
+func (l *queryLogger) contextAttrs(ctx context.Context) []any {
+	var attrs []any
+	if traceID := logger.GetTraceID(ctx); traceID != "" {
+		attrs = append(attrs, "trace_id", traceID)
+	}
+	if requestID := logger.GetRequestID(ctx); requestID != "" {
+		attrs = append(attrs, "request_id", requestID)
+	}
+	return attrs
+}
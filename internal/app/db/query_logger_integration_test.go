@@ -0,0 +1,51 @@
+//go:build integration
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=test sslmode=disable" go test -tags integration ./internal/app/db
+func TestQueryLogger_SlowQueryIntegration(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("未设置 TEST_DATABASE_DSN，跳过集成测试")
+	}
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: NewQueryLogger(gormlogger.Warn, 50*time.Millisecond, log),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	// pg_sleep 使查询超过慢查询阈值
+	ctx := logger.WithTraceID(context.Background(), "trace-slow")
+	require.NoError(t, db.WithContext(ctx).Exec("SELECT pg_sleep(0.1)").Error)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "慢查询", entry["msg"])
+	assert.Equal(t, "SELECT pg_sleep(0.1)", entry["sql"])
+	assert.GreaterOrEqual(t, entry["duration_ms"], float64(100))
+	assert.Equal(t, "trace-slow", entry["trace_id"])
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// newLoggedDB 创建日志输出到缓冲区的sqlmock数据库
+func newLoggedDB(t *testing.T, slowThreshold time.Duration) (*gorm.DB, sqlmock.Sqlmock, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	conn, mock := newMockConn(t)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: NewQueryLogger(gormlogger.Warn, slowThreshold, log),
+	})
+	require.NoError(t, err)
+	return db, mock, &buf
+}
+
+// logEntries 解析缓冲区中的JSON日志
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestQueryLogger(t *testing.T) {
+	ctx := logger.WithTraceID(context.Background(), "trace-1")
+
+	// 超过阈值的查询以Warn级别记录SQL、耗时、行数和链路追踪ID，不记录参数
+	t.Run("SlowQuery", func(t *testing.T) {
+		db, mock, buf := newLoggedDB(t, 20*time.Millisecond)
+		mock.ExpectQuery(`SELECT`).
+			WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		var users []testUser
+		require.NoError(t, db.WithContext(ctx).Where("name = ?", "alice").Find(&users).Error)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		entry := entries[0]
+		assert.Equal(t, "WARN", entry["level"])
+		assert.Equal(t, "慢查询", entry["msg"])
+		assert.Contains(t, entry["sql"], `SELECT * FROM "test_users" WHERE name = $1`)
+		assert.NotContains(t, entry["sql"], "alice")
+		assert.GreaterOrEqual(t, entry["duration_ms"], float64(50))
+		assert.Equal(t, float64(1), entry["rows"])
+		assert.Equal(t, float64(20), entry["threshold_ms"])
+		assert.Equal(t, "trace-1", entry["trace_id"])
+	})
+
+	// 未超过阈值的查询在Warn级别下不记录
+	t.Run("FastQuery", func(t *testing.T) {
+		db, mock, buf := newLoggedDB(t, time.Second)
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		var users []testUser
+		require.NoError(t, db.WithContext(ctx).Find(&users).Error)
+		assert.Empty(t, buf.String())
+	})
+
+	// 失败的SQL以Error级别记录，记录未找到不记录
+	t.Run("Errors", func(t *testing.T) {
+		db, mock, buf := newLoggedDB(t, time.Second)
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		var user testUser
+		require.Error(t, db.WithContext(ctx).First(&user).Error)
+		require.ErrorIs(t, db.WithContext(ctx).First(&user).Error, gorm.ErrRecordNotFound)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "ERROR", entries[0]["level"])
+		assert.Equal(t, "connection refused", entries[0]["error"])
+		assert.Equal(t, "trace-1", entries[0]["trace_id"])
+	})
+
+	// Silent 级别不输出日志
+	t.Run("Silent", func(t *testing.T) {
+		db, mock, buf := newLoggedDB(t, time.Nanosecond)
+		db.Logger = db.Logger.LogMode(gormlogger.Silent)
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection refused"))
+
+		var user testUser
+		require.Error(t, db.First(&user).Error)
+		assert.Empty(t, buf.String())
+	})
+}
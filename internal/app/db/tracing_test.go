@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTracingPlugin(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	conn, mock := newMockConn(t)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(&tracingPlugin{tracer: provider.Tracer(tracerName)}))
+
+	// 查询span作为请求span的子span，记录不含参数的SQL
+	t.Run("QuerySpan", func(t *testing.T) {
+		exporter.Reset()
+		mock.ExpectQuery(`SELECT \* FROM "test_users"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+		var user testUser
+		require.NoError(t, db.WithContext(ctx).Where("name = ?", "alice").First(&user).Error)
+		parent.End()
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		query := spans[0]
+		assert.Equal(t, "db.select", query.Name)
+		assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+
+		attrs := map[string]string{}
+		for _, kv := range query.Attributes {
+			attrs[string(kv.Key)] = kv.Value.Emit()
+		}
+		assert.Equal(t, "test_users", attrs["db.collection.name"])
+		assert.Contains(t, attrs["db.query.text"], "name = $1")
+		assert.NotContains(t, attrs["db.query.text"], "alice")
+	})
+
+	// 数据库错误标记span为错误，记录不存在不视为错误
+	t.Run("ErrorStatus", func(t *testing.T) {
+		exporter.Reset()
+		mock.ExpectQuery(`SELECT`).WillReturnError(errors.New("connection reset"))
+		mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+
+		var user testUser
+		require.Error(t, db.First(&user).Error)
+		require.ErrorIs(t, db.First(&user).Error, gorm.ErrRecordNotFound)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, codes.Unset, spans[1].Status.Code)
+	})
+}
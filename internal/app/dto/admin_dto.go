@@ -0,0 +1,12 @@
+package dto
+
+// LogLevelRequest 调整日志级别请求
+type LogLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error"` // debug、info、warn、error
+}
+
+// LogLevelResponse 日志级别响应
+type LogLevelResponse struct {
+	Level    string `json:"level"`    // 当前日志级别
+	Previous string `json:"previous"` // 调整前的日志级别
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// AuditLogFilter 审计日志筛选条件，未设置的条件不参与筛选
+type AuditLogFilter struct {
+	ActorID    *uint      `json:"actor_id"`    // 操作者用户ID
+	Action     string     `json:"action"`      // 审计动作，如 "user.delete"
+	EntityType string     `json:"entity_type"` // 实体类型，如 "user"
+	EntityID   *uint      `json:"entity_id"`   // 实体ID
+	Since      *time.Time `json:"since"`       // 起始时间（含）
+	Until      *time.Time `json:"until"`       // 截止时间（不含）
+}
+
+// AuditLogResponse 审计日志响应
+type AuditLogResponse struct {
+	ID         uint                        `json:"id"`
+	ActorID    *uint                       `json:"actor_id"`
+	Action     string                      `json:"action"`
+	EntityType string                      `json:"entity_type"`
+	EntityID   uint                        `json:"entity_id"`
+	Changes    map[string]AuditFieldChange `json:"changes"` // 按字段名记录的变更，敏感字段已脱敏
+	RequestID  string                      `json:"request_id"`
+	CreatedAt  time.Time                   `json:"created_at"`
+}
+
+// AuditFieldChange 单个字段变更前后的值
+type AuditFieldChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
@@ -1,9 +1,15 @@
 package dto
 
+import "time"
+
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+
+	// 登录设备信息，由处理器从请求中填写，记录到会话中
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 // LoginResponse 登录响应
@@ -20,9 +26,48 @@ type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
+// ForgotPasswordRequest 忘记密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求，密码强度按配置的策略校验
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// IntrospectRequest 令牌内省请求
+type IntrospectRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// IntrospectResponse 令牌内省响应，参照 RFC 7662，令牌无效时只返回 active=false
+type IntrospectResponse struct {
+	Active    bool     `json:"active"`
+	Subject   string   `json:"sub,omitempty"` // 用户ID
+	Role      string   `json:"role,omitempty"`
+	Scope     string   `json:"scope,omitempty"` // 权限范围，以空格分隔
+	ExpiresAt int64    `json:"exp,omitempty"`   // 过期时间，Unix秒
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+}
+
+// SessionResponse 登录会话，每次登录创建一个会话，刷新令牌不会创建新会话
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	IssuedAt  time.Time `json:"issued_at"` // 登录时间
+	Current   bool      `json:"current"`   // 是否为当前请求所用的会话
+}
+
 // TokenResponse 令牌响应
 type TokenResponse struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int64  `json:"expires_in"`
-	TokenType   string `json:"token_type"`
-} 
\ No newline at end of file
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"` // 轮换后的新刷新令牌，旧令牌随即失效
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
@@ -19,8 +19,30 @@ type ErrorResponse struct {
 
 // ListResponse 列表分页响应
 type ListResponse struct {
-	Data  interface{} `json:"data"`   // 列表数据
-	Page  int         `json:"page"`   // 当前页码
-	Size  int         `json:"size"`   // 每页大小
-	Total int64       `json:"total"`  // 总记录数
+	Data       interface{} `json:"data"`        // 列表数据
+	Page       int         `json:"page"`        // 当前页码
+	Size       int         `json:"size"`        // 每页大小
+	Total      int64       `json:"total"`       // 总记录数
+	TotalPages int         `json:"total_pages"` // 总页数
+	HasNext    bool        `json:"has_next"`    // 是否有下一页
+	HasPrev    bool        `json:"has_prev"`    // 是否有上一页
+}
+
+// NewListResponse 创建列表分页响应并计算总页数和前后页标记
+// 每页大小不大于0时总页数为0，不存在前后页
+func NewListResponse(data interface{}, page, size int, total int64) ListResponse {
+	response := ListResponse{
+		Data:  data,
+		Page:  page,
+		Size:  size,
+		Total: total,
+	}
+	if size <= 0 {
+		return response
+	}
+
+	response.TotalPages = int((total + int64(size) - 1) / int64(size))
+	response.HasNext = page < response.TotalPages
+	response.HasPrev = page > 1 && response.TotalPages > 0
+	return response
 } 
\ No newline at end of file
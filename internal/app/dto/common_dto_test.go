@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewListResponse(t *testing.T) {
+	// 总数恰好填满最后一页
+	t.Run("ExactFit", func(t *testing.T) {
+		first := NewListResponse(nil, 1, 10, 30)
+		assert.Equal(t, 3, first.TotalPages)
+		assert.True(t, first.HasNext)
+		assert.False(t, first.HasPrev)
+
+		last := NewListResponse(nil, 3, 10, 30)
+		assert.Equal(t, 3, last.TotalPages)
+		assert.False(t, last.HasNext)
+		assert.True(t, last.HasPrev)
+	})
+
+	// 最后一页未填满
+	t.Run("PartialLastPage", func(t *testing.T) {
+		middle := NewListResponse(nil, 2, 10, 31)
+		assert.Equal(t, 4, middle.TotalPages)
+		assert.True(t, middle.HasNext)
+		assert.True(t, middle.HasPrev)
+
+		last := NewListResponse(nil, 4, 10, 31)
+		assert.False(t, last.HasNext)
+		assert.True(t, last.HasPrev)
+	})
+
+	// 没有记录时不存在前后页
+	t.Run("Empty", func(t *testing.T) {
+		response := NewListResponse([]string{}, 1, 10, 0)
+		assert.Equal(t, 0, response.TotalPages)
+		assert.False(t, response.HasNext)
+		assert.False(t, response.HasPrev)
+		assert.Equal(t, []string{}, response.Data)
+	})
+
+	// 每页大小为0时不做除法
+	t.Run("ZeroSize", func(t *testing.T) {
+		response := NewListResponse(nil, 2, 0, 25)
+		assert.Equal(t, 0, response.TotalPages)
+		assert.False(t, response.HasNext)
+		assert.False(t, response.HasPrev)
+		assert.Equal(t, int64(25), response.Total)
+	})
+}
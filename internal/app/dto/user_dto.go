@@ -1,19 +1,56 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
 
 // CreateUserInput 创建用户请求
 type CreateUserInput struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	// Role 用户角色，为空时为 user；不从请求中读取，仅供内部调用（如初始化数据）设置
+	Role string `json:"-" validate:"omitempty,max=20"`
+	// Verified 创建为邮箱已验证的用户，不发送验证邮件；不从请求中读取，仅供内部调用（如初始化数据）设置
+	Verified bool `json:"-"`
 }
 
-// UpdateUserInput 更新用户请求
+// UpdateUserInput 更新用户请求（PUT），整体替换用户资料
+// 密码不属于用户资料的返回内容，为空时保持不变
 type UpdateUserInput struct {
-	Name     string `json:"name" validate:"omitempty,min=2,max=100"`
-	Email    string `json:"email" validate:"omitempty,email"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"omitempty,min=6"`
+	Version  *uint  `json:"version,omitempty"` // 读取时的版本号，设置后与当前版本不一致时返回冲突
+}
+
+// PatchUserInput 部分更新用户请求（PATCH）
+// 字段为nil（请求中省略或为null）时保持不变，出现时（包括空字符串）按字段规则校验后设置
+type PatchUserInput struct {
+	Name     *string `json:"name" validate:"omitnil,min=2,max=100"`
+	Email    *string `json:"email" validate:"omitnil,email"`
+	Password *string `json:"password" validate:"omitnil,min=6"`
+	Version  *uint   `json:"version,omitempty"` // 读取时的版本号，设置后与当前版本不一致时返回冲突
+}
+
+// ListUsersFilter 用户列表筛选和排序条件
+type ListUsersFilter struct {
+	Search string `json:"search"` // 按姓名或邮箱模糊匹配，不区分大小写
+	Role   string `json:"role"`   // 按角色精确匹配
+	Sort   string `json:"sort"`   // 排序字段，多个字段用逗号分隔，前缀"-"表示降序，如 "-created_at,name"
+}
+
+// IsZero 是否未设置任何筛选和排序条件
+func (f ListUsersFilter) IsZero() bool {
+	return f == ListUsersFilter{}
+}
+
+// UserListOptions 用户列表查询选项
+type UserListOptions struct {
+	ListUsersFilter
+	IncludeDeleted bool `json:"include_deleted"` // 是否包含已软删除的用户
 }
 
 // UserResponse 用户响应
@@ -22,6 +59,32 @@ type UserResponse struct {
 	Name      string    `json:"name"`
 	Email     string    `json:"email"`
 	Role      string    `json:"role"`
+	AvatarURL string    `json:"avatar_url,omitempty"` // 头像地址，未上传时省略
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	Version   uint      `json:"version"`  // 版本号，更新时通过 version 字段或 If-Match 请求头回传
+	Verified  bool      `json:"verified"` // 邮箱是否已验证
+}
+
+// BulkCreateUsersResponse 批量创建用户响应
+type BulkCreateUsersResponse struct {
+	Created int                    `json:"created"` // 创建成功的数量
+	Failed  int                    `json:"failed"`  // 创建失败的数量
+	Results []BulkCreateUserResult `json:"results"` // 与请求数组一一对应的结果
+}
+
+// BulkCreateUserResult 批量创建中单条记录的结果
+type BulkCreateUserResult struct {
+	Index   int            `json:"index"`           // 在请求数组中的下标
+	Success bool           `json:"success"`         // 是否创建成功
+	User    *UserResponse  `json:"user,omitempty"`  // 创建成功的用户
+	Error   *BulkItemError `json:"error,omitempty"` // 创建失败的原因
+}
+
+// BulkItemError 批量操作中单条记录的错误
+type BulkItemError struct {
+	Type    string                 `json:"type"`
+	Code    string                 `json:"code,omitempty"` // 业务错误码，如 USER_EMAIL_TAKEN
+	Message string                 `json:"message"`
+	Fields  []apperrors.FieldError `json:"fields,omitempty"`
 }
@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// Config GraphQL 接口配置
+type Config struct {
+	MaxDepth       int  // 查询的最大嵌套深度，<=0时使用 DefaultConfig.MaxDepth
+	MaxQueryLength int  // 查询语句的最大长度（字节），<=0时使用 DefaultConfig.MaxQueryLength
+	Introspection  bool // 是否允许内省查询，生产环境建议关闭
+}
+
+// DefaultConfig 默认 GraphQL 接口配置
+var DefaultConfig = Config{
+	MaxDepth:       10,
+	MaxQueryLength: 10 << 10,
+}
+
+// request GraphQL 请求体
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler 处理 GraphQL 请求，与 REST 接口共用用户和认证服务
+type Handler struct {
+	schema *graphqlgo.Schema
+	logger *slog.Logger
+}
+
+// NewHandler 创建 GraphQL 处理器，config为空时使用默认配置
+func NewHandler(us services.UserService, as services.AuthService, logger *slog.Logger, v *validator.Validate, config *Config) (*Handler, error) {
+	if config == nil {
+		config = &DefaultConfig
+	}
+	maxDepth := config.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultConfig.MaxDepth
+	}
+	maxQueryLength := config.MaxQueryLength
+	if maxQueryLength <= 0 {
+		maxQueryLength = DefaultConfig.MaxQueryLength
+	}
+
+	opts := []graphqlgo.SchemaOpt{
+		graphqlgo.MaxDepth(maxDepth),
+		graphqlgo.MaxQueryLength(maxQueryLength),
+		graphqlgo.Logger(&panicLogger{logger: logger}),
+	}
+	if !config.Introspection {
+		opts = append(opts, graphqlgo.DisableIntrospection())
+	}
+
+	schema, err := graphqlgo.ParseSchema(schemaSDL, &resolver{userService: us, authService: as, validator: v}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("解析GraphQL模式失败: %w", err)
+	}
+	return &Handler{schema: schema, logger: logger}, nil
+}
+
+// ServeHTTP 执行 GraphQL 请求
+// @Summary 执行GraphQL请求
+// @Description 执行GraphQL查询或变更，请求体为 {"query","operationName","variables"}。login 无需认证，其余操作需要 Authorization 请求头；错误信息按 Accept-Language 本地化，extensions 中包含错误类型、业务错误码和字段错误
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Success 200 {object} object "GraphQL响应（data、errors）"
+// @Failure 400,401 {object} Response{error=ErrorInfo}
+// @Router /api/v1/graphql [post]
+// @Security BearerAuth
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	if err := handlers.DecodeJSON(r, &req); err != nil {
+		handlers.RespondError(w, r, err)
+		return
+	}
+	if req.Query == "" {
+		handlers.RespondError(w, r, apperrors.BadRequestError("缺少GraphQL查询语句", nil))
+		return
+	}
+
+	resp := h.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	locale := handlers.RequestLocale(r)
+	for _, qErr := range resp.Errors {
+		translateError(qErr, locale)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", locale)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		h.logger.Error("GraphQL响应JSON序列化失败", "error", err)
+	}
+}
+
+// translateError 将解析器返回的应用错误转换为本地化信息，错误类型、业务错误码和字段错误写入 extensions
+// 与 REST 错误响应中的 ErrorInfo 字段一致；非应用错误按内部错误处理，避免泄露错误详情
+func translateError(qErr *gqlerrors.QueryError, locale string) {
+	if qErr.ResolverError == nil {
+		// 语法和校验错误由 graphql-go 生成
+		return
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(qErr.ResolverError, &appErr) {
+		appErr = apperrors.InternalError("内部服务器错误", qErr.ResolverError)
+	}
+
+	status := appErr.StatusCode()
+	if status >= 500 {
+		slog.Error(appErr.Message, "error", appErr, "type", string(appErr.Type), "path", qErr.Path)
+	} else {
+		slog.Debug(appErr.Message, "error", appErr, "type", string(appErr.Type), "path", qErr.Path)
+	}
+
+	qErr.Message = appErr.LocalizedMessage(locale)
+	extensions := map[string]interface{}{
+		"type":   string(appErr.Type),
+		"status": status,
+	}
+	if appErr.Code != "" {
+		extensions["code"] = string(appErr.Code)
+	}
+	if len(appErr.Fields) > 0 {
+		extensions["fields"] = appErr.Fields
+	}
+	qErr.Extensions = extensions
+}
+
+// panicLogger 将解析器中的panic记录到应用日志
+type panicLogger struct {
+	logger *slog.Logger
+}
+
+// LogPanic 记录执行查询时发生的panic
+func (l *panicLogger) LogPanic(ctx context.Context, value interface{}) {
+	l.logger.ErrorContext(ctx, "GraphQL解析器发生panic", "panic", value)
+}
@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+// stubUserService 内存中的用户服务，只实现 GraphQL 用到的方法
+type stubUserService struct {
+	services.UserService
+	users   map[string]*models.User
+	lastOpt dto.UserListOptions
+}
+
+func newStubUserService() *stubUserService {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user", Version: 1}
+	user.ID = 1
+	user.CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &stubUserService{users: map[string]*models.User{"1": user}}
+}
+
+func (s *stubUserService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, apperrors.NotFoundError("用户", nil)
+	}
+	return user, nil
+}
+
+func (s *stubUserService) ListUsers(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	s.lastOpt = opts
+	return []*models.User{s.users["1"]}, 11, nil
+}
+
+func (s *stubUserService) CreateUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error) {
+	user := &models.User{Name: input.Name, Email: input.Email, Role: "user", Version: 1}
+	user.ID = 2
+	s.users["2"] = user
+	return user, nil
+}
+
+// stubAuthService 只接受固定账号的认证服务
+type stubAuthService struct {
+	services.AuthService
+}
+
+func (s *stubAuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	if req.Password != "password123" {
+		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil).WithCode(apperrors.CodeAuthInvalidCredentials)
+	}
+	return &dto.LoginResponse{
+		AccessToken: "access",
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+		User:        dto.UserResponse{ID: 1, Email: req.Email},
+	}, nil
+}
+
+// graphqlResponse 解析后的 GraphQL 响应
+type graphqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// newTestServer 创建经过可选JWT认证的 GraphQL 服务，返回服务和生成指定角色令牌的函数
+func newTestServer(t *testing.T) (http.Handler, *stubUserService, func(role string) string) {
+	t.Helper()
+
+	users := newStubUserService()
+	h, err := NewHandler(users, &stubAuthService{}, slog.New(slog.NewTextHandler(io.Discard, nil)), validator.New(), nil)
+	require.NoError(t, err)
+
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	token := func(role string) string {
+		token, err := jwtpkg.GenerateAccessToken(1, role, "family", tokenConfig)
+		require.NoError(t, err)
+		return token
+	}
+	return custommiddleware.JWTAuth(&custommiddleware.JWTConfig{Token: tokenConfig, Optional: true})(h), users, token
+}
+
+// execute 发送 GraphQL 请求并解析响应
+func execute(t *testing.T, handler http.Handler, token, query string, variables map[string]interface{}) graphqlResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestHandler_Query(t *testing.T) {
+	handler, users, token := newTestServer(t)
+
+	// 按ID查询用户
+	t.Run("User", func(t *testing.T) {
+		resp := execute(t, handler, token("user"), `query($id: ID!) { user(id: $id) { id name email avatarUrl version } }`, map[string]interface{}{"id": "1"})
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"id":"1","name":"Test User","email":"test@example.com","avatarUrl":null,"version":1}`, string(resp.Data["user"]))
+	})
+
+	// 分页查询用户列表，筛选条件传给服务
+	t.Run("Users", func(t *testing.T) {
+		resp := execute(t, handler, token("user"), `{ users(page: 2, sort: "-created_at") { nodes { id } page size total totalPages hasNext hasPrev } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"nodes":[{"id":"1"}],"page":2,"size":10,"total":11,"totalPages":2,"hasNext":false,"hasPrev":true}`, string(resp.Data["users"]))
+		assert.Equal(t, "-created_at", users.lastOpt.Sort)
+	})
+
+	// 应用错误转换为带类型和状态码的 extensions
+	t.Run("NotFound", func(t *testing.T) {
+		resp := execute(t, handler, token("user"), `{ user(id: "404") { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "用户 not found", resp.Errors[0].Message)
+		assert.Equal(t, "NOT_FOUND", resp.Errors[0].Extensions["type"])
+		assert.EqualValues(t, http.StatusNotFound, resp.Errors[0].Extensions["status"])
+	})
+
+	// 未认证时拒绝查询
+	t.Run("Unauthenticated", func(t *testing.T) {
+		resp := execute(t, handler, "", `{ user(id: "1") { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "UNAUTHORIZED", resp.Errors[0].Extensions["type"])
+	})
+}
+
+func TestHandler_Mutation(t *testing.T) {
+	handler, users, token := newTestServer(t)
+
+	// 管理员创建用户
+	t.Run("CreateUser", func(t *testing.T) {
+		resp := execute(t, handler, token("admin"), `mutation($input: CreateUserInput!) { createUser(input: $input) { id name role } }`, map[string]interface{}{
+			"input": map[string]string{"name": "New User", "email": "new@example.com", "password": "password123"},
+		})
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"id":"2","name":"New User","role":"user"}`, string(resp.Data["createUser"]))
+		assert.Contains(t, users.users, "2")
+	})
+
+	// 输入沿用 REST 接口的校验规则，字段错误写入 extensions
+	t.Run("ValidationError", func(t *testing.T) {
+		resp := execute(t, handler, token("admin"), `mutation { createUser(input: {name: "N", email: "invalid", password: "password123"}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "VALIDATION_ERROR", resp.Errors[0].Extensions["type"])
+		assert.Len(t, resp.Errors[0].Extensions["fields"], 2)
+	})
+
+	// 非管理员不能创建和删除用户
+	t.Run("Forbidden", func(t *testing.T) {
+		resp := execute(t, handler, token("user"), `mutation { createUser(input: {name: "New User", email: "new@example.com", password: "password123"}) { id } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["type"])
+
+		resp = execute(t, handler, token("user"), `mutation { deleteUser(id: "1") }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["type"])
+	})
+
+	// 登录无需认证
+	t.Run("Login", func(t *testing.T) {
+		resp := execute(t, handler, "", `mutation { login(email: "test@example.com", password: "password123") { accessToken tokenType user { id email } } }`, nil)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"accessToken":"access","tokenType":"Bearer","user":{"id":"1","email":"test@example.com"}}`, string(resp.Data["login"]))
+
+		resp = execute(t, handler, "", `mutation { login(email: "test@example.com", password: "wrong-password") { accessToken } }`, nil)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, string(apperrors.CodeAuthInvalidCredentials), resp.Errors[0].Extensions["code"])
+	})
+}
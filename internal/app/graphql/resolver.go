@@ -0,0 +1,251 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// resolver 根解析器，查询和变更直接调用 REST 接口使用的服务
+type resolver struct {
+	userService services.UserService
+	authService services.AuthService
+	validator   *validator.Validate
+}
+
+// requireUser 要求请求已通过认证
+// 接口允许未携带令牌的请求以便登录，其余操作在此校验
+func requireUser(ctx context.Context) error {
+	if _, ok := custommiddleware.GetUserID(ctx); !ok {
+		return apperrors.UnauthorizedError("缺少认证令牌", nil)
+	}
+	return nil
+}
+
+// requireRole 要求请求已通过认证且拥有指定角色
+func requireRole(ctx context.Context, role string) error {
+	if err := requireUser(ctx); err != nil {
+		return err
+	}
+	if !custommiddleware.HasRole(ctx, role) {
+		return apperrors.ForbiddenError("没有权限访问", nil)
+	}
+	return nil
+}
+
+// User 获取用户详情
+func (r *resolver) User(ctx context.Context, args struct{ ID graphqlgo.ID }) (*userResolver, error) {
+	if err := requireUser(ctx); err != nil {
+		return nil, err
+	}
+
+	user, err := r.userService.GetByID(ctx, string(args.ID))
+	if err != nil {
+		return nil, err
+	}
+	return newUserResolver(user), nil
+}
+
+// usersArgs 用户列表查询参数
+type usersArgs struct {
+	Page     *int32
+	PageSize *int32
+	Search   *string
+	Role     *string
+	Sort     *string
+}
+
+// Users 分页获取用户列表，页码和每页大小无效时使用默认值，与 REST 接口一致
+func (r *resolver) Users(ctx context.Context, args usersArgs) (*userConnectionResolver, error) {
+	if err := requireUser(ctx); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := 1, 10
+	if args.Page != nil && *args.Page > 0 {
+		page = int(*args.Page)
+	}
+	if args.PageSize != nil && *args.PageSize > 0 {
+		pageSize = int(*args.PageSize)
+	}
+
+	opts := dto.UserListOptions{
+		ListUsersFilter: dto.ListUsersFilter{
+			Search: stringValue(args.Search),
+			Role:   stringValue(args.Role),
+			Sort:   stringValue(args.Sort),
+		},
+	}
+
+	users, total, err := r.userService.ListUsers(ctx, page, pageSize, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*userResolver, len(users))
+	for i, user := range users {
+		nodes[i] = newUserResolver(user)
+	}
+	return &userConnectionResolver{nodes: nodes, list: dto.NewListResponse(nil, page, pageSize, total)}, nil
+}
+
+// Login 登录
+func (r *resolver) Login(ctx context.Context, args struct{ Email, Password string }) (*loginPayloadResolver, error) {
+	resp, err := r.authService.Login(ctx, dto.LoginRequest{Email: args.Email, Password: args.Password})
+	if err != nil {
+		return nil, err
+	}
+	return &loginPayloadResolver{resp: resp}, nil
+}
+
+// createUserInput 创建用户参数
+type createUserInput struct {
+	Name     string
+	Email    string
+	Password string
+}
+
+// CreateUser 创建用户（仅管理员）
+func (r *resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	if err := requireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+
+	input := dto.CreateUserInput{Name: args.Input.Name, Email: args.Input.Email, Password: args.Input.Password}
+	if err := r.validator.Struct(input); err != nil {
+		return nil, apperrors.ValidationError("数据验证失败", err)
+	}
+
+	user, err := r.userService.CreateUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return newUserResolver(user), nil
+}
+
+// updateUserInput 更新用户参数
+type updateUserInput struct {
+	Name     string
+	Email    string
+	Password *string
+	Version  *int32
+}
+
+// UpdateUser 整体更新用户资料
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphqlgo.ID
+	Input updateUserInput
+}) (*userResolver, error) {
+	if err := requireUser(ctx); err != nil {
+		return nil, err
+	}
+
+	input := dto.UpdateUserInput{Name: args.Input.Name, Email: args.Input.Email, Password: stringValue(args.Input.Password)}
+	if args.Input.Version != nil {
+		if *args.Input.Version < 0 {
+			return nil, apperrors.BadRequestError("无效的版本号", nil)
+		}
+		version := uint(*args.Input.Version)
+		input.Version = &version
+	}
+
+	user, err := r.userService.UpdateUser(ctx, string(args.ID), input)
+	if err != nil {
+		return nil, err
+	}
+	return newUserResolver(user), nil
+}
+
+// DeleteUser 删除用户（仅管理员）
+func (r *resolver) DeleteUser(ctx context.Context, args struct{ ID graphqlgo.ID }) (bool, error) {
+	if err := requireRole(ctx, "admin"); err != nil {
+		return false, err
+	}
+
+	if err := r.userService.DeleteUser(ctx, string(args.ID)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// userResolver 用户字段解析器，字段与 REST 接口的用户响应一致
+type userResolver struct {
+	user dto.UserResponse
+}
+
+// newUserResolver 从用户模型创建用户解析器
+func newUserResolver(user *models.User) *userResolver {
+	return &userResolver{user: dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}}
+}
+
+func (r *userResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(strconv.FormatUint(uint64(r.user.ID), 10))
+}
+
+func (r *userResolver) Name() string  { return r.user.Name }
+func (r *userResolver) Email() string { return r.user.Email }
+func (r *userResolver) Role() string  { return r.user.Role }
+
+// AvatarURL 头像地址，未上传时为null
+func (r *userResolver) AvatarURL() *string {
+	if r.user.AvatarURL == "" {
+		return nil
+	}
+	return &r.user.AvatarURL
+}
+
+func (r *userResolver) CreatedAt() graphqlgo.Time { return graphqlgo.Time{Time: r.user.CreatedAt} }
+func (r *userResolver) UpdatedAt() graphqlgo.Time { return graphqlgo.Time{Time: r.user.UpdatedAt} }
+func (r *userResolver) Version() int32            { return int32(r.user.Version) }
+
+// userConnectionResolver 用户列表分页结果解析器，分页信息与 REST 列表响应一致
+type userConnectionResolver struct {
+	nodes []*userResolver
+	list  dto.ListResponse
+}
+
+func (r *userConnectionResolver) Nodes() []*userResolver { return r.nodes }
+func (r *userConnectionResolver) Page() int32            { return int32(r.list.Page) }
+func (r *userConnectionResolver) Size() int32            { return int32(r.list.Size) }
+func (r *userConnectionResolver) Total() int32           { return int32(r.list.Total) }
+func (r *userConnectionResolver) TotalPages() int32      { return int32(r.list.TotalPages) }
+func (r *userConnectionResolver) HasNext() bool          { return r.list.HasNext }
+func (r *userConnectionResolver) HasPrev() bool          { return r.list.HasPrev }
+
+// loginPayloadResolver 登录结果解析器
+type loginPayloadResolver struct {
+	resp *dto.LoginResponse
+}
+
+func (r *loginPayloadResolver) AccessToken() string  { return r.resp.AccessToken }
+func (r *loginPayloadResolver) RefreshToken() string { return r.resp.RefreshToken }
+func (r *loginPayloadResolver) ExpiresIn() int32     { return int32(r.resp.ExpiresIn) }
+func (r *loginPayloadResolver) TokenType() string    { return r.resp.TokenType }
+func (r *loginPayloadResolver) User() *userResolver  { return &userResolver{user: r.resp.User} }
+
+// stringValue 返回字符串指针的值，为nil时返回空字符串
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
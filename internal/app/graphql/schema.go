@@ -0,0 +1,72 @@
+package graphql
+
+// schemaSDL GraphQL 接口定义，字段与 REST 接口的请求和响应保持一致
+const schemaSDL = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+type Query {
+	# 获取用户详情（需要认证）
+	user(id: ID!): User!
+	# 分页获取用户列表（需要认证），page 默认为1，pageSize 默认为10
+	users(page: Int, pageSize: Int, search: String, role: String, sort: String): UserConnection!
+}
+
+type Mutation {
+	# 登录，无需认证
+	login(email: String!, password: String!): LoginPayload!
+	# 创建用户（仅管理员）
+	createUser(input: CreateUserInput!): User!
+	# 整体更新用户资料（需要认证），password 为空时保持不变
+	updateUser(id: ID!, input: UpdateUserInput!): User!
+	# 删除用户（仅管理员）
+	deleteUser(id: ID!): Boolean!
+}
+
+type User {
+	id: ID!
+	name: String!
+	email: String!
+	role: String!
+	avatarUrl: String
+	createdAt: Time!
+	updatedAt: Time!
+	version: Int!
+}
+
+type UserConnection {
+	nodes: [User!]!
+	page: Int!
+	size: Int!
+	total: Int!
+	totalPages: Int!
+	hasNext: Boolean!
+	hasPrev: Boolean!
+}
+
+type LoginPayload {
+	accessToken: String!
+	refreshToken: String!
+	expiresIn: Int!
+	tokenType: String!
+	user: User!
+}
+
+input CreateUserInput {
+	name: String!
+	email: String!
+	password: String!
+}
+
+input UpdateUserInput {
+	name: String!
+	email: String!
+	password: String
+	# 读取时的版本号，设置后与当前版本不一致时返回冲突
+	version: Int
+}
+`
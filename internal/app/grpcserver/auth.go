@@ -0,0 +1,79 @@
+package grpcserver
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	userv1 "github.com/vadxq/go-rest-starter/api/proto/user/v1"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// publicMethods 无需认证的方法
+var publicMethods = map[string]bool{
+	userv1.AuthService_Login_FullMethodName:        true,
+	userv1.AuthService_RefreshToken_FullMethodName: true,
+}
+
+// unaryAuthInterceptor JWT认证拦截器，与HTTP的 JWTAuth 中间件使用相同的令牌验证和黑名单
+// 令牌从元数据 authorization 中读取，用户ID、角色和权限范围写入上下文供处理器和服务层读取
+// blacklist为空（缓存不可用）时不检查令牌是否已登出
+func unaryAuthInterceptor(config *jwtpkg.Config, blacklist *jwtpkg.Blacklist) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		tokenString, err := bearerToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		claims, err := jwtpkg.ParseToken(tokenString, config)
+		if err != nil {
+			slog.Debug("解析令牌失败", "error", err, "method", info.FullMethod)
+			return nil, apperrors.UnauthorizedError("无效的认证令牌", err)
+		}
+
+		// 已登出或所属会话已撤销的令牌
+		if blacklist.IsRevoked(ctx, claims) {
+			return nil, apperrors.UnauthorizedError("认证令牌已失效", nil)
+		}
+
+		ctx = context.WithValue(ctx, custommiddleware.UserIDKey{}, claims.UserID)
+		ctx = context.WithValue(ctx, custommiddleware.RoleKey{}, claims.Role)
+		ctx = context.WithValue(ctx, custommiddleware.ScopeKey{}, claims.Scopes())
+		// 同时写入日志上下文，供服务层读取操作者（如审计日志）
+		ctx = logger.WithUserID(ctx, strconv.FormatUint(uint64(claims.UserID), 10))
+		return handler(ctx, req)
+	}
+}
+
+// bearerToken 从元数据 authorization 中读取 Bearer 令牌
+func bearerToken(ctx context.Context) (string, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 || values[0] == "" {
+		return "", apperrors.UnauthorizedError("缺少认证令牌", nil)
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || scheme != "Bearer" || token == "" {
+		return "", apperrors.UnauthorizedError("认证令牌格式无效", nil)
+	}
+	return token, nil
+}
+
+// requireRole 要求当前用户拥有指定角色
+func requireRole(ctx context.Context, role string) error {
+	if !custommiddleware.HasRole(ctx, role) {
+		return apperrors.ForbiddenError("没有权限访问", nil)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	userv1 "github.com/vadxq/go-rest-starter/api/proto/user/v1"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// authServer 认证服务的gRPC实现，与 REST 接口共用 AuthService
+type authServer struct {
+	userv1.UnimplementedAuthServiceServer
+
+	authService services.AuthService
+	validator   *validator.Validate
+}
+
+// Login 登录
+func (s *authServer) Login(ctx context.Context, req *userv1.LoginRequest) (*userv1.LoginResponse, error) {
+	input := dto.LoginRequest{Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := s.validator.Struct(input); err != nil {
+		return nil, apperrors.ValidationError("数据验证失败", err)
+	}
+
+	resp, err := s.authService.Login(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userv1.LoginResponse{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		TokenType:    resp.TokenType,
+		User:         protoUser(resp.User),
+	}, nil
+}
+
+// RefreshToken 刷新令牌
+func (s *authServer) RefreshToken(ctx context.Context, req *userv1.RefreshTokenRequest) (*userv1.TokenResponse, error) {
+	input := dto.RefreshTokenRequest{RefreshToken: req.GetRefreshToken()}
+	if err := s.validator.Struct(input); err != nil {
+		return nil, apperrors.ValidationError("数据验证失败", err)
+	}
+
+	resp, err := s.authService.RefreshToken(ctx, input.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &userv1.TokenResponse{
+		AccessToken:  resp.AccessToken,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+		TokenType:    resp.TokenType,
+	}, nil
+}
+
+// Logout 登出，使元数据中携带的访问令牌失效
+func (s *authServer) Logout(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authService.Logout(ctx, token); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,101 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// errorDomain 状态详情 ErrorInfo 中的错误域
+const errorDomain = "go-rest-starter"
+
+// statusCode 应用错误类型对应的gRPC状态码
+func statusCode(appErr *apperrors.Error) codes.Code {
+	switch appErr.Type {
+	case apperrors.ErrorTypeValidation, apperrors.ErrorTypeBadRequest:
+		return codes.InvalidArgument
+	case apperrors.ErrorTypeNotFound:
+		return codes.NotFound
+	case apperrors.ErrorTypeUnauthorized:
+		return codes.Unauthenticated
+	case apperrors.ErrorTypeForbidden:
+		return codes.PermissionDenied
+	case apperrors.ErrorTypeConflict:
+		// 版本冲突应重新读取后重试，其余冲突（如邮箱已被使用）为资源已存在
+		if appErr.Code == apperrors.CodeUserVersionConflict {
+			return codes.Aborted
+		}
+		return codes.AlreadyExists
+	case apperrors.ErrorTypeTooManyRequests, apperrors.ErrorTypePayloadTooLarge:
+		return codes.ResourceExhausted
+	case apperrors.ErrorTypeTimeout:
+		return codes.DeadlineExceeded
+	case apperrors.ErrorTypeServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus 将应用错误转换为gRPC状态
+// 错误类型和业务错误码写入 ErrorInfo 详情，字段错误写入 BadRequest 详情；非应用错误按内部错误处理，避免泄露错误详情
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		appErr = apperrors.InternalError("内部服务器错误", err)
+	}
+
+	code := statusCode(appErr)
+	if code == codes.Internal {
+		slog.Error(appErr.Message, "error", appErr, "type", string(appErr.Type))
+	} else {
+		slog.Debug(appErr.Message, "error", appErr, "type", string(appErr.Type))
+	}
+
+	reason := string(appErr.Code)
+	if reason == "" {
+		reason = string(appErr.Type)
+	}
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"type": string(appErr.Type)},
+	}}
+	if len(appErr.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, field := range appErr.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	st := status.New(code, appErr.LocalizedMessage(apperrors.DefaultLocale))
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// unaryErrorInterceptor 将处理器返回的应用错误转换为gRPC状态
+func unaryErrorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	return resp, toStatus(err)
+}
@@ -0,0 +1,79 @@
+// Package grpcserver 提供用户和认证服务的gRPC接口，与HTTP接口共用服务层
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"runtime/debug"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	userv1 "github.com/vadxq/go-rest-starter/api/proto/user/v1"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+// Server gRPC服务器
+type Server struct {
+	server *grpc.Server
+	logger *slog.Logger
+}
+
+// NewServer 创建注册了用户和认证服务的gRPC服务器
+// 拦截器依次为：恢复panic、应用错误转换为gRPC状态、JWT认证；c为令牌黑名单使用的缓存，与HTTP接口共用
+func NewServer(us services.UserService, as services.AuthService, v *validator.Validate, token *jwtpkg.Config, c cache.Cache, logger *slog.Logger, opts ...grpc.ServerOption) *Server {
+	s := &Server{logger: logger}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		s.unaryRecoveryInterceptor,
+		unaryErrorInterceptor,
+		unaryAuthInterceptor(token, jwtpkg.NewBlacklist(c, token.LeewayDuration)),
+	))
+	s.server = grpc.NewServer(opts...)
+
+	userv1.RegisterUserServiceServer(s.server, &userServer{userService: us, validator: v})
+	userv1.RegisterAuthServiceServer(s.server, &authServer{authService: as, validator: v})
+	return s
+}
+
+// Serve 在监听器上处理请求，直到服务器关闭
+func (s *Server) Serve(lis net.Listener) error {
+	if err := s.server.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		return fmt.Errorf("gRPC服务器错误: %w", err)
+	}
+	return nil
+}
+
+// Shutdown 停止接受新请求并等待进行中的请求完成，ctx结束时强制关闭
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("等待gRPC请求完成超时: %w", ctx.Err())
+	}
+}
+
+// unaryRecoveryInterceptor 恢复处理器中的panic并返回内部错误，避免服务器退出
+func (s *Server) unaryRecoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.ErrorContext(ctx, "gRPC处理器发生panic", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+			err = status.Error(codes.Internal, "内部服务器错误")
+		}
+	}()
+	return handler(ctx, req)
+}
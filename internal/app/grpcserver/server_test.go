@@ -0,0 +1,295 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	userv1 "github.com/vadxq/go-rest-starter/api/proto/user/v1"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+// stubUserService 内存中的用户服务，只实现gRPC接口用到的方法
+type stubUserService struct {
+	services.UserService
+	users     map[string]*models.User
+	deletedBy uint
+	scopes    []string // 最近一次查询用户时上下文中的权限范围
+}
+
+func newStubUserService() *stubUserService {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user", Version: 1}
+	user.ID = 1
+	user.CreatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &stubUserService{users: map[string]*models.User{"1": user}}
+}
+
+func (s *stubUserService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	s.scopes = custommiddleware.GetScopes(ctx)
+	user, ok := s.users[id]
+	if !ok {
+		return nil, apperrors.NotFoundError("用户", nil)
+	}
+	return user, nil
+}
+
+func (s *stubUserService) ListUsers(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	return []*models.User{s.users["1"]}, 11, nil
+}
+
+func (s *stubUserService) CreateUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error) {
+	user := &models.User{Name: input.Name, Email: input.Email, Role: "user", Version: 1}
+	user.ID = 2
+	s.users["2"] = user
+	return user, nil
+}
+
+func (s *stubUserService) UpdateUser(ctx context.Context, id string, input dto.UpdateUserInput) (*models.User, error) {
+	if input.Version != nil && *input.Version != s.users[id].Version {
+		return nil, apperrors.ConflictError("用户已被修改", nil).WithCode(apperrors.CodeUserVersionConflict)
+	}
+	return s.users[id], nil
+}
+
+func (s *stubUserService) DeleteUser(ctx context.Context, id string) error {
+	s.deletedBy, _ = custommiddleware.GetUserID(ctx)
+	delete(s.users, id)
+	return nil
+}
+
+// stubAuthService 只接受固定密码的认证服务，登出时将令牌加入黑名单
+type stubAuthService struct {
+	services.AuthService
+	token     *jwtpkg.Config
+	blacklist *jwtpkg.Blacklist
+	loggedOut string
+}
+
+func (s *stubAuthService) Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error) {
+	if req.Password != "password123" {
+		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil).WithCode(apperrors.CodeAuthInvalidCredentials)
+	}
+	return &dto.LoginResponse{AccessToken: "access", TokenType: "Bearer", User: dto.UserResponse{ID: 1, Email: req.Email}}, nil
+}
+
+func (s *stubAuthService) Logout(ctx context.Context, accessToken string) error {
+	s.loggedOut = accessToken
+	claims, err := jwtpkg.ParseToken(accessToken, s.token)
+	if err != nil {
+		return err
+	}
+	return s.blacklist.Revoke(ctx, claims)
+}
+
+// testClients 连接到进程内gRPC服务器的客户端
+type testClients struct {
+	users    userv1.UserServiceClient
+	auth     userv1.AuthServiceClient
+	userSvc  *stubUserService
+	authSvc  *stubAuthService
+	newToken func(userID uint, role string) string
+}
+
+// newTestClients 启动使用内存连接的gRPC服务器并返回客户端
+func newTestClients(t *testing.T) *testClients {
+	t.Helper()
+
+	tokenConfig := &jwtpkg.Config{
+		Secret:         "test-secret",
+		AccessTokenExp: time.Hour,
+		RoleScopes:     map[string][]string{"admin": {"users:read", "users:write"}},
+	}
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	userSvc := newStubUserService()
+	authSvc := &stubAuthService{token: tokenConfig, blacklist: jwtpkg.NewBlacklist(c, 0)}
+	srv := NewServer(userSvc, authSvc, validator.New(), tokenConfig, c, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testClients{
+		users:   userv1.NewUserServiceClient(conn),
+		auth:    userv1.NewAuthServiceClient(conn),
+		userSvc: userSvc,
+		authSvc: authSvc,
+		newToken: func(userID uint, role string) string {
+			token, err := jwtpkg.GenerateAccessToken(userID, role, "family", tokenConfig)
+			require.NoError(t, err)
+			return token
+		},
+	}
+}
+
+// withToken 在请求元数据中携带访问令牌
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// errorReason 读取状态详情中的错误原因
+func errorReason(t *testing.T, err error) string {
+	t.Helper()
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestUserService(t *testing.T) {
+	c := newTestClients(t)
+	userCtx := withToken(c.newToken(1, "user"))
+	adminCtx := withToken(c.newToken(9, "admin"))
+
+	// 已认证用户查询用户详情和列表
+	t.Run("GetAndList", func(t *testing.T) {
+		user, err := c.users.GetUser(userCtx, &userv1.GetUserRequest{Id: 1})
+		require.NoError(t, err)
+		assert.Equal(t, "test@example.com", user.GetEmail())
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), user.GetCreatedAt().AsTime())
+
+		list, err := c.users.ListUsers(userCtx, &userv1.ListUsersRequest{Page: 2})
+		require.NoError(t, err)
+		assert.Len(t, list.GetUsers(), 1)
+		assert.Equal(t, int32(10), list.GetSize())
+		assert.Equal(t, int32(2), list.GetTotalPages())
+		assert.True(t, list.GetHasPrev())
+		assert.False(t, list.GetHasNext())
+	})
+
+	// 缺少或无效的令牌返回 UNAUTHENTICATED
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := c.users.GetUser(context.Background(), &userv1.GetUserRequest{Id: 1})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		_, err = c.users.GetUser(withToken("invalid"), &userv1.GetUserRequest{Id: 1})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	// 令牌的权限范围写入上下文
+	t.Run("Scopes", func(t *testing.T) {
+		_, err := c.users.GetUser(adminCtx, &userv1.GetUserRequest{Id: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"users:read", "users:write"}, c.userSvc.scopes)
+	})
+
+	// 应用错误转换为对应的状态码和错误详情
+	t.Run("ErrorMapping", func(t *testing.T) {
+		_, err := c.users.GetUser(userCtx, &userv1.GetUserRequest{Id: 404})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Equal(t, "NOT_FOUND", errorReason(t, err))
+
+		_, err = c.users.GetUser(userCtx, &userv1.GetUserRequest{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		version := uint64(5)
+		_, err = c.users.UpdateUser(userCtx, &userv1.UpdateUserRequest{Id: 1, Name: "Test User", Email: "test@example.com", Version: &version})
+		assert.Equal(t, codes.Aborted, status.Code(err))
+		assert.Equal(t, string(apperrors.CodeUserVersionConflict), errorReason(t, err))
+	})
+
+	// 创建用户仅限管理员，输入沿用 REST 接口的校验规则
+	t.Run("CreateUser", func(t *testing.T) {
+		_, err := c.users.CreateUser(userCtx, &userv1.CreateUserRequest{Name: "New User", Email: "new@example.com", Password: "password123"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		_, err = c.users.CreateUser(adminCtx, &userv1.CreateUserRequest{Name: "N", Email: "invalid", Password: "password123"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+		var violations []*errdetails.BadRequest_FieldViolation
+		for _, detail := range status.Convert(err).Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				violations = badRequest.GetFieldViolations()
+			}
+		}
+		assert.Len(t, violations, 2)
+
+		user, err := c.users.CreateUser(adminCtx, &userv1.CreateUserRequest{Name: "New User", Email: "new@example.com", Password: "password123"})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), user.GetId())
+	})
+
+	// 删除时上下文中带有操作者
+	t.Run("DeleteUser", func(t *testing.T) {
+		_, err := c.users.DeleteUser(adminCtx, &userv1.DeleteUserRequest{Id: 2})
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), c.userSvc.deletedBy)
+		assert.NotContains(t, c.userSvc.users, "2")
+	})
+}
+
+func TestAuthService(t *testing.T) {
+	c := newTestClients(t)
+
+	// 登录无需认证
+	resp, err := c.auth.Login(context.Background(), &userv1.LoginRequest{Email: "test@example.com", Password: "password123"})
+	require.NoError(t, err)
+	assert.Equal(t, "access", resp.GetAccessToken())
+	assert.Equal(t, "test@example.com", resp.GetUser().GetEmail())
+
+	_, err = c.auth.Login(context.Background(), &userv1.LoginRequest{Email: "test@example.com", Password: "wrong-password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, string(apperrors.CodeAuthInvalidCredentials), errorReason(t, err))
+
+	// 登出使元数据中的访问令牌失效
+	token := c.newToken(1, "user")
+	_, err = c.auth.Logout(withToken(token), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, token, c.authSvc.loggedOut)
+
+	_, err = c.auth.Logout(context.Background(), &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// 登出后的令牌不能再调用需要认证的方法，其他令牌不受影响
+	_, err = c.users.GetUser(withToken(token), &userv1.GetUserRequest{Id: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.auth.Logout(withToken(token), &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = c.users.GetUser(withToken(c.newToken(1, "user")), &userv1.GetUserRequest{Id: 1})
+	assert.NoError(t, err)
+}
+
+func TestToStatus(t *testing.T) {
+	// 非应用错误按内部错误处理，不泄露错误详情
+	st := status.Convert(toStatus(assert.AnError))
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "内部服务器错误", st.Message())
+
+	// 邮箱冲突为资源已存在
+	assert.Equal(t, codes.AlreadyExists, status.Code(toStatus(apperrors.ConflictError("邮箱已被使用", nil).WithCode(apperrors.CodeUserEmailTaken))))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(toStatus(apperrors.TooManyRequestsError("请求过多", nil))))
+	assert.NoError(t, toStatus(nil))
+}
@@ -0,0 +1,168 @@
+package grpcserver
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	userv1 "github.com/vadxq/go-rest-starter/api/proto/user/v1"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// userServer 用户服务的gRPC实现，与 REST 接口共用 UserService 的业务逻辑和校验规则
+type userServer struct {
+	userv1.UnimplementedUserServiceServer
+
+	userService services.UserService
+	validator   *validator.Validate
+}
+
+// GetUser 获取用户详情
+func (s *userServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	id, err := userID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toProtoUser(user), nil
+}
+
+// ListUsers 分页获取用户列表，页码和每页大小无效时使用默认值，与 REST 接口一致
+func (s *userServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	page, pageSize := 1, 10
+	if req.GetPage() > 0 {
+		page = int(req.GetPage())
+	}
+	if req.GetPageSize() > 0 {
+		pageSize = int(req.GetPageSize())
+	}
+
+	opts := dto.UserListOptions{
+		ListUsersFilter: dto.ListUsersFilter{
+			Search: req.GetSearch(),
+			Role:   req.GetRole(),
+			Sort:   req.GetSort(),
+		},
+	}
+
+	users, total, err := s.userService.ListUsers(ctx, page, pageSize, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	list := dto.NewListResponse(nil, page, pageSize, total)
+	resp := &userv1.ListUsersResponse{
+		Users:      make([]*userv1.User, len(users)),
+		Page:       int32(list.Page),
+		Size:       int32(list.Size),
+		Total:      list.Total,
+		TotalPages: int32(list.TotalPages),
+		HasNext:    list.HasNext,
+		HasPrev:    list.HasPrev,
+	}
+	for i, user := range users {
+		resp.Users[i] = toProtoUser(user)
+	}
+	return resp, nil
+}
+
+// CreateUser 创建用户（仅管理员）
+func (s *userServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	if err := requireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+
+	input := dto.CreateUserInput{Name: req.GetName(), Email: req.GetEmail(), Password: req.GetPassword()}
+	if err := s.validator.Struct(input); err != nil {
+		return nil, apperrors.ValidationError("数据验证失败", err)
+	}
+
+	user, err := s.userService.CreateUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return toProtoUser(user), nil
+}
+
+// UpdateUser 整体更新用户资料
+func (s *userServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	id, err := userID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	input := dto.UpdateUserInput{Name: req.GetName(), Email: req.GetEmail(), Password: req.GetPassword()}
+	if req.Version != nil {
+		version := uint(req.GetVersion())
+		input.Version = &version
+	}
+
+	user, err := s.userService.UpdateUser(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	return toProtoUser(user), nil
+}
+
+// DeleteUser 删除用户（仅管理员）
+func (s *userServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := requireRole(ctx, "admin"); err != nil {
+		return nil, err
+	}
+
+	id, err := userID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userService.DeleteUser(ctx, id); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// userID 校验并转换请求中的用户ID
+func userID(id uint64) (string, error) {
+	if id == 0 {
+		return "", apperrors.BadRequestError("ID参数缺失", nil)
+	}
+	return strconv.FormatUint(id, 10), nil
+}
+
+// toProtoUser 将用户模型转换为gRPC消息
+func toProtoUser(user *models.User) *userv1.User {
+	return protoUser(dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	})
+}
+
+// protoUser 将用户响应转换为gRPC消息，字段与 REST 接口的用户响应一致
+func protoUser(user dto.UserResponse) *userv1.User {
+	return &userv1.User{
+		Id:        uint64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarUrl: user.AvatarURL,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+		Version:   uint64(user.Version),
+	}
+}
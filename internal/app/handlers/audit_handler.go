@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"log/slog"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// AuditHandler 处理审计日志相关的 HTTP 请求
+type AuditHandler struct {
+	auditService services.AuditService
+	logger       *slog.Logger
+}
+
+// NewAuditHandler 创建一个新的 AuditHandler 实例
+func NewAuditHandler(as services.AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: as,
+		logger:       logger,
+	}
+}
+
+// ListAuditLogs 获取审计日志列表
+// @Summary 获取审计日志列表
+// @Description 分页获取审计日志，最新的记录在前，支持按操作者、动作、实体和时间范围筛选（仅管理员）
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，默认为1" default(1)
+// @Param page_size query int false "每页大小，默认为10" default(10)
+// @Param actor_id query int false "操作者用户ID"
+// @Param action query string false "审计动作，如 user.create、user.update、user.delete、user.restore"
+// @Param entity_type query string false "实体类型，如 user"
+// @Param entity_id query int false "实体ID"
+// @Param since query string false "起始时间（含），RFC3339格式" example(2024-01-01T00:00:00Z)
+// @Param until query string false "截止时间（不含），RFC3339格式"
+// @Success 200 {object} Response{data=dto.ListResponse{data=[]dto.AuditLogResponse}}
+// @Failure 400,401,403 {object} Response{error=ErrorInfo}
+// @Failure 500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/audit [get]
+// @Security BearerAuth
+func (h *AuditHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	// 解析分页参数
+	page := 1
+	pageSize := 10
+
+	if pageVal, err := strconv.Atoi(query.Get("page")); err == nil && pageVal > 0 {
+		page = pageVal
+	}
+	if pageSizeVal, err := strconv.Atoi(query.Get("page_size")); err == nil && pageSizeVal > 0 {
+		pageSize = pageSizeVal
+	}
+
+	filter, err := parseAuditLogFilter(query)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	logs, total, err := h.auditService.List(r.Context(), page, pageSize, filter)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// 转换为 DTO
+	responses := make([]dto.AuditLogResponse, len(logs))
+	for i, log := range logs {
+		changes := make(map[string]dto.AuditFieldChange, len(log.Changes))
+		for field, change := range log.Changes {
+			changes[field] = dto.AuditFieldChange{Before: change.Before, After: change.After}
+		}
+		responses[i] = dto.AuditLogResponse{
+			ID:         log.ID,
+			ActorID:    log.ActorID,
+			Action:     log.Action,
+			EntityType: log.EntityType,
+			EntityID:   log.EntityID,
+			Changes:    changes,
+			RequestID:  log.RequestID,
+			CreatedAt:  log.CreatedAt,
+		}
+	}
+
+	RespondJSON(w, http.StatusOK, dto.NewListResponse(responses, page, pageSize, total))
+}
+
+// parseAuditLogFilter 解析审计日志筛选参数，ID或时间格式无效时返回请求错误
+func parseAuditLogFilter(query url.Values) (dto.AuditLogFilter, error) {
+	filter := dto.AuditLogFilter{
+		Action:     query.Get("action"),
+		EntityType: query.Get("entity_type"),
+	}
+
+	var err error
+	if filter.ActorID, err = parseOptionalID(query.Get("actor_id")); err != nil {
+		return filter, apperrors.BadRequestError("无效的操作者ID", err)
+	}
+	if filter.EntityID, err = parseOptionalID(query.Get("entity_id")); err != nil {
+		return filter, apperrors.BadRequestError("无效的实体ID", err)
+	}
+	if filter.Since, err = parseOptionalTime(query.Get("since")); err != nil {
+		return filter, apperrors.BadRequestError("无效的起始时间，应为RFC3339格式", err)
+	}
+	if filter.Until, err = parseOptionalTime(query.Get("until")); err != nil {
+		return filter, apperrors.BadRequestError("无效的截止时间，应为RFC3339格式", err)
+	}
+	return filter, nil
+}
+
+// parseOptionalID 解析可选的ID参数，参数为空时返回nil
+func parseOptionalID(value string) (*uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	result := uint(id)
+	return &result, nil
+}
+
+// parseOptionalTime 解析可选的RFC3339时间参数，参数为空时返回nil
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"log/slog"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 
 	"github.com/vadxq/go-rest-starter/internal/app/dto"
@@ -31,13 +32,13 @@ func NewAuthHandler(as services.AuthService, logger *slog.Logger, v *validator.V
 
 // Login 处理用户登录请求
 // @Summary 用户登录
-// @Description 通过邮箱和密码进行登录，并获取访问令牌
+// @Description 通过邮箱和密码进行登录，并获取访问令牌；连续登录失败达到阈值后账户将被临时锁定
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param body body dto.LoginRequest true "登录请求体"
 // @Success 200 {object} Response{data=dto.LoginResponse}
-// @Failure 400,401,500 {object} Response{error=ErrorInfo}
+// @Failure 400,401,429,500 {object} Response{error=ErrorInfo}
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req dto.LoginRequest
@@ -45,13 +46,17 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if err := BindJSON(r, &req, func(v interface{}) error {
 		return h.validator.Struct(v)
 	}); err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
+	// ClientIPResolver 中间件已将 RemoteAddr 设置为解析后的客户端IP
+	req.UserAgent = r.UserAgent()
+	req.IP = r.RemoteAddr
+
 	response, err := h.authService.Login(r.Context(), req)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
@@ -74,19 +79,105 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	if err := BindJSON(r, &req, func(v interface{}) error {
 		return h.validator.Struct(v)
 	}); err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
 	response, err := h.authService.RefreshToken(r.Context(), req.RefreshToken)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
+		return
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
+// Introspect 处理令牌内省请求
+// @Summary 令牌内省
+// @Description 返回访问令牌是否有效及其用户、角色、权限范围和过期时间，已过期或已登出的令牌返回 active=false；调用方的令牌需要 auth:introspect 权限范围
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.IntrospectRequest true "令牌内省请求体"
+// @Success 200 {object} Response{data=dto.IntrospectResponse}
+// @Failure 400,401,403,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/auth/introspect [post]
+// @Security BearerAuth
+func (h *AuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	var req dto.IntrospectRequest
+
+	if err := BindJSON(r, &req, func(v interface{}) error {
+		return h.validator.Struct(v)
+	}); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	response, err := h.authService.Introspect(r.Context(), req.Token)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	RespondJSON(w, http.StatusOK, response)
 }
 
+// ForgotPassword 处理忘记密码请求
+// @Summary 忘记密码
+// @Description 向邮箱发送密码重置邮件；无论邮箱是否已注册都返回成功，避免枚举注册邮箱
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ForgotPasswordRequest true "忘记密码请求体"
+// @Success 200 {object} Response
+// @Failure 400,429,500,503 {object} Response{error=ErrorInfo}
+// @Router /api/v1/auth/forgot-password [post]
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+
+	if err := BindJSON(r, &req, func(v interface{}) error {
+		return h.validator.Struct(v)
+	}); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), req); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	RespondJSON(w, http.StatusOK, nil)
+}
+
+// ResetPassword 处理重置密码请求
+// @Summary 重置密码
+// @Description 使用重置邮件中的令牌设置新密码，成功后令牌失效，并撤销用户已有的登录会话
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.ResetPasswordRequest true "重置密码请求体"
+// @Success 200 {object} Response
+// @Failure 400,429,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+
+	if err := BindJSON(r, &req, func(v interface{}) error {
+		return h.validator.Struct(v)
+	}); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	RespondJSON(w, http.StatusOK, nil)
+}
+
 // Logout 处理用户登出请求
 // @Summary 用户登出
 // @Description 使当前用户的访问令牌失效
@@ -98,29 +189,84 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 // @Router /api/v1/auth/logout [post]
 // @Security BearerAuth
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// 从Authorization头部获取访问令牌
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		RespondError(w, apperrors.UnauthorizedError("未提供授权令牌", nil))
+	accessToken, err := bearerToken(r)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	// 分离Bearer前缀和令牌
-	parts := strings.Split(authHeader, " ")
-	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-		RespondError(w, apperrors.UnauthorizedError("授权格式无效", nil))
+	// 调用服务执行登出
+	err = h.authService.Logout(r.Context(), accessToken)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	accessToken := parts[1]
+	// 成功登出返回204状态码
+	RespondJSON(w, http.StatusNoContent, nil)
+}
+
+// ListSessions 处理会话列表请求
+// @Summary 获取登录会话列表
+// @Description 返回当前用户在各设备上的活跃会话，包含设备、IP和登录时间，current标记当前请求所用的会话
+// @Tags auth
+// @Produce json
+// @Success 200 {object} Response{data=[]dto.SessionResponse}
+// @Failure 401,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/account/sessions [get]
+// @Security BearerAuth
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := bearerToken(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
 
-	// 调用服务执行登出
-	err := h.authService.Logout(r.Context(), accessToken)
+	sessions, err := h.authService.ListSessions(r.Context(), accessToken)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
+		return
+	}
+
+	RespondJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession 处理撤销会话请求
+// @Summary 撤销登录会话
+// @Description 撤销当前用户的指定会话，该会话的刷新令牌立即失效
+// @Tags auth
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 204 {object} nil
+// @Failure 401,404,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/account/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	accessToken, err := bearerToken(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), accessToken, chi.URLParam(r, "id")); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	// 成功登出返回204状态码
 	RespondJSON(w, http.StatusNoContent, nil)
 }
+
+// bearerToken 从Authorization头部获取访问令牌
+func bearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", apperrors.UnauthorizedError("未提供授权令牌", nil)
+	}
+
+	// 分离Bearer前缀和令牌
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return "", apperrors.UnauthorizedError("授权格式无效", nil)
+	}
+	return parts[1], nil
+}
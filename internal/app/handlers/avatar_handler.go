@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"log/slog"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// avatarFormField 上传表单中头像文件的字段名
+const avatarFormField = "avatar"
+
+// multipartOverhead 请求体上限在文件大小之外为表单边界和字段头预留的字节数
+const multipartOverhead = 64 << 10
+
+// sniffLen http.DetectContentType 最多读取的字节数
+const sniffLen = 512
+
+// AvatarConfig 头像上传限制
+type AvatarConfig struct {
+	MaxSize      int64    // 头像文件大小上限（字节），<=0时使用 DefaultAvatarConfig.MaxSize
+	AllowedTypes []string // 允许的内容类型，按文件内容识别，不信任客户端声明的类型；为空时使用 DefaultAvatarConfig.AllowedTypes
+}
+
+// DefaultAvatarConfig 默认头像上传限制
+var DefaultAvatarConfig = AvatarConfig{
+	MaxSize:      2 << 20,
+	AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+}
+
+// AvatarHandler 处理用户头像相关的 HTTP 请求
+type AvatarHandler struct {
+	avatarService services.AvatarService
+	logger        *slog.Logger
+	config        AvatarConfig
+}
+
+// NewAvatarHandler 创建一个新的 AvatarHandler 实例，config为空时使用默认上传限制
+func NewAvatarHandler(as services.AvatarService, logger *slog.Logger, config *AvatarConfig) *AvatarHandler {
+	if config == nil {
+		config = &DefaultAvatarConfig
+	}
+
+	h := &AvatarHandler{
+		avatarService: as,
+		logger:        logger,
+		config:        *config,
+	}
+	if h.config.MaxSize <= 0 {
+		h.config.MaxSize = DefaultAvatarConfig.MaxSize
+	}
+	if len(h.config.AllowedTypes) == 0 {
+		h.config.AllowedTypes = DefaultAvatarConfig.AllowedTypes
+	}
+	return h
+}
+
+// UploadAvatar 上传用户头像
+// @Summary 上传用户头像
+// @Description 以 multipart/form-data 上传头像图片，文件类型按内容识别，保存后返回带头像地址的用户信息，仅本人或管理员可以上传
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param avatar formData file true "头像图片（JPEG、PNG、GIF或WebP）"
+// @Success 200 {object} Response{data=dto.UserResponse}
+// @Failure 400,403,404,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/users/{id}/avatar [post]
+// @Security BearerAuth
+func (h *AvatarHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
+		return
+	}
+
+	// 限制整个请求体，超过上限的文件不会被完整读入内存或临时文件
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(h.config.MaxSize); err != nil {
+		RespondError(w, r, h.formError(err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile(avatarFormField)
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			RespondError(w, r, apperrors.BadRequestError(fmt.Sprintf("缺少头像文件字段 %s", avatarFormField), err))
+			return
+		}
+		RespondError(w, r, apperrors.BadRequestError("读取头像文件失败", err))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.config.MaxSize {
+		RespondError(w, r, h.tooLarge(nil))
+		return
+	}
+
+	// 按文件头识别内容类型，之后回到文件开头保存完整内容
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		RespondError(w, r, apperrors.BadRequestError("读取头像文件失败", err))
+		return
+	}
+	if n == 0 {
+		RespondError(w, r, apperrors.BadRequestError("头像文件不能为空", nil))
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !slices.Contains(h.config.AllowedTypes, contentType) {
+		appErr := apperrors.BadRequestError("不支持的头像文件类型", nil).WithCode(apperrors.CodeAvatarTypeNotAllowed)
+		appErr.Fields = []apperrors.FieldError{{
+			Field:   avatarFormField,
+			Tag:     "mimetype",
+			Message: fmt.Sprintf("文件类型为%s，只允许%s", contentType, strings.Join(h.config.AllowedTypes, "、")),
+		}}
+		RespondError(w, r, appErr)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		RespondError(w, r, apperrors.InternalError("读取头像文件失败", err))
+		return
+	}
+
+	user, err := h.avatarService.UpdateAvatar(r.Context(), userID, file, contentType)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// 转换为 DTO
+	response := dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
+// formError 将表单解析错误转换为应用错误
+func (h *AvatarHandler) formError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return h.tooLarge(err)
+	case errors.Is(err, http.ErrNotMultipart):
+		return apperrors.BadRequestError("请求必须为 multipart/form-data 格式", err)
+	default:
+		return apperrors.BadRequestError("解析上传表单失败", err)
+	}
+}
+
+// tooLarge 头像文件超过大小上限的错误
+func (h *AvatarHandler) tooLarge(err error) error {
+	appErr := apperrors.BadRequestError("头像文件过大", err).WithCode(apperrors.CodeAvatarTooLarge)
+	appErr.Fields = []apperrors.FieldError{{
+		Field:   avatarFormField,
+		Tag:     "max",
+		Message: fmt.Sprintf("不能超过 %d 字节", h.config.MaxSize),
+	}}
+	return appErr
+}
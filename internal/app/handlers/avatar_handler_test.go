@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// stubAvatarService 记录收到的头像内容并返回带头像地址的用户
+type stubAvatarService struct {
+	contentType string
+	data        []byte
+}
+
+func (s *stubAvatarService) UpdateAvatar(ctx context.Context, id string, file io.Reader, contentType string) (*models.User, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	s.contentType, s.data = contentType, data
+
+	user := &models.User{Name: "Test User", AvatarURL: "/uploads/avatars/" + id + "/a.png"}
+	user.ID = 1
+	return user, nil
+}
+
+// pngImage 生成一张小的PNG图片
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.White)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// uploadAvatar 以multipart表单上传文件并返回响应
+func uploadAvatar(t *testing.T, h *AvatarHandler, field string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, "avatar.png")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/1/avatar", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	rec := httptest.NewRecorder()
+	h.UploadAvatar(rec, req)
+	return rec
+}
+
+// errorInfo 解析错误响应中的错误信息
+func errorInfo(t *testing.T, rec *httptest.ResponseRecorder) ErrorInfo {
+	t.Helper()
+	var resp struct {
+		Data ErrorInfo `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+func TestAvatarHandler_UploadAvatar(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// PNG图片按内容识别类型后保存
+	t.Run("ValidImage", func(t *testing.T) {
+		service := &stubAvatarService{}
+		h := NewAvatarHandler(service, logger, nil)
+		img := pngImage(t)
+
+		rec := uploadAvatar(t, h, "avatar", img)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "image/png", service.contentType)
+		assert.Equal(t, img, service.data)
+		assert.Contains(t, rec.Body.String(), `"avatar_url":"/uploads/avatars/1/a.png"`)
+	})
+
+	// 超过大小上限的文件被拒绝
+	t.Run("Oversized", func(t *testing.T) {
+		service := &stubAvatarService{}
+		h := NewAvatarHandler(service, logger, &AvatarConfig{MaxSize: 1024})
+
+		rec := uploadAvatar(t, h, "avatar", append(pngImage(t), make([]byte, 2048)...))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		info := errorInfo(t, rec)
+		assert.Equal(t, string(apperrors.CodeAvatarTooLarge), info.Code)
+		require.Len(t, info.Fields, 1)
+		assert.Equal(t, "不能超过 1024 字节", info.Fields[0].Message)
+		assert.Nil(t, service.data)
+
+		// 请求体远超上限时在读取表单阶段拒绝
+		rec = uploadAvatar(t, h, "avatar", make([]byte, 256<<10))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, string(apperrors.CodeAvatarTooLarge), errorInfo(t, rec).Code)
+	})
+
+	// 内容不是允许的图片类型时拒绝，不信任文件名和声明的类型
+	t.Run("DisallowedType", func(t *testing.T) {
+		service := &stubAvatarService{}
+		h := NewAvatarHandler(service, logger, nil)
+
+		rec := uploadAvatar(t, h, "avatar", []byte("%PDF-1.4 not an image"))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, string(apperrors.CodeAvatarTypeNotAllowed), errorInfo(t, rec).Code)
+		assert.Nil(t, service.data)
+	})
+
+	// 缺少头像字段
+	t.Run("MissingFile", func(t *testing.T) {
+		h := NewAvatarHandler(&stubAvatarService{}, logger, nil)
+		rec := uploadAvatar(t, h, "file", pngImage(t))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
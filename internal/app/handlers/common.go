@@ -1,12 +1,22 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
+	"math"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
 // Response 标准API响应结构
@@ -19,9 +29,11 @@ type Response struct {
 
 // ErrorInfo 错误信息结构
 type ErrorInfo struct {
-	Type    string   `json:"type"`
-	Message string   `json:"message"`
-	Fields  []string `json:"fields,omitempty"`
+	Type    string                 `json:"type"`
+	Code    string                 `json:"code,omitempty"` // 业务错误码，如 USER_EMAIL_TAKEN
+	Message string                 `json:"message"`
+	Fields  []apperrors.FieldError `json:"fields,omitempty"`
+	TraceID string                 `json:"trace_id,omitempty"` // 链路追踪ID，与 X-Trace-ID 响应头相同，便于排查问题
 }
 
 // RespondJSON 发送JSON响应
@@ -42,8 +54,116 @@ func RespondJSON(w http.ResponseWriter, status int, data interface{}) {
 	}
 }
 
-// RespondError 发送错误响应
-func RespondError(w http.ResponseWriter, err error) {
+// RespondJSONWithETag 发送带ETag的JSON响应
+// 根据数据内容生成ETag，请求的If-None-Match命中时返回304且不发送响应体
+func RespondJSONWithETag(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	etag, err := GenerateETag(data)
+	if err != nil {
+		slog.Error("生成ETag失败", "error", err)
+		RespondJSON(w, status, data)
+		return
+	}
+
+	respondWithETag(w, r, status, data, etag)
+}
+
+// RespondJSONWithVersion 发送以版本号为ETag（W/"<version>"）的JSON响应，用于带乐观锁版本号的资源
+// 客户端可将收到的ETag原样放入If-Match请求头更新资源，见 IfMatchVersion
+func RespondJSONWithVersion(w http.ResponseWriter, r *http.Request, status int, data interface{}, version uint) {
+	respondWithETag(w, r, status, data, VersionETag(version))
+}
+
+// VersionETag 根据资源版本号生成弱ETag
+func VersionETag(version uint) string {
+	return `W/"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// respondWithETag 设置ETag并发送JSON响应，请求的If-None-Match命中时返回304且不发送响应体
+func respondWithETag(w http.ResponseWriter, r *http.Request, status int, data interface{}, etag string) {
+	w.Header().Set("ETag", etag)
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	RespondJSON(w, status, data)
+}
+
+// GenerateETag 根据数据的JSON序列化结果生成弱ETag
+// 响应可能被压缩中间件改写编码，因此使用弱校验
+func GenerateETag(data interface{}) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// etagMatch 判断If-None-Match是否匹配ETag（弱比较）
+func etagMatch(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	target := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatchVersion 从If-Match请求头解析资源版本号，支持 3、"3" 和 W/"3"（即 VersionETag 生成的ETag），未设置时返回nil
+func IfMatchVersion(r *http.Request) (*uint, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return nil, nil
+	}
+
+	value = strings.Trim(strings.TrimPrefix(value, "W/"), `"`)
+	version, err := strconv.ParseUint(value, 10, strconv.IntSize)
+	if err != nil {
+		return nil, apperrors.BadRequestError("无效的If-Match版本号", err)
+	}
+
+	v := uint(version)
+	return &v, nil
+}
+
+// RequestLocale 根据 Accept-Language 请求头选择错误信息的语言
+// 按权重从高到低匹配支持的语言（只比较主语言标签，如 en-US 匹配 en），均不支持或r为空时使用默认语言
+func RequestLocale(r *http.Request) string {
+	if r == nil {
+		return apperrors.DefaultLocale
+	}
+
+	locale, best := apperrors.DefaultLocale, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > best && apperrors.IsSupportedLocale(primary) {
+			locale, best = primary, q
+		}
+	}
+	return locale
+}
+
+// RespondError 发送错误响应，错误信息按请求的 Accept-Language 本地化
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
 	var appErr *apperrors.Error
 
 	// 尝试将err转换为应用错误类型
@@ -55,12 +175,22 @@ func RespondError(w http.ResponseWriter, err error) {
 	// 获取HTTP状态码
 	status := appErr.StatusCode()
 
+	// 本地化错误信息
+	locale := RequestLocale(r)
+	message := appErr.LocalizedMessage(locale)
+
 	// 构建错误响应
 	response := Response{
 		Code:    status,
 		Success: false,
-		Msg:     appErr.Message,
-		Data:    appErr, // 将错误信息放入data字段
+		Msg:     message,
+		Data: ErrorInfo{ // 将错误信息放入data字段
+			Type:    string(appErr.Type),
+			Code:    string(appErr.Code),
+			Message: message,
+			Fields:  appErr.Fields,
+			TraceID: requestTraceID(r),
+		},
 	}
 
 	// 记录错误
@@ -70,8 +200,15 @@ func RespondError(w http.ResponseWriter, err error) {
 		slog.Debug(appErr.Message, "error", appErr, "type", string(appErr.Type))
 	}
 
+	// 断路器打开时告知客户端何时重试
+	var openErr *apperrors.CircuitOpenError
+	if errors.As(err, &openErr) {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(openErr.ResetAt)))
+	}
+
 	// 发送响应
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", locale)
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -80,18 +217,143 @@ func RespondError(w http.ResponseWriter, err error) {
 	}
 }
 
-// DecodeJSON 从请求体解析JSON数据
+// requestTraceID 请求的链路追踪ID，未设置时为请求ID，r为空时返回空字符串
+func requestTraceID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	return logger.GetTraceID(r.Context())
+}
+
+// retryAfterSeconds 距离重试时间的秒数，向上取整且至少为1
+func retryAfterSeconds(at time.Time) int {
+	seconds := int(math.Ceil(time.Until(at).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// DecodeOptions JSON请求体解析选项
+type DecodeOptions struct {
+	// 请求体包含结构体中未定义的字段时返回错误
+	DisallowUnknownFields bool
+	// 请求体最大字节数，<=0时不限制
+	MaxBodyBytes int64
+}
+
+// DefaultDecodeOptions 默认解析选项，忽略未知字段，便于客户端在新旧版本间兼容
+var DefaultDecodeOptions = DecodeOptions{
+	MaxBodyBytes: 1 << 20,
+}
+
+// StrictDecodeOptions 拒绝未知字段的解析选项，供需要发现字段名拼写错误的处理器按需使用
+var StrictDecodeOptions = DecodeOptions{
+	DisallowUnknownFields: true,
+	MaxBodyBytes:          1 << 20,
+}
+
+// DecodeJSON 使用默认选项从请求体解析JSON数据
 func DecodeJSON(r *http.Request, v interface{}) error {
-	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
-		return apperrors.BadRequestError("无效的JSON数据", err)
+	return DecodeJSONWithOptions(r, v, &DefaultDecodeOptions)
+}
+
+// DecodeJSONWithOptions 从请求体解析JSON数据，opts为空时使用默认选项
+// 请求体为空、语法错误、字段类型不匹配和未知字段分别返回说明原因的错误请求错误，请求体过大时返回413
+func DecodeJSONWithOptions(r *http.Request, v interface{}, opts *DecodeOptions) error {
+	if opts == nil {
+		opts = &DefaultDecodeOptions
+	}
+
+	body := r.Body
+	if opts.MaxBodyBytes > 0 {
+		body = http.MaxBytesReader(nil, body, opts.MaxBodyBytes)
+	}
+
+	decoder := json.NewDecoder(body)
+	if opts.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+
+	if err := decoder.Decode(v); err != nil {
+		return jsonDecodeError(err)
+	}
+
+	// 请求体只能包含一个JSON值
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return jsonDecodeError(err)
+		}
+		return apperrors.BadRequestError("请求体只能包含一个JSON值", err)
 	}
 	return nil
 }
 
-// BindJSON 从请求体解析JSON并验证
+// jsonDecodeError 将JSON解析错误转换为包含字段和位置（请求体中的字节偏移）的错误请求错误
+func jsonDecodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+	var invalidErr *json.InvalidUnmarshalError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return apperrors.BadRequestError("请求体不能为空", err)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperrors.BadRequestError("JSON数据不完整", err)
+	case errors.As(err, &syntaxErr):
+		return apperrors.BadRequestError(fmt.Sprintf("JSON语法错误（位置 %d）", syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return apperrors.BadRequestError(fmt.Sprintf("请求体应为%s（位置 %d）", jsonTypeName(typeErr.Type), typeErr.Offset), err)
+		}
+		return apperrors.BadRequestError(fmt.Sprintf("字段 %s 的类型错误，应为%s（位置 %d）", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Offset), err)
+	case errors.As(err, &maxBytesErr):
+		return apperrors.PayloadTooLargeError(fmt.Sprintf("请求体过大，不能超过 %d 字节", maxBytesErr.Limit), err)
+	case errors.As(err, &invalidErr):
+		return apperrors.InternalError("解析请求体失败", err)
+	}
+
+	// encoding/json 未导出未知字段错误的类型，只能按错误信息识别
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return apperrors.BadRequestError(fmt.Sprintf("不支持的字段 %s", strings.Trim(field, `"`)), err)
+	}
+	return apperrors.BadRequestError("无效的JSON数据", err)
+}
+
+// jsonTypeName 字段期望的JSON类型名称
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "字符串"
+	case reflect.Bool:
+		return "布尔值"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "整数"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "非负整数"
+	case reflect.Float32, reflect.Float64:
+		return "数字"
+	case reflect.Slice, reflect.Array:
+		return "数组"
+	default:
+		return "对象"
+	}
+}
+
+// BindJSON 使用默认选项从请求体解析JSON并验证
 func BindJSON(r *http.Request, v interface{}, validate func(interface{}) error) error {
+	return BindJSONWithOptions(r, v, &DefaultDecodeOptions, validate)
+}
+
+// BindJSONWithOptions 按解析选项从请求体解析JSON并验证，opts为空时使用默认选项
+func BindJSONWithOptions(r *http.Request, v interface{}, opts *DecodeOptions, validate func(interface{}) error) error {
 	// 解析JSON
-	if err := DecodeJSON(r, v); err != nil {
+	if err := DecodeJSONWithOptions(r, v, opts); err != nil {
 		return err
 	}
 
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+// errorResponse 错误响应解析结构
+type errorResponse struct {
+	Code    int       `json:"code"`
+	Success bool      `json:"success"`
+	Msg     string    `json:"msg"`
+	Data    ErrorInfo `json:"data"`
+}
+
+func TestRespondError_ValidationFields(t *testing.T) {
+	validate := utils.NewValidator()
+
+	// 同时违反多个约束的创建用户请求
+	body := `{"name":"A","email":"not-an-email"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+
+	var input dto.CreateUserInput
+	err := BindJSON(req, &input, validate.Struct)
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	RespondError(rec, req, err)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Success)
+	assert.Equal(t, string(apperrors.ErrorTypeValidation), resp.Data.Type)
+
+	// 每个失败字段都有字段名、规则和原因，字段名使用json标签
+	assert.Equal(t, []apperrors.FieldError{
+		{Field: "name", Tag: "min", Message: "长度不能小于2"},
+		{Field: "email", Tag: "email", Message: "必须是有效的邮箱地址"},
+		{Field: "password", Tag: "required", Message: "不能为空"},
+	}, resp.Data.Fields)
+}
+
+func TestRespondError_WithoutFields(t *testing.T) {
+	// 非校验错误不包含字段信息
+	t.Run("NotFound", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		RespondError(rec, nil, apperrors.NotFoundError("用户", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.NotContains(t, rec.Body.String(), `"fields"`)
+
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, string(apperrors.ErrorTypeNotFound), resp.Data.Type)
+		assert.Equal(t, "用户 not found", resp.Data.Message)
+	})
+
+	// 包装非validator错误的验证错误
+	t.Run("PlainValidationError", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		RespondError(rec, nil, apperrors.ValidationError("输入数据验证失败", errors.New("boom")))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.NotContains(t, rec.Body.String(), `"fields"`)
+	})
+}
+
+func TestRespondError_StatusMapping(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Validation", apperrors.ValidationError("输入数据验证失败", nil), http.StatusBadRequest},
+		{"NotFound", apperrors.NotFoundError("用户", nil), http.StatusNotFound},
+		{"Unauthorized", apperrors.UnauthorizedError("未认证", nil), http.StatusUnauthorized},
+		{"Forbidden", apperrors.ForbiddenError("权限不足", nil), http.StatusForbidden},
+		{"Internal", apperrors.InternalError("内部错误", nil), http.StatusInternalServerError},
+		{"BadRequest", apperrors.BadRequestError("无效的请求", nil), http.StatusBadRequest},
+		{"Conflict", apperrors.ConflictError("邮箱已被注册", nil), http.StatusConflict},
+		{"TooManyRequests", apperrors.TooManyRequestsError("请求过于频繁", nil), http.StatusTooManyRequests},
+		{"ServiceUnavailable", apperrors.ServiceUnavailableError("数据库暂时不可用", nil), http.StatusServiceUnavailable},
+		{"UnknownType", apperrors.New("UNKNOWN", "未知错误", nil), http.StatusInternalServerError},
+		// 被包装的应用错误仍按原类型映射
+		{"Wrapped", fmt.Errorf("获取用户: %w", apperrors.NotFoundError("用户", nil)), http.StatusNotFound},
+		// 非应用错误按内部错误处理
+		{"Plain", errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RespondError(rec, nil, tc.err)
+
+			assert.Equal(t, tc.status, rec.Code)
+
+			var resp errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tc.status, resp.Code)
+			assert.False(t, resp.Success)
+
+			// AsError 与 RespondError 对同一错误得到相同的状态码
+			assert.Equal(t, tc.status, apperrors.AsError(tc.err).StatusCode())
+		})
+	}
+}
+
+// 断路器打开导致的错误返回503，并通过Retry-After告知重试时间
+func TestRespondError_CircuitOpen(t *testing.T) {
+	openErr := &apperrors.CircuitOpenError{ResetAt: time.Now().Add(10 * time.Second)}
+	rec := httptest.NewRecorder()
+	RespondError(rec, nil, apperrors.ServiceUnavailableError("数据库暂时不可用", openErr))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+
+	// 重置时间已过时至少等待1秒
+	rec = httptest.NewRecorder()
+	RespondError(rec, nil, apperrors.ServiceUnavailableError("数据库暂时不可用", &apperrors.CircuitOpenError{ResetAt: time.Now()}))
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+}
+
+func TestRespondError_ErrorCode(t *testing.T) {
+	// 业务错误码原样输出到响应的 code 字段
+	t.Run("WithCode", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		RespondError(rec, nil, apperrors.ConflictError("邮箱已被注册", nil).WithCode(apperrors.CodeUserEmailTaken))
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, string(apperrors.ErrorTypeConflict), resp.Data.Type)
+		assert.Equal(t, "USER_EMAIL_TAKEN", resp.Data.Code)
+	})
+
+	// 被包装的错误同样保留错误码，校验字段与错误码可同时存在
+	t.Run("WrappedWithFields", func(t *testing.T) {
+		err := utils.ValidatePasswordStrength("short", utils.DefaultPasswordPolicy)
+		rec := httptest.NewRecorder()
+		RespondError(rec, nil, fmt.Errorf("修改密码: %w", err))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "PASSWORD_TOO_WEAK", resp.Data.Code)
+		assert.NotEmpty(t, resp.Data.Fields)
+	})
+
+	// 未设置错误码时不输出 code 字段
+	t.Run("WithoutCode", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		RespondError(rec, nil, apperrors.NotFoundError("用户", nil))
+
+		assert.NotContains(t, rec.Body.String(), `"code":"`)
+	})
+}
+
+func TestRequestLocale(t *testing.T) {
+	cases := map[string]string{
+		"":                          apperrors.LocaleZH,
+		"en":                        apperrors.LocaleEN,
+		"zh":                        apperrors.LocaleZH,
+		"en-US,en;q=0.9":            apperrors.LocaleEN,
+		"fr-FR, en;q=0.8, zh;q=0.5": apperrors.LocaleEN,
+		"zh-CN;q=0.6, en;q=0.4":     apperrors.LocaleZH,
+		"en;q=0.3, zh-TW;q=0.9":     apperrors.LocaleZH,
+		"fr, de":                    apperrors.DefaultLocale, // 均不支持时使用默认语言
+		"en;q=0":                    apperrors.DefaultLocale, // 权重为0表示不接受
+		"en;q=abc":                  apperrors.DefaultLocale,
+	}
+	for header, want := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Language", header)
+		assert.Equal(t, want, RequestLocale(req), "Accept-Language: %q", header)
+	}
+}
+
+func TestRespondError_Localized(t *testing.T) {
+	respond := func(acceptLanguage string, err error) (*httptest.ResponseRecorder, errorResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		rec := httptest.NewRecorder()
+		RespondError(rec, req, err)
+
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return rec, resp
+	}
+	emailTaken := apperrors.ConflictError("邮箱已被注册", nil).WithCode(apperrors.CodeUserEmailTaken)
+
+	// 英文请求按错误码返回英文信息
+	t.Run("English", func(t *testing.T) {
+		rec, resp := respond("en-US,en;q=0.9", emailTaken)
+
+		assert.Equal(t, "Email is already registered", resp.Msg)
+		assert.Equal(t, "Email is already registered", resp.Data.Message)
+		assert.Equal(t, "USER_EMAIL_TAKEN", resp.Data.Code)
+		assert.Equal(t, apperrors.LocaleEN, rec.Header().Get("Content-Language"))
+	})
+
+	// 中文请求返回中文信息
+	t.Run("Chinese", func(t *testing.T) {
+		rec, resp := respond("zh-CN", emailTaken)
+
+		assert.Equal(t, "邮箱已被注册", resp.Data.Message)
+		assert.Equal(t, apperrors.LocaleZH, rec.Header().Get("Content-Language"))
+	})
+
+	// 不支持的语言回退到默认语言
+	t.Run("Fallback", func(t *testing.T) {
+		_, resp := respond("fr", emailTaken)
+
+		assert.Equal(t, "邮箱已被注册", resp.Data.Message)
+	})
+
+	// 没有错误码时英文使用错误类型的通用信息，默认语言保留原始信息
+	t.Run("WithoutCode", func(t *testing.T) {
+		_, en := respond("en", apperrors.BadRequestError("无效的用户ID", nil))
+		assert.Equal(t, "Bad request", en.Data.Message)
+
+		_, zh := respond("zh", apperrors.BadRequestError("无效的用户ID", nil))
+		assert.Equal(t, "无效的用户ID", zh.Data.Message)
+	})
+}
+
+func TestRespondJSONWithETag(t *testing.T) {
+	user := dto.UserResponse{
+		ID:        1,
+		Name:      "Test User",
+		Email:     "test@example.com",
+		Role:      "user",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	doGet := func(data interface{}, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		RespondJSONWithETag(rec, req, http.StatusOK, data)
+		return rec
+	}
+
+	// 首次请求返回完整响应体和ETag
+	fresh := doGet(user, "")
+	require.Equal(t, http.StatusOK, fresh.Code)
+	etag := fresh.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Contains(t, fresh.Body.String(), `"email":"test@example.com"`)
+
+	// 条件请求命中时返回304且无响应体
+	t.Run("NotModified", func(t *testing.T) {
+		rec := doGet(user, etag)
+
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Zero(t, rec.Body.Len())
+	})
+
+	// If-None-Match 包含多个ETag或使用强校验格式时同样命中
+	t.Run("NotModifiedList", func(t *testing.T) {
+		strong := strings.TrimPrefix(etag, "W/")
+		assert.Equal(t, http.StatusNotModified, doGet(user, `"other", `+strong).Code)
+	})
+
+	// 资源更新后ETag变化，返回新内容
+	t.Run("ModifiedAfterUpdate", func(t *testing.T) {
+		updated := user
+		updated.UpdatedAt = updated.UpdatedAt.Add(time.Hour)
+
+		rec := doGet(updated, etag)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	})
+}
+
+func TestRespondJSONWithVersion(t *testing.T) {
+	doGet := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		RespondJSONWithVersion(rec, req, http.StatusOK, dto.UserResponse{ID: 1, Version: 7}, 7)
+		return rec
+	}
+
+	rec := doGet("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `W/"7"`, rec.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusNotModified, doGet(`W/"7"`).Code)
+	assert.Equal(t, http.StatusOK, doGet(`W/"6"`).Code)
+
+	// 生成的ETag可直接作为If-Match解析出版本号
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	req.Header.Set("If-Match", rec.Header().Get("ETag"))
+	version, err := IfMatchVersion(req)
+	require.NoError(t, err)
+	require.NotNil(t, version)
+	assert.Equal(t, uint(7), *version)
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	// 支持不带引号、带引号和弱校验格式
+	t.Run("Valid", func(t *testing.T) {
+		for _, header := range []string{`3`, `"3"`, `W/"3"`, ` "3" `} {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			req.Header.Set("If-Match", header)
+
+			version, err := IfMatchVersion(req)
+			require.NoError(t, err, header)
+			require.NotNil(t, version, header)
+			assert.Equal(t, uint(3), *version, header)
+		}
+	})
+
+	// 未设置时返回nil，不校验版本
+	t.Run("Missing", func(t *testing.T) {
+		version, err := IfMatchVersion(httptest.NewRequest(http.MethodPut, "/", nil))
+		require.NoError(t, err)
+		assert.Nil(t, version)
+	})
+
+	// 非数字版本号返回请求错误
+	t.Run("Invalid", func(t *testing.T) {
+		for _, header := range []string{`*`, `"abc"`, `-1`} {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			req.Header.Set("If-Match", header)
+
+			_, err := IfMatchVersion(req)
+			var appErr *apperrors.Error
+			require.ErrorAs(t, err, &appErr, header)
+			assert.Equal(t, apperrors.ErrorTypeBadRequest, appErr.Type, header)
+		}
+	})
+}
+
+func TestDecodeJSON_Errors(t *testing.T) {
+	type profile struct {
+		City string `json:"city"`
+	}
+	type input struct {
+		Name    string   `json:"name"`
+		Age     int      `json:"age"`
+		Tags    []string `json:"tags"`
+		Profile profile  `json:"profile"`
+	}
+
+	cases := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{"EmptyBody", ``, "请求体不能为空"},
+		{"Truncated", `{"name":"张三"`, "JSON数据不完整"},
+		{"Syntax", `{"name":"张三",}`, "JSON语法错误（位置 18）"},
+		{"FieldType", `{"name":"张三","age":"18"}`, "字段 age 的类型错误，应为整数（位置 27）"},
+		{"NestedFieldType", `{"profile":{"city":1}}`, "字段 profile.city 的类型错误，应为字符串（位置 20）"},
+		{"ArrayType", `{"tags":"a"}`, "字段 tags 的类型错误，应为数组（位置 11）"},
+		{"TopLevelType", `[1,2]`, "请求体应为对象（位置 1）"},
+		{"UnknownField", `{"name":"张三","role":"admin"}`, "不支持的字段 role"},
+		{"TrailingData", `{"name":"张三"}{"name":"李四"}`, "请求体只能包含一个JSON值"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			var v input
+			appErr := apperrors.AsError(DecodeJSONWithOptions(req, &v, &StrictDecodeOptions))
+			require.NotNil(t, appErr)
+			assert.Equal(t, apperrors.ErrorTypeBadRequest, appErr.Type)
+			assert.Equal(t, tc.message, appErr.Message)
+		})
+	}
+
+	// 超过大小限制的请求体，包括在第一个JSON值之后超过限制的情况
+	t.Run("TooLarge", func(t *testing.T) {
+		opts := &DecodeOptions{MaxBodyBytes: 16}
+		for _, body := range []string{`{"name":"` + strings.Repeat("a", 32) + `"}`, `{"name":"a"}` + strings.Repeat(" ", 32)} {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+			var v input
+			appErr := apperrors.AsError(DecodeJSONWithOptions(req, &v, opts))
+			require.NotNil(t, appErr)
+			assert.Equal(t, apperrors.ErrorTypePayloadTooLarge, appErr.Type)
+			assert.Equal(t, "请求体过大，不能超过 16 字节", appErr.Message)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"`+strings.Repeat("a", 32)+`"}`))
+		rec := httptest.NewRecorder()
+		var v input
+		RespondError(rec, req, DecodeJSONWithOptions(req, &v, opts))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+
+	// 默认忽略未知字段，处理器通过 StrictDecodeOptions 开启检查
+	t.Run("UnknownFieldsOptIn", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"张三","role":"admin"}`))
+
+		var v input
+		require.NoError(t, DecodeJSON(req, &v))
+		assert.Equal(t, "张三", v.Name)
+
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"张三","role":"admin"}`))
+		assert.Error(t, BindJSONWithOptions(req, &v, &StrictDecodeOptions, nil))
+	})
+
+	// 错误信息通过响应返回给客户端
+	t.Run("Response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"age":true}`))
+
+		var v input
+		rec := httptest.NewRecorder()
+		RespondError(rec, req, DecodeJSON(req, &v))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "字段 age 的类型错误，应为整数（位置 11）", resp.Data.Message)
+	})
+}
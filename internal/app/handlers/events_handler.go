@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// eventsTopicParam 订阅主题的查询参数名，可重复指定以订阅多个主题
+const eventsTopicParam = "topic"
+
+// EventsConfig 实时事件推送配置
+type EventsConfig struct {
+	Topics    []string      // 允许客户端订阅的主题，为空时拒绝所有订阅
+	Heartbeat time.Duration // 心跳间隔，保持连接不被代理断开，<=0时使用 DefaultEventsConfig.Heartbeat
+}
+
+// DefaultEventsConfig 默认实时事件推送配置
+var DefaultEventsConfig = EventsConfig{
+	Heartbeat: 15 * time.Second,
+}
+
+// EventsHandler 通过服务器推送事件（SSE）向客户端推送队列消息
+type EventsHandler struct {
+	broker *queue.Broker
+	logger *slog.Logger
+	config EventsConfig
+
+	closeOnce sync.Once
+	closing   chan struct{}
+}
+
+// NewEventsHandler 创建一个新的 EventsHandler 实例，config为空时使用默认配置
+func NewEventsHandler(broker *queue.Broker, logger *slog.Logger, config *EventsConfig) *EventsHandler {
+	if config == nil {
+		config = &DefaultEventsConfig
+	}
+
+	h := &EventsHandler{
+		broker:  broker,
+		logger:  logger,
+		config:  *config,
+		closing: make(chan struct{}),
+	}
+	if h.config.Heartbeat <= 0 {
+		h.config.Heartbeat = DefaultEventsConfig.Heartbeat
+	}
+	return h
+}
+
+// Stream 订阅实时事件
+// @Summary 订阅实时事件
+// @Description 以 text/event-stream 格式持续推送订阅主题的消息，事件名为主题，数据为消息负载；定期发送心跳注释保持连接
+// @Tags events
+// @Produce text/event-stream
+// @Param topic query []string true "订阅的主题，可重复指定" collectionFormat(multi)
+// @Success 200 {string} string "事件流"
+// @Failure 400,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/events [get]
+// @Security BearerAuth
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	topics := r.URL.Query()[eventsTopicParam]
+	if len(topics) == 0 {
+		RespondError(w, r, apperrors.BadRequestError(fmt.Sprintf("缺少订阅主题参数 %s", eventsTopicParam), nil))
+		return
+	}
+	for _, topic := range topics {
+		if !slices.Contains(h.config.Topics, topic) {
+			RespondError(w, r, unsupportedTopic(topic, h.config.Topics))
+			return
+		}
+	}
+
+	// 长连接不受服务器写超时限制，底层连接不支持时忽略
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	// 先订阅再返回响应头，客户端收到响应后发布的消息不会丢失
+	messages, unsubscribe := h.broker.Subscribe(topics...)
+	defer unsubscribe()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 关闭Nginx的响应缓冲
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("事件流不支持刷新响应", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			// 客户端断开连接，退出后取消订阅
+			return
+		case <-h.closing:
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// Close 结束所有事件流，之后建立的事件流立即结束
+// 事件流不会自行结束，关闭HTTP服务器前调用，否则 http.Server.Shutdown 会一直等到超时
+func (h *EventsHandler) Close() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// unsupportedTopic 订阅的主题不在允许列表中的错误
+func unsupportedTopic(topic string, allowed []string) error {
+	appErr := apperrors.BadRequestError("不支持订阅的主题", nil)
+	appErr.Fields = []apperrors.FieldError{{
+		Field:   eventsTopicParam,
+		Tag:     "oneof",
+		Message: fmt.Sprintf("主题%s不可订阅，可订阅的主题为%s", topic, strings.Join(allowed, "、")),
+	}}
+	return appErr
+}
+
+// writeEvent 按SSE格式写入一条消息，多行数据逐行写为 data 字段
+func writeEvent(w io.Writer, msg *queue.Message) error {
+	var b strings.Builder
+	if msg.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", msg.ID)
+	}
+	fmt.Fprintf(&b, "event: %s\n", msg.Topic)
+	for _, line := range strings.Split(string(msg.Payload), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
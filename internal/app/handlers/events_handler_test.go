@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// sseEvent 解析出的一条服务器推送事件
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvent 读取下一条事件，跳过心跳等注释行
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if ev.event != "" || ev.data != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// openStream 连接事件流，返回响应和断开连接的函数
+func openStream(t *testing.T, srv *httptest.Server, query string) (*http.Response, context.CancelFunc) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/v1/events"+query, nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, cancel
+}
+
+func TestEventsHandler_Stream(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// 发布的消息以事件推送给客户端，断开连接后取消订阅
+	t.Run("ReceiveAndDisconnect", func(t *testing.T) {
+		broker := queue.NewBroker(0)
+		h := NewEventsHandler(broker, logger, &EventsConfig{Topics: []string{"jobs", "emails"}})
+		srv := httptest.NewServer(http.HandlerFunc(h.Stream))
+		defer srv.Close()
+
+		resp, disconnect := openStream(t, srv, "?topic=jobs&topic=emails")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		require.Equal(t, 1, broker.SubscriberCount("jobs"))
+
+		broker.Publish(&queue.Message{ID: "1", Topic: "jobs", Payload: json.RawMessage(`{"job":"export","status":"done"}`)})
+		broker.Publish(&queue.Message{ID: "2", Topic: "emails", Payload: json.RawMessage(`{"to":"test@example.com"}`)})
+
+		reader := bufio.NewReader(resp.Body)
+		first := readEvent(t, reader)
+		assert.Equal(t, sseEvent{id: "1", event: "jobs", data: `{"job":"export","status":"done"}`}, first)
+		second := readEvent(t, reader)
+		assert.Equal(t, sseEvent{id: "2", event: "emails", data: `{"to":"test@example.com"}`}, second)
+
+		disconnect()
+		assert.Eventually(t, func() bool {
+			return broker.SubscriberCount("jobs") == 0 && broker.SubscriberCount("emails") == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	// 没有消息时定期发送心跳注释
+	t.Run("Heartbeat", func(t *testing.T) {
+		h := NewEventsHandler(queue.NewBroker(0), logger, &EventsConfig{Topics: []string{"jobs"}, Heartbeat: 10 * time.Millisecond})
+		srv := httptest.NewServer(http.HandlerFunc(h.Stream))
+		defer srv.Close()
+
+		resp, disconnect := openStream(t, srv, "?topic=jobs")
+		defer disconnect()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, ": ping\n", line)
+	})
+
+	// 关闭后结束所有事件流，服务器可以完成关闭
+	t.Run("Close", func(t *testing.T) {
+		broker := queue.NewBroker(0)
+		h := NewEventsHandler(broker, logger, &EventsConfig{Topics: []string{"jobs"}})
+		srv := httptest.NewServer(http.HandlerFunc(h.Stream))
+		defer srv.Close()
+
+		resp, disconnect := openStream(t, srv, "?topic=jobs")
+		defer disconnect()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		h.Close()
+		_, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return broker.SubscriberCount("jobs") == 0 }, time.Second, 10*time.Millisecond)
+	})
+
+	// 缺少主题或主题不在允许列表中时拒绝
+	t.Run("InvalidTopic", func(t *testing.T) {
+		broker := queue.NewBroker(0)
+		h := NewEventsHandler(broker, logger, &EventsConfig{Topics: []string{"jobs"}})
+
+		for _, query := range []string{"", "?topic=secrets", "?topic=jobs&topic=secrets"} {
+			rec := httptest.NewRecorder()
+			h.Stream(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+		assert.Zero(t, broker.SubscriberCount("jobs"))
+	})
+}
+
+func TestWriteEvent(t *testing.T) {
+	// 多行数据拆成多个 data 字段
+	var b strings.Builder
+	require.NoError(t, writeEvent(&b, &queue.Message{Topic: "jobs", Payload: json.RawMessage("{\n\"a\":1\n}")}))
+	assert.Equal(t, "event: jobs\ndata: {\ndata: \"a\":1\ndata: }\n\n", b.String())
+}
@@ -1,33 +1,82 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"log/slog"
+
+	"github.com/vadxq/go-rest-starter/internal/app/db"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
 )
 
+// healthCheckTimeout 依赖检查的整体超时时间
+const healthCheckTimeout = 5 * time.Second
+
+// cacheProbeTTL 缓存探测键的过期时间，删除失败时也会自动清理
+const cacheProbeTTL = 10 * time.Second
+
 // HealthHandler 健康检查处理器
 type HealthHandler struct {
 	db     *gorm.DB
 	redis  *redis.Client
+	cache  cache.Cache
+	queue  queue.Queue
 	logger *slog.Logger
+
+	// 数据库断路器，未启用时为nil
+	dbBreaker *apperrors.CircuitBreaker
+
+	// 启动完成标记，设置前就绪检查返回503，避免初始化未完成时接收流量
+	started atomic.Bool
+	// 关闭中标记，设置后就绪检查返回503，使负载均衡器停止转发新请求
+	shuttingDown atomic.Bool
 }
 
-// NewHealthHandler 创建健康检查处理器
-func NewHealthHandler(db *gorm.DB, redis *redis.Client, logger *slog.Logger) *HealthHandler {
+// NewHealthHandler 创建健康检查处理器，为nil的依赖在检查结果中标记为 unavailable
+// 数据库启用了断路器时，详细检查和依赖检查的结果中包含断路器状态
+func NewHealthHandler(database *gorm.DB, redis *redis.Client, cache cache.Cache, queue queue.Queue, logger *slog.Logger) *HealthHandler {
 	return &HealthHandler{
-		db:     db,
-		redis:  redis,
-		logger: logger,
+		db:        database,
+		redis:     redis,
+		cache:     cache,
+		queue:     queue,
+		logger:    logger,
+		dbBreaker: db.CircuitBreakerOf(database),
 	}
 }
 
+// SetStarted 标记应用启动完成，就绪检查开始检查依赖
+func (h *HealthHandler) SetStarted() {
+	h.started.Store(true)
+}
+
+// IsStarted 应用是否已启动完成
+func (h *HealthHandler) IsStarted() bool {
+	return h.started.Load()
+}
+
+// SetShuttingDown 标记应用开始关闭
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// IsShuttingDown 应用是否正在关闭
+func (h *HealthHandler) IsShuttingDown() bool {
+	return h.shuttingDown.Load()
+}
+
 // HealthStatus 健康状态结构
 type HealthStatus struct {
 	Status     string            `json:"status"`
@@ -35,6 +84,8 @@ type HealthStatus struct {
 	Services   map[string]string `json:"services"`
 	Version    string            `json:"version"`
 	Uptime     string            `json:"uptime,omitempty"`
+	// 断路器状态：closed、open 或 half_open
+	CircuitBreakers map[string]string `json:"circuit_breakers,omitempty"`
 }
 
 var startTime = time.Now()
@@ -67,7 +118,7 @@ func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
 // @Success 503 {object} HealthStatus "服务不可用"
 // @Router /health/detailed [get]
 func (h *HealthHandler) DetailedHealth(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
 	defer cancel()
 
 	status := &HealthStatus{
@@ -78,16 +129,26 @@ func (h *HealthHandler) DetailedHealth(w http.ResponseWriter, r *http.Request) {
 		Services:  make(map[string]string),
 	}
 
-	// 检查数据库连接
-	dbStatus := h.checkDatabase(ctx)
-	status.Services["database"] = dbStatus
+	// 并发检查数据库、Redis、缓存和消息队列
+	healthy := true
+	for _, result := range h.runChecks(ctx, h.dependencyChecks("database")) {
+		if result.err != nil {
+			h.logger.Error("依赖健康检查失败", "dependency", result.name, "error", result.err)
+		}
+		status.Services[result.name] = result.status
+		if result.status != "healthy" {
+			healthy = false
+		}
+	}
 
-	// 检查Redis连接
-	redisStatus := h.checkRedis(ctx)
-	status.Services["redis"] = redisStatus
+	// 断路器打开期间数据库请求直接失败，视为不健康
+	status.CircuitBreakers = h.circuitBreakers()
+	if h.dbBreaker != nil && h.dbBreaker.State() == apperrors.StateOpen {
+		healthy = false
+	}
 
 	// 确定整体状态
-	if dbStatus != "healthy" || redisStatus != "healthy" {
+	if !healthy {
 		status.Status = "unhealthy"
 		RespondJSON(w, http.StatusServiceUnavailable, status)
 		return
@@ -105,6 +166,26 @@ func (h *HealthHandler) DetailedHealth(w http.ResponseWriter, r *http.Request) {
 // @Success 503 {object} map[string]interface{} "服务未就绪"
 // @Router /ready [get]
 func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	// 关闭过程中不再接收新流量，无需检查依赖
+	if h.IsShuttingDown() {
+		RespondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"ready":         false,
+			"shutting_down": true,
+			"timestamp":     time.Now(),
+		})
+		return
+	}
+
+	// 启动尚未完成时不接收流量
+	if !h.IsStarted() {
+		RespondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"ready":     false,
+			"starting":  true,
+			"timestamp": time.Now(),
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
@@ -157,36 +238,146 @@ func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
 
 // checkDatabase 检查数据库连接状态
 func (h *HealthHandler) checkDatabase(ctx context.Context) string {
+	status, err := h.probeDatabase(ctx)
+	if err != nil {
+		h.logger.Error("数据库健康检查失败", "error", err)
+	}
+	return status
+}
+
+// checkRedis 检查Redis连接状态
+func (h *HealthHandler) checkRedis(ctx context.Context) string {
+	status, err := h.probeRedis(ctx)
+	if err != nil {
+		h.logger.Error("Redis ping失败", "error", err)
+	}
+	return status
+}
+
+// circuitBreakers 返回各断路器的状态，未启用断路器时返回nil
+func (h *HealthHandler) circuitBreakers() map[string]string {
+	if h.dbBreaker == nil {
+		return nil
+	}
+	return map[string]string{"database": h.dbBreaker.State().String()}
+}
+
+// dependencyCheck 单个依赖的检查项
+type dependencyCheck struct {
+	name  string
+	probe func(ctx context.Context) (string, error)
+}
+
+// dependencyResult 单个依赖的检查结果
+type dependencyResult struct {
+	name         string
+	status       string
+	err          error
+	responseTime time.Duration
+}
+
+// dependencyChecks 返回所有依赖的检查项，dbName为数据库在结果中的名称
+func (h *HealthHandler) dependencyChecks(dbName string) []dependencyCheck {
+	return []dependencyCheck{
+		{name: dbName, probe: h.probeDatabase},
+		{name: "redis", probe: h.probeRedis},
+		{name: "cache", probe: h.probeCache},
+		{name: "queue", probe: h.probeQueue},
+	}
+}
+
+// runChecks 并发执行依赖检查，结果顺序与检查项一致
+func (h *HealthHandler) runChecks(ctx context.Context, checks []dependencyCheck) []dependencyResult {
+	results := make([]dependencyResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check dependencyCheck) {
+			defer wg.Done()
+			start := time.Now()
+			status, err := check.probe(ctx)
+			results[i] = dependencyResult{
+				name:         check.name,
+				status:       status,
+				err:          err,
+				responseTime: time.Since(start),
+			}
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// probeDatabase 探测数据库连接
+func (h *HealthHandler) probeDatabase(ctx context.Context) (string, error) {
 	if h.db == nil {
-		return "unavailable"
+		return "unavailable", nil
 	}
 
 	sqlDB, err := h.db.DB()
 	if err != nil {
-		h.logger.Error("获取数据库连接失败", "error", err)
-		return "error"
+		return "error", err
 	}
 
 	if err := sqlDB.PingContext(ctx); err != nil {
-		h.logger.Error("数据库ping失败", "error", err)
-		return "unhealthy"
+		return "unhealthy", err
 	}
 
-	return "healthy"
+	return "healthy", nil
 }
 
-// checkRedis 检查Redis连接状态
-func (h *HealthHandler) checkRedis(ctx context.Context) string {
+// probeRedis 探测Redis连接
+func (h *HealthHandler) probeRedis(ctx context.Context) (string, error) {
 	if h.redis == nil {
-		return "unavailable"
+		return "unavailable", nil
 	}
 
 	if err := h.redis.Ping(ctx).Err(); err != nil {
-		h.logger.Error("Redis ping失败", "error", err)
-		return "unhealthy"
+		return "unhealthy", err
+	}
+
+	return "healthy", nil
+}
+
+// probeCache 探测缓存读写：写入探测键后读回比对，并在结束时删除
+func (h *HealthHandler) probeCache(ctx context.Context) (string, error) {
+	if !cache.Available(h.cache) {
+		return "unavailable", nil
+	}
+
+	key := fmt.Sprintf("health:probe:%d", time.Now().UnixNano())
+	value := []byte("ok")
+	if err := h.cache.Set(ctx, key, value, cacheProbeTTL); err != nil {
+		return "unhealthy", fmt.Errorf("写入缓存失败: %w", err)
 	}
+	defer func() {
+		if err := h.cache.Delete(ctx, key); err != nil {
+			h.logger.Warn("删除缓存探测键失败", "key", key, "error", err)
+		}
+	}()
 
-	return "healthy"
+	got, err := h.cache.Get(ctx, key)
+	if err != nil {
+		return "unhealthy", fmt.Errorf("读取缓存失败: %w", err)
+	}
+	if !bytes.Equal(got, value) {
+		return "unhealthy", errors.New("缓存读取的值与写入不一致")
+	}
+
+	return "healthy", nil
+}
+
+// probeQueue 探测消息队列
+func (h *HealthHandler) probeQueue(ctx context.Context) (string, error) {
+	if h.queue == nil {
+		return "unavailable", nil
+	}
+
+	if err := h.queue.Ping(ctx); err != nil {
+		return "unhealthy", err
+	}
+
+	return "healthy", nil
 }
 
 // Readiness K8s就绪探针
@@ -227,86 +418,64 @@ func (h *HealthHandler) SystemInfo(w http.ResponseWriter, r *http.Request) {
 		},
 		"timestamp": time.Now().Unix(),
 	}
+	if stats, ok := h.dbStats(); ok {
+		systemInfo["database"] = stats
+	}
 	
 	RespondJSON(w, http.StatusOK, systemInfo)
 }
 
+// dbStats 数据库连接池统计，用于排查连接池耗尽；数据库不可用时返回false
+func (h *HealthHandler) dbStats() (map[string]interface{}, bool) {
+	if h.db == nil {
+		return nil, false
+	}
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return nil, false
+	}
+
+	stats := sqlDB.Stats()
+	return map[string]interface{}{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration_ms":     stats.WaitDuration.Milliseconds(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_idle_time_closed": stats.MaxIdleTimeClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}, true
+}
+
 // CheckDependencies 检查所有依赖服务
 func (h *HealthHandler) CheckDependencies(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
 	defer cancel()
-	
+
 	type DependencyStatus struct {
 		Name         string        `json:"name"`
 		Status       string        `json:"status"`
 		ResponseTime time.Duration `json:"response_time_ms"`
 		Error        string        `json:"error,omitempty"`
 	}
-	
-	var dependencies []DependencyStatus
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	
-	// 检查数据库
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		start := time.Now()
-		status := "healthy"
-		var errMsg string
-		
-		if h.db != nil {
-			sqlDB, err := h.db.DB()
-			if err != nil {
-				status = "error"
-				errMsg = err.Error()
-			} else if err := sqlDB.PingContext(ctx); err != nil {
-				status = "unhealthy"
-				errMsg = err.Error()
-			}
-		} else {
-			status = "unavailable"
-		}
-		
-		mu.Lock()
-		dependencies = append(dependencies, DependencyStatus{
-			Name:         "postgresql",
-			Status:       status,
-			ResponseTime: time.Since(start) / time.Millisecond,
-			Error:        errMsg,
-		})
-		mu.Unlock()
-	}()
-	
-	// 检查Redis
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		start := time.Now()
-		status := "healthy"
+
+	results := h.runChecks(ctx, h.dependencyChecks("postgresql"))
+	dependencies := make([]DependencyStatus, 0, len(results))
+	for _, result := range results {
 		var errMsg string
-		
-		if h.redis != nil {
-			if err := h.redis.Ping(ctx).Err(); err != nil {
-				status = "unhealthy"
-				errMsg = err.Error()
-			}
-		} else {
-			status = "unavailable"
+		if result.err != nil {
+			errMsg = result.err.Error()
 		}
-		
-		mu.Lock()
 		dependencies = append(dependencies, DependencyStatus{
-			Name:         "redis",
-			Status:       status,
-			ResponseTime: time.Since(start) / time.Millisecond,
+			Name:         result.name,
+			Status:       result.status,
+			ResponseTime: result.responseTime / time.Millisecond,
 			Error:        errMsg,
 		})
-		mu.Unlock()
-	}()
-	
-	wg.Wait()
-	
+	}
+
 	// 确定整体状态
 	overallStatus := "healthy"
 	for _, dep := range dependencies {
@@ -318,17 +487,23 @@ func (h *HealthHandler) CheckDependencies(w http.ResponseWriter, r *http.Request
 			}
 		}
 	}
-	
+	if h.dbBreaker != nil && h.dbBreaker.State() == apperrors.StateOpen {
+		overallStatus = "unhealthy"
+	}
+
 	response := map[string]interface{}{
 		"status":       overallStatus,
 		"dependencies": dependencies,
 		"timestamp":    time.Now().Unix(),
 	}
-	
+	if breakers := h.circuitBreakers(); breakers != nil {
+		response["circuit_breakers"] = breakers
+	}
+
 	statusCode := http.StatusOK
 	if overallStatus == "unhealthy" {
 		statusCode = http.StatusServiceUnavailable
 	}
-	
+
 	RespondJSON(w, statusCode, response)
-}
\ No newline at end of file
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// stubCache 健康检查使用的内存缓存桩，err不为空时所有操作失败
+type stubCache struct {
+	cache.Cache
+	data map[string][]byte
+	err  error
+}
+
+func newStubCache(err error) *stubCache {
+	return &stubCache{data: make(map[string][]byte), err: err}
+}
+
+func (c *stubCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.data[key] = value
+	return nil
+}
+
+func (c *stubCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	value, ok := c.data[key]
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+	return value, nil
+}
+
+func (c *stubCache) Delete(ctx context.Context, key string) error {
+	if c.err != nil {
+		return c.err
+	}
+	delete(c.data, key)
+	return nil
+}
+
+// stubQueue 健康检查使用的队列桩，Ping返回err
+type stubQueue struct {
+	queue.Queue
+	err error
+}
+
+func (q *stubQueue) Ping(ctx context.Context) error {
+	return q.err
+}
+
+// newTestHealthHandler 创建依赖均可用的健康检查处理器
+func newTestHealthHandler(t *testing.T) *HealthHandler {
+	t.Helper()
+	return newTestHealthHandlerWith(t, newStubCache(nil), &stubQueue{})
+}
+
+// newTestHealthHandlerWith 使用指定的缓存和队列创建健康检查处理器，数据库和Redis均可用
+func newTestHealthHandlerWith(t *testing.T, c cache.Cache, q queue.Queue) *HealthHandler {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	for i := 0; i < 10; i++ {
+		mock.ExpectPing()
+	}
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+	require.NoError(t, err)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return NewHealthHandler(db, rdb, c, q, slog.Default())
+}
+
+func TestHealthHandler_Shutdown(t *testing.T) {
+	h := newTestHealthHandler(t)
+	h.SetStarted()
+
+	serve := func(handler http.HandlerFunc, path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		return rec.Code, data
+	}
+
+	// 关闭前就绪检查通过
+	code, data := serve(h.Ready, "/ready")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, data["ready"])
+	assert.False(t, h.IsShuttingDown())
+
+	h.SetShuttingDown()
+
+	// 开始关闭后就绪检查返回503
+	t.Run("ReadyUnavailable", func(t *testing.T) {
+		code, data := serve(h.Ready, "/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, false, data["ready"])
+		assert.Equal(t, true, data["shutting_down"])
+	})
+
+	t.Run("ReadinessUnavailable", func(t *testing.T) {
+		code, _ := serve(h.Readiness, "/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	// 存活检查不受影响
+	t.Run("LiveHealthy", func(t *testing.T) {
+		code, data := serve(h.Live, "/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, data["alive"])
+
+		code, _ = serve(h.Liveness, "/health/live")
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
+
+func TestHealthHandler_CacheAndQueue(t *testing.T) {
+	// detailed 调用详细健康检查，返回状态码和各服务状态
+	detailed := func(h *HealthHandler) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		h.DetailedHealth(rec, httptest.NewRequest(http.MethodGet, "/health/detailed", nil))
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		services, _ := data["services"].(map[string]interface{})
+		return rec.Code, services
+	}
+
+	// dependencies 调用依赖检查，返回状态码、整体状态和按名称索引的依赖状态
+	dependencies := func(h *HealthHandler) (int, string, map[string]map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		h.CheckDependencies(rec, httptest.NewRequest(http.MethodGet, "/health/dependencies", nil))
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		deps := make(map[string]map[string]interface{})
+		list, _ := data["dependencies"].([]interface{})
+		for _, item := range list {
+			dep := item.(map[string]interface{})
+			deps[dep["name"].(string)] = dep
+		}
+		status, _ := data["status"].(string)
+		return rec.Code, status, deps
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		c := newStubCache(nil)
+		h := newTestHealthHandlerWith(t, c, &stubQueue{})
+
+		code, services := detailed(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "healthy", services["cache"])
+		assert.Equal(t, "healthy", services["queue"])
+
+		code, status, deps := dependencies(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "healthy", status)
+		assert.Equal(t, "healthy", deps["cache"]["status"])
+		assert.Equal(t, "healthy", deps["queue"]["status"])
+
+		// 探测键在检查结束后被删除
+		assert.Empty(t, c.data)
+	})
+
+	t.Run("CacheFailure", func(t *testing.T) {
+		h := newTestHealthHandlerWith(t, newStubCache(errors.New("cache down")), &stubQueue{})
+
+		code, services := detailed(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unhealthy", services["cache"])
+		assert.Equal(t, "healthy", services["queue"])
+
+		code, status, deps := dependencies(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unhealthy", status)
+		assert.Equal(t, "unhealthy", deps["cache"]["status"])
+		assert.Contains(t, deps["cache"]["error"], "cache down")
+	})
+
+	t.Run("QueueFailure", func(t *testing.T) {
+		h := newTestHealthHandlerWith(t, newStubCache(nil), &stubQueue{err: queue.ErrQueueClosed})
+
+		code, services := detailed(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "healthy", services["cache"])
+		assert.Equal(t, "unhealthy", services["queue"])
+
+		code, status, deps := dependencies(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "unhealthy", status)
+		assert.Equal(t, "unhealthy", deps["queue"]["status"])
+		assert.Equal(t, queue.ErrQueueClosed.Error(), deps["queue"]["error"])
+	})
+
+	// 未配置缓存和队列时标记为不可用，依赖检查整体降级
+	t.Run("Unavailable", func(t *testing.T) {
+		h := newTestHealthHandlerWith(t, nil, nil)
+
+		code, status, deps := dependencies(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "degraded", status)
+		assert.Equal(t, "unavailable", deps["cache"]["status"])
+		assert.Equal(t, "unavailable", deps["queue"]["status"])
+	})
+}
+
+func TestHealthHandler_Startup(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	serve := func(handler http.HandlerFunc, path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		return rec.Code, data
+	}
+
+	// 启动完成前就绪检查返回503，存活检查返回200
+	t.Run("BeforeStarted", func(t *testing.T) {
+		assert.False(t, h.IsStarted())
+
+		code, data := serve(h.Ready, "/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, false, data["ready"])
+		assert.Equal(t, true, data["starting"])
+
+		code, _ = serve(h.Readiness, "/health/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+
+		code, data = serve(h.Live, "/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, data["alive"])
+	})
+
+	// 启动完成后就绪检查通过
+	t.Run("AfterStarted", func(t *testing.T) {
+		h.SetStarted()
+		assert.True(t, h.IsStarted())
+
+		code, data := serve(h.Ready, "/ready")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, true, data["ready"])
+		assert.Nil(t, data["starting"])
+
+		code, _ = serve(h.Readiness, "/health/ready")
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
+
+func TestHealthHandler_CircuitBreaker(t *testing.T) {
+	h := newTestHealthHandler(t)
+	h.dbBreaker = apperrors.NewCircuitBreaker(1, time.Minute)
+
+	serve := func(handler http.HandlerFunc, path string) (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		return rec.Code, data
+	}
+
+	// 断路器关闭时健康检查正常，并返回断路器状态
+	code, data := serve(h.DetailedHealth, "/health/detailed")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"database": "closed"}, data["circuit_breakers"])
+
+	// 断路器打开后数据库请求直接失败，健康检查返回503
+	_ = h.dbBreaker.Execute(func() error { return errors.New("connection refused") })
+
+	code, data = serve(h.DetailedHealth, "/health/detailed")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", data["status"])
+	assert.Equal(t, map[string]interface{}{"database": "open"}, data["circuit_breakers"])
+
+	code, data = serve(h.CheckDependencies, "/health/dependencies")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "unhealthy", data["status"])
+	assert.Equal(t, map[string]interface{}{"database": "open"}, data["circuit_breakers"])
+}
+
+func TestHealthHandler_SystemInfo(t *testing.T) {
+	h := newTestHealthHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.SystemInfo(rec, httptest.NewRequest(http.MethodGet, "/health/system", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	data, _ := resp.Data.(map[string]interface{})
+
+	// 返回数据库连接池统计
+	database, ok := data["database"].(map[string]interface{})
+	require.True(t, ok, "缺少 database 统计")
+	for _, field := range []string{"max_open_connections", "open_connections", "in_use", "idle", "wait_count", "wait_duration_ms"} {
+		assert.Contains(t, database, field)
+	}
+	assert.Contains(t, data, "runtime")
+	assert.Contains(t, data, "memory")
+
+	// 数据库不可用时不返回连接池统计
+	h = NewHealthHandler(nil, nil, nil, nil, slog.Default())
+	rec = httptest.NewRecorder()
+	h.SystemInfo(rec, httptest.NewRequest(http.MethodGet, "/health/system", nil))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	data, _ = resp.Data.(map[string]interface{})
+	assert.NotContains(t, data, "database")
+}
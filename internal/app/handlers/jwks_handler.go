@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+// JWKSHandler 公钥分发处理器，按JWKS格式发布用于验证令牌的RSA公钥
+type JWKSHandler struct {
+	jwtConfig *jwt.Config
+}
+
+// NewJWKSHandler 创建公钥分发处理器
+func NewJWKSHandler(jwtConfig *jwt.Config) *JWKSHandler {
+	return &JWKSHandler{
+		jwtConfig: jwtConfig,
+	}
+}
+
+// JWKS 获取JWT公钥集合
+// @Summary JWT公钥集合
+// @Description 以JWKS格式返回当前及轮换前的RSA公钥，HS256时返回空集合
+// @Tags auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	jwks := jwt.JWKS{Keys: []jwt.JWK{}}
+	if h.jwtConfig != nil {
+		jwks = h.jwtConfig.JWKS()
+	}
+
+	// JWKS 是标准格式，不使用统一响应包装
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(jwks); err != nil {
+		slog.Error("响应JSON序列化失败", "error", err)
+	}
+}
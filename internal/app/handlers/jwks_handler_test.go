@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	current, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	previous, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	serve := func(config *jwt.Config) (*httptest.ResponseRecorder, map[string][]map[string]string) {
+		rec := httptest.NewRecorder()
+		NewJWKSHandler(config).JWKS(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+		var body map[string][]map[string]string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	// 发布当前公钥和轮换前的公钥
+	t.Run("PublishesKeys", func(t *testing.T) {
+		rec, body := serve(&jwt.Config{
+			Algorithm:  jwt.AlgorithmRS256,
+			KeyID:      "current",
+			PrivateKey: current,
+			PublicKey:  &current.PublicKey,
+			PreviousPublicKeys: []jwt.PublicKey{
+				{KeyID: "previous", Key: &previous.PublicKey},
+			},
+		})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		keys := body["keys"]
+		require.Len(t, keys, 2)
+
+		// 当前公钥与已知密钥一致
+		assert.Equal(t, map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": "current",
+			"n":   base64.RawURLEncoding.EncodeToString(current.N.Bytes()),
+			"e":   "AQAB",
+		}, keys[0])
+
+		assert.Equal(t, "previous", keys[1]["kid"])
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(previous.N.Bytes()), keys[1]["n"])
+
+		// n 和 e 可还原为原始公钥
+		n, err := base64.RawURLEncoding.DecodeString(keys[0]["n"])
+		require.NoError(t, err)
+		assert.Zero(t, new(big.Int).SetBytes(n).Cmp(current.N))
+	})
+
+	// 未配置kid时使用公钥指纹
+	t.Run("DefaultKeyID", func(t *testing.T) {
+		_, body := serve(&jwt.Config{Algorithm: jwt.AlgorithmRS256, PublicKey: &current.PublicKey})
+
+		require.Len(t, body["keys"], 1)
+		assert.Equal(t, jwt.Thumbprint(&current.PublicKey), body["keys"][0]["kid"])
+	})
+
+	// HS256没有可公开的密钥
+	t.Run("EmptyForHS256", func(t *testing.T) {
+		rec, body := serve(&jwt.Config{Secret: "secret"})
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotNil(t, body["keys"])
+		assert.Empty(t, body["keys"])
+	})
+}
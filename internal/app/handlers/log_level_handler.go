@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// LogLevelHandler 运行时调整全局日志级别，用于排查线上问题时临时开启调试日志
+// 调整只对当前实例生效，重启或配置文件变更后恢复为配置中的级别
+type LogLevelHandler struct {
+	level     *slog.LevelVar
+	logger    *slog.Logger
+	validator *validator.Validate
+}
+
+// NewLogLevelHandler 创建日志级别处理器，level为全局日志处理器使用的级别
+func NewLogLevelHandler(level *slog.LevelVar, logger *slog.Logger, validator *validator.Validate) *LogLevelHandler {
+	return &LogLevelHandler{
+		level:     level,
+		logger:    logger,
+		validator: validator,
+	}
+}
+
+// SetLogLevel 调整日志级别
+// @Summary 调整日志级别
+// @Description 立即调整当前实例的全局日志级别，重启后恢复为配置中的级别（仅管理员）
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param body body dto.LogLevelRequest true "日志级别：debug、info、warn、error"
+// @Success 200 {object} Response{data=dto.LogLevelResponse}
+// @Failure 400,401,403 {object} Response{error=ErrorInfo}
+// @Router /api/v1/admin/log-level [put]
+// @Security BearerAuth
+func (h *LogLevelHandler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req dto.LogLevelRequest
+
+	if err := BindJSON(r, &req, func(v interface{}) error {
+		return h.validator.Struct(v)
+	}); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	level, err := logger.ParseLevel(req.Level)
+	if err != nil {
+		RespondError(w, r, apperrors.BadRequestError(err.Error(), err))
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	h.logger.WarnContext(r.Context(), "日志级别已通过接口调整", "previous", previous.String(), "level", level.String(), "user_id", logger.GetUserID(r.Context()))
+
+	RespondJSON(w, http.StatusOK, dto.LogLevelResponse{
+		Level:    strings.ToLower(level.String()),
+		Previous: strings.ToLower(previous.String()),
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+func TestLogLevelHandler(t *testing.T) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelInfo)
+	handler := NewLogLevelHandler(level, slog.Default(), utils.NewValidator())
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.SetLogLevel(rec, req)
+		return rec
+	}
+
+	// 有效的日志级别立即生效
+	t.Run("Valid", func(t *testing.T) {
+		rec := put(`{"level":"debug"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, slog.LevelDebug, level.Level())
+
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, map[string]string{"level": "debug", "previous": "info"}, body.Data)
+
+		rec = put(`{"level":"error"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, slog.LevelError, level.Level())
+	})
+
+	// 无效的日志级别返回400，级别保持不变
+	t.Run("Invalid", func(t *testing.T) {
+		level.Set(slog.LevelWarn)
+
+		for _, body := range []string{`{"level":"verbose"}`, `{"level":""}`, `{}`} {
+			rec := put(body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+		assert.Equal(t, slog.LevelWarn, level.Level())
+	})
+}
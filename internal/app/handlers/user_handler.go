@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
@@ -19,14 +20,21 @@ type UserHandler struct {
 	userService services.UserService
 	logger      *slog.Logger
 	validator   *validator.Validate
+	// isAdmin 判断当前用户是否为管理员，处理器包不能依赖认证中间件，由调用方注入
+	isAdmin func(ctx context.Context) bool
 }
 
 // NewUserHandler 创建一个新的 UserHandler 实例
-func NewUserHandler(us services.UserService, logger *slog.Logger, v *validator.Validate) *UserHandler {
+// isAdmin 判断请求的用户是否为管理员，为空时视为非管理员
+func NewUserHandler(us services.UserService, logger *slog.Logger, v *validator.Validate, isAdmin func(ctx context.Context) bool) *UserHandler {
+	if isAdmin == nil {
+		isAdmin = func(context.Context) bool { return false }
+	}
 	return &UserHandler{
 		userService: us,
 		logger:      logger,
 		validator:   v,
+		isAdmin:     isAdmin,
 	}
 }
 
@@ -37,20 +45,22 @@ func NewUserHandler(us services.UserService, logger *slog.Logger, v *validator.V
 // @Accept json
 // @Produce json
 // @Param id path string true "用户ID"
+// @Param If-None-Match header string false "上次响应的ETag"
 // @Success 200 {object} Response{data=dto.UserResponse}
+// @Success 304 "资源未修改"
 // @Failure 400,404,500 {object} Response{error=ErrorInfo}
 // @Router /api/v1/users/{id} [get]
 // @Security BearerAuth
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		RespondError(w, apperrors.BadRequestError("ID参数缺失", nil))
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
 		return
 	}
 
 	user, err := h.userService.GetByID(r.Context(), userID)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
@@ -60,11 +70,14 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		Name:      user.Name,
 		Email:     user.Email,
 		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
 	}
 
-	RespondJSON(w, http.StatusOK, response)
+	RespondJSONWithVersion(w, r, http.StatusOK, response, user.Version)
 }
 
 // CreateUser 创建用户
@@ -81,16 +94,16 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var input dto.CreateUserInput
 
-	if err := BindJSON(r, &input, func(v interface{}) error {
+	if err := BindJSONWithOptions(r, &input, &StrictDecodeOptions, func(v interface{}) error {
 		return h.validator.Struct(v)
 	}); err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
 	user, err := h.userService.CreateUser(r.Context(), input)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
@@ -100,41 +113,171 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		Name:      user.Name,
 		Email:     user.Email,
 		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
 	}
 
 	RespondJSON(w, http.StatusCreated, response)
 }
 
+// CreateUsersBulk 批量创建用户
+// @Summary 批量创建用户
+// @Description 在同一事务中批量创建用户，单条记录失败不影响其他记录，返回逐条结果；数量超过上限时整体拒绝
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body []dto.CreateUserInput true "创建用户请求体数组"
+// @Success 200 {object} Response{data=dto.BulkCreateUsersResponse}
+// @Failure 400,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/users/bulk [post]
+// @Security BearerAuth
+func (h *UserHandler) CreateUsersBulk(w http.ResponseWriter, r *http.Request) {
+	var inputs []dto.CreateUserInput
+	if err := DecodeJSONWithOptions(r, &inputs, &StrictDecodeOptions); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	results, err := h.userService.CreateUsersBulk(r.Context(), inputs)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	response := dto.BulkCreateUsersResponse{
+		Results: make([]dto.BulkCreateUserResult, len(results)),
+	}
+	for i, result := range results {
+		item := dto.BulkCreateUserResult{Index: i, Success: result.Err == nil}
+		if result.Err != nil {
+			appErr := apperrors.AsError(result.Err)
+			item.Error = &dto.BulkItemError{
+				Type:    string(appErr.Type),
+				Code:    string(appErr.Code),
+				Message: appErr.LocalizedMessage(RequestLocale(r)),
+				Fields:  appErr.Fields,
+			}
+			response.Failed++
+		} else {
+			item.User = &dto.UserResponse{
+				ID:        result.User.ID,
+				Name:      result.User.Name,
+				Email:     result.User.Email,
+				Role:      result.User.Role,
+				AvatarURL: result.User.AvatarURL,
+				CreatedAt: result.User.CreatedAt,
+				UpdatedAt: result.User.UpdatedAt,
+				Version:   result.User.Version,
+				Verified:  result.User.Verified,
+			}
+			response.Created++
+		}
+		response.Results[i] = item
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
 // UpdateUser 更新用户
 // @Summary 更新用户
-// @Description 根据用户ID更新用户信息
+// @Description 根据用户ID整体更新用户资料，name和email必填，password为空时保持不变，仅本人或管理员可以更新
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path string true "用户ID"
 // @Param body body dto.UpdateUserInput true "更新用户请求体"
+// @Param If-Match header string false "读取时响应的ETag（即版本号），请求体未携带version时使用"
 // @Success 200 {object} Response{data=dto.UserResponse}
-// @Failure 400,404,500 {object} Response{error=ErrorInfo}
+// @Failure 400,403,404,409,500 {object} Response{error=ErrorInfo}
 // @Router /api/v1/users/{id} [put]
 // @Security BearerAuth
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		RespondError(w, apperrors.BadRequestError("ID参数缺失", nil))
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
 		return
 	}
 
 	var input dto.UpdateUserInput
-	if err := BindJSON(r, &input, nil); err != nil {
-		RespondError(w, err)
+	if err := BindJSONWithOptions(r, &input, &StrictDecodeOptions, nil); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
+	// 请求体未携带版本号时使用If-Match请求头
+	if input.Version == nil {
+		version, err := IfMatchVersion(r)
+		if err != nil {
+			RespondError(w, r, err)
+			return
+		}
+		input.Version = version
+	}
+
 	user, err := h.userService.UpdateUser(r.Context(), userID, input)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
+		return
+	}
+
+	// 转换为 DTO
+	response := dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
+// PatchUser 部分更新用户
+// @Summary 部分更新用户
+// @Description 根据用户ID部分更新用户信息，省略或为null的字段保持不变，出现的字段（包括空字符串）按规则校验后设置，仅本人或管理员可以更新
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "用户ID"
+// @Param body body dto.PatchUserInput true "部分更新用户请求体"
+// @Param If-Match header string false "读取时响应的ETag（即版本号），请求体未携带version时使用"
+// @Success 200 {object} Response{data=dto.UserResponse}
+// @Failure 400,403,404,409,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/users/{id} [patch]
+// @Security BearerAuth
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
+		return
+	}
+
+	var input dto.PatchUserInput
+	if err := BindJSONWithOptions(r, &input, &StrictDecodeOptions, nil); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// 请求体未携带版本号时使用If-Match请求头
+	if input.Version == nil {
+		version, err := IfMatchVersion(r)
+		if err != nil {
+			RespondError(w, r, err)
+			return
+		}
+		input.Version = version
+	}
+
+	user, err := h.userService.PatchUser(r.Context(), userID, input)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
@@ -144,8 +287,11 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		Name:      user.Name,
 		Email:     user.Email,
 		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
 	}
 
 	RespondJSON(w, http.StatusOK, response)
@@ -165,28 +311,112 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		RespondError(w, apperrors.BadRequestError("ID参数缺失", nil))
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
 		return
 	}
 
 	err := h.userService.DeleteUser(r.Context(), userID)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
 	RespondJSON(w, http.StatusNoContent, nil)
 }
 
+// RestoreUser 恢复已删除的用户
+// @Summary 恢复用户
+// @Description 根据用户ID恢复已软删除的用户 (仅管理员)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {object} Response{data=dto.UserResponse}
+// @Failure 400,403,404,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/users/{id}/restore [post]
+// @Security BearerAuth
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
+		return
+	}
+
+	user, err := h.userService.RestoreUser(r.Context(), userID)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// 转换为 DTO
+	response := dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
+// VerifyEmail 验证用户邮箱
+// @Summary 验证邮箱
+// @Description 使用验证邮件中的令牌将用户标记为已验证，令牌过期或邮箱已修改时验证失败
+// @Tags auth
+// @Produce json
+// @Param token query string true "邮箱验证令牌"
+// @Success 200 {object} Response{data=dto.UserResponse}
+// @Failure 400,404,500 {object} Response{error=ErrorInfo}
+// @Router /api/v1/auth/verify-email [get]
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		RespondError(w, r, apperrors.BadRequestError("token参数缺失", nil).WithCode(apperrors.CodeAuthVerificationTokenInvalid))
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(r.Context(), token)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// 转换为 DTO
+	response := dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}
+
+	RespondJSON(w, http.StatusOK, response)
+}
+
 // ListUsers 获取用户列表
 // @Summary 获取用户列表
-// @Description 分页获取用户列表
+// @Description 分页获取用户列表，支持搜索、按角色筛选和排序
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param page query int false "页码，默认为1" default(1)
 // @Param page_size query int false "每页大小，默认为10" default(10)
+// @Param search query string false "按姓名或邮箱模糊搜索"
+// @Param role query string false "按角色筛选"
+// @Param sort query string false "排序字段（id、name、email、role、created_at、updated_at），前缀-表示降序，多个字段用逗号分隔" example(-created_at)
+// @Param include_deleted query bool false "是否包含已删除的用户（仅管理员）"
 // @Success 200 {object} Response{data=dto.ListResponse{data=[]dto.UserResponse}}
+// @Failure 400 {object} Response{error=ErrorInfo}
+// @Failure 403 {object} Response{error=ErrorInfo}
 // @Failure 500 {object} Response{error=ErrorInfo}
 // @Router /api/v1/users [get]
 // @Security BearerAuth
@@ -212,9 +442,32 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, total, err := h.userService.ListUsers(r.Context(), page, pageSize)
+	query := r.URL.Query()
+	opts := dto.UserListOptions{
+		ListUsersFilter: dto.ListUsersFilter{
+			Search: query.Get("search"),
+			Role:   query.Get("role"),
+			Sort:   query.Get("sort"),
+		},
+	}
+
+	// 只有管理员可以查询已删除的用户
+	if value := query.Get("include_deleted"); value != "" {
+		includeDeleted, err := strconv.ParseBool(value)
+		if err != nil {
+			RespondError(w, r, apperrors.BadRequestError("include_deleted 参数无效", err))
+			return
+		}
+		if includeDeleted && !h.isAdmin(r.Context()) {
+			RespondError(w, r, apperrors.ForbiddenError("只有管理员可以查询已删除的用户", nil))
+			return
+		}
+		opts.IncludeDeleted = includeDeleted
+	}
+
+	users, total, err := h.userService.ListUsers(r.Context(), page, pageSize, opts)
 	if err != nil {
-		RespondError(w, err)
+		RespondError(w, r, err)
 		return
 	}
 
@@ -226,17 +479,13 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 			Name:      user.Name,
 			Email:     user.Email,
 			Role:      user.Role,
+			AvatarURL: user.AvatarURL,
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
+			Version:   user.Version,
+			Verified:  user.Verified,
 		}
 	}
 
-	response := dto.ListResponse{
-		Data:  userResponses,
-		Total: total,
-		Page:  page,
-		Size:  pageSize,
-	}
-
-	RespondJSON(w, http.StatusOK, response)
+	RespondJSON(w, http.StatusOK, dto.NewListResponse(userResponses, page, pageSize, total))
 }
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// stubUserService 在内存中保存单个用户，更新时按版本号做乐观锁校验
+// 未实现的方法调用嵌入的nil接口会panic
+type stubUserService struct {
+	services.UserService
+	user *models.User
+	opts dto.UserListOptions // 最近一次 ListUsers 的查询条件
+}
+
+func (s *stubUserService) GetByID(ctx context.Context, id string) (*models.User, error) {
+	u := *s.user
+	return &u, nil
+}
+
+func (s *stubUserService) UpdateUser(ctx context.Context, id string, input dto.UpdateUserInput) (*models.User, error) {
+	if input.Version != nil && *input.Version != s.user.Version {
+		return nil, apperrors.ConflictError("用户已被其他请求修改，请刷新后重试", nil).WithCode(apperrors.CodeUserVersionConflict)
+	}
+	s.user.Name, s.user.Email = input.Name, input.Email
+	s.user.Version++
+
+	u := *s.user
+	return &u, nil
+}
+
+func (s *stubUserService) ListUsers(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	s.opts = opts
+	return nil, 0, nil
+}
+
+// withUserID 设置路由参数id
+func withUserID(req *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestUserHandler_GetThenUpdateWithETag(t *testing.T) {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user", Version: 3}
+	user.ID = 1
+	h := NewUserHandler(&stubUserService{user: user}, nil, validator.New(), nil)
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.GetUser(rec, withUserID(httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil), "1"))
+		return rec
+	}
+	put := func(ifMatch string) *httptest.ResponseRecorder {
+		body := strings.NewReader(`{"name":"Renamed","email":"test@example.com"}`)
+		req := withUserID(httptest.NewRequest(http.MethodPut, "/api/v1/users/1", body), "1")
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		h.UpdateUser(rec, req)
+		return rec
+	}
+
+	// GET返回的ETag为版本号
+	rec := get()
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.Equal(t, `W/"3"`, etag)
+
+	// 原样放入If-Match即可更新
+	rec = put(etag)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"name":"Renamed"`)
+
+	// 旧的ETag已过期，返回版本冲突
+	rec = put(etag)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, string(apperrors.CodeUserVersionConflict), errorInfo(t, rec).Code)
+
+	// 重新读取后ETag随版本更新，If-None-Match使用旧ETag时返回新内容
+	req := withUserID(httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil), "1")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.GetUser(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `W/"4"`, rec.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, put(rec.Header().Get("ETag")).Code)
+}
+
+func TestUserHandler_ListUsersIncludeDeleted(t *testing.T) {
+	list := func(admin bool, query string) (*stubUserService, *httptest.ResponseRecorder) {
+		svc := &stubUserService{}
+		h := NewUserHandler(svc, nil, validator.New(), func(context.Context) bool { return admin })
+		rec := httptest.NewRecorder()
+		h.ListUsers(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users"+query, nil))
+		return svc, rec
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		svc, rec := list(true, "?include_deleted=true")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.True(t, svc.opts.IncludeDeleted)
+	})
+
+	t.Run("DefaultExcluded", func(t *testing.T) {
+		svc, rec := list(true, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.False(t, svc.opts.IncludeDeleted)
+	})
+
+	t.Run("NonAdminForbidden", func(t *testing.T) {
+		svc, rec := list(false, "?include_deleted=true")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.False(t, svc.opts.IncludeDeleted)
+
+		// 显式传false不需要管理员权限
+		_, rec = list(false, "?include_deleted=false")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, rec := list(true, "?include_deleted=maybe")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
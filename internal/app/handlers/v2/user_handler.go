@@ -0,0 +1,148 @@
+// Package v2 API v2 的HTTP处理器
+// v2 只包含响应格式与 v1 不同的接口，与 v1 共用服务层；新增接口时在此添加处理器并在 router/v2 中注册
+package v2
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// Pagination 分页信息
+type Pagination struct {
+	Page       int   `json:"page"`        // 当前页码
+	PageSize   int   `json:"page_size"`   // 每页大小
+	Total      int64 `json:"total"`       // 总记录数
+	TotalPages int   `json:"total_pages"` // 总页数
+	HasNext    bool  `json:"has_next"`    // 是否有下一页
+	HasPrev    bool  `json:"has_prev"`    // 是否有上一页
+}
+
+// UserListResponse 用户列表响应，分页信息与列表数据分开返回
+type UserListResponse struct {
+	Items      []dto.UserResponse `json:"items"`
+	Pagination Pagination         `json:"pagination"`
+}
+
+// UserHandler 处理 v2 用户相关的 HTTP 请求
+type UserHandler struct {
+	userService services.UserService
+	logger      *slog.Logger
+}
+
+// NewUserHandler 创建一个新的 v2 UserHandler 实例
+func NewUserHandler(us services.UserService, logger *slog.Logger) *UserHandler {
+	return &UserHandler{
+		userService: us,
+		logger:      logger,
+	}
+}
+
+// GetUser 获取用户详情
+// @Summary 获取用户详情（v2）
+// @Description 根据用户ID获取用户详细信息
+// @Tags users-v2
+// @Accept json
+// @Produce json
+// @Param id path string true "用户ID"
+// @Success 200 {object} handlers.Response{data=dto.UserResponse}
+// @Failure 400,404,500 {object} handlers.Response{error=handlers.ErrorInfo}
+// @Router /api/v2/users/{id} [get]
+// @Security BearerAuth
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if userID == "" {
+		handlers.RespondError(w, r, apperrors.BadRequestError("ID参数缺失", nil))
+		return
+	}
+
+	user, err := h.userService.GetByID(r.Context(), userID)
+	if err != nil {
+		handlers.RespondError(w, r, err)
+		return
+	}
+
+	handlers.RespondJSONWithVersion(w, r, http.StatusOK, userResponse(user), user.Version)
+}
+
+// ListUsers 获取用户列表
+// @Summary 获取用户列表（v2）
+// @Description 分页获取用户列表，分页信息在 pagination 字段中返回
+// @Tags users-v2
+// @Accept json
+// @Produce json
+// @Param page query int false "页码，默认为1" default(1)
+// @Param page_size query int false "每页大小，默认为10" default(10)
+// @Param search query string false "按姓名或邮箱模糊搜索"
+// @Param role query string false "按角色筛选"
+// @Param sort query string false "排序字段，前缀-表示降序，多个字段用逗号分隔" example(-created_at)
+// @Success 200 {object} handlers.Response{data=UserListResponse}
+// @Failure 400,500 {object} handlers.Response{error=handlers.ErrorInfo}
+// @Router /api/v2/users [get]
+// @Security BearerAuth
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, pageSize := 1, 10
+	if v, err := strconv.Atoi(query.Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(query.Get("page_size")); err == nil && v > 0 {
+		pageSize = v
+	}
+
+	opts := dto.UserListOptions{
+		ListUsersFilter: dto.ListUsersFilter{
+			Search: query.Get("search"),
+			Role:   query.Get("role"),
+			Sort:   query.Get("sort"),
+		},
+	}
+
+	users, total, err := h.userService.ListUsers(r.Context(), page, pageSize, opts)
+	if err != nil {
+		handlers.RespondError(w, r, err)
+		return
+	}
+
+	list := dto.NewListResponse(nil, page, pageSize, total)
+	response := UserListResponse{
+		Items: make([]dto.UserResponse, len(users)),
+		Pagination: Pagination{
+			Page:       list.Page,
+			PageSize:   list.Size,
+			Total:      list.Total,
+			TotalPages: list.TotalPages,
+			HasNext:    list.HasNext,
+			HasPrev:    list.HasPrev,
+		},
+	}
+	for i, user := range users {
+		response.Items[i] = userResponse(user)
+	}
+
+	handlers.RespondJSON(w, http.StatusOK, response)
+}
+
+// userResponse 将用户模型转换为响应
+func userResponse(user *models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: user.AvatarURL,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		Version:   user.Version,
+		Verified:  user.Verified,
+	}
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/gorilla/websocket"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// WebSocket 消息类型
+const (
+	wsTypeMessage = "message" // 订阅主题的队列消息
+	wsTypeError   = "error"   // 客户端消息处理失败
+)
+
+// wsSendBuffer 每个连接待发送的错误通知缓冲数
+const wsSendBuffer = 8
+
+// WebSocketConfig WebSocket连接配置
+type WebSocketConfig struct {
+	Topics         []string      // 允许客户端订阅的主题，通过查询参数 topic 指定
+	PublishTopics  []string      // 允许客户端发布消息的主题，为空时客户端只能接收
+	AllowedOrigins []string      // 允许的来源，*表示任意来源，为空时只允许与服务同源的请求
+	PingInterval   time.Duration // 向客户端发送ping的间隔，应小于PongWait，<=0时使用 DefaultWebSocketConfig.PingInterval
+	PongWait       time.Duration // 等待客户端pong或消息的最长时间，超时后断开，<=0时使用 DefaultWebSocketConfig.PongWait
+	WriteWait      time.Duration // 单次写入的超时，<=0时使用 DefaultWebSocketConfig.WriteWait
+	MaxMessageSize int64         // 客户端消息大小上限（字节），<=0时使用 DefaultWebSocketConfig.MaxMessageSize
+}
+
+// DefaultWebSocketConfig 默认WebSocket连接配置
+var DefaultWebSocketConfig = WebSocketConfig{
+	PingInterval:   30 * time.Second,
+	PongWait:       60 * time.Second,
+	WriteWait:      10 * time.Second,
+	MaxMessageSize: 64 << 10,
+}
+
+// wsInbound 客户端发送的消息，负载发布到队列主题
+type wsInbound struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// wsOutbound 发送给客户端的消息
+type wsOutbound struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id,omitempty"`
+	Topic     string          `json:"topic,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Timestamp *time.Time      `json:"timestamp,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// WebSocketHandler 通过WebSocket双向收发队列消息
+// 客户端发送的消息发布到队列，订阅主题的队列消息推送给客户端
+type WebSocketHandler struct {
+	broker   *queue.Broker
+	queue    queue.Queue
+	logger   *slog.Logger
+	config   WebSocketConfig
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	closed  bool
+	closing chan struct{}
+	conns   sync.WaitGroup
+}
+
+// NewWebSocketHandler 创建一个新的 WebSocketHandler 实例，config为空时使用默认配置
+// q为空时客户端发布的消息返回错误通知
+func NewWebSocketHandler(broker *queue.Broker, q queue.Queue, logger *slog.Logger, config *WebSocketConfig) *WebSocketHandler {
+	if config == nil {
+		config = &DefaultWebSocketConfig
+	}
+
+	h := &WebSocketHandler{
+		broker:  broker,
+		queue:   q,
+		logger:  logger,
+		config:  *config,
+		closing: make(chan struct{}),
+	}
+	if h.config.PingInterval <= 0 {
+		h.config.PingInterval = DefaultWebSocketConfig.PingInterval
+	}
+	if h.config.PongWait <= 0 {
+		h.config.PongWait = DefaultWebSocketConfig.PongWait
+	}
+	if h.config.WriteWait <= 0 {
+		h.config.WriteWait = DefaultWebSocketConfig.WriteWait
+	}
+	if h.config.MaxMessageSize <= 0 {
+		h.config.MaxMessageSize = DefaultWebSocketConfig.MaxMessageSize
+	}
+	if len(h.config.AllowedOrigins) > 0 {
+		h.upgrader.CheckOrigin = h.checkOrigin
+	}
+	return h
+}
+
+// Connect 建立WebSocket连接
+// @Summary 建立WebSocket连接
+// @Description 升级为WebSocket连接，推送订阅主题的队列消息；客户端发送 {"topic","payload"} 将负载发布到允许的主题。浏览器无法设置请求头时可通过 access_token 查询参数传递令牌
+// @Tags events
+// @Param topic query []string false "订阅的主题，可重复指定" collectionFormat(multi)
+// @Param access_token query string false "访问令牌，未使用 Authorization 请求头时提供"
+// @Success 101 {string} string "切换协议"
+// @Failure 400,401,503 {object} Response{error=ErrorInfo}
+// @Router /api/v1/ws [get]
+// @Security BearerAuth
+func (h *WebSocketHandler) Connect(w http.ResponseWriter, r *http.Request) {
+	topics := r.URL.Query()[eventsTopicParam]
+	for _, topic := range topics {
+		if !slices.Contains(h.config.Topics, topic) {
+			RespondError(w, r, unsupportedTopic(topic, h.config.Topics))
+			return
+		}
+	}
+
+	// 关闭中不再接受新连接，登记连接后关闭时等待其完成关闭握手
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		RespondError(w, r, apperrors.ServiceUnavailableError("服务正在关闭", nil))
+		return
+	}
+	h.conns.Add(1)
+	h.mu.Unlock()
+	defer h.conns.Done()
+
+	// 升级失败时 Upgrader 已写入错误响应
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	messages, unsubscribe := h.broker.Subscribe(topics...)
+	defer unsubscribe()
+
+	errs := make(chan wsOutbound, wsSendBuffer)
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		h.readPump(r.Context(), conn, errs)
+	}()
+
+	h.writePump(conn, messages, errs, readDone)
+	conn.Close()
+	<-readDone
+}
+
+// readPump 读取客户端消息并发布到队列，连接断开或读取超时后返回
+func (h *WebSocketHandler) readPump(ctx context.Context, conn *websocket.Conn, errs chan<- wsOutbound) {
+	conn.SetReadLimit(h.config.MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(h.config.PongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				h.logger.Debug("WebSocket连接异常断开", "error", err)
+			}
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(h.config.PongWait))
+
+		if err := h.publish(ctx, data); err != nil {
+			// 发送缓冲已满时丢弃错误通知，不阻塞读取
+			select {
+			case errs <- *err:
+			default:
+			}
+		}
+	}
+}
+
+// publish 将客户端消息发布到队列，失败时返回发送给客户端的错误通知
+func (h *WebSocketHandler) publish(ctx context.Context, data []byte) *wsOutbound {
+	var in wsInbound
+	if err := json.Unmarshal(data, &in); err != nil {
+		return &wsOutbound{Type: wsTypeError, Error: "消息必须为包含topic和payload的JSON对象"}
+	}
+	if !slices.Contains(h.config.PublishTopics, in.Topic) {
+		return &wsOutbound{Type: wsTypeError, Topic: in.Topic, Error: fmt.Sprintf("不允许向主题%s发布消息", in.Topic)}
+	}
+	if h.queue == nil {
+		return &wsOutbound{Type: wsTypeError, Topic: in.Topic, Error: "消息队列不可用"}
+	}
+
+	payload := in.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	if err := h.queue.Publish(ctx, in.Topic, payload); err != nil {
+		h.logger.Error("发布WebSocket消息失败", "topic", in.Topic, "error", err)
+		return &wsOutbound{Type: wsTypeError, Topic: in.Topic, Error: "发布消息失败"}
+	}
+	return nil
+}
+
+// writePump 向客户端推送队列消息、错误通知和ping，是连接唯一的写入方
+// 客户端断开、写入失败或服务关闭时返回；服务关闭时先完成关闭握手
+func (h *WebSocketHandler) writePump(conn *websocket.Conn, messages <-chan *queue.Message, errs <-chan wsOutbound, readDone <-chan struct{}) {
+	ping := time.NewTicker(h.config.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-readDone:
+			return
+		case <-h.closing:
+			h.closeGracefully(conn, readDone)
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			timestamp := msg.Timestamp
+			if err := h.writeJSON(conn, wsOutbound{
+				Type:      wsTypeMessage,
+				ID:        msg.ID,
+				Topic:     msg.Topic,
+				Payload:   msg.Payload,
+				Timestamp: &timestamp,
+			}); err != nil {
+				return
+			}
+		case out := <-errs:
+			if err := h.writeJSON(conn, out); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(h.config.WriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeJSON 在写入超时内发送JSON消息
+func (h *WebSocketHandler) writeJSON(conn *websocket.Conn, v wsOutbound) error {
+	conn.SetWriteDeadline(time.Now().Add(h.config.WriteWait))
+	return conn.WriteJSON(v)
+}
+
+// closeGracefully 发送关闭帧并等待客户端回应，最多等待 WriteWait
+func (h *WebSocketHandler) closeGracefully(conn *websocket.Conn, readDone <-chan struct{}) {
+	message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	if err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(h.config.WriteWait)); err != nil {
+		return
+	}
+
+	select {
+	case <-readDone:
+	case <-time.After(h.config.WriteWait):
+	}
+}
+
+// checkOrigin 校验请求来源是否在允许列表中，未携带Origin的非浏览器客户端直接放行
+func (h *WebSocketHandler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(h.config.AllowedOrigins, "*") {
+		return true
+	}
+	if slices.Contains(h.config.AllowedOrigins, origin) {
+		return true
+	}
+
+	// 同源请求始终允许
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// Shutdown 向所有连接发送关闭帧并等待连接关闭，之后拒绝新连接
+// 被劫持的WebSocket连接不受 http.Server.Shutdown 管理，需要单独关闭
+func (h *WebSocketHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.closing)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.conns.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待WebSocket连接关闭超时: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// newTestWebSocket 创建使用miniredis队列的WebSocket服务，队列的 chat 主题转发给广播器
+func newTestWebSocket(t *testing.T, config *WebSocketConfig) (*WebSocketHandler, *queue.Broker, *httptest.Server) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	q := queue.NewRedisQueue(client, 1)
+	t.Cleanup(func() { q.Close() })
+
+	broker := queue.NewBroker(0)
+	require.NoError(t, broker.Attach(context.Background(), q, "chat"))
+
+	h := NewWebSocketHandler(broker, q, slog.New(slog.NewTextHandler(io.Discard, nil)), config)
+	srv := httptest.NewServer(http.HandlerFunc(h.Connect))
+	t.Cleanup(srv.Close)
+	return h, broker, srv
+}
+
+// dialWebSocket 建立WebSocket连接
+func dialWebSocket(t *testing.T, srv *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/ws"+query, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readOutbound 在超时内读取一条服务端消息
+func readOutbound(t *testing.T, conn *websocket.Conn) wsOutbound {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var out wsOutbound
+	require.NoError(t, conn.ReadJSON(&out))
+	return out
+}
+
+func TestWebSocketHandler_Connect(t *testing.T) {
+	config := &WebSocketConfig{Topics: []string{"chat"}, PublishTopics: []string{"chat"}}
+
+	// 客户端发送的消息发布到队列，再经订阅推送回客户端
+	t.Run("SendAndReceive", func(t *testing.T) {
+		_, broker, srv := newTestWebSocket(t, config)
+		conn := dialWebSocket(t, srv, "?topic=chat")
+		require.Equal(t, 1, broker.SubscriberCount("chat"))
+
+		require.NoError(t, conn.WriteJSON(map[string]interface{}{
+			"topic":   "chat",
+			"payload": map[string]string{"text": "hello"},
+		}))
+
+		out := readOutbound(t, conn)
+		assert.Equal(t, wsTypeMessage, out.Type)
+		assert.Equal(t, "chat", out.Topic)
+		assert.NotEmpty(t, out.ID)
+		assert.NotNil(t, out.Timestamp)
+		assert.JSONEq(t, `{"text":"hello"}`, string(out.Payload))
+
+		// 断开连接后取消订阅
+		conn.Close()
+		assert.Eventually(t, func() bool { return broker.SubscriberCount("chat") == 0 }, time.Second, 10*time.Millisecond)
+	})
+
+	// 不允许发布的主题和无效消息返回错误通知，连接保持可用
+	t.Run("PublishRejected", func(t *testing.T) {
+		_, _, srv := newTestWebSocket(t, &WebSocketConfig{Topics: []string{"chat"}})
+		conn := dialWebSocket(t, srv, "?topic=chat")
+
+		require.NoError(t, conn.WriteJSON(map[string]string{"topic": "chat"}))
+		out := readOutbound(t, conn)
+		assert.Equal(t, wsTypeError, out.Type)
+		assert.Equal(t, "chat", out.Topic)
+		assert.Contains(t, out.Error, "不允许")
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+		assert.Equal(t, wsTypeError, readOutbound(t, conn).Type)
+	})
+
+	// 服务端定期发送ping
+	t.Run("Ping", func(t *testing.T) {
+		_, _, srv := newTestWebSocket(t, &WebSocketConfig{PingInterval: 10 * time.Millisecond})
+		conn := dialWebSocket(t, srv, "")
+
+		pinged := make(chan struct{}, 1)
+		conn.SetPingHandler(func(string) error {
+			select {
+			case pinged <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+		go conn.ReadMessage()
+
+		select {
+		case <-pinged:
+		case <-time.After(5 * time.Second):
+			t.Fatal("没有收到ping")
+		}
+	})
+
+	// 关闭时向客户端发送关闭帧，等待连接关闭后拒绝新连接
+	t.Run("Shutdown", func(t *testing.T) {
+		h, _, srv := newTestWebSocket(t, config)
+		conn := dialWebSocket(t, srv, "?topic=chat")
+
+		shutdownErr := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdownErr <- h.Shutdown(ctx)
+		}()
+
+		// 读取到关闭帧后客户端自动回应关闭帧
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+		require.NoError(t, <-shutdownErr)
+
+		_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/ws", nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	})
+
+	// 订阅不允许的主题时拒绝升级
+	t.Run("InvalidTopic", func(t *testing.T) {
+		_, _, srv := newTestWebSocket(t, config)
+		_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/v1/ws?topic=secrets", nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestWebSocketHandler_CheckOrigin(t *testing.T) {
+	h := NewWebSocketHandler(queue.NewBroker(0), nil, slog.New(slog.NewTextHandler(io.Discard, nil)), &WebSocketConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+	})
+
+	check := func(origin string) bool {
+		req := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/v1/ws", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		return h.checkOrigin(req)
+	}
+
+	// 允许列表中的来源、同源请求和非浏览器客户端
+	assert.True(t, check("https://app.example.com"))
+	assert.True(t, check("http://api.example.com"))
+	assert.True(t, check(""))
+	// 其他来源
+	assert.False(t, check("https://evil.example.com"))
+}
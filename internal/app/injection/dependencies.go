@@ -1,17 +1,30 @@
 package injection
 
 import (
+	"context"
 	"log/slog"
+	"os"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/config"
+	"github.com/vadxq/go-rest-starter/internal/app/graphql"
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
 	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/featureflags"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
 	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/mailer"
 	"github.com/vadxq/go-rest-starter/pkg/queue"
+	"github.com/vadxq/go-rest-starter/pkg/scheduler"
+	"github.com/vadxq/go-rest-starter/pkg/storage"
+	"github.com/vadxq/go-rest-starter/pkg/templates"
 	"github.com/vadxq/go-rest-starter/pkg/transaction"
+	"github.com/vadxq/go-rest-starter/web"
 )
 
 // Dependencies 应用依赖容器
@@ -29,6 +42,21 @@ type Dependencies struct {
 	// 应用配置 - 全局配置信息
 	Config *config.AppConfig
 
+	// JWT配置 - 令牌签名与验证，认证服务和认证中间件共用
+	JWT *jwt.Config
+
+	// 缓存预热器 - 启动时预加载热点数据
+	CacheWarmer *cache.Warmer
+
+	// 定时任务调度器 - 周期执行后台任务，多实例时通过Redis锁保证只有一个实例执行
+	Scheduler *scheduler.Scheduler
+
+	// 功能开关 - 配置文件中的默认开关，启用Redis时由Redis中的开关覆盖
+	FeatureFlags featureflags.FlagProvider
+
+	// featureFlagDefaults 配置文件中的默认开关，配置重新加载时替换
+	featureFlagDefaults *featureflags.MemoryProvider
+
 	// 基础设施 - 提供底层支持
 	Infrastructure struct {
 		DB                *gorm.DB
@@ -38,6 +66,9 @@ type Dependencies struct {
 		Logger            logger.Logger
 		Queue             queue.Queue
 		TransactionManager transaction.Manager
+		Storage           storage.Storage
+		Broker            *queue.Broker
+		Templates         *templates.Engine
 	}
 }
 
@@ -51,6 +82,7 @@ func NewDependencies(
 	appConfig *config.AppConfig, // 应用配置
 	cacheInstance cache.Cache, // 缓存实例
 	appLogger logger.Logger, // 日志记录器
+	logLevel *slog.LevelVar, // 全局日志级别，可通过管理接口调整
 ) *Dependencies {
 	// 创建队列管理器（仅支持Redis）
 	var queueManager queue.Queue
@@ -60,11 +92,12 @@ func NewDependencies(
 	// 如果没有Redis，队列功能将不可用
 
 	// 创建事务管理器
-	txManager := transaction.NewGormTransactionManager(db)
+	txManager := transaction.NewGormTransactionManager(db, createTxRetryConfig(appConfig))
 
 	// 创建依赖容器
 	deps := &Dependencies{
 		Config: appConfig,
+		JWT:    createJWTConfig(appConfig),
 		Infrastructure: struct {
 			DB                *gorm.DB
 			Redis             *redis.Client
@@ -73,6 +106,9 @@ func NewDependencies(
 			Logger            logger.Logger
 			Queue             queue.Queue
 			TransactionManager transaction.Manager
+			Storage           storage.Storage
+			Broker            *queue.Broker
+			Templates         *templates.Engine
 		}{
 			DB:                db,
 			Redis:             rdb,
@@ -81,20 +117,192 @@ func NewDependencies(
 			Logger:            appLogger,
 			Queue:             queueManager,
 			TransactionManager: txManager,
+			Storage:           createStorage(appConfig),
+			Broker:            createBroker(queueManager, appConfig),
+			Templates:         createTemplates(appConfig),
 		},
 	}
 
+	// 创建功能开关
+	deps.featureFlagDefaults = featureflags.NewMemoryProvider(FeatureFlagSet(appConfig))
+	deps.FeatureFlags = createFeatureFlags(deps.featureFlagDefaults, rdb, appConfig)
+
+	// 订阅邮件队列并通过SMTP发送
+	startEmailSender(queueManager, deps.Infrastructure.Templates, appConfig)
+
 	// 1. 初始化仓库层依赖 - 数据访问层
-	deps.Repositories = InitRepositories(db)
+	deps.Repositories = InitRepositories(db, appLogger)
 
 	// 2. 初始化服务层依赖 - 业务逻辑层
-	deps.Services = InitServices(deps.Repositories, validate, db, appConfig, cacheInstance)
+	deps.Services = InitServices(deps.Repositories, validate, db, appConfig, cacheInstance, txManager, deps.JWT, deps.Infrastructure.Storage, queueManager, appLogger)
+	deps.CacheWarmer = InitCacheWarmer(deps.Services, appConfig, cacheInstance)
+	deps.Scheduler = InitScheduler(deps.Services, rdb)
 
 	// 3. 初始化处理器层依赖 - 表现层
 	// 需要将 logger.Logger 接口转换为 *slog.Logger
 	slogLogger := slog.Default()
-	deps.Handlers = InitHandlers(deps.Services, slogLogger, validate, db, rdb)
+	deps.Handlers = InitHandlers(deps.Services, slogLogger, validate, db, rdb, cacheInstance, queueManager, deps.JWT, &handlers.AvatarConfig{
+		MaxSize:      appConfig.Users.AvatarMaxSize,
+		AllowedTypes: appConfig.Users.AvatarAllowedTypes,
+	}, deps.Infrastructure.Broker, &handlers.EventsConfig{
+		Topics:    appConfig.Events.Topics,
+		Heartbeat: appConfig.Events.Heartbeat,
+	}, &handlers.WebSocketConfig{
+		Topics:         appConfig.Events.Topics,
+		PublishTopics:  appConfig.Events.WebSocket.PublishTopics,
+		AllowedOrigins: appConfig.CORS.AllowedOrigins,
+		PingInterval:   appConfig.Events.WebSocket.PingInterval,
+		PongWait:       appConfig.Events.WebSocket.PongWait,
+		MaxMessageSize: appConfig.Events.WebSocket.MaxMessageSize,
+	}, createGraphQLConfig(appConfig), logLevel)
 
 	// 返回组装好的依赖容器
 	return deps
 }
+
+// ReloadFeatureFlags 使用新配置中的开关替换默认开关，配置文件变更时调用；Redis中的开关不受影响
+func (d *Dependencies) ReloadFeatureFlags(config *config.AppConfig) {
+	d.featureFlagDefaults.Replace(FeatureFlagSet(config))
+	slog.Info("功能开关已重新加载", "count", len(config.FeatureFlags.Flags))
+}
+
+// FeatureFlagSet 将配置中的功能开关转换为 featureflags.Set
+func FeatureFlagSet(config *config.AppConfig) featureflags.Set {
+	flags := make(featureflags.Set, len(config.FeatureFlags.Flags))
+	for name, flag := range config.FeatureFlags.Flags {
+		flags[name] = featureflags.Flag{
+			Enabled:    flag.Enabled,
+			Percentage: flag.Percentage,
+			Users:      flag.Users,
+		}
+	}
+	return flags
+}
+
+// createFeatureFlags 创建功能开关，启用Redis且Redis可用时由Redis中的开关覆盖默认开关
+func createFeatureFlags(defaults *featureflags.MemoryProvider, rdb *redis.Client, config *config.AppConfig) featureflags.FlagProvider {
+	if !config.FeatureFlags.Redis {
+		return defaults
+	}
+	if rdb == nil {
+		slog.Warn("Redis不可用，功能开关只使用配置文件中的默认值")
+		return defaults
+	}
+	return featureflags.NewRedisProvider(rdb, featureflags.DefaultRedisKey, defaults)
+}
+
+// createTxRetryConfig 从应用配置创建事务重试策略
+func createTxRetryConfig(config *config.AppConfig) *apperrors.RetryConfig {
+	retry := transaction.DefaultRetryConfig
+	retry.MaxAttempts = config.Database.TxRetry.MaxAttempts
+	retry.InitialDelay = config.Database.TxRetry.InitialDelay
+	retry.MaxDelay = config.Database.TxRetry.MaxDelay
+	return &retry
+}
+
+// createGraphQLConfig 从应用配置创建GraphQL接口配置，未启用时返回nil
+func createGraphQLConfig(config *config.AppConfig) *graphql.Config {
+	if !config.GraphQL.Enabled {
+		return nil
+	}
+	return &graphql.Config{
+		MaxDepth:       config.GraphQL.MaxDepth,
+		MaxQueryLength: config.GraphQL.MaxQueryLength,
+		Introspection:  config.GraphQL.Introspection,
+	}
+}
+
+// createBroker 创建实时事件广播器，并订阅配置中需要推送给客户端的队列主题
+// 队列不可用时广播器没有消息来源，SSE客户端只能收到心跳
+func createBroker(q queue.Queue, config *config.AppConfig) *queue.Broker {
+	broker := queue.NewBroker(0)
+	if len(config.Events.Topics) == 0 {
+		return broker
+	}
+	if q == nil {
+		slog.Warn("消息队列不可用，实时事件不会推送", "topics", config.Events.Topics)
+		return broker
+	}
+
+	if err := broker.Attach(context.Background(), q, config.Events.Topics...); err != nil {
+		slog.Error("订阅实时事件主题失败", "error", err)
+		os.Exit(1)
+	}
+	return broker
+}
+
+// createTemplates 创建邮件和页面模板引擎，配置了模板目录时从该目录读取，否则使用内置模板；模板无效时退出
+func createTemplates(config *config.AppConfig) *templates.Engine {
+	fsys := web.Templates()
+	if config.Templates.Dir != "" {
+		fsys = os.DirFS(config.Templates.Dir)
+	}
+
+	engine, err := templates.New(fsys, &templates.Config{Reload: config.Templates.Reload})
+	if err != nil {
+		slog.Error("加载模板失败", "dir", config.Templates.Dir, "error", err)
+		os.Exit(1)
+	}
+	return engine
+}
+
+// startEmailSender 配置了SMTP服务器时订阅验证和密码重置邮件主题并发送邮件，配置无效时退出
+// 未配置SMTP服务器时不消费邮件主题，消息留在队列中，可由其他服务消费
+func startEmailSender(q queue.Queue, engine *templates.Engine, config *config.AppConfig) {
+	if config.Mail.SMTP.Host == "" {
+		return
+	}
+	if q == nil {
+		slog.Warn("消息队列不可用，不会发送邮件", "smtp_host", config.Mail.SMTP.Host)
+		return
+	}
+
+	m, err := mailer.NewSMTPMailer(&mailer.SMTPConfig{
+		Host:     config.Mail.SMTP.Host,
+		Port:     config.Mail.SMTP.Port,
+		Username: config.Mail.SMTP.Username,
+		Password: config.Mail.SMTP.Password,
+		From:     config.Mail.SMTP.From,
+		TLS:      config.Mail.SMTP.TLS,
+		Timeout:  config.Mail.SMTP.Timeout,
+	})
+	if err != nil {
+		slog.Error("创建SMTP邮件发送器失败", "error", err)
+		os.Exit(1)
+	}
+	emailTemplates, err := services.LoadEmailTemplates(engine)
+	if err != nil {
+		slog.Error("加载邮件模板失败", "error", err)
+		os.Exit(1)
+	}
+	if err := services.NewEmailSender(m, emailTemplates).Subscribe(context.Background(), q, nil); err != nil {
+		slog.Error("订阅邮件主题失败", "error", err)
+		os.Exit(1)
+	}
+}
+
+// createStorage 从应用配置创建文件存储，配置无效时退出
+func createStorage(config *config.AppConfig) storage.Storage {
+	fileStorage, err := storage.New(&storage.Config{
+		Driver: config.Storage.Driver,
+		Local: storage.LocalConfig{
+			Dir:        config.Storage.Local.Dir,
+			BaseURL:    config.Storage.Local.BaseURL,
+			SigningKey: config.Storage.Local.SigningKey,
+		},
+		S3: storage.S3Config{
+			Endpoint:        config.Storage.S3.Endpoint,
+			Region:          config.Storage.S3.Region,
+			Bucket:          config.Storage.S3.Bucket,
+			AccessKeyID:     config.Storage.S3.AccessKeyID,
+			SecretAccessKey: config.Storage.S3.SecretAccessKey,
+			PathStyle:       config.Storage.S3.PathStyle,
+			BaseURL:         config.Storage.S3.BaseURL,
+		},
+	})
+	if err != nil {
+		slog.Error("创建文件存储失败", "error", err)
+		os.Exit(1)
+	}
+	return fileStorage
+}
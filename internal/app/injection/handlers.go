@@ -1,20 +1,36 @@
 package injection
 
 import (
+	"context"
 	"log/slog"
+	"os"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
+	"github.com/vadxq/go-rest-starter/internal/app/graphql"
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	v2handlers "github.com/vadxq/go-rest-starter/internal/app/handlers/v2"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
 )
 
 // Handlers 包含所有HTTP处理器
 type Handlers struct {
-	UserHandler   *handlers.UserHandler
-	AuthHandler   *handlers.AuthHandler
-	HealthHandler *handlers.HealthHandler
+	UserHandler      *handlers.UserHandler
+	UserV2Handler    *v2handlers.UserHandler
+	AuthHandler      *handlers.AuthHandler
+	HealthHandler    *handlers.HealthHandler
+	JWKSHandler      *handlers.JWKSHandler
+	AuditHandler     *handlers.AuditHandler
+	AvatarHandler    *handlers.AvatarHandler
+	EventsHandler    *handlers.EventsHandler
+	WebSocketHandler *handlers.WebSocketHandler
+	GraphQLHandler   *graphql.Handler          // 未启用GraphQL接口时为空
+	LogLevelHandler  *handlers.LogLevelHandler // 未提供日志级别时为空
 }
 
 // InitHandlers 初始化所有HTTP处理器
@@ -24,12 +40,28 @@ func InitHandlers(
 	validator *validator.Validate,
 	db *gorm.DB,
 	redis *redis.Client,
+	cache cache.Cache,
+	queue queue.Queue,
+	jwtConfig *jwt.Config,
+	avatarConfig *handlers.AvatarConfig,
+	broker *queue.Broker,
+	eventsConfig *handlers.EventsConfig,
+	websocketConfig *handlers.WebSocketConfig,
+	graphqlConfig *graphql.Config,
+	logLevel *slog.LevelVar,
 ) *Handlers {
 	// 初始化用户处理器
 	userHandler := handlers.NewUserHandler(
 		services.UserService,
 		logger,
 		validator,
+		func(ctx context.Context) bool { return custommiddleware.HasRole(ctx, "admin") },
+	)
+
+	// 初始化 v2 用户处理器
+	userV2Handler := v2handlers.NewUserHandler(
+		services.UserService,
+		logger,
 	)
 
 	// 初始化认证处理器
@@ -43,12 +75,76 @@ func InitHandlers(
 	healthHandler := handlers.NewHealthHandler(
 		db,
 		redis,
+		cache,
+		queue,
+		logger,
+	)
+
+	// 初始化公钥分发处理器
+	jwksHandler := handlers.NewJWKSHandler(jwtConfig)
+
+	// 初始化审计日志处理器
+	auditHandler := handlers.NewAuditHandler(
+		services.AuditService,
+		logger,
+	)
+
+	// 初始化头像处理器
+	avatarHandler := handlers.NewAvatarHandler(
+		services.AvatarService,
 		logger,
+		avatarConfig,
 	)
 
+	// 初始化实时事件处理器
+	eventsHandler := handlers.NewEventsHandler(
+		broker,
+		logger,
+		eventsConfig,
+	)
+
+	// 初始化WebSocket处理器
+	websocketHandler := handlers.NewWebSocketHandler(
+		broker,
+		queue,
+		logger,
+		websocketConfig,
+	)
+
+	// 初始化GraphQL处理器，graphqlConfig为空时不启用
+	var graphqlHandler *graphql.Handler
+	if graphqlConfig != nil {
+		var err error
+		graphqlHandler, err = graphql.NewHandler(
+			services.UserService,
+			services.AuthService,
+			logger,
+			validator,
+			graphqlConfig,
+		)
+		if err != nil {
+			slog.Error("初始化GraphQL处理器失败", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// 初始化日志级别处理器，logLevel为空时不开放调整接口
+	var logLevelHandler *handlers.LogLevelHandler
+	if logLevel != nil {
+		logLevelHandler = handlers.NewLogLevelHandler(logLevel, logger, validator)
+	}
+
 	return &Handlers{
-		UserHandler:   userHandler,
-		AuthHandler:   authHandler,
-		HealthHandler: healthHandler,
+		UserHandler:      userHandler,
+		UserV2Handler:    userV2Handler,
+		AuthHandler:      authHandler,
+		HealthHandler:    healthHandler,
+		JWKSHandler:      jwksHandler,
+		AuditHandler:     auditHandler,
+		AvatarHandler:    avatarHandler,
+		EventsHandler:    eventsHandler,
+		WebSocketHandler: websocketHandler,
+		GraphQLHandler:   graphqlHandler,
+		LogLevelHandler:  logLevelHandler,
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/repository"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
 // Repositories 所有仓库的集合
@@ -15,14 +16,17 @@ type Repositories struct {
 	// 用户数据访问对象
 	UserRepo repository.UserRepository
 
+	// 审计日志数据访问对象
+	AuditLogRepo repository.AuditLogRepository
+
 	// 可以在此添加更多仓库...
 	// ProductRepo repository.ProductRepository
 	// OrderRepo repository.OrderRepository
 }
 
 // InitRepositories 初始化所有仓库
-// 这是依赖注入的第一层，负责创建所有数据访问对象
-func InitRepositories(db *gorm.DB) *Repositories {
+// 这是依赖注入的第一层，负责创建所有数据访问对象，appLogger 用于记录带追踪ID的数据库错误
+func InitRepositories(db *gorm.DB, appLogger logger.Logger) *Repositories {
 	// 参数验证
 	if db == nil {
 		slog.Error("数据库连接不能为空")
@@ -30,10 +34,12 @@ func InitRepositories(db *gorm.DB) *Repositories {
 	}
 
 	// 创建所有仓库实例
-	userRepo := repository.NewUserRepository(db)
+	userRepo := repository.NewUserRepository(db, appLogger)
+	auditLogRepo := repository.NewAuditLogRepository(db, appLogger)
 
 	// 返回仓库集合
 	return &Repositories{
-		UserRepo: userRepo,
+		UserRepo:     userRepo,
+		AuditLogRepo: auditLogRepo,
 	}
 }
@@ -1,16 +1,26 @@
 package injection
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/config"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
 	"github.com/vadxq/go-rest-starter/internal/app/services"
 	"github.com/vadxq/go-rest-starter/pkg/cache"
 	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/lock"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+	"github.com/vadxq/go-rest-starter/pkg/scheduler"
+	"github.com/vadxq/go-rest-starter/pkg/storage"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
 )
 
 // Services 所有服务的集合
@@ -22,6 +32,12 @@ type Services struct {
 	// 认证相关业务逻辑
 	AuthService services.AuthService
 
+	// 审计日志相关业务逻辑
+	AuditService services.AuditService
+
+	// 用户头像相关业务逻辑
+	AvatarService services.AvatarService
+
 	// 可以在此添加更多服务...
 	// ProductService services.ProductService
 	// OrderService services.OrderService
@@ -35,6 +51,11 @@ func InitServices(
 	db *gorm.DB,
 	config *config.AppConfig,
 	cacheInstance cache.Cache,
+	txManager transaction.Manager,
+	jwtConfig *jwt.Config,
+	fileStorage storage.Storage,
+	q queue.Queue,
+	appLogger logger.Logger,
 ) *Services {
 	// 参数验证
 	if repos == nil {
@@ -54,31 +75,150 @@ func InitServices(
 		os.Exit(1)
 	}
 
-	// 创建JWT配置
-	jwtConfig := createJWTConfig(config)
+	if jwtConfig == nil {
+		slog.Error("JWT配置不能为空")
+		os.Exit(1)
+	}
+	if fileStorage == nil {
+		slog.Error("文件存储不能为空")
+		os.Exit(1)
+	}
+
+	// 创建密码哈希器，新密码使用配置的算法，已存储的哈希按前缀校验
+	hasher, err := utils.NewPasswordHasher(&utils.PasswordHashConfig{
+		Algorithm:  config.Auth.PasswordHash.Algorithm,
+		BcryptCost: config.Auth.PasswordHash.BcryptCost,
+		Argon2id: utils.Argon2idParams{
+			Memory:      config.Auth.PasswordHash.Argon2Memory,
+			Iterations:  config.Auth.PasswordHash.Argon2Iterations,
+			Parallelism: config.Auth.PasswordHash.Argon2Parallelism,
+		},
+	})
+	if err != nil {
+		slog.Error("创建密码哈希器失败", "error", err)
+		os.Exit(1)
+	}
 
 	// 创建所有服务实例
-	userService := services.NewUserService(repos.UserRepo, validate, db, cacheInstance)
-	authService := services.NewAuthService(repos.UserRepo, validate, db, jwtConfig, cacheInstance)
+	auditService := services.NewAuditService(repos.AuditLogRepo)
+	verifier := createEmailVerifier(config, jwtConfig, q)
+	policy := &utils.PasswordPolicy{
+		MinLength:        config.Auth.Password.MinLength,
+		RequireUppercase: config.Auth.Password.RequireUppercase,
+		RequireLowercase: config.Auth.Password.RequireLowercase,
+		RequireDigit:     config.Auth.Password.RequireDigit,
+		RequireSymbol:    config.Auth.Password.RequireSymbol,
+		Denylist:         config.Auth.Password.Denylist,
+	}
+	userService := services.NewUserService(repos.UserRepo, validate, txManager, cacheInstance, policy, hasher, config.Users.BulkMaxSize, auditService, verifier, appLogger)
+	resetter := services.NewPasswordResetter(jwtConfig, q, &services.PasswordResetConfig{
+		TokenExp: config.Auth.PasswordReset.TokenExp,
+		URL:      config.Auth.PasswordReset.URL,
+	})
+	authService := services.NewAuthService(repos.UserRepo, validate, db, jwtConfig, cacheInstance, &services.LockoutConfig{
+		Threshold: config.Auth.LockoutThreshold,
+		Window:    config.Auth.LockoutWindow,
+		Duration:  config.Auth.LockoutDuration,
+	}, hasher, policy, config.Auth.EmailVerification.Required, resetter)
+	avatarService := services.NewAvatarService(repos.UserRepo, txManager, cacheInstance, fileStorage, auditService, appLogger)
 
 	// 返回服务集合
 	return &Services{
-		UserService: userService,
-		AuthService: authService,
+		UserService:   userService,
+		AuthService:   authService,
+		AuditService:  auditService,
+		AvatarService: avatarService,
 	}
 }
 
-// createJWTConfig 从应用配置创建JWT配置
-// 这是一个辅助函数，用于创建JWT服务所需的配置
-func createJWTConfig(config *config.AppConfig) *jwt.Config {
-	if config.JWT.Secret == "" {
-		slog.Warn("JWT密钥为空，这可能导致安全问题")
+// createEmailVerifier 从应用配置创建邮箱验证器，未启用时返回nil
+func createEmailVerifier(config *config.AppConfig, jwtConfig *jwt.Config, q queue.Queue) *services.EmailVerifier {
+	cfg := config.Auth.EmailVerification
+	if cfg.Required && !cfg.Enabled {
+		slog.Warn("要求邮箱验证但未启用验证邮件，新用户将无法登录")
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+	if q == nil {
+		slog.Warn("消息队列不可用，验证邮件不会发送")
+	}
+	return services.NewEmailVerifier(jwtConfig, q, &services.EmailVerificationConfig{
+		TokenExp: cfg.TokenExp,
+		URL:      cfg.URL,
+	})
+}
+
+// InitCacheWarmer 创建缓存预热器并注册各服务的预热函数
+// 缓存不可用时不注册任何预热函数
+func InitCacheWarmer(svcs *Services, config *config.AppConfig, cacheInstance cache.Cache) *cache.Warmer {
+	warmer := cache.NewWarmer(&cache.WarmerConfig{Timeout: config.Cache.WarmupTimeout})
+	if !cache.Available(cacheInstance) {
+		return warmer
 	}
 
-	return &jwt.Config{
+	// 用户列表首页，与列表接口的默认分页一致
+	warmer.Register("user_list", func(ctx context.Context) error {
+		_, _, err := svcs.UserService.ListUsers(ctx, 1, 10, dto.UserListOptions{})
+		return err
+	})
+
+	// 可以在此注册更多服务的预热函数...
+
+	return warmer
+}
+
+// InitScheduler 创建定时任务调度器并注册定时任务，应用启动后开始调度
+// 有Redis时通过分布式锁保证多实例部署时同一任务只有一个实例执行
+func InitScheduler(svcs *Services, rdb *redis.Client) *scheduler.Scheduler {
+	var locker *lock.Locker
+	if rdb != nil {
+		locker = lock.NewLocker(rdb)
+	}
+	s := scheduler.NewScheduler(locker, nil)
+
+	// 在此注册定时任务，任务通常调用 svcs 中的服务方法，例如每天3点执行：
+	// s.Register("cleanup", "0 3 * * *", func(ctx context.Context) error { ... })
+
+	return s
+}
+
+// createJWTConfig 从应用配置创建JWT配置
+// 这是一个辅助函数，用于创建JWT服务所需的配置，RS256时从PEM文件加载密钥
+func createJWTConfig(config *config.AppConfig) *jwt.Config {
+	jwtConfig := &jwt.Config{
 		Secret:          config.JWT.Secret,
 		AccessTokenExp:  config.JWT.AccessTokenExp,
 		RefreshTokenExp: config.JWT.RefreshTokenExp,
 		Issuer:          config.JWT.Issuer,
+		Audience:        config.JWT.Audience,
+		LeewayDuration:  config.JWT.Leeway,
+		Algorithm:       config.JWT.Algorithm,
+		PrivateKeyFile:  config.JWT.PrivateKeyFile,
+		PublicKeyFile:   config.JWT.PublicKeyFile,
+		KeyID:           config.JWT.KeyID,
+
+		PreviousPublicKeyFiles: config.JWT.PreviousPublicKeyFiles,
+		RoleScopes:             config.JWT.RoleScopes,
 	}
+
+	switch jwtConfig.Algorithm {
+	case "", jwt.AlgorithmHS256:
+		if jwtConfig.Secret == "" {
+			slog.Warn("JWT密钥为空，这可能导致安全问题")
+		}
+	case jwt.AlgorithmRS256:
+		if err := jwtConfig.LoadKeys(); err != nil {
+			slog.Error("加载JWT密钥失败", "error", err)
+			os.Exit(1)
+		}
+		if jwtConfig.PrivateKey == nil {
+			slog.Warn("未配置JWT私钥，当前实例只能验证令牌")
+		}
+	default:
+		slog.Error("不支持的JWT签名算法", "algorithm", jwtConfig.Algorithm)
+		os.Exit(1)
+	}
+
+	return jwtConfig
 }
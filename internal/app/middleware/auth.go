@@ -4,11 +4,17 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
+
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
 	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
 // UserIDKey 用户ID键
@@ -17,14 +23,27 @@ type UserIDKey struct{}
 // RoleKey 角色键
 type RoleKey struct{}
 
+// ScopeKey 权限范围键
+type ScopeKey struct{}
+
 // JWTConfig JWT中间件配置
 type JWTConfig struct {
-	Secret       string   // JWT密钥
-	ExcludePaths []string // 排除的路径（不需要认证）
+	Token        *jwtpkg.Config // 令牌验证配置（算法与密钥）
+	ExcludePaths []string       // 排除的路径（不需要认证）
+	// QueryParam 请求头中没有令牌时读取令牌的查询参数，为空时只接受请求头；
+	// 用于浏览器WebSocket等无法设置请求头的客户端
+	QueryParam string
+	// Optional 为true时未携带令牌的请求直接放行（上下文中没有用户信息），由处理器自行校验；
+	// 携带的令牌无效时仍然拒绝。用于同一接口中既有公开操作又有受保护操作的场景（如GraphQL）
+	Optional bool
+	// Cache 令牌黑名单所在的缓存，登出或撤销会话后的令牌被拒绝；不可用时不检查黑名单
+	Cache cache.Cache
 }
 
 // JWTAuth JWT认证中间件
 func JWTAuth(config *JWTConfig) func(http.Handler) http.Handler {
+	blacklist := jwtpkg.NewBlacklist(config.Cache, config.Token.LeewayDuration)
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// 跳过OPTIONS请求
@@ -42,32 +61,48 @@ func JWTAuth(config *JWTConfig) func(http.Handler) http.Handler {
 				}
 			}
 
-			// 从请求头中获取令牌
+			// 从请求头中获取令牌，未提供时按配置从查询参数获取
 			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
-				renderUnauthorized(w, "缺少认证令牌")
+			var tokenString string
+			switch {
+			case authHeader != "":
+				// 提取令牌
+				tokenParts := strings.Split(authHeader, " ")
+				if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+					renderUnauthorized(w, r, "认证令牌格式无效")
+					return
+				}
+				tokenString = tokenParts[1]
+			case config.QueryParam != "" && r.URL.Query().Get(config.QueryParam) != "":
+				tokenString = takeQueryToken(r, config.QueryParam)
+			case config.Optional:
+				next.ServeHTTP(w, r)
 				return
-			}
-
-			// 提取令牌
-			tokenParts := strings.Split(authHeader, " ")
-			if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-				renderUnauthorized(w, "认证令牌格式无效")
+			default:
+				renderUnauthorized(w, r, "缺少认证令牌")
 				return
 			}
-			tokenString := tokenParts[1]
 
 			// 解析令牌
-			claims, err := jwtpkg.ParseToken(tokenString, config.Secret)
+			claims, err := jwtpkg.ParseToken(tokenString, config.Token)
 			if err != nil {
 				slog.Error("解析令牌失败", "error", err, "token", tokenString)
-				renderUnauthorized(w, "无效的认证令牌")
+				renderUnauthorized(w, r, "无效的认证令牌")
+				return
+			}
+
+			// 已登出或所属会话已撤销的令牌
+			if blacklist.IsRevoked(r.Context(), claims) {
+				renderUnauthorized(w, r, "认证令牌已失效")
 				return
 			}
 
 			// 将用户ID和角色添加到上下文
 			ctx := context.WithValue(r.Context(), UserIDKey{}, claims.UserID)
 			ctx = context.WithValue(ctx, RoleKey{}, claims.Role)
+			ctx = context.WithValue(ctx, ScopeKey{}, claims.Scopes())
+			// 同时写入日志上下文，供不依赖中间件包的服务层读取操作者（如审计日志）
+			ctx = logger.WithUserID(ctx, strconv.FormatUint(uint64(claims.UserID), 10))
 
 			// 如果有请求上下文，也添加用户信息到请求上下文
 			reqCtx := GetRequestContext(ctx)
@@ -82,6 +117,20 @@ func JWTAuth(config *JWTConfig) func(http.Handler) http.Handler {
 	}
 }
 
+// takeQueryToken 读取查询参数中的令牌并将其从请求地址中移除，避免令牌写入访问日志
+// 请求地址为外层中间件共享，直接修改
+func takeQueryToken(r *http.Request, param string) string {
+	query := r.URL.Query()
+	token := query.Get(param)
+	query.Del(param)
+	r.URL.RawQuery = query.Encode()
+	r.RequestURI = r.URL.RequestURI()
+	if reqCtx := GetRequestContext(r.Context()); reqCtx != nil {
+		reqCtx.RequestURI = r.RequestURI
+	}
+	return token
+}
+
 // GetUserID 从上下文中获取用户ID
 func GetUserID(ctx context.Context) (uint, bool) {
 	userID, ok := ctx.Value(UserIDKey{}).(uint)
@@ -94,28 +143,130 @@ func GetRole(ctx context.Context) (string, bool) {
 	return role, ok
 }
 
+// GetScopes 从上下文中获取令牌的权限范围
+func GetScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(ScopeKey{}).([]string)
+	return scopes
+}
+
+// HasScope 判断上下文中的令牌是否拥有指定权限范围
+func HasScope(ctx context.Context, scope string) bool {
+	return slices.Contains(GetScopes(ctx), scope)
+}
+
+// RequireScope 要求令牌同时拥有所有指定权限范围的中间件，粒度比角色更细
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := GetScopes(r.Context())
+			for _, scope := range scopes {
+				if !slices.Contains(granted, scope) {
+					renderForbidden(w, r, "缺少权限范围: "+scope)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasRole 判断上下文中的用户是否拥有指定角色
+func HasRole(ctx context.Context, role string) bool {
+	return containsRole(getRoles(ctx), role)
+}
+
 // RequireRole 要求特定角色的中间件
 func RequireRole(role string) func(http.Handler) http.Handler {
+	return RequireAnyRole(role)
+}
+
+// RequireAnyRole 要求用户拥有任一指定角色的中间件
+func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userRole, ok := GetRole(r.Context())
-			if !ok || userRole != role {
-				renderForbidden(w, "没有权限访问")
+			userRoles := getRoles(r.Context())
+			for _, role := range roles {
+				if containsRole(userRoles, role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			renderForbidden(w, r, "没有权限访问")
+		})
+	}
+}
+
+// RequireAllRoles 要求用户同时拥有所有指定角色的中间件
+func RequireAllRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userRoles := getRoles(r.Context())
+			if len(userRoles) == 0 {
+				renderForbidden(w, r, "没有权限访问")
 				return
 			}
+			for _, role := range roles {
+				if !containsRole(userRoles, role) {
+					renderForbidden(w, r, "没有权限访问")
+					return
+				}
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// RequireSelfOrRole 要求路由参数param为当前用户ID或用户拥有指定角色的中间件，用于只允许本人或管理员操作的资源
+func RequireSelfOrRole(param, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID, ok := GetUserID(r.Context()); ok && chi.URLParam(r, param) == strconv.FormatUint(uint64(userID), 10) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if containsRole(getRoles(r.Context()), role) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			renderForbidden(w, r, "没有权限访问")
+		})
+	}
+}
+
+// getRoles 从上下文中获取用户角色列表，多个角色以逗号分隔
+func getRoles(ctx context.Context) []string {
+	role, ok := GetRole(ctx)
+	if !ok || role == "" {
+		return nil
+	}
+
+	var roles []string
+	for _, r := range strings.Split(role, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+// containsRole 判断角色列表中是否包含指定角色
+func containsRole(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // 渲染未授权错误响应
-func renderUnauthorized(w http.ResponseWriter, message string) {
+func renderUnauthorized(w http.ResponseWriter, r *http.Request, message string) {
 	err := apperrors.New(apperrors.ErrorTypeUnauthorized, message, nil)
-	handlers.RespondError(w, err)
+	handlers.RespondError(w, r, err)
 }
 
 // 渲染权限不足错误响应
-func renderForbidden(w http.ResponseWriter, message string) {
+func renderForbidden(w http.ResponseWriter, r *http.Request, message string) {
 	err := apperrors.New(apperrors.ErrorTypeForbidden, message, nil)
-	handlers.RespondError(w, err)
+	handlers.RespondError(w, r, err)
 }
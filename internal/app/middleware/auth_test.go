@@ -0,0 +1,299 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+func doRoleRequest(mw func(http.Handler) http.Handler, role string, withRole bool) int {
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	if withRole {
+		req = req.WithContext(context.WithValue(req.Context(), RoleKey{}, role))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequireAnyRole(t *testing.T) {
+	mw := RequireAnyRole("admin", "editor")
+
+	// 匹配任一角色即放行
+	t.Run("Allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRoleRequest(mw, "admin", true))
+		assert.Equal(t, http.StatusOK, doRoleRequest(mw, "editor", true))
+		assert.Equal(t, http.StatusOK, doRoleRequest(mw, "user, editor", true))
+	})
+
+	// 角色不在列表中
+	t.Run("Denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "user", true))
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "", true))
+	})
+
+	// 上下文中没有角色
+	t.Run("MissingRole", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "", false))
+	})
+}
+
+func TestRequireAllRoles(t *testing.T) {
+	mw := RequireAllRoles("admin", "editor")
+
+	// 拥有全部角色才放行
+	t.Run("Allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRoleRequest(mw, "admin,editor", true))
+		assert.Equal(t, http.StatusOK, doRoleRequest(mw, "editor, user, admin", true))
+	})
+
+	// 只拥有部分角色
+	t.Run("Denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "admin", true))
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "user", true))
+	})
+
+	// 上下文中没有角色
+	t.Run("MissingRole", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "", false))
+	})
+}
+
+func TestRequireRole(t *testing.T) {
+	mw := RequireRole("admin")
+
+	assert.Equal(t, http.StatusOK, doRoleRequest(mw, "admin", true))
+	assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "user", true))
+	assert.Equal(t, http.StatusForbidden, doRoleRequest(mw, "", false))
+}
+
+func TestRequireSelfOrRole(t *testing.T) {
+	r := chi.NewRouter()
+	r.With(RequireSelfOrRole("id", "admin")).Post("/users/{id}/avatar", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	doRequest := func(id string, userID uint, role string) int {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+id+"/avatar", nil)
+		ctx := context.WithValue(req.Context(), UserIDKey{}, userID)
+		ctx = context.WithValue(ctx, RoleKey{}, role)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req.WithContext(ctx))
+		return rec.Code
+	}
+
+	// 本人或管理员可以操作
+	assert.Equal(t, http.StatusOK, doRequest("42", 42, "user"))
+	assert.Equal(t, http.StatusOK, doRequest("42", 1, "admin"))
+
+	// 其他用户返回403
+	assert.Equal(t, http.StatusForbidden, doRequest("42", 7, "user"))
+	assert.Equal(t, http.StatusForbidden, doRequest("042", 42, "user"))
+}
+
+func TestJWTAuth_QueryParam(t *testing.T) {
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	token, err := jwtpkg.GenerateAccessToken(42, "user", "family", tokenConfig)
+	require.NoError(t, err)
+
+	var gotUserID uint
+	var gotQuery string
+	handler := JWTAuth(&JWTConfig{Token: tokenConfig, QueryParam: "access_token"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = GetUserID(r.Context())
+		gotQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// 查询参数中的令牌通过认证，并从请求地址中移除
+	t.Run("Valid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws?topic=chat&access_token="+token, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, uint(42), gotUserID)
+		assert.Equal(t, "topic=chat", gotQuery)
+		assert.Equal(t, "topic=chat", req.URL.RawQuery)
+	})
+
+	// 请求头优先于查询参数
+	t.Run("HeaderTakesPrecedence", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws?access_token="+token, nil)
+		req.Header.Set("Authorization", "Bearer invalid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	// 无效令牌和未配置查询参数时拒绝
+	t.Run("Rejected", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ws?access_token=invalid", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		headerOnly := JWTAuth(&JWTConfig{Token: tokenConfig})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		rec = httptest.NewRecorder()
+		headerOnly.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/ws?access_token="+token, nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestJWTAuth_Optional(t *testing.T) {
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	token, err := jwtpkg.GenerateAccessToken(42, "admin", "family", tokenConfig)
+	require.NoError(t, err)
+
+	var authenticated, admin bool
+	handler := JWTAuth(&JWTConfig{Token: tokenConfig, Optional: true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, authenticated = GetUserID(r.Context())
+		admin = HasRole(r.Context(), "admin")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// 未携带令牌时放行，上下文中没有用户信息
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/graphql", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, authenticated)
+	assert.False(t, admin)
+
+	// 有效令牌写入用户信息
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, authenticated)
+	assert.True(t, admin)
+
+	// 无效令牌仍然拒绝
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/graphql", nil)
+	req.Header.Set("Authorization", "Bearer invalid")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequireScope(t *testing.T) {
+	tokenConfig := &jwtpkg.Config{
+		Secret:         "test-secret",
+		AccessTokenExp: time.Hour,
+		Audience:       "api",
+		RoleScopes: map[string][]string{
+			"user":  {"users:read"},
+			"admin": {"users:read", "users:write"},
+		},
+	}
+	handler := JWTAuth(&JWTConfig{Token: tokenConfig})(RequireScope("users:read", "users:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	doRequest := func(role string) int {
+		token, err := jwtpkg.GenerateAccessToken(42, role, "family", tokenConfig)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// 拥有全部权限范围
+	t.Run("Allowed", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, doRequest("admin"))
+	})
+
+	// 只拥有部分权限范围或没有权限范围
+	t.Run("Denied", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, doRequest("user"))
+		assert.Equal(t, http.StatusForbidden, doRequest("guest"))
+	})
+
+	// 受众不匹配的令牌在认证阶段被拒绝
+	t.Run("WrongAudience", func(t *testing.T) {
+		other := *tokenConfig
+		other.Audience = "other"
+		token, err := jwtpkg.GenerateAccessToken(42, "admin", "family", &other)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestJWTAuth_Blacklist(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	authService := services.NewAuthService(nil, validator.New(), nil, tokenConfig, c, nil, nil, nil, false, nil)
+	handler := JWTAuth(&JWTConfig{Token: tokenConfig, Cache: c})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	doRequest := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// 登出后的令牌被拒绝，同一会话中的其他令牌不受影响
+	t.Run("Logout", func(t *testing.T) {
+		token, err := jwtpkg.GenerateAccessToken(42, "user", "family", tokenConfig)
+		require.NoError(t, err)
+		other, err := jwtpkg.GenerateAccessToken(42, "user", "family", tokenConfig)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, doRequest(token))
+
+		require.NoError(t, authService.Logout(context.Background(), token))
+		assert.Equal(t, http.StatusUnauthorized, doRequest(token))
+		assert.Equal(t, http.StatusOK, doRequest(other))
+	})
+
+	// 撤销令牌家族后家族内的所有令牌被拒绝
+	t.Run("RevokedFamily", func(t *testing.T) {
+		token, err := jwtpkg.GenerateAccessToken(42, "user", "revoked", tokenConfig)
+		require.NoError(t, err)
+
+		require.NoError(t, jwtpkg.NewBlacklist(c, 0).RevokeFamily(context.Background(), "revoked", time.Hour))
+		assert.Equal(t, http.StatusUnauthorized, doRequest(token))
+	})
+
+	// 缓存不可用时不检查黑名单
+	t.Run("NoCache", func(t *testing.T) {
+		token, err := jwtpkg.GenerateAccessToken(42, "user", "revoked", tokenConfig)
+		require.NoError(t, err)
+
+		noCache := JWTAuth(&JWTConfig{Token: tokenConfig, Cache: cache.NewNullCache()})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		noCache.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// BodyLoggingConfig 请求和响应体日志配置，仅用于调试，生产环境不建议开启
+type BodyLoggingConfig struct {
+	Paths        []string         // 记录请求和响应体的路径前缀，为空时不记录任何请求
+	ExcludePaths []string         // 始终不记录的路径前缀，优先于Paths，如认证接口
+	MaxBodySize  int              // 每个请求体或响应体最多记录的字节数
+	Redactor     *logger.Redactor // 脱敏器，为空时只脱敏内置敏感字段
+	Logger       *slog.Logger     // 为空时使用默认日志记录器
+}
+
+// DefaultBodyLoggingConfig 默认请求和响应体日志配置，认证和账户接口包含令牌和密码，始终不记录
+var DefaultBodyLoggingConfig = BodyLoggingConfig{
+	ExcludePaths: []string{"/api/v1/auth", "/api/v1/account"},
+	MaxBodySize:  4 << 10,
+}
+
+// BodyLoggingMiddleware 记录匹配路径的请求体和响应体，超过MaxBodySize的部分不记录
+// JSON和表单中的敏感字段按脱敏器替换；JSON超出大小上限而无法解析、或为二进制内容时只记录大小。
+// 读取的请求体会还原，后续处理器仍可完整读取。config为空时使用默认配置
+func BodyLoggingMiddleware(config *BodyLoggingConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultBodyLoggingConfig
+	}
+	maxSize := config.MaxBodySize
+	if maxSize <= 0 {
+		maxSize = DefaultBodyLoggingConfig.MaxBodySize
+	}
+	redactor := config.Redactor
+	if redactor == nil {
+		redactor = logger.NewRedactor()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !shouldLogBody(r.URL.Path, config) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// 读取不超过上限的请求体，并与未读取的部分拼接后还原
+			var reqBody []byte
+			reqTruncated := false
+			if r.Body != nil && r.Body != http.NoBody {
+				buf, err := io.ReadAll(io.LimitReader(r.Body, int64(maxSize)+1))
+				if err != nil {
+					slog.Debug("读取请求体失败", "error", err)
+				}
+				if len(buf) > maxSize {
+					reqBody, reqTruncated = buf[:maxSize], true
+				} else {
+					reqBody = buf
+				}
+				r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), r.Body), Closer: r.Body}
+			}
+
+			// 同时写入响应和有上限的缓冲区
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			respBody := &limitedBuffer{limit: maxSize}
+			ww.Tee(respBody)
+
+			next.ServeHTTP(ww, r)
+
+			log := config.Logger
+			if log == nil {
+				log = slog.Default()
+			}
+			log.InfoContext(r.Context(), fmt.Sprintf("%s %s 请求和响应体", r.Method, r.URL.Path),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", ww.Status(),
+				"request_body", formatBody(reqBody, reqTruncated, r.Header.Get("Content-Type"), redactor),
+				"response_body", formatBody(respBody.Bytes(), respBody.truncated, ww.Header().Get("Content-Type"), redactor),
+			)
+		})
+	}
+}
+
+// shouldLogBody 路径是否需要记录请求和响应体
+func shouldLogBody(path string, config *BodyLoggingConfig) bool {
+	for _, prefix := range config.ExcludePaths {
+		if strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range config.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatBody 按内容类型脱敏并格式化用于日志的请求体或响应体
+func formatBody(body []byte, truncated bool, contentType string, redactor *logger.Redactor) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		// 截断的JSON无法解析，无法保证脱敏，只记录大小
+		if redacted, ok := redactor.RedactJSON(body); ok && !truncated {
+			return string(redacted)
+		}
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	case mediaType == "application/x-www-form-urlencoded":
+		return withTruncation(redactor.RedactQuery(string(body)), truncated)
+	case strings.HasPrefix(mediaType, "text/"):
+		return withTruncation(string(body), truncated)
+	default:
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	}
+}
+
+// withTruncation 被截断时追加标记
+func withTruncation(body string, truncated bool) string {
+	if truncated {
+		return body + "...[truncated]"
+	}
+	return body
+}
+
+// readCloser 组合读取器和原始请求体的关闭方法
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitedBuffer 只保留前limit字节的缓冲区，超出部分丢弃但不返回错误，避免影响响应写入
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write 实现 io.Writer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.Buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
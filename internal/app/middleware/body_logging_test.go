@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBodyLoggingHandler 创建记录日志到缓冲区的中间件，处理器返回读取到的请求体
+func newBodyLoggingHandler(config BodyLoggingConfig) (http.Handler, *bytes.Buffer, *[]byte) {
+	var buf bytes.Buffer
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var received []byte
+	handler := BodyLoggingMiddleware(&config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"token":"t1"}`))
+	}))
+	return handler, &buf, &received
+}
+
+func TestBodyLoggingMiddleware(t *testing.T) {
+	config := DefaultBodyLoggingConfig
+	config.Paths = []string{"/api/v1/users", "/api/v1/auth"}
+
+	// 记录脱敏后的请求体和响应体，处理器仍读取到完整的请求体
+	t.Run("LogsAndRestoresBody", func(t *testing.T) {
+		handler, buf, received := newBodyLoggingHandler(config)
+		body := `{"name":"张三","password":"secret123"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, body, string(*received))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"id":1,"token":"t1"}`, rec.Body.String())
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.JSONEq(t, `{"name":"张三","password":"***"}`, entry["request_body"].(string))
+		assert.JSONEq(t, `{"id":1,"token":"***"}`, entry["response_body"].(string))
+		assert.Equal(t, float64(http.StatusCreated), entry["status"])
+		assert.NotContains(t, buf.String(), "secret123")
+	})
+
+	// 超过上限的请求体不记录内容，处理器仍读取到完整的请求体
+	t.Run("LargeBody", func(t *testing.T) {
+		small := config
+		small.MaxBodySize = 8
+		handler, buf, received := newBodyLoggingHandler(small)
+		body := `{"name":"张三","password":"secret123"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, body, string(*received))
+		assert.Contains(t, buf.String(), `"request_body":"[8 bytes omitted]"`)
+		assert.NotContains(t, buf.String(), "secret123")
+	})
+
+	// 认证接口即使在路径列表中也不记录
+	t.Run("SkipsAuthPaths", func(t *testing.T) {
+		handler, buf, received := newBodyLoggingHandler(config)
+		body := `{"email":"a@example.com","password":"secret123"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, body, string(*received))
+		assert.Empty(t, buf.String())
+	})
+
+	// 不在路径列表中的请求不记录
+	t.Run("SkipsUnlistedPaths", func(t *testing.T) {
+		handler, buf, _ := newBodyLoggingHandler(config)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil))
+		assert.Empty(t, buf.String())
+	})
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ClientIPResolver 按可信代理列表解析客户端IP
+// 只有直接连接方（RemoteAddr）是可信代理时才读取 X-Forwarded-For 和 X-Real-IP，
+// 否则客户端可以伪造这些请求头绕过按IP的速率限制
+type ClientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// NewClientIPResolver 创建客户端IP解析器，trustedProxies 为可信代理的CIDR或单个IP，为空时不信任任何代理
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return nil, fmt.Errorf("无效的可信代理地址 %q: %w", proxy, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		resolver.trusted = append(resolver.trusted, prefix.Masked())
+	}
+	return resolver, nil
+}
+
+// ClientIP 解析请求的客户端IP
+// 直接连接方可信时从右向左遍历 X-Forwarded-For，跳过可信代理，返回第一个不可信的地址；
+// 全部为可信代理时返回最左侧的地址，没有 X-Forwarded-For 时使用 X-Real-IP
+func (c *ClientIPResolver) ClientIP(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+	addr, err := netip.ParseAddr(remote)
+	if err != nil || !c.isTrusted(addr) {
+		return remote
+	}
+
+	hops := forwardedHops(r.Header)
+	if len(hops) == 0 {
+		if realIP, ok := parseForwardedHop(r.Header.Get("X-Real-IP")); ok {
+			return realIP.String()
+		}
+		return remote
+	}
+
+	client := addr.Unmap()
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseForwardedHop(hops[i])
+		if !ok {
+			// 无法解析的地址可能是伪造的，使用最后一个可信代理转发的地址
+			break
+		}
+		client = hop
+		if !c.isTrusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// Middleware 解析客户端IP并保存到请求上下文，同时将 RemoteAddr 设置为客户端IP，替代 chi 的 RealIP 中间件
+func (c *ClientIPResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := c.ClientIP(r)
+		r.RemoteAddr = ip
+		next.ServeHTTP(w, r.WithContext(WithClientIP(r.Context(), ip)))
+	})
+}
+
+// isTrusted 地址是否属于可信代理
+func (c *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range c.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHops 按顺序返回所有 X-Forwarded-For 请求头中的地址
+func forwardedHops(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// parseForwardedHop 解析转发请求头中的单个地址，允许带端口（1.2.3.4:80、[2001:db8::1]:443）和引号，
+// 空值、"unknown"、未指定地址（0.0.0.0、::）和组播地址视为无效
+func parseForwardedHop(hop string) (netip.Addr, bool) {
+	hop = strings.Trim(strings.TrimSpace(hop), `"`)
+	if hop == "" {
+		return netip.Addr{}, false
+	}
+
+	addr, err := netip.ParseAddr(hop)
+	if err != nil {
+		addrPort, portErr := netip.ParseAddrPort(hop)
+		if portErr != nil {
+			return netip.Addr{}, false
+		}
+		addr = addrPort.Addr()
+	}
+
+	addr = addr.Unmap()
+	if addr.IsUnspecified() || addr.IsMulticast() {
+		return netip.Addr{}, false
+	}
+	return addr, true
+}
+
+// remoteIP 去掉 RemoteAddr 中的端口
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// WithClientIP 在上下文中保存解析后的客户端IP
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// GetClientIP 从上下文获取客户端IP，未经过 ClientIPResolver 中间件时返回空字符串
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newForwardedRequest 创建来自remoteAddr、带指定转发请求头的请求
+func newForwardedRequest(remoteAddr string, header http.Header) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	return req
+}
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       string
+	}{
+		{"NoHeaders", "203.0.113.5:1234", nil, "203.0.113.5"},
+		// 不可信来源伪造的请求头被忽略
+		{"UntrustedSpoofedXFF", "203.0.113.5:1234", http.Header{"X-Forwarded-For": {"1.2.3.4"}}, "203.0.113.5"},
+		{"UntrustedSpoofedRealIP", "203.0.113.5:1234", http.Header{"X-Real-Ip": {"1.2.3.4"}}, "203.0.113.5"},
+		// 可信代理转发时使用代理追加的客户端地址
+		{"TrustedSingleHop", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		// 客户端在请求头中伪造的地址位于左侧，取最右侧的不可信地址
+		{"TrustedSpoofedLeftmost", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"1.2.3.4, 198.51.100.7"}}, "198.51.100.7"},
+		// 跳过多层可信代理
+		{"TrustedChain", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.7, 10.1.1.1, 192.168.1.1"}}, "198.51.100.7"},
+		{"MultipleHeaders", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"1.2.3.4", "198.51.100.7, 10.1.1.1"}}, "198.51.100.7"},
+		// 全部为可信代理时取最左侧地址
+		{"AllTrusted", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"10.9.9.9, 10.1.1.1"}}, "10.9.9.9"},
+		// 无法解析的地址之前的内容不可信
+		{"InvalidHop", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.7, garbage, 10.1.1.1"}}, "10.1.1.1"},
+		{"TrustedRealIP", "10.0.0.2:80", http.Header{"X-Real-Ip": {"198.51.100.7"}}, "198.51.100.7"},
+		{"TrustedNoHeaders", "10.0.0.2:80", nil, "10.0.0.2"},
+		{"IPv6", "[::1]:80", http.Header{"X-Forwarded-For": {"2001:db8::1"}}, "2001:db8::1"},
+		{"IPv4MappedProxy", "[::ffff:10.0.0.2]:80", http.Header{"X-Forwarded-For": {"198.51.100.7"}}, "198.51.100.7"},
+		{"IPv6Chain", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"2001:db8::1, [::1]:8080"}}, "2001:db8::1"},
+		// 带端口和空格的地址
+		{"HopWithPort", "10.0.0.2:80", http.Header{"X-Forwarded-For": {" 198.51.100.7:5678 ,10.1.1.1"}}, "198.51.100.7"},
+		// 畸形或空的地址不会被当作客户端IP，也不会返回整个请求头
+		{"MalformedOnly", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"unknown"}}, "10.0.0.2"},
+		{"EmptyHop", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.7, , 10.1.1.1"}}, "10.1.1.1"},
+		{"UnspecifiedHop", "10.0.0.2:80", http.Header{"X-Forwarded-For": {"0.0.0.0"}}, "10.0.0.2"},
+		{"MalformedRealIP", "10.0.0.2:80", http.Header{"X-Real-Ip": {"1.2.3.4, 5.6.7.8"}}, "10.0.0.2"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, resolver.ClientIP(newForwardedRequest(tc.remoteAddr, tc.header)))
+		})
+	}
+
+	// 未配置可信代理时始终使用远端地址
+	t.Run("NoTrustedProxies", func(t *testing.T) {
+		resolver, err := NewClientIPResolver(nil)
+		require.NoError(t, err)
+
+		req := newForwardedRequest("10.0.0.2:80", http.Header{"X-Forwarded-For": {"1.2.3.4"}, "X-Real-Ip": {"1.2.3.4"}})
+		assert.Equal(t, "10.0.0.2", resolver.ClientIP(req))
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := NewClientIPResolver([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+		_, err = NewClientIPResolver([]string{"not-an-ip"})
+		assert.Error(t, err)
+	})
+}
+
+func TestParseForwardedHop(t *testing.T) {
+	cases := []struct {
+		hop  string
+		want string // 为空表示无效
+	}{
+		{"198.51.100.7", "198.51.100.7"},
+		{"  198.51.100.7  ", "198.51.100.7"},
+		{"198.51.100.7:8080", "198.51.100.7"},
+		{`"198.51.100.7"`, "198.51.100.7"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"::ffff:198.51.100.7", "198.51.100.7"},
+		{"", ""},
+		{"unknown", ""},
+		{"198.51.100.7, 10.0.0.1", ""},
+		{"999.1.1.1", ""},
+		{"[2001:db8::1]", ""},
+		{"0.0.0.0", ""},
+		{"::", ""},
+		{"224.0.0.1", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.hop, func(t *testing.T) {
+			addr, ok := parseForwardedHop(tc.hop)
+			if tc.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, tc.want, addr.String())
+		})
+	}
+}
+
+func TestClientIPResolver_Middleware(t *testing.T) {
+	resolver, err := NewClientIPResolver([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	// 速率限制键使用解析后的客户端IP，不可信来源无法通过伪造请求头更换限流键
+	var keys []string
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, UserOrIPKey(r))
+		assert.Equal(t, GetClientIP(r.Context()), r.RemoteAddr)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newForwardedRequest("203.0.113.5:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}}))
+	handler.ServeHTTP(httptest.NewRecorder(), newForwardedRequest("203.0.113.5:1234", http.Header{"X-Forwarded-For": {"2.2.2.2"}}))
+	handler.ServeHTTP(httptest.NewRecorder(), newForwardedRequest("10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.7"}}))
+
+	assert.Equal(t, []string{"ip:203.0.113.5", "ip:203.0.113.5", "ip:198.51.100.7"}, keys)
+
+	// 未经过中间件时只使用远端地址
+	req := newForwardedRequest("203.0.113.5:1234", http.Header{"X-Forwarded-For": {"1.1.1.1"}})
+	assert.Equal(t, "203.0.113.5", getClientIP(req))
+}
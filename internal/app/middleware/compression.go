@@ -0,0 +1,284 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	// 压缩级别（参考 compress/flate 中的级别定义）
+	Level int
+	// 触发压缩的最小响应体大小（字节）
+	MinSize int
+	// 是否启用deflate编码（gzip始终启用且优先）
+	EnableDeflate bool
+	// 跳过压缩的内容类型前缀（通常为已压缩的格式）
+	ExcludedContentTypes []string
+}
+
+// DefaultCompressionConfig 默认压缩配置
+var DefaultCompressionConfig = CompressionConfig{
+	Level:         gzip.DefaultCompression,
+	MinSize:       1024,
+	EnableDeflate: true,
+	ExcludedContentTypes: []string{
+		"image/",
+		"video/",
+		"audio/",
+		"font/woff",
+		"application/zip",
+		"application/gzip",
+		"application/x-gzip",
+		"application/x-7z-compressed",
+		"application/x-rar-compressed",
+		"application/octet-stream",
+		"text/event-stream",
+	},
+}
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// CompressionMiddleware 响应压缩中间件，根据Accept-Encoding对响应进行gzip/deflate压缩
+func CompressionMiddleware(config *CompressionConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultCompressionConfig
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 无论是否压缩，响应都会因Accept-Encoding而不同
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), config.EnableDeflate)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				config:         config,
+				encoding:       encoding,
+				status:         http.StatusOK,
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding 解析Accept-Encoding头，选择服务端支持的编码
+func negotiateEncoding(acceptEncoding string, enableDeflate bool) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		// q=0 表示客户端明确拒绝该编码
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+
+	if accepted[encodingGzip] || accepted["*"] {
+		return encodingGzip
+	}
+	if enableDeflate && accepted[encodingDeflate] {
+		return encodingDeflate
+	}
+	return ""
+}
+
+// compressResponseWriter 压缩响应写入器
+// 在响应体达到MinSize前先缓冲数据，再决定是否压缩
+type compressResponseWriter struct {
+	http.ResponseWriter
+	config   *CompressionConfig
+	encoding string
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	compressor  io.WriteCloser
+}
+
+// WriteHeader 记录状态码，实际写入延迟到决定是否压缩之后
+func (cw *compressResponseWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+	cw.status = code
+
+	// 无响应体的状态码直接透传
+	if !bodyAllowedForStatus(code) {
+		cw.decide(false)
+	}
+}
+
+// Write 写入响应数据
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.decided {
+		if cw.compressor != nil {
+			return cw.compressor.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.config.MinSize {
+		if err := cw.decide(cw.shouldCompress()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush 实现http.Flusher接口
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		cw.decide(len(cw.buf) >= cw.config.MinSize && cw.shouldCompress())
+	}
+
+	if gw, ok := cw.compressor.(*gzip.Writer); ok {
+		gw.Flush()
+	} else if fw, ok := cw.compressor.(*flate.Writer); ok {
+		fw.Flush()
+	}
+
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack 实现http.Hijacker接口
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("底层ResponseWriter不支持Hijack")
+}
+
+// Unwrap 返回底层ResponseWriter，供http.ResponseController使用
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close 结束响应，刷新缓冲区并关闭压缩器
+func (cw *compressResponseWriter) Close() error {
+	if !cw.decided {
+		// 响应体不足MinSize，不压缩直接输出
+		if !cw.wroteHeader && len(cw.buf) == 0 {
+			return nil
+		}
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+
+	if cw.compressor != nil {
+		return cw.compressor.Close()
+	}
+	return nil
+}
+
+// shouldCompress 根据响应头判断是否应该压缩
+func (cw *compressResponseWriter) shouldCompress() bool {
+	header := cw.Header()
+
+	// 已经被编码的响应不再压缩
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+	}
+	contentType = strings.ToLower(contentType)
+
+	for _, excluded := range cw.config.ExcludedContentTypes {
+		if strings.HasPrefix(contentType, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// decide 确定是否压缩，写出响应头并刷新已缓冲的数据
+func (cw *compressResponseWriter) decide(compress bool) error {
+	cw.decided = true
+	buf := cw.buf
+	cw.buf = nil
+
+	header := cw.Header()
+	if compress {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+
+		var err error
+		switch cw.encoding {
+		case encodingGzip:
+			cw.compressor, err = gzip.NewWriterLevel(cw.ResponseWriter, cw.config.Level)
+		case encodingDeflate:
+			cw.compressor, err = flate.NewWriter(cw.ResponseWriter, cw.config.Level)
+		}
+		if err != nil {
+			// 压缩级别无效时退化为不压缩
+			header.Del("Content-Encoding")
+			cw.compressor = nil
+		}
+	}
+
+	if header.Get("Content-Type") == "" && len(buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(buf))
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.compressor != nil {
+		_, err := cw.compressor.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// bodyAllowedForStatus 判断状态码是否允许携带响应体
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
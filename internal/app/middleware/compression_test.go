@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	largeBody := strings.Repeat(`{"name":"Test User","email":"test@example.com"}`, 100)
+	smallBody := `{"ok":true}`
+
+	newHandler := func(contentType, body string) http.Handler {
+		return CompressionMiddleware(&DefaultCompressionConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Length", "999")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(body))
+		}))
+	}
+
+	// 大响应体应被gzip压缩
+	t.Run("GzipLargeResponse", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		rec := httptest.NewRecorder()
+
+		newHandler("application/json", largeBody).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+		assert.Empty(t, rec.Header().Get("Content-Length"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+
+		gr, err := gzip.NewReader(rec.Body)
+		require.NoError(t, err)
+		decoded, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, largeBody, string(decoded))
+	})
+
+	// 客户端只支持deflate时使用deflate压缩
+	t.Run("DeflateLargeResponse", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Accept-Encoding", "deflate")
+		rec := httptest.NewRecorder()
+
+		newHandler("application/json", largeBody).ServeHTTP(rec, req)
+
+		assert.Equal(t, "deflate", rec.Header().Get("Content-Encoding"))
+
+		decoded, err := io.ReadAll(flate.NewReader(rec.Body))
+		require.NoError(t, err)
+		assert.Equal(t, largeBody, string(decoded))
+	})
+
+	// 小于MinSize的响应不压缩
+	t.Run("PassThroughSmallResponse", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		newHandler("application/json", smallBody).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, smallBody, rec.Body.String())
+	})
+
+	// 未声明Accept-Encoding时不压缩
+	t.Run("PassThroughWithoutAcceptEncoding", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		rec := httptest.NewRecorder()
+
+		newHandler("application/json", largeBody).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, rec.Body.String())
+	})
+
+	// 已压缩的内容类型跳过压缩
+	t.Run("PassThroughExcludedContentType", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/avatar.png", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		newHandler("image/png", largeBody).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, rec.Body.String())
+	})
+
+	// q=0 表示拒绝该编码
+	t.Run("RespectQZero", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Accept-Encoding", "gzip;q=0")
+		rec := httptest.NewRecorder()
+
+		newHandler("application/json", largeBody).ServeHTTP(rec, req)
+
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, largeBody, rec.Body.String())
+	})
+
+	// 无响应体的状态码直接透传
+	t.Run("PassThroughNoContent", func(t *testing.T) {
+		handler := CompressionMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/1", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+		assert.Zero(t, rec.Body.Len())
+	})
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import "context"
+
+// 上下文键使用未导出的结构体类型，其他包无法构造相同的键，不会与字符串键或其他包的键冲突
+type (
+	// spanIDKey 当前span ID
+	spanIDKey struct{}
+	// parentSpanIDKey 上游span ID
+	parentSpanIDKey struct{}
+	// httpRequestKey 请求方法、路径等HTTP请求信息
+	httpRequestKey struct{}
+	// reqContextKey 请求上下文对象
+	reqContextKey struct{}
+	// clientIPKey 按可信代理解析后的客户端IP
+	clientIPKey struct{}
+)
+
+// WithSpanIDs 在上下文中保存当前span ID和上游span ID
+func WithSpanIDs(ctx context.Context, spanID, parentSpanID string) context.Context {
+	ctx = context.WithValue(ctx, spanIDKey{}, spanID)
+	return context.WithValue(ctx, parentSpanIDKey{}, parentSpanID)
+}
+
+// GetSpanID 从上下文获取当前span ID，未设置时返回空字符串
+func GetSpanID(ctx context.Context) string {
+	spanID, _ := ctx.Value(spanIDKey{}).(string)
+	return spanID
+}
+
+// GetParentSpanID 从上下文获取上游span ID，未设置时返回空字符串
+func GetParentSpanID(ctx context.Context) string {
+	parentSpanID, _ := ctx.Value(parentSpanIDKey{}).(string)
+	return parentSpanID
+}
+
+// WithHTTPRequestContext 在上下文中保存HTTP请求信息
+func WithHTTPRequestContext(ctx context.Context, info HTTPRequestContext) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, info)
+}
+
+// GetHTTPRequestContext 获取HTTP请求上下文信息，未设置时返回零值
+func GetHTTPRequestContext(ctx context.Context) HTTPRequestContext {
+	info, _ := ctx.Value(httpRequestKey{}).(HTTPRequestContext)
+	return info
+}
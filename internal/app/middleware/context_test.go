@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextAccessors(t *testing.T) {
+	// 读取保存的值，未设置时返回零值
+	t.Run("SpanIDs", func(t *testing.T) {
+		ctx := WithSpanIDs(context.Background(), "span-1", "parent-1")
+		assert.Equal(t, "span-1", GetSpanID(ctx))
+		assert.Equal(t, "parent-1", GetParentSpanID(ctx))
+
+		assert.Empty(t, GetSpanID(context.Background()))
+		assert.Empty(t, GetParentSpanID(context.Background()))
+	})
+
+	// 同名字符串键与类型化的键互不影响
+	t.Run("NoCollision", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "span_id", "from-string-key")
+		assert.Empty(t, GetSpanID(ctx))
+
+		ctx = WithSpanIDs(ctx, "span-1", "")
+		assert.Equal(t, "span-1", GetSpanID(ctx))
+		assert.Equal(t, "from-string-key", ctx.Value("span_id"))
+		assert.Empty(t, GetParentSpanID(ctx))
+
+		// 不同的键类型即使值类型相同也不会互相读取
+		ctx = WithHTTPRequestContext(ctx, HTTPRequestContext{Method: http.MethodGet})
+		assert.Nil(t, GetRequestContext(ctx))
+		assert.Equal(t, http.MethodGet, GetHTTPRequestContext(ctx).Method)
+	})
+
+	// 中间件保存的HTTP请求信息可以在处理器中读取
+	t.Run("RequestContextMiddleware", func(t *testing.T) {
+		var got HTTPRequestContext
+		handler := RequestContextMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = GetHTTPRequestContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users?page=2", nil)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", "test-agent")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, HTTPRequestContext{
+			Method:      http.MethodPost,
+			Path:        "/api/v1/users",
+			Query:       "page=2",
+			UserAgent:   "test-agent",
+			RemoteAddr:  req.RemoteAddr,
+			ContentType: "application/json",
+			Accept:      "application/json",
+		}, got)
+		assert.Equal(t, HTTPRequestContext{}, GetHTTPRequestContext(context.Background()))
+	})
+
+	// 追踪中间件保存的span ID与响应头一致
+	t.Run("TracingMiddleware", func(t *testing.T) {
+		var info TraceInfo
+		handler := TracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info = GetTraceInfo(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("X-Span-ID", "span-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		require.Equal(t, "req-1", info.RequestID)
+		assert.NotEmpty(t, info.SpanID)
+		assert.Equal(t, rec.Header().Get("X-Span-ID"), info.SpanID)
+	})
+}
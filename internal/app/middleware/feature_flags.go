@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vadxq/go-rest-starter/pkg/featureflags"
+)
+
+// FeatureFlags 功能开关中间件，在请求上下文中保存当前开关的快照，
+// 处理器通过 featureflags.IsEnabled(r.Context(), name) 判断，同一请求内的结果不受开关变更影响
+// 开关按上下文中的用户ID判断，快照在认证之前加载也能用于认证后的处理器
+// 读取开关失败时记录警告并使用已读取到的开关（如Redis不可用时的默认开关），不中断请求
+func FeatureFlags(provider featureflags.FlagProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flags, err := provider.Flags(r.Context())
+			if err != nil {
+				slog.WarnContext(r.Context(), "读取功能开关失败", "error", err)
+			}
+			next.ServeHTTP(w, r.WithContext(featureflags.NewContext(r.Context(), flags)))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vadxq/go-rest-starter/pkg/featureflags"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	provider := featureflags.NewMemoryProvider(featureflags.Set{
+		"on":   {Enabled: true},
+		"beta": {Users: []string{"7"}},
+	})
+
+	handler := FeatureFlags(provider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 加载快照后修改开关，同一请求内不受影响
+		provider.Set("on", featureflags.Flag{})
+
+		ctx := logger.WithUserID(r.Context(), r.Header.Get("X-User"))
+		w.Write([]byte(strconv.FormatBool(featureflags.IsEnabled(ctx, "on")) + " " +
+			strconv.FormatBool(featureflags.IsEnabled(ctx, "beta")) + " " +
+			strconv.FormatBool(featureflags.IsEnabled(ctx, "missing"))))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User", "7")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "true true false", rec.Body.String())
+
+	// 下一个请求使用变更后的开关
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User", "8")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "false false false", rec.Body.String())
+}
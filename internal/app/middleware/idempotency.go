@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// IdempotencyConfig 幂等键配置
+type IdempotencyConfig struct {
+	HeaderName   string        // 幂等键请求头
+	KeyPrefix    string        // 缓存键前缀
+	TTL          time.Duration // 响应缓存时间，期间相同的幂等键直接返回原响应
+	LockTTL      time.Duration // 处理中标记的过期时间，防止处理异常时幂等键长期不可用
+	MaxKeyLength int           // 幂等键最大长度
+}
+
+// DefaultIdempotencyConfig 默认幂等键配置
+var DefaultIdempotencyConfig = IdempotencyConfig{
+	HeaderName:   "Idempotency-Key",
+	KeyPrefix:    "idempotency:",
+	TTL:          24 * time.Hour,
+	LockTTL:      time.Minute,
+	MaxKeyLength: 255,
+}
+
+// replayHeaders 随缓存响应一起保存的响应头
+// 压缩、限流等由外层中间件设置的响应头在重放时会重新生成，不予保存
+var replayHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// idempotencyRecord 幂等键对应的请求指纹和响应
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	InProgress  bool        `json:"in_progress"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// IdempotencyMiddleware 幂等键中间件，按路由启用，仅应用于会修改数据的接口
+type IdempotencyMiddleware struct {
+	cache  cache.Cache
+	claim  cache.AtomicCache // 原子占用幂等键，与cache为同一实例
+	config IdempotencyConfig
+}
+
+// NewIdempotencyMiddleware 创建幂等键中间件
+// 缓存不可用（nil或 cache.NullCache）或不支持原子写入（cache.AtomicCache）时直接放行所有请求
+func NewIdempotencyMiddleware(c cache.Cache, config IdempotencyConfig) *IdempotencyMiddleware {
+	m := &IdempotencyMiddleware{config: config}
+	if claim, ok := c.(cache.AtomicCache); ok && cache.Available(c) {
+		m.cache, m.claim = c, claim
+	}
+	return m
+}
+
+// Handler 幂等键中间件处理函数
+func (m *IdempotencyMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(m.config.HeaderName)
+		if key == "" || m.cache == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if m.config.MaxKeyLength > 0 && len(key) > m.config.MaxKeyLength {
+			handlers.RespondError(w, r, apperrors.BadRequestError("幂等键过长", nil))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			handlers.RespondError(w, r, apperrors.BadRequestError("读取请求体失败", err))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		cacheKey := m.cacheKey(r, key)
+		fingerprint := requestFingerprint(r, body)
+
+		// 原子占用幂等键，并发的相同请求只有一个执行
+		claimed, err := m.claim.SetObjectIfAbsent(ctx, cacheKey, idempotencyRecord{
+			Fingerprint: fingerprint,
+			InProgress:  true,
+		}, m.config.LockTTL)
+		if err != nil {
+			// 缓存不可用时降级放行，与速率限制保持一致
+			slog.Warn("幂等键缓存不可用，放行请求", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !claimed {
+			m.respondExisting(w, r, cacheKey, fingerprint)
+			return
+		}
+
+		rec := &idempotencyResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// 服务端错误不缓存，允许客户端使用相同幂等键重试
+		if rec.status >= http.StatusInternalServerError {
+			_ = m.cache.Delete(ctx, cacheKey)
+			return
+		}
+
+		if err := m.cache.SetObject(ctx, cacheKey, idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			Header:      snapshotHeaders(w.Header()),
+			Body:        rec.body.Bytes(),
+		}, m.config.TTL); err != nil {
+			slog.Warn("缓存幂等响应失败", "error", err)
+		}
+	})
+}
+
+// respondExisting 幂等键已被占用时，重放已完成请求的响应，或返回冲突
+func (m *IdempotencyMiddleware) respondExisting(w http.ResponseWriter, r *http.Request, cacheKey, fingerprint string) {
+	var record idempotencyRecord
+	err := m.cache.GetObject(r.Context(), cacheKey, &record)
+	switch {
+	case err == nil && record.Fingerprint != fingerprint:
+		handlers.RespondError(w, r, apperrors.ConflictError("幂等键已用于不同的请求", nil).WithCode(apperrors.CodeIdempotencyKeyReused))
+	case err == nil && !record.InProgress:
+		replayResponse(w, &record)
+	case err == nil || errors.Is(err, cache.ErrNotFound):
+		// 占用失败后记录被删除（如处理出错），同样视为处理中，由客户端稍后重试
+		handlers.RespondError(w, r, apperrors.ConflictError("相同幂等键的请求正在处理中", nil).WithCode(apperrors.CodeIdempotencyInProgress))
+	default:
+		handlers.RespondError(w, r, apperrors.InternalError("读取幂等键失败", err))
+	}
+}
+
+// cacheKey 生成缓存键，按用户隔离幂等键
+func (m *IdempotencyMiddleware) cacheKey(r *http.Request, key string) string {
+	scope := "anonymous"
+	if userID, ok := GetUserID(r.Context()); ok {
+		scope = fmt.Sprintf("user:%d", userID)
+	}
+	return m.config.KeyPrefix + scope + ":" + key
+}
+
+// requestFingerprint 计算请求指纹（方法、路径和请求体）
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// snapshotHeaders 复制需要重放的响应头
+func snapshotHeaders(header http.Header) http.Header {
+	snapshot := make(http.Header, len(replayHeaders))
+	for _, name := range replayHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			snapshot[name] = append([]string(nil), values...)
+		}
+	}
+	return snapshot
+}
+
+// replayResponse 返回缓存的原始响应
+func replayResponse(w http.ResponseWriter, record *idempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// idempotencyResponseWriter 在写入客户端的同时记录响应
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+// WriteHeader 记录状态码
+func (w *idempotencyResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write 记录响应体
+func (w *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap 返回原始ResponseWriter
+func (w *idempotencyResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
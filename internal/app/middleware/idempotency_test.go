@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	handler := NewIdempotencyMiddleware(c, DefaultIdempotencyConfig).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":` + strconv.Itoa(int(n)) + `}`))
+	}))
+
+	doRequest := func(key, body string, userID uint) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		if userID != 0 {
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey{}, userID))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	body := `{"name":"Test User","email":"test@example.com","password":"password123"}`
+
+	// 首次请求正常执行
+	first := doRequest("key-1", body, 1)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, `{"id":1}`, first.Body.String())
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 相同幂等键和请求体返回原响应，不重复执行
+	t.Run("ReplayIdentical", func(t *testing.T) {
+		rec := doRequest("key-1", body, 1)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, first.Body.String(), rec.Body.String())
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	// 相同幂等键但请求体不同返回冲突
+	t.Run("ReplayMismatched", func(t *testing.T) {
+		rec := doRequest("key-1", `{"name":"Other"}`, 1)
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	// 幂等键按用户隔离
+	t.Run("ScopedPerUser", func(t *testing.T) {
+		rec := doRequest("key-1", body, 2)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	// 未携带幂等键时每次都执行
+	t.Run("WithoutKey", func(t *testing.T) {
+		before := calls.Load()
+		doRequest("", body, 1)
+		doRequest("", body, 1)
+		assert.Equal(t, before+2, calls.Load())
+	})
+}
+
+func TestIdempotencyMiddleware_ServerError(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	handler := NewIdempotencyMiddleware(c, DefaultIdempotencyConfig).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	doRequest := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{}`))
+		req.Header.Set("Idempotency-Key", "retry")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// 服务端错误不缓存，可使用相同幂等键重试
+	assert.Equal(t, http.StatusInternalServerError, doRequest())
+	assert.Equal(t, http.StatusCreated, doRequest())
+	assert.Equal(t, http.StatusCreated, doRequest())
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_Concurrent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := NewIdempotencyMiddleware(c, DefaultIdempotencyConfig).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"name":"Test"}`))
+		req.Header.Set("Idempotency-Key", "concurrent")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 相同幂等键的并发请求只有一个执行，其余返回处理中
+	const n = 20
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- doRequest().Code
+		}()
+	}
+
+	require.Eventually(t, func() bool { return len(codes) == n-1 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(codes)
+
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, map[int]int{http.StatusCreated: 1, http.StatusConflict: n - 1}, counts)
+
+	// 处理完成后重放原响应
+	rec := doRequest()
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// 耗时直方图以微秒记录，每个2的幂区间再等分为16个子桶，分位数的相对误差不超过1/16
+const (
+	latencySubBucketBits  = 4
+	latencySubBuckets     = 1 << latencySubBucketBits
+	latencyMaxMicros      = uint64(time.Hour / time.Microsecond) // 超过1小时的耗时记为1小时
+	latencyBucketCount    = (bits.UintSize - latencySubBucketBits) * latencySubBuckets
+	latencySmallestBucket = latencySubBuckets * 2 // 小于该值（微秒）的耗时精确记录
+)
+
+// latencyHistogram 并发安全的请求耗时直方图
+type latencyHistogram struct {
+	counts [latencyBucketCount]atomic.Uint64
+	total  atomic.Uint64
+	max    atomic.Uint64
+}
+
+// LatencySummary 单个路由的耗时分位数（毫秒）
+type LatencySummary struct {
+	Count uint64  `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+// record 记录一次耗时
+func (h *latencyHistogram) record(d time.Duration) {
+	v := uint64(max(d/time.Microsecond, 0))
+	v = min(v, latencyMaxMicros)
+
+	h.counts[latencyBucketIndex(v)].Add(1)
+	h.total.Add(1)
+	for {
+		current := h.max.Load()
+		if v <= current || h.max.CompareAndSwap(current, v) {
+			break
+		}
+	}
+}
+
+// quantile 返回分位数q（0~1）对应的耗时上界（微秒），不超过记录到的最大值
+func (h *latencyHistogram) quantile(q float64, total uint64) uint64 {
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	rank = max(rank, 1)
+
+	var seen uint64
+	for i := range h.counts {
+		seen += h.counts[i].Load()
+		if seen >= rank {
+			return min(latencyBucketUpper(i), h.max.Load())
+		}
+	}
+	return h.max.Load()
+}
+
+// summary 计算p50/p95/p99和最大耗时
+func (h *latencyHistogram) summary() LatencySummary {
+	total := h.total.Load()
+	return LatencySummary{
+		Count: total,
+		P50:   microsToMillis(h.quantile(0.50, total)),
+		P95:   microsToMillis(h.quantile(0.95, total)),
+		P99:   microsToMillis(h.quantile(0.99, total)),
+		Max:   microsToMillis(h.max.Load()),
+	}
+}
+
+// latencyBucketIndex 计算耗时所在的桶：小值一一对应，之后每个2的幂区间分为16个子桶
+func latencyBucketIndex(v uint64) int {
+	if v < latencySmallestBucket {
+		return int(v)
+	}
+	shift := bits.Len64(v) - latencySubBucketBits - 1
+	sub := v >> shift // [16, 32)
+	return (shift+1)*latencySubBuckets + int(sub-latencySubBuckets)
+}
+
+// latencyBucketUpper 桶内的最大耗时（微秒）
+func latencyBucketUpper(index int) uint64 {
+	if index < latencySmallestBucket {
+		return uint64(index)
+	}
+	shift := index/latencySubBuckets - 1
+	sub := uint64(index%latencySubBuckets + latencySubBuckets)
+	return (sub+1)<<shift - 1
+}
+
+func microsToMillis(v uint64) float64 {
+	return float64(v) / 1000
+}
@@ -3,22 +3,23 @@ package middleware
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
-)
-
-// 上下文键类型
-type contextKey string
-
-const (
-	// 请求上下文键
-	reqContextKey contextKey = "request_context" // 请求上下文对象
+	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
 // ReqContext 请求上下文结构体
@@ -47,7 +48,7 @@ func GetRequestContext(ctx context.Context) *ReqContext {
 	if ctx == nil {
 		return nil
 	}
-	if rc, ok := ctx.Value(reqContextKey).(*ReqContext); ok {
+	if rc, ok := ctx.Value(reqContextKey{}).(*ReqContext); ok {
 		return rc
 	}
 	return nil
@@ -59,7 +60,7 @@ func RequestContext(next http.Handler) http.Handler {
 		// 创建请求上下文
 		reqCtx := &ReqContext{
 			RequestID:  r.Header.Get("X-Request-ID"),
-			ClientIP:   r.Header.Get("X-Forwarded-For"),
+			ClientIP:   getClientIP(r),
 			StartTime:  time.Now(),
 			RequestURI: r.RequestURI,
 			Method:     r.Method,
@@ -70,114 +71,365 @@ func RequestContext(next http.Handler) http.Handler {
 			reqCtx.RequestID = middleware.GetReqID(r.Context())
 		}
 
-		// 设置跟踪ID与请求ID相同
-		reqCtx.TraceID = reqCtx.RequestID
-
-		// 如果没有客户端IP，则使用RemoteAddr
-		if reqCtx.ClientIP == "" {
-			reqCtx.ClientIP = r.RemoteAddr
+		// 存在链路追踪span时使用其追踪ID，否则与请求ID相同
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			reqCtx.TraceID = sc.TraceID().String()
+		} else {
+			reqCtx.TraceID = reqCtx.RequestID
 		}
 
 		// 设置响应头
 		w.Header().Set("X-Request-ID", reqCtx.RequestID)
 
 		// 将请求上下文添加到请求上下文
-		ctx := context.WithValue(r.Context(), reqContextKey, reqCtx)
+		ctx := context.WithValue(r.Context(), reqContextKey{}, reqCtx)
 
 		// 继续处理请求
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// LoggingMiddleware 日志中间件，记录请求日志
+// LoggingMiddleware 日志中间件，记录结构化请求日志
 func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 获取请求上下文
-		reqCtx := GetRequestContext(r.Context())
-		if reqCtx == nil {
-			// 如果没有请求上下文，则创建一个
-			reqCtx = &ReqContext{
-				StartTime:  time.Now(),
-				RequestURI: r.RequestURI,
-				Method:     r.Method,
+	return AccessLogMiddleware(nil)(next)
+}
+
+// 访问日志格式
+const (
+	AccessLogStructured = "structured" // slog 结构化日志
+	AccessLogCombined   = "combined"   // Apache/nginx Combined Log Format
+	AccessLogBoth       = "both"       // 同时输出两种格式
+)
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	Format   string           // 日志格式: structured, combined, both
+	Output   io.Writer        // Combined Log Format 日志的输出，为空时输出到标准输出
+	Redactor *logger.Redactor // 替换 Combined Log Format 中请求URI的敏感查询参数，为空时只脱敏内置敏感字段
+}
+
+// DefaultAccessLogConfig 默认访问日志配置，只输出结构化日志
+var DefaultAccessLogConfig = AccessLogConfig{
+	Format: AccessLogStructured,
+}
+
+// AccessLogMiddleware 访问日志中间件，按配置输出结构化日志和/或 Combined Log Format 日志
+// Combined Log Format 日志末尾追加以秒为单位的响应时间。config为空时使用默认配置
+func AccessLogMiddleware(config *AccessLogConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultAccessLogConfig
+	}
+	structured := config.Format != AccessLogCombined
+	combined := config.Format == AccessLogCombined || config.Format == AccessLogBoth
+	output := config.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	redactor := config.Redactor
+	if redactor == nil {
+		redactor = logger.NewRedactor()
+	}
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 获取请求上下文
+			reqCtx := GetRequestContext(r.Context())
+			if reqCtx == nil {
+				// 如果没有请求上下文，则创建一个
+				reqCtx = &ReqContext{
+					StartTime:  time.Now(),
+					RequestURI: r.RequestURI,
+					Method:     r.Method,
+				}
 			}
-		}
 
-		// 获取请求主体大小
-		var requestSize int64
-		if r.ContentLength > 0 {
-			requestSize = r.ContentLength
-		}
+			// 获取请求主体大小
+			var requestSize int64
+			if r.ContentLength > 0 {
+				requestSize = r.ContentLength
+			}
+
+			// 包装响应写入器以获取状态码
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			// 处理请求
+			next.ServeHTTP(ww, r)
+
+			// 计算请求处理延迟
+			latency := time.Since(reqCtx.StartTime)
+
+			if combined {
+				line := combinedLogLine(r, reqCtx, redactor.RedactURL(r.RequestURI), ww.Status(), ww.BytesWritten(), latency)
+				mu.Lock()
+				io.WriteString(output, line)
+				mu.Unlock()
+			}
+			if !structured {
+				return
+			}
+
+			// 构建日志事件参数
+			args := []interface{}{
+				"method", reqCtx.Method,
+				"path", reqCtx.RequestURI,
+				"query", r.URL.RawQuery,
+				"status", ww.Status(),
+				"latency", latency.String(),
+				"size", ww.BytesWritten(),
+				"req_size", requestSize,
+				"ip", reqCtx.ClientIP,
+				"user_agent", r.UserAgent(),
+				"trace_id", reqCtx.TraceID,
+			}
 
-		// 包装响应写入器以获取状态码
-		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-
-		// 处理请求
-		next.ServeHTTP(ww, r)
-
-		// 计算请求处理延迟
-		latency := time.Since(reqCtx.StartTime)
-
-		// 构建日志事件参数
-		args := []interface{}{
-			"method", reqCtx.Method,
-			"path", reqCtx.RequestURI,
-			"query", r.URL.RawQuery,
-			"status", ww.Status(),
-			"latency", latency.String(),
-			"size", ww.BytesWritten(),
-			"req_size", requestSize,
-			"ip", reqCtx.ClientIP,
-			"user_agent", r.UserAgent(),
-			"trace_id", reqCtx.TraceID,
+			// 添加用户信息（如果有）
+			if reqCtx.UserID != 0 {
+				args = append(args, "user_id", reqCtx.UserID)
+			}
+
+			// 记录日志
+			slog.Info(fmt.Sprintf("%s %s - %d", reqCtx.Method, reqCtx.RequestURI, ww.Status()), args...)
+		})
+	}
+}
+
+// combinedLogLine 生成一行 Combined Log Format 日志：
+// host ident authuser [time] "request" status bytes "referer" "user-agent" response_time
+func combinedLogLine(r *http.Request, reqCtx *ReqContext, requestURI string, status, written int, latency time.Duration) string {
+	host := reqCtx.ClientIP
+	if host == "" {
+		host = r.RemoteAddr
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
 		}
+	}
+	user := "-"
+	if reqCtx.UserID != 0 {
+		user = strconv.FormatUint(uint64(reqCtx.UserID), 10)
+	}
+	size := "-"
+	if written > 0 {
+		size = strconv.Itoa(written)
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\" %.3f\n",
+		orDash(host), user, reqCtx.StartTime.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, escapeLogField(requestURI), r.Proto, status, size,
+		escapeLogField(orDash(r.Referer())), escapeLogField(orDash(r.UserAgent())), latency.Seconds())
+}
+
+// orDash 空值在 Combined Log Format 中记为 "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
 
-		// 添加用户信息（如果有）
-		if reqCtx.UserID != 0 {
-			args = append(args, "user_id", reqCtx.UserID)
+// escapeLogField 转义引号、反斜杠和控制字符，防止伪造日志字段或换行
+func escapeLogField(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
 		}
+	}
+	return b.String()
+}
 
-		// 记录日志
-		slog.Info(fmt.Sprintf("%s %s - %d", reqCtx.Method, reqCtx.RequestURI, ww.Status()), args...)
-	})
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	// 允许的来源，"*" 表示允许任意来源（仅建议开发环境使用）
+	AllowedOrigins []string
+	// 允许的请求方法
+	AllowedMethods []string
+	// 允许的请求头
+	AllowedHeaders []string
+	// 允许浏览器读取的响应头
+	ExposedHeaders []string
+	// 是否允许携带凭证（Cookie、Authorization等）
+	AllowCredentials bool
+	// 预检请求结果的缓存时间
+	MaxAge time.Duration
 }
 
-// CORSMiddleware 处理跨域请求
-func CORSMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
-		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusNoContent)
-			return
+// DefaultCORSConfig 默认跨域配置，允许任意来源且不携带凭证
+var DefaultCORSConfig = CORSConfig{
+	AllowedOrigins: []string{"*"},
+	AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+	AllowedHeaders: []string{"Content-Type", "Authorization", "X-Request-ID", "If-None-Match", "Idempotency-Key"},
+	ExposedHeaders: []string{"X-Request-ID", "ETag", APIVersionHeader},
+	MaxAge:         time.Hour,
+}
+
+// CORSMiddleware 处理跨域请求，仅当请求来源在允许列表中时返回跨域响应头
+func CORSMiddleware(config *CORSConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultCORSConfig
+	}
+
+	allowAll := false
+	origins := make(map[string]struct{}, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+			continue
 		}
+		origins[strings.ToLower(strings.TrimRight(origin, "/"))] = struct{}{}
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	methods := strings.Join(config.AllowedMethods, ", ")
+	headers := strings.Join(config.AllowedHeaders, ", ")
+	exposed := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// 响应内容随来源变化，需告知缓存
+			if !allowAll || config.AllowCredentials {
+				w.Header().Add("Vary", "Origin")
+			}
+
+			_, allowed := origins[strings.ToLower(origin)]
+			if origin != "" && (allowAll || allowed) {
+				// 携带凭证时浏览器不接受通配符，回显请求来源
+				if allowAll && !config.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				} else {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				if config.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+
+				if preflight {
+					w.Header().Set("Access-Control-Allow-Methods", methods)
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+					if config.MaxAge > 0 {
+						w.Header().Set("Access-Control-Max-Age", maxAge)
+					}
+				} else if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+			}
+
+			// 预检请求直接返回，来源不被允许时不返回跨域响应头，由浏览器拦截
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RecoveryConfig 恢复中间件配置
+type RecoveryConfig struct {
+	StackSize    int // 记录堆栈的初始缓冲区大小（字节）
+	MaxStackSize int // 堆栈最大记录的字节数，超出部分截断
+}
+
+// DefaultRecoveryConfig 默认恢复中间件配置
+var DefaultRecoveryConfig = RecoveryConfig{
+	StackSize:    4 << 10,
+	MaxStackSize: 64 << 10,
 }
 
-// RecoveryMiddleware 恢复中间件，处理 panic
+// RecoveryMiddleware 使用默认配置的恢复中间件
 func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer apperrors.RecoverPanicWithCallback("HTTP请求处理", func(err interface{}) {
-			// 获取请求上下文
-			reqCtx := GetRequestContext(r.Context())
+	return Recovery(nil)(next)
+}
 
-			// 构建错误消息
-			message := "服务器内部错误"
-			if reqCtx != nil && reqCtx.TraceID != "" {
-				message = "服务器内部错误，请稍后重试"
-			}
+// Recovery 恢复中间件，处理 panic，全局只注册这一个恢复中间件
+// 返回500错误响应，并在 X-Trace-ID 响应头和错误信息的 trace_id 中带上链路追踪ID，便于用户反馈时关联日志。
+// 响应已开始写入时无法再返回错误响应，只记录日志；http.ErrAbortHandler 继续抛出，由 net/http 中断连接。
+// config为空时使用默认配置
+func Recovery(config *RecoveryConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultRecoveryConfig
+	}
+	stackSize, maxStackSize := config.StackSize, config.MaxStackSize
+	if stackSize <= 0 {
+		stackSize = DefaultRecoveryConfig.StackSize
+	}
+	if maxStackSize <= 0 {
+		maxStackSize = DefaultRecoveryConfig.MaxStackSize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-			// 使用统一的错误响应处理
-			appErr := apperrors.InternalError(message, fmt.Errorf("%v", err))
-			handlers.RespondError(w, appErr)
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				traceID := logger.GetTraceID(r.Context())
+				if reqCtx := GetRequestContext(r.Context()); traceID == "" && reqCtx != nil {
+					traceID = reqCtx.TraceID
+				}
+				stack, truncated := captureStack(stackSize, maxStackSize)
+				slog.ErrorContext(r.Context(), "panic recovered",
+					"error", fmt.Sprintf("%v", rec),
+					"method", r.Method,
+					"path", r.URL.Path,
+					"trace_id", traceID,
+					"stack_trace", stack,
+					"stack_truncated", truncated,
+				)
+
+				if ww.Status() != 0 {
+					return
+				}
+				if traceID != "" {
+					w.Header().Set("X-Trace-ID", traceID)
+				}
+
+				// 使用统一的错误响应处理
+				appErr := apperrors.InternalError("服务器内部错误，请稍后重试", fmt.Errorf("%v", rec))
+				handlers.RespondError(w, r.WithContext(logger.WithTraceID(r.Context(), traceID)), appErr)
+			}()
+
+			next.ServeHTTP(ww, r)
 		})
+	}
+}
 
-		next.ServeHTTP(w, r)
-	})
+// captureStack 获取当前协程的堆栈，缓冲区从size开始按倍数扩大直到容纳完整堆栈
+// 超过maxSize时只保留前maxSize字节并返回true
+func captureStack(size, maxSize int) (string, bool) {
+	if size > maxSize {
+		size = maxSize
+	}
+	for {
+		buf := make([]byte, size)
+		n := runtime.Stack(buf, false)
+		if n < size {
+			return string(buf[:n]), false
+		}
+		if size >= maxSize {
+			return string(buf[:n]), true
+		}
+		size = min(size*2, maxSize)
+	}
 }
@@ -0,0 +1,263 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	config := &CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	newHandler := func(config *CORSConfig) http.Handler {
+		return CORSMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	// 允许的来源回显Origin并允许携带凭证
+	t.Run("AllowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+
+		newHandler(config).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Request-ID", rec.Header().Get("Access-Control-Expose-Headers"))
+		assert.Contains(t, rec.Header().Values("Vary"), "Origin")
+	})
+
+	// 不在允许列表中的来源不返回跨域响应头
+	t.Run("DisallowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rec := httptest.NewRecorder()
+
+		newHandler(config).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	// 预检请求返回允许的方法、请求头和缓存时间，且不进入后续处理器
+	t.Run("Preflight", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rec := httptest.NewRecorder()
+
+		newHandler(config).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "https://app.example.com", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", rec.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, Authorization", rec.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+	})
+
+	// 预检请求来源不被允许时不返回跨域响应头
+	t.Run("PreflightDisallowedOrigin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/api/v1/users", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		rec := httptest.NewRecorder()
+
+		newHandler(config).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	// 默认配置允许任意来源，返回通配符
+	t.Run("Wildcard", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		rec := httptest.NewRecorder()
+
+		newHandler(nil).ServeHTTP(rec, req)
+
+		assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	// 通配符与凭证同时启用时回显请求来源
+	t.Run("WildcardWithCredentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Origin", "http://localhost:3000")
+		rec := httptest.NewRecorder()
+
+		newHandler(&CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}).ServeHTTP(rec, req)
+
+		assert.Equal(t, "http://localhost:3000", rec.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users?page=2&token=secret", nil)
+		req.Header.Set("Referer", "https://app.example.com/users")
+		req.Header.Set("User-Agent", `curl/8.0 "test"`)
+		start := time.Date(2026, 10, 14, 13, 55, 36, 0, time.FixedZone("CST", 8*3600))
+		reqCtx := &ReqContext{ClientIP: "203.0.113.7", UserID: 42, StartTime: start, RequestURI: req.RequestURI, Method: req.Method}
+		return req.WithContext(context.WithValue(req.Context(), reqContextKey{}, reqCtx))
+	}
+	handler := func(config *AccessLogConfig) http.Handler {
+		return AccessLogMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":1}`))
+		}))
+	}
+
+	// 输出 Combined Log Format 日志，末尾为响应时间
+	t.Run("Combined", func(t *testing.T) {
+		var buf bytes.Buffer
+		handler(&AccessLogConfig{Format: AccessLogCombined, Output: &buf}).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		pattern := `^203\.0\.113\.7 - 42 \[14/Oct/2026:13:55:36 \+0800\] "GET /api/v1/users\?page=2&token=\*\*\* HTTP/1\.1" 201 8 ` +
+			`"https://app\.example\.com/users" "curl/8\.0 \\"test\\"" \d+\.\d{3}\n$`
+		assert.Regexp(t, regexp.MustCompile(pattern), buf.String())
+	})
+
+	// 缺少的字段记为 "-"
+	t.Run("MissingFields", func(t *testing.T) {
+		var buf bytes.Buffer
+		h := AccessLogMiddleware(&AccessLogConfig{Format: AccessLogCombined, Output: &buf})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/1", nil)
+		req.Header.Del("User-Agent")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Regexp(t, `^192\.0\.2\.1 - - \[.+\] "DELETE /api/v1/users/1 HTTP/1\.1" 204 - "-" "-" \d+\.\d{3}\n$`, buf.String())
+	})
+
+	// 结构化格式不输出 Combined Log Format 日志
+	t.Run("Structured", func(t *testing.T) {
+		var buf bytes.Buffer
+		rec := httptest.NewRecorder()
+		handler(&AccessLogConfig{Format: AccessLogStructured, Output: &buf}).ServeHTTP(rec, newRequest())
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	// panic 时返回500，响应头和错误信息中包含链路追踪ID
+	t.Run("TraceID", func(t *testing.T) {
+		handler := TracingMiddleware(RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("X-Trace-ID", "trace-123")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Equal(t, "trace-123", rec.Header().Get("X-Trace-ID"))
+
+		var body struct {
+			Data handlers.ErrorInfo `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		assert.Equal(t, "trace-123", body.Data.TraceID)
+		assert.Equal(t, "INTERNAL_ERROR", body.Data.Type)
+	})
+
+	// 响应已开始写入时不再写入错误响应
+	t.Run("AfterWrite", func(t *testing.T) {
+		handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("partial"))
+			panic("boom")
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Equal(t, "partial", rec.Body.String())
+	})
+
+	// http.ErrAbortHandler 继续抛出
+	t.Run("AbortHandler", func(t *testing.T) {
+		handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+		})
+	})
+}
+
+// deepPanic 递归depth层后panic，用于产生超过初始缓冲区的堆栈
+func deepPanic(depth int) {
+	if depth == 0 {
+		panic("deep boom")
+	}
+	deepPanic(depth - 1)
+}
+
+func TestCaptureStack(t *testing.T) {
+	// 堆栈超过初始缓冲区时扩大缓冲区，记录完整堆栈，只包含当前协程
+	t.Run("Grows", func(t *testing.T) {
+		var stack string
+		var truncated bool
+		func() {
+			defer func() {
+				recover()
+				stack, truncated = captureStack(1<<10, 1<<20)
+			}()
+			deepPanic(80)
+		}()
+
+		assert.False(t, truncated)
+		assert.Greater(t, len(stack), 1<<10)
+		assert.True(t, strings.HasPrefix(stack, "goroutine "))
+		assert.NotContains(t, stack, "\n\ngoroutine ")
+		assert.GreaterOrEqual(t, strings.Count(stack, "deepPanic"), 80)
+		assert.Contains(t, stack, "TestCaptureStack")
+	})
+
+	// 超过最大值时截断到最大值
+	t.Run("Truncated", func(t *testing.T) {
+		var stack string
+		var truncated bool
+		func() {
+			defer func() {
+				recover()
+				stack, truncated = captureStack(1<<10, 8<<10)
+			}()
+			deepPanic(80)
+		}()
+
+		assert.True(t, truncated)
+		assert.Len(t, stack, 8<<10)
+	})
+}
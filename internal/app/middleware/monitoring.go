@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -16,6 +17,9 @@ type Metrics struct {
 	ActiveRequests  atomic.Int64
 	TotalErrors     atomic.Uint64
 	StartTime       time.Time
+
+	// 按chi路由模式统计的耗时分布，值为 *latencyHistogram
+	routeLatency sync.Map
 }
 
 // GlobalMetrics 全局指标实例
@@ -47,27 +51,51 @@ func MonitoringMiddleware(next http.Handler) http.Handler {
 		// 添加响应时间头
 		duration := time.Since(start)
 		w.Header().Set("X-Response-Time", strconv.FormatInt(duration.Milliseconds(), 10)+"ms")
+
+		// 路由匹配在处理请求时完成，处理后才能取得路由模式
+		GlobalMetrics.ObserveLatency(routePattern(r), duration)
 	})
 }
 
+// ObserveLatency 记录路由的一次请求耗时
+func (m *Metrics) ObserveLatency(route string, d time.Duration) {
+	h, ok := m.routeLatency.Load(route)
+	if !ok {
+		h, _ = m.routeLatency.LoadOrStore(route, &latencyHistogram{})
+	}
+	h.(*latencyHistogram).record(d)
+}
+
 // GetMetricsSnapshot 获取指标快照
 func GetMetricsSnapshot() MetricsSnapshot {
-	uptime := time.Since(GlobalMetrics.StartTime)
-	total := GlobalMetrics.TotalRequests.Load()
-	errors := GlobalMetrics.TotalErrors.Load()
+	return GlobalMetrics.Snapshot()
+}
+
+// Snapshot 获取指标快照，包含各路由的耗时分位数
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	uptime := time.Since(m.StartTime)
+	total := m.TotalRequests.Load()
+	errors := m.TotalErrors.Load()
 	
 	var errorRate float64
 	if total > 0 {
 		errorRate = float64(errors) / float64(total) * 100
 	}
 	
+	routes := make(map[string]LatencySummary)
+	m.routeLatency.Range(func(route, h any) bool {
+		routes[route.(string)] = h.(*latencyHistogram).summary()
+		return true
+	})
+	
 	return MetricsSnapshot{
 		TotalRequests:  total,
-		ActiveRequests: GlobalMetrics.ActiveRequests.Load(),
+		ActiveRequests: m.ActiveRequests.Load(),
 		TotalErrors:    errors,
 		ErrorRate:      errorRate,
 		Uptime:         uptime,
 		QPS:            float64(total) / uptime.Seconds(),
+		Routes:         routes,
 	}
 }
 
@@ -79,6 +107,8 @@ type MetricsSnapshot struct {
 	ErrorRate      float64       `json:"error_rate"`
 	Uptime         time.Duration `json:"uptime_seconds"`
 	QPS            float64       `json:"qps"`
+	// 各路由的请求耗时分位数，键为chi路由模式，未匹配路由的请求记为 unmatched
+	Routes map[string]LatencySummary `json:"routes"`
 }
 
 // MetricsHandler 指标端点处理器
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 直方图分位数的最大相对误差
+const latencyTolerance = 1.0 / latencySubBuckets
+
+func TestMetrics_LatencyPercentiles(t *testing.T) {
+	// 1ms~100ms各一次，分位数与实际值的误差不超过1/16
+	t.Run("KnownDurations", func(t *testing.T) {
+		m := &Metrics{StartTime: time.Now()}
+		for i := 1; i <= 100; i++ {
+			m.ObserveLatency("/users/{id}", time.Duration(i)*time.Millisecond)
+		}
+
+		summary := m.Snapshot().Routes["/users/{id}"]
+		assert.Equal(t, uint64(100), summary.Count)
+		assert.InEpsilon(t, 50.0, summary.P50, latencyTolerance)
+		assert.InEpsilon(t, 95.0, summary.P95, latencyTolerance)
+		assert.InEpsilon(t, 99.0, summary.P99, latencyTolerance)
+		assert.Equal(t, 100.0, summary.Max)
+		assert.GreaterOrEqual(t, summary.P50, 50.0)
+	})
+
+	// 尾部慢请求体现在p99而不影响p50
+	t.Run("TailLatency", func(t *testing.T) {
+		m := &Metrics{StartTime: time.Now()}
+		for i := 0; i < 980; i++ {
+			m.ObserveLatency("/orders", 10*time.Millisecond)
+		}
+		for i := 0; i < 20; i++ {
+			m.ObserveLatency("/orders", 2*time.Second)
+		}
+
+		summary := m.Snapshot().Routes["/orders"]
+		assert.InEpsilon(t, 10.0, summary.P50, latencyTolerance)
+		assert.InEpsilon(t, 10.0, summary.P95, latencyTolerance)
+		assert.InEpsilon(t, 2000.0, summary.P99, latencyTolerance)
+		assert.Equal(t, 2000.0, summary.Max)
+	})
+
+	// 路由分别统计，小于32微秒的耗时精确记录
+	t.Run("PerRoute", func(t *testing.T) {
+		m := &Metrics{StartTime: time.Now()}
+		m.ObserveLatency("/a", 5*time.Microsecond)
+		m.ObserveLatency("/b", time.Second)
+
+		routes := m.Snapshot().Routes
+		require.Len(t, routes, 2)
+		assert.Equal(t, 0.005, routes["/a"].P99)
+		assert.InEpsilon(t, 1000.0, routes["/b"].P50, latencyTolerance)
+	})
+
+	// 并发记录计数准确
+	t.Run("Concurrent", func(t *testing.T) {
+		m := &Metrics{StartTime: time.Now()}
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					m.ObserveLatency("/users", time.Millisecond)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint64(2000), m.Snapshot().Routes["/users"].Count)
+	})
+}
+
+func TestLatencyBuckets(t *testing.T) {
+	// 每个值都落在上界不小于自身、相对误差不超过1/16的桶中，桶序号随值单调递增
+	prev := 0
+	for _, v := range []uint64{0, 1, 31, 32, 33, 63, 64, 1000, 50000, 123456, latencyMaxMicros} {
+		index := latencyBucketIndex(v)
+		require.GreaterOrEqual(t, index, prev)
+		upper := latencyBucketUpper(index)
+		assert.GreaterOrEqual(t, upper, v)
+		assert.LessOrEqual(t, float64(upper-v), float64(v)*latencyTolerance)
+		prev = index
+	}
+}
+
+func TestMonitoringMiddleware_RouteLatency(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(MonitoringMiddleware)
+	r.Get("/monitoring-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	})
+	r.Get("/status/metrics", MetricsHandler)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/monitoring-test/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/monitoring-test/2", nil))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/metrics", nil))
+	var snapshot MetricsSnapshot
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snapshot))
+
+	// 按路由模式而非原始路径统计
+	summary, ok := snapshot.Routes["/monitoring-test/{id}"]
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), summary.Count)
+	assert.GreaterOrEqual(t, summary.P50, 5.0)
+	assert.NotContains(t, snapshot.Routes, "/monitoring-test/1")
+}
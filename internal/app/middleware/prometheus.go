@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+)
+
+// PrometheusConfig Prometheus指标配置
+type PrometheusConfig struct {
+	// 指标名称前缀
+	Namespace string
+	// 请求耗时直方图的桶（单位：秒）
+	Buckets []float64
+	// 是否采集Go运行时和进程指标
+	EnableRuntimeMetrics bool
+}
+
+// DefaultPrometheusConfig 默认Prometheus指标配置
+var DefaultPrometheusConfig = PrometheusConfig{
+	Namespace:            "app",
+	Buckets:              []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	EnableRuntimeMetrics: true,
+}
+
+// 未匹配任何路由时使用的路由标签，避免原始路径导致高基数
+const unmatchedRoute = "unmatched"
+
+// PrometheusMetrics Prometheus指标收集器
+type PrometheusMetrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	errorsTotal     *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+// NewPrometheusMetrics 创建Prometheus指标收集器
+func NewPrometheusMetrics(config *PrometheusConfig) *PrometheusMetrics {
+	if config == nil {
+		config = &DefaultPrometheusConfig
+	}
+
+	buckets := config.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	labels := []string{"method", "route", "status"}
+
+	pm := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP请求总数",
+		}, labels),
+		errorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_errors_total",
+			Help:      "HTTP错误响应总数（状态码>=400）",
+		}, labels),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: config.Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP请求处理耗时（秒）",
+			Buckets:   buckets,
+		}, labels),
+	}
+
+	pm.registry.MustRegister(pm.requestsTotal, pm.errorsTotal, pm.requestDuration)
+
+	if config.EnableRuntimeMetrics {
+		pm.registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+
+	return pm
+}
+
+// Middleware 记录请求指标的中间件
+func (pm *PrometheusMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// 包装响应写入器
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		// 执行请求
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		values := []string{r.Method, routePattern(r), strconv.Itoa(status)}
+
+		pm.requestsTotal.WithLabelValues(values...).Inc()
+		if status >= 400 {
+			pm.errorsTotal.WithLabelValues(values...).Inc()
+		}
+		pm.requestDuration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Handler 返回Prometheus格式的指标端点处理器
+func (pm *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(pm.registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDBStats 注册数据库连接池指标（打开、使用中、空闲连接数和等待次数、等待时长等），
+// 指标名以 go_sql_ 开头并带 db_name 标签
+func (pm *PrometheusMetrics) RegisterDBStats(db *sql.DB, name string) error {
+	return pm.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterCacheStats 注册缓存读取指标（cache_hits_total、cache_misses_total、cache_evictions_total），
+// 采集时读取一次缓存统计，淘汰数量无法获取时不输出该指标
+func (pm *PrometheusMetrics) RegisterCacheStats(provider cache.StatsProvider) error {
+	return pm.registry.Register(newCacheStatsCollector(provider))
+}
+
+// Registry 返回指标注册表，便于注册其他组件的指标
+func (pm *PrometheusMetrics) Registry() *prometheus.Registry {
+	return pm.registry
+}
+
+// routePattern 获取chi匹配的路由模式，如 /api/v1/users/{id}
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return unmatchedRoute
+	}
+
+	pattern := rctx.RoutePattern()
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	return pattern
+}
+
+// 采集缓存统计的超时时间，避免Redis无响应时阻塞指标端点
+const cacheStatsTimeout = time.Second
+
+// cacheStatsCollector 缓存读取指标收集器
+type cacheStatsCollector struct {
+	provider  cache.StatsProvider
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+}
+
+func newCacheStatsCollector(provider cache.StatsProvider) *cacheStatsCollector {
+	return &cacheStatsCollector{
+		provider:  provider,
+		hits:      prometheus.NewDesc("cache_hits_total", "缓存读取命中次数", nil, nil),
+		misses:    prometheus.NewDesc("cache_misses_total", "缓存读取未命中次数", nil, nil),
+		evictions: prometheus.NewDesc("cache_evictions_total", "缓存因内存达到上限被淘汰的键数量", nil, nil),
+	}
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (c *cacheStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (c *cacheStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheStatsTimeout)
+	defer cancel()
+
+	stats, err := c.provider.Stats(ctx)
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	if err != nil {
+		slog.Debug("获取缓存淘汰数量失败", "error", err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+}
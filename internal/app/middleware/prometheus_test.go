@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	metrics := NewPrometheusMetrics(&PrometheusConfig{
+		Namespace: "test",
+		Buckets:   []float64{0.1, 0.5, 1},
+	})
+
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	for _, path := range []string{"/users/1", "/users/2", "/fail", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	output := string(body)
+
+	// 使用路由模式而非原始路径作为标签
+	t.Run("RoutePatternLabel", func(t *testing.T) {
+		assert.Contains(t, output, `test_http_requests_total{method="GET",route="/users/{id}",status="200"} 2`)
+		assert.NotContains(t, output, `route="/users/1"`)
+		assert.Contains(t, output, `test_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	})
+
+	// 错误计数只统计状态码>=400的请求
+	t.Run("ErrorCounter", func(t *testing.T) {
+		assert.Contains(t, output, `test_http_request_errors_total{method="GET",route="/fail",status="500"} 1`)
+		assert.NotContains(t, output, `test_http_request_errors_total{method="GET",route="/users/{id}"`)
+	})
+
+	// 直方图使用配置的桶
+	t.Run("HistogramBuckets", func(t *testing.T) {
+		assert.Contains(t, output, "# TYPE test_http_request_duration_seconds histogram")
+		assert.Contains(t, output, `test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="200",le="0.5"} 2`)
+		assert.Contains(t, output, `test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="200",le="+Inf"} 2`)
+		assert.Contains(t, output, `test_http_request_duration_seconds_count{method="GET",route="/users/{id}",status="200"} 2`)
+	})
+}
+
+func TestPrometheusMetrics_DBStats(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	metrics := NewPrometheusMetrics(&PrometheusConfig{Namespace: "test"})
+	require.NoError(t, metrics.RegisterDBStats(sqlDB, "primary"))
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// 连接池指标带数据库名称标签
+	output := rec.Body.String()
+	for _, name := range []string{"go_sql_open_connections", "go_sql_in_use_connections", "go_sql_idle_connections", "go_sql_wait_count_total", "go_sql_wait_duration_seconds_total"} {
+		assert.Contains(t, output, name+`{db_name="primary"}`)
+	}
+
+	// 同名数据库不能重复注册
+	assert.Error(t, metrics.RegisterDBStats(sqlDB, "primary"))
+}
+
+// stubCacheStats 返回固定统计的缓存统计提供者
+type stubCacheStats struct {
+	stats cache.Stats
+	err   error
+}
+
+func (s *stubCacheStats) Stats(context.Context) (cache.Stats, error) {
+	return s.stats, s.err
+}
+
+func TestPrometheusMetrics_CacheStats(t *testing.T) {
+	scrape := func(metrics *PrometheusMetrics) string {
+		rec := httptest.NewRecorder()
+		metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	t.Run("all stats", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(&PrometheusConfig{Namespace: "test"})
+		require.NoError(t, metrics.RegisterCacheStats(&stubCacheStats{stats: cache.Stats{Hits: 7, Misses: 3, Evictions: 2}}))
+
+		output := scrape(metrics)
+		assert.Contains(t, output, "cache_hits_total 7")
+		assert.Contains(t, output, "cache_misses_total 3")
+		assert.Contains(t, output, "cache_evictions_total 2")
+	})
+
+	// 淘汰数量无法获取时只输出命中指标
+	t.Run("evictions unavailable", func(t *testing.T) {
+		metrics := NewPrometheusMetrics(&PrometheusConfig{Namespace: "test"})
+		require.NoError(t, metrics.RegisterCacheStats(&stubCacheStats{stats: cache.Stats{Hits: 1}, err: errors.New("info unsupported")}))
+
+		output := scrape(metrics)
+		assert.Contains(t, output, "cache_hits_total 1")
+		assert.Contains(t, output, "cache_misses_total 0")
+		assert.NotContains(t, output, "cache_evictions_total")
+	})
+}
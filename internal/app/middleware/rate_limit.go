@@ -1,8 +1,11 @@
 package middleware
 
 import (
-	"net"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,9 +14,11 @@ import (
 
 // RateLimitConfig 速率限制配置
 type RateLimitConfig struct {
-	RequestsPerSecond int           // 每秒允许请求数
+	RequestsPerSecond float64       // 每秒允许请求数（可小于1，例如0.2表示每5秒1次）
 	Burst             int           // 突发请求数
 	CleanupInterval   time.Duration // 清理过期记录的间隔
+	// Policies 命名的限制配置，通过 Limit(name) 应用到特定路由组
+	Policies map[string]RateLimitConfig
 }
 
 // DefaultRateLimitConfig 默认速率限制配置
@@ -21,25 +26,51 @@ var DefaultRateLimitConfig = RateLimitConfig{
 	RequestsPerSecond: 10,
 	Burst:             20,
 	CleanupInterval:   10 * time.Minute,
+	Policies: map[string]RateLimitConfig{
+		// 认证接口更严格，防止暴力破解
+		"auth": {RequestsPerSecond: 0.2, Burst: 5},
+		// 已认证接口按用户限制
+		"api": {RequestsPerSecond: 10, Burst: 20},
+	},
 }
 
+// KeyFunc 从请求中提取速率限制键
+type KeyFunc func(r *http.Request) string
+
+// UserOrIPKey 已认证时按用户ID限制，否则按客户端IP限制
+func UserOrIPKey(r *http.Request) string {
+	if userID, ok := GetUserID(r.Context()); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + getClientIP(r)
+}
+
+// 默认策略名称
+const defaultRateLimitPolicy = "default"
+
 // rateLimiter 速率限制器
 type rateLimiter struct {
 	limiter  *rate.Limiter
 	lastSeen time.Time
 }
 
-// RateLimitMiddleware 基于 IP 的速率限制中间件
+// RateLimitMiddleware 速率限制中间件，支持按用户/IP和命名策略限制
 type RateLimitMiddleware struct {
 	config   RateLimitConfig
+	keyFunc  KeyFunc
 	limiters map[string]*rateLimiter
 	mu       sync.RWMutex
 }
 
-// NewRateLimitMiddleware 创建新的速率限制中间件
-func NewRateLimitMiddleware(config RateLimitConfig) *RateLimitMiddleware {
+// NewRateLimitMiddleware 创建新的速率限制中间件，keyFunc为空时使用 UserOrIPKey
+func NewRateLimitMiddleware(config RateLimitConfig, keyFunc KeyFunc) *RateLimitMiddleware {
+	if keyFunc == nil {
+		keyFunc = UserOrIPKey
+	}
+
 	rlm := &RateLimitMiddleware{
 		config:   config,
+		keyFunc:  keyFunc,
 		limiters: make(map[string]*rateLimiter),
 	}
 
@@ -49,18 +80,55 @@ func NewRateLimitMiddleware(config RateLimitConfig) *RateLimitMiddleware {
 	return rlm
 }
 
-// Handler 速率限制中间件处理函数
+// Handler 使用默认配置的速率限制中间件处理函数
+// 注意：全局注册时位于JWT认证之前，此时只能按IP限制
 func (rlm *RateLimitMiddleware) Handler(next http.Handler) http.Handler {
+	return rlm.handler(defaultRateLimitPolicy, rlm.config, next)
+}
+
+// Limit 返回使用命名策略的速率限制中间件，未找到策略时使用默认配置
+func (rlm *RateLimitMiddleware) Limit(name string) func(http.Handler) http.Handler {
+	config, ok := rlm.config.Policies[name]
+	if !ok {
+		slog.Warn("未找到速率限制策略，使用默认配置", "policy", name)
+		config = rlm.config
+	}
+
+	return func(next http.Handler) http.Handler {
+		return rlm.handler(name, config, next)
+	}
+}
+
+// handler 按指定策略执行速率限制
+func (rlm *RateLimitMiddleware) handler(policy string, config RateLimitConfig, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 获取客户端 IP
-		ip := getClientIP(r)
+		// 获取限制键（用户ID或客户端IP）
+		key := policy + ":" + rlm.keyFunc(r)
 
 		// 获取或创建限制器
-		limiter := rlm.getLimiter(ip)
+		limiter := rlm.getLimiter(key, config)
 
 		// 检查是否允许请求
-		if !limiter.Allow() {
-			writeRateLimitResponse(w)
+		allowed := limiter.Allow()
+		tokens := limiter.Tokens()
+
+		remaining := int(math.Floor(tokens))
+		if remaining < 0 {
+			remaining = 0
+		}
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(config.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			// 计算获得下一个令牌所需的时间
+			retryAfter := 1
+			if config.RequestsPerSecond > 0 {
+				retryAfter = int(math.Ceil((1 - tokens) / config.RequestsPerSecond))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+			}
+			writeRateLimitResponse(w, retryAfter)
 			return
 		}
 
@@ -68,21 +136,21 @@ func (rlm *RateLimitMiddleware) Handler(next http.Handler) http.Handler {
 	})
 }
 
-// getLimiter 获取或创建 IP 对应的限制器
-func (rlm *RateLimitMiddleware) getLimiter(ip string) *rate.Limiter {
+// getLimiter 获取或创建键对应的限制器
+func (rlm *RateLimitMiddleware) getLimiter(key string, config RateLimitConfig) *rate.Limiter {
 	rlm.mu.Lock()
 	defer rlm.mu.Unlock()
 
-	limiterInfo, exists := rlm.limiters[ip]
+	limiterInfo, exists := rlm.limiters[key]
 	if !exists {
 		limiterInfo = &rateLimiter{
 			limiter: rate.NewLimiter(
-				rate.Limit(rlm.config.RequestsPerSecond),
-				rlm.config.Burst,
+				rate.Limit(config.RequestsPerSecond),
+				config.Burst,
 			),
 			lastSeen: time.Now(),
 		}
-		rlm.limiters[ip] = limiterInfo
+		rlm.limiters[key] = limiterInfo
 	} else {
 		limiterInfo.lastSeen = time.Now()
 	}
@@ -99,9 +167,9 @@ func (rlm *RateLimitMiddleware) cleanup() {
 		rlm.mu.Lock()
 		cutoff := time.Now().Add(-rlm.config.CleanupInterval * 2)
 
-		for ip, limiterInfo := range rlm.limiters {
+		for key, limiterInfo := range rlm.limiters {
 			if limiterInfo.lastSeen.Before(cutoff) {
-				delete(rlm.limiters, ip)
+				delete(rlm.limiters, key)
 			}
 		}
 		rlm.mu.Unlock()
@@ -109,13 +177,11 @@ func (rlm *RateLimitMiddleware) cleanup() {
 }
 
 // writeRateLimitResponse 写入速率限制响应
-func writeRateLimitResponse(w http.ResponseWriter) {
+func writeRateLimitResponse(w http.ResponseWriter, retryAfter int) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-RateLimit-Limit", "10")
-	w.Header().Set("X-RateLimit-Remaining", "0")
-	w.Header().Set("Retry-After", "60")
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
 	w.WriteHeader(http.StatusTooManyRequests)
-	
+
 	response := `{
 		"error": {
 			"type": "RATE_LIMIT_EXCEEDED",
@@ -123,34 +189,15 @@ func writeRateLimitResponse(w http.ResponseWriter) {
 			"details": "Rate limit exceeded. Please try again later."
 		}
 	}`
-	
+
 	w.Write([]byte(response))
 }
 
-// getClientIP 获取客户端真实IP地址
+// getClientIP 获取客户端IP地址
+// 优先使用 ClientIPResolver 中间件按可信代理解析的结果，否则只使用 RemoteAddr，不信任可伪造的转发请求头
 func getClientIP(r *http.Request) string {
-	// 检查 X-Forwarded-For 头
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// 取第一个IP地址
-		if idx := len(xff); idx > 0 {
-			return xff[:idx]
-		}
+	if ip := GetClientIP(r.Context()); ip != "" {
+		return ip
 	}
-
-	// 检查 X-Real-IP 头
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-
-	// 检查 X-Forwarded-For 头的第一个地址
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		return xff
-	}
-
-	// 默认使用 RemoteAddr
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return host
-}
\ No newline at end of file
+	return remoteIP(r.RemoteAddr)
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter() *RateLimitMiddleware {
+	return NewRateLimitMiddleware(RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             3,
+		CleanupInterval:   time.Minute,
+		Policies: map[string]RateLimitConfig{
+			"strict": {RequestsPerSecond: 0.1, Burst: 1},
+		},
+	}, nil)
+}
+
+func doRateLimitedRequest(handler http.Handler, remoteAddr string, userID uint) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.RemoteAddr = remoteAddr
+	if userID != 0 {
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey{}, userID))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// 剩余令牌数随请求递减
+	t.Run("RemainingHeader", func(t *testing.T) {
+		handler := newTestRateLimiter().Handler(okHandler)
+
+		rec := doRateLimitedRequest(handler, "10.0.0.1:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Remaining"))
+
+		rec = doRateLimitedRequest(handler, "10.0.0.1:1234", 0)
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+	})
+
+	// 已认证用户按用户ID限制，同一IP下的不同用户互不影响
+	t.Run("UserKeyedLimiting", func(t *testing.T) {
+		handler := newTestRateLimiter().Handler(okHandler)
+
+		for i := 0; i < 3; i++ {
+			rec := doRateLimitedRequest(handler, "10.0.0.2:1234", 1)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		rec := doRateLimitedRequest(handler, "10.0.0.2:1234", 1)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+		// 相同IP的另一个用户仍可访问
+		rec = doRateLimitedRequest(handler, "10.0.0.2:1234", 2)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		// 相同IP的未认证请求使用IP作为键，也不受影响
+		rec = doRateLimitedRequest(handler, "10.0.0.2:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// 命名策略覆盖默认配置，且与默认限制器互相独立
+	t.Run("PerRouteOverride", func(t *testing.T) {
+		rlm := newTestRateLimiter()
+		strict := rlm.Limit("strict")(okHandler)
+		normal := rlm.Handler(okHandler)
+
+		rec := doRateLimitedRequest(strict, "10.0.0.3:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Limit"))
+
+		rec = doRateLimitedRequest(strict, "10.0.0.3:1234", 0)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get("Retry-After"))
+
+		rec = doRateLimitedRequest(normal, "10.0.0.3:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// 未知策略回退到默认配置
+	t.Run("UnknownPolicyFallback", func(t *testing.T) {
+		handler := newTestRateLimiter().Limit("missing")(okHandler)
+
+		rec := doRateLimitedRequest(handler, "10.0.0.4:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get("X-RateLimit-Limit"))
+	})
+
+	// 自定义键函数
+	t.Run("CustomKeyFunc", func(t *testing.T) {
+		rlm := NewRateLimitMiddleware(RateLimitConfig{
+			RequestsPerSecond: 0.1,
+			Burst:             1,
+			CleanupInterval:   time.Minute,
+		}, func(r *http.Request) string { return "shared" })
+		handler := rlm.Handler(okHandler)
+
+		assert.Equal(t, http.StatusOK, doRateLimitedRequest(handler, "10.0.0.5:1234", 0).Code)
+		assert.Equal(t, http.StatusTooManyRequests, doRateLimitedRequest(handler, "10.0.0.6:1234", 0).Code)
+	})
+}
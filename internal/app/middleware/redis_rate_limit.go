@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+// RateLimiter 速率限制器接口，内存实现和Redis实现均满足该接口
+type RateLimiter interface {
+	Handler(next http.Handler) http.Handler
+}
+
+// RedisRateLimitConfig Redis分布式速率限制配置
+type RedisRateLimitConfig struct {
+	Limit     int           // 窗口内允许的最大请求数
+	Window    time.Duration // 滑动窗口大小
+	KeyPrefix string        // Redis键前缀
+	Timeout   time.Duration // Redis操作超时，超时后放行请求
+}
+
+// DefaultRedisRateLimitConfig 默认Redis速率限制配置
+var DefaultRedisRateLimitConfig = RedisRateLimitConfig{
+	Limit:     600,
+	Window:    time.Minute,
+	KeyPrefix: "ratelimit:",
+	Timeout:   100 * time.Millisecond,
+}
+
+// slidingWindowScript 滑动窗口限流脚本
+// 使用有序集合记录窗口内每个请求的时间戳，保证计数的原子性
+//
+// KEYS[1] 限流键
+// ARGV[1] 当前时间（毫秒）
+// ARGV[2] 窗口大小（毫秒）
+// ARGV[3] 窗口内允许的请求数
+// ARGV[4] 本次请求的唯一成员
+//
+// 返回 {是否允许, 剩余请求数, 重试等待毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	return {1, limit - count - 1, 0}
+end
+
+local retry = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	retry = tonumber(oldest[2]) + window - now
+end
+return {0, 0, retry}
+`)
+
+// RedisRateLimiter 基于Redis的分布式速率限制器，适用于多实例部署
+type RedisRateLimiter struct {
+	client  *redis.Client
+	config  RedisRateLimitConfig
+	keyFunc KeyFunc
+	// 实例标识与序号组合成窗口成员，避免多实例同一毫秒内的成员冲突
+	instanceID string
+	seq        atomic.Uint64
+}
+
+// NewRedisRateLimiter 创建Redis速率限制器，keyFunc为空时使用 UserOrIPKey
+func NewRedisRateLimiter(client *redis.Client, config RedisRateLimitConfig, keyFunc KeyFunc) *RedisRateLimiter {
+	if keyFunc == nil {
+		keyFunc = UserOrIPKey
+	}
+
+	instanceID, err := utils.GenerateRandomString(6)
+	if err != nil {
+		instanceID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return &RedisRateLimiter{
+		client:     client,
+		config:     config,
+		keyFunc:    keyFunc,
+		instanceID: instanceID,
+	}
+}
+
+// Handler 速率限制中间件处理函数
+func (rl *RedisRateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, remaining, retryAfter, err := rl.allow(r.Context(), rl.keyFunc(r))
+		if err != nil {
+			// Redis不可用时降级放行，避免限流组件影响可用性
+			slog.Warn("Redis速率限制不可用，放行请求", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.config.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if !allowed {
+			writeRateLimitResponse(w, int(math.Ceil(retryAfter.Seconds())))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow 执行限流脚本，判断请求是否被允许
+func (rl *RedisRateLimiter) allow(ctx context.Context, key string) (bool, int, time.Duration, error) {
+	if rl.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rl.config.Timeout)
+		defer cancel()
+	}
+
+	now := time.Now().UnixMilli()
+	member := fmt.Sprintf("%d-%s-%d", now, rl.instanceID, rl.seq.Add(1))
+
+	result, err := slidingWindowScript.Run(ctx, rl.client,
+		[]string{rl.config.KeyPrefix + key},
+		now, rl.config.Window.Milliseconds(), rl.config.Limit, member,
+	).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	if len(result) != 3 {
+		return false, 0, 0, fmt.Errorf("限流脚本返回值异常: %v", result)
+	}
+
+	retryAfter := time.Duration(result[2]) * time.Millisecond
+	if result[0] == 0 && retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	return result[0] == 1, int(result[1]), retryAfter, nil
+}
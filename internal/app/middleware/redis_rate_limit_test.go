@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisRateLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	config := RedisRateLimitConfig{
+		Limit:     2,
+		Window:    time.Minute,
+		KeyPrefix: "ratelimit:",
+		Timeout:   time.Second,
+	}
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// 超过窗口限制后拒绝请求
+	t.Run("LimitWithinWindow", func(t *testing.T) {
+		handler := NewRedisRateLimiter(client, config, nil).Handler(okHandler)
+
+		rec := doRateLimitedRequest(handler, "10.0.1.1:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("X-RateLimit-Remaining"))
+
+		rec = doRateLimitedRequest(handler, "10.0.1.1:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+
+		rec = doRateLimitedRequest(handler, "10.0.1.1:1234", 0)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+		// 其他客户端不受影响
+		rec = doRateLimitedRequest(handler, "10.0.1.2:1234", 0)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// 多个实例共享同一个计数
+	t.Run("SharedAcrossInstances", func(t *testing.T) {
+		instanceA := NewRedisRateLimiter(client, config, nil).Handler(okHandler)
+		instanceB := NewRedisRateLimiter(client, config, nil).Handler(okHandler)
+
+		assert.Equal(t, http.StatusOK, doRateLimitedRequest(instanceA, "10.0.1.3:1234", 0).Code)
+		assert.Equal(t, http.StatusOK, doRateLimitedRequest(instanceB, "10.0.1.3:1234", 0).Code)
+		assert.Equal(t, http.StatusTooManyRequests, doRateLimitedRequest(instanceA, "10.0.1.3:1234", 0).Code)
+	})
+
+	// 限流键设置了过期时间
+	t.Run("KeyExpiration", func(t *testing.T) {
+		handler := NewRedisRateLimiter(client, config, nil).Handler(okHandler)
+		doRateLimitedRequest(handler, "10.0.1.4:1234", 0)
+
+		ttl := mr.TTL("ratelimit:ip:10.0.1.4")
+		require.True(t, ttl > 0)
+		assert.LessOrEqual(t, ttl, config.Window)
+	})
+
+	// Redis不可用时降级放行
+	t.Run("DegradeWhenRedisUnavailable", func(t *testing.T) {
+		brokenClient := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+		defer brokenClient.Close()
+		handler := NewRedisRateLimiter(brokenClient, config, nil).Handler(okHandler)
+
+		for i := 0; i < 5; i++ {
+			rec := doRateLimitedRequest(handler, "10.0.1.5:1234", 0)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+}
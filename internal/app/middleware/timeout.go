@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// DefaultRequestTimeout 默认请求处理超时，与 ServerConfig.Timeout 的默认值一致
+const DefaultRequestTimeout = 30 * time.Second
+
+// TimeoutMiddleware 请求超时中间件，timeout<=0时使用 DefaultRequestTimeout
+// 为请求派生带截止时间的上下文，下游数据库和Redis调用随之取消；
+// 处理器的响应先写入缓冲区，超时后丢弃处理器的输出，返回504 JSON错误响应；
+// 服务器推送事件（Accept: text/event-stream）和WebSocket升级请求是长连接，不设超时，响应不经缓冲直接写出
+func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isLongLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicChan := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				// 在当前协程重新抛出，交给外层的恢复中间件处理
+				panic(p)
+			case <-done:
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.markTimedOut()
+				// 客户端主动断开时无需响应
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					handlers.RespondError(w, r, apperrors.TimeoutError("请求处理超时", ctx.Err()))
+				}
+			}
+		})
+	}
+}
+
+// isLongLived 判断请求是否为服务器推送事件或WebSocket长连接
+func isLongLived(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// timeoutWriter 缓冲处理器的响应，超时后拒绝继续写入
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+// Header 返回处理器可修改的响应头，未超时完成时复制到实际响应
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader 记录响应状态码
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.status = status
+	tw.wroteHeader = true
+}
+
+// Write 写入缓冲区，超时后返回 http.ErrHandlerTimeout
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+		tw.wroteHeader = true
+	}
+	return tw.buf.Write(p)
+}
+
+// markTimedOut 标记已超时，之后处理器的写入全部丢弃
+func (tw *timeoutWriter) markTimedOut() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+}
+
+// flushTo 将缓冲的响应头、状态码和响应体写入实际响应
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.buf.Bytes())
+}
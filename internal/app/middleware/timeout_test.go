@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	// 处理器超过截止时间时返回504 JSON错误，下游上下文被取消，之后的写入被丢弃
+	t.Run("SlowHandler", func(t *testing.T) {
+		ctxErr := make(chan error, 1)
+		writeErr := make(chan error, 1)
+		handler := TimeoutMiddleware(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			ctxErr <- r.Context().Err()
+
+			// 等待中间件写完超时响应后再写入
+			time.Sleep(20 * time.Millisecond)
+			w.Header().Set("X-Late", "1")
+			_, err := w.Write([]byte(`{"late":true}`))
+			writeErr <- err
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		req.Header.Set("Accept-Language", "en")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var resp handlers.Response
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+		assert.False(t, resp.Success)
+		assert.Equal(t, "Request timed out", resp.Msg)
+		data, ok := resp.Data.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, string(apperrors.ErrorTypeTimeout), data["type"])
+
+		assert.ErrorIs(t, <-ctxErr, context.DeadlineExceeded)
+		assert.ErrorIs(t, <-writeErr, http.ErrHandlerTimeout)
+		assert.Empty(t, rec.Header().Get("X-Late"))
+		assert.NotContains(t, rec.Body.String(), "late")
+	})
+
+	// 未超时时原样返回处理器的状态码、响应头和响应体
+	t.Run("FastHandler", func(t *testing.T) {
+		handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline := r.Context().Deadline()
+			assert.True(t, hasDeadline)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"ok":true}`))
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `{"ok":true}`, rec.Body.String())
+	})
+
+	// 服务器推送事件的长连接不设截止时间，超过超时时间后仍可直接写出并刷新
+	t.Run("EventStream", func(t *testing.T) {
+		handler := TimeoutMiddleware(10 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline := r.Context().Deadline()
+			assert.False(t, hasDeadline)
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			time.Sleep(30 * time.Millisecond)
+			w.Write([]byte("data: {}\n\n"))
+			require.NoError(t, http.NewResponseController(w).Flush())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?topic=jobs", nil)
+		req.Header.Set("Accept", "text/event-stream")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, rec.Flushed)
+		assert.Equal(t, "data: {}\n\n", rec.Body.String())
+	})
+
+	// WebSocket升级请求不经缓冲，处理器可以劫持连接
+	t.Run("WebSocketUpgrade", func(t *testing.T) {
+		handler := TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline := r.Context().Deadline()
+			assert.False(t, hasDeadline)
+			_, ok := w.(*timeoutWriter)
+			assert.False(t, ok)
+			w.WriteHeader(http.StatusSwitchingProtocols)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusSwitchingProtocols, rec.Code)
+	})
+
+	// 处理器panic时在中间件所在协程重新抛出，由恢复中间件处理
+	t.Run("PanicPropagates", func(t *testing.T) {
+		handler := RecoveryMiddleware(TimeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -5,12 +5,28 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
+// tracerName 中间件使用的追踪器名称
+const tracerName = "github.com/vadxq/go-rest-starter/internal/app/middleware"
+
 // TracingMiddleware 请求追踪中间件
+// 从请求头提取上游追踪上下文（优先W3C traceparent/tracestate，其次B3），为每个请求创建服务端span，
+// span随请求上下文传递到服务层和仓库层；格式错误的traceparent会被忽略并开启新的链路，
+// 未配置TracerProvider时仅透传 X-Trace-ID 等追踪头
 func TracingMiddleware(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 获取或生成请求ID
 		requestID := r.Header.Get("X-Request-ID")
@@ -21,45 +37,86 @@ func TracingMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
+		// 提取上游追踪上下文并创建请求span
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		parent := trace.SpanContextFromContext(ctx)
+		ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				semconv.ClientAddress(r.RemoteAddr),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("http.request_id", requestID),
+			),
+		)
+		defer span.End()
+
 		// 获取或生成链路追踪ID
-		traceID := r.Header.Get("X-Trace-ID")
-		if traceID == "" {
-			traceID = r.Header.Get("X-B3-TraceId") // 支持Zipkin B3格式
+		var traceID, spanID, parentSpanID string
+		if sc := span.SpanContext(); sc.IsValid() {
+			traceID = sc.TraceID().String()
+			spanID = sc.SpanID().String()
+			if parent.IsValid() {
+				parentSpanID = parent.SpanID().String()
+			}
+		} else {
+			traceID = r.Header.Get("X-Trace-ID")
 			if traceID == "" {
-				traceID = requestID // 如果没有trace ID，使用request ID
+				traceID = r.Header.Get("X-B3-TraceId") // 支持Zipkin B3格式
+				if traceID == "" {
+					traceID = requestID // 如果没有trace ID，使用request ID
+				}
 			}
-		}
 
-		// 获取span ID（如果存在）
-		spanID := r.Header.Get("X-Span-ID")
-		if spanID == "" {
-			spanID = r.Header.Get("X-B3-SpanId") // 支持Zipkin B3格式
-		}
+			// 获取span ID（如果存在）
+			spanID = r.Header.Get("X-Span-ID")
+			if spanID == "" {
+				spanID = r.Header.Get("X-B3-SpanId") // 支持Zipkin B3格式
+			}
 
-		// 获取parent span ID（如果存在）
-		parentSpanID := r.Header.Get("X-Parent-Span-ID")
-		if parentSpanID == "" {
-			parentSpanID = r.Header.Get("X-B3-ParentSpanId") // 支持Zipkin B3格式
+			// 获取parent span ID（如果存在）
+			parentSpanID = r.Header.Get("X-Parent-Span-ID")
+			if parentSpanID == "" {
+				parentSpanID = r.Header.Get("X-B3-ParentSpanId") // 支持Zipkin B3格式
+			}
 		}
 
-		// 设置响应头
+		// 设置响应头，traceparent/tracestate 携带本次请求的span，便于调用方关联
 		w.Header().Set("X-Request-ID", requestID)
 		w.Header().Set("X-Trace-ID", traceID)
 		if spanID != "" {
 			w.Header().Set("X-Span-ID", spanID)
 		}
+		propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(w.Header()))
 
 		// 创建带有追踪信息的上下文
-		ctx := r.Context()
 		ctx = logger.WithRequestID(ctx, requestID)
 		ctx = logger.WithTraceID(ctx, traceID)
-		ctx = context.WithValue(ctx, "span_id", spanID)
-		ctx = context.WithValue(ctx, "parent_span_id", parentSpanID)
+		ctx = WithSpanIDs(ctx, spanID, parentSpanID)
 
 		// 将追踪信息添加到Chi上下文
 		ctx = context.WithValue(ctx, middleware.RequestIDKey, requestID)
 
-		next.ServeHTTP(w, r.WithContext(ctx))
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// 路由匹配完成后使用路由模板命名span，避免路径参数导致span名称过多
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	})
 }
 
@@ -68,8 +125,8 @@ func GetTraceInfo(ctx context.Context) TraceInfo {
 	return TraceInfo{
 		RequestID:    logger.GetRequestID(ctx),
 		TraceID:      logger.GetTraceID(ctx),
-		SpanID:       getStringFromContext(ctx, "span_id"),
-		ParentSpanID: getStringFromContext(ctx, "parent_span_id"),
+		SpanID:       GetSpanID(ctx),
+		ParentSpanID: GetParentSpanID(ctx),
 	}
 }
 
@@ -81,50 +138,24 @@ type TraceInfo struct {
 	ParentSpanID string `json:"parent_span_id,omitempty"`
 }
 
-// getStringFromContext 从上下文获取字符串值
-func getStringFromContext(ctx context.Context, key string) string {
-	if value := ctx.Value(key); value != nil {
-		if str, ok := value.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
 // RequestContextMiddleware 请求上下文中间件
 func RequestContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 创建请求上下文
-		ctx := r.Context()
-		
-		// 添加请求方法和路径
-		ctx = context.WithValue(ctx, "http_method", r.Method)
-		ctx = context.WithValue(ctx, "http_path", r.URL.Path)
-		ctx = context.WithValue(ctx, "http_query", r.URL.RawQuery)
-		ctx = context.WithValue(ctx, "user_agent", r.UserAgent())
-		ctx = context.WithValue(ctx, "remote_addr", r.RemoteAddr)
-		
-		// 添加常用请求头
-		ctx = context.WithValue(ctx, "content_type", r.Header.Get("Content-Type"))
-		ctx = context.WithValue(ctx, "accept", r.Header.Get("Accept"))
+		// 添加请求方法、路径和常用请求头
+		ctx := WithHTTPRequestContext(r.Context(), HTTPRequestContext{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Query:       r.URL.RawQuery,
+			UserAgent:   r.UserAgent(),
+			RemoteAddr:  r.RemoteAddr,
+			ContentType: r.Header.Get("Content-Type"),
+			Accept:      r.Header.Get("Accept"),
+		})
 		
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// GetHTTPRequestContext 获取HTTP请求上下文信息
-func GetHTTPRequestContext(ctx context.Context) HTTPRequestContext {
-	return HTTPRequestContext{
-		Method:      getStringFromContext(ctx, "http_method"),
-		Path:        getStringFromContext(ctx, "http_path"),
-		Query:       getStringFromContext(ctx, "http_query"),
-		UserAgent:   getStringFromContext(ctx, "user_agent"),
-		RemoteAddr:  getStringFromContext(ctx, "remote_addr"),
-		ContentType: getStringFromContext(ctx, "content_type"),
-		Accept:      getStringFromContext(ctx, "accept"),
-	}
-}
-
 // HTTPRequestContext HTTP请求上下文信息
 type HTTPRequestContext struct {
 	Method      string `json:"method"`
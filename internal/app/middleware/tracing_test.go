@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/vadxq/go-rest-starter/pkg/tracing"
+)
+
+// newTestTracing 使用内存导出器替换全局 TracerProvider，测试结束后恢复
+func newTestTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(tracing.Propagator())
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	return exporter
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := newTestTracing(t)
+
+	var handlerSpan trace.SpanContext
+	r := chi.NewRouter()
+	r.Use(TracingMiddleware)
+	r.Get("/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	// 每个请求创建一个以路由模板命名的服务端span，并传递到处理器上下文
+	t.Run("SpanPerRequest", func(t *testing.T) {
+		exporter.Reset()
+
+		for _, id := range []string{"1", "2"} {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id, nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, handlerSpan.TraceID().String(), rec.Header().Get("X-Trace-ID"))
+		}
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 2)
+		for _, span := range spans {
+			assert.Equal(t, "GET /api/v1/users/{id}", span.Name)
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+		}
+		assert.NotEqual(t, spans[0].SpanContext.TraceID(), spans[1].SpanContext.TraceID())
+	})
+
+	// 延续上游W3C traceparent中的追踪ID，响应中返回携带新span ID的traceparent
+	t.Run("TraceParent", func(t *testing.T) {
+		exporter.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		req.Header.Set("tracestate", "vendor=value")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		span := spans[0]
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent.SpanID().String())
+		assert.NotEqual(t, "00f067aa0ba902b7", span.SpanContext.SpanID().String())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", rec.Header().Get("X-Trace-ID"))
+
+		// 处理器上下文和响应头中都是本次请求的span
+		assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext.SpanID().String()+"-01",
+			rec.Header().Get("traceparent"))
+		assert.Equal(t, "vendor=value", rec.Header().Get("tracestate"))
+	})
+
+	// 格式错误的traceparent被忽略，开启新的链路
+	t.Run("MalformedTraceParent", func(t *testing.T) {
+		for _, header := range []string{
+			"not-a-traceparent",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		} {
+			exporter.Reset()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+			req.Header.Set("traceparent", header)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1, header)
+			span := spans[0]
+			assert.False(t, span.Parent.IsValid(), header)
+			assert.NotEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), header)
+			assert.Equal(t, span.SpanContext.TraceID().String(), rec.Header().Get("X-Trace-ID"), header)
+			assert.Equal(t, "00-"+span.SpanContext.TraceID().String()+"-"+span.SpanContext.SpanID().String()+"-01",
+				rec.Header().Get("traceparent"), header)
+		}
+	})
+
+	// traceparent格式错误时回退到B3请求头
+	t.Run("MalformedTraceParentB3Fallback", func(t *testing.T) {
+		exporter.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		req.Header.Set("traceparent", "garbage")
+		req.Header.Set("X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7")
+		req.Header.Set("X-B3-SpanId", "e457b5a2e4d86bd1")
+		req.Header.Set("X-B3-Sampled", "1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", spans[0].SpanContext.TraceID().String())
+		assert.Equal(t, "e457b5a2e4d86bd1", spans[0].Parent.SpanID().String())
+	})
+
+	// 兼容B3请求头
+	t.Run("B3Headers", func(t *testing.T) {
+		exporter.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil)
+		req.Header.Set("X-B3-TraceId", "80f198ee56343ba864fe8b2a57d3eff7")
+		req.Header.Set("X-B3-SpanId", "e457b5a2e4d86bd1")
+		req.Header.Set("X-B3-Sampled", "1")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", spans[0].SpanContext.TraceID().String())
+		assert.Equal(t, "e457b5a2e4d86bd1", spans[0].Parent.SpanID().String())
+	})
+
+	// 5xx响应标记span为错误
+	t.Run("ServerError", func(t *testing.T) {
+		exporter.Reset()
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+}
+
+// 未启用导出时仍为每个请求生成新的span ID，并保留上游的采样标记
+func TestTracingMiddleware_ExportDisabled(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	shutdown, err := tracing.Init(context.Background(), &tracing.Config{Enabled: false})
+	require.NoError(t, err)
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	handler := TracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, flags := range []string{"01", "00"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-"+flags)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		parts := strings.Split(rec.Header().Get("traceparent"), "-")
+		require.Len(t, parts, 4)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", parts[1])
+		assert.NotEqual(t, "00f067aa0ba902b7", parts[2])
+		assert.Equal(t, parts[2], rec.Header().Get("X-Span-ID"))
+		assert.Equal(t, flags, parts[3])
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// APIVersionHeader 响应中标明实际使用的API版本的响应头
+const APIVersionHeader = "API-Version"
+
+// apiVersionKey API版本的上下文键
+type apiVersionKey struct{}
+
+// APIVersionConfig API版本协商配置
+type APIVersionConfig struct {
+	Vendor   string   // 厂商媒体类型中的名称，如 application/vnd.myapp.v2+json 中的 myapp
+	Versions []string // 已注册路由的版本，如 v1、v2
+	Default  string   // Accept 请求头未指定版本时使用的版本
+	Prefix   string   // 版本化API的路径前缀
+}
+
+// DefaultAPIVersionConfig 默认API版本协商配置
+var DefaultAPIVersionConfig = APIVersionConfig{
+	Vendor:   "myapp",
+	Versions: []string{"v1", "v2"},
+	Default:  "v1",
+	Prefix:   "/api",
+}
+
+// APIVersion API版本协商中间件，需注册在路由匹配之前
+// 路径中带版本（如 /api/v1/users）时以路径为准；路径不带版本（如 /api/users）时
+// 按 Accept 请求头中的厂商媒体类型（如 application/vnd.myapp.v2+json）选择版本并改写为对应版本的路径，
+// 未指定版本时使用默认版本，指定了不支持的版本时返回406。config为空时使用默认配置
+// 选择的版本没有对应路由时（如 v2 未提供的接口）回退到默认版本，API-Version 响应头为实际使用的版本
+func APIVersion(config *APIVersionConfig) func(http.Handler) http.Handler {
+	if config == nil {
+		config = &DefaultAPIVersionConfig
+	}
+
+	mediaTypePrefix := "application/vnd." + strings.ToLower(config.Vendor) + "."
+	prefix := strings.TrimRight(config.Prefix, "/") + "/"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rctx := chi.RouteContext(r.Context())
+			path := r.URL.Path
+			if rctx != nil && rctx.RoutePath != "" {
+				path = rctx.RoutePath
+			}
+
+			rest, ok := strings.CutPrefix(path, prefix)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			version, _, _ := strings.Cut(rest, "/")
+			if !isVersionSegment(version) {
+				// 响应随 Accept 请求头变化，提示缓存按该请求头区分
+				w.Header().Add("Vary", "Accept")
+
+				var err error
+				version, err = negotiateVersion(r.Header.Get("Accept"), mediaTypePrefix, config)
+				if err != nil {
+					handlers.RespondError(w, r, err)
+					return
+				}
+
+				// 改写为带版本的路径，后续中间件和路由按改写后的路径处理
+				newPath := prefix + version + "/" + rest
+				if version != config.Default && !routeExists(rctx, r.Method, newPath) {
+					version = config.Default
+					newPath = prefix + version + "/" + rest
+				}
+				if rctx != nil && rctx.RoutePath != "" {
+					rctx.RoutePath = newPath
+				}
+				r.URL.Path = newPath
+				r.URL.RawPath = ""
+			}
+
+			w.Header().Set(APIVersionHeader, version)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+		})
+	}
+}
+
+// GetAPIVersion 获取请求使用的API版本，未经过 APIVersion 中间件时返回空字符串
+func GetAPIVersion(ctx context.Context) string {
+	version, _ := ctx.Value(apiVersionKey{}).(string)
+	return version
+}
+
+// negotiateVersion 按Accept请求头中厂商媒体类型出现的顺序选择第一个支持的版本
+// 没有指定版本的厂商媒体类型时使用默认版本，指定的版本都不支持时返回错误
+func negotiateVersion(accept, mediaTypePrefix string, config *APIVersionConfig) (string, error) {
+	requested := false
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(mediaRange, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		rest, ok := strings.CutPrefix(mediaType, mediaTypePrefix)
+		if !ok {
+			continue
+		}
+		version, ok := strings.CutSuffix(rest, "+json")
+		if !ok || !isVersionSegment(version) {
+			continue
+		}
+
+		requested = true
+		for _, supported := range config.Versions {
+			if version == supported {
+				return version, nil
+			}
+		}
+	}
+
+	if requested {
+		return "", apperrors.NotAcceptableError("不支持请求的API版本", nil).WithCode(apperrors.CodeAPIVersionUnsupported)
+	}
+	return config.Default, nil
+}
+
+// routeExists 判断当前路由器中是否注册了该路径和方法的路由，不在chi路由器中时视为存在
+func routeExists(rctx *chi.Context, method, path string) bool {
+	if rctx == nil || rctx.Routes == nil {
+		return true
+	}
+	return rctx.Routes.Match(chi.NewRouteContext(), method, path)
+}
+
+// isVersionSegment 是否为 v 加数字形式的版本号
+func isVersionSegment(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
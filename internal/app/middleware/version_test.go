@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// newVersionedRouter 创建注册了 v1 和 v2 用户列表路由及仅 v1 登录路由的路由器，响应体为匹配的路由和上下文中的版本
+func newVersionedRouter() http.Handler {
+	r := chi.NewRouter()
+	r.Use(APIVersion(nil))
+	for _, version := range []string{"v1", "v2"} {
+		route := "/api/" + version + "/users"
+		r.Get(route, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(route + " " + GetAPIVersion(r.Context())))
+		})
+	}
+	r.Route("/api/v1/auth", func(r chi.Router) {
+		r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("/api/v1/auth/login " + GetAPIVersion(r.Context())))
+		})
+	})
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok " + GetAPIVersion(r.Context())))
+	})
+	return r
+}
+
+func doVersionRequest(handler http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIVersion(t *testing.T) {
+	handler := newVersionedRouter()
+
+	// Accept 请求头选择 v1 和 v2
+	t.Run("FromAcceptHeader", func(t *testing.T) {
+		rec := doVersionRequest(handler, "/api/users", "application/vnd.myapp.v1+json")
+		assert.Equal(t, "/api/v1/users v1", rec.Body.String())
+		assert.Equal(t, "v1", rec.Header().Get(APIVersionHeader))
+
+		rec = doVersionRequest(handler, "/api/users?page=2", "application/vnd.myapp.v2+json; charset=utf-8")
+		assert.Equal(t, "/api/v2/users v2", rec.Body.String())
+		assert.Equal(t, "v2", rec.Header().Get(APIVersionHeader))
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+	})
+
+	// 多个媒体类型时使用第一个支持的版本，忽略其他厂商的媒体类型
+	t.Run("MultipleMediaTypes", func(t *testing.T) {
+		rec := doVersionRequest(handler, "/api/users", "application/vnd.other.v1+json, application/vnd.myapp.v9+json, APPLICATION/VND.MYAPP.V2+JSON;q=0.9")
+		assert.Equal(t, "/api/v2/users v2", rec.Body.String())
+	})
+
+	// 未指定版本时使用默认版本
+	t.Run("DefaultVersion", func(t *testing.T) {
+		for _, accept := range []string{"", "application/json", "*/*", "application/vnd.myapp+json"} {
+			rec := doVersionRequest(handler, "/api/users", accept)
+			assert.Equal(t, "/api/v1/users v1", rec.Body.String(), accept)
+		}
+
+		// 自定义默认版本
+		config := DefaultAPIVersionConfig
+		config.Default = "v2"
+		r := chi.NewRouter()
+		r.Use(APIVersion(&config))
+		r.Get("/api/v2/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(GetAPIVersion(r.Context())))
+		})
+		assert.Equal(t, "v2", doVersionRequest(r, "/api/users", "").Body.String())
+	})
+
+	// 路径中带版本时以路径为准，不改写
+	t.Run("PathVersion", func(t *testing.T) {
+		rec := doVersionRequest(handler, "/api/v1/users", "application/vnd.myapp.v2+json")
+		assert.Equal(t, "/api/v1/users v1", rec.Body.String())
+		assert.Equal(t, "v1", rec.Header().Get(APIVersionHeader))
+		assert.Empty(t, rec.Header().Get("Vary"))
+
+		// 未注册的版本路径不改写，由路由返回404
+		rec = doVersionRequest(handler, "/api/v3/users", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	// 选择的版本没有对应路由时回退到默认版本
+	t.Run("FallbackToDefault", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+		req.Header.Set("Accept", "application/vnd.myapp.v2+json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "/api/v1/auth/login v1", rec.Body.String())
+		assert.Equal(t, "v1", rec.Header().Get(APIVersionHeader))
+
+		// v2 有对应路由时不回退
+		rec = doVersionRequest(handler, "/api/users", "application/vnd.myapp.v2+json")
+		assert.Equal(t, "/api/v2/users v2", rec.Body.String())
+
+		// 默认版本也没有对应路由时由路由返回404
+		rec = doVersionRequest(handler, "/api/missing", "application/vnd.myapp.v2+json")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	// 请求的版本都不支持时返回406
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		rec := doVersionRequest(handler, "/api/users", "application/vnd.myapp.v9+json")
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "API_VERSION_UNSUPPORTED")
+	})
+
+	// 版本化前缀之外的路径不受影响
+	t.Run("OutsidePrefix", func(t *testing.T) {
+		rec := doVersionRequest(handler, "/health", "application/vnd.myapp.v2+json")
+		assert.Equal(t, "ok ", rec.Body.String())
+		assert.Empty(t, rec.Header().Get(APIVersionHeader))
+	})
+}
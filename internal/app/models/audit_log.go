@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 审计实体类型
+const (
+	AuditEntityUser = "user"
+)
+
+// 审计动作
+const (
+	AuditActionUserCreate  = "user.create"
+	AuditActionUserUpdate  = "user.update"
+	AuditActionUserDelete  = "user.delete"
+	AuditActionUserRestore = "user.restore"
+	AuditActionUserVerify  = "user.verify_email"
+)
+
+// AuditLog 审计日志，记录一次数据变更的操作者、动作和字段变化，只追加不修改
+type AuditLog struct {
+	ID         uint         `gorm:"primarykey" json:"id"`
+	ActorID    *uint        `gorm:"index" json:"actor_id"` // 操作者用户ID，无认证上下文时为空
+	Action     string       `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType string       `gorm:"type:varchar(50);not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID   uint         `gorm:"not null;index:idx_audit_logs_entity" json:"entity_id"`
+	Changes    AuditChanges `gorm:"type:jsonb;not null" json:"changes"`
+	RequestID  string       `gorm:"type:varchar(64)" json:"request_id"`
+	CreatedAt  time.Time    `gorm:"index" json:"created_at"`
+}
+
+// AuditChange 单个字段变更前后的值，创建时没有 Before，删除时没有 After
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditChanges 按字段名记录的变更集合，以JSON存储
+type AuditChanges map[string]AuditChange
+
+// Value 实现 driver.Valuer，空集合存储为 {}
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现 sql.Scanner
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("不支持的审计变更类型: %T", value)
+	}
+	return json.Unmarshal(data, c)
+}
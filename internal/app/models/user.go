@@ -5,8 +5,16 @@ import "gorm.io/gorm"
 // User 用户模型
 type User struct {
 	gorm.Model
-	Name     string `gorm:"type:varchar(100);not null" json:"name"`
-	Email    string `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
-	Password string `gorm:"type:varchar(100);not null" json:"-"`
-	Role     string `gorm:"type:varchar(20);default:'user'" json:"role"`
+	Name      string `gorm:"type:varchar(100);not null" json:"name"`
+	Email     string `gorm:"type:varchar(100);uniqueIndex:idx_users_email,where:deleted_at IS NULL;not null" json:"email"` // 只在未删除的用户中唯一
+	Password  string `gorm:"type:varchar(255);not null" json:"-"`
+	Role      string `gorm:"type:varchar(20);default:'user'" json:"role"`
+	AvatarURL string `gorm:"type:varchar(512);not null;default:''" json:"avatar_url"` // 头像公开访问地址，未上传时为空
+	Version   uint   `gorm:"not null;default:1" json:"version"`                       // 乐观锁版本号，每次更新加一
+	Verified  bool   `gorm:"not null;default:false" json:"verified"`                  // 邮箱是否已验证
+}
+
+// CacheVersion 实现 cache.Versioned，缓存按版本号条件写入，旧版本不覆盖新版本
+func (u User) CacheVersion() int64 {
+	return int64(u.Version)
 }
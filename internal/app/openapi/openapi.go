@@ -0,0 +1,179 @@
+// Package openapi 生成 OpenAPI 3.0 接口文档
+// 请求和响应的Schema通过反射从DTO生成，路由、参数和错误响应在此集中声明；
+// 新增或修改接口时需同步更新 addPaths
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	v2handlers "github.com/vadxq/go-rest-starter/internal/app/handlers/v2"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+// Version 文档描述的API版本
+const Version = "1.0.0"
+
+// schemaTypes 注册到组件中的DTO，键为组件名称
+var schemaTypes = map[string]interface{}{
+	"Response":                handlers.Response{},
+	"ErrorInfo":               handlers.ErrorInfo{},
+	"ListResponse":            dto.ListResponse{},
+	"UserResponse":            dto.UserResponse{},
+	"CreateUserInput":         dto.CreateUserInput{},
+	"UpdateUserInput":         dto.UpdateUserInput{},
+	"PatchUserInput":          dto.PatchUserInput{},
+	"BulkCreateUsersResponse": dto.BulkCreateUsersResponse{},
+	"LoginRequest":            dto.LoginRequest{},
+	"LoginResponse":           dto.LoginResponse{},
+	"RefreshTokenRequest":     dto.RefreshTokenRequest{},
+	"TokenResponse":           dto.TokenResponse{},
+	"IntrospectRequest":       dto.IntrospectRequest{},
+	"IntrospectResponse":      dto.IntrospectResponse{},
+	"SessionResponse":         dto.SessionResponse{},
+	"ForgotPasswordRequest":   dto.ForgotPasswordRequest{},
+	"ResetPasswordRequest":    dto.ResetPasswordRequest{},
+	"AuditLogResponse":        dto.AuditLogResponse{},
+	"LogLevelRequest":         dto.LogLevelRequest{},
+	"LogLevelResponse":        dto.LogLevelResponse{},
+	"HealthStatus":            handlers.HealthStatus{},
+	"JWKS":                    jwtpkg.JWKS{},
+	"UserListResponseV2":      v2handlers.UserListResponse{},
+}
+
+// errorResponses 错误响应组件，键为HTTP状态码
+var errorResponses = map[int]string{
+	http.StatusBadRequest:          "BadRequest",
+	http.StatusUnauthorized:        "Unauthorized",
+	http.StatusForbidden:           "Forbidden",
+	http.StatusNotFound:            "NotFound",
+	http.StatusNotAcceptable:       "NotAcceptable",
+	http.StatusConflict:            "Conflict",
+	http.StatusTooManyRequests:     "TooManyRequests",
+	http.StatusInternalServerError: "InternalError",
+	http.StatusServiceUnavailable:  "ServiceUnavailable",
+}
+
+// Build 生成 OpenAPI 3.0 文档
+func Build() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "Go-Rest-Starter API",
+			Description: "Go-Rest-Starter(https://github.com/vadxq/go-rest-starter) RESTful API服务，基于Go Chi、GORM、PostgreSQL和Redis",
+			Version:     Version,
+		},
+		Servers: openapi3.Servers{{URL: "/"}},
+		Paths:   openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"BearerAuth": &openapi3.SecuritySchemeRef{
+					Value: openapi3.NewJWTSecurityScheme().WithDescription("输入格式: Bearer {token}"),
+				},
+			},
+			Parameters: parameters(),
+			Responses:  openapi3.ResponseBodies{},
+		},
+		// 接口默认需要认证，公开接口单独声明
+		Security: openapi3.SecurityRequirements{{"BearerAuth": []string{}}},
+	}
+
+	g := newSchemaGenerator()
+	for name, value := range schemaTypes {
+		if _, err := g.register(name, value); err != nil {
+			return nil, err
+		}
+	}
+	// 错误响应的 data 为错误信息
+	g.schemas["ErrorResponse"] = envelope(schemaRef("ErrorInfo"))
+	doc.Components.Schemas = g.schemas
+
+	for status, name := range errorResponses {
+		doc.Components.Responses[name] = &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().
+				WithDescription(http.StatusText(status)).
+				WithJSONSchemaRef(schemaRef("ErrorResponse")),
+		}
+	}
+
+	addPaths(doc)
+	return doc, nil
+}
+
+// Handler 返回提供 OpenAPI 文档的处理器，文档在创建时生成一次
+func Handler() (http.HandlerFunc, error) {
+	doc, err := Build()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("序列化OpenAPI文档失败: %w", err)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}, nil
+}
+
+// parameters 可复用的请求参数
+func parameters() openapi3.ParametersMap {
+	params := map[string]*openapi3.Parameter{
+		"Page":     openapi3.NewQueryParameter("page").WithDescription("页码，默认为1").WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithDefault(1)),
+		"PageSize": openapi3.NewQueryParameter("page_size").WithDescription("每页大小，默认为10").WithSchema(openapi3.NewIntegerSchema().WithMin(1).WithDefault(10)),
+		"Search":   openapi3.NewQueryParameter("search").WithDescription("按姓名或邮箱模糊搜索").WithSchema(openapi3.NewStringSchema()),
+		"Role":     openapi3.NewQueryParameter("role").WithDescription("按角色筛选").WithSchema(openapi3.NewStringSchema()),
+		"Sort": openapi3.NewQueryParameter("sort").
+			WithDescription("排序字段（id、name、email、role、created_at、updated_at），前缀-表示降序，多个字段用逗号分隔").
+			WithSchema(openapi3.NewStringSchema()),
+		"UserID":            openapi3.NewPathParameter("id").WithDescription("用户ID").WithSchema(openapi3.NewStringSchema()),
+		"SessionID":         openapi3.NewPathParameter("id").WithDescription("会话ID").WithSchema(openapi3.NewStringSchema()),
+		"IfNoneMatch":       openapi3.NewHeaderParameter("If-None-Match").WithDescription("上次响应的ETag").WithSchema(openapi3.NewStringSchema()),
+		"IfMatch":           openapi3.NewHeaderParameter("If-Match").WithDescription("读取时响应的ETag（即版本号），请求体未携带version时使用").WithSchema(openapi3.NewStringSchema()),
+		"IdempotencyKey":    openapi3.NewHeaderParameter("Idempotency-Key").WithDescription("幂等键，相同键的重试返回首次请求的响应").WithSchema(openapi3.NewStringSchema()),
+		"VerificationToken": openapi3.NewQueryParameter("token").WithDescription("验证邮件中的令牌").WithRequired(true).WithSchema(openapi3.NewStringSchema()),
+		"AcceptLanguage":    openapi3.NewHeaderParameter("Accept-Language").WithDescription("错误信息的语言（zh、en）").WithSchema(openapi3.NewStringSchema()),
+	}
+
+	m := openapi3.ParametersMap{}
+	for name, param := range params {
+		m[name] = &openapi3.ParameterRef{Value: param}
+	}
+	return m
+}
+
+// envelope 标准响应结构，data 为指定的Schema
+func envelope(data *openapi3.SchemaRef) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("", &openapi3.Schema{
+		AllOf: openapi3.SchemaRefs{
+			schemaRef("Response"),
+			objectWith("data", data),
+		},
+	})
+}
+
+// listOf 分页列表响应，data 为指定元素的数组
+func listOf(item *openapi3.SchemaRef) *openapi3.SchemaRef {
+	items := openapi3.NewArraySchema()
+	items.Items = item
+	return openapi3.NewSchemaRef("", &openapi3.Schema{
+		AllOf: openapi3.SchemaRefs{
+			schemaRef("ListResponse"),
+			objectWith("data", openapi3.NewSchemaRef("", items)),
+		},
+	})
+}
+
+// objectWith 只声明一个属性的对象Schema
+func objectWith(name string, property *openapi3.SchemaRef) *openapi3.SchemaRef {
+	schema := openapi3.NewObjectSchema()
+	schema.Properties = openapi3.Schemas{name: property}
+	return openapi3.NewSchemaRef("", schema)
+}
@@ -0,0 +1,89 @@
+package openapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadServedDocument 请求文档处理器并解析返回的文档
+func loadServedDocument(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	handler, err := Handler()
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	doc, err := openapi3.NewLoader().LoadFromData(rec.Body.Bytes())
+	require.NoError(t, err)
+	return doc
+}
+
+func TestHandler(t *testing.T) {
+	doc := loadServedDocument(t)
+
+	// 返回的文档是有效的 OpenAPI 3 文档，引用都能解析
+	t.Run("Validates", func(t *testing.T) {
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+		require.NoError(t, doc.Validate(context.Background()))
+	})
+
+	// 默认需要 Bearer 认证，登录接口为公开接口
+	t.Run("Security", func(t *testing.T) {
+		scheme := doc.Components.SecuritySchemes["BearerAuth"].Value
+		assert.Equal(t, "http", scheme.Type)
+		assert.Equal(t, "bearer", scheme.Scheme)
+		assert.Equal(t, "JWT", scheme.BearerFormat)
+
+		login := doc.Paths.Find("/api/v1/auth/login").Post
+		require.NotNil(t, login.Security)
+		assert.Empty(t, *login.Security)
+		assert.Nil(t, doc.Paths.Find("/api/v1/users").Get.Security)
+	})
+
+	// 请求体Schema按 validate 标签设置必填字段和约束
+	t.Run("SchemasFromDTOs", func(t *testing.T) {
+		input := doc.Components.Schemas["CreateUserInput"].Value
+		assert.ElementsMatch(t, []string{"name", "email", "password"}, input.Required)
+		assert.Equal(t, "email", input.Properties["email"].Value.Format)
+		assert.Equal(t, uint64(2), input.Properties["name"].Value.MinLength)
+		assert.Equal(t, uint64(100), *input.Properties["name"].Value.MaxLength)
+		assert.NotContains(t, input.Properties, "Role")
+
+		// 部分更新的字段都可省略
+		assert.Empty(t, doc.Components.Schemas["PatchUserInput"].Value.Required)
+		assert.Equal(t, "date-time", doc.Components.Schemas["UserResponse"].Value.Properties["created_at"].Value.Format)
+	})
+
+	// 分页参数和错误响应
+	t.Run("PaginationAndErrors", func(t *testing.T) {
+		list := doc.Paths.Find("/api/v1/users").Get
+		var names []string
+		for _, param := range list.Parameters {
+			names = append(names, param.Value.Name)
+		}
+		assert.Subset(t, names, []string{"page", "page_size", "search", "role", "sort"})
+		assert.Equal(t, float64(10), doc.Components.Parameters["PageSize"].Value.Schema.Value.Default)
+
+		page := list.Responses.Status(http.StatusOK).Value.Content.Get("application/json").Schema.Value
+		require.Len(t, page.AllOf, 2)
+
+		errorInfo := doc.Components.Schemas["ErrorInfo"].Value
+		assert.Contains(t, errorInfo.Properties, "type")
+		assert.Contains(t, errorInfo.Properties, "code")
+		assert.Contains(t, errorInfo.Properties, "fields")
+
+		conflict := doc.Paths.Find("/api/v1/users/{id}").Put.Responses.Status(http.StatusConflict)
+		require.NotNil(t, conflict)
+		assert.Equal(t, "#/components/responses/Conflict", conflict.Ref)
+	})
+}
@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// endpoint 接口声明
+type endpoint struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	tag         string
+	public      bool                  // 无需认证
+	params      []string              // 引用的参数组件
+	body        *openapi3.RequestBody // 请求体，为空时没有请求体
+	status      int                   // 成功时的状态码
+	response    *openapi3.SchemaRef   // 成功时的响应体，为空时没有响应体
+	errors      []int                 // 可能返回的错误状态码
+	conditional bool                  // 支持 If-None-Match 条件请求，命中时返回304
+}
+
+// jsonBody JSON请求体
+func jsonBody(schema *openapi3.SchemaRef) *openapi3.RequestBody {
+	return openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(schema)
+}
+
+// endpoints 文档中的接口，与 router 中注册的 REST 接口对应
+// SSE、WebSocket 和 GraphQL 接口不是JSON请求响应模式，不在此声明
+func endpoints() []endpoint {
+	userList := envelope(listOf(schemaRef("UserResponse")))
+	user := envelope(schemaRef("UserResponse"))
+	avatarForm := openapi3.NewObjectSchema().WithProperty("avatar", openapi3.NewStringSchema().WithFormat("binary"))
+	avatarForm.Required = []string{"avatar"}
+	bulkBody := openapi3.NewArraySchema()
+	bulkBody.Items = schemaRef("CreateUserInput")
+	sessions := openapi3.NewArraySchema()
+	sessions.Items = schemaRef("SessionResponse")
+
+	return []endpoint{
+		// 健康检查和公钥
+		{method: http.MethodGet, path: "/health", operationID: "health", summary: "基础健康检查", tag: "health", public: true,
+			status: http.StatusOK, response: schemaRef("HealthStatus")},
+		{method: http.MethodGet, path: "/health/detailed", operationID: "detailedHealth", summary: "详细健康检查", tag: "health", public: true,
+			status: http.StatusOK, response: schemaRef("HealthStatus")},
+		{method: http.MethodGet, path: "/.well-known/jwks.json", operationID: "jwks", summary: "JWT公钥集合", tag: "auth", public: true,
+			status: http.StatusOK, response: schemaRef("JWKS")},
+
+		// 认证
+		{method: http.MethodPost, path: "/api/v1/auth/login", operationID: "login", summary: "用户登录", tag: "auth", public: true,
+			params: []string{"AcceptLanguage"}, body: jsonBody(schemaRef("LoginRequest")),
+			status: http.StatusOK, response: envelope(schemaRef("LoginResponse")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized}},
+		{method: http.MethodPost, path: "/api/v1/auth/refresh", operationID: "refreshToken", summary: "刷新令牌", tag: "auth", public: true,
+			params: []string{"AcceptLanguage"}, body: jsonBody(schemaRef("RefreshTokenRequest")),
+			status: http.StatusOK, response: envelope(schemaRef("TokenResponse")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized}},
+		{method: http.MethodPost, path: "/api/v1/auth/forgot-password", operationID: "forgotPassword", summary: "忘记密码", tag: "auth", public: true,
+			params: []string{"AcceptLanguage"}, body: jsonBody(schemaRef("ForgotPasswordRequest")),
+			status: http.StatusOK, response: schemaRef("Response"),
+			errors: []int{http.StatusBadRequest, http.StatusServiceUnavailable}},
+		{method: http.MethodPost, path: "/api/v1/auth/reset-password", operationID: "resetPassword", summary: "重置密码", tag: "auth", public: true,
+			params: []string{"AcceptLanguage"}, body: jsonBody(schemaRef("ResetPasswordRequest")),
+			status: http.StatusOK, response: schemaRef("Response"),
+			errors: []int{http.StatusBadRequest}},
+		{method: http.MethodGet, path: "/api/v1/auth/verify-email", operationID: "verifyEmail", summary: "验证邮箱", tag: "auth", public: true,
+			params: []string{"VerificationToken", "AcceptLanguage"},
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{method: http.MethodPost, path: "/api/v1/auth/introspect", operationID: "introspectToken", summary: "令牌内省（需要 auth:introspect 权限范围）", tag: "auth",
+			body:   jsonBody(schemaRef("IntrospectRequest")),
+			status: http.StatusOK, response: envelope(schemaRef("IntrospectResponse")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}},
+		{method: http.MethodPost, path: "/api/v1/account/logout", operationID: "logout", summary: "用户登出", tag: "auth",
+			status: http.StatusNoContent, errors: []int{http.StatusUnauthorized}},
+		{method: http.MethodGet, path: "/api/v1/account/sessions", operationID: "listSessions", summary: "获取登录会话列表", tag: "auth",
+			status: http.StatusOK, response: envelope(openapi3.NewSchemaRef("", sessions)),
+			errors: []int{http.StatusUnauthorized}},
+		{method: http.MethodDelete, path: "/api/v1/account/sessions/{id}", operationID: "revokeSession", summary: "撤销登录会话", tag: "auth",
+			params: []string{"SessionID"},
+			status: http.StatusNoContent, errors: []int{http.StatusUnauthorized, http.StatusNotFound}},
+
+		// 用户
+		{method: http.MethodGet, path: "/api/v1/users", operationID: "listUsers", summary: "获取用户列表", tag: "users",
+			params: []string{"Page", "PageSize", "Search", "Role", "Sort"},
+			status: http.StatusOK, response: userList,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized}},
+		{method: http.MethodPost, path: "/api/v1/users", operationID: "createUser", summary: "创建用户（仅管理员）", tag: "users",
+			params: []string{"IdempotencyKey", "AcceptLanguage"}, body: jsonBody(schemaRef("CreateUserInput")),
+			status: http.StatusCreated, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusConflict}},
+		{method: http.MethodPost, path: "/api/v1/users/bulk", operationID: "createUsersBulk", summary: "批量创建用户（仅管理员）", tag: "users",
+			params: []string{"IdempotencyKey", "AcceptLanguage"}, body: jsonBody(openapi3.NewSchemaRef("", bulkBody)),
+			status: http.StatusOK, response: envelope(schemaRef("BulkCreateUsersResponse")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}},
+		{method: http.MethodGet, path: "/api/v1/users/{id}", operationID: "getUser", summary: "获取用户详情", tag: "users",
+			params: []string{"UserID", "IfNoneMatch"}, conditional: true,
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound}},
+		{method: http.MethodPut, path: "/api/v1/users/{id}", operationID: "updateUser", summary: "更新用户", tag: "users",
+			params: []string{"UserID", "IfMatch", "AcceptLanguage"}, body: jsonBody(schemaRef("UpdateUserInput")),
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodPatch, path: "/api/v1/users/{id}", operationID: "patchUser", summary: "部分更新用户", tag: "users",
+			params: []string{"UserID", "IfMatch", "AcceptLanguage"}, body: jsonBody(schemaRef("PatchUserInput")),
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodDelete, path: "/api/v1/users/{id}", operationID: "deleteUser", summary: "删除用户", tag: "users",
+			params: []string{"UserID"},
+			status: http.StatusNoContent,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound}},
+		{method: http.MethodPost, path: "/api/v1/users/{id}/restore", operationID: "restoreUser", summary: "恢复已删除用户（仅管理员）", tag: "users",
+			params: []string{"UserID"},
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodPost, path: "/api/v1/users/{id}/avatar", operationID: "uploadAvatar", summary: "上传头像", tag: "users",
+			params: []string{"UserID"}, body: openapi3.NewRequestBody().WithRequired(true).WithFormDataSchema(avatarForm),
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}},
+
+		// 审计日志
+		{method: http.MethodGet, path: "/api/v1/audit", operationID: "listAuditLogs", summary: "获取审计日志列表（仅管理员）", tag: "audit",
+			params: []string{"Page", "PageSize"},
+			status: http.StatusOK, response: envelope(listOf(schemaRef("AuditLogResponse"))),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}},
+
+		// 运维管理
+		{method: http.MethodPut, path: "/api/v1/admin/log-level", operationID: "setLogLevel", summary: "调整日志级别（仅管理员）", tag: "admin",
+			body:   jsonBody(schemaRef("LogLevelRequest")),
+			status: http.StatusOK, response: envelope(schemaRef("LogLevelResponse")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}},
+
+		// API v2
+		{method: http.MethodGet, path: "/api/v2/users", operationID: "listUsersV2", summary: "获取用户列表（v2）", tag: "users-v2",
+			params: []string{"Page", "PageSize", "Search", "Role", "Sort"},
+			status: http.StatusOK, response: envelope(schemaRef("UserListResponseV2")),
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized}},
+		{method: http.MethodGet, path: "/api/v2/users/{id}", operationID: "getUserV2", summary: "获取用户详情（v2）", tag: "users-v2",
+			params: []string{"UserID"},
+			status: http.StatusOK, response: user,
+			errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound}},
+	}
+}
+
+// addPaths 将接口声明转换为文档路径
+func addPaths(doc *openapi3.T) {
+	for _, e := range endpoints() {
+		op := openapi3.NewOperation()
+		op.OperationID = e.operationID
+		op.Summary = e.summary
+		op.Tags = []string{e.tag}
+		if e.public {
+			op.Security = openapi3.NewSecurityRequirements()
+		}
+		for _, name := range e.params {
+			op.Parameters = append(op.Parameters, &openapi3.ParameterRef{Ref: "#/components/parameters/" + name})
+		}
+		if e.body != nil {
+			op.RequestBody = &openapi3.RequestBodyRef{Value: e.body}
+		}
+
+		op.Responses = openapi3.NewResponsesWithCapacity(0)
+		success := openapi3.NewResponse().WithDescription(http.StatusText(e.status))
+		if e.response != nil {
+			success = success.WithJSONSchemaRef(e.response)
+		}
+		op.Responses.Set(strconv.Itoa(e.status), &openapi3.ResponseRef{Value: success})
+		if e.conditional {
+			op.Responses.Set(strconv.Itoa(http.StatusNotModified), &openapi3.ResponseRef{
+				Value: openapi3.NewResponse().WithDescription("资源未修改"),
+			})
+		}
+
+		// 所有接口都可能被限流或发生内部错误
+		errs := append([]int{http.StatusTooManyRequests, http.StatusInternalServerError}, e.errors...)
+		for _, status := range errs {
+			op.Responses.Set(strconv.Itoa(status), &openapi3.ResponseRef{Ref: "#/components/responses/" + errorResponses[status]})
+		}
+
+		doc.AddOperation(e.path, e.method, op)
+	}
+}
@@ -0,0 +1,112 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// schemaGenerator 通过反射从Go类型生成JSON Schema，并按 validate 标签补充必填和长度等约束，
+// 使文档与请求校验规则保持一致
+type schemaGenerator struct {
+	schemas openapi3.Schemas
+}
+
+// newSchemaGenerator 创建Schema生成器
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{schemas: openapi3.Schemas{}}
+}
+
+// register 生成类型的Schema并以name注册到组件中，返回对该组件的引用
+func (g *schemaGenerator) register(name string, value interface{}) (*openapi3.SchemaRef, error) {
+	ref, err := openapi3gen.NewSchemaRefForValue(value, nil, openapi3gen.SchemaCustomizer(applyValidateTags))
+	if err != nil {
+		return nil, fmt.Errorf("生成 %s 的Schema失败: %w", name, err)
+	}
+	g.schemas[name] = openapi3.NewSchemaRef("", ref.Value)
+	return schemaRef(name), nil
+}
+
+// schemaRef 引用组件中的Schema
+func schemaRef(name string) *openapi3.SchemaRef {
+	return openapi3.NewSchemaRef("#/components/schemas/"+name, nil)
+}
+
+// applyValidateTags 按字段的 validate 标签设置Schema约束，结构体按字段标签设置必填字段
+func applyValidateTags(_ string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) error {
+	if t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			if hasRule(field.Tag.Get("validate"), "required") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+
+	for _, rule := range strings.Split(tag.Get("validate"), ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "email":
+			schema.Format = "email"
+		case "min", "max":
+			n, err := strconv.ParseUint(param, 10, 64)
+			if err != nil {
+				continue
+			}
+			setBound(schema, key == "min", n)
+		}
+	}
+	return nil
+}
+
+// setBound 设置字符串长度或数值范围的上下限
+func setBound(schema *openapi3.Schema, lower bool, n uint64) {
+	if schema.Type.Is(openapi3.TypeString) {
+		if lower {
+			schema.MinLength = n
+		} else {
+			schema.MaxLength = &n
+		}
+		return
+	}
+	value := float64(n)
+	if lower {
+		schema.Min = &value
+	} else {
+		schema.Max = &value
+	}
+}
+
+// hasRule validate 标签中是否包含指定规则
+func hasRule(validate, rule string) bool {
+	for _, r := range strings.Split(validate, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName 字段序列化后的名称，不参与序列化的字段返回空字符串
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
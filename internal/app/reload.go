@@ -0,0 +1,100 @@
+package app
+
+import (
+	"database/sql"
+	"log/slog"
+	"sync"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+)
+
+// restartRequiredFields 运行时无法调整的配置，变更后需重启才能生效
+var restartRequiredFields = []struct {
+	name  string
+	value func(*config.AppConfig) any
+}{
+	{"server.port", func(c *config.AppConfig) any { return c.Server.Port }},
+	{"server.read_timeout", func(c *config.AppConfig) any { return c.Server.ReadTimeout }},
+	{"server.write_timeout", func(c *config.AppConfig) any { return c.Server.WriteTimeout }},
+	{"database.driver", func(c *config.AppConfig) any { return c.Database.Driver }},
+	{"database.host", func(c *config.AppConfig) any { return c.Database.Host }},
+	{"database.port", func(c *config.AppConfig) any { return c.Database.Port }},
+	{"database.username", func(c *config.AppConfig) any { return c.Database.Username }},
+	{"database.password", func(c *config.AppConfig) any { return c.Database.Password }},
+	{"database.dbname", func(c *config.AppConfig) any { return c.Database.DBName }},
+	{"redis.host", func(c *config.AppConfig) any { return c.Redis.Host }},
+	{"redis.port", func(c *config.AppConfig) any { return c.Redis.Port }},
+	{"redis.password", func(c *config.AppConfig) any { return c.Redis.Password }},
+	{"redis.db", func(c *config.AppConfig) any { return c.Redis.DB }},
+	{"jwt.secret", func(c *config.AppConfig) any { return c.JWT.Secret }},
+	{"grpc.enabled", func(c *config.AppConfig) any { return c.GRPC.Enabled }},
+	{"grpc.port", func(c *config.AppConfig) any { return c.GRPC.Port }},
+}
+
+// configReloader 将配置文件的变更应用到运行中的组件
+// 运行时生效的配置：日志级别、主库连接池大小和功能开关；
+// 需重启才能生效的配置（restartRequiredFields）变更时记录警告并忽略，仍使用启动时的值
+type configReloader struct {
+	mu      sync.Mutex
+	started *config.AppConfig // 启动时的配置，判断需重启的配置是否变更
+	current *config.AppConfig // 最近一次应用的配置
+
+	level          *slog.LevelVar          // 日志级别，为空时不调整
+	db             *sql.DB                 // 主库连接，为空时不调整连接池
+	onFeatureFlags func(*config.AppConfig) // 重新加载功能开关，为空时不重新加载
+}
+
+// newConfigReloader 创建配置重载器，cfg为启动时的配置
+func newConfigReloader(cfg *config.AppConfig, level *slog.LevelVar, db *sql.DB, onFeatureFlags func(*config.AppConfig)) *configReloader {
+	return &configReloader{
+		started:        cfg,
+		current:        cfg,
+		level:          level,
+		db:             db,
+		onFeatureFlags: onFeatureFlags,
+	}
+}
+
+// Apply 应用新配置，注册为 config.ConfigWatcher 的变更回调，可并发调用
+func (r *configReloader) Apply(cfg *config.AppConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, field := range restartRequiredFields {
+		if old, updated := field.value(r.started), field.value(cfg); old != updated {
+			slog.Warn("配置变更需重启才能生效，已忽略", "key", field.name)
+		}
+	}
+
+	if r.level != nil && cfg.Log.Level != r.current.Log.Level {
+		setLogLevel(cfg.Log.Level, r.level)
+	}
+
+	if r.db != nil {
+		r.applyPool(&cfg.Database)
+	}
+
+	if r.onFeatureFlags != nil {
+		r.onFeatureFlags(cfg)
+	}
+
+	r.current = cfg
+}
+
+// applyPool 调整主库连接池，只读副本的连接池需重启才能调整
+func (r *configReloader) applyPool(cfg *config.DatabaseConfig) {
+	old := &r.current.Database
+	if cfg.MaxOpenConns != old.MaxOpenConns {
+		r.db.SetMaxOpenConns(cfg.MaxOpenConns)
+		slog.Info("数据库最大连接数已调整", "old", old.MaxOpenConns, "new", cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns != old.MaxIdleConns {
+		r.db.SetMaxIdleConns(cfg.MaxIdleConns)
+		slog.Info("数据库最大空闲连接数已调整", "old", old.MaxIdleConns, "new", cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime != old.ConnMaxLifetime {
+		r.db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		r.db.SetConnMaxIdleTime(cfg.ConnMaxLifetime / 2)
+		slog.Info("数据库连接最大存活时间已调整", "old", old.ConnMaxLifetime, "new", cfg.ConnMaxLifetime)
+	}
+}
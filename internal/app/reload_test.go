@@ -0,0 +1,78 @@
+package app
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+)
+
+func newReloadConfig() *config.AppConfig {
+	cfg := &config.AppConfig{}
+	cfg.Server.Port = 8080
+	cfg.Log.Level = "info"
+	cfg.Database.MaxOpenConns = 10
+	cfg.Database.MaxIdleConns = 5
+	return cfg
+}
+
+func TestConfigReloader_LogLevel(t *testing.T) {
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelInfo)
+	reloader := newConfigReloader(newReloadConfig(), level, nil, nil)
+
+	cfg := newReloadConfig()
+	cfg.Log.Level = "debug"
+	reloader.Apply(cfg)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+
+	cfg = newReloadConfig()
+	cfg.Log.Level = "error"
+	reloader.Apply(cfg)
+	assert.Equal(t, slog.LevelError, level.Level())
+
+	// 未变更的日志级别不覆盖运行时的设置
+	level.Set(slog.LevelWarn)
+	reloader.Apply(cfg)
+	assert.Equal(t, slog.LevelWarn, level.Level())
+}
+
+func TestConfigReloader_DatabasePool(t *testing.T) {
+	sqlDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(10)
+
+	reloader := newConfigReloader(newReloadConfig(), nil, sqlDB, nil)
+
+	cfg := newReloadConfig()
+	cfg.Database.MaxOpenConns = 25
+	cfg.Database.MaxIdleConns = 8
+	reloader.Apply(cfg)
+	assert.Equal(t, 25, sqlDB.Stats().MaxOpenConnections)
+}
+
+func TestConfigReloader_RestartRequired(t *testing.T) {
+	level := new(slog.LevelVar)
+	var reloaded []*config.AppConfig
+	started := newReloadConfig()
+	reloader := newConfigReloader(started, level, nil, func(cfg *config.AppConfig) {
+		reloaded = append(reloaded, cfg)
+	})
+
+	// 服务端口变更被忽略，同一次变更中的其他配置仍然生效
+	cfg := newReloadConfig()
+	cfg.Server.Port = 9000
+	cfg.Log.Level = "debug"
+	reloader.Apply(cfg)
+
+	assert.Equal(t, slog.LevelDebug, level.Level())
+	assert.Equal(t, 8080, reloader.started.Server.Port)
+	assert.Equal(t, 8080, started.Server.Port)
+	require.Len(t, reloaded, 1)
+	assert.Same(t, cfg, reloaded[0])
+}
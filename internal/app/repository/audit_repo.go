@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// AuditLogRepository 定义了审计日志仓库接口
+type AuditLogRepository interface {
+	Create(ctx context.Context, tx *gorm.DB, log *models.AuditLog) error
+	List(ctx context.Context, page, pageSize int, filter dto.AuditLogFilter) ([]*models.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	*BaseRepository[models.AuditLog]
+}
+
+// NewAuditLogRepository 创建一个新的 AuditLogRepository 实例，log为空时使用 slog 默认处理器
+func NewAuditLogRepository(db *gorm.DB, log logger.Logger) AuditLogRepository {
+	return &auditLogRepository{
+		BaseRepository: NewBaseRepository[models.AuditLog](db, "审计日志", log),
+	}
+}
+
+// auditLogOrder 审计日志按写入顺序倒序返回，最新的记录在前
+var auditLogOrder = clause.OrderBy{Columns: []clause.OrderByColumn{{
+	Column: clause.Column{Name: "id"},
+	Desc:   true,
+}}}
+
+// List 按筛选条件分页获取审计日志
+func (r *auditLogRepository) List(ctx context.Context, page, pageSize int, filter dto.AuditLogFilter) ([]*models.AuditLog, int64, error) {
+	var scopes []func(*gorm.DB) *gorm.DB
+	if filter.ActorID != nil {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("actor_id = ?", *filter.ActorID) })
+	}
+	if filter.Action != "" {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("action = ?", filter.Action) })
+	}
+	if filter.EntityType != "" {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("entity_type = ?", filter.EntityType) })
+	}
+	if filter.EntityID != nil {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("entity_id = ?", *filter.EntityID) })
+	}
+	if filter.Since != nil {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("created_at >= ?", *filter.Since) })
+	}
+	if filter.Until != nil {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("created_at < ?", *filter.Until) })
+	}
+
+	return r.BaseRepository.ListSorted(ctx, page, pageSize, auditLogOrder, scopes...)
+}
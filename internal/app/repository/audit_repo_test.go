@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+)
+
+// newTestAuditLogRepository 创建使用sqlmock的审计日志仓库
+func newTestAuditLogRepository(t *testing.T) (AuditLogRepository, *gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	return NewAuditLogRepository(db, nil), db, mock
+}
+
+func TestAuditLogRepository(t *testing.T) {
+	ctx := context.Background()
+
+	// 变更集合以JSON写入
+	t.Run("Create", func(t *testing.T) {
+		repo, db, mock := newTestAuditLogRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "audit_logs"`).
+			WithArgs(nil, models.AuditActionUserDelete, models.AuditEntityUser, 1, `{"email":{"before":"a@example.com"}}`, "req-1", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectCommit()
+
+		err := repo.Create(ctx, db, &models.AuditLog{
+			Action:     models.AuditActionUserDelete,
+			EntityType: models.AuditEntityUser,
+			EntityID:   1,
+			Changes:    models.AuditChanges{"email": {Before: "a@example.com"}},
+			RequestID:  "req-1",
+			CreatedAt:  time.Now(),
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 按条件筛选，最新的记录在前，排序不影响总数查询
+	t.Run("ListFilter", func(t *testing.T) {
+		repo, _, mock := newTestAuditLogRepository(t)
+		actorID := uint(7)
+		mock.ExpectQuery(`SELECT \* FROM "audit_logs" WHERE actor_id = \$1 AND action = \$2 ORDER BY "id" DESC LIMIT \$3`).
+			WithArgs(7, models.AuditActionUserCreate, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "action", "changes"}).
+				AddRow(2, 7, models.AuditActionUserCreate, `{"name":{"after":"Test User"}}`))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "audit_logs" WHERE actor_id = \$1 AND action = \$2$`).
+			WithArgs(7, models.AuditActionUserCreate).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		logs, total, err := repo.List(ctx, 1, 10, dto.AuditLogFilter{ActorID: &actorID, Action: models.AuditActionUserCreate})
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		assert.Equal(t, "Test User", logs[0].Changes["name"].After)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
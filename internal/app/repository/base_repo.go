@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
+)
+
+// pgUniqueViolation PostgreSQL唯一约束冲突的SQLSTATE
+const pgUniqueViolation = "23505"
+
+// 分页默认值
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// BaseRepository 通用仓库，为模型提供基础的增删改查
+// T 为GORM模型类型，需要软删除能力的模型应嵌入 gorm.Model
+type BaseRepository[T any] struct {
+	db     *gorm.DB
+	name   string        // 实体名称，用于错误信息，如"用户"
+	logger logger.Logger // 记录数据库错误，日志带上请求上下文中的追踪ID
+}
+
+// NewBaseRepository 创建一个新的 BaseRepository 实例，log为空时使用 slog 默认处理器
+func NewBaseRepository[T any](db *gorm.DB, name string, log logger.Logger) *BaseRepository[T] {
+	if log == nil {
+		log = logger.New(nil)
+	}
+	return &BaseRepository[T]{
+		db:     db,
+		name:   name,
+		logger: log,
+	}
+}
+
+// internalError 记录数据库错误并包装为内部错误
+// 唯一约束冲突由上层转换为冲突错误，属于预期情况，不记录；
+// 数据库断路器打开时返回服务不可用错误，断路器打开时已记录过，同样不重复记录
+func (r *BaseRepository[T]) internalError(ctx context.Context, msg string, err error) error {
+	var openErr *apperrors.CircuitOpenError
+	if errors.As(err, &openErr) {
+		return apperrors.ServiceUnavailableError("数据库暂时不可用，请稍后重试", err)
+	}
+	if !isUniqueViolation(err) {
+		r.logger.WithContext(ctx).Error(msg, "entity", r.name, "error", err)
+	}
+	return apperrors.InternalError(msg, err)
+}
+
+// Create 创建实体
+func (r *BaseRepository[T]) Create(ctx context.Context, tx *gorm.DB, entity *T) error {
+	result := tx.WithContext(ctx).Create(entity)
+	if result.Error != nil {
+		return r.internalError(ctx, fmt.Sprintf("创建%s失败", r.name), result.Error)
+	}
+	return nil
+}
+
+// parseID 解析字符串形式的ID，非正整数的ID不可能匹配任何记录，返回NotFound错误
+// ID不能以字符串直接作为GORM的查询条件，非数字的字符串会被当作原始SQL拼接
+func (r *BaseRepository[T]) parseID(id string) (uint64, error) {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || n == 0 {
+		return 0, apperrors.NotFoundError(r.name, err)
+	}
+	return n, nil
+}
+
+// GetByID 根据 ID 获取实体，ID不是正整数时返回NotFound错误
+func (r *BaseRepository[T]) GetByID(ctx context.Context, id string) (*T, error) {
+	n, err := r.parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity T
+	result := reader(ctx, r.db).First(&entity, "id = ?", n)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFoundError(r.name, result.Error)
+		}
+		return nil, r.internalError(ctx, fmt.Sprintf("获取%s失败", r.name), result.Error)
+	}
+	return &entity, nil
+}
+
+// GetByIDForUpdate 在事务中根据 ID 获取实体并加行锁，锁持有到事务结束
+// 始终在主库上执行，用于先读后写的更新以避免并发更新丢失；ID不是正整数时返回NotFound错误
+func (r *BaseRepository[T]) GetByIDForUpdate(ctx context.Context, tx *gorm.DB, id string) (*T, error) {
+	n, err := r.parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity T
+	if err := transaction.LockForUpdate(ctx, tx, &entity, uint(n)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFoundError(r.name, err)
+		}
+		return nil, r.internalError(ctx, fmt.Sprintf("获取%s失败", r.name), err)
+	}
+	return &entity, nil
+}
+
+// Update 更新实体
+func (r *BaseRepository[T]) Update(ctx context.Context, tx *gorm.DB, entity *T) error {
+	result := tx.WithContext(ctx).Save(entity)
+	if result.Error != nil {
+		return r.internalError(ctx, fmt.Sprintf("更新%s失败", r.name), result.Error)
+	}
+	return nil
+}
+
+// Delete 删除实体（模型包含 gorm.DeletedAt 时为软删除）
+func (r *BaseRepository[T]) Delete(ctx context.Context, tx *gorm.DB, id uint) error {
+	result := tx.WithContext(ctx).Delete(new(T), id)
+	if result.Error != nil {
+		return r.internalError(ctx, fmt.Sprintf("删除%s失败", r.name), result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFoundError(r.name, nil)
+	}
+	return nil
+}
+
+// Restore 恢复已软删除的实体
+func (r *BaseRepository[T]) Restore(ctx context.Context, tx *gorm.DB, id uint) error {
+	result := tx.WithContext(ctx).Unscoped().Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return r.internalError(ctx, fmt.Sprintf("恢复%s失败", r.name), result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFoundError("已删除"+r.name, nil)
+	}
+	return nil
+}
+
+// List 分页获取实体列表，scopes 用于追加查询条件
+// page 小于1时取第一页，pageSize 不在 1~100 之间时取10
+func (r *BaseRepository[T]) List(ctx context.Context, page, pageSize int, scopes ...func(*gorm.DB) *gorm.DB) ([]*T, int64, error) {
+	return r.ListSorted(ctx, page, pageSize, clause.OrderBy{}, scopes...)
+}
+
+// ListSorted 按指定顺序分页获取实体列表，排序只作用于列表查询，不作用于总数查询
+func (r *BaseRepository[T]) ListSorted(ctx context.Context, page, pageSize int, order clause.OrderBy, scopes ...func(*gorm.DB) *gorm.DB) ([]*T, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		pageSize = defaultPageSize
+	}
+
+	offset := (page - 1) * pageSize
+
+	query := reader(ctx, r.db).Model(new(T)).Scopes(scopes...)
+
+	list := query.Session(&gorm.Session{})
+	if len(order.Columns) > 0 {
+		list = list.Order(order)
+	}
+
+	var entities []*T
+	result := list.Offset(offset).Limit(pageSize).Find(&entities)
+	if result.Error != nil {
+		return nil, 0, r.internalError(ctx, fmt.Sprintf("获取%s列表失败", r.name), result.Error)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, r.internalError(ctx, fmt.Sprintf("获取%s总数失败", r.name), err)
+	}
+
+	return entities, total, nil
+}
+
+// isUniqueViolation 判断错误是否为唯一约束冲突
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
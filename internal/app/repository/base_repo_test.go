@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+	"github.com/vadxq/go-rest-starter/internal/app/db"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	applogger "github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// article 用于验证通用仓库复用的示例模型
+type article struct {
+	gorm.Model
+	Title string
+}
+
+// newTestArticleRepository 创建使用sqlmock的文章仓库
+func newTestArticleRepository(t *testing.T) (*BaseRepository[article], *gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	return NewBaseRepository[article](db, "文章", nil), db, mock
+}
+
+func assertErrorType(t *testing.T, err error, errType apperrors.ErrorType) {
+	t.Helper()
+
+	require.Error(t, err)
+	appErr, ok := err.(*apperrors.Error)
+	require.True(t, ok)
+	assert.Equal(t, errType, appErr.Type)
+}
+
+func TestBaseRepository(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"id", "title"}
+
+	t.Run("Create", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "articles"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+
+		entity := &article{Title: "hello"}
+		require.NoError(t, repo.Create(ctx, db, entity))
+		assert.Equal(t, uint(7), entity.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		repo, _, mock := newTestArticleRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1 AND "articles"."deleted_at" IS NULL`).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "hello"))
+
+		entity, err := repo.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "hello", entity.Title)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 记录不存在时返回NotFound错误
+	t.Run("GetByIDNotFound", func(t *testing.T) {
+		repo, _, mock := newTestArticleRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetByID(ctx, "404")
+		assertErrorType(t, err, apperrors.ErrorTypeNotFound)
+	})
+
+	// ID不是正整数时不查询数据库，避免被当作原始SQL拼接
+	t.Run("GetByIDInvalid", func(t *testing.T) {
+		repo, _, mock := newTestArticleRepository(t)
+
+		for _, id := range []string{"1 OR 1=1", "abc", "", "0", "-1"} {
+			_, err := repo.GetByID(ctx, id)
+			assertErrorType(t, err, apperrors.ErrorTypeNotFound)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 加锁查询在事务内使用 FOR UPDATE
+	t.Run("GetByIDForUpdate", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "articles" WHERE id = \$1 AND "articles"."deleted_at" IS NULL ORDER BY "articles"."id" LIMIT \$2 FOR UPDATE`).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "hello"))
+		mock.ExpectCommit()
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			entity, err := repo.GetByIDForUpdate(ctx, tx, "1")
+			if err == nil {
+				assert.Equal(t, "hello", entity.Title)
+			}
+			return err
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetByIDForUpdateNotFound", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectQuery(`FOR UPDATE`).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.GetByIDForUpdate(ctx, db, "404")
+		assertErrorType(t, err, apperrors.ErrorTypeNotFound)
+
+		// ID不是正整数时不查询数据库
+		_, err = repo.GetByIDForUpdate(ctx, db, "1 OR 1=1")
+		assertErrorType(t, err, apperrors.ErrorTypeNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "articles" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		entity := &article{Title: "updated"}
+		entity.ID = 1
+		require.NoError(t, repo.Update(ctx, db, entity))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 软删除，未影响任何行时返回NotFound错误
+	t.Run("Delete", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "articles" SET "deleted_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "articles" SET "deleted_at"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Delete(ctx, db, 1))
+		assertErrorType(t, repo.Delete(ctx, db, 2), apperrors.ErrorTypeNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		repo, db, mock := newTestArticleRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "articles" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NOT NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assertErrorType(t, repo.Restore(ctx, db, 1), apperrors.ErrorTypeNotFound)
+	})
+
+	// 分页参数超出范围时使用默认值
+	t.Run("ListClampsPagination", func(t *testing.T) {
+		repo, _, mock := newTestArticleRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "articles" WHERE "articles"."deleted_at" IS NULL LIMIT \$1`).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "a").AddRow(2, "b"))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "articles"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		entities, total, err := repo.List(ctx, 0, 1000)
+		require.NoError(t, err)
+		assert.Len(t, entities, 2)
+		assert.Equal(t, int64(2), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// scopes 追加的条件同时作用于列表和总数
+	t.Run("ListWithScopes", func(t *testing.T) {
+		repo, _, mock := newTestArticleRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "articles" WHERE title LIKE \$1 LIMIT \$2 OFFSET \$3`).
+			WithArgs("go%", 5, 5).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(6, "go"))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "articles" WHERE title LIKE \$1`).
+			WithArgs("go%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+		entities, total, err := repo.List(ctx, 2, 5, func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Where("title LIKE ?", "go%")
+		})
+		require.NoError(t, err)
+		assert.Len(t, entities, 1)
+		assert.Equal(t, int64(6), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBaseRepository_ErrorLogging(t *testing.T) {
+	ctx := applogger.WithTraceID(applogger.WithRequestID(context.Background(), "req-1"), "trace-1")
+
+	// 数据库错误记录到日志，并带上请求上下文中的追踪ID
+	t.Run("WithTraceID", func(t *testing.T) {
+		_, db, mock := newTestArticleRepository(t)
+		var buf bytes.Buffer
+		repo := NewBaseRepository[article](db, "文章", applogger.New(slog.NewJSONHandler(&buf, nil)))
+		mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnError(errors.New("connection reset"))
+
+		_, err := repo.GetByID(ctx, "1")
+		assertErrorType(t, err, apperrors.ErrorTypeInternal)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "ERROR", entry["level"])
+		assert.Equal(t, "获取文章失败", entry["msg"])
+		assert.Equal(t, "trace-1", entry["trace_id"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, "connection reset", entry["error"])
+	})
+
+	// 唯一约束冲突由上层处理，不记录错误日志
+	t.Run("SkipUniqueViolation", func(t *testing.T) {
+		_, db, mock := newTestArticleRepository(t)
+		var buf bytes.Buffer
+		repo := NewBaseRepository[article](db, "文章", applogger.New(slog.NewJSONHandler(&buf, nil)))
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "articles"`).WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+		mock.ExpectRollback()
+
+		err := repo.Create(ctx, db, &article{Title: "hello"})
+		assertErrorType(t, err, apperrors.ErrorTypeInternal)
+		assert.Empty(t, buf.String())
+	})
+	// 断路器打开后返回服务不可用错误，不再访问数据库也不重复记录日志
+	t.Run("CircuitOpen", func(t *testing.T) {
+		_, gdb, mock := newTestArticleRepository(t)
+		require.NoError(t, db.EnableCircuitBreaker(gdb, &config.CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Minute}))
+		var buf bytes.Buffer
+		repo := NewBaseRepository[article](gdb, "文章", applogger.New(slog.NewJSONHandler(&buf, nil)))
+		mock.ExpectQuery(`SELECT \* FROM "articles"`).WillReturnError(errors.New("connection refused"))
+
+		_, err := repo.GetByID(ctx, "1")
+		assertErrorType(t, err, apperrors.ErrorTypeInternal)
+		buf.Reset()
+
+		_, err = repo.GetByID(ctx, "1")
+		assertErrorType(t, err, apperrors.ErrorTypeServiceUnavailable)
+		var openErr *apperrors.CircuitOpenError
+		assert.ErrorAs(t, err, &openErr)
+		assert.Empty(t, buf.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// primaryKey 强制主库读取的上下文键
+type primaryKey struct{}
+
+// WithPrimary 返回强制从主库读取的上下文
+// 配置只读副本后查询默认路由到副本，写入后立即读取时使用，避免复制延迟读到旧数据
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// usePrimary 判断上下文是否要求从主库读取
+func usePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// reader 返回查询使用的数据库会话，上下文要求时强制使用主库
+func reader(ctx context.Context, db *gorm.DB) *gorm.DB {
+	session := db.WithContext(ctx)
+	if usePrimary(ctx) {
+		session = session.Clauses(dbresolver.Write)
+	}
+	return session
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestWithPrimary(t *testing.T) {
+	primaryConn, primary, err := sqlmock.New()
+	require.NoError(t, err)
+	defer primaryConn.Close()
+	replicaConn, replica, err := sqlmock.New()
+	require.NoError(t, err)
+	defer replicaConn.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: primaryConn}), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{postgres.New(postgres.Config{Conn: replicaConn})},
+	})))
+
+	repo := NewUserRepository(db, nil)
+	columns := []string{"id", "name", "email"}
+
+	// 默认从副本读取
+	replica.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "replica", "test@example.com"))
+	user, err := repo.GetByID(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "replica", user.Name)
+
+	// WithPrimary 强制从主库读取
+	primary.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "primary", "test@example.com"))
+	user, err = repo.GetByID(WithPrimary(context.Background()), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "primary", user.Name)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
@@ -2,66 +2,146 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
 	"github.com/vadxq/go-rest-starter/internal/app/models"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
 )
 
 // UserRepository 定义了用户仓库接口
 type UserRepository interface {
 	Create(ctx context.Context, tx *gorm.DB, user *models.User) error
+	CreateMany(ctx context.Context, tx *gorm.DB, users []*models.User) ([]error, error)
 	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByIDForUpdate(ctx context.Context, tx *gorm.DB, id string) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	Update(ctx context.Context, tx *gorm.DB, user *models.User) error
+	UpdatePasswordHash(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) error
+	ResetPassword(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) (bool, error)
 	Delete(ctx context.Context, tx *gorm.DB, id uint) error
-	List(ctx context.Context, page, pageSize int) ([]*models.User, int64, error)
+	Restore(ctx context.Context, tx *gorm.DB, id uint) error
+	List(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error)
 }
 
 type userRepository struct {
-	db *gorm.DB
+	*BaseRepository[models.User]
 }
 
-// NewUserRepository 创建一个新的 UserRepository 实例
-func NewUserRepository(db *gorm.DB) UserRepository {
+// NewUserRepository 创建一个新的 UserRepository 实例，log为空时使用 slog 默认处理器
+func NewUserRepository(db *gorm.DB, log logger.Logger) UserRepository {
 	return &userRepository{
-		db: db,
+		BaseRepository: NewBaseRepository[models.User](db, "用户", log),
 	}
 }
 
-// Create 创建用户
+// Create 创建用户，邮箱唯一索引冲突时返回冲突错误
+// 并发注册同一邮箱时可能都通过 ExistsByEmail 检查，由数据库唯一约束兜底
 func (r *userRepository) Create(ctx context.Context, tx *gorm.DB, user *models.User) error {
-	result := tx.WithContext(ctx).Create(user)
+	err := r.BaseRepository.Create(ctx, tx, user)
+	if isUniqueViolation(err) {
+		return apperrors.ConflictError("邮箱已被注册", err).WithCode(apperrors.CodeUserEmailTaken)
+	}
+	return err
+}
+
+// Restore 恢复已软删除的用户，邮箱只在未删除的用户中唯一，删除后已被重新注册时返回冲突错误
+func (r *userRepository) Restore(ctx context.Context, tx *gorm.DB, id uint) error {
+	err := r.BaseRepository.Restore(ctx, tx, id)
+	if isUniqueViolation(err) {
+		return apperrors.ConflictError("邮箱已被其他用户注册，无法恢复", err).WithCode(apperrors.CodeUserEmailTaken)
+	}
+	return err
+}
+
+// CreateMany 在事务中逐条创建用户，每条记录使用独立的保存点，单条失败只回滚该条记录
+// 返回与users一一对应的错误；保存点操作失败时事务已不可用，返回整体错误
+func (r *userRepository) CreateMany(ctx context.Context, tx *gorm.DB, users []*models.User) ([]error, error) {
+	errs := make([]error, len(users))
+	db := tx.WithContext(ctx)
+
+	for i, user := range users {
+		savepoint := fmt.Sprintf("bulk_create_%d", i)
+		if err := db.SavePoint(savepoint).Error; err != nil {
+			return nil, r.internalError(ctx, "创建保存点失败", err)
+		}
+
+		if err := r.Create(ctx, tx, user); err != nil {
+			errs[i] = err
+			// PostgreSQL中语句失败后事务处于中止状态，回滚到保存点后才能继续执行
+			if err := db.RollbackTo(savepoint).Error; err != nil {
+				return nil, r.internalError(ctx, "回滚保存点失败", err)
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// Update 更新用户，使用版本号做乐观并发控制
+// 只有数据库中的版本与 user.Version 一致时才写入，写入后版本号加一；
+// 记录已被其他请求修改时返回冲突错误，修改后的邮箱与其他用户冲突时同样返回冲突错误
+func (r *userRepository) Update(ctx context.Context, tx *gorm.DB, user *models.User) error {
+	expected := user.Version
+	user.Version++
+
+	result := tx.WithContext(ctx).Model(user).Where("version = ?", expected).Select("*").Updates(user)
 	if result.Error != nil {
-		return apperrors.InternalError("创建用户失败", result.Error)
+		user.Version = expected
+		if isUniqueViolation(result.Error) {
+			return apperrors.ConflictError("邮箱已被注册", result.Error).WithCode(apperrors.CodeUserEmailTaken)
+		}
+		return r.internalError(ctx, "更新用户失败", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		user.Version = expected
+		return apperrors.ConflictError("用户已被其他请求修改，请刷新后重试", nil).WithCode(apperrors.CodeUserVersionConflict)
 	}
 	return nil
 }
 
-// GetByID 根据 ID 获取用户
-func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
-	var user models.User
-	result := r.db.WithContext(ctx).First(&user, id)
+// UpdatePasswordHash 将用户的密码哈希替换为重新计算的哈希，仅在存储的哈希仍为oldHash时写入
+// 密码未变化，不更新版本号和更新时间；期间密码已被修改时不写入，也不返回错误
+func (r *userRepository) UpdatePasswordHash(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) error {
+	result := tx.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		UpdateColumn("password", newHash)
 	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			return nil, apperrors.NotFoundError("用户", result.Error)
-		}
-		return nil, apperrors.InternalError("获取用户失败", result.Error)
+		return r.internalError(ctx, "更新密码哈希失败", result.Error)
 	}
-	return &user, nil
+	return nil
+}
+
+// ResetPassword 将用户密码设置为newHash并递增版本号，仅在存储的哈希仍为oldHash时写入
+// 返回是否写入，期间密码已被修改（如重置令牌被并发使用）时返回false
+func (r *userRepository) ResetPassword(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) (bool, error) {
+	result := tx.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		Updates(map[string]interface{}{
+			"password": newHash,
+			"version":  gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, r.internalError(ctx, "重置密码失败", result.Error)
+	}
+	return result.RowsAffected > 0, nil
 }
 
 // GetByEmail 根据邮箱获取用户
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	result := r.db.WithContext(ctx).Where("email = ?", email).First(&user)
+	result := reader(ctx, r.db).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			return nil, apperrors.NotFoundError("用户", result.Error)
 		}
-		return nil, apperrors.InternalError("获取用户失败", result.Error)
+		return nil, r.internalError(ctx, "获取用户失败", result.Error)
 	}
 	return &user, nil
 }
@@ -69,55 +149,78 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 // ExistsByEmail 检查邮箱是否存在
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var count int64
-	result := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count)
+	result := reader(ctx, r.db).Model(&models.User{}).Where("email = ?", email).Count(&count)
 	if result.Error != nil {
-		return false, apperrors.InternalError("检查邮箱是否存在失败", result.Error)
+		return false, r.internalError(ctx, "检查邮箱是否存在失败", result.Error)
 	}
 	return count > 0, nil
 }
 
-// Update 更新用户
-func (r *userRepository) Update(ctx context.Context, tx *gorm.DB, user *models.User) error {
-	result := tx.WithContext(ctx).Save(user)
-	if result.Error != nil {
-		return apperrors.InternalError("更新用户失败", result.Error)
-	}
-	return nil
+// userSortColumns 用户列表允许排序的字段及对应的列，排序参数不在白名单中时拒绝，避免拼接到SQL
+var userSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"role":       "role",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
 }
 
-// Delete 删除用户
-func (r *userRepository) Delete(ctx context.Context, tx *gorm.DB, id uint) error {
-	result := tx.WithContext(ctx).Delete(&models.User{}, id)
-	if result.Error != nil {
-		return apperrors.InternalError("删除用户失败", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return apperrors.NotFoundError("用户", nil)
+// likeEscaper 转义LIKE模式中的通配符，搜索词按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// List 获取用户列表，支持按姓名/邮箱搜索、按角色筛选和按白名单字段排序，默认按ID升序
+func (r *userRepository) List(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	var scopes []func(*gorm.DB) *gorm.DB
+	if opts.IncludeDeleted {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
 	}
-	return nil
-}
 
-// List 获取用户列表
-func (r *userRepository) List(ctx context.Context, page, pageSize int) ([]*models.User, int64, error) {
-	if page < 1 {
-		page = 1
+	if search := strings.TrimSpace(opts.Search); search != "" {
+		pattern := "%" + likeEscaper.Replace(search) + "%"
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB {
+			return db.Where(db.Session(&gorm.Session{NewDB: true}).
+				Where("name ILIKE ?", pattern).Or("email ILIKE ?", pattern))
+		})
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
+	if opts.Role != "" {
+		scopes = append(scopes, func(db *gorm.DB) *gorm.DB { return db.Where("role = ?", opts.Role) })
 	}
 
-	offset := (page - 1) * pageSize
-
-	var users []*models.User
-	result := r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&users)
-	if result.Error != nil {
-		return nil, 0, apperrors.InternalError("获取用户列表失败", result.Error)
+	// 未指定排序时按ID排序，保证分页结果稳定
+	sort := opts.Sort
+	if sort == "" {
+		sort = "id"
 	}
-
-	var total int64
-	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error; err != nil {
-		return nil, 0, apperrors.InternalError("获取用户总数失败", err)
+	order, err := parseUserSort(sort)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return users, total, nil
+	return r.BaseRepository.ListSorted(ctx, page, pageSize, order, scopes...)
+}
+
+// parseUserSort 解析排序参数，字段必须在 userSortColumns 白名单中
+func parseUserSort(sort string) (clause.OrderBy, error) {
+	var order clause.OrderBy
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		name, desc := strings.CutPrefix(field, "-")
+
+		column, ok := userSortColumns[name]
+		if !ok {
+			err := apperrors.ValidationError("不支持的排序字段", nil)
+			err.Fields = []apperrors.FieldError{{
+				Field:   "sort",
+				Tag:     "oneof",
+				Message: fmt.Sprintf("不支持按 %q 排序", field),
+			}}
+			return order, err
+		}
+		order.Columns = append(order.Columns, clause.OrderByColumn{
+			Column: clause.Column{Name: column},
+			Desc:   desc,
+		})
+	}
+	return order, nil
 }
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// newTestUserRepository 创建使用sqlmock的用户仓库
+func newTestUserRepository(t *testing.T) (UserRepository, *gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	return NewUserRepository(db, nil), db, mock
+}
+
+func TestUserRepository_UniqueEmail(t *testing.T) {
+	ctx := context.Background()
+	uniqueViolation := &pgconn.PgError{
+		Code:           pgUniqueViolation,
+		Message:        `duplicate key value violates unique constraint "idx_users_email"`,
+		ConstraintName: "idx_users_email",
+	}
+
+	// 并发注册时数据库返回唯一约束冲突，转换为冲突错误
+	t.Run("CreateDuplicate", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(uniqueViolation)
+		mock.ExpectRollback()
+
+		err := repo.Create(ctx, db, &models.User{Name: "Test User", Email: "test@example.com", Password: "hashed"})
+		assertErrorType(t, err, apperrors.ErrorTypeConflict)
+		assert.ErrorIs(t, err, uniqueViolation)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 修改为已被占用的邮箱同样返回冲突错误
+	t.Run("UpdateDuplicate", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET`).WillReturnError(uniqueViolation)
+		mock.ExpectRollback()
+
+		user := &models.User{Name: "Test User", Email: "taken@example.com", Password: "hashed"}
+		user.ID = 1
+		err := repo.Update(ctx, db, user)
+		assertErrorType(t, err, apperrors.ErrorTypeConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 删除后邮箱已被重新注册时，恢复返回冲突错误
+	t.Run("RestoreDuplicate", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "deleted_at"`).WillReturnError(uniqueViolation)
+		mock.ExpectRollback()
+
+		err := repo.Restore(ctx, db, 1)
+		assertErrorType(t, err, apperrors.ErrorTypeConflict)
+		assert.Equal(t, apperrors.CodeUserEmailTaken, apperrors.AsError(err).Code)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 其他数据库错误仍为内部错误
+	t.Run("OtherError", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(&pgconn.PgError{Code: "23502"})
+		mock.ExpectRollback()
+
+		err := repo.Create(ctx, db, &models.User{Name: "Test User", Email: "test@example.com"})
+		assertErrorType(t, err, apperrors.ErrorTypeInternal)
+	})
+}
+
+func TestUserRepository_ListFilter(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"id", "name", "email", "role"}
+
+	// 按姓名或邮箱模糊匹配，搜索词中的通配符按字面匹配，未指定排序时按ID排序
+	t.Run("Search", func(t *testing.T) {
+		repo, _, mock := newTestUserRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE \(name ILIKE \$1 OR email ILIKE \$2\) AND role = \$3 AND "users"."deleted_at" IS NULL ORDER BY "id" LIMIT \$4`).
+			WithArgs(`%ali\_ce%`, `%ali\_ce%`, "admin", 10).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Ali_ce", "alice@example.com", "admin"))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE \(name ILIKE \$1 OR email ILIKE \$2\) AND role = \$3`).
+			WithArgs(`%ali\_ce%`, `%ali\_ce%`, "admin").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		users, total, err := repo.List(ctx, 1, 10, dto.UserListOptions{
+			ListUsersFilter: dto.ListUsersFilter{Search: " ali_ce ", Role: "admin"},
+		})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, "Ali_ce", users[0].Name)
+		assert.Equal(t, int64(1), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 前缀"-"表示降序，多个字段按顺序排序，排序不影响总数查询
+	t.Run("DescendingSort", func(t *testing.T) {
+		repo, _, mock := newTestUserRepository(t)
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."deleted_at" IS NULL ORDER BY "created_at" DESC,"name" LIMIT \$1`).
+			WithArgs(10).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow(2, "Bob", "bob@example.com", "user").AddRow(1, "Alice", "alice@example.com", "user"))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE "users"."deleted_at" IS NULL$`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		users, total, err := repo.List(ctx, 1, 10, dto.UserListOptions{
+			ListUsersFilter: dto.ListUsersFilter{Sort: "-created_at,name"},
+		})
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, uint(2), users[0].ID)
+		assert.Equal(t, int64(2), total)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 排序字段不在白名单中时返回验证错误，不执行查询
+	t.Run("InvalidSort", func(t *testing.T) {
+		for _, sort := range []string{"password", "-name;DROP TABLE users", "name,", "--name"} {
+			repo, _, mock := newTestUserRepository(t)
+
+			_, _, err := repo.List(ctx, 1, 10, dto.UserListOptions{
+				ListUsersFilter: dto.ListUsersFilter{Sort: sort},
+			})
+			assertErrorType(t, err, apperrors.ErrorTypeValidation)
+
+			var appErr *apperrors.Error
+			require.ErrorAs(t, err, &appErr)
+			require.Len(t, appErr.Fields, 1)
+			assert.Equal(t, "sort", appErr.Fields[0].Field)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestUserRepository_CreateMany(t *testing.T) {
+	ctx := context.Background()
+
+	// 每条记录使用独立保存点，失败的记录回滚到保存点后继续写入后续记录
+	t.Run("RollbackFailedItem", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT bulk_create_0`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`SAVEPOINT bulk_create_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT bulk_create_1`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`SAVEPOINT bulk_create_2`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectCommit()
+
+		users := []*models.User{
+			{Name: "Alice", Email: "alice@example.com", Password: "hashed"},
+			{Name: "Taken", Email: "taken@example.com", Password: "hashed"},
+			{Name: "Carol", Email: "carol@example.com", Password: "hashed"},
+		}
+
+		var errs []error
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			errs, err = repo.CreateMany(ctx, tx, users)
+			return err
+		})
+		require.NoError(t, err)
+		require.Len(t, errs, 3)
+		assert.NoError(t, errs[0])
+		assertErrorType(t, errs[1], apperrors.ErrorTypeConflict)
+		assert.NoError(t, errs[2])
+		assert.Equal(t, uint(1), users[0].ID)
+		assert.Equal(t, uint(3), users[2].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_UpdateVersion(t *testing.T) {
+	ctx := context.Background()
+	newUser := func() *models.User {
+		user := &models.User{Name: "Test User", Email: "test@example.com", Password: "hashed", Role: "user", Version: 3}
+		user.ID = 1
+		return user
+	}
+
+	// 版本一致时写入，版本号加一
+	t.Run("Success", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET .*"version"=\$9,"verified"=\$10 WHERE version = \$11 AND "users"."deleted_at" IS NULL AND "id" = \$12`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "Test User", "test@example.com", "hashed", "user", "", 4, false, 3, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		user := newUser()
+		require.NoError(t, repo.Update(ctx, db, user))
+		assert.Equal(t, uint(4), user.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 记录已被其他请求修改（版本不一致），返回冲突错误且不改变版本号
+	t.Run("StaleVersion", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET .* WHERE version = \$11`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		user := newUser()
+		err := repo.Update(ctx, db, user)
+		assertErrorType(t, err, apperrors.ErrorTypeConflict)
+		assert.Equal(t, uint(3), user.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_UpdatePasswordHash(t *testing.T) {
+	ctx := context.Background()
+
+	// 仅在存储的哈希未变化时写入，不更新版本号
+	t.Run("Success", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "password"=\$1 WHERE \(id = \$2 AND password = \$3\) AND "users"."deleted_at" IS NULL`).
+			WithArgs("new-hash", 1, "old-hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.UpdatePasswordHash(ctx, db, 1, "old-hash", "new-hash"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 期间密码已被修改时不写入，也不返回错误
+	t.Run("PasswordChanged", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "password"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.UpdatePasswordHash(ctx, db, 1, "old-hash", "new-hash"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestUserRepository_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+
+	// 存储的哈希未变化时写入新密码并递增版本号
+	t.Run("Success", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "password"=\$1,"version"=version \+ 1,"updated_at"=\$2 WHERE \(id = \$3 AND password = \$4\) AND "users"."deleted_at" IS NULL`).
+			WithArgs("new-hash", sqlmock.AnyArg(), 1, "old-hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		updated, err := repo.ResetPassword(ctx, db, 1, "old-hash", "new-hash")
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 期间密码已被修改时不写入
+	t.Run("PasswordChanged", func(t *testing.T) {
+		repo, db, mock := newTestUserRepository(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "users" SET "password"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		updated, err := repo.ResetPassword(ctx, db, 1, "old-hash", "new-hash")
+		require.NoError(t, err)
+		assert.False(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
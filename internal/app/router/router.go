@@ -1,15 +1,27 @@
 package api
 
 import (
+	"database/sql"
+	"log/slog"
 	"net/http"
+	"net/http/pprof"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/redis/go-redis/v9"
 
+	"github.com/vadxq/go-rest-starter/internal/app/graphql"
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	v2handlers "github.com/vadxq/go-rest-starter/internal/app/handlers/v2"
 	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/internal/app/openapi"
 	v1 "github.com/vadxq/go-rest-starter/internal/app/router/v1"
+	v2 "github.com/vadxq/go-rest-starter/internal/app/router/v2"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	"github.com/vadxq/go-rest-starter/pkg/featureflags"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
 )
 
 // 路由组类型定义
@@ -20,50 +32,129 @@ type RouterGroup struct {
 
 // RouterConfig 路由配置
 type RouterConfig struct {
-	UserHandler   *handlers.UserHandler
-	AuthHandler   *handlers.AuthHandler
-	HealthHandler *handlers.HealthHandler
-	JWTSecret     string
+	UserHandler      *handlers.UserHandler
+	UserV2Handler    *v2handlers.UserHandler
+	AuthHandler      *handlers.AuthHandler
+	HealthHandler    *handlers.HealthHandler
+	JWKSHandler      *handlers.JWKSHandler
+	AuditHandler     *handlers.AuditHandler
+	AvatarHandler    *handlers.AvatarHandler
+	EventsHandler    *handlers.EventsHandler
+	WebSocketHandler *handlers.WebSocketHandler
+	GraphQLHandler   *graphql.Handler                    // 为空时不开放GraphQL接口
+	LogLevelHandler  *handlers.LogLevelHandler           // 为空时不开放日志级别调整接口
+	JWT              *jwtpkg.Config                      // 令牌签名与验证配置
+	Redis            *redis.Client                       // 配置后使用Redis分布式速率限制
+	Cache            cache.Cache                         // 幂等键响应缓存，为空时不启用幂等控制
+	CORS             *custommiddleware.CORSConfig        // 跨域配置，为空时使用默认配置
+	APIVersion       *custommiddleware.APIVersionConfig  // API版本协商配置，为空时使用默认配置
+	BodyLogging      *custommiddleware.BodyLoggingConfig // 请求和响应体日志配置，为空时不记录
+	AccessLog        *custommiddleware.AccessLogConfig   // 访问日志配置，为空时只输出结构化日志
+	Recovery         *custommiddleware.RecoveryConfig    // 恢复中间件配置，为空时使用默认配置
+	Timeout          time.Duration                       // 请求处理超时，为0时使用默认值
+	DB               *sql.DB                             // 配置后在 /metrics 导出数据库连接池指标
+	ClientIP         *custommiddleware.ClientIPResolver  // 客户端IP解析，为空时不信任任何代理
+	Uploads          http.Handler                        // 本地存储的文件访问处理器，与UploadPath同时配置时提供上传文件的访问
+	UploadPath       string                              // 上传文件的访问路径，如 /uploads
+	FeatureFlags     featureflags.FlagProvider           // 功能开关，配置后在请求上下文中保存开关快照
+	Pprof            bool                                // 是否在 /debug/pprof 开放性能分析接口（仅管理员）
 }
 
 // Setup 设置所有API路由
 func Setup(r chi.Router, config RouterConfig) {
+	// Prometheus指标收集器
+	metrics := custommiddleware.NewPrometheusMetrics(&custommiddleware.DefaultPrometheusConfig)
+	if config.DB != nil {
+		if err := metrics.RegisterDBStats(config.DB, "primary"); err != nil {
+			slog.Warn("注册数据库连接池指标失败", "error", err)
+		}
+	}
+	if provider, ok := config.Cache.(cache.StatsProvider); ok {
+		if err := metrics.RegisterCacheStats(provider); err != nil {
+			slog.Warn("注册缓存指标失败", "error", err)
+		}
+	}
+
+	// 速率限制器（全局按IP限制，路由组可使用命名策略）
+	rateLimiter := custommiddleware.NewRateLimitMiddleware(custommiddleware.DefaultRateLimitConfig, nil)
+
+	// 全局速率限制器，多实例部署时使用Redis共享计数
+	var globalLimiter custommiddleware.RateLimiter = rateLimiter
+	if config.Redis != nil {
+		globalLimiter = custommiddleware.NewRedisRateLimiter(config.Redis, custommiddleware.DefaultRedisRateLimitConfig, nil)
+	}
+
+	// 客户端IP解析，未配置时只使用连接的远端地址
+	clientIP := config.ClientIP
+	if clientIP == nil {
+		clientIP, _ = custommiddleware.NewClientIPResolver(nil)
+	}
+
 	// 应用全局中间件
-	applyGlobalMiddleware(r)
+	applyGlobalMiddleware(r, metrics, globalLimiter, clientIP, config)
 
 	// API文档路由
 	v1.SetupSwaggerRoutes(r)
 
 	// 健康检查和状态监控
-	setupUtilityRoutes(r, config.HealthHandler)
+	setupUtilityRoutes(r, config.HealthHandler, config.JWKSHandler, metrics)
+
+	// 本地存储的上传文件
+	if config.Uploads != nil && config.UploadPath != "" {
+		setupUploadRoutes(r, config.Uploads, config.UploadPath)
+	}
 
 	// API v1
-	setupV1Routes(r, config)
+	jwtConfig := setupV1Routes(r, config, rateLimiter)
+
+	// API v2
+	setupV2Routes(r, config, rateLimiter, jwtConfig)
+
+	// 性能分析
+	if config.Pprof {
+		setupPprofRoutes(r, jwtConfig)
+	}
 }
 
 // applyGlobalMiddleware 应用全局中间件
-func applyGlobalMiddleware(r chi.Router) {
+func applyGlobalMiddleware(r chi.Router, metrics *custommiddleware.PrometheusMetrics, rateLimiter custommiddleware.RateLimiter, clientIP *custommiddleware.ClientIPResolver, config RouterConfig) {
 	// 基础中间件
-	r.Use(middleware.RequestID)                 // 请求ID
-	r.Use(middleware.RealIP)                    // 真实IP
-	r.Use(custommiddleware.RequestContext)      // 请求上下文
-	r.Use(custommiddleware.LoggingMiddleware)   // 日志
-	r.Use(custommiddleware.RecoveryMiddleware)  // 恢复
-	r.Use(middleware.Timeout(60 * time.Second)) // 超时
-	r.Use(middleware.CleanPath)                 // 清理路径
-	r.Use(middleware.StripSlashes)              // 去除尾部斜杠
+	r.Use(middleware.RequestID)                                   // 请求ID
+	r.Use(clientIP.Middleware)                                    // 按可信代理解析客户端IP
+	r.Use(custommiddleware.TracingMiddleware)                     // 链路追踪
+	r.Use(custommiddleware.RequestContext)                        // 请求上下文
+	r.Use(custommiddleware.AccessLogMiddleware(config.AccessLog)) // 访问日志
+	r.Use(custommiddleware.MonitoringMiddleware)                  // 基础指标
+	r.Use(metrics.Middleware)                                     // Prometheus指标
+	r.Use(custommiddleware.Recovery(config.Recovery))             // 恢复
+	r.Use(custommiddleware.TimeoutMiddleware(config.Timeout))     // 超时，返回JSON错误响应
+	r.Use(middleware.CleanPath)                                   // 清理路径
+	r.Use(middleware.StripSlashes)                                // 去除尾部斜杠
+	r.Use(custommiddleware.APIVersion(config.APIVersion))         // 按Accept请求头选择API版本
+
+	// 功能开关快照，处理器通过 featureflags.IsEnabled 判断
+	if config.FeatureFlags != nil {
+		r.Use(custommiddleware.FeatureFlags(config.FeatureFlags))
+	}
+
+	// 响应压缩中间件
+	r.Use(custommiddleware.CompressionMiddleware(&custommiddleware.DefaultCompressionConfig)) // gzip/deflate压缩
+
+	// 请求和响应体日志，位于版本路径改写之后以按实际路径排除认证接口，位于压缩之后以记录未压缩的响应
+	if config.BodyLogging != nil {
+		r.Use(custommiddleware.BodyLoggingMiddleware(config.BodyLogging))
+	}
 
 	// 安全中间件
-	r.Use(custommiddleware.CORSMiddleware) // 跨域
-	r.Use(securityHeaders)                 // 安全头
-	
+	r.Use(custommiddleware.CORSMiddleware(config.CORS)) // 跨域
+	r.Use(securityHeaders)                              // 安全头
+
 	// 速率限制中间件
-	rateLimiter := custommiddleware.NewRateLimitMiddleware(custommiddleware.DefaultRateLimitConfig)
 	r.Use(rateLimiter.Handler) // 速率限制
 }
 
 // setupUtilityRoutes 设置实用路由（健康检查、状态监控等）
-func setupUtilityRoutes(r chi.Router, healthHandler *handlers.HealthHandler) {
+func setupUtilityRoutes(r chi.Router, healthHandler *handlers.HealthHandler, jwksHandler *handlers.JWKSHandler, metrics *custommiddleware.PrometheusMetrics) {
 	// 基础健康检查
 	r.Get("/health", healthHandler.Health)
 
@@ -76,6 +167,19 @@ func setupUtilityRoutes(r chi.Router, healthHandler *handlers.HealthHandler) {
 	// 存活检查
 	r.Get("/live", healthHandler.Live)
 
+	// Prometheus指标
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	// JWT公钥集合，供其他服务验证令牌
+	r.Get("/.well-known/jwks.json", jwksHandler.JWKS)
+
+	// OpenAPI 3.0 文档，供新版代码生成工具使用
+	if openAPIHandler, err := openapi.Handler(); err != nil {
+		slog.Warn("生成OpenAPI文档失败", "error", err)
+	} else {
+		r.Get("/openapi.json", openAPIHandler)
+	}
+
 	// 版本信息
 	r.Get("/version", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -90,11 +194,37 @@ func setupUtilityRoutes(r chi.Router, healthHandler *handlers.HealthHandler) {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte(`{"status":"running"}`))
 		})
+
+		// JSON格式的指标快照
+		r.Get("/metrics", custommiddleware.MetricsHandler)
+	})
+}
+
+// setupPprofRoutes 在 /debug/pprof 下挂载 net/http/pprof 的处理器，仅管理员可访问
+// CPU分析和执行追踪的 seconds 参数需小于服务器的写超时（server.write_timeout）
+func setupPprofRoutes(r chi.Router, jwtConfig *custommiddleware.JWTConfig) {
+	r.Route("/debug/pprof", func(r chi.Router) {
+		r.Use(custommiddleware.JWTAuth(jwtConfig))
+		r.Use(custommiddleware.RequireRole("admin"))
+
+		r.Get("/", pprof.Index)
+		r.Get("/cmdline", pprof.Cmdline)
+		r.Get("/profile", pprof.Profile)
+		r.Get("/symbol", pprof.Symbol)
+		r.Post("/symbol", pprof.Symbol)
+		r.Get("/trace", pprof.Trace)
+		r.Get("/{profile}", pprof.Index) // heap、goroutine、allocs、block、mutex、threadcreate
 	})
 }
 
-// setupV1Routes 设置 API v1 路由
-func setupV1Routes(r chi.Router, config RouterConfig) {
+// setupUploadRoutes 在path下挂载上传文件的访问处理器
+func setupUploadRoutes(r chi.Router, uploads http.Handler, path string) {
+	path = strings.TrimRight(path, "/")
+	r.Get(path+"/*", http.StripPrefix(path, uploads).ServeHTTP)
+}
+
+// setupV1Routes 设置 API v1 路由，返回的JWT认证配置供其他版本的路由复用
+func setupV1Routes(r chi.Router, config RouterConfig, rateLimiter *custommiddleware.RateLimitMiddleware) *custommiddleware.JWTConfig {
 	// 定义排除认证的路径
 	excludePaths := []string{
 		"/api/v1/auth/login",
@@ -103,25 +233,51 @@ func setupV1Routes(r chi.Router, config RouterConfig) {
 		"/health",
 		"/version",
 		"/status",
+		"/metrics",
 	}
 
 	// 创建JWT认证配置
 	jwtConfig := &custommiddleware.JWTConfig{
-		Secret:       config.JWTSecret,
+		Token:        config.JWT,
 		ExcludePaths: excludePaths,
+		Cache:        config.Cache,
 	}
 
 	// API v1 基础路径
 	r.Route("/api/v1", func(r chi.Router) {
 		v1Config := v1.RouterConfig{
-			UserHandler: config.UserHandler,
-			AuthHandler: config.AuthHandler,
-			JWTSecret:   config.JWTSecret,
+			UserHandler:      config.UserHandler,
+			AuthHandler:      config.AuthHandler,
+			AuditHandler:     config.AuditHandler,
+			AvatarHandler:    config.AvatarHandler,
+			EventsHandler:    config.EventsHandler,
+			WebSocketHandler: config.WebSocketHandler,
+			GraphQLHandler:   config.GraphQLHandler,
+			LogLevelHandler:  config.LogLevelHandler,
+			RateLimiter:      rateLimiter,
+			Idempotency:      custommiddleware.NewIdempotencyMiddleware(config.Cache, custommiddleware.DefaultIdempotencyConfig),
 		}
 		// 公共路由组 - 不需要认证
 		v1.SetupPublicRoutes(r, v1Config)
 		// 受保护路由组 - 需要认证
 		v1.SetupProtectedRoutes(r, v1Config, jwtConfig)
+		// WebSocket路由组 - 需要认证，令牌可通过查询参数传递
+		v1.SetupWebSocketRoutes(r, v1Config, jwtConfig)
+		// GraphQL路由组 - 登录无需认证，其余操作由解析器校验认证
+		v1.SetupGraphQLRoutes(r, v1Config, jwtConfig)
+	})
+	return jwtConfig
+}
+
+// setupV2Routes 设置 API v2 路由，v2 未提供的接口仍通过 v1 访问
+func setupV2Routes(r chi.Router, config RouterConfig, rateLimiter *custommiddleware.RateLimitMiddleware, jwtConfig *custommiddleware.JWTConfig) {
+	r.Route("/api/v2", func(r chi.Router) {
+		v2Config := v2.RouterConfig{
+			UserHandler: config.UserV2Handler,
+			RateLimiter: rateLimiter,
+		}
+		// 受保护路由组 - 需要认证
+		v2.SetupProtectedRoutes(r, v2Config, jwtConfig)
 	})
 }
 
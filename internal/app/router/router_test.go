@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+func TestPprofRoutes(t *testing.T) {
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	r := chi.NewRouter()
+	setupPprofRoutes(r, &custommiddleware.JWTConfig{Token: tokenConfig})
+
+	request := func(path, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if role != "" {
+			token, err := jwtpkg.GenerateAccessToken(1, role, "family", tokenConfig)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 未认证和非管理员不能访问
+	assert.Equal(t, http.StatusUnauthorized, request("/debug/pprof/", "").Code)
+	assert.Equal(t, http.StatusForbidden, request("/debug/pprof/", "user").Code)
+	assert.Equal(t, http.StatusForbidden, request("/debug/pprof/heap", "user").Code)
+
+	// 管理员可以访问索引和各项分析
+	rec := request("/debug/pprof/", "admin")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = request("/debug/pprof/goroutine?debug=1", "admin")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine profile")
+
+	assert.Equal(t, http.StatusOK, request("/debug/pprof/cmdline", "admin").Code)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
 	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
 )
 
 // SetupProtectedRoutes 设置受保护路由（需要认证）
@@ -11,29 +12,104 @@ func SetupProtectedRoutes(r chi.Router, config RouterConfig, jwtConfig *custommi
 	// 创建需要JWT认证的路由组
 	r.Group(func(r chi.Router) {
 		r.Use(custommiddleware.JWTAuth(jwtConfig))
+		r.Use(config.RateLimiter.Limit("api")) // 按用户ID限制请求速率
 
 		// 用户登出（需要认证的认证相关路由）
 		r.Route("/account", func(r chi.Router) {
 			r.Post("/logout", config.AuthHandler.Logout)
+			r.Get("/sessions", config.AuthHandler.ListSessions)          // 获取登录会话列表
+			r.Delete("/sessions/{id}", config.AuthHandler.RevokeSession) // 撤销指定会话
 		})
 
+		// 令牌内省，仅限拥有 auth:introspect 权限范围的受信任客户端（如网关）
+		r.With(custommiddleware.RequireScope(jwt.ScopeIntrospect)).Post("/auth/introspect", config.AuthHandler.Introspect)
+
 		// 用户资源路由
-		SetupUserRoutes(r, config.UserHandler)
+		SetupUserRoutes(r, config.UserHandler, config.AvatarHandler, config.Idempotency)
+
+		// 审计日志路由
+		SetupAuditRoutes(r, config.AuditHandler)
+
+		// 运维管理路由
+		SetupAdminRoutes(r, config.LogLevelHandler)
+
+		// 实时事件推送
+		r.Get("/events", config.EventsHandler.Stream) // 订阅实时事件 (text/event-stream)
+	})
+}
+
+// SetupWebSocketRoutes 设置WebSocket路由（需要认证）
+// 浏览器无法为WebSocket请求设置请求头，令牌也可以通过 access_token 查询参数传递
+func SetupWebSocketRoutes(r chi.Router, config RouterConfig, jwtConfig *custommiddleware.JWTConfig) {
+	wsJWTConfig := *jwtConfig
+	wsJWTConfig.QueryParam = "access_token"
+
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.JWTAuth(&wsJWTConfig))
+		r.Use(config.RateLimiter.Limit("api")) // 按用户ID限制建立连接的速率
+
+		r.Get("/ws", config.WebSocketHandler.Connect) // 建立WebSocket连接
+	})
+}
+
+// SetupGraphQLRoutes 设置GraphQL路由，未启用GraphQL时不注册
+// 同一接口中既有无需认证的登录又有受保护的操作，未携带令牌的请求放行，由解析器校验认证和角色
+func SetupGraphQLRoutes(r chi.Router, config RouterConfig, jwtConfig *custommiddleware.JWTConfig) {
+	if config.GraphQLHandler == nil {
+		return
+	}
+
+	graphqlJWTConfig := *jwtConfig
+	graphqlJWTConfig.Optional = true
+
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.JWTAuth(&graphqlJWTConfig))
+		r.Use(config.RateLimiter.Limit("api"))
+
+		r.Post("/graphql", config.GraphQLHandler.ServeHTTP) // 执行GraphQL查询或变更
+	})
+}
+
+// SetupAuditRoutes 设置审计日志相关路由（仅管理员）
+func SetupAuditRoutes(r chi.Router, auditHandler *handlers.AuditHandler) {
+	r.Route("/audit", func(r chi.Router) {
+		r.Use(custommiddleware.RequireRole("admin"))
+
+		r.Get("/", auditHandler.ListAuditLogs) // 获取审计日志列表
+	})
+}
+
+// SetupAdminRoutes 设置运维管理路由（仅管理员）
+func SetupAdminRoutes(r chi.Router, logLevelHandler *handlers.LogLevelHandler) {
+	if logLevelHandler == nil {
+		return
+	}
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(custommiddleware.RequireRole("admin"))
+
+		r.Put("/log-level", logLevelHandler.SetLogLevel) // 调整日志级别
 	})
 }
 
 // SetupUserRoutes 设置用户相关路由
-func SetupUserRoutes(r chi.Router, userHandler *handlers.UserHandler) {
+func SetupUserRoutes(r chi.Router, userHandler *handlers.UserHandler, avatarHandler *handlers.AvatarHandler, idempotency *custommiddleware.IdempotencyMiddleware) {
 	r.Route("/users", func(r chi.Router) {
 		// 用户集合操作
-		r.Get("/", userHandler.ListUsers)                                               // 获取用户列表
-		r.With(custommiddleware.RequireRole("admin")).Post("/", userHandler.CreateUser) // 创建用户 (仅管理员)
+		r.Get("/", userHandler.ListUsers)                                                                             // 获取用户列表
+		r.With(custommiddleware.RequireRole("admin"), idempotency.Handler).Post("/", userHandler.CreateUser)          // 创建用户 (仅管理员，支持幂等键)
+		r.With(custommiddleware.RequireRole("admin"), idempotency.Handler).Post("/bulk", userHandler.CreateUsersBulk) // 批量创建用户 (仅管理员，支持幂等键)
 
 		// 用户实例操作
 		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", userHandler.GetUser)       // 获取用户详情
-			r.Put("/", userHandler.UpdateUser)    // 更新用户
-			r.Delete("/", userHandler.DeleteUser) // 删除用户
+			r.Get("/", userHandler.GetUser)                                                             // 获取用户详情
+			r.With(custommiddleware.RequireSelfOrRole("id", "admin")).Put("/", userHandler.UpdateUser)  // 更新用户 (仅本人或管理员)
+			r.With(custommiddleware.RequireSelfOrRole("id", "admin")).Patch("/", userHandler.PatchUser) // 部分更新用户 (仅本人或管理员)
+			r.Delete("/", userHandler.DeleteUser)                                                       // 删除用户
+
+			r.With(custommiddleware.RequireSelfOrRole("id", "admin")).Post("/avatar", avatarHandler.UploadAvatar) // 上传头像 (仅本人或管理员，multipart/form-data)
+
+			r.With(custommiddleware.RequireRole("admin")).Post("/restore", userHandler.RestoreUser) // 恢复已删除用户 (仅管理员)
 		})
 	})
 }
@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+	jwtpkg "github.com/vadxq/go-rest-starter/pkg/jwt"
+)
+
+func TestSetupUserRoutes_UpdateOwnership(t *testing.T) {
+	tokenConfig := &jwtpkg.Config{Secret: "test-secret", AccessTokenExp: time.Hour}
+	r := chi.NewRouter()
+	r.Use(custommiddleware.JWTAuth(&custommiddleware.JWTConfig{Token: tokenConfig}))
+	// 请求体无效时处理器在调用服务前返回400，用于判断请求是否通过了权限校验
+	SetupUserRoutes(r, handlers.NewUserHandler(nil, nil, validator.New(), nil), nil, nil)
+
+	request := func(method string, userID uint, role string) int {
+		token, err := jwtpkg.GenerateAccessToken(userID, role, "family", tokenConfig)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(method, "/users/1", strings.NewReader("{"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodPatch} {
+		// 其他普通用户不能修改
+		assert.Equal(t, http.StatusForbidden, request(method, 2, "user"), method)
+
+		// 本人和管理员通过权限校验
+		assert.Equal(t, http.StatusBadRequest, request(method, 1, "user"), method)
+		assert.Equal(t, http.StatusBadRequest, request(method, 2, "admin"), method)
+	}
+}
@@ -2,22 +2,36 @@ package v1
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/vadxq/go-rest-starter/internal/app/graphql"
 	"github.com/vadxq/go-rest-starter/internal/app/handlers"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
 )
 
 // RouterConfig 路由配置
 type RouterConfig struct {
-	UserHandler *handlers.UserHandler
-	AuthHandler *handlers.AuthHandler
-	JWTSecret   string
+	UserHandler      *handlers.UserHandler
+	AuthHandler      *handlers.AuthHandler
+	AuditHandler     *handlers.AuditHandler
+	AvatarHandler    *handlers.AvatarHandler
+	EventsHandler    *handlers.EventsHandler
+	WebSocketHandler *handlers.WebSocketHandler
+	GraphQLHandler   *graphql.Handler          // 为空时不注册GraphQL路由
+	LogLevelHandler  *handlers.LogLevelHandler // 为空时不注册日志级别调整路由
+	RateLimiter      *custommiddleware.RateLimitMiddleware
+	Idempotency      *custommiddleware.IdempotencyMiddleware
 }
 
 // SetupPublicRoutes 设置公共路由（不需要认证）
 func SetupPublicRoutes(r chi.Router, config RouterConfig) {
 	// 认证相关路由
 	r.Route("/auth", func(r chi.Router) {
-		r.Post("/login", config.AuthHandler.Login)          // 登录
-		r.Post("/refresh", config.AuthHandler.RefreshToken) // 刷新令牌
-		// 可以添加注册、忘记密码等路由
+		r.Use(config.RateLimiter.Limit("auth")) // 认证接口使用更严格的速率限制
+
+		r.Post("/login", config.AuthHandler.Login)                    // 登录
+		r.Post("/refresh", config.AuthHandler.RefreshToken)           // 刷新令牌
+		r.Get("/verify-email", config.UserHandler.VerifyEmail)        // 验证邮箱
+		r.Post("/forgot-password", config.AuthHandler.ForgotPassword) // 忘记密码
+		r.Post("/reset-password", config.AuthHandler.ResetPassword)   // 重置密码
+		// 可以添加注册等路由
 	})
 }
@@ -0,0 +1,36 @@
+// Package v2 API v2 路由
+// v2 只注册响应格式与 v1 不同的接口，客户端可通过 /api/v2 路径或
+// Accept: application/vnd.<vendor>.v2+json 请求头访问
+package v2
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	v2handlers "github.com/vadxq/go-rest-starter/internal/app/handlers/v2"
+	custommiddleware "github.com/vadxq/go-rest-starter/internal/app/middleware"
+)
+
+// RouterConfig 路由配置
+type RouterConfig struct {
+	UserHandler *v2handlers.UserHandler
+	RateLimiter *custommiddleware.RateLimitMiddleware
+}
+
+// SetupProtectedRoutes 设置受保护路由（需要认证）
+func SetupProtectedRoutes(r chi.Router, config RouterConfig, jwtConfig *custommiddleware.JWTConfig) {
+	r.Group(func(r chi.Router) {
+		r.Use(custommiddleware.JWTAuth(jwtConfig))
+		r.Use(config.RateLimiter.Limit("api")) // 按用户ID限制请求速率
+
+		// 用户资源路由
+		SetupUserRoutes(r, config.UserHandler)
+	})
+}
+
+// SetupUserRoutes 设置用户相关路由
+func SetupUserRoutes(r chi.Router, userHandler *v2handlers.UserHandler) {
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/", userHandler.ListUsers)   // 获取用户列表
+		r.Get("/{id}", userHandler.GetUser) // 获取用户详情
+	})
+}
@@ -0,0 +1,41 @@
+// Package seed 初始化本地开发和测试环境所需的基础数据
+package seed
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// AdminRole 默认管理员的角色
+const AdminRole = "admin"
+
+// ErrAdminPasswordRequired 未配置默认管理员密码
+var ErrAdminPasswordRequired = errors.New("seed: admin password is required")
+
+// Admin 通过用户服务创建默认管理员，邮箱已存在时跳过
+// 管理员创建为邮箱已验证状态，不发送验证邮件；返回是否新建了管理员，密码同样需满足密码策略
+func Admin(ctx context.Context, users services.UserService, cfg config.SeedAdminConfig) (bool, error) {
+	if cfg.Password == "" {
+		return false, ErrAdminPasswordRequired
+	}
+
+	_, err := users.CreateUser(ctx, dto.CreateUserInput{
+		Name:     cfg.Name,
+		Email:    cfg.Email,
+		Password: cfg.Password,
+		Role:     AdminRole,
+		Verified: true,
+	})
+	if err != nil {
+		if apperrors.AsError(err).Code == apperrors.CodeUserEmailTaken {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
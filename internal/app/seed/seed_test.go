@@ -0,0 +1,90 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/services"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// stubUserService 记录创建请求的用户服务桩，err不为空时创建失败
+type stubUserService struct {
+	services.UserService
+	inputs []dto.CreateUserInput
+	err    error
+}
+
+func (s *stubUserService) CreateUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error) {
+	s.inputs = append(s.inputs, input)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &models.User{Name: input.Name, Email: input.Email, Role: input.Role}, nil
+}
+
+func TestAdmin(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.SeedAdminConfig{
+		Name:     "Administrator",
+		Email:    "admin@example.com",
+		Password: "S3cure-Passphrase",
+	}
+
+	// 邮箱不存在时以管理员角色创建
+	t.Run("Creates", func(t *testing.T) {
+		users := &stubUserService{}
+
+		created, err := Admin(ctx, users, cfg)
+		require.NoError(t, err)
+		assert.True(t, created)
+		require.Len(t, users.inputs, 1)
+		assert.Equal(t, dto.CreateUserInput{
+			Name:     cfg.Name,
+			Email:    cfg.Email,
+			Password: cfg.Password,
+			Role:     AdminRole,
+			Verified: true,
+		}, users.inputs[0])
+	})
+
+	// 邮箱已存在时跳过，不返回错误
+	t.Run("SkipsExisting", func(t *testing.T) {
+		users := &stubUserService{
+			err: apperrors.ConflictError("邮箱已被注册", nil).WithCode(apperrors.CodeUserEmailTaken),
+		}
+
+		created, err := Admin(ctx, users, cfg)
+		require.NoError(t, err)
+		assert.False(t, created)
+	})
+
+	// 未配置密码时不调用用户服务
+	t.Run("PasswordRequired", func(t *testing.T) {
+		users := &stubUserService{}
+		noPassword := cfg
+		noPassword.Password = ""
+
+		created, err := Admin(ctx, users, noPassword)
+		assert.ErrorIs(t, err, ErrAdminPasswordRequired)
+		assert.False(t, created)
+		assert.Empty(t, users.inputs)
+	})
+
+	// 其他错误原样返回
+	t.Run("OtherError", func(t *testing.T) {
+		errBoom := errors.New("db down")
+		users := &stubUserService{err: errBoom}
+
+		created, err := Admin(ctx, users, cfg)
+		assert.ErrorIs(t, err, errBoom)
+		assert.False(t, created)
+	})
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/repository"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// auditRedacted 敏感字段在审计日志中的占位值
+const auditRedacted = "[REDACTED]"
+
+// auditSensitiveFields 只记录是否变更、不记录取值的字段
+var auditSensitiveFields = map[string]struct{}{
+	"password": {},
+}
+
+// AuditEntry 一次待记录的数据变更
+// Before 和 After 为变更前后的字段快照，创建时 Before 为空，删除时 After 为空
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   uint
+	Before     map[string]interface{}
+	After      map[string]interface{}
+}
+
+// AuditService 审计服务接口
+type AuditService interface {
+	Record(ctx context.Context, tx *gorm.DB, entry AuditEntry) error
+	List(ctx context.Context, page, pageSize int, filter dto.AuditLogFilter) ([]*models.AuditLog, int64, error)
+}
+
+// auditService 审计服务实现
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditService 创建审计服务
+func NewAuditService(ar repository.AuditLogRepository) AuditService {
+	return &auditService{
+		auditRepo: ar,
+	}
+}
+
+// Record 在业务事务中写入审计日志，写入失败时事务整体回滚，保证变更与审计记录一致
+// 操作者和请求ID从上下文中读取，只记录前后取值不同的字段
+func (s *auditService) Record(ctx context.Context, tx *gorm.DB, entry AuditEntry) error {
+	log := &models.AuditLog{
+		ActorID:    auditActorID(ctx),
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Changes:    diffAuditSnapshots(entry.Before, entry.After),
+		RequestID:  logger.GetRequestID(ctx),
+		CreatedAt:  time.Now(),
+	}
+	return s.auditRepo.Create(ctx, tx, log)
+}
+
+// List 分页获取审计日志
+func (s *auditService) List(ctx context.Context, page, pageSize int, filter dto.AuditLogFilter) ([]*models.AuditLog, int64, error) {
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		err := apperrors.ValidationError("时间范围无效", nil)
+		err.Fields = []apperrors.FieldError{{
+			Field:   "until",
+			Tag:     "gtfield",
+			Message: "截止时间必须晚于起始时间",
+		}}
+		return nil, 0, err
+	}
+
+	return s.auditRepo.List(ctx, page, pageSize, filter)
+}
+
+// auditActorID 从上下文中读取当前认证用户ID，未认证时返回nil
+func auditActorID(ctx context.Context) *uint {
+	id, err := strconv.ParseUint(logger.GetUserID(ctx), 10, 32)
+	if err != nil {
+		return nil
+	}
+	actorID := uint(id)
+	return &actorID
+}
+
+// diffAuditSnapshots 对比前后快照，返回取值不同的字段，敏感字段的取值替换为占位值
+func diffAuditSnapshots(before, after map[string]interface{}) models.AuditChanges {
+	changes := make(models.AuditChanges)
+	for field, value := range before {
+		if next, ok := after[field]; ok && reflect.DeepEqual(value, next) {
+			continue
+		}
+		changes[field] = models.AuditChange{Before: value, After: after[field]}
+	}
+	for field, value := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = models.AuditChange{After: value}
+		}
+	}
+
+	for field, change := range changes {
+		if _, ok := auditSensitiveFields[field]; !ok {
+			continue
+		}
+		if change.Before != nil {
+			change.Before = auditRedacted
+		}
+		if change.After != nil {
+			change.After = auditRedacted
+		}
+		changes[field] = change
+	}
+	return changes
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// MockAuditLogRepository 是 AuditLogRepository 的模拟实现
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) Create(ctx context.Context, tx *gorm.DB, log *models.AuditLog) error {
+	args := m.Called(ctx, tx, log)
+	return args.Error(0)
+}
+
+func (m *MockAuditLogRepository) List(ctx context.Context, page, pageSize int, filter dto.AuditLogFilter) ([]*models.AuditLog, int64, error) {
+	args := m.Called(ctx, page, pageSize, filter)
+	if args.Get(0) == nil {
+		return nil, args.Get(1).(int64), args.Error(2)
+	}
+	return args.Get(0).([]*models.AuditLog), args.Get(1).(int64), args.Error(2)
+}
+
+func TestAuditService_Record(t *testing.T) {
+	// 创建时记录全部字段，密码脱敏，操作者和请求ID取自上下文
+	t.Run("Create", func(t *testing.T) {
+		repo := new(MockAuditLogRepository)
+		service := NewAuditService(repo)
+		ctx := logger.WithRequestID(logger.WithUserID(context.Background(), "7"), "req-1")
+
+		var recorded *models.AuditLog
+		repo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.AuditLog")).
+			Run(func(args mock.Arguments) { recorded = args.Get(2).(*models.AuditLog) }).
+			Return(nil)
+
+		err := service.Record(ctx, nil, AuditEntry{
+			Action:     models.AuditActionUserCreate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   1,
+			After:      map[string]interface{}{"name": "Test User", "password": "hashed"},
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, recorded.ActorID)
+		assert.Equal(t, uint(7), *recorded.ActorID)
+		assert.Equal(t, "req-1", recorded.RequestID)
+		assert.Equal(t, uint(1), recorded.EntityID)
+		assert.Equal(t, models.AuditChanges{
+			"name":     {After: "Test User"},
+			"password": {After: auditRedacted},
+		}, recorded.Changes)
+		assert.False(t, recorded.CreatedAt.IsZero())
+	})
+
+	// 更新时只记录取值变化的字段，未认证时操作者为空
+	t.Run("UpdateOnlyChanged", func(t *testing.T) {
+		repo := new(MockAuditLogRepository)
+		service := NewAuditService(repo)
+		ctx := context.Background()
+
+		var recorded *models.AuditLog
+		repo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.AuditLog")).
+			Run(func(args mock.Arguments) { recorded = args.Get(2).(*models.AuditLog) }).
+			Return(nil)
+
+		err := service.Record(ctx, nil, AuditEntry{
+			Action:     models.AuditActionUserUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   1,
+			Before:     map[string]interface{}{"name": "Old", "email": "a@example.com", "password": "old-hash"},
+			After:      map[string]interface{}{"name": "New", "email": "a@example.com", "password": "new-hash"},
+		})
+
+		require.NoError(t, err)
+		assert.Nil(t, recorded.ActorID)
+		assert.Equal(t, models.AuditChanges{
+			"name":     {Before: "Old", After: "New"},
+			"password": {Before: auditRedacted, After: auditRedacted},
+		}, recorded.Changes)
+	})
+}
+
+func TestAuditService_List(t *testing.T) {
+	ctx := context.Background()
+
+	// 截止时间早于起始时间时拒绝查询
+	t.Run("InvalidRange", func(t *testing.T) {
+		service := NewAuditService(new(MockAuditLogRepository))
+		since := time.Now()
+		until := since.Add(-time.Hour)
+
+		_, _, err := service.List(ctx, 1, 10, dto.AuditLogFilter{Since: &since, Until: &until})
+
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+	})
+
+	// 筛选条件原样传给仓库层
+	t.Run("Filter", func(t *testing.T) {
+		repo := new(MockAuditLogRepository)
+		service := NewAuditService(repo)
+		filter := dto.AuditLogFilter{Action: models.AuditActionUserDelete}
+		logs := []*models.AuditLog{{ID: 2, Action: models.AuditActionUserDelete}}
+		repo.On("List", ctx, 1, 10, filter).Return(logs, int64(1), nil)
+
+		result, total, err := service.List(ctx, 1, 10, filter)
+
+		require.NoError(t, err)
+		assert.Equal(t, logs, result)
+		assert.Equal(t, int64(1), total)
+		repo.AssertExpectations(t)
+	})
+}
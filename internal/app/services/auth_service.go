@@ -2,32 +2,84 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
 	"github.com/vadxq/go-rest-starter/internal/app/repository"
 	"github.com/vadxq/go-rest-starter/pkg/cache"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
 	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
 )
 
 const (
 	// 令牌缓存键前缀
 	tokenCachePrefix = "token:"
 
-	// 令牌黑名单缓存键前缀
-	tokenBlacklistPrefix = "blacklist:"
+	// 刷新令牌家族缓存键前缀
+	refreshFamilyPrefix = "refresh_family:"
+
+	// 登录失败计数缓存键前缀
+	loginAttemptsPrefix = "login_attempts:"
+
+	// 会话撤销时间缓存键前缀，早于该时间登录的令牌家族不能再刷新
+	sessionsRevokedPrefix = "sessions_revoked:"
+
+	// 用户会话列表缓存键前缀，保存用户所有令牌家族ID
+	userSessionsPrefix = "user_sessions:"
+
+	// 已使用的刷新令牌缓存键前缀，按jti记录，同一刷新令牌只有第一次使用能占用
+	refreshUsedPrefix = "refresh_used:"
 )
 
+// LockoutConfig 登录失败锁定配置
+type LockoutConfig struct {
+	// 窗口期内允许的登录失败次数，达到后锁定账户，小于等于0时不启用锁定
+	Threshold int
+	// 失败次数统计窗口，从首次失败开始计算
+	Window time.Duration
+	// 锁定时长，锁定期间不再校验密码
+	Duration time.Duration
+}
+
+// DefaultLockoutConfig 默认登录锁定配置
+var DefaultLockoutConfig = LockoutConfig{
+	Threshold: 5,
+	Window:    15 * time.Minute,
+	Duration:  15 * time.Minute,
+}
+
+// refreshFamily 刷新令牌家族状态
+// 每次登录创建一个家族，即一个会话，家族内只有最新签发的刷新令牌(jti)有效
+type refreshFamily struct {
+	UserID    uint      `json:"user_id"`
+	JTI       string    `json:"jti"`
+	CreatedAt time.Time `json:"created_at"` // 登录时间，轮换时保持不变
+	UserAgent string    `json:"user_agent,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+}
+
 // AuthService 认证服务接口
 type AuthService interface {
 	Login(ctx context.Context, req dto.LoginRequest) (*dto.LoginResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenResponse, error)
 	Logout(ctx context.Context, accessToken string) error
+	Introspect(ctx context.Context, token string) (*dto.IntrospectResponse, error)
+	ListSessions(ctx context.Context, accessToken string) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, accessToken, sessionID string) error
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
 }
 
 // authService 认证服务实现
@@ -37,16 +89,54 @@ type authService struct {
 	db        *gorm.DB
 	jwtConfig *jwt.Config
 	cache     cache.Cache
+	counter   cache.AtomicCache // 登录失败计数和刷新令牌占用，缓存不支持原子操作时为空，不启用登录锁定，刷新令牌只做非原子的轮换校验
+	blacklist *jwt.Blacklist
+	lockout   *LockoutConfig
+	hasher    utils.PasswordHasher
+	// passwordPolicy 重置密码时的强度策略
+	passwordPolicy *utils.PasswordPolicy
+	// requireVerified 邮箱未验证的用户不能登录
+	requireVerified bool
+	resetter        *PasswordResetter
 }
 
-// NewAuthService 创建认证服务
-func NewAuthService(ur repository.UserRepository, v *validator.Validate, db *gorm.DB, jwtConfig *jwt.Config, c cache.Cache) AuthService {
+// NewAuthService 创建认证服务，lockout为空时使用默认锁定配置，hasher为空时使用 utils.DefaultPasswordHasher，
+// policy为空时使用 utils.DefaultPasswordPolicy
+// 缓存不可用（nil或 cache.NullCache）时不启用登录锁定（缓存还需实现 cache.AtomicCache）、刷新令牌轮换校验、令牌黑名单、会话管理和重置密码后的会话撤销；
+// requireVerified为true时邮箱未验证的用户不能登录；resetter为空时不能发送密码重置邮件
+func NewAuthService(ur repository.UserRepository, v *validator.Validate, db *gorm.DB, jwtConfig *jwt.Config, c cache.Cache, lockout *LockoutConfig, hasher utils.PasswordHasher, policy *utils.PasswordPolicy, requireVerified bool, resetter *PasswordResetter) AuthService {
+	if !cache.Available(c) {
+		c = nil
+	}
+	if lockout == nil {
+		lockout = &DefaultLockoutConfig
+	}
+	if hasher == nil {
+		hasher = utils.DefaultPasswordHasher
+	}
+	if policy == nil {
+		policy = &utils.DefaultPasswordPolicy
+	}
+	if resetter == nil {
+		resetter = NewPasswordResetter(jwtConfig, nil, nil)
+	}
+
+	counter, _ := c.(cache.AtomicCache)
+
 	return &authService{
 		userRepo:  ur,
 		validator: v,
 		db:        db,
 		jwtConfig: jwtConfig,
 		cache:     c,
+		counter:   counter,
+		blacklist: jwt.NewBlacklist(c, jwtConfig.LeewayDuration),
+		lockout:   lockout,
+		hasher:    hasher,
+
+		passwordPolicy:  policy,
+		requireVerified: requireVerified,
+		resetter:        resetter,
 	}
 }
 
@@ -57,38 +147,52 @@ func (s *authService) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 		return nil, apperrors.ValidationError("输入数据验证失败", err)
 	}
 
+	// 账户锁定期间直接拒绝，不再校验密码
+	if s.isLocked(ctx, req.Email) {
+		return nil, apperrors.TooManyRequestsError("登录失败次数过多，账户已临时锁定，请稍后重试", nil).WithCode(apperrors.CodeAuthAccountLocked)
+	}
+
 	// 获取用户
 	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		// 不管是没找到还是数据库错误，都返回相同的错误信息，避免枚举攻击
-		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil)
+		s.recordLoginFailure(ctx, req.Email)
+		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil).WithCode(apperrors.CodeAuthInvalidCredentials)
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil)
+	// 验证密码，按存储哈希的前缀选择算法
+	if err := s.hasher.Verify(user.Password, req.Password); err != nil {
+		s.recordLoginFailure(ctx, req.Email)
+		return nil, apperrors.UnauthorizedError("邮箱或密码错误", nil).WithCode(apperrors.CodeAuthInvalidCredentials)
 	}
 
-	// 生成访问令牌
-	accessToken, err := jwt.GenerateAccessToken(user.ID, user.Role, s.jwtConfig)
-	if err != nil {
-		return nil, apperrors.InternalError("生成访问令牌失败", err)
+	// 登录成功，清除失败计数
+	s.resetLoginFailures(ctx, req.Email)
+
+	// 密码正确后才提示邮箱未验证，避免泄露账户是否存在
+	if s.requireVerified && !user.Verified {
+		return nil, apperrors.ForbiddenError("邮箱未验证，请先完成邮箱验证", nil).WithCode(apperrors.CodeAuthEmailNotVerified)
 	}
 
-	// 生成刷新令牌
-	refreshToken, err := jwt.GenerateRefreshToken(user.ID, s.jwtConfig)
+	// 哈希算法或成本已过时时，用当前配置重新计算并保存
+	s.upgradePasswordHash(ctx, user, req.Password)
+
+	// 创建新的令牌家族
+	familyID, err := utils.GenerateRandomString(16)
 	if err != nil {
-		return nil, apperrors.InternalError("生成刷新令牌失败", err)
+		return nil, apperrors.InternalError("生成令牌家族ID失败", err)
 	}
 
-	// 缓存令牌 - 可以用于快速验证或令牌追踪
-	tokenKey := fmt.Sprintf("%s%d", tokenCachePrefix, user.ID)
-	if s.cache != nil {
-		_ = s.cache.SetObject(ctx, tokenKey, map[string]string{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-		}, s.jwtConfig.AccessTokenExp)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user.Role, familyID, refreshFamily{
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+		UserAgent: req.UserAgent,
+		IP:        req.IP,
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.trackSession(ctx, user.ID, familyID)
 
 	return &dto.LoginResponse{
 		AccessToken:  accessToken,
@@ -100,28 +204,149 @@ func (s *authService) Login(ctx context.Context, req dto.LoginRequest) (*dto.Log
 			Name:      user.Name,
 			Email:     user.Email,
 			Role:      user.Role,
+			AvatarURL: user.AvatarURL,
 			CreatedAt: user.CreatedAt,
 			UpdatedAt: user.UpdatedAt,
+			Version:   user.Version,
+			Verified:  user.Verified,
 		},
 	}, nil
 }
 
-// RefreshToken 刷新令牌
-func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenResponse, error) {
-	// 检查令牌是否在黑名单中
-	blacklistKey := fmt.Sprintf("%s%s", tokenBlacklistPrefix, refreshToken)
-	var blacklisted bool
-	if s.cache != nil {
-		err := s.cache.GetObject(ctx, blacklistKey, &blacklisted)
-		if err == nil && blacklisted {
-			return nil, apperrors.UnauthorizedError("刷新令牌已被撤销", nil)
+// loginAttemptsKey 登录失败计数缓存键，邮箱不区分大小写
+func loginAttemptsKey(email string) string {
+	return loginAttemptsPrefix + strings.ToLower(strings.TrimSpace(email))
+}
+
+// lockoutEnabled 是否启用登录锁定
+func (s *authService) lockoutEnabled() bool {
+	return s.counter != nil && s.lockout.Threshold > 0
+}
+
+// upgradePasswordHash 密码校验通过后，若存储的哈希不符合当前配置则重新计算并保存
+// 失败只记录日志，不影响本次登录，下次登录时会再次尝试
+func (s *authService) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		slog.Warn("重新计算密码哈希失败", "user_id", user.ID, "error", err)
+		return
+	}
+	if err := s.userRepo.UpdatePasswordHash(ctx, s.db, user.ID, user.Password, hash); err != nil {
+		slog.Warn("保存密码哈希失败", "user_id", user.ID, "error", err)
+		return
+	}
+	user.Password = hash
+}
+
+// isLocked 检查邮箱对应的账户是否处于锁定状态
+func (s *authService) isLocked(ctx context.Context, email string) bool {
+	if !s.lockoutEnabled() {
+		return false
+	}
+
+	data, err := s.cache.Get(ctx, loginAttemptsKey(email))
+	if err != nil {
+		return false
+	}
+	failures, err := strconv.Atoi(string(data))
+	return err == nil && failures >= s.lockout.Threshold
+}
+
+// recordLoginFailure 记录一次登录失败，达到阈值后锁定账户
+// 计数原子递增，并发的失败请求不会互相覆盖；计数在首次失败后的统计窗口结束时过期，
+// 达到阈值时改为在锁定时长结束后过期
+func (s *authService) recordLoginFailure(ctx context.Context, email string) {
+	if !s.lockoutEnabled() {
+		return
+	}
+
+	key := loginAttemptsKey(email)
+	failures, err := s.counter.Increment(ctx, key, s.lockout.Window)
+	if err != nil {
+		slog.Warn("记录登录失败次数失败", "email", email, "error", err)
+		return
+	}
+
+	// 只在恰好达到阈值时延长过期时间，锁定期间的失败不会继续延长锁定
+	if failures == int64(s.lockout.Threshold) {
+		slog.Warn("登录失败次数过多，账户已临时锁定",
+			"email", email,
+			"failures", failures,
+			"duration", s.lockout.Duration,
+		)
+		if err := s.cache.Set(ctx, key, []byte(strconv.FormatInt(failures, 10)), s.lockout.Duration); err != nil {
+			slog.Warn("锁定账户失败", "email", email, "error", err)
 		}
 	}
+}
+
+// resetLoginFailures 清除登录失败计数
+func (s *authService) resetLoginFailures(ctx context.Context, email string) {
+	if !s.lockoutEnabled() {
+		return
+	}
+	_ = s.cache.Delete(ctx, loginAttemptsKey(email))
+}
 
+// RefreshToken 刷新令牌
+// 每次刷新都会轮换刷新令牌，旧令牌立即失效；若已使用过的刷新令牌被再次提交（包括并发提交同一令牌），
+// 视为令牌被盗用，撤销整个令牌家族及其已签发的访问令牌
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*dto.TokenResponse, error) {
 	// 解析刷新令牌
-	userId, err := jwt.ParseRefreshToken(refreshToken, s.jwtConfig.Secret)
+	claims, err := jwt.ParseRefreshToken(refreshToken, s.jwtConfig)
 	if err != nil {
-		return nil, apperrors.UnauthorizedError("无效的刷新令牌", nil)
+		return nil, apperrors.UnauthorizedError("无效的刷新令牌", nil).WithCode(apperrors.CodeAuthRefreshTokenInvalid)
+	}
+
+	userId, err := claims.UserID()
+	if err != nil {
+		return nil, apperrors.UnauthorizedError("无效的刷新令牌", nil).WithCode(apperrors.CodeAuthRefreshTokenInvalid)
+	}
+
+	// 校验令牌家族状态
+	family := refreshFamily{UserID: userId, CreatedAt: time.Now()}
+	if s.cache != nil {
+		// 家族已因重放被撤销，避免与撤销并发的轮换重新写入家族状态后继续刷新
+		if s.blacklist.IsRevoked(ctx, &jwt.Claims{FamilyID: claims.FamilyID}) {
+			return nil, apperrors.UnauthorizedError("刷新令牌已被撤销", nil).WithCode(apperrors.CodeAuthRefreshTokenRevoked)
+		}
+
+		familyKey := refreshFamilyPrefix + claims.FamilyID
+		if err := s.cache.GetObject(ctx, familyKey, &family); err != nil {
+			if errors.Is(err, cache.ErrNotFound) {
+				return nil, apperrors.UnauthorizedError("刷新令牌已被撤销", nil).WithCode(apperrors.CodeAuthRefreshTokenRevoked)
+			}
+			return nil, apperrors.InternalError("验证刷新令牌失败", err)
+		}
+
+		replayed := family.UserID != userId || family.JTI != claims.ID
+		if !replayed {
+			// 读取和轮换之间可能有并发请求提交同一令牌，原子占用jti，只有一个请求能继续轮换
+			claimed, err := s.claimRefreshToken(ctx, claims.ID)
+			if err != nil {
+				return nil, apperrors.InternalError("验证刷新令牌失败", err)
+			}
+			replayed = !claimed
+		}
+		if replayed {
+			// 已轮换的令牌被重放，撤销整个家族
+			s.revokeFamily(ctx, userId, claims.FamilyID)
+			slog.Warn("检测到刷新令牌重放，已撤销令牌家族",
+				"user_id", userId,
+				"family_id", claims.FamilyID,
+			)
+			return nil, apperrors.UnauthorizedError("刷新令牌已被撤销", nil).WithCode(apperrors.CodeAuthRefreshTokenRevoked)
+		}
+
+		// 登录后用户的会话已被撤销（如重置密码），撤销家族
+		if s.sessionsRevoked(ctx, userId, family.CreatedAt) {
+			_ = s.cache.Delete(ctx, familyKey)
+			return nil, apperrors.UnauthorizedError("刷新令牌已被撤销", nil).WithCode(apperrors.CodeAuthRefreshTokenRevoked)
+		}
 	}
 
 	// 用户ID转为字符串
@@ -133,44 +358,375 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*d
 		return nil, apperrors.UnauthorizedError("用户不存在", nil)
 	}
 
-	// 生成新的访问令牌
-	accessToken, err := jwt.GenerateAccessToken(user.ID, user.Role, s.jwtConfig)
+	// 在同一家族内签发新的访问令牌和刷新令牌
+	accessToken, newRefreshToken, err := s.issueTokens(ctx, user.Role, claims.FamilyID, family)
 	if err != nil {
-		return nil, apperrors.InternalError("生成访问令牌失败", err)
+		return nil, err
+	}
+
+	return &dto.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.jwtConfig.AccessTokenExp.Seconds()),
+		TokenType:    "Bearer",
+	}, nil
+}
+
+// claimRefreshToken 占用刷新令牌的jti，返回是否为第一次使用；缓存不支持原子操作时总是返回true
+// 占用记录保留刷新令牌的有效期，之后令牌本身已过期
+func (s *authService) claimRefreshToken(ctx context.Context, jti string) (bool, error) {
+	if s.counter == nil {
+		return true, nil
+	}
+	return s.counter.SetObjectIfAbsent(ctx, refreshUsedPrefix+jti, true, s.jwtConfig.RefreshTokenExp)
+}
+
+// revokeFamily 撤销令牌家族：删除家族状态使刷新令牌失效，并将家族加入黑名单使已签发的访问令牌失效
+// 黑名单记录保留刷新令牌的有效期，期间家族内的刷新令牌也不能再使用
+func (s *authService) revokeFamily(ctx context.Context, userID uint, familyID string) {
+	if err := s.blacklist.RevokeFamily(ctx, familyID, s.jwtConfig.RefreshTokenExp); err != nil {
+		slog.Warn("令牌家族加入黑名单失败", "user_id", userID, "family_id", familyID, "error", err)
+	}
+	_ = s.cache.Delete(ctx, refreshFamilyPrefix+familyID)
+	s.untrackSession(ctx, userID, familyID)
+}
+
+// issueTokens 在指定令牌家族内签发访问令牌和刷新令牌，并记录家族当前有效的jti，
+// family中的用户ID、登录时间和设备信息原样保存
+func (s *authService) issueTokens(ctx context.Context, role, familyID string, family refreshFamily) (string, string, error) {
+	userID := family.UserID
+	jti, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", "", apperrors.InternalError("生成令牌ID失败", err)
+	}
+
+	// 生成访问令牌
+	accessToken, err := jwt.GenerateAccessToken(userID, role, familyID, s.jwtConfig)
+	if err != nil {
+		return "", "", apperrors.InternalError("生成访问令牌失败", err)
+	}
+
+	// 生成刷新令牌
+	refreshToken, err := jwt.GenerateRefreshToken(userID, familyID, jti, s.jwtConfig)
+	if err != nil {
+		return "", "", apperrors.InternalError("生成刷新令牌失败", err)
 	}
 
-	// 更新缓存中的令牌
-	tokenKey := fmt.Sprintf("%s%d", tokenCachePrefix, user.ID)
 	if s.cache != nil {
+		// 更新家族当前有效的jti，旧的刷新令牌随之失效
+		familyKey := refreshFamilyPrefix + familyID
+		family.JTI = jti
+		if err := s.cache.SetObject(ctx, familyKey, family, s.jwtConfig.RefreshTokenExp); err != nil {
+			return "", "", apperrors.InternalError("保存刷新令牌失败", err)
+		}
+
+		// 缓存令牌 - 可以用于快速验证或令牌追踪
+		tokenKey := fmt.Sprintf("%s%d", tokenCachePrefix, userID)
 		_ = s.cache.SetObject(ctx, tokenKey, map[string]string{
-			"access_token": accessToken,
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
 		}, s.jwtConfig.AccessTokenExp)
 	}
 
-	return &dto.TokenResponse{
-		AccessToken: accessToken,
-		ExpiresIn:   int64(s.jwtConfig.AccessTokenExp.Seconds()),
-		TokenType:   "Bearer",
-	}, nil
+	return accessToken, refreshToken, nil
 }
 
 // Logout 用户登出
 func (s *authService) Logout(ctx context.Context, accessToken string) error {
 	// 解析令牌以获取用户ID
-	claims, err := jwt.ParseToken(accessToken, s.jwtConfig.Secret)
+	claims, err := jwt.ParseToken(accessToken, s.jwtConfig)
 	if err != nil {
-		return apperrors.UnauthorizedError("无效的访问令牌", nil)
+		return apperrors.UnauthorizedError("无效的访问令牌", nil).WithCode(apperrors.CodeAuthTokenInvalid)
 	}
 
-	// 将令牌加入黑名单
+	// 将令牌加入黑名单，JWTAuth 中间件随即拒绝该令牌
 	if s.cache != nil {
-		blacklistKey := fmt.Sprintf("%s%s", tokenBlacklistPrefix, accessToken)
-		_ = s.cache.SetObject(ctx, blacklistKey, true, s.jwtConfig.AccessTokenExp)
+		if err := s.blacklist.Revoke(ctx, claims); err != nil {
+			slog.Warn("访问令牌加入黑名单失败", "user_id", claims.UserID, "error", err)
+		}
 
 		// 清除用户令牌缓存
 		tokenKey := fmt.Sprintf("%s%d", tokenCachePrefix, claims.UserID)
 		_ = s.cache.Delete(ctx, tokenKey)
+
+		// 撤销本次登录的令牌家族，使对应的刷新令牌失效
+		if claims.FamilyID != "" {
+			_ = s.cache.Delete(ctx, refreshFamilyPrefix+claims.FamilyID)
+			s.untrackSession(ctx, claims.UserID, claims.FamilyID)
+		}
+	}
+
+	return nil
+}
+
+// Introspect 返回访问令牌的状态，供网关等服务校验令牌
+// 签名无效、已过期或已登出（在黑名单中）的令牌返回 active=false，不返回错误
+func (s *authService) Introspect(ctx context.Context, token string) (*dto.IntrospectResponse, error) {
+	inactive := &dto.IntrospectResponse{Active: false}
+
+	claims, err := jwt.ParseToken(token, s.jwtConfig)
+	if err != nil {
+		return inactive, nil
 	}
 
+	if s.blacklist.IsRevoked(ctx, claims) {
+		return inactive, nil
+	}
+
+	resp := &dto.IntrospectResponse{
+		Active:    true,
+		Subject:   strconv.FormatUint(uint64(claims.UserID), 10),
+		Role:      claims.Role,
+		Scope:     claims.Scope,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		TokenType: "Bearer",
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return resp, nil
+}
+
+// ForgotPassword 向邮箱对应的用户发送密码重置邮件
+// 无论邮箱是否存在都返回成功，避免枚举注册邮箱；仅消息队列不可用时返回错误，与邮箱是否存在无关
+func (s *authService) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.ValidationError("输入数据验证失败", err)
+	}
+	if !s.resetter.CanSend() {
+		return apperrors.ServiceUnavailableError("消息队列不可用，无法发送密码重置邮件", nil)
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if apperrors.AsError(err).Type != apperrors.ErrorTypeNotFound {
+			slog.Warn("查询密码重置用户失败", "error", err)
+		}
+		return nil
+	}
+
+	if err := s.resetter.Send(ctx, user); err != nil {
+		slog.Warn("发送密码重置邮件失败", "user_id", user.ID, "error", err)
+	}
 	return nil
 }
+
+// ResetPassword 使用重置令牌设置新密码，并撤销用户已有的会话及其已签发的访问令牌
+// 令牌绑定签发时的密码哈希，重置成功后同一令牌即失效
+func (s *authService) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return apperrors.ValidationError("输入数据验证失败", err)
+	}
+
+	userID, fingerprint, err := s.resetter.Parse(req.Token)
+	if err != nil {
+		return err
+	}
+
+	// 校验密码强度
+	if err := utils.ValidatePasswordStrength(req.Password, *s.passwordPolicy); err != nil {
+		return err
+	}
+
+	invalid := apperrors.BadRequestError("无效的密码重置令牌", nil).WithCode(apperrors.CodeAuthResetTokenInvalid)
+	user, err := s.userRepo.GetByID(ctx, strconv.FormatUint(uint64(userID), 10))
+	if err != nil {
+		if apperrors.AsError(err).Type == apperrors.ErrorTypeNotFound {
+			return invalid
+		}
+		return err
+	}
+	// 签发后密码已修改，说明令牌已使用过
+	if passwordFingerprint(user.Password) != fingerprint {
+		return invalid
+	}
+
+	hash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return apperrors.InternalError("密码加密失败", err)
+	}
+	// 仅在密码仍为签发时的哈希时写入，同一令牌并发使用时只有一次成功
+	updated, err := s.userRepo.ResetPassword(ctx, s.db, user.ID, user.Password, hash)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return invalid
+	}
+
+	s.revokeSessions(ctx, user.ID)
+	s.resetLoginFailures(ctx, user.Email)
+	if s.cache != nil {
+		// 版本号已变化，清除用户缓存
+		_ = s.cache.Delete(ctx, getUserCacheKey(strconv.FormatUint(uint64(user.ID), 10)))
+		_ = s.cache.DeleteByPattern(ctx, userListCacheKey+":*")
+	}
+
+	slog.Info("用户已重置密码", "user_id", user.ID)
+	return nil
+}
+
+// revokeSessions 撤销用户在此之前登录的所有会话：会话列表中的令牌家族加入黑名单并删除，
+// 家族内的访问令牌随即被 JWTAuth 中间件拒绝；另记录撤销时间，未记入会话列表的家族同样不能再刷新
+// 令牌家族在刷新令牌有效期内未刷新即过期，撤销记录保留相同时长即可
+func (s *authService) revokeSessions(ctx context.Context, userID uint) {
+	if s.cache == nil {
+		return
+	}
+
+	ids, _ := s.loadSessions(ctx, userID)
+	for _, id := range ids {
+		if err := s.blacklist.RevokeFamily(ctx, id, s.jwtConfig.AccessTokenExp); err != nil {
+			slog.Warn("撤销会话的访问令牌失败", "user_id", userID, "family_id", id, "error", err)
+		}
+		_ = s.cache.Delete(ctx, refreshFamilyPrefix+id)
+		s.untrackSession(ctx, userID, id)
+	}
+
+	key := fmt.Sprintf("%s%d", sessionsRevokedPrefix, userID)
+	if err := s.cache.SetObject(ctx, key, time.Now(), s.jwtConfig.RefreshTokenExp); err != nil {
+		slog.Warn("撤销用户会话失败", "user_id", userID, "error", err)
+	}
+	_ = s.cache.Delete(ctx, fmt.Sprintf("%s%d", tokenCachePrefix, userID))
+}
+
+// sessionsRevoked 在createdAt登录的令牌家族是否已被撤销
+func (s *authService) sessionsRevoked(ctx context.Context, userID uint, createdAt time.Time) bool {
+	var revokedAt time.Time
+	if err := s.cache.GetObject(ctx, fmt.Sprintf("%s%d", sessionsRevokedPrefix, userID), &revokedAt); err != nil {
+		return false
+	}
+	return !createdAt.After(revokedAt)
+}
+
+// ListSessions 返回访问令牌所属用户的活跃会话（未过期且未撤销的令牌家族），最近登录的在前
+// 缓存不可用时不记录会话，返回空列表
+func (s *authService) ListSessions(ctx context.Context, accessToken string) ([]dto.SessionResponse, error) {
+	claims, err := jwt.ParseToken(accessToken, s.jwtConfig)
+	if err != nil {
+		return nil, apperrors.UnauthorizedError("无效的访问令牌", nil).WithCode(apperrors.CodeAuthTokenInvalid)
+	}
+
+	sessions := []dto.SessionResponse{}
+	if s.cache == nil {
+		return sessions, nil
+	}
+
+	ids, families := s.loadSessions(ctx, claims.UserID)
+	for _, id := range ids {
+		family := families[id]
+		sessions = append(sessions, dto.SessionResponse{
+			ID:        id,
+			UserAgent: family.UserAgent,
+			IP:        family.IP,
+			IssuedAt:  family.CreatedAt,
+			Current:   id == claims.FamilyID,
+		})
+	}
+	slices.SortStableFunc(sessions, func(a, b dto.SessionResponse) int {
+		return b.IssuedAt.Compare(a.IssuedAt)
+	})
+	return sessions, nil
+}
+
+// RevokeSession 撤销访问令牌所属用户的指定会话：删除令牌家族使其刷新令牌失效，
+// 并将家族加入黑名单，家族内尚未过期的访问令牌随即被 JWTAuth 中间件拒绝
+func (s *authService) RevokeSession(ctx context.Context, accessToken, sessionID string) error {
+	claims, err := jwt.ParseToken(accessToken, s.jwtConfig)
+	if err != nil {
+		return apperrors.UnauthorizedError("无效的访问令牌", nil).WithCode(apperrors.CodeAuthTokenInvalid)
+	}
+	if s.cache == nil {
+		return apperrors.NotFoundError("会话", nil)
+	}
+
+	// 只能撤销自己的会话，其他用户的会话与不存在的会话返回相同的错误
+	familyKey := refreshFamilyPrefix + sessionID
+	var family refreshFamily
+	if err := s.cache.GetObject(ctx, familyKey, &family); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return apperrors.NotFoundError("会话", nil)
+		}
+		return apperrors.InternalError("查询会话失败", err)
+	}
+	if family.UserID != claims.UserID {
+		return apperrors.NotFoundError("会话", nil)
+	}
+
+	if err := s.blacklist.RevokeFamily(ctx, sessionID, s.jwtConfig.AccessTokenExp); err != nil {
+		return apperrors.InternalError("撤销会话失败", err)
+	}
+	if err := s.cache.Delete(ctx, familyKey); err != nil {
+		return apperrors.InternalError("撤销会话失败", err)
+	}
+	s.untrackSession(ctx, claims.UserID, sessionID)
+	return nil
+}
+
+// loadSessions 读取用户会话列表，返回仍然有效的令牌家族ID及其状态，已过期或已撤销的家族被跳过
+func (s *authService) loadSessions(ctx context.Context, userID uint) ([]string, map[string]refreshFamily) {
+	var ids []string
+	if err := s.cache.GetObject(ctx, fmt.Sprintf("%s%d", userSessionsPrefix, userID), &ids); err != nil || len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = refreshFamilyPrefix + id
+	}
+	values, err := s.cache.MGet(ctx, keys...)
+	if err != nil {
+		slog.Warn("读取用户会话失败", "user_id", userID, "error", err)
+		return nil, nil
+	}
+
+	active := make([]string, 0, len(ids))
+	families := make(map[string]refreshFamily, len(ids))
+	for i, id := range ids {
+		data, ok := values[keys[i]]
+		if !ok {
+			continue
+		}
+		var family refreshFamily
+		if err := json.Unmarshal(data, &family); err != nil || family.UserID != userID {
+			continue
+		}
+		active = append(active, id)
+		families[id] = family
+	}
+	return active, families
+}
+
+// trackSession 将令牌家族加入用户会话列表，同时清理已失效的家族
+// 会话列表整体读写，同一用户并发登录时可能漏记其中一个会话，该会话仍可正常使用，只是不出现在列表中
+func (s *authService) trackSession(ctx context.Context, userID uint, familyID string) {
+	if s.cache == nil {
+		return
+	}
+	ids, _ := s.loadSessions(ctx, userID)
+	s.saveSessions(ctx, userID, append(ids, familyID))
+}
+
+// untrackSession 将令牌家族从用户会话列表中移除
+func (s *authService) untrackSession(ctx context.Context, userID uint, familyID string) {
+	ids, _ := s.loadSessions(ctx, userID)
+	s.saveSessions(ctx, userID, slices.DeleteFunc(ids, func(id string) bool { return id == familyID }))
+}
+
+// saveSessions 保存用户会话列表，与令牌家族的有效期相同，每次登录时顺延
+func (s *authService) saveSessions(ctx context.Context, userID uint, ids []string) {
+	key := fmt.Sprintf("%s%d", userSessionsPrefix, userID)
+	var err error
+	if len(ids) == 0 {
+		err = s.cache.Delete(ctx, key)
+	} else {
+		err = s.cache.SetObject(ctx, key, ids, s.jwtConfig.RefreshTokenExp)
+	}
+	if err != nil {
+		slog.Warn("保存用户会话列表失败", "user_id", userID, "error", err)
+	}
+}
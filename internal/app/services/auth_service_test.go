@@ -0,0 +1,721 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+// newTestAuthService 创建使用miniredis缓存的认证服务，并预置一个可登录的用户
+func newTestAuthService(t *testing.T) (AuthService, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	user := &models.User{
+		Model:    gorm.Model{ID: 1, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: string(hashed),
+		Role:     "user",
+	}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", context.Background(), user.Email).Return(user, nil)
+	mockRepo.On("GetByID", context.Background(), "1").Return(user, nil)
+
+	jwtConfig := &jwt.Config{
+		Secret:          "test-secret",
+		AccessTokenExp:  15 * time.Minute,
+		RefreshTokenExp: 24 * time.Hour,
+		Issuer:          "test",
+	}
+
+	return NewAuthService(mockRepo, validator.New(), nil, jwtConfig, c, nil, newMinCostHasher(t), nil, false, nil), mr
+}
+
+// newMinCostHasher 创建与预置用户哈希成本一致的bcrypt哈希器，登录时不触发重新计算
+func newMinCostHasher(t *testing.T) utils.PasswordHasher {
+	t.Helper()
+
+	hasher, err := utils.NewPasswordHasher(&utils.PasswordHashConfig{Algorithm: utils.HashAlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	return hasher
+}
+
+func login(t *testing.T, service AuthService) *dto.LoginResponse {
+	t.Helper()
+
+	resp, err := service.Login(context.Background(), dto.LoginRequest{
+		Email:    "test@example.com",
+		Password: "password123",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.RefreshToken)
+	return resp
+}
+
+func assertUnauthorized(t *testing.T, err error) {
+	t.Helper()
+
+	require.Error(t, err)
+	appErr, ok := err.(*apperrors.Error)
+	require.True(t, ok)
+	assert.Equal(t, apperrors.ErrorTypeUnauthorized, appErr.Type)
+}
+
+func TestAuthService_RefreshToken(t *testing.T) {
+	ctx := context.Background()
+
+	// 每次刷新都签发新的刷新令牌，且新令牌可继续使用
+	t.Run("Rotation", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		first, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEmpty(t, first.AccessToken)
+		assert.NotEmpty(t, first.RefreshToken)
+		assert.NotEqual(t, loginResp.RefreshToken, first.RefreshToken)
+		assert.Equal(t, "Bearer", first.TokenType)
+
+		second, err := service.RefreshToken(ctx, first.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+		// 轮换后的令牌仍属于同一家族
+		firstClaims, err := jwt.ParseRefreshToken(first.RefreshToken, &jwt.Config{Secret: "test-secret"})
+		require.NoError(t, err)
+		secondClaims, err := jwt.ParseRefreshToken(second.RefreshToken, &jwt.Config{Secret: "test-secret"})
+		require.NoError(t, err)
+		assert.Equal(t, firstClaims.FamilyID, secondClaims.FamilyID)
+		assert.NotEqual(t, firstClaims.ID, secondClaims.ID)
+	})
+
+	// 重放已使用的刷新令牌会撤销整个家族
+	t.Run("ReplayRevokesFamily", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		rotated, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+		require.NoError(t, err)
+
+		// 再次提交已轮换的旧令牌
+		_, err = service.RefreshToken(ctx, loginResp.RefreshToken)
+		assertUnauthorized(t, err)
+
+		// 家族已被撤销，最新签发的令牌同样失效，家族内已签发的访问令牌也失效
+		_, err = service.RefreshToken(ctx, rotated.RefreshToken)
+		assertUnauthorized(t, err)
+		for _, accessToken := range []string{loginResp.AccessToken, rotated.AccessToken} {
+			introspection, err := service.Introspect(ctx, accessToken)
+			require.NoError(t, err)
+			assert.False(t, introspection.Active)
+		}
+
+		// 重新登录会创建新的家族，不受影响
+		_, err = service.RefreshToken(ctx, login(t, service).RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	// 并发提交同一刷新令牌时只有一个请求轮换成功，其余视为重放并撤销家族
+	t.Run("ConcurrentReuse", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		const n = 10
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			rotated   []*dto.TokenResponse
+			failures  int
+			startGate = make(chan struct{})
+		)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-startGate
+				resp, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					failures++
+					return
+				}
+				rotated = append(rotated, resp)
+			}()
+		}
+		close(startGate)
+		wg.Wait()
+
+		require.Len(t, rotated, 1)
+		assert.Equal(t, n-1, failures)
+
+		// 检测到重放后成功轮换得到的令牌同样失效
+		_, err := service.RefreshToken(ctx, rotated[0].RefreshToken)
+		assertUnauthorized(t, err)
+		introspection, err := service.Introspect(ctx, rotated[0].AccessToken)
+		require.NoError(t, err)
+		assert.False(t, introspection.Active)
+	})
+
+	// 登出后本次登录的刷新令牌失效
+	t.Run("LogoutRevokesFamily", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		require.NoError(t, service.Logout(ctx, loginResp.AccessToken))
+
+		_, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+		assertUnauthorized(t, err)
+	})
+
+	// 家族状态过期后刷新令牌不可用
+	t.Run("ExpiredFamily", func(t *testing.T) {
+		service, mr := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		mr.FastForward(25 * time.Hour)
+
+		_, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+		assertUnauthorized(t, err)
+	})
+
+	// 无效的刷新令牌
+	t.Run("InvalidToken", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+
+		_, err := service.RefreshToken(ctx, "invalid-token")
+		assertUnauthorized(t, err)
+	})
+}
+
+func TestAuthService_Introspect(t *testing.T) {
+	ctx := context.Background()
+
+	// 有效令牌返回用户、角色和过期时间
+	t.Run("Valid", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+
+		resp, err := service.Introspect(ctx, loginResp.AccessToken)
+		require.NoError(t, err)
+		assert.True(t, resp.Active)
+		assert.Equal(t, "1", resp.Subject)
+		assert.Equal(t, "user", resp.Role)
+		assert.Equal(t, "test", resp.Issuer)
+		assert.Equal(t, "Bearer", resp.TokenType)
+		assert.InDelta(t, time.Now().Add(15*time.Minute).Unix(), resp.ExpiresAt, 5)
+	})
+
+	// 过期的令牌无效，不返回令牌信息
+	t.Run("Expired", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		expired, err := jwt.GenerateAccessToken(1, "user", "family", &jwt.Config{Secret: "test-secret", AccessTokenExp: -time.Minute})
+		require.NoError(t, err)
+
+		resp, err := service.Introspect(ctx, expired)
+		require.NoError(t, err)
+		assert.Equal(t, &dto.IntrospectResponse{Active: false}, resp)
+	})
+
+	// 登出后令牌在黑名单中，无效
+	t.Run("Blacklisted", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		loginResp := login(t, service)
+		require.NoError(t, service.Logout(ctx, loginResp.AccessToken))
+
+		resp, err := service.Introspect(ctx, loginResp.AccessToken)
+		require.NoError(t, err)
+		assert.False(t, resp.Active)
+		assert.Empty(t, resp.Subject)
+	})
+
+	// 签名无效的令牌
+	t.Run("Invalid", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+
+		resp, err := service.Introspect(ctx, "invalid-token")
+		require.NoError(t, err)
+		assert.False(t, resp.Active)
+	})
+}
+
+func TestAuthService_Sessions(t *testing.T) {
+	ctx := context.Background()
+
+	loginFrom := func(t *testing.T, service AuthService, userAgent, ip string) *dto.LoginResponse {
+		t.Helper()
+		resp, err := service.Login(ctx, dto.LoginRequest{
+			Email:     "test@example.com",
+			Password:  "password123",
+			UserAgent: userAgent,
+			IP:        ip,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	// 每次登录创建一个会话，刷新令牌不创建新会话
+	t.Run("List", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		laptop := loginFrom(t, service, "laptop", "10.0.0.1")
+		phone := loginFrom(t, service, "phone", "10.0.0.2")
+		_, err := service.RefreshToken(ctx, laptop.RefreshToken)
+		require.NoError(t, err)
+
+		sessions, err := service.ListSessions(ctx, phone.AccessToken)
+		require.NoError(t, err)
+		require.Len(t, sessions, 2)
+
+		byDevice := map[string]dto.SessionResponse{}
+		for _, session := range sessions {
+			byDevice[session.UserAgent] = session
+		}
+		assert.Equal(t, "10.0.0.1", byDevice["laptop"].IP)
+		assert.False(t, byDevice["laptop"].Current)
+		assert.Equal(t, "10.0.0.2", byDevice["phone"].IP)
+		assert.True(t, byDevice["phone"].Current)
+		assert.False(t, byDevice["phone"].IssuedAt.IsZero())
+	})
+
+	// 撤销的会话不再出现在列表中，其刷新令牌失效，访问令牌在内省中无效
+	t.Run("Revoke", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		laptop := loginFrom(t, service, "laptop", "10.0.0.1")
+		phone := loginFrom(t, service, "phone", "10.0.0.2")
+
+		laptopClaims, err := jwt.ParseToken(laptop.AccessToken, &jwt.Config{Secret: "test-secret"})
+		require.NoError(t, err)
+		require.NoError(t, service.RevokeSession(ctx, phone.AccessToken, laptopClaims.FamilyID))
+
+		sessions, err := service.ListSessions(ctx, phone.AccessToken)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.Equal(t, "phone", sessions[0].UserAgent)
+
+		_, err = service.RefreshToken(ctx, laptop.RefreshToken)
+		assertUnauthorized(t, err)
+		introspection, err := service.Introspect(ctx, laptop.AccessToken)
+		require.NoError(t, err)
+		assert.False(t, introspection.Active)
+
+		// 其他会话不受影响
+		_, err = service.RefreshToken(ctx, phone.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	// 不存在的会话或其他用户的会话返回未找到
+	t.Run("RevokeUnknown", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		resp := login(t, service)
+
+		err := service.RevokeSession(ctx, resp.AccessToken, "missing")
+		assert.Equal(t, apperrors.ErrorTypeNotFound, apperrors.AsError(err).Type)
+
+		other, err := jwt.GenerateAccessToken(2, "user", "", &jwt.Config{Secret: "test-secret", AccessTokenExp: time.Minute})
+		require.NoError(t, err)
+		claims, err := jwt.ParseToken(resp.AccessToken, &jwt.Config{Secret: "test-secret"})
+		require.NoError(t, err)
+		err = service.RevokeSession(ctx, other, claims.FamilyID)
+		assert.Equal(t, apperrors.ErrorTypeNotFound, apperrors.AsError(err).Type)
+	})
+
+	// 登出后会话从列表中移除
+	t.Run("Logout", func(t *testing.T) {
+		service, _ := newTestAuthService(t)
+		first := login(t, service)
+		second := login(t, service)
+		require.NoError(t, service.Logout(ctx, first.AccessToken))
+
+		sessions, err := service.ListSessions(ctx, second.AccessToken)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+	})
+}
+
+func TestAuthService_LoginLockout(t *testing.T) {
+	ctx := context.Background()
+	wrong := dto.LoginRequest{Email: "test@example.com", Password: "wrong-password"}
+	correct := dto.LoginRequest{Email: "test@example.com", Password: "password123"}
+
+	assertLocked := func(t *testing.T, err error) {
+		t.Helper()
+
+		require.Error(t, err)
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeTooManyRequests, appErr.Type)
+	}
+
+	// 超过失败阈值后锁定账户，即使密码正确也拒绝登录，锁定期结束后恢复
+	t.Run("LockAfterThreshold", func(t *testing.T) {
+		service, mr := newTestAuthService(t)
+
+		for i := 0; i < DefaultLockoutConfig.Threshold; i++ {
+			_, err := service.Login(ctx, wrong)
+			assertUnauthorized(t, err)
+		}
+
+		_, err := service.Login(ctx, correct)
+		assertLocked(t, err)
+
+		// 邮箱大小写不同也视为同一账户
+		_, err = service.Login(ctx, dto.LoginRequest{Email: "TEST@example.com", Password: "password123"})
+		assertLocked(t, err)
+
+		mr.FastForward(DefaultLockoutConfig.Duration)
+
+		login(t, service)
+		assert.False(t, mr.Exists(loginAttemptsKey(correct.Email)))
+	})
+
+	// 并发的失败请求不会互相覆盖计数，达到阈值后锁定
+	t.Run("ConcurrentFailures", func(t *testing.T) {
+		service, mr := newTestAuthService(t)
+
+		const n = 20
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				service.Login(ctx, wrong)
+			}()
+		}
+		wg.Wait()
+
+		_, err := service.Login(ctx, correct)
+		assertLocked(t, err)
+		assert.Equal(t, DefaultLockoutConfig.Duration, mr.TTL(loginAttemptsKey(correct.Email)))
+	})
+
+	// 登录成功后清除失败计数，重新开始计算
+	t.Run("ResetOnSuccess", func(t *testing.T) {
+		service, mr := newTestAuthService(t)
+
+		for i := 0; i < DefaultLockoutConfig.Threshold-1; i++ {
+			_, err := service.Login(ctx, wrong)
+			assertUnauthorized(t, err)
+		}
+		require.True(t, mr.Exists(loginAttemptsKey(correct.Email)))
+
+		login(t, service)
+		assert.False(t, mr.Exists(loginAttemptsKey(correct.Email)))
+
+		_, err := service.Login(ctx, wrong)
+		assertUnauthorized(t, err)
+		login(t, service)
+	})
+
+	// 失败计数在统计窗口结束后过期
+	t.Run("WindowExpires", func(t *testing.T) {
+		service, mr := newTestAuthService(t)
+
+		for i := 0; i < DefaultLockoutConfig.Threshold-1; i++ {
+			_, err := service.Login(ctx, wrong)
+			assertUnauthorized(t, err)
+		}
+
+		mr.FastForward(DefaultLockoutConfig.Window)
+		assert.False(t, mr.Exists(loginAttemptsKey(correct.Email)))
+
+		_, err := service.Login(ctx, wrong)
+		assertUnauthorized(t, err)
+		login(t, service)
+	})
+}
+
+func TestAuthService_PasswordRehash(t *testing.T) {
+	ctx := context.Background()
+
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	newService := func(t *testing.T, hasher utils.PasswordHasher) (AuthService, *MockUserRepository) {
+		t.Helper()
+
+		mr := miniredis.RunT(t)
+		c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+
+		user := &models.User{
+			Model:    gorm.Model{ID: 1},
+			Email:    "test@example.com",
+			Password: string(oldHash),
+			Role:     "user",
+		}
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+		jwtConfig := &jwt.Config{Secret: "test-secret", AccessTokenExp: 15 * time.Minute, RefreshTokenExp: time.Hour}
+		return NewAuthService(mockRepo, validator.New(), nil, jwtConfig, c, nil, hasher, nil, false, nil), mockRepo
+	}
+
+	// 成本提高后登录，旧哈希按新成本重新计算并保存
+	t.Run("UpgradesLowCost", func(t *testing.T) {
+		hasher, err := utils.NewPasswordHasher(&utils.PasswordHashConfig{Algorithm: utils.HashAlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+		require.NoError(t, err)
+		service, mockRepo := newService(t, hasher)
+
+		var saved string
+		mockRepo.On("UpdatePasswordHash", ctx, mock.Anything, uint(1), string(oldHash), mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { saved = args.String(4) }).
+			Return(nil).Once()
+
+		login(t, service)
+
+		mockRepo.AssertExpectations(t)
+		cost, err := bcrypt.Cost([]byte(saved))
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.MinCost+1, cost)
+		assert.NoError(t, hasher.Verify(saved, "password123"))
+	})
+
+	// 切换到Argon2id后登录，bcrypt哈希升级为Argon2id
+	t.Run("UpgradesAlgorithm", func(t *testing.T) {
+		hasher, err := utils.NewPasswordHasher(&utils.PasswordHashConfig{
+			Algorithm: utils.HashAlgorithmArgon2id,
+			Argon2id:  utils.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1},
+		})
+		require.NoError(t, err)
+		service, mockRepo := newService(t, hasher)
+
+		var saved string
+		mockRepo.On("UpdatePasswordHash", ctx, mock.Anything, uint(1), string(oldHash), mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { saved = args.String(4) }).
+			Return(nil).Once()
+
+		login(t, service)
+
+		assert.True(t, strings.HasPrefix(saved, "$argon2id$"))
+		assert.NoError(t, hasher.Verify(saved, "password123"))
+	})
+
+	// 哈希已符合当前配置时不重新计算
+	t.Run("UpToDate", func(t *testing.T) {
+		service, mockRepo := newService(t, newMinCostHasher(t))
+
+		login(t, service)
+
+		mockRepo.AssertNotCalled(t, "UpdatePasswordHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	// 密码错误时不重新计算
+	t.Run("WrongPassword", func(t *testing.T) {
+		service, mockRepo := newService(t, utils.DefaultPasswordHasher)
+
+		_, err := service.Login(ctx, dto.LoginRequest{Email: "test@example.com", Password: "wrong-password"})
+		assertUnauthorized(t, err)
+
+		mockRepo.AssertNotCalled(t, "UpdatePasswordHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	// 保存失败不影响登录
+	t.Run("SaveFailure", func(t *testing.T) {
+		service, mockRepo := newService(t, utils.DefaultPasswordHasher)
+		mockRepo.On("UpdatePasswordHash", ctx, mock.Anything, uint(1), string(oldHash), mock.AnythingOfType("string")).
+			Return(apperrors.InternalError("更新密码哈希失败", nil))
+
+		login(t, service)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// 缓存不可用时登录和刷新令牌仍可使用，不依赖缓存中的令牌家族状态
+func TestAuthService_NullCache(t *testing.T) {
+	ctx := context.Background()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{Model: gorm.Model{ID: 1}, Email: "test@example.com", Password: string(hashed), Role: "user"}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	mockRepo.On("GetByID", ctx, "1").Return(user, nil)
+
+	jwtConfig := &jwt.Config{Secret: "test-secret", AccessTokenExp: 15 * time.Minute, RefreshTokenExp: time.Hour}
+	service := NewAuthService(mockRepo, validator.New(), nil, jwtConfig, cache.NewNullCache(), nil, newMinCostHasher(t), nil, false, nil)
+
+	loginResp := login(t, service)
+
+	refreshed, err := service.RefreshToken(ctx, loginResp.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, refreshed.AccessToken)
+
+	assert.NoError(t, service.Logout(ctx, refreshed.AccessToken))
+}
+
+// 要求邮箱验证时，未验证的用户不能登录
+func TestAuthService_RequireVerified(t *testing.T) {
+	ctx := context.Background()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{Model: gorm.Model{ID: 1}, Email: "test@example.com", Password: string(hashed), Role: "user"}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+
+	jwtConfig := &jwt.Config{Secret: "test-secret", AccessTokenExp: 15 * time.Minute, RefreshTokenExp: time.Hour}
+	service := NewAuthService(mockRepo, validator.New(), nil, jwtConfig, cache.NewNullCache(), nil, newMinCostHasher(t), nil, true, nil)
+
+	_, err = service.Login(ctx, dto.LoginRequest{Email: "test@example.com", Password: "password123"})
+	require.Error(t, err)
+	appErr, ok := err.(*apperrors.Error)
+	require.True(t, ok)
+	assert.Equal(t, apperrors.ErrorTypeForbidden, appErr.Type)
+	assert.Equal(t, apperrors.CodeAuthEmailNotVerified, appErr.Code)
+
+	user.Verified = true
+	login(t, service)
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	ctx := context.Background()
+	jwtConfig := &jwt.Config{Secret: "test-secret", AccessTokenExp: 15 * time.Minute, RefreshTokenExp: time.Hour}
+
+	// newService 创建带重置邮件队列的认证服务，重置密码时同步修改预置用户的密码哈希
+	newService := func(t *testing.T) (AuthService, *models.User, *recordingQueue) {
+		t.Helper()
+
+		mr := miniredis.RunT(t)
+		c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+		require.NoError(t, err)
+		user := &models.User{Model: gorm.Model{ID: 1}, Name: "Test User", Email: "test@example.com", Password: string(hashed), Role: "user"}
+
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		mockRepo.On("GetByEmail", ctx, "unknown@example.com").Return(nil, apperrors.NotFoundError("用户", nil))
+		mockRepo.On("GetByID", ctx, "1").Return(user, nil)
+		mockRepo.On("ResetPassword", ctx, mock.Anything, uint(1), mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+			Run(func(args mock.Arguments) { user.Password = args.String(4) }).
+			Return(true, nil)
+
+		q := &recordingQueue{}
+		resetter := NewPasswordResetter(jwtConfig, q, nil)
+		return NewAuthService(mockRepo, validator.New(), nil, jwtConfig, c, nil, newMinCostHasher(t), nil, false, resetter), user, q
+	}
+
+	assertCode := func(t *testing.T, err error, code apperrors.Code) {
+		t.Helper()
+		require.Error(t, err)
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, code, appErr.Code)
+	}
+
+	// 发送重置邮件、重置密码后旧会话和令牌失效，新密码可以登录
+	t.Run("RoundTrip", func(t *testing.T) {
+		service, _, q := newService(t)
+		session := login(t, service)
+
+		require.NoError(t, service.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: "test@example.com"}))
+		require.Equal(t, []string{TopicPasswordReset}, q.topics)
+		var email PasswordResetEmail
+		require.NoError(t, json.Unmarshal(q.payloads[0], &email))
+
+		reset := dto.ResetPasswordRequest{Token: email.Token, Password: "N3w-Passphrase"}
+		require.NoError(t, service.ResetPassword(ctx, reset))
+
+		// 重置前登录的会话不能再刷新，已签发的访问令牌同样失效
+		_, err := service.RefreshToken(ctx, session.RefreshToken)
+		assertCode(t, err, apperrors.CodeAuthRefreshTokenRevoked)
+		introspection, err := service.Introspect(ctx, session.AccessToken)
+		require.NoError(t, err)
+		assert.False(t, introspection.Active)
+
+		// 旧密码不能登录，新密码登录后的会话正常
+		_, err = service.Login(ctx, dto.LoginRequest{Email: "test@example.com", Password: "password123"})
+		assertUnauthorized(t, err)
+		resp, err := service.Login(ctx, dto.LoginRequest{Email: "test@example.com", Password: "N3w-Passphrase"})
+		require.NoError(t, err)
+		_, err = service.RefreshToken(ctx, resp.RefreshToken)
+		require.NoError(t, err)
+
+		// 会话列表中只剩重置后登录的会话
+		sessions, err := service.ListSessions(ctx, resp.AccessToken)
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.True(t, sessions[0].Current)
+
+		// 令牌只能使用一次
+		assertCode(t, service.ResetPassword(ctx, reset), apperrors.CodeAuthResetTokenInvalid)
+	})
+
+	// 未注册的邮箱同样返回成功，但不发送邮件
+	t.Run("UnknownEmail", func(t *testing.T) {
+		service, _, q := newService(t)
+		require.NoError(t, service.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: "unknown@example.com"}))
+		assert.Empty(t, q.topics)
+	})
+
+	// 过期的令牌不能重置密码
+	t.Run("ExpiredToken", func(t *testing.T) {
+		service, user, _ := newService(t)
+		token, err := jwt.GeneratePasswordResetToken(1, passwordFingerprint(user.Password), -time.Minute, jwtConfig)
+		require.NoError(t, err)
+
+		err = service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "N3w-Passphrase"})
+		assertCode(t, err, apperrors.CodeAuthResetTokenExpired)
+	})
+
+	// 无效的令牌和其他用途的令牌不能重置密码
+	t.Run("InvalidToken", func(t *testing.T) {
+		service, _, _ := newService(t)
+		err := service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: "not-a-token", Password: "N3w-Passphrase"})
+		assertCode(t, err, apperrors.CodeAuthResetTokenInvalid)
+
+		verification, err := jwt.GenerateEmailVerificationToken(1, "test@example.com", time.Hour, jwtConfig)
+		require.NoError(t, err)
+		err = service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: verification, Password: "N3w-Passphrase"})
+		assertCode(t, err, apperrors.CodeAuthResetTokenInvalid)
+	})
+
+	// 新密码按强度策略校验
+	t.Run("WeakPassword", func(t *testing.T) {
+		service, user, _ := newService(t)
+		token, err := jwt.GeneratePasswordResetToken(1, passwordFingerprint(user.Password), time.Hour, jwtConfig)
+		require.NoError(t, err)
+
+		err = service.ResetPassword(ctx, dto.ResetPasswordRequest{Token: token, Password: "alllowercase"})
+		require.Error(t, err)
+		assert.Equal(t, apperrors.ErrorTypeValidation, err.(*apperrors.Error).Type)
+	})
+
+	// 消息队列不可用时返回服务不可用，与邮箱是否注册无关
+	t.Run("QueueUnavailable", func(t *testing.T) {
+		service := NewAuthService(new(MockUserRepository), validator.New(), nil, jwtConfig, nil, nil, newMinCostHasher(t), nil, false, nil)
+		err := service.ForgotPassword(ctx, dto.ForgotPasswordRequest{Email: "unknown@example.com"})
+		require.Error(t, err)
+		assert.Equal(t, apperrors.ErrorTypeServiceUnavailable, err.(*apperrors.Error).Type)
+	})
+}
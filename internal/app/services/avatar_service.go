@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"gorm.io/gorm"
+
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/repository"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/storage"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
+)
+
+// avatarExtensions 支持的头像内容类型及保存时使用的扩展名
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// AvatarService 用户头像服务接口
+type AvatarService interface {
+	UpdateAvatar(ctx context.Context, id string, file io.Reader, contentType string) (*models.User, error)
+}
+
+// avatarService 用户头像服务实现
+type avatarService struct {
+	userRepo  repository.UserRepository
+	txManager transaction.Manager
+	cache     cache.Cache
+	storage   storage.Storage
+	// audit 记录头像变更的审计服务，为空时不记录
+	audit  AuditService
+	logger logger.Logger
+}
+
+// NewAvatarService 创建用户头像服务，audit为空时不记录审计日志，log为空时使用 slog 默认处理器
+func NewAvatarService(ur repository.UserRepository, tm transaction.Manager, c cache.Cache, store storage.Storage, audit AuditService, log logger.Logger) AvatarService {
+	if log == nil {
+		log = logger.New(nil)
+	}
+
+	return &avatarService{
+		userRepo:  ur,
+		txManager: tm,
+		cache:     c,
+		storage:   store,
+		audit:     audit,
+		logger:    log,
+	}
+}
+
+// UpdateAvatar 保存头像文件并更新用户的头像地址，文件内容和类型需已由调用方校验
+// 每次上传使用新的文件键，避免CDN和浏览器缓存旧头像；写入数据库失败时删除刚上传的文件
+func (s *avatarService) UpdateAvatar(ctx context.Context, id string, file io.Reader, contentType string) (*models.User, error) {
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return nil, apperrors.BadRequestError("不支持的头像文件类型", nil).WithCode(apperrors.CodeAvatarTypeNotAllowed)
+	}
+
+	// 先确认用户存在，避免为不存在的用户上传文件
+	existing, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
+	}
+
+	key, err := avatarKey(existing.ID, ext)
+	if err != nil {
+		return nil, apperrors.InternalError("生成头像文件名失败", err)
+	}
+	url, err := s.storage.Put(ctx, key, file, contentType)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("保存头像失败", "key", key, "error", err)
+		return nil, apperrors.InternalError("保存头像失败", err)
+	}
+
+	var user *models.User
+	err = s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		var err error
+		user, err = s.userRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		before := userSnapshot(user)
+
+		user.AvatarURL = url
+		if err := s.userRepo.Update(ctx, tx, user); err != nil {
+			return err
+		}
+
+		if s.audit == nil {
+			return nil
+		}
+		return s.audit.Record(ctx, tx, AuditEntry{
+			Action:     models.AuditActionUserUpdate,
+			EntityType: models.AuditEntityUser,
+			EntityID:   user.ID,
+			Before:     before,
+			After:      userSnapshot(user),
+		})
+	})
+	if err != nil {
+		if delErr := s.storage.Delete(context.WithoutCancel(ctx), key); delErr != nil {
+			s.logger.WithContext(ctx).Warn("删除未使用的头像文件失败", "key", key, "error", delErr)
+		}
+		return nil, err // 错误已经在仓库层包装
+	}
+
+	// 更新缓存
+	s.logCacheError(ctx, "更新用户缓存失败", cache.SetLatest(ctx, s.cache, getUserCacheKey(id), user, userCacheTTL))
+	s.logCacheError(ctx, "清除用户列表缓存失败", s.cache.DeleteByPattern(ctx, userListCacheKey+":*"))
+
+	return user, nil
+}
+
+// logCacheError 记录缓存更新失败，不影响业务结果
+func (s *avatarService) logCacheError(ctx context.Context, msg string, err error) {
+	if err != nil {
+		s.logger.WithContext(ctx).Warn(msg, "error", err)
+	}
+}
+
+// avatarKey 生成头像文件键，格式为 avatars/{用户ID}/{随机串}{扩展名}
+func avatarKey(id uint, ext string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("avatars/%d/%s%s", id, hex.EncodeToString(b), ext), nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/storage"
+)
+
+func TestAvatarService_UpdateAvatar(t *testing.T) {
+	ctx := context.Background()
+
+	newStorage := func(t *testing.T) (*storage.LocalStorage, string) {
+		dir := t.TempDir()
+		store, err := storage.NewLocalStorage(&storage.LocalConfig{Dir: dir, BaseURL: "/uploads"})
+		require.NoError(t, err)
+		return store, dir
+	}
+
+	// 保存文件后写入头像地址并更新缓存
+	t.Run("Success", func(t *testing.T) {
+		store, dir := newStorage(t)
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		user := &models.User{Name: "Test User", Email: "test@example.com"}
+		user.ID = 1
+
+		mockRepo.On("GetByID", ctx, "1").Return(user, nil)
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(user, nil)
+		mockRepo.On("Update", ctx, mock.Anything, user).Return(nil)
+		mockCache.On("SetObject", ctx, getUserCacheKey("1"), user, userCacheTTL).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		service := NewAvatarService(mockRepo, &MockTxManager{}, mockCache, store, nil, nil)
+		updated, err := service.UpdateAvatar(ctx, "1", strings.NewReader("png-data"), "image/png")
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(updated.AvatarURL, "/uploads/avatars/1/"))
+		assert.True(t, strings.HasSuffix(updated.AvatarURL, ".png"))
+		data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(updated.AvatarURL, "/uploads/")))
+		require.NoError(t, err)
+		assert.Equal(t, "png-data", string(data))
+		mockCache.AssertExpectations(t)
+	})
+
+	// 写入数据库失败时删除刚上传的文件
+	t.Run("UpdateFailsRemovesFile", func(t *testing.T) {
+		store, dir := newStorage(t)
+		mockRepo := new(MockUserRepository)
+		user := &models.User{Name: "Test User"}
+		user.ID = 1
+
+		mockRepo.On("GetByID", ctx, "1").Return(user, nil)
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(user, nil)
+		mockRepo.On("Update", ctx, mock.Anything, user).Return(apperrors.InternalError("更新用户失败", nil))
+
+		service := NewAvatarService(mockRepo, &MockTxManager{}, new(MockCache), store, nil, nil)
+		_, err := service.UpdateAvatar(ctx, "1", strings.NewReader("png-data"), "image/png")
+		require.Error(t, err)
+
+		entries, err := os.ReadDir(filepath.Join(dir, "avatars", "1"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	// 用户不存在时不保存文件
+	t.Run("UserNotFound", func(t *testing.T) {
+		store, dir := newStorage(t)
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("GetByID", ctx, "2").Return(nil, apperrors.NotFoundError("用户", nil))
+
+		service := NewAvatarService(mockRepo, &MockTxManager{}, new(MockCache), store, nil, nil)
+		_, err := service.UpdateAvatar(ctx, "2", strings.NewReader("png-data"), "image/png")
+		assert.Equal(t, apperrors.ErrorTypeNotFound, apperrors.AsError(err).Type)
+		assert.NoDirExists(t, filepath.Join(dir, "avatars"))
+	})
+
+	// 不支持的内容类型直接拒绝
+	t.Run("UnsupportedType", func(t *testing.T) {
+		store, _ := newStorage(t)
+		service := NewAvatarService(new(MockUserRepository), &MockTxManager{}, new(MockCache), store, nil, nil)
+		_, err := service.UpdateAvatar(ctx, "1", strings.NewReader("%PDF"), "application/pdf")
+		assert.Equal(t, apperrors.CodeAvatarTypeNotAllowed, apperrors.AsError(err).Code)
+	})
+}
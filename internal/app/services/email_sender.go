@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/mail"
+
+	"github.com/vadxq/go-rest-starter/pkg/mailer"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+	"github.com/vadxq/go-rest-starter/pkg/templates"
+	"github.com/vadxq/go-rest-starter/web"
+)
+
+// emailTemplateFiles 验证和密码重置邮件的模板文件，以队列主题为模板名称
+var emailTemplateFiles = map[string]string{
+	TopicEmailVerification: "email/verification",
+	TopicPasswordReset:     "email/password_reset",
+}
+
+// LoadEmailTemplates 从r加载验证和密码重置邮件模板，模板文件见 web/templates/email
+func LoadEmailTemplates(r mailer.Renderer) (*mailer.Templates, error) {
+	emailTemplates := mailer.NewTemplates()
+	for name, file := range emailTemplateFiles {
+		if err := emailTemplates.Load(name, r, file); err != nil {
+			return nil, err
+		}
+	}
+	return emailTemplates, nil
+}
+
+// DefaultEmailTemplates 使用内置模板创建验证和密码重置邮件模板
+func DefaultEmailTemplates() *mailer.Templates {
+	engine, err := templates.New(web.Templates(), nil)
+	if err != nil {
+		panic(err)
+	}
+	emailTemplates, err := LoadEmailTemplates(engine)
+	if err != nil {
+		panic(err)
+	}
+	return emailTemplates
+}
+
+// EmailSender 消费验证和密码重置邮件的队列消息，按模板构建邮件并通过 Mailer 发送
+// 发送失败时按订阅的重试策略重试，邮件内容无效或SMTP服务器返回5xx时不再重试，直接进入死信队列
+type EmailSender struct {
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+}
+
+// NewEmailSender 创建邮件发送器，templates为空时使用 DefaultEmailTemplates
+func NewEmailSender(m mailer.Mailer, templates *mailer.Templates) *EmailSender {
+	if templates == nil {
+		templates = DefaultEmailTemplates()
+	}
+	return &EmailSender{mailer: m, templates: templates}
+}
+
+// Subscribe 订阅验证和密码重置邮件主题，opts为空时使用 queue.DefaultSubscribeOptions
+// 未设置 Retry.RetryIf 时，永久性发送错误不再重试
+func (s *EmailSender) Subscribe(ctx context.Context, q queue.Queue, opts *queue.SubscribeOptions) error {
+	if opts == nil {
+		opts = &queue.DefaultSubscribeOptions
+	}
+	subscribeOpts := *opts
+	if subscribeOpts.Retry.RetryIf == nil {
+		subscribeOpts.Retry.RetryIf = func(err error) bool { return !mailer.IsPermanent(err) }
+	}
+
+	for _, topic := range []string{TopicEmailVerification, TopicPasswordReset} {
+		if err := q.Subscribe(ctx, topic, s.Handle, &subscribeOpts); err != nil {
+			return fmt.Errorf("订阅邮件主题 %s 失败: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// Handle 处理一条邮件队列消息，实现 queue.Handler
+func (s *EmailSender) Handle(ctx context.Context, msg *queue.Message) error {
+	var (
+		to   string
+		data interface{}
+	)
+	switch msg.Topic {
+	case TopicEmailVerification:
+		var email VerificationEmail
+		if err := json.Unmarshal(msg.Payload, &email); err != nil {
+			return fmt.Errorf("%w: 解析验证邮件消息失败: %v", mailer.ErrInvalidMessage, err)
+		}
+		to, data = recipient(email.Name, email.Email), email
+	case TopicPasswordReset:
+		var email PasswordResetEmail
+		if err := json.Unmarshal(msg.Payload, &email); err != nil {
+			return fmt.Errorf("%w: 解析密码重置邮件消息失败: %v", mailer.ErrInvalidMessage, err)
+		}
+		to, data = recipient(email.Name, email.Email), email
+	default:
+		return fmt.Errorf("%w: 不支持的邮件主题 %s", mailer.ErrInvalidMessage, msg.Topic)
+	}
+
+	m, err := s.templates.Build(msg.Topic, []string{to}, data)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(ctx, m); err != nil {
+		slog.WarnContext(ctx, "发送邮件失败",
+			"topic", msg.Topic,
+			"message_id", msg.ID,
+			"retries", msg.Retries,
+			"permanent", mailer.IsPermanent(err),
+			"error", err,
+		)
+		return err
+	}
+
+	slog.InfoContext(ctx, "邮件已发送", "topic", msg.Topic, "message_id", msg.ID)
+	return nil
+}
+
+// recipient 带显示名称的收件人地址
+func recipient(name, email string) string {
+	return (&mail.Address{Name: name, Address: email}).String()
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/mailer"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// fakeMailer 记录发送邮件的 Mailer 桩，err不为空时发送失败
+type fakeMailer struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+	sent  []*mailer.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// messages 已发送的邮件
+func (m *fakeMailer) messages() []*mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*mailer.Message(nil), m.sent...)
+}
+
+// callCount 调用 Send 的次数
+func (m *fakeMailer) callCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// newTestEmailQueue 创建使用miniredis的队列，并以较短的重试间隔订阅邮件主题
+func newTestEmailQueue(t *testing.T, m mailer.Mailer) queue.Queue {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	q := queue.NewRedisQueue(client, 1)
+	t.Cleanup(func() { q.Close() })
+
+	require.NoError(t, NewEmailSender(m, nil).Subscribe(context.Background(), q, &queue.SubscribeOptions{
+		Concurrency: 1,
+		Timeout:     time.Second,
+		Retry: apperrors.RetryConfig{
+			MaxAttempts:  2,
+			InitialDelay: 10 * time.Millisecond,
+			MaxDelay:     10 * time.Millisecond,
+			Multiplier:   1,
+		},
+	}))
+	return q
+}
+
+func TestEmailSender(t *testing.T) {
+	ctx := context.Background()
+
+	// 验证邮件经队列消费后按模板渲染并发送给用户
+	t.Run("VerificationEmail", func(t *testing.T) {
+		m := &fakeMailer{}
+		q := newTestEmailQueue(t, m)
+
+		require.NoError(t, newTestVerifier(q, time.Hour).Send(ctx, newVerifyTestUser()))
+
+		require.Eventually(t, func() bool { return len(m.messages()) == 1 }, 5*time.Second, 20*time.Millisecond)
+		msg := m.messages()[0]
+		assert.Equal(t, []string{`"Test User" <test@example.com>`}, msg.To)
+		assert.Equal(t, "请验证您的邮箱", msg.Subject)
+		assert.Contains(t, msg.Text, "https://app.example.com/verify?lang=zh&token=")
+		assert.Contains(t, msg.HTML, `<a href="https://app.example.com/verify?lang=zh&amp;token=`)
+	})
+
+	// 未配置重置链接时，密码重置邮件直接包含令牌
+	t.Run("PasswordResetEmail", func(t *testing.T) {
+		m := &fakeMailer{}
+		q := newTestEmailQueue(t, m)
+
+		resetter := NewPasswordResetter(testVerificationJWTConfig, q, nil)
+		require.NoError(t, resetter.Send(ctx, newVerifyTestUser()))
+
+		require.Eventually(t, func() bool { return len(m.messages()) == 1 }, 5*time.Second, 20*time.Millisecond)
+		msg := m.messages()[0]
+		assert.Equal(t, "重置您的密码", msg.Subject)
+
+		_, rest, ok := strings.Cut(msg.HTML, "<code>")
+		require.True(t, ok)
+		token, _, _ := strings.Cut(rest, "</code>")
+		assert.Contains(t, msg.Text, token)
+		userID, _, err := resetter.Parse(token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+	})
+
+	// 临时性错误按重试策略重试，超过次数后进入死信队列
+	t.Run("TemporaryFailure", func(t *testing.T) {
+		m := &fakeMailer{err: errors.New("connection refused")}
+		q := newTestEmailQueue(t, m)
+
+		require.NoError(t, newTestVerifier(q, time.Hour).Send(ctx, newVerifyTestUser()))
+
+		var messages []queue.DeadLetterMessage
+		require.Eventually(t, func() bool {
+			var err error
+			messages, err = q.ListDeadLetters(ctx, TopicEmailVerification, 0)
+			return err == nil && len(messages) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 3, m.callCount())
+		assert.Equal(t, "connection refused", messages[0].Error)
+	})
+
+	// SMTP服务器返回5xx时不再重试，直接进入死信队列
+	t.Run("PermanentFailure", func(t *testing.T) {
+		m := &fakeMailer{err: &textproto.Error{Code: 550, Msg: "No such user"}}
+		q := newTestEmailQueue(t, m)
+
+		require.NoError(t, newTestVerifier(q, time.Hour).Send(ctx, newVerifyTestUser()))
+
+		require.Eventually(t, func() bool {
+			messages, err := q.ListDeadLetters(ctx, TopicEmailVerification, 0)
+			return err == nil && len(messages) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 1, m.callCount())
+	})
+
+	// 无法解析的消息不发送，直接返回永久性错误
+	t.Run("InvalidPayload", func(t *testing.T) {
+		m := &fakeMailer{}
+		sender := NewEmailSender(m, nil)
+
+		err := sender.Handle(ctx, &queue.Message{Topic: TopicPasswordReset, Payload: []byte("not json")})
+		assert.True(t, mailer.IsPermanent(err))
+		err = sender.Handle(ctx, &queue.Message{Topic: "email.unknown", Payload: []byte("{}")})
+		assert.True(t, mailer.IsPermanent(err))
+		assert.Empty(t, m.messages())
+	})
+}
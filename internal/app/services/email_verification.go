@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// TopicEmailVerification 验证邮件的队列主题，由邮件发送服务订阅并发送
+const TopicEmailVerification = "email.verification"
+
+// EmailVerificationConfig 邮箱验证配置
+type EmailVerificationConfig struct {
+	// TokenExp 验证令牌有效期
+	TokenExp time.Duration
+	// URL 验证链接地址，令牌作为 token 查询参数追加，为空时邮件中只包含令牌
+	URL string
+}
+
+// DefaultEmailVerificationConfig 默认邮箱验证配置
+var DefaultEmailVerificationConfig = EmailVerificationConfig{
+	TokenExp: 24 * time.Hour,
+}
+
+// VerificationEmail 验证邮件的队列消息负载
+type VerificationEmail struct {
+	UserID    uint      `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url,omitempty"` // 带令牌的验证链接
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EmailVerifier 签发验证令牌并将验证邮件放入队列，令牌与访问令牌使用相同的签名密钥
+type EmailVerifier struct {
+	jwtConfig *jwt.Config
+	queue     queue.Queue
+	config    *EmailVerificationConfig
+}
+
+// NewEmailVerifier 创建邮箱验证器，config为空时使用默认配置
+// q为空（未配置Redis）时仍可校验令牌，但不会发送验证邮件
+func NewEmailVerifier(jwtConfig *jwt.Config, q queue.Queue, config *EmailVerificationConfig) *EmailVerifier {
+	if config == nil {
+		config = &DefaultEmailVerificationConfig
+	}
+	cfg := *config
+	if cfg.TokenExp <= 0 {
+		cfg.TokenExp = DefaultEmailVerificationConfig.TokenExp
+	}
+
+	return &EmailVerifier{
+		jwtConfig: jwtConfig,
+		queue:     q,
+		config:    &cfg,
+	}
+}
+
+// NewVerificationEmail 为用户签发验证令牌并生成验证邮件
+func (v *EmailVerifier) NewVerificationEmail(user *models.User) (*VerificationEmail, error) {
+	token, err := jwt.GenerateEmailVerificationToken(user.ID, user.Email, v.config.TokenExp, v.jwtConfig)
+	if err != nil {
+		return nil, apperrors.InternalError("生成邮箱验证令牌失败", err)
+	}
+
+	email := &VerificationEmail{
+		UserID:    user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(v.config.TokenExp),
+	}
+	if v.config.URL != "" {
+		if email.URL, err = tokenURL(v.config.URL, token); err != nil {
+			return nil, apperrors.InternalError("无效的邮箱验证链接地址", err)
+		}
+	}
+	return email, nil
+}
+
+// tokenURL 将令牌作为 token 查询参数追加到链接地址，保留原有查询参数
+func tokenURL(base, token string) (string, error) {
+	link, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// Send 签发验证令牌并将验证邮件放入队列
+func (v *EmailVerifier) Send(ctx context.Context, user *models.User) error {
+	if v.queue == nil {
+		return apperrors.ServiceUnavailableError("消息队列不可用，无法发送验证邮件", nil)
+	}
+
+	email, err := v.NewVerificationEmail(user)
+	if err != nil {
+		return err
+	}
+	if err := v.queue.Publish(ctx, TopicEmailVerification, email); err != nil {
+		return apperrors.InternalError("发送验证邮件失败", err)
+	}
+	return nil
+}
+
+// Parse 校验验证令牌，返回签发时的用户ID和邮箱
+func (v *EmailVerifier) Parse(token string) (uint, string, error) {
+	claims, err := jwt.ParseEmailVerificationToken(token, v.jwtConfig)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return 0, "", apperrors.BadRequestError("邮箱验证令牌已过期", err).WithCode(apperrors.CodeAuthVerificationTokenExpired)
+		}
+		return 0, "", apperrors.BadRequestError("无效的邮箱验证令牌", err).WithCode(apperrors.CodeAuthVerificationTokenInvalid)
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return 0, "", apperrors.BadRequestError("无效的邮箱验证令牌", err).WithCode(apperrors.CodeAuthVerificationTokenInvalid)
+	}
+	return userID, claims.Email, nil
+}
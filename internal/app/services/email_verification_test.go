@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// recordingQueue 记录发布消息的队列桩
+type recordingQueue struct {
+	queue.Queue
+	topics   []string
+	payloads [][]byte
+}
+
+func (q *recordingQueue) Publish(ctx context.Context, topic string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	q.topics = append(q.topics, topic)
+	q.payloads = append(q.payloads, data)
+	return nil
+}
+
+// testVerificationJWTConfig 邮箱验证测试使用的HS256密钥配置
+var testVerificationJWTConfig = &jwt.Config{Secret: "test-secret", Issuer: "test"}
+
+// newTestVerifier 创建使用测试密钥的邮箱验证器
+func newTestVerifier(q queue.Queue, exp time.Duration) *EmailVerifier {
+	return NewEmailVerifier(testVerificationJWTConfig, q, &EmailVerificationConfig{
+		TokenExp: exp,
+		URL:      "https://app.example.com/verify?lang=zh",
+	})
+}
+
+// expiredVerificationToken 签发已过期的验证令牌
+func expiredVerificationToken(t *testing.T) string {
+	t.Helper()
+	token, err := jwt.GenerateEmailVerificationToken(1, "test@example.com", -time.Minute, testVerificationJWTConfig)
+	require.NoError(t, err)
+	return token
+}
+
+// newVerifyTestUser 创建待验证的测试用户
+func newVerifyTestUser() *models.User {
+	user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user", Version: 1}
+	user.ID = 1
+	return user
+}
+
+func TestEmailVerifier(t *testing.T) {
+	// 签发的令牌可解析出用户ID和邮箱，验证链接保留原有查询参数
+	t.Run("TokenGeneration", func(t *testing.T) {
+		verifier := newTestVerifier(nil, time.Hour)
+		email, err := verifier.NewVerificationEmail(newVerifyTestUser())
+		require.NoError(t, err)
+
+		userID, address, err := verifier.Parse(email.Token)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), userID)
+		assert.Equal(t, "test@example.com", address)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), email.ExpiresAt, time.Minute)
+
+		link, err := url.Parse(email.URL)
+		require.NoError(t, err)
+		assert.Equal(t, email.Token, link.Query().Get("token"))
+		assert.Equal(t, "zh", link.Query().Get("lang"))
+	})
+
+	// 验证邮件发布到队列
+	t.Run("Send", func(t *testing.T) {
+		q := &recordingQueue{}
+		require.NoError(t, newTestVerifier(q, time.Hour).Send(context.Background(), newVerifyTestUser()))
+
+		require.Equal(t, []string{TopicEmailVerification}, q.topics)
+		var email VerificationEmail
+		require.NoError(t, json.Unmarshal(q.payloads[0], &email))
+		assert.Equal(t, "test@example.com", email.Email)
+		assert.NotEmpty(t, email.Token)
+	})
+
+	// 过期和无效的令牌返回不同的错误码
+	t.Run("InvalidToken", func(t *testing.T) {
+		_, _, err := newTestVerifier(nil, time.Hour).Parse(expiredVerificationToken(t))
+		assert.Equal(t, apperrors.CodeAuthVerificationTokenExpired, err.(*apperrors.Error).Code)
+
+		_, _, err = newTestVerifier(nil, time.Hour).Parse("not-a-token")
+		assert.Equal(t, apperrors.CodeAuthVerificationTokenInvalid, err.(*apperrors.Error).Code)
+	})
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+
+	// 创建用户后发送验证邮件
+	t.Run("CreateUserSendsEmail", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		q := &recordingQueue{}
+		service := NewUserService(mockRepo, validator.New(), &MockTxManager{}, mockCache, nil, nil, 0, nil, newTestVerifier(q, time.Hour), nil)
+
+		mockRepo.On("ExistsByEmail", ctx, "test@example.com").Return(false, nil)
+		mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Run(func(args mock.Arguments) {
+			args.Get(2).(*models.User).ID = 1
+		}).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("1")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		user, err := service.CreateUser(ctx, dto.CreateUserInput{Name: "Test User", Email: "test@example.com", Password: "S3cure-Passphrase"})
+		require.NoError(t, err)
+		assert.False(t, user.Verified)
+		assert.Equal(t, []string{TopicEmailVerification}, q.topics)
+	})
+
+	// 未启用邮箱验证时新用户直接为已验证；内部创建的已验证用户不发送验证邮件
+	t.Run("CreateVerifiedUser", func(t *testing.T) {
+		q := &recordingQueue{}
+		for name, tc := range map[string]struct {
+			verifier *EmailVerifier
+			verified bool
+		}{
+			"WithoutVerifier": {verifier: nil},
+			"SeededUser":      {verifier: newTestVerifier(q, time.Hour), verified: true},
+		} {
+			mockRepo := new(MockUserRepository)
+			mockCache := new(MockCache)
+			service := NewUserService(mockRepo, validator.New(), &MockTxManager{}, mockCache, nil, nil, 0, nil, tc.verifier, nil)
+
+			mockRepo.On("ExistsByEmail", ctx, "test@example.com").Return(false, nil)
+			mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Run(func(args mock.Arguments) {
+				args.Get(2).(*models.User).ID = 1
+			}).Return(nil)
+			mockCache.On("Delete", ctx, getUserNotFoundCacheKey("1")).Return(nil)
+			mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+			user, err := service.CreateUser(ctx, dto.CreateUserInput{Name: "Test User", Email: "test@example.com", Password: "S3cure-Passphrase", Verified: tc.verified})
+			require.NoError(t, err, name)
+			assert.True(t, user.Verified, name)
+		}
+		assert.Empty(t, q.topics)
+	})
+
+	// 令牌有效时将用户标记为已验证并更新缓存
+	t.Run("VerifySuccess", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		verifier := newTestVerifier(nil, time.Hour)
+		service := NewUserService(mockRepo, validator.New(), &MockTxManager{}, mockCache, nil, nil, 0, nil, verifier, nil)
+
+		locked := newVerifyTestUser()
+		email, err := verifier.NewVerificationEmail(locked)
+		require.NoError(t, err)
+
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(locked, nil)
+		mockRepo.On("Update", ctx, mock.Anything, locked).Return(nil)
+		mockCache.On("SetObject", ctx, getUserCacheKey("1"), locked, userCacheTTL).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		user, err := service.VerifyEmail(ctx, email.Token)
+		require.NoError(t, err)
+		assert.True(t, user.Verified)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 过期的令牌不查询用户
+	t.Run("ExpiredToken", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator.New(), &MockTxManager{}, new(MockCache), nil, nil, 0, nil, newTestVerifier(nil, time.Hour), nil)
+
+		user, err := service.VerifyEmail(ctx, expiredVerificationToken(t))
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeBadRequest, appErr.Type)
+		assert.Equal(t, apperrors.CodeAuthVerificationTokenExpired, appErr.Code)
+		mockRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	// 签发后邮箱已修改，令牌失效
+	t.Run("EmailChanged", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		verifier := newTestVerifier(nil, time.Hour)
+		service := NewUserService(mockRepo, validator.New(), &MockTxManager{}, new(MockCache), nil, nil, 0, nil, verifier, nil)
+
+		email, err := verifier.NewVerificationEmail(newVerifyTestUser())
+		require.NoError(t, err)
+		locked := newVerifyTestUser()
+		locked.Email = "new@example.com"
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(locked, nil)
+
+		_, err = service.VerifyEmail(ctx, email.Token)
+		assert.Equal(t, apperrors.CodeAuthVerificationTokenInvalid, err.(*apperrors.Error).Code)
+		assert.False(t, locked.Verified)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
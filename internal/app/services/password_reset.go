@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/jwt"
+	"github.com/vadxq/go-rest-starter/pkg/queue"
+)
+
+// TopicPasswordReset 密码重置邮件的队列主题，由邮件发送服务订阅并发送
+const TopicPasswordReset = "email.password_reset"
+
+// PasswordResetConfig 密码重置配置
+type PasswordResetConfig struct {
+	// TokenExp 重置令牌有效期
+	TokenExp time.Duration
+	// URL 重置页面地址，令牌作为 token 查询参数追加，为空时邮件中只包含令牌
+	URL string
+}
+
+// DefaultPasswordResetConfig 默认密码重置配置
+var DefaultPasswordResetConfig = PasswordResetConfig{
+	TokenExp: 30 * time.Minute,
+}
+
+// PasswordResetEmail 密码重置邮件的队列消息负载
+type PasswordResetEmail struct {
+	UserID    uint      `json:"user_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url,omitempty"` // 带令牌的重置链接
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordResetter 签发密码重置令牌并将重置邮件放入队列
+// 令牌包含签发时密码哈希的指纹，密码修改后（包括使用令牌重置后）令牌即失效，因此只能使用一次
+type PasswordResetter struct {
+	jwtConfig *jwt.Config
+	queue     queue.Queue
+	config    *PasswordResetConfig
+}
+
+// NewPasswordResetter 创建密码重置器，config为空时使用默认配置
+// q为空（未配置Redis）时仍可校验令牌，但不能发送重置邮件
+func NewPasswordResetter(jwtConfig *jwt.Config, q queue.Queue, config *PasswordResetConfig) *PasswordResetter {
+	if config == nil {
+		config = &DefaultPasswordResetConfig
+	}
+	cfg := *config
+	if cfg.TokenExp <= 0 {
+		cfg.TokenExp = DefaultPasswordResetConfig.TokenExp
+	}
+
+	return &PasswordResetter{
+		jwtConfig: jwtConfig,
+		queue:     q,
+		config:    &cfg,
+	}
+}
+
+// CanSend 是否可以发送重置邮件
+func (p *PasswordResetter) CanSend() bool {
+	return p.queue != nil
+}
+
+// NewResetEmail 为用户签发重置令牌并生成重置邮件
+func (p *PasswordResetter) NewResetEmail(user *models.User) (*PasswordResetEmail, error) {
+	token, err := jwt.GeneratePasswordResetToken(user.ID, passwordFingerprint(user.Password), p.config.TokenExp, p.jwtConfig)
+	if err != nil {
+		return nil, apperrors.InternalError("生成密码重置令牌失败", err)
+	}
+
+	email := &PasswordResetEmail{
+		UserID:    user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(p.config.TokenExp),
+	}
+	if p.config.URL != "" {
+		if email.URL, err = tokenURL(p.config.URL, token); err != nil {
+			return nil, apperrors.InternalError("无效的密码重置链接地址", err)
+		}
+	}
+	return email, nil
+}
+
+// Send 签发重置令牌并将重置邮件放入队列
+func (p *PasswordResetter) Send(ctx context.Context, user *models.User) error {
+	if !p.CanSend() {
+		return apperrors.ServiceUnavailableError("消息队列不可用，无法发送密码重置邮件", nil)
+	}
+
+	email, err := p.NewResetEmail(user)
+	if err != nil {
+		return err
+	}
+	if err := p.queue.Publish(ctx, TopicPasswordReset, email); err != nil {
+		return apperrors.InternalError("发送密码重置邮件失败", err)
+	}
+	return nil
+}
+
+// Parse 校验重置令牌，返回签发时的用户ID和密码指纹
+func (p *PasswordResetter) Parse(token string) (uint, string, error) {
+	claims, err := jwt.ParsePasswordResetToken(token, p.jwtConfig)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return 0, "", apperrors.BadRequestError("密码重置令牌已过期", err).WithCode(apperrors.CodeAuthResetTokenExpired)
+		}
+		return 0, "", apperrors.BadRequestError("无效的密码重置令牌", err).WithCode(apperrors.CodeAuthResetTokenInvalid)
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return 0, "", apperrors.BadRequestError("无效的密码重置令牌", err).WithCode(apperrors.CodeAuthResetTokenInvalid)
+	}
+	return userID, claims.PasswordFingerprint, nil
+}
+
+// passwordFingerprint 密码哈希的指纹，用于判断签发令牌后密码是否已修改，不泄露哈希本身
+func passwordFingerprint(hash string) string {
+	sum := sha256.Sum256([]byte(hash))
+	return hex.EncodeToString(sum[:8])
+}
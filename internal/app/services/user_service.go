@@ -3,10 +3,12 @@ package services
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/dto"
@@ -14,6 +16,9 @@ import (
 	"github.com/vadxq/go-rest-starter/internal/app/repository"
 	"github.com/vadxq/go-rest-starter/pkg/cache"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
 )
 
 const (
@@ -25,32 +30,93 @@ const (
 
 	// 用户缓存过期时间
 	userCacheTTL = 30 * time.Minute
+
+	// 用户不存在标记缓存键前缀
+	userNotFoundCachePrefix = "user:notfound:"
+
+	// 用户不存在标记过期时间，远短于 userCacheTTL，避免新建用户后长时间不可见
+	userNotFoundCacheTTL = time.Minute
 )
 
 // UserService 用户服务接口
 type UserService interface {
 	CreateUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error)
+	CreateUsersBulk(ctx context.Context, inputs []dto.CreateUserInput) ([]BulkCreateResult, error)
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	UpdateUser(ctx context.Context, id string, input dto.UpdateUserInput) (*models.User, error)
+	PatchUser(ctx context.Context, id string, input dto.PatchUserInput) (*models.User, error)
 	DeleteUser(ctx context.Context, id string) error
-	ListUsers(ctx context.Context, page, pageSize int) ([]*models.User, int64, error)
+	RestoreUser(ctx context.Context, id string) (*models.User, error)
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	ListUsers(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error)
 }
 
 // userService 用户服务实现
 type userService struct {
 	userRepo  repository.UserRepository
 	validator *validator.Validate
-	db        *gorm.DB
+	txManager transaction.Manager
 	cache     cache.Cache
+	// flight 合并同一缓存键的并发加载，防止缓存击穿
+	flight *cache.SingleFlight
+	// passwordPolicy 创建和修改密码时的强度策略
+	passwordPolicy *utils.PasswordPolicy
+	// hasher 计算密码哈希
+	hasher utils.PasswordHasher
+	// maxBulkSize 单次批量创建的最大用户数
+	maxBulkSize int
+	// audit 记录用户变更的审计服务，为空时不记录
+	audit AuditService
+	// verifier 创建用户后发送验证邮件并校验验证令牌，为空时不发送验证邮件
+	verifier *EmailVerifier
+	// logger 记录不影响返回结果的失败（如缓存失效失败），日志带上请求上下文中的追踪ID
+	logger logger.Logger
+}
+
+// DefaultMaxBulkSize 单次批量创建用户的默认上限
+const DefaultMaxBulkSize = 100
+
+// BulkCreateResult 批量创建中单条记录的结果，User和Err有且只有一个非空
+type BulkCreateResult struct {
+	User *models.User
+	Err  error
+}
+
+// userListCache 用户列表缓存结构
+type userListCache struct {
+	Users []*models.User `json:"users"`
+	Total int64          `json:"total"`
 }
 
-// NewUserService 创建用户服务
-func NewUserService(ur repository.UserRepository, v *validator.Validate, db *gorm.DB, c cache.Cache) UserService {
+// NewUserService 创建用户服务，policy为空时使用默认密码强度策略，hasher为空时使用 utils.DefaultPasswordHasher，
+// maxBulkSize<=0时使用 DefaultMaxBulkSize
+// audit为空时不记录审计日志，verifier为空时不发送验证邮件，log为空时使用 slog 默认处理器
+func NewUserService(ur repository.UserRepository, v *validator.Validate, tm transaction.Manager, c cache.Cache, policy *utils.PasswordPolicy, hasher utils.PasswordHasher, maxBulkSize int, audit AuditService, verifier *EmailVerifier, log logger.Logger) UserService {
+	if policy == nil {
+		policy = &utils.DefaultPasswordPolicy
+	}
+	if hasher == nil {
+		hasher = utils.DefaultPasswordHasher
+	}
+	if maxBulkSize <= 0 {
+		maxBulkSize = DefaultMaxBulkSize
+	}
+	if log == nil {
+		log = logger.New(nil)
+	}
+
 	return &userService{
-		userRepo:  ur,
-		validator: v,
-		db:        db,
-		cache:     c,
+		userRepo:       ur,
+		validator:      v,
+		txManager:      tm,
+		cache:          c,
+		flight:         cache.NewSingleFlight(c, nil, userCacheTTL),
+		passwordPolicy: policy,
+		hasher:         hasher,
+		maxBulkSize:    maxBulkSize,
+		audit:          audit,
+		verifier:       verifier,
+		logger:         log,
 	}
 }
 
@@ -59,13 +125,190 @@ func getUserCacheKey(id string) string {
 	return fmt.Sprintf("%s%s", userCachePrefix, id)
 }
 
+// invalidateUserList 清除所有分页的用户列表缓存
+func (s *userService) invalidateUserList(ctx context.Context) {
+	s.logCacheError(ctx, "清除用户列表缓存失败", s.cache.DeleteByPattern(ctx, userListCacheKey+":*"))
+}
+
+// cacheUser 写操作提交后写入最新的用户缓存，保证写入方之后的读请求读到自己写入的数据（read-your-writes）
+// 按版本号条件写入，提交前开始的读请求回填的旧版本不会覆盖新数据；并使之后的读请求不再等待提交前开始的加载
+func (s *userService) cacheUser(ctx context.Context, id string, user *models.User) {
+	cacheKey := getUserCacheKey(id)
+	s.logCacheError(ctx, "更新用户缓存失败", cache.SetLatest(ctx, s.cache, cacheKey, user, userCacheTTL))
+	s.flight.Forget(cacheKey)
+}
+
+// logCacheError 记录缓存失效失败，不影响业务结果，但在缓存过期前可能读到旧数据
+func (s *userService) logCacheError(ctx context.Context, msg string, err error) {
+	if err != nil {
+		s.logger.WithContext(ctx).Warn(msg, "error", err)
+	}
+}
+
+// 获取用户不存在标记缓存键
+func getUserNotFoundCacheKey(id string) string {
+	return fmt.Sprintf("%s%s", userNotFoundCachePrefix, id)
+}
+
 // CreateUser 创建用户
 func (s *userService) CreateUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error) {
+	user, err := s.newUser(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// 开启事务
+	err = s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := s.userRepo.Create(ctx, tx, user); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditActionUserCreate, user.ID, nil, userSnapshot(user))
+	})
+
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
+	}
+
+	s.invalidateCreated(ctx, user)
+	s.sendVerification(ctx, user)
+
+	return user, nil
+}
+
+// CreateUsersBulk 批量创建用户，所有记录在同一事务中写入
+// 单条记录校验或写入失败不影响其他记录，结果与inputs一一对应；数量超过上限时整体拒绝
+func (s *userService) CreateUsersBulk(ctx context.Context, inputs []dto.CreateUserInput) ([]BulkCreateResult, error) {
+	if len(inputs) == 0 {
+		return nil, apperrors.ValidationError("批量创建的用户不能为空", nil)
+	}
+	if len(inputs) > s.maxBulkSize {
+		err := apperrors.ValidationError(fmt.Sprintf("单次最多创建%d个用户", s.maxBulkSize), nil).WithCode(apperrors.CodeUserBulkLimitExceeded)
+		err.Fields = []apperrors.FieldError{{
+			Field:   "users",
+			Tag:     "max",
+			Message: fmt.Sprintf("最多%d条，实际%d条", s.maxBulkSize, len(inputs)),
+		}}
+		return nil, err
+	}
+
+	results := make([]BulkCreateResult, len(inputs))
+	users := make([]*models.User, 0, len(inputs))
+	indexes := make([]int, 0, len(inputs))
+	seen := make(map[string]struct{}, len(inputs))
+
+	// 先逐条校验和加密密码，只有通过的记录进入事务
+	for i, input := range inputs {
+		if _, dup := seen[input.Email]; dup {
+			results[i].Err = apperrors.ConflictError("邮箱在本批次中重复", nil).WithCode(apperrors.CodeUserEmailDuplicateInBatch)
+			continue
+		}
+
+		user, err := s.newUser(ctx, input)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		seen[input.Email] = struct{}{}
+		users = append(users, user)
+		indexes = append(indexes, i)
+	}
+
+	if len(users) == 0 {
+		return results, nil
+	}
+
+	err := s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		errs, err := s.userRepo.CreateMany(ctx, tx, users)
+		if err != nil {
+			return err
+		}
+		for k, i := range indexes {
+			results[i] = BulkCreateResult{User: users[k], Err: errs[k]}
+			if errs[k] != nil {
+				results[i].User = nil
+				continue
+			}
+			if err := s.recordAudit(ctx, tx, models.AuditActionUserCreate, users[k].ID, nil, userSnapshot(users[k])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
+	}
+
+	for _, result := range results {
+		if result.User != nil {
+			s.invalidateCreated(ctx, result.User)
+			s.sendVerification(ctx, result.User)
+		}
+	}
+
+	return results, nil
+}
+
+// recordAudit 在事务中记录用户变更的审计日志，未配置审计服务时跳过
+func (s *userService) recordAudit(ctx context.Context, tx *gorm.DB, action string, id uint, before, after map[string]interface{}) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Record(ctx, tx, AuditEntry{
+		Action:     action,
+		EntityType: models.AuditEntityUser,
+		EntityID:   id,
+		Before:     before,
+		After:      after,
+	})
+}
+
+// userSnapshot 返回用户可审计字段的快照，密码哈希仅用于判断是否变更，记录时会脱敏
+func userSnapshot(user *models.User) map[string]interface{} {
+	if user == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":       user.Name,
+		"email":      user.Email,
+		"role":       user.Role,
+		"avatar_url": user.AvatarURL,
+		"verified":   user.Verified,
+		"password":   user.Password,
+	}
+}
+
+// invalidateCreated 清除新建用户ID的不存在标记和用户列表缓存
+func (s *userService) invalidateCreated(ctx context.Context, user *models.User) {
+	// 清除该ID的不存在标记
+	s.logCacheError(ctx, "清除用户不存在标记失败", s.cache.Delete(ctx, getUserNotFoundCacheKey(strconv.FormatUint(uint64(user.ID), 10))))
+
+	// 清除用户列表缓存
+	s.invalidateUserList(ctx)
+}
+
+// sendVerification 向新建的未验证用户发送验证邮件，失败只记录日志，不影响创建结果
+func (s *userService) sendVerification(ctx context.Context, user *models.User) {
+	if s.verifier == nil || user.Verified {
+		return
+	}
+	if err := s.verifier.Send(ctx, user); err != nil {
+		s.logger.WithContext(ctx).Warn("发送验证邮件失败", "user_id", user.ID, "error", err)
+	}
+}
+
+// newUser 校验创建用户的输入并加密密码，返回待写入的用户
+func (s *userService) newUser(ctx context.Context, input dto.CreateUserInput) (*models.User, error) {
 	// 验证输入
 	if err := s.validator.Struct(input); err != nil {
 		return nil, apperrors.ValidationError("输入数据验证失败", err)
 	}
 
+	// 校验密码强度
+	if err := utils.ValidatePasswordStrength(input.Password, *s.passwordPolicy); err != nil {
+		return nil, err
+	}
+
 	// 检查邮箱是否已存在
 	exists, err := s.userRepo.ExistsByEmail(ctx, input.Email)
 	if err != nil {
@@ -73,111 +316,153 @@ func (s *userService) CreateUser(ctx context.Context, input dto.CreateUserInput)
 	}
 
 	if exists {
-		return nil, apperrors.ConflictError("邮箱已被注册", nil)
+		return nil, apperrors.ConflictError("邮箱已被注册", nil).WithCode(apperrors.CodeUserEmailTaken)
 	}
 
 	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(input.Password)
 	if err != nil {
+		s.logger.WithContext(ctx).Error("密码加密失败", "error", err)
 		return nil, apperrors.InternalError("密码加密失败", err)
 	}
 
+	role := input.Role
+	if role == "" {
+		role = "user" // 默认角色
+	}
+
+	// 未启用邮箱验证时用户无法完成验证，直接视为已验证
 	user := &models.User{
 		Name:     input.Name,
 		Email:    input.Email,
-		Password: string(hashedPassword),
-		Role:     "user", // 默认角色
-	}
-
-	// 开启事务
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		if err := s.userRepo.Create(ctx, tx, user); err != nil {
-			return err
-		}
-		return nil
-	})
-
-	if err != nil {
-		return nil, err // 错误已经在仓库层包装
+		Password: hashedPassword,
+		Role:     role,
+		Verified: input.Verified || s.verifier == nil,
 	}
 
-	// 清除用户列表缓存
-	_ = s.cache.Delete(ctx, userListCacheKey)
-
 	return user, nil
 }
 
 // GetByID 根据ID获取用户
 func (s *userService) GetByID(ctx context.Context, id string) (*models.User, error) {
-	// 尝试从缓存获取
-	cacheKey := getUserCacheKey(id)
+	// 先查缓存，未命中时同一用户的并发请求只查询一次数据库，结果写入缓存
 	var user models.User
+	err := s.flight.GetOrLoad(ctx, getUserCacheKey(id), &user, func(ctx context.Context, _ string) (interface{}, error) {
+		// 近期确认过不存在的用户直接返回，防止缓存穿透
+		notFoundKey := getUserNotFoundCacheKey(id)
+		if _, err := s.cache.Get(ctx, notFoundKey); err == nil {
+			return nil, apperrors.NotFoundError("用户", nil)
+		}
 
-	err := s.cache.GetObject(ctx, cacheKey, &user)
-	if err == nil {
-		return &user, nil
-	}
-
-	// 缓存未命中，从数据库获取
-	user2, err := s.userRepo.GetByID(ctx, id)
+		found, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			if apperrors.AsError(err).Type == apperrors.ErrorTypeNotFound {
+				_ = s.cache.Set(ctx, notFoundKey, []byte("1"), userNotFoundCacheTTL)
+			}
+			return nil, err
+		}
+		return found, nil
+	})
 	if err != nil {
 		return nil, err // 错误已经在仓库层包装
 	}
 
-	// 存入缓存
-	_ = s.cache.SetObject(ctx, cacheKey, user2, userCacheTTL)
-
-	return user2, nil
+	return &user, nil
 }
 
-// UpdateUser 更新用户
+// UpdateUser 整体更新用户资料，密码为空时保持不变
 func (s *userService) UpdateUser(ctx context.Context, id string, input dto.UpdateUserInput) (*models.User, error) {
 	// 验证输入
 	if err := s.validator.Struct(input); err != nil {
 		return nil, apperrors.ValidationError("输入数据验证失败", err)
 	}
 
-	// 获取用户
-	user, err := s.userRepo.GetByID(ctx, id)
-	if err != nil {
-		return nil, err // 错误已经在仓库层包装
+	patch := dto.PatchUserInput{Name: &input.Name, Email: &input.Email, Version: input.Version}
+	if input.Password != "" {
+		patch.Password = &input.Password
 	}
+	return s.applyUserChanges(ctx, id, patch)
+}
 
-	// 更新用户字段
-	if input.Name != "" {
-		user.Name = input.Name
+// PatchUser 部分更新用户，只修改请求中出现的字段
+func (s *userService) PatchUser(ctx context.Context, id string, input dto.PatchUserInput) (*models.User, error) {
+	// 验证输入
+	if err := s.validator.Struct(input); err != nil {
+		return nil, apperrors.ValidationError("输入数据验证失败", err)
 	}
 
-	if input.Email != "" && input.Email != user.Email {
-		// 检查新邮箱是否存在
-		exists, err := s.userRepo.ExistsByEmail(ctx, input.Email)
-		if err != nil {
-			return nil, err // 错误已经在仓库层包装
-		}
+	return s.applyUserChanges(ctx, id, input)
+}
 
-		if exists {
-			return nil, apperrors.ConflictError("邮箱已被注册", nil)
+// applyUserChanges 将非nil字段写入用户，输入需已通过校验
+// 在事务中对用户记录加行锁后再读取和修改，并发更新同一用户时依次执行，避免后提交的更新覆盖先提交的修改；
+// 客户端提供读取时的版本号时，版本已变化说明其依据的数据已过期，返回冲突错误
+func (s *userService) applyUserChanges(ctx context.Context, id string, input dto.PatchUserInput) (*models.User, error) {
+	// 加密密码，在事务外完成以缩短持锁时间
+	var hashedPassword string
+	if input.Password != nil {
+		// 校验密码强度
+		if err := utils.ValidatePasswordStrength(*input.Password, *s.passwordPolicy); err != nil {
+			return nil, err
 		}
 
-		user.Email = input.Email
-	}
-
-	if input.Password != "" {
-		// 加密密码
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		var err error
+		hashedPassword, err = s.hasher.Hash(*input.Password)
 		if err != nil {
+			s.logger.WithContext(ctx).Error("密码加密失败", "error", err)
 			return nil, apperrors.InternalError("密码加密失败", err)
 		}
-
-		user.Password = string(hashedPassword)
 	}
 
 	// 开启事务
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	var user *models.User
+	emailChanged := false
+	err := s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		// 获取用户并加行锁
+		var err error
+		user, err = s.userRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if input.Version != nil && *input.Version != user.Version {
+			return apperrors.ConflictError("用户已被其他请求修改，请刷新后重试", nil).WithCode(apperrors.CodeUserVersionConflict)
+		}
+		before := userSnapshot(user)
+
+		// 更新用户字段
+		if input.Name != nil {
+			user.Name = *input.Name
+		}
+
+		if input.Email != nil && *input.Email != user.Email {
+			// 检查新邮箱是否存在
+			exists, err := s.userRepo.ExistsByEmail(ctx, *input.Email)
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				return apperrors.ConflictError("邮箱已被注册", nil).WithCode(apperrors.CodeUserEmailTaken)
+			}
+
+			user.Email = *input.Email
+			emailChanged = true
+
+			// 启用邮箱验证时，新邮箱需要重新验证
+			if s.verifier != nil {
+				user.Verified = false
+			}
+		}
+
+		if input.Password != nil {
+			user.Password = hashedPassword
+		}
+
 		if err := s.userRepo.Update(ctx, tx, user); err != nil {
 			return err
 		}
-		return nil
+		return s.recordAudit(ctx, tx, models.AuditActionUserUpdate, user.ID, before, userSnapshot(user))
 	})
 
 	if err != nil {
@@ -185,11 +470,16 @@ func (s *userService) UpdateUser(ctx context.Context, id string, input dto.Updat
 	}
 
 	// 更新缓存
-	cacheKey := getUserCacheKey(id)
-	_ = s.cache.SetObject(ctx, cacheKey, user, userCacheTTL)
+	s.cacheUser(ctx, id, user)
+	s.logCacheError(ctx, "清除用户不存在标记失败", s.cache.Delete(ctx, getUserNotFoundCacheKey(id)))
 
 	// 清除用户列表缓存
-	_ = s.cache.Delete(ctx, userListCacheKey)
+	s.invalidateUserList(ctx)
+
+	// 邮箱修改后发送新的验证邮件
+	if emailChanged {
+		s.sendVerification(ctx, user)
+	}
 
 	return user, nil
 }
@@ -203,59 +493,148 @@ func (s *userService) DeleteUser(ctx context.Context, id string) error {
 	}
 
 	// 开启事务
-	err = s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
 		if err := s.userRepo.Delete(ctx, tx, user.ID); err != nil {
 			return err
 		}
-		return nil
+		return s.recordAudit(ctx, tx, models.AuditActionUserDelete, user.ID, userSnapshot(user), nil)
 	})
 
 	if err != nil {
 		return err // 错误已经在仓库层包装
 	}
 
-	// 删除缓存
+	// 删除缓存，软删除不改变版本号，删除前开始的读请求回填的数据版本号较小，不会写入
 	cacheKey := getUserCacheKey(id)
-	_ = s.cache.Delete(ctx, cacheKey)
+	s.logCacheError(ctx, "清除用户缓存失败", cache.DeleteLatest(ctx, s.cache, cacheKey, int64(user.Version)+1, userCacheTTL))
+	s.flight.Forget(cacheKey)
 
 	// 清除用户列表缓存
-	_ = s.cache.Delete(ctx, userListCacheKey)
+	s.invalidateUserList(ctx)
 
 	return nil
 }
 
-// ListUsers 获取用户列表
-func (s *userService) ListUsers(ctx context.Context, page, pageSize int) ([]*models.User, int64, error) {
-	// 生成缓存键，包含分页信息
-	cacheKey := fmt.Sprintf("%s:%d:%d", userListCacheKey, page, pageSize)
+// RestoreUser 恢复已软删除的用户
+func (s *userService) RestoreUser(ctx context.Context, id string) (*models.User, error) {
+	userID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, apperrors.BadRequestError("无效的用户ID", err)
+	}
 
-	// 尝试从缓存获取
-	var cachedResult struct {
-		Users []*models.User `json:"users"`
-		Total int64          `json:"total"`
+	// 开启事务
+	err = s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		if err := s.userRepo.Restore(ctx, tx, uint(userID)); err != nil {
+			return err
+		}
+		return s.recordAudit(ctx, tx, models.AuditActionUserRestore, uint(userID), nil, nil)
+	})
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
 	}
 
-	err := s.cache.GetObject(ctx, cacheKey, &cachedResult)
-	if err == nil {
-		return cachedResult.Users, cachedResult.Total, nil
+	// 获取恢复后的用户，从主库读取避免副本复制延迟
+	user, err := s.userRepo.GetByID(repository.WithPrimary(ctx), id)
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
 	}
 
-	// 缓存未命中，从数据库获取
-	users, total, err := s.userRepo.List(ctx, page, pageSize)
+	// 删除缓存，删除时记录的版本号过期前恢复的用户不会写入缓存，读请求直接查询数据库
+	cacheKey := getUserCacheKey(id)
+	s.logCacheError(ctx, "清除用户缓存失败", s.cache.Delete(ctx, cacheKey))
+	s.logCacheError(ctx, "清除用户不存在标记失败", s.cache.Delete(ctx, getUserNotFoundCacheKey(id)))
+	s.flight.Forget(cacheKey)
+
+	// 清除用户列表缓存
+	s.invalidateUserList(ctx)
+
+	return user, nil
+}
+
+// VerifyEmail 校验邮箱验证令牌并将用户标记为已验证，已验证的用户直接返回
+// 令牌签发后邮箱已修改时令牌失效；未配置验证器时返回错误请求
+func (s *userService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	if s.verifier == nil {
+		return nil, apperrors.BadRequestError("未启用邮箱验证", nil)
+	}
+
+	userID, email, err := s.verifier.Parse(token)
 	if err != nil {
-		return nil, 0, err // 错误已经在仓库层包装
+		return nil, err
+	}
+	id := strconv.FormatUint(uint64(userID), 10)
+
+	// 开启事务
+	var user *models.User
+	changed := false
+	err = s.txManager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+		// 获取用户并加行锁
+		var err error
+		user, err = s.userRepo.GetByIDForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if user.Email != email {
+			return apperrors.BadRequestError("无效的邮箱验证令牌", nil).WithCode(apperrors.CodeAuthVerificationTokenInvalid)
+		}
+		if user.Verified {
+			return nil
+		}
+
+		before := userSnapshot(user)
+		user.Verified = true
+		if err := s.userRepo.Update(ctx, tx, user); err != nil {
+			return err
+		}
+		changed = true
+		return s.recordAudit(ctx, tx, models.AuditActionUserVerify, user.ID, before, userSnapshot(user))
+	})
+
+	if err != nil {
+		return nil, err // 错误已经在仓库层包装
+	}
+	if !changed {
+		return user, nil
 	}
 
-	// 存入缓存
-	cachedResult = struct {
-		Users []*models.User `json:"users"`
-		Total int64          `json:"total"`
-	}{
-		Users: users,
-		Total: total,
+	// 更新缓存
+	s.cacheUser(ctx, id, user)
+
+	// 清除用户列表缓存
+	s.invalidateUserList(ctx)
+
+	return user, nil
+}
+
+// ListUsers 获取用户列表
+// 带搜索词的查询直接读取数据库，不写入缓存，避免任意搜索词使缓存键无限增长
+func (s *userService) ListUsers(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	if strings.TrimSpace(opts.Search) != "" {
+		return s.userRepo.List(ctx, page, pageSize, opts)
+	}
+
+	// 生成缓存键，包含分页信息和查询选项，筛选条件转义后追加，保证不同条件互不冲突
+	cacheKey := fmt.Sprintf("%s:%d:%d:%t", userListCacheKey, page, pageSize, opts.IncludeDeleted)
+	if !opts.ListUsersFilter.IsZero() {
+		cacheKey += ":" + url.Values{
+			"role": {opts.Role},
+			"sort": {opts.Sort},
+		}.Encode()
 	}
 
-	_ = s.cache.SetObject(ctx, cacheKey, cachedResult, userCacheTTL)
+	// 先查缓存，未命中时相同查询的并发请求只查询一次数据库，结果写入缓存
+	var result userListCache
+	err := s.flight.GetOrLoad(ctx, cacheKey, &result, func(ctx context.Context, _ string) (interface{}, error) {
+		users, total, err := s.userRepo.List(ctx, page, pageSize, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &userListCache{Users: users, Total: total}, nil
+	})
+	if err != nil {
+		return nil, 0, err // 错误已经在仓库层包装
+	}
 
-	return users, total, nil
+	return result.Users, result.Total, nil
 }
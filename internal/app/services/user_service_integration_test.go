@@ -0,0 +1,100 @@
+//go:build integration
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/vadxq/go-rest-starter/internal/app/dto"
+	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/repository"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
+)
+
+// 集成测试需要PostgreSQL，通过环境变量提供连接串，例如：
+// TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=test sslmode=disable" go test -tags integration ./internal/app/services
+func newIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("未设置 TEST_DATABASE_DSN，跳过集成测试")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}))
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func TestUserService_ConcurrentUpdates(t *testing.T) {
+	db := newIntegrationDB(t)
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	service := NewUserService(repository.NewUserRepository(db, nil), validator.New(), transaction.NewGormTransactionManager(db, nil), c, nil, nil, 0, nil, nil, nil)
+
+	// 两个请求同时修改同一用户的不同字段，加锁后两次修改都应保留
+	for round := 0; round < 20; round++ {
+		user := &models.User{
+			Name:     "Original",
+			Email:    fmt.Sprintf("concurrent-%d-%s@example.com", round, t.Name()),
+			Password: "hashed",
+			Role:     "user",
+		}
+		require.NoError(t, db.Create(user).Error)
+		t.Cleanup(func() { db.Unscoped().Delete(&models.User{}, user.ID) })
+
+		id := fmt.Sprintf("%d", user.ID)
+		newName := fmt.Sprintf("Renamed %d", round)
+		newEmail := fmt.Sprintf("changed-%d-%s@example.com", round, t.Name())
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[0] = service.PatchUser(ctx, id, dto.PatchUserInput{Name: &newName})
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[1] = service.PatchUser(ctx, id, dto.PatchUserInput{Email: &newEmail})
+		}()
+		close(start)
+		wg.Wait()
+
+		require.NoError(t, errs[0])
+		require.NoError(t, errs[1])
+
+		var stored models.User
+		require.NoError(t, db.First(&stored, user.ID).Error)
+		assert.Equal(t, newName, stored.Name, "round %d", round)
+		assert.Equal(t, newEmail, stored.Email, "round %d", round)
+	}
+}
@@ -1,20 +1,31 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
 	"github.com/vadxq/go-rest-starter/internal/app/dto"
 	"github.com/vadxq/go-rest-starter/internal/app/models"
+	"github.com/vadxq/go-rest-starter/internal/app/repository"
+	"github.com/vadxq/go-rest-starter/pkg/cache"
 	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+	"github.com/vadxq/go-rest-starter/pkg/transaction"
 )
 
 // MockUserRepository 是 UserRepository 的模拟实现
@@ -27,6 +38,14 @@ func (m *MockUserRepository) Create(ctx context.Context, tx *gorm.DB, user *mode
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) CreateMany(ctx context.Context, tx *gorm.DB, users []*models.User) ([]error, error) {
+	args := m.Called(ctx, tx, users)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]error), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -35,6 +54,14 @@ func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*models.Us
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, tx *gorm.DB, id string) (*models.User, error) {
+	args := m.Called(ctx, tx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -53,31 +80,43 @@ func (m *MockUserRepository) Update(ctx context.Context, tx *gorm.DB, user *mode
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) error {
+	args := m.Called(ctx, tx, id, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ResetPassword(ctx context.Context, tx *gorm.DB, id uint, oldHash, newHash string) (bool, error) {
+	args := m.Called(ctx, tx, id, oldHash, newHash)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(ctx context.Context, tx *gorm.DB, id uint) error {
 	args := m.Called(ctx, tx, id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) List(ctx context.Context, page, pageSize int) ([]*models.User, int64, error) {
-	args := m.Called(ctx, page, pageSize)
+func (m *MockUserRepository) Restore(ctx context.Context, tx *gorm.DB, id uint) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) List(ctx context.Context, page, pageSize int, opts dto.UserListOptions) ([]*models.User, int64, error) {
+	args := m.Called(ctx, page, pageSize, opts)
 	if args.Get(0) == nil {
 		return nil, args.Get(1).(int64), args.Error(2)
 	}
 	return args.Get(0).([]*models.User), args.Get(1).(int64), args.Error(2)
 }
 
-// MockDB 是 gorm.DB 的模拟实现
-type MockDB struct {
-	mock.Mock
+// MockTxManager 是 transaction.Manager 的模拟实现，直接执行事务函数
+type MockTxManager struct{}
+
+func (m *MockTxManager) Execute(ctx context.Context, fn transaction.TxFunc) error {
+	return fn(ctx, nil)
 }
 
-func (m *MockDB) Transaction(fc func(tx *gorm.DB) error) error {
-	args := m.Called(fc)
-	if args.Error(0) != nil {
-		return args.Error(0)
-	}
-	// 执行回调函数
-	return fc(nil)
+func (m *MockTxManager) ExecuteWithOptions(ctx context.Context, opts *sql.TxOptions, fn transaction.TxFunc) error {
+	return fn(ctx, nil)
 }
 
 // MockCache 是缓存的模拟实现
@@ -113,6 +152,24 @@ func (m *MockCache) GetObject(ctx context.Context, key string, dest interface{})
 	return args.Error(0)
 }
 
+func (m *MockCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	args := m.Called(ctx, pattern)
+	return args.Error(0)
+}
+
+func (m *MockCache) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	args := m.Called(ctx, keys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[string][]byte), args.Error(1)
+}
+
+func (m *MockCache) MSet(ctx context.Context, items map[string][]byte, expiration time.Duration) error {
+	args := m.Called(ctx, items, expiration)
+	return args.Error(0)
+}
+
 func (m *MockCache) Exists(ctx context.Context, key string) (bool, error) {
 	args := m.Called(ctx, key)
 	return args.Bool(0), args.Error(1)
@@ -129,20 +186,22 @@ func TestUserService_CreateUser(t *testing.T) {
 	mockCache := new(MockCache)
 	validator := validator.New()
 
-	service := NewUserService(mockRepo, validator, nil, mockCache)
+	service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
 
 	ctx := context.Background()
 	input := dto.CreateUserInput{
 		Name:     "Test User",
 		Email:    "test@example.com",
-		Password: "password123",
+		Password: "S3cure-Passphrase",
 	}
 
 	// 成功创建用户的测试
 	t.Run("Success", func(t *testing.T) {
 		// 设置期望
 		mockRepo.On("ExistsByEmail", ctx, input.Email).Return(false, nil)
-		mockCache.On("Delete", ctx, userListCacheKey).Return(nil)
+		mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("0")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
 
 		// 执行测试
 		user, err := service.CreateUser(ctx, input)
@@ -166,7 +225,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	// 邮箱已存在的测试
 	t.Run("EmailExists", func(t *testing.T) {
 		mockRepo2 := new(MockUserRepository)
-		service2 := NewUserService(mockRepo2, validator, nil, mockCache)
+		service2 := NewUserService(mockRepo2, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
 
 		// 设置期望
 		mockRepo2.On("ExistsByEmail", ctx, input.Email).Return(true, nil)
@@ -181,6 +240,7 @@ func TestUserService_CreateUser(t *testing.T) {
 		appErr, ok := err.(*apperrors.Error)
 		assert.True(t, ok)
 		assert.Equal(t, apperrors.ErrorTypeConflict, appErr.Type)
+		assert.Equal(t, apperrors.CodeUserEmailTaken, appErr.Code)
 
 		// 验证模拟调用
 		mockRepo2.AssertExpectations(t)
@@ -189,7 +249,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	// 验证失败的测试
 	t.Run("ValidationError", func(t *testing.T) {
 		mockRepo3 := new(MockUserRepository)
-		service3 := NewUserService(mockRepo3, validator, nil, mockCache)
+		service3 := NewUserService(mockRepo3, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
 
 		invalidInput := dto.CreateUserInput{
 			Name:     "", // 空名称应该失败
@@ -208,13 +268,356 @@ func TestUserService_CreateUser(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
 	})
+
+	// 密码强度不足时拒绝创建，不访问仓库
+	t.Run("WeakPassword", func(t *testing.T) {
+		mockRepo4 := new(MockUserRepository)
+		service4 := NewUserService(mockRepo4, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+
+		user, err := service4.CreateUser(ctx, dto.CreateUserInput{
+			Name:     "Test User",
+			Email:    "test@example.com",
+			Password: "password123",
+		})
+
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+		require.NotEmpty(t, appErr.Fields)
+		assert.Equal(t, "password", appErr.Fields[0].Field)
+		mockRepo4.AssertNotCalled(t, "ExistsByEmail", mock.Anything, mock.Anything)
+	})
+
+	// 内部调用指定角色时使用该角色
+	t.Run("WithRole", func(t *testing.T) {
+		mockRepo5 := new(MockUserRepository)
+		mockCache5 := new(MockCache)
+		service5 := NewUserService(mockRepo5, validator, &MockTxManager{}, mockCache5, nil, nil, 0, nil, nil, nil)
+
+		adminInput := input
+		adminInput.Role = "admin"
+		mockRepo5.On("ExistsByEmail", ctx, input.Email).Return(false, nil)
+		mockRepo5.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+		mockCache5.On("Delete", ctx, getUserNotFoundCacheKey("0")).Return(nil)
+		mockCache5.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		user, err := service5.CreateUser(ctx, adminInput)
+		require.NoError(t, err)
+		assert.Equal(t, "admin", user.Role)
+	})
+}
+
+func TestUserService_CreateUsersBulk(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	input := func(name, email string) dto.CreateUserInput {
+		return dto.CreateUserInput{Name: name, Email: email, Password: "S3cure-Passphrase"}
+	}
+
+	// 全部创建成功，结果与输入一一对应
+	t.Run("AllSuccess", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		inputs := []dto.CreateUserInput{input("Alice", "alice@example.com"), input("Bob", "bob@example.com")}
+		mockRepo.On("ExistsByEmail", ctx, mock.Anything).Return(false, nil)
+		mockRepo.On("CreateMany", ctx, mock.Anything, mock.AnythingOfType("[]*models.User")).
+			Run(func(args mock.Arguments) {
+				for i, user := range args.Get(2).([]*models.User) {
+					user.ID = uint(i + 1)
+				}
+			}).
+			Return([]error{nil, nil}, nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("1")).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("2")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		results, err := service.CreateUsersBulk(ctx, inputs)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for i, result := range results {
+			require.NoError(t, result.Err)
+			assert.Equal(t, inputs[i].Email, result.User.Email)
+			assert.Equal(t, uint(i+1), result.User.ID)
+			assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(result.User.Password), []byte(inputs[i].Password)))
+		}
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 单条记录失败不影响其他记录
+	t.Run("PartialFailure", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		inputs := []dto.CreateUserInput{
+			input("Alice", "alice@example.com"),
+			input("Invalid", "not-an-email"),
+			input("Alice Again", "alice@example.com"),
+			input("Taken", "taken@example.com"),
+			input("Racer", "racer@example.com"),
+		}
+		mockRepo.On("ExistsByEmail", ctx, "alice@example.com").Return(false, nil)
+		mockRepo.On("ExistsByEmail", ctx, "taken@example.com").Return(true, nil)
+		mockRepo.On("ExistsByEmail", ctx, "racer@example.com").Return(false, nil)
+		// 写入时与并发注册冲突
+		mockRepo.On("CreateMany", ctx, mock.Anything, mock.MatchedBy(func(users []*models.User) bool {
+			return len(users) == 2 && users[0].Email == "alice@example.com" && users[1].Email == "racer@example.com"
+		})).
+			Run(func(args mock.Arguments) { args.Get(2).([]*models.User)[0].ID = 7 }).
+			Return([]error{nil, apperrors.ConflictError("邮箱已被注册", nil)}, nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("7")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil).Once()
+
+		results, err := service.CreateUsersBulk(ctx, inputs)
+
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+		require.NoError(t, results[0].Err)
+		assert.Equal(t, uint(7), results[0].User.ID)
+
+		expected := []apperrors.ErrorType{
+			1: apperrors.ErrorTypeValidation,
+			2: apperrors.ErrorTypeConflict,
+			3: apperrors.ErrorTypeConflict,
+			4: apperrors.ErrorTypeConflict,
+		}
+		for i := 1; i < len(results); i++ {
+			require.Error(t, results[i].Err, "index %d", i)
+			assert.Nil(t, results[i].User, "index %d", i)
+			assert.Equal(t, expected[i], apperrors.AsError(results[i].Err).Type, "index %d", i)
+		}
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 超过批量上限时整体拒绝，不访问仓库
+	t.Run("OverLimit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 2, nil, nil, nil)
+
+		inputs := []dto.CreateUserInput{
+			input("Alice", "alice@example.com"),
+			input("Bob", "bob@example.com"),
+			input("Carol", "carol@example.com"),
+		}
+		results, err := service.CreateUsersBulk(ctx, inputs)
+
+		assert.Nil(t, results)
+		require.Error(t, err)
+		appErr := apperrors.AsError(err)
+		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+		require.Len(t, appErr.Fields, 1)
+		assert.Equal(t, "users", appErr.Fields[0].Field)
+		mockRepo.AssertNotCalled(t, "ExistsByEmail", mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "CreateMany", mock.Anything, mock.Anything, mock.Anything)
+
+		_, err = service.CreateUsersBulk(ctx, nil)
+		assert.Equal(t, apperrors.ErrorTypeValidation, apperrors.AsError(err).Type)
+	})
+}
+
+func TestUserService_UpdateUser(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	existingUser := &models.User{
+		Name:  "Test User",
+		Email: "test@example.com",
+		Role:  "user",
+	}
+	existingUser.ID = 1
+
+	// 在事务中加锁读取用户后更新
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		locked := *existingUser
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(&locked, nil)
+		mockRepo.On("Update", ctx, mock.Anything, &locked).Return(nil)
+		mockCache.On("SetObject", ctx, getUserCacheKey("1"), &locked, userCacheTTL).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("1")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		user, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: "New Name", Email: existingUser.Email})
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+		assert.Equal(t, existingUser.Email, user.Email)
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 新密码强度不足时拒绝更新
+	t.Run("WeakPassword", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+
+		user, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{
+			Name:     existingUser.Name,
+			Email:    existingUser.Email,
+			Password: "alllowercase",
+		})
+
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+		mockRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	// PUT整体替换资料，缺少必填字段时拒绝而不是保留原值
+	t.Run("RequiresFullRepresentation", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+
+		_, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: "New Name"})
+
+		appErr := apperrors.AsError(err)
+		assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+		require.Len(t, appErr.Fields, 1)
+		assert.Equal(t, "Email", appErr.Fields[0].Field)
+		mockRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_PatchUser(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	existingUser := &models.User{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Password: "hashed",
+		Role:     "user",
+	}
+	existingUser.ID = 1
+
+	// expectUpdate 设置加锁读取、更新和缓存刷新的期望，返回被修改的用户副本
+	expectUpdate := func(mockRepo *MockUserRepository, mockCache *MockCache) *models.User {
+		locked := *existingUser
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(&locked, nil)
+		mockRepo.On("Update", ctx, mock.Anything, &locked).Return(nil)
+		mockCache.On("SetObject", ctx, getUserCacheKey("1"), &locked, userCacheTTL).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("1")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+		return &locked
+	}
+
+	// 省略的字段保持不变
+	t.Run("OmittedFieldsUnchanged", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+		expectUpdate(mockRepo, mockCache)
+
+		var input dto.PatchUserInput
+		require.NoError(t, json.Unmarshal([]byte(`{"name":"New Name","email":null}`), &input))
+		require.Nil(t, input.Email)
+		require.Nil(t, input.Password)
+
+		user, err := service.PatchUser(ctx, "1", input)
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+		assert.Equal(t, existingUser.Email, user.Email)
+		assert.Equal(t, existingUser.Password, user.Password)
+		mockRepo.AssertNotCalled(t, "ExistsByEmail", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 空请求体不修改任何字段
+	t.Run("EmptyPatch", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+		expectUpdate(mockRepo, mockCache)
+
+		user, err := service.PatchUser(ctx, "1", dto.PatchUserInput{})
+		require.NoError(t, err)
+		assert.Equal(t, existingUser.Name, user.Name)
+		assert.Equal(t, existingUser.Email, user.Email)
+	})
+
+	// 显式的空字符串表示设置为空值，按字段规则校验，不会被当作省略
+	t.Run("ExplicitEmptyValidated", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+
+		for _, body := range []string{`{"name":""}`, `{"email":""}`, `{"password":""}`} {
+			var input dto.PatchUserInput
+			require.NoError(t, json.Unmarshal([]byte(body), &input))
+
+			user, err := service.PatchUser(ctx, "1", input)
+			assert.Nil(t, user, body)
+			assert.Equal(t, apperrors.ErrorTypeValidation, apperrors.AsError(err).Type, body)
+		}
+		mockRepo.AssertNotCalled(t, "GetByIDForUpdate", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	// 修改邮箱和密码
+	t.Run("EmailAndPassword", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+		expectUpdate(mockRepo, mockCache)
+		mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+
+		email, password := "new@example.com", "S3cure-Passphrase"
+		user, err := service.PatchUser(ctx, "1", dto.PatchUserInput{Email: &email, Password: &password})
+		require.NoError(t, err)
+		assert.Equal(t, existingUser.Name, user.Name)
+		assert.Equal(t, email, user.Email)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)))
+		mockRepo.AssertExpectations(t)
+	})
+
+	// 客户端提供的版本与当前版本一致时更新
+	t.Run("MatchingVersion", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+		locked := expectUpdate(mockRepo, mockCache)
+		locked.Version = 3
+
+		name, version := "New Name", uint(3)
+		user, err := service.PatchUser(ctx, "1", dto.PatchUserInput{Name: &name, Version: &version})
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+		mockRepo.AssertExpectations(t)
+	})
+
+	// 客户端读取后记录已被修改，版本不一致时返回冲突且不写入
+	t.Run("StaleVersion", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+		locked := *existingUser
+		locked.Version = 4
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(&locked, nil)
+
+		name, version := "New Name", uint(3)
+		user, err := service.PatchUser(ctx, "1", dto.PatchUserInput{Name: &name, Version: &version})
+		assert.Nil(t, user)
+		assert.Equal(t, apperrors.ErrorTypeConflict, apperrors.AsError(err).Type)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+
+		// PUT同样校验版本
+		_, err = service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: name, Email: existingUser.Email, Version: &version})
+		assert.Equal(t, apperrors.ErrorTypeConflict, apperrors.AsError(err).Type)
+	})
 }
 
 func TestUserService_GetByID(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockCache := new(MockCache)
 	validator := validator.New()
-	service := NewUserService(mockRepo, validator, nil, mockCache)
+	service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
 
 	ctx := context.Background()
 	userID := "1"
@@ -250,12 +653,13 @@ func TestUserService_GetByID(t *testing.T) {
 	t.Run("CacheMissDBSuccess", func(t *testing.T) {
 		mockRepo2 := new(MockUserRepository)
 		mockCache2 := new(MockCache)
-		service2 := NewUserService(mockRepo2, validator, nil, mockCache2)
+		service2 := NewUserService(mockRepo2, validator, &MockTxManager{}, mockCache2, nil, nil, 0, nil, nil, nil)
 
 		cacheKey := getUserCacheKey(userID)
 		
 		// 设置期望
 		mockCache2.On("GetObject", ctx, cacheKey, mock.AnythingOfType("*models.User")).Return(errors.New("cache miss"))
+		mockCache2.On("Get", ctx, getUserNotFoundCacheKey(userID)).Return(nil, cache.ErrNotFound)
 		mockRepo2.On("GetByID", ctx, userID).Return(expectedUser, nil)
 		mockCache2.On("SetObject", ctx, cacheKey, expectedUser, userCacheTTL).Return(nil)
 
@@ -277,13 +681,16 @@ func TestUserService_GetByID(t *testing.T) {
 	t.Run("UserNotFound", func(t *testing.T) {
 		mockRepo3 := new(MockUserRepository)
 		mockCache3 := new(MockCache)
-		service3 := NewUserService(mockRepo3, validator, nil, mockCache3)
+		service3 := NewUserService(mockRepo3, validator, &MockTxManager{}, mockCache3, nil, nil, 0, nil, nil, nil)
 
 		cacheKey := getUserCacheKey(userID)
 
 		// 设置期望
 		mockCache3.On("GetObject", ctx, cacheKey, mock.AnythingOfType("*models.User")).Return(errors.New("cache miss"))
+		mockCache3.On("Get", ctx, getUserNotFoundCacheKey(userID)).Return(nil, cache.ErrNotFound)
 		mockRepo3.On("GetByID", ctx, userID).Return(nil, apperrors.NotFoundError("用户", nil))
+		// 记录不存在标记，过期时间短于用户缓存
+		mockCache3.On("Set", ctx, getUserNotFoundCacheKey(userID), []byte("1"), userNotFoundCacheTTL).Return(nil)
 
 		// 执行测试
 		user, err := service3.GetByID(ctx, userID)
@@ -300,4 +707,756 @@ func TestUserService_GetByID(t *testing.T) {
 		mockRepo3.AssertExpectations(t)
 		mockCache3.AssertExpectations(t)
 	})
-}
\ No newline at end of file
+}
+func TestUserService_DeleteUser(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	userID := "1"
+	existingUser := &models.User{
+		Name:  "Test User",
+		Email: "test@example.com",
+		Role:  "user",
+	}
+	existingUser.ID = 1
+
+	// 软删除成功，并清除相关缓存
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		mockRepo.On("GetByID", ctx, userID).Return(existingUser, nil)
+		mockRepo.On("Delete", ctx, mock.Anything, existingUser.ID).Return(nil)
+		mockCache.On("Delete", ctx, getUserCacheKey(userID)).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		err := service.DeleteUser(ctx, userID)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 已删除的用户再次删除返回未找到
+	t.Run("AlreadyDeleted", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		mockRepo.On("GetByID", ctx, userID).Return(nil, apperrors.NotFoundError("用户", nil))
+
+		err := service.DeleteUser(ctx, userID)
+
+		assert.Error(t, err)
+		appErr, ok := err.(*apperrors.Error)
+		assert.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeNotFound, appErr.Type)
+		mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	activeUser := &models.User{Name: "Active", Email: "active@example.com", Role: "user"}
+	activeUser.ID = 1
+	deletedUser := &models.User{Name: "Deleted", Email: "deleted@example.com", Role: "user"}
+	deletedUser.ID = 2
+	deletedUser.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	// 默认不包含已删除用户
+	t.Run("ExcludeDeletedByDefault", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		opts := dto.UserListOptions{}
+		cacheKey := userListCacheKey + ":1:10:false"
+		mockCache.On("GetObject", ctx, cacheKey, mock.Anything).Return(errors.New("cache miss"))
+		mockRepo.On("List", ctx, 1, 10, opts).Return([]*models.User{activeUser}, int64(1), nil)
+		mockCache.On("SetObject", ctx, cacheKey, mock.Anything, userCacheTTL).Return(nil)
+
+		users, total, err := service.ListUsers(ctx, 1, 10, opts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, users, 1)
+		assert.False(t, users[0].DeletedAt.Valid)
+		mockRepo.AssertExpectations(t)
+	})
+
+	// 显式要求包含已删除用户，且使用独立的缓存键
+	t.Run("IncludeDeleted", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		opts := dto.UserListOptions{IncludeDeleted: true}
+		cacheKey := userListCacheKey + ":1:10:true"
+		mockCache.On("GetObject", ctx, cacheKey, mock.Anything).Return(errors.New("cache miss"))
+		mockRepo.On("List", ctx, 1, 10, opts).Return([]*models.User{activeUser, deletedUser}, int64(2), nil)
+		mockCache.On("SetObject", ctx, cacheKey, mock.Anything, userCacheTTL).Return(nil)
+
+		users, total, err := service.ListUsers(ctx, 1, 10, opts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, users, 2)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 筛选条件传递给仓库层，并写入缓存键，列表失效时一并清除
+	t.Run("Filter", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		opts := dto.UserListOptions{
+			ListUsersFilter: dto.ListUsersFilter{Role: "user&admin", Sort: "-name"},
+		}
+		cacheKey := userListCacheKey + ":1:10:false:role=user%26admin&sort=-name"
+		mockCache.On("GetObject", ctx, cacheKey, mock.Anything).Return(errors.New("cache miss"))
+		mockRepo.On("List", ctx, 1, 10, opts).Return([]*models.User{activeUser}, int64(1), nil)
+		mockCache.On("SetObject", ctx, cacheKey, mock.Anything, userCacheTTL).Return(nil)
+
+		users, total, err := service.ListUsers(ctx, 1, 10, opts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, users, 1)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 带搜索词的查询直接读取数据库，不读写缓存
+	t.Run("SearchNotCached", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		opts := dto.UserListOptions{
+			ListUsersFilter: dto.ListUsersFilter{Search: "act:ive*", Role: "user"},
+		}
+		mockRepo.On("List", ctx, 1, 10, opts).Return([]*models.User{activeUser}, int64(1), nil)
+
+		users, total, err := service.ListUsers(ctx, 1, 10, opts)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, users, 1)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertNotCalled(t, "GetObject", mock.Anything, mock.Anything, mock.Anything)
+		mockCache.AssertNotCalled(t, "SetObject", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestUserService_StampedeProtection(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	const concurrency = 50
+
+	// run 同时发起并发请求，等待全部返回
+	run := func(fn func() error) []error {
+		start := make(chan struct{})
+		errs := make([]error, concurrency)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				errs[i] = fn()
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		return errs
+	}
+
+	// 缓存未命中时，并发获取同一用户只查询一次数据库
+	t.Run("GetByID", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user"}
+		user.ID = 1
+		cacheKey := getUserCacheKey("1")
+		mockCache.On("GetObject", ctx, cacheKey, mock.AnythingOfType("*models.User")).Return(errors.New("cache miss"))
+		mockCache.On("Get", ctx, getUserNotFoundCacheKey("1")).Return(nil, cache.ErrNotFound)
+		mockRepo.On("GetByID", ctx, "1").After(200*time.Millisecond).Return(user, nil)
+		mockCache.On("SetObject", ctx, cacheKey, user, userCacheTTL).Return(nil)
+
+		errs := run(func() error {
+			got, err := service.GetByID(ctx, "1")
+			if err == nil && got.Email != user.Email {
+				return errors.New("unexpected user")
+			}
+			return err
+		})
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+		mockCache.AssertNumberOfCalls(t, "SetObject", 1)
+	})
+
+	// 并发获取同一页用户列表只查询一次数据库
+	t.Run("ListUsers", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user"}
+		user.ID = 1
+		opts := dto.UserListOptions{}
+		cacheKey := userListCacheKey + ":1:10:false"
+		mockCache.On("GetObject", ctx, cacheKey, mock.Anything).Return(errors.New("cache miss"))
+		mockRepo.On("List", ctx, 1, 10, opts).After(200*time.Millisecond).Return([]*models.User{user}, int64(1), nil)
+		mockCache.On("SetObject", ctx, cacheKey, mock.Anything, userCacheTTL).Return(nil)
+
+		errs := run(func() error {
+			users, total, err := service.ListUsers(ctx, 1, 10, opts)
+			if err == nil && (len(users) != 1 || total != 1) {
+				return errors.New("unexpected list")
+			}
+			return err
+		})
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		mockRepo.AssertNumberOfCalls(t, "List", 1)
+	})
+
+	// 数据库错误共享给所有等待者
+	t.Run("SharedNotFound", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		cacheKey := getUserCacheKey("404")
+		mockCache.On("GetObject", ctx, cacheKey, mock.AnythingOfType("*models.User")).Return(errors.New("cache miss"))
+		mockCache.On("Get", ctx, getUserNotFoundCacheKey("404")).Return(nil, cache.ErrNotFound)
+		mockCache.On("Set", ctx, getUserNotFoundCacheKey("404"), []byte("1"), userNotFoundCacheTTL).Return(nil)
+		mockRepo.On("GetByID", ctx, "404").After(200*time.Millisecond).Return(nil, apperrors.NotFoundError("用户", nil))
+
+		errs := run(func() error {
+			_, err := service.GetByID(ctx, "404")
+			return err
+		})
+
+		for _, err := range errs {
+			appErr, ok := err.(*apperrors.Error)
+			require.True(t, ok)
+			assert.Equal(t, apperrors.ErrorTypeNotFound, appErr.Type)
+		}
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	})
+}
+
+func TestUserService_NegativeCache(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	// newService 创建使用miniredis缓存的用户服务
+	newService := func(t *testing.T) (UserService, *MockUserRepository, *miniredis.Miniredis) {
+		mr := miniredis.RunT(t)
+		c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+
+		mockRepo := new(MockUserRepository)
+		return NewUserService(mockRepo, validator, &MockTxManager{}, c, nil, nil, 0, nil, nil, nil), mockRepo, mr
+	}
+
+	assertNotFound := func(t *testing.T, err error) {
+		appErr, ok := err.(*apperrors.Error)
+		require.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeNotFound, appErr.Type)
+	}
+
+	// 重复查询不存在的用户只查询一次数据库
+	t.Run("RepeatedMissingID", func(t *testing.T) {
+		service, mockRepo, mr := newService(t)
+		mockRepo.On("GetByID", ctx, "404").Return(nil, apperrors.NotFoundError("用户", nil))
+
+		for i := 0; i < 5; i++ {
+			_, err := service.GetByID(ctx, "404")
+			assertNotFound(t, err)
+		}
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+
+		// 不存在标记的过期时间短于用户缓存
+		ttl := mr.TTL(getUserNotFoundCacheKey("404"))
+		assert.Equal(t, userNotFoundCacheTTL, ttl)
+		assert.Less(t, ttl, userCacheTTL)
+
+		// 标记过期后重新查询数据库
+		mr.FastForward(userNotFoundCacheTTL + time.Second)
+		_, err := service.GetByID(ctx, "404")
+		assertNotFound(t, err)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 2)
+	})
+
+	// 创建用户后清除该ID的不存在标记
+	t.Run("CreateClearsTombstone", func(t *testing.T) {
+		service, mockRepo, mr := newService(t)
+		mockRepo.On("GetByID", ctx, "7").Return(nil, apperrors.NotFoundError("用户", nil)).Once()
+
+		_, err := service.GetByID(ctx, "7")
+		assertNotFound(t, err)
+		require.True(t, mr.Exists(getUserNotFoundCacheKey("7")))
+
+		mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+		mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
+			args[2].(*models.User).ID = 7
+		})
+		_, err = service.CreateUser(ctx, dto.CreateUserInput{Name: "New User", Email: "new@example.com", Password: "S3cure-Passphrase"})
+		require.NoError(t, err)
+		assert.False(t, mr.Exists(getUserNotFoundCacheKey("7")))
+
+		created := &models.User{Name: "New User", Email: "new@example.com", Role: "user"}
+		created.ID = 7
+		mockRepo.On("GetByID", ctx, "7").Return(created, nil).Once()
+		user, err := service.GetByID(ctx, "7")
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), user.ID)
+	})
+
+	// 更新用户后清除该ID的不存在标记
+	t.Run("UpdateClearsTombstone", func(t *testing.T) {
+		service, mockRepo, mr := newService(t)
+		require.NoError(t, mr.Set(getUserNotFoundCacheKey("1"), "1"))
+
+		existing := &models.User{Name: "Old Name", Email: "test@example.com", Role: "user"}
+		existing.ID = 1
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(existing, nil)
+		mockRepo.On("Update", ctx, mock.Anything, existing).Return(nil)
+
+		_, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: "New Name", Email: existing.Email})
+		require.NoError(t, err)
+		assert.False(t, mr.Exists(getUserNotFoundCacheKey("1")))
+	})
+}
+
+func TestUserService_ReadYourWrites(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	// newCachedUser 创建数据库中的用户，每次返回新的副本
+	newCachedUser := func(name string, version uint) *models.User {
+		user := &models.User{Name: name, Email: "test@example.com", Role: "user", Version: version}
+		user.ID = 1
+		return user
+	}
+
+	// blockingGetByID 模拟更新提交前开始、提交后才返回的慢查询，返回started和release
+	blockingGetByID := func(mockRepo *MockUserRepository, result *models.User, err error) (chan struct{}, chan struct{}) {
+		started, release := make(chan struct{}), make(chan struct{})
+		mockRepo.On("GetByID", mock.Anything, "1").Return(result, err).Once().Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		})
+		return started, release
+	}
+
+	// mockUpdate 与仓库一样在更新时递增版本号
+	mockUpdate := func(mockRepo *MockUserRepository) {
+		mockRepo.On("GetByIDForUpdate", ctx, mock.Anything, "1").Return(newCachedUser("Old Name", 1), nil)
+		mockRepo.On("Update", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Run(func(args mock.Arguments) {
+			args[2].(*models.User).Version++
+		})
+	}
+
+	// 更新前开始的读请求在更新后回填旧数据，不覆盖更新写入的缓存
+	t.Run("StaleFillAfterUpdate", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, c, nil, nil, 0, nil, nil, nil)
+
+		started, release := blockingGetByID(mockRepo, newCachedUser("Old Name", 1), nil)
+		staleDone := make(chan *models.User)
+		go func() {
+			user, _ := service.GetByID(ctx, "1")
+			staleDone <- user
+		}()
+		<-started
+
+		mockUpdate(mockRepo)
+		updated, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: "New Name", Email: "test@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), updated.Version)
+
+		user, err := service.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+
+		close(release)
+		assert.Equal(t, "Old Name", (<-staleDone).Name)
+
+		user, err = service.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+		assert.Equal(t, uint(2), user.Version)
+		mockRepo.AssertNumberOfCalls(t, "GetByID", 1)
+	})
+
+	// 缓存不可用时，更新后的读请求不等待更新前开始的加载，重新查询数据库
+	t.Run("NoCache", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, cache.NewNullCache(), nil, nil, 0, nil, nil, nil)
+
+		started, release := blockingGetByID(mockRepo, newCachedUser("Old Name", 1), nil)
+		staleDone := make(chan struct{})
+		go func() {
+			_, _ = service.GetByID(ctx, "1")
+			close(staleDone)
+		}()
+		<-started
+
+		mockUpdate(mockRepo)
+		_, err := service.UpdateUser(ctx, "1", dto.UpdateUserInput{Name: "New Name", Email: "test@example.com"})
+		require.NoError(t, err)
+
+		mockRepo.On("GetByID", mock.Anything, "1").Return(newCachedUser("New Name", 2), nil).Once()
+		user, err := service.GetByID(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, "New Name", user.Name)
+
+		close(release)
+		<-staleDone
+	})
+
+	// 删除前开始的读请求不能回填已删除的用户
+	t.Run("StaleFillAfterDelete", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+		mockRepo := new(MockUserRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, c, nil, nil, 0, nil, nil, nil)
+
+		started, release := blockingGetByID(mockRepo, newCachedUser("Old Name", 1), nil)
+		staleDone := make(chan struct{})
+		go func() {
+			_, _ = service.GetByID(ctx, "1")
+			close(staleDone)
+		}()
+		<-started
+
+		mockRepo.On("GetByID", ctx, "1").Return(newCachedUser("Old Name", 1), nil).Once()
+		mockRepo.On("Delete", ctx, mock.Anything, uint(1)).Return(nil)
+		require.NoError(t, service.DeleteUser(ctx, "1"))
+
+		close(release)
+		<-staleDone
+		assert.False(t, mr.Exists(getUserCacheKey("1")))
+
+		mockRepo.On("GetByID", mock.Anything, "1").Return(nil, apperrors.NotFoundError("用户", nil)).Once()
+		_, err = service.GetByID(ctx, "1")
+		assert.Equal(t, apperrors.ErrorTypeNotFound, apperrors.AsError(err).Type)
+	})
+}
+
+func TestUserService_ListInvalidation(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, validator, &MockTxManager{}, c, nil, nil, 0, nil, nil, nil)
+
+	user := &models.User{Name: "Test User", Email: "test@example.com", Role: "user"}
+	user.ID = 1
+	mockRepo.On("List", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*models.User{user}, int64(1), nil)
+
+	// 缓存多个分页的列表
+	for page := 1; page <= 3; page++ {
+		_, _, err := service.ListUsers(ctx, page, 10, dto.UserListOptions{})
+		require.NoError(t, err)
+	}
+	_, _, err = service.ListUsers(ctx, 1, 20, dto.UserListOptions{IncludeDeleted: true})
+	require.NoError(t, err)
+	require.Len(t, mr.Keys(), 4)
+
+	// 创建用户后清除所有分页的列表缓存
+	mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil)
+	mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+	_, err = service.CreateUser(ctx, dto.CreateUserInput{Name: "New User", Email: "new@example.com", Password: "S3cure-Passphrase"})
+	require.NoError(t, err)
+
+	for _, key := range mr.Keys() {
+		assert.NotContains(t, key, userListCacheKey)
+	}
+
+	// 再次查询从数据库加载
+	_, _, err = service.ListUsers(ctx, 2, 10, dto.UserListOptions{})
+	require.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "List", 5)
+}
+
+func TestUserService_DeleteRemovesFromList(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, validator, &MockTxManager{}, c, nil, nil, 0, nil, nil, nil)
+
+	alice := &models.User{Name: "Alice", Email: "alice@example.com", Role: "user"}
+	alice.ID = 1
+	bob := &models.User{Name: "Bob", Email: "bob@example.com", Role: "user"}
+	bob.ID = 2
+
+	// 删除前后数据库返回不同的列表
+	mockRepo.On("List", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*models.User{alice, bob}, int64(2), nil).Once()
+	mockRepo.On("List", ctx, mock.Anything, mock.Anything, mock.Anything).Return([]*models.User{bob}, int64(1), nil)
+	mockRepo.On("GetByID", ctx, "1").Return(alice, nil)
+	mockRepo.On("Delete", ctx, mock.Anything, alice.ID).Return(nil)
+
+	users, total, err := service.ListUsers(ctx, 1, 10, dto.UserListOptions{})
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, int64(2), total)
+
+	require.NoError(t, service.DeleteUser(ctx, "1"))
+
+	// 删除后重新查询，已删除的用户不应出现在列表中
+	users, total, err = service.ListUsers(ctx, 1, 10, dto.UserListOptions{})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, bob.ID, users[0].ID)
+	assert.Equal(t, int64(1), total)
+	mockRepo.AssertNumberOfCalls(t, "List", 2)
+}
+
+func TestUserService_RestoreUser(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+	userID := "1"
+	restoredUser := &models.User{
+		Name:  "Test User",
+		Email: "test@example.com",
+		Role:  "user",
+	}
+	restoredUser.ID = 1
+
+	// 恢复成功，返回用户并清除缓存
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		mockRepo.On("Restore", ctx, mock.Anything, uint(1)).Return(nil)
+		// 恢复后从主库读取
+		mockRepo.On("GetByID", repository.WithPrimary(ctx), userID).Return(restoredUser, nil)
+		mockCache.On("Delete", ctx, getUserCacheKey(userID)).Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey(userID)).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		user, err := service.RestoreUser(ctx, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, restoredUser.ID, user.ID)
+		mockRepo.AssertExpectations(t)
+		mockCache.AssertExpectations(t)
+	})
+
+	// 用户不存在或未被删除
+	t.Run("NotDeleted", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, nil)
+
+		mockRepo.On("Restore", ctx, mock.Anything, uint(1)).Return(apperrors.NotFoundError("已删除用户", nil))
+
+		user, err := service.RestoreUser(ctx, userID)
+
+		assert.Error(t, err)
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperrors.Error)
+		assert.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeNotFound, appErr.Type)
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	// 无效的用户ID
+	t.Run("InvalidID", func(t *testing.T) {
+		service := NewUserService(new(MockUserRepository), validator, &MockTxManager{}, new(MockCache), nil, nil, 0, nil, nil, nil)
+
+		user, err := service.RestoreUser(ctx, "abc")
+
+		assert.Error(t, err)
+		assert.Nil(t, user)
+		appErr, ok := err.(*apperrors.Error)
+		assert.True(t, ok)
+		assert.Equal(t, apperrors.ErrorTypeBadRequest, appErr.Type)
+	})
+}
+
+func TestUserService_Audit(t *testing.T) {
+	validator := validator.New()
+	ctx := context.Background()
+
+	// 创建用户时在同一事务中写入审计日志
+	t.Run("CreateRecordsAudit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		auditRepo := new(MockAuditLogRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, NewAuditService(auditRepo), nil, nil)
+
+		input := dto.CreateUserInput{Name: "Test User", Email: "test@example.com", Password: "S3cure-Passphrase"}
+		mockRepo.On("ExistsByEmail", ctx, input.Email).Return(false, nil)
+		mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).
+			Run(func(args mock.Arguments) { args.Get(2).(*models.User).ID = 5 }).
+			Return(nil)
+		mockCache.On("Delete", ctx, getUserNotFoundCacheKey("5")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+		auditRepo.On("Create", ctx, mock.Anything, mock.MatchedBy(func(log *models.AuditLog) bool {
+			return log.Action == models.AuditActionUserCreate &&
+				log.EntityType == models.AuditEntityUser &&
+				log.EntityID == 5 &&
+				log.Changes["email"].After == input.Email &&
+				log.Changes["password"].After == auditRedacted
+		})).Return(nil)
+
+		_, err := service.CreateUser(ctx, input)
+
+		assert.NoError(t, err)
+		auditRepo.AssertExpectations(t)
+	})
+
+	// 删除用户时记录删除前的字段
+	t.Run("DeleteRecordsAudit", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		auditRepo := new(MockAuditLogRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, NewAuditService(auditRepo), nil, nil)
+
+		existing := &models.User{Name: "Test User", Email: "test@example.com", Role: "user"}
+		existing.ID = 1
+		mockRepo.On("GetByID", ctx, "1").Return(existing, nil)
+		mockRepo.On("Delete", ctx, mock.Anything, uint(1)).Return(nil)
+		mockCache.On("Delete", ctx, getUserCacheKey("1")).Return(nil)
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+		auditRepo.On("Create", ctx, mock.Anything, mock.MatchedBy(func(log *models.AuditLog) bool {
+			change := log.Changes["email"]
+			return log.Action == models.AuditActionUserDelete &&
+				log.EntityID == 1 &&
+				change.Before == existing.Email && change.After == nil
+		})).Return(nil)
+
+		err := service.DeleteUser(ctx, "1")
+
+		assert.NoError(t, err)
+		auditRepo.AssertExpectations(t)
+	})
+
+	// 审计日志写入失败时整体失败，不清除缓存
+	t.Run("RecordFailure", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		auditRepo := new(MockAuditLogRepository)
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, new(MockCache), nil, nil, 0, NewAuditService(auditRepo), nil, nil)
+
+		existing := &models.User{Name: "Test User", Email: "test@example.com"}
+		existing.ID = 1
+		mockRepo.On("GetByID", ctx, "1").Return(existing, nil)
+		mockRepo.On("Delete", ctx, mock.Anything, uint(1)).Return(nil)
+		auditRepo.On("Create", ctx, mock.Anything, mock.Anything).Return(apperrors.InternalError("创建审计日志失败", nil))
+
+		err := service.DeleteUser(ctx, "1")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestUserService_Logging(t *testing.T) {
+	validator := validator.New()
+
+	// 缓存失效失败不影响删除结果，告警日志带上请求上下文中的追踪ID
+	t.Run("CacheErrorWithTraceID", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockCache := new(MockCache)
+		var buf bytes.Buffer
+		service := NewUserService(mockRepo, validator, &MockTxManager{}, mockCache, nil, nil, 0, nil, nil, logger.New(slog.NewJSONHandler(&buf, nil)))
+
+		ctx := logger.WithTraceID(logger.WithRequestID(context.Background(), "req-1"), "trace-1")
+		existing := &models.User{Name: "Test User", Email: "test@example.com"}
+		existing.ID = 1
+		mockRepo.On("GetByID", ctx, "1").Return(existing, nil)
+		mockRepo.On("Delete", ctx, mock.Anything, uint(1)).Return(nil)
+		mockCache.On("Delete", ctx, getUserCacheKey("1")).Return(errors.New("redis unavailable"))
+		mockCache.On("DeleteByPattern", ctx, userListCacheKey+":*").Return(nil)
+
+		err := service.DeleteUser(ctx, "1")
+		require.NoError(t, err)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "WARN", entry["level"])
+		assert.Equal(t, "清除用户缓存失败", entry["msg"])
+		assert.Equal(t, "trace-1", entry["trace_id"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, "redis unavailable", entry["error"])
+	})
+}
+
+func TestUserService_NullCache(t *testing.T) {
+	ctx := context.Background()
+	validator := validator.New()
+
+	existingUser := &models.User{Name: "Test User", Email: "test@example.com", Role: "user"}
+	existingUser.ID = 1
+
+	// 缓存不可用时每次读取都访问数据库，写入和删除正常完成
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(mockRepo, validator, &MockTxManager{}, cache.NewNullCache(), nil, nil, 0, nil, nil, nil)
+
+	t.Run("CreateUser", func(t *testing.T) {
+		mockRepo.On("ExistsByEmail", ctx, "new@example.com").Return(false, nil).Once()
+		mockRepo.On("Create", ctx, mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+		user, err := service.CreateUser(ctx, dto.CreateUserInput{Name: "New User", Email: "new@example.com", Password: "S3cure-Passphrase"})
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", user.Email)
+	})
+
+	t.Run("GetByID", func(t *testing.T) {
+		mockRepo.On("GetByID", ctx, "1").Return(existingUser, nil).Twice()
+
+		for i := 0; i < 2; i++ {
+			user, err := service.GetByID(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, existingUser.Email, user.Email)
+		}
+	})
+
+	t.Run("ListUsers", func(t *testing.T) {
+		mockRepo.On("List", ctx, 1, 10, dto.UserListOptions{}).Return([]*models.User{existingUser}, int64(1), nil).Twice()
+
+		for i := 0; i < 2; i++ {
+			users, total, err := service.ListUsers(ctx, 1, 10, dto.UserListOptions{})
+			require.NoError(t, err)
+			assert.Len(t, users, 1)
+			assert.Equal(t, int64(1), total)
+		}
+	})
+
+	t.Run("DeleteUser", func(t *testing.T) {
+		mockRepo.On("GetByID", ctx, "1").Return(existingUser, nil).Once()
+		mockRepo.On("Delete", ctx, mock.Anything, existingUser.ID).Return(nil).Once()
+
+		assert.NoError(t, service.DeleteUser(ctx, "1"))
+	})
+
+	mockRepo.AssertExpectations(t)
+}
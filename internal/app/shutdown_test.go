@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/internal/app/config"
+)
+
+func TestApp_ShutdownDelay(t *testing.T) {
+	newApp := func(delay time.Duration) (*App, *httptest.Server) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(server.Close)
+
+		cfg := &config.AppConfig{}
+		cfg.Server.ShutdownDelay = delay
+		return &App{Config: cfg, Server: server.Config}, server
+	}
+
+	// 等待期间仍正常处理新请求，结束后才停止服务器
+	t.Run("ServesDuringDelay", func(t *testing.T) {
+		app, server := newApp(200 * time.Millisecond)
+
+		done := make(chan error, 1)
+		start := time.Now()
+		go func() { done <- app.Shutdown(context.Background()) }()
+
+		time.Sleep(50 * time.Millisecond)
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		require.NoError(t, <-done)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	})
+
+	// 关闭超时先于等待结束时不再等待
+	t.Run("ContextDeadline", func(t *testing.T) {
+		app, _ := newApp(time.Minute)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		require.NoError(t, app.Shutdown(ctx))
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+// 进行中的请求结束后才关闭Redis等连接
+func TestApp_ShutdownClosesConnectionsAfterRequests(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	app := &App{Config: &config.AppConfig{}, Redis: client}
+
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		if err := client.Ping(r.Context()).Err(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	app.Server = server.Config
+
+	result := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			result <- 0
+			return
+		}
+		resp.Body.Close()
+		result <- resp.StatusCode
+	}()
+
+	<-started
+	require.NoError(t, app.Shutdown(context.Background()))
+	assert.Equal(t, http.StatusNoContent, <-result)
+}
@@ -0,0 +1,4 @@
+-- 邮箱唯一索引，与模型定义保持一致
+-- 并发注册同一邮箱时由数据库拒绝重复插入（SQLSTATE 23505）
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email);
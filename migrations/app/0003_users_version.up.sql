@@ -0,0 +1,3 @@
+-- 用户乐观锁版本号，每次更新加一，与模型定义保持一致
+-- 更新时带 WHERE version = ? 条件，版本不一致说明记录已被其他请求修改
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
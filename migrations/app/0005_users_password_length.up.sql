@@ -0,0 +1,3 @@
+-- 放宽密码哈希长度，与模型定义保持一致
+-- Argon2id 的PHC格式哈希包含算法参数和盐，调大参数或哈希长度后可能超过100个字符
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255);
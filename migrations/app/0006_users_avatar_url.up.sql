@@ -0,0 +1,2 @@
+-- 用户头像地址，上传头像后写入存储返回的公开访问地址
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(512) NOT NULL DEFAULT '';
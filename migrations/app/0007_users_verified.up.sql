@@ -0,0 +1,4 @@
+-- 用户邮箱是否已验证，与模型定义保持一致
+-- 迁移前已存在的用户视为已验证，避免开启验证要求后无法登录；新用户默认未验证
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users ALTER COLUMN verified SET DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- 邮箱只在未删除的用户中唯一，与模型定义保持一致
+-- 软删除的用户仍保留原邮箱，使用同一邮箱重新注册时不与已删除的记录冲突
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
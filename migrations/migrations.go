@@ -0,0 +1,16 @@
+// Package migrations 内置的数据库迁移文件，随程序一起编译
+package migrations
+
+import (
+	"embed"
+
+	"github.com/vadxq/go-rest-starter/pkg/migrate"
+)
+
+//go:embed app/*.up.sql
+var files embed.FS
+
+// App 返回应用数据库的迁移列表，按版本号升序排列
+func App() ([]migrate.Migration, error) {
+	return migrate.FromFS(files, "app")
+}
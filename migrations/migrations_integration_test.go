@@ -0,0 +1,94 @@
+//go:build integration
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/migrate"
+)
+
+// newEphemeralDB 在 TEST_DATABASE_DSN 指定的数据库中创建临时schema，测试结束后删除
+// TEST_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=test sslmode=disable" go test -tags integration ./migrations
+func newEphemeralDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("未设置 TEST_DATABASE_DSN，跳过集成测试")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := sql.Open("pgx", dsn+" search_path="+schema)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestApp_Migrations(t *testing.T) {
+	db := newEphemeralDB(t)
+	ctx := context.Background()
+
+	list, err := App()
+	require.NoError(t, err)
+	require.NotEmpty(t, list)
+
+	runner, err := migrate.NewRunner(db, list, nil)
+	require.NoError(t, err)
+
+	// 首次执行全部迁移
+	applied, err := runner.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(list))
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count))
+	assert.Equal(t, len(list), count)
+
+	for _, table := range []string{"users", "audit_logs"} {
+		var exists bool
+		require.NoError(t, db.QueryRow("SELECT to_regclass($1) IS NOT NULL", table).Scan(&exists))
+		assert.True(t, exists, table)
+	}
+
+	// 邮箱只在未删除的用户中唯一，软删除后可以重新注册
+	t.Run("EmailUniqueAmongActiveUsers", func(t *testing.T) {
+		insert := "INSERT INTO users (name, email, password) VALUES ('Test User', 'reuse@example.com', 'hashed')"
+		_, err := db.Exec(insert)
+		require.NoError(t, err)
+		_, err = db.Exec(insert)
+		assert.Error(t, err, "未删除的用户邮箱重复")
+
+		_, err = db.Exec("UPDATE users SET deleted_at = NOW() WHERE email = 'reuse@example.com'")
+		require.NoError(t, err)
+		_, err = db.Exec(insert)
+		assert.NoError(t, err)
+	})
+
+	// 再次执行不重复迁移
+	t.Run("Idempotent", func(t *testing.T) {
+		applied, err := runner.Up(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count))
+		assert.Equal(t, len(list), count)
+	})
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// AtomicCache 支持原子操作的缓存，用于多个实例之间需要互斥的场景（如幂等键占用、失败计数）
+// 与 VersionedCache 一样通过类型断言检测，Redis缓存实现了该接口
+type AtomicCache interface {
+	// SetObjectIfAbsent 键不存在时写入对象，返回是否写入；已存在时不修改原值和过期时间
+	SetObjectIfAbsent(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
+
+	// Increment 将键的整数值加1并返回新值，键不存在时从0开始并设置过期时间；已存在时不修改过期时间
+	Increment(ctx context.Context, key string, expiration time.Duration) (int64, error)
+}
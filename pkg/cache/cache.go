@@ -28,6 +28,15 @@ type Cache interface {
 
 	// SetObject 将对象序列化后存入缓存
 	SetObject(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+
+	// DeleteByPattern 删除所有匹配通配符模式的键，如 "user:list*"
+	DeleteByPattern(ctx context.Context, pattern string) error
+
+	// MGet 批量获取，返回键到值的映射，不存在的键不包含在结果中
+	MGet(ctx context.Context, keys ...string) (map[string][]byte, error)
+
+	// MSet 批量设置，所有键使用相同的过期时间
+	MSet(ctx context.Context, items map[string][]byte, expiration time.Duration) error
 }
 
 // Options 缓存选项
@@ -46,6 +55,9 @@ type Options struct {
 
 	// 清理间隔
 	CleanupInterval time.Duration
+
+	// 是否为Redis命令创建OpenTelemetry追踪span
+	Tracing bool
 }
 
 // NewCache 创建缓存实例（仅支持Redis）
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// NullCache 不存储任何数据的缓存，Redis不可用时代替真实缓存，使服务降级为直接访问数据库
+// 读取始终返回 ErrNotFound，写入和删除始终成功
+type NullCache struct{}
+
+// NewNullCache 创建空缓存
+func NewNullCache() Cache {
+	return NullCache{}
+}
+
+// Available 缓存是否可用，nil和 NullCache 都视为不可用
+// 依赖缓存保存状态（而非加速读取）的组件应在不可用时关闭相应功能
+func Available(c Cache) bool {
+	if c == nil {
+		return false
+	}
+	_, null := c.(NullCache)
+	return !null
+}
+
+// Get 始终返回 ErrNotFound
+func (NullCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+// Set 不保存任何数据
+func (NullCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return nil
+}
+
+// Delete 无需删除
+func (NullCache) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+// Clear 无需清空
+func (NullCache) Clear(ctx context.Context) error {
+	return nil
+}
+
+// GetObject 始终返回 ErrNotFound
+func (NullCache) GetObject(ctx context.Context, key string, value interface{}) error {
+	return ErrNotFound
+}
+
+// SetObject 不保存任何数据
+func (NullCache) SetObject(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return nil
+}
+
+// DeleteByPattern 无需删除
+func (NullCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	return nil
+}
+
+// MGet 始终返回空结果
+func (NullCache) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
+
+// MSet 不保存任何数据
+func (NullCache) MSet(ctx context.Context, items map[string][]byte, expiration time.Duration) error {
+	return nil
+}
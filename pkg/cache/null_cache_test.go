@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNullCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewNullCache()
+
+	// 写入后读取仍然未命中
+	require.NoError(t, c.Set(ctx, "key", []byte("value"), time.Minute))
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, c.SetObject(ctx, "obj", map[string]string{"a": "b"}, time.Minute))
+	var obj map[string]string
+	assert.ErrorIs(t, c.GetObject(ctx, "obj", &obj), ErrNotFound)
+
+	require.NoError(t, c.MSet(ctx, map[string][]byte{"a": []byte("1")}, time.Minute))
+	values, err := c.MGet(ctx, "a", "b")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	assert.NoError(t, c.Delete(ctx, "key"))
+	assert.NoError(t, c.DeleteByPattern(ctx, "key*"))
+	assert.NoError(t, c.Clear(ctx))
+}
+
+func TestAvailable(t *testing.T) {
+	assert.False(t, Available(nil))
+	assert.False(t, Available(NewNullCache()))
+
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+	assert.True(t, Available(c))
+}
@@ -3,9 +3,13 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,6 +17,7 @@ import (
 type redisCache struct {
 	client            *redis.Client
 	defaultExpiration time.Duration
+	counter           hitCounter
 }
 
 // 创建Redis缓存
@@ -23,6 +28,12 @@ func newRedisCache(opts Options) (Cache, error) {
 		DB:       opts.RedisDB,
 	})
 
+	if opts.Tracing {
+		if err := redisotel.InstrumentTracing(client); err != nil {
+			return nil, fmt.Errorf("启用Redis追踪失败: %w", err)
+		}
+	}
+
 	// 测试连接
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -40,10 +51,11 @@ func newRedisCache(opts Options) (Cache, error) {
 // 获取缓存
 func (c *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	val, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		err = ErrNotFound
+	}
+	c.counter.record(err)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, ErrNotFound
-		}
 		return nil, err
 	}
 	return val, nil
@@ -86,4 +98,191 @@ func (c *redisCache) SetObject(ctx context.Context, key string, value interface{
 	}
 	
 	return c.Set(ctx, key, data, expiration)
-} 
\ No newline at end of file
+}
+
+// SetObjectIfAbsent 实现 AtomicCache，使用 SET NX
+func (c *redisCache) SetObjectIfAbsent(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	if expiration == 0 {
+		expiration = c.defaultExpiration
+	}
+
+	return c.client.SetNX(ctx, key, data, expiration).Result()
+}
+
+// incrementScript 递增计数，首次创建时设置过期时间
+// KEYS[1] 计数键；ARGV[1] 过期时间（毫秒，0表示不过期）
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// Increment 实现 AtomicCache，递增和设置过期时间在同一脚本中执行
+func (c *redisCache) Increment(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	if expiration == 0 {
+		expiration = c.defaultExpiration
+	}
+
+	return incrementScript.Run(ctx, c.client, []string{key}, expiration.Milliseconds()).Int64()
+}
+
+// versionKeySuffix 记录缓存值版本号的键后缀
+const versionKeySuffix = ":version"
+
+// setIfNewerScript 版本号不小于已记录的版本号时写入值和版本号
+// KEYS[1] 值的键，KEYS[2] 版本号的键；ARGV[1] 值，ARGV[2] 版本号，ARGV[3] 过期时间（毫秒，0表示不过期）
+var setIfNewerScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[2])
+if current and tonumber(current) > tonumber(ARGV[2]) then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+	redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+	redis.call('SET', KEYS[2], ARGV[2])
+end
+return 1
+`)
+
+// deleteBelowVersionScript 删除值，版本号小于ARGV[1]时记为ARGV[1]
+// KEYS[1] 值的键，KEYS[2] 版本号的键；ARGV[1] 版本号，ARGV[2] 过期时间（毫秒，0表示不过期）
+var deleteBelowVersionScript = redis.NewScript(`
+redis.call('DEL', KEYS[1])
+local current = redis.call('GET', KEYS[2])
+if current and tonumber(current) >= tonumber(ARGV[1]) then
+	return 0
+end
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// SetObjectIfNewer 实现 VersionedCache，版本号与值一起原子写入，过期时间相同
+func (c *redisCache) SetObjectIfNewer(ctx context.Context, key string, value interface{}, version int64, expiration time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	if expiration == 0 {
+		expiration = c.defaultExpiration
+	}
+
+	written, err := setIfNewerScript.Run(ctx, c.client, []string{key, key + versionKeySuffix}, data, version, expiration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return written == 1, nil
+}
+
+// DeleteBelowVersion 实现 VersionedCache
+func (c *redisCache) DeleteBelowVersion(ctx context.Context, key string, version int64, expiration time.Duration) error {
+	if expiration == 0 {
+		expiration = c.defaultExpiration
+	}
+	return deleteBelowVersionScript.Run(ctx, c.client, []string{key, key + versionKeySuffix}, version, expiration.Milliseconds()).Err()
+}
+
+// 扫描批量大小
+const scanBatchSize = 100
+
+// 按模式删除缓存，使用SCAN遍历避免KEYS阻塞Redis
+// 先完成遍历再分批通过管道删除，避免遍历过程中删除键影响游标
+func (c *redisCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	var keys []string
+	iter := c.client.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	for start := 0; start < len(keys); start += scanBatchSize {
+		end := start + scanBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pipe := c.client.Pipeline()
+		for _, key := range keys[start:end] {
+			pipe.Del(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 批量获取缓存
+func (c *redisCache) MGet(ctx context.Context, keys ...string) (map[string][]byte, error) {
+	result := make(map[string][]byte, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			result[keys[i]] = []byte(str)
+		}
+	}
+	c.counter.hits.Add(uint64(len(result)))
+	c.counter.misses.Add(uint64(len(keys) - len(result)))
+	return result, nil
+}
+
+// 批量设置缓存，MSET不支持过期时间，因此通过管道逐个SET
+func (c *redisCache) MSet(ctx context.Context, items map[string][]byte, expiration time.Duration) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if expiration == 0 {
+		expiration = c.defaultExpiration
+	}
+
+	pipe := c.client.Pipeline()
+	for key, value := range items {
+		pipe.Set(ctx, key, value, expiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Stats 返回读取统计，淘汰数量从Redis的 INFO stats 中读取
+func (c *redisCache) Stats(ctx context.Context) (Stats, error) {
+	stats := Stats{
+		Hits:   c.counter.hits.Load(),
+		Misses: c.counter.misses.Load(),
+	}
+
+	info, err := c.client.Info(ctx, "stats").Result()
+	if err != nil {
+		return stats, fmt.Errorf("读取Redis统计信息失败: %w", err)
+	}
+	for _, line := range strings.Split(info, "\r\n") {
+		if value, ok := strings.CutPrefix(line, "evicted_keys:"); ok {
+			stats.Evictions, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return stats, fmt.Errorf("解析Redis淘汰键数量失败: %w", err)
+			}
+			return stats, nil
+		}
+	}
+	return stats, errors.New("Redis统计信息中缺少evicted_keys")
+}
@@ -0,0 +1,175 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisCache_DeleteByPattern(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	// 超过一批SCAN的分页列表缓存
+	for page := 1; page <= 150; page++ {
+		key := fmt.Sprintf("user:list:%d:10:false", page)
+		require.NoError(t, c.Set(ctx, key, []byte("page"), time.Minute))
+	}
+	require.NoError(t, c.Set(ctx, "user:list", []byte("base"), time.Minute))
+	require.NoError(t, c.Set(ctx, "user:1", []byte("user"), time.Minute))
+
+	require.NoError(t, c.DeleteByPattern(ctx, "user:list*"))
+
+	// 所有分页列表均被清除，其他键保留
+	assert.Equal(t, []string{"user:1"}, mr.Keys())
+
+	// 没有匹配的键
+	assert.NoError(t, c.DeleteByPattern(ctx, "product:*"))
+}
+
+func TestRedisCache_Batch(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr(), DefaultExpiration: time.Hour})
+	require.NoError(t, err)
+
+	t.Run("MSet", func(t *testing.T) {
+		require.NoError(t, c.MSet(ctx, map[string][]byte{
+			"a": []byte("1"),
+			"b": []byte("2"),
+		}, time.Minute))
+
+		assert.Equal(t, time.Minute, mr.TTL("a"))
+		assert.Equal(t, time.Minute, mr.TTL("b"))
+
+		// 过期时间为0时使用默认过期时间
+		require.NoError(t, c.MSet(ctx, map[string][]byte{"c": []byte("3")}, 0))
+		assert.Equal(t, time.Hour, mr.TTL("c"))
+
+		assert.NoError(t, c.MSet(ctx, nil, time.Minute))
+	})
+
+	// 不存在的键不包含在结果中
+	t.Run("MGet", func(t *testing.T) {
+		values, err := c.MGet(ctx, "a", "missing", "b")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"a": []byte("1"),
+			"b": []byte("2"),
+		}, values)
+
+		empty, err := c.MGet(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+}
+
+// versionedItem 带版本号的测试数据
+type versionedItem struct {
+	ID      int `json:"id"`
+	Version int `json:"version"`
+}
+
+func (v versionedItem) CacheVersion() int64 { return int64(v.Version) }
+
+func TestRedisCache_SetLatest(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	get := func(key string) versionedItem {
+		var item versionedItem
+		require.NoError(t, c.GetObject(ctx, key, &item))
+		return item
+	}
+
+	// 旧版本不能覆盖新版本，相同或更新的版本可以写入
+	t.Run("RejectsOlderVersion", func(t *testing.T) {
+		require.NoError(t, SetLatest(ctx, c, "item:1", versionedItem{ID: 1, Version: 2}, time.Minute))
+		require.NoError(t, SetLatest(ctx, c, "item:1", versionedItem{ID: 1, Version: 1}, time.Minute))
+		assert.Equal(t, 2, get("item:1").Version)
+
+		require.NoError(t, SetLatest(ctx, c, "item:1", versionedItem{ID: 1, Version: 3}, time.Minute))
+		assert.Equal(t, 3, get("item:1").Version)
+		assert.Equal(t, time.Minute, mr.TTL("item:1"))
+		assert.Equal(t, time.Minute, mr.TTL("item:1"+versionKeySuffix))
+	})
+
+	// 删除键后旧版本同样不能写回
+	t.Run("AfterDelete", func(t *testing.T) {
+		require.NoError(t, c.Delete(ctx, "item:1"))
+		written, err := c.(VersionedCache).SetObjectIfNewer(ctx, "item:1", versionedItem{ID: 1, Version: 2}, 2, time.Minute)
+		require.NoError(t, err)
+		assert.False(t, written)
+		_, err = c.Get(ctx, "item:1")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	// 删除时记录的版本号之前的数据不能写回，之后的版本可以写入
+	t.Run("DeleteLatest", func(t *testing.T) {
+		require.NoError(t, SetLatest(ctx, c, "item:2", versionedItem{ID: 2, Version: 1}, time.Minute))
+		require.NoError(t, DeleteLatest(ctx, c, "item:2", 2, time.Minute))
+		require.NoError(t, SetLatest(ctx, c, "item:2", versionedItem{ID: 2, Version: 1}, time.Minute))
+		_, err := c.Get(ctx, "item:2")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, SetLatest(ctx, c, "item:2", versionedItem{ID: 2, Version: 2}, time.Minute))
+		assert.Equal(t, 2, get("item:2").Version)
+	})
+
+	// 不带版本号的值直接写入
+	t.Run("Unversioned", func(t *testing.T) {
+		require.NoError(t, SetLatest(ctx, c, "plain", map[string]int{"id": 1}, time.Minute))
+		assert.False(t, mr.Exists("plain"+versionKeySuffix))
+	})
+}
+
+func TestRedisCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+
+	provider, ok := c.(StatsProvider)
+	require.True(t, ok)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, c.SetObject(ctx, "obj", map[string]int{"v": 1}, time.Minute))
+
+	// Get命中和未命中
+	_, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// GetObject命中和未命中
+	var obj map[string]int
+	require.NoError(t, c.GetObject(ctx, "obj", &obj))
+	require.ErrorIs(t, c.GetObject(ctx, "missing", &obj), ErrNotFound)
+
+	// MGet按键计数
+	_, err = c.MGet(ctx, "a", "b", "c")
+	require.NoError(t, err)
+
+	// miniredis不支持 INFO stats，淘汰数量无法获取，命中统计仍然有效
+	stats, err := provider.Stats(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+
+	// Redis出错不计入命中统计
+	mr.Close()
+	_, err = c.Get(ctx, "a")
+	require.Error(t, err)
+	stats, _ = provider.Stats(ctx)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(4), stats.Misses)
+}
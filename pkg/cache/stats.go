@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Stats 缓存读取统计，Hits和Misses为当前进程的累计值
+type Stats struct {
+	Hits      uint64 // 读取命中次数，MGet按键计数
+	Misses    uint64 // 读取未命中次数，MGet按键计数
+	Evictions uint64 // 因内存达到上限被淘汰的键数量，由Redis统计（INFO stats 的 evicted_keys）
+}
+
+// StatsProvider 提供读取统计的缓存实现，用于导出缓存指标
+type StatsProvider interface {
+	// Stats 返回读取统计，淘汰数量无法获取时返回错误，Hits和Misses仍然有效
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// hitCounter 统计缓存命中和未命中次数
+type hitCounter struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// record 根据读取结果计数，ErrNotFound计为未命中，其他错误不计数
+func (c *hitCounter) record(err error) {
+	switch err {
+	case nil:
+		c.hits.Add(1)
+	case ErrNotFound:
+		c.misses.Add(1)
+	}
+}
@@ -3,10 +3,16 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
 	"sync"
 	"time"
 )
 
+// 异步写入缓存的超时时间，避免Redis无响应时写入协程堆积
+const asyncWriteTimeout = 3 * time.Second
+
 // CacheAside Cache-Aside模式（最常用的缓存模式）
 type CacheAside struct {
 	cache  Cache
@@ -39,12 +45,31 @@ func (ca *CacheAside) Get(ctx context.Context, key string, dest interface{}) err
 	if err != nil {
 		return err
 	}
-	
-	// 写入缓存（异步，避免阻塞）
-	go ca.cache.SetObject(context.Background(), key, data, ca.ttl)
-	
+
+	// 只序列化一次，写入缓存和复制到目标共用序列化结果
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	// 写入缓存（异步，避免阻塞），请求结束后继续写入但受超时限制
+	go ca.store(context.WithoutCancel(ctx), key, raw)
+
 	// 将数据复制到目标
-	return copyValue(data, dest)
+	if assignValue(data, dest) {
+		return nil
+	}
+	return json.Unmarshal(raw, dest)
+}
+
+// store 写入缓存，失败时记录日志，返回的数据不受影响
+func (ca *CacheAside) store(ctx context.Context, key string, raw []byte) {
+	ctx, cancel := context.WithTimeout(ctx, asyncWriteTimeout)
+	defer cancel()
+
+	if err := ca.cache.Set(ctx, key, raw, ca.ttl); err != nil {
+		slog.WarnContext(ctx, "Cache-Aside写入缓存失败", "key", key, "error", err)
+	}
 }
 
 // Invalidate 失效缓存
@@ -79,9 +104,14 @@ func NewSingleFlight(cache Cache, loader DataLoader, ttl time.Duration) *SingleF
 
 // Get 获取数据（防止缓存击穿）
 func (sf *SingleFlight) Get(ctx context.Context, key string, dest interface{}) error {
+	return sf.GetOrLoad(ctx, key, dest, sf.loader)
+}
+
+// GetOrLoad 使用指定的加载器获取数据，同一键的并发请求共享一次加载
+// 适用于加载参数随请求变化（如分页）、无法在创建时确定加载器的场景
+func (sf *SingleFlight) GetOrLoad(ctx context.Context, key string, dest interface{}, loader DataLoader) (err error) {
 	// 先从缓存获取
-	err := sf.cache.GetObject(ctx, key, dest)
-	if err == nil {
+	if err = sf.cache.GetObject(ctx, key, dest); err == nil {
 		return nil
 	}
 	
@@ -102,31 +132,70 @@ func (sf *SingleFlight) Get(ctx context.Context, key string, dest interface{}) e
 	fg.wg.Add(1)
 	sf.flights[key] = fg
 	sf.mu.Unlock()
+
+	// 标记完成并清理flight group，加载器panic时同样执行，避免等待者永久阻塞
+	defer func() {
+		if r := recover(); r != nil {
+			fg.err = fmt.Errorf("cache: loader panic: %v", r)
+			err = fg.err
+		}
+		fg.wg.Done()
+
+		// 调用 Forget 后可能已有新的加载，只清理自己
+		sf.mu.Lock()
+		if sf.flights[key] == fg {
+			delete(sf.flights, key)
+		}
+		sf.mu.Unlock()
+	}()
 	
 	// 加载数据
-	fg.val, fg.err = sf.loader(ctx, key)
-	if fg.err == nil {
-		// 写入缓存
-		sf.cache.SetObject(ctx, key, fg.val, sf.ttl)
-		copyValue(fg.val, dest)
+	fg.val, fg.err = loader(ctx, key)
+	if fg.err != nil {
+		return fg.err
 	}
-	
-	// 标记完成
-	fg.wg.Done()
-	
-	// 清理flight group
+
+	// 写入缓存，带版本号的值不覆盖加载期间写入的新版本
+	SetLatest(ctx, sf.cache, key, fg.val, sf.ttl)
+	return copyValue(fg.val, dest)
+}
+
+// Forget 使该键之后的请求不再等待正在进行的加载
+// 数据修改提交后调用，避免修改后的读请求拿到修改前开始加载的旧数据；已在等待的请求不受影响
+func (sf *SingleFlight) Forget(key string) {
 	sf.mu.Lock()
 	delete(sf.flights, key)
 	sf.mu.Unlock()
-	
-	return fg.err
 }
 
-// copyValue 复制值（简单的JSON序列化/反序列化）
+// copyValue 复制值，类型一致时直接赋值，否则通过JSON序列化/反序列化转换
 func copyValue(src, dest interface{}) error {
+	if assignValue(src, dest) {
+		return nil
+	}
+
 	data, err := json.Marshal(src)
 	if err != nil {
 		return err
 	}
 	return json.Unmarshal(data, dest)
-}
\ No newline at end of file
+}
+
+// assignValue 类型一致时将src直接赋值给dest指向的值，返回是否已赋值
+func assignValue(src, dest interface{}) bool {
+	srcVal, destVal := reflect.ValueOf(src), reflect.ValueOf(dest)
+	if !srcVal.IsValid() || destVal.Kind() != reflect.Ptr || destVal.IsNil() {
+		return false
+	}
+
+	target := destVal.Elem()
+	switch {
+	case srcVal.Type() == destVal.Type() && !srcVal.IsNil():
+		target.Set(srcVal.Elem())
+		return true
+	case srcVal.Type() == target.Type():
+		target.Set(srcVal)
+		return true
+	}
+	return false
+}
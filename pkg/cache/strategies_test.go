@@ -0,0 +1,268 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// newTestCache 创建使用miniredis的缓存
+func newTestCache(t *testing.T) Cache {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	c, err := NewCache(Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+	return c
+}
+
+// failingCache 写入总是失败的缓存
+type failingCache struct {
+	Cache
+	err error
+}
+
+func (c *failingCache) Set(ctx context.Context, key string, value []byte, expiration time.Duration) error {
+	return c.err
+}
+
+// syncBuffer 可并发写入的日志缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs 将默认日志输出到缓冲区，测试结束后恢复
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+
+	buf := &syncBuffer{}
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return buf
+}
+
+func TestCacheAside(t *testing.T) {
+	ctx := context.Background()
+	loader := func(ctx context.Context, key string) (interface{}, error) {
+		return &testItem{ID: 1, Name: "item"}, nil
+	}
+
+	// 未命中时从数据源加载并写入缓存
+	t.Run("LoadAndStore", func(t *testing.T) {
+		c := newTestCache(t)
+		ca := NewCacheAside(c, loader, time.Minute)
+
+		var item testItem
+		require.NoError(t, ca.Get(ctx, "item:1", &item))
+		assert.Equal(t, testItem{ID: 1, Name: "item"}, item)
+
+		assert.Eventually(t, func() bool {
+			var cached testItem
+			return c.GetObject(ctx, "item:1", &cached) == nil && cached == item
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	// 目标类型与加载的数据不同时通过JSON转换
+	t.Run("ConvertType", func(t *testing.T) {
+		ca := NewCacheAside(newTestCache(t), loader, time.Minute)
+
+		var item map[string]interface{}
+		require.NoError(t, ca.Get(ctx, "item:1", &item))
+		assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "item"}, item)
+	})
+
+	// 请求上下文取消后缓存仍然写入
+	t.Run("DetachedFromRequest", func(t *testing.T) {
+		c := newTestCache(t)
+		ca := NewCacheAside(c, loader, time.Minute)
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		var item testItem
+		require.NoError(t, ca.Get(reqCtx, "item:1", &item))
+		cancel()
+
+		assert.Eventually(t, func() bool {
+			_, err := c.Get(ctx, "item:1")
+			return err == nil
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	// 写入缓存失败时返回的数据不受影响，并记录日志
+	t.Run("StoreError", func(t *testing.T) {
+		logs := captureLogs(t)
+		ca := NewCacheAside(&failingCache{Cache: newTestCache(t), err: errors.New("redis down")}, loader, time.Minute)
+
+		var item testItem
+		require.NoError(t, ca.Get(ctx, "item:1", &item))
+		assert.Equal(t, testItem{ID: 1, Name: "item"}, item)
+
+		assert.Eventually(t, func() bool {
+			output := logs.String()
+			return strings.Contains(output, "Cache-Aside写入缓存失败") &&
+				strings.Contains(output, "key=item:1") &&
+				strings.Contains(output, "error=\"redis down\"")
+		}, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestSingleFlight(t *testing.T) {
+	ctx := context.Background()
+
+	// 并发获取同一个未缓存的键，慢加载器只执行一次
+	t.Run("LoadOnce", func(t *testing.T) {
+		var loads atomic.Int32
+		sf := NewSingleFlight(newTestCache(t), func(ctx context.Context, key string) (interface{}, error) {
+			loads.Add(1)
+			time.Sleep(100 * time.Millisecond)
+			return &testItem{ID: 1, Name: "item"}, nil
+		}, time.Minute)
+
+		const n = 50
+		var wg sync.WaitGroup
+		results := make([]testItem, n)
+		errs := make([]error, n)
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = sf.Get(ctx, "item:1", &results[i])
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		for i := 0; i < n; i++ {
+			require.NoError(t, errs[i])
+			assert.Equal(t, testItem{ID: 1, Name: "item"}, results[i])
+		}
+
+		// 加载结果已写入缓存，再次获取不会触发加载
+		var cached testItem
+		require.NoError(t, sf.Get(ctx, "item:1", &cached))
+		assert.Equal(t, int32(1), loads.Load())
+	})
+
+	// 加载失败时所有等待者都收到同一错误，且不写入缓存
+	t.Run("SharedError", func(t *testing.T) {
+		errLoad := errors.New("db down")
+		var loads atomic.Int32
+		c := newTestCache(t)
+		sf := NewSingleFlight(c, nil, time.Minute)
+		loader := func(ctx context.Context, key string) (interface{}, error) {
+			loads.Add(1)
+			time.Sleep(50 * time.Millisecond)
+			return nil, errLoad
+		}
+
+		var wg sync.WaitGroup
+		var failures atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var item testItem
+				if errors.Is(sf.GetOrLoad(ctx, "item:2", &item, loader), errLoad) {
+					failures.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+		assert.Equal(t, int32(10), failures.Load())
+		_, err := c.Get(ctx, "item:2")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	// 加载器panic时返回错误，后续请求可以重新加载
+	t.Run("LoaderPanic", func(t *testing.T) {
+		sf := NewSingleFlight(newTestCache(t), nil, time.Minute)
+
+		var item testItem
+		err := sf.GetOrLoad(ctx, "item:3", &item, func(ctx context.Context, key string) (interface{}, error) {
+			panic("boom")
+		})
+		assert.Error(t, err)
+
+		err = sf.GetOrLoad(ctx, "item:3", &item, func(ctx context.Context, key string) (interface{}, error) {
+			return testItem{ID: 3}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, item.ID)
+	})
+
+	// 加载期间写入的新版本不被加载结果覆盖
+	t.Run("VersionedFill", func(t *testing.T) {
+		c := newTestCache(t)
+		sf := NewSingleFlight(c, nil, time.Minute)
+
+		var item versionedItem
+		err := sf.GetOrLoad(ctx, "item:4", &item, func(ctx context.Context, key string) (interface{}, error) {
+			require.NoError(t, SetLatest(ctx, c, key, versionedItem{ID: 4, Version: 2}, time.Minute))
+			return versionedItem{ID: 4, Version: 1}, nil
+		})
+		require.NoError(t, err)
+
+		var cached versionedItem
+		require.NoError(t, c.GetObject(ctx, "item:4", &cached))
+		assert.Equal(t, 2, cached.Version)
+	})
+
+	// Forget 后的请求不再等待之前开始的加载
+	t.Run("Forget", func(t *testing.T) {
+		sf := NewSingleFlight(NewNullCache(), nil, time.Minute)
+
+		started, release := make(chan struct{}), make(chan struct{})
+		done := make(chan testItem)
+		go func() {
+			var item testItem
+			_ = sf.GetOrLoad(ctx, "item:5", &item, func(ctx context.Context, key string) (interface{}, error) {
+				close(started)
+				<-release
+				return testItem{ID: 5, Name: "old"}, nil
+			})
+			done <- item
+		}()
+		<-started
+
+		sf.Forget("item:5")
+		var item testItem
+		err := sf.GetOrLoad(ctx, "item:5", &item, func(ctx context.Context, key string) (interface{}, error) {
+			return testItem{ID: 5, Name: "new"}, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "new", item.Name)
+
+		close(release)
+		assert.Equal(t, "old", (<-done).Name)
+	})
+}
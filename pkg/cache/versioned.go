@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Versioned 带版本号的缓存值，版本号随数据每次修改递增，如乐观锁版本号
+type Versioned interface {
+	CacheVersion() int64
+}
+
+// VersionedCache 支持按版本号条件写入的缓存
+type VersionedCache interface {
+	// SetObjectIfNewer 版本号不小于该键已写入的最大版本号时写入对象，返回是否写入
+	// 删除键不会清除已记录的版本号，删除后旧版本的数据同样不能写回
+	SetObjectIfNewer(ctx context.Context, key string, value interface{}, version int64, expiration time.Duration) (bool, error)
+
+	// DeleteBelowVersion 删除键，并在expiration内拒绝写入版本号小于version的数据
+	DeleteBelowVersion(ctx context.Context, key string, version int64, expiration time.Duration) error
+}
+
+// SetLatest 写入对象，value实现 Versioned 且缓存实现 VersionedCache 时按版本号条件写入
+//
+// 写操作提交后写入新数据，与此同时，在提交前开始的读请求可能刚从数据库读到旧数据并回填缓存，
+// 无条件写入时旧数据会覆盖新数据，直到缓存过期。按版本号条件写入后，旧版本的回填被丢弃，
+// 写入方之后的读请求总能读到自己写入的数据；并发更新的缓存写入顺序与提交顺序不同时也保留最新版本
+func SetLatest(ctx context.Context, c Cache, key string, value interface{}, expiration time.Duration) error {
+	v, ok := value.(Versioned)
+	vc, supported := c.(VersionedCache)
+	if !ok || !supported {
+		return c.SetObject(ctx, key, value, expiration)
+	}
+	_, err := vc.SetObjectIfNewer(ctx, key, value, v.CacheVersion(), expiration)
+	return err
+}
+
+// DeleteLatest 删除键；缓存实现 VersionedCache 时，在expiration内拒绝写入版本号小于version的数据，
+// 用于删除数据但版本号不变的场景（如软删除），传入当前版本号加一，删除前开始的读请求不能回填已删除的数据
+func DeleteLatest(ctx context.Context, c Cache, key string, version int64, expiration time.Duration) error {
+	if vc, ok := c.(VersionedCache); ok {
+		return vc.DeleteBelowVersion(ctx, key, version, expiration)
+	}
+	return c.Delete(ctx, key)
+}
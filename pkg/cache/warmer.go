@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WarmupFunc 缓存预热函数，通常调用服务层方法将热点数据写入缓存
+type WarmupFunc func(ctx context.Context) error
+
+// WarmerConfig 缓存预热配置
+type WarmerConfig struct {
+	// Timeout 全部预热任务的总超时，超时后未完成的任务通过上下文取消
+	Timeout time.Duration
+}
+
+// DefaultWarmerConfig 默认缓存预热配置
+var DefaultWarmerConfig = WarmerConfig{
+	Timeout: 30 * time.Second,
+}
+
+// warmupTask 已注册的预热任务
+type warmupTask struct {
+	name string
+	fn   WarmupFunc
+}
+
+// Warmer 缓存预热器，各服务注册预热函数，启动时统一执行
+type Warmer struct {
+	config WarmerConfig
+	mu     sync.Mutex
+	tasks  []warmupTask
+}
+
+// NewWarmer 创建缓存预热器
+func NewWarmer(config *WarmerConfig) *Warmer {
+	if config == nil {
+		config = &DefaultWarmerConfig
+	}
+	cfg := *config
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultWarmerConfig.Timeout
+	}
+
+	return &Warmer{config: cfg}
+}
+
+// Register 注册预热函数，name 用于错误信息和日志
+func (w *Warmer) Register(name string, fn WarmupFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tasks = append(w.tasks, warmupTask{name: name, fn: fn})
+}
+
+// Len 返回已注册的预热函数数量
+func (w *Warmer) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(w.tasks)
+}
+
+// Warm 并发执行所有预热函数并等待完成，返回所有失败任务的错误
+// 单个任务失败不影响其他任务
+func (w *Warmer) Warm(ctx context.Context) error {
+	w.mu.Lock()
+	tasks := append([]warmupTask(nil), w.tasks...)
+	w.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	errs := make([]error, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task warmupTask) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("缓存预热 %s panic: %v", task.name, r)
+				}
+			}()
+
+			if err := task.fn(ctx); err != nil {
+				errs[i] = fmt.Errorf("缓存预热 %s 失败: %w", task.name, err)
+			}
+		}(i, task)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Start 在后台执行预热，不阻塞调用方；预热结束后通过返回的通道发送结果
+func (w *Warmer) Start(ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Warm(ctx)
+		close(done)
+	}()
+	return done
+}
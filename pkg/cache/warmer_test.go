@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarmer(t *testing.T) {
+	// 执行所有注册的预热函数
+	t.Run("InvokesRegistered", func(t *testing.T) {
+		w := NewWarmer(nil)
+
+		var calls atomic.Int32
+		for _, name := range []string{"users", "products", "settings"} {
+			w.Register(name, func(ctx context.Context) error {
+				calls.Add(1)
+				return nil
+			})
+		}
+		require.Equal(t, 3, w.Len())
+
+		require.NoError(t, w.Warm(context.Background()))
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	// 单个任务失败不影响其他任务，错误中包含任务名
+	t.Run("CollectsErrors", func(t *testing.T) {
+		w := NewWarmer(nil)
+
+		errBoom := errors.New("boom")
+		var calls atomic.Int32
+		w.Register("broken", func(ctx context.Context) error { return errBoom })
+		w.Register("panics", func(ctx context.Context) error { panic("unexpected") })
+		w.Register("ok", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		err := w.Warm(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errBoom)
+		assert.Contains(t, err.Error(), "broken")
+		assert.Contains(t, err.Error(), "panics")
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	// 超时后取消未完成的任务
+	t.Run("Timeout", func(t *testing.T) {
+		w := NewWarmer(&WarmerConfig{Timeout: 50 * time.Millisecond})
+		w.Register("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		start := time.Now()
+		err := w.Warm(context.Background())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	// 后台执行，不阻塞调用方
+	t.Run("StartAsync", func(t *testing.T) {
+		w := NewWarmer(nil)
+
+		release := make(chan struct{})
+		w.Register("blocking", func(ctx context.Context) error {
+			<-release
+			return nil
+		})
+
+		done := w.Start(context.Background())
+		select {
+		case <-done:
+			t.Fatal("预热不应阻塞完成")
+		default:
+		}
+
+		close(release)
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("预热未完成")
+		}
+	})
+
+	// 未注册任何函数
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, NewWarmer(nil).Warm(context.Background()))
+	})
+}
@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrWriterClosed 写策略已关闭
+var ErrWriterClosed = errors.New("cache: writer closed")
+
+// WriteStrategy 缓存写策略，服务层通过它同时更新数据源和缓存
+type WriteStrategy interface {
+	// Write 写入数据，数据源和缓存的更新时机由具体策略决定
+	Write(ctx context.Context, key string, value interface{}) error
+
+	// Close 关闭写策略，尚未写入数据源的数据在返回前写入
+	Close(ctx context.Context) error
+}
+
+// DataWriter 数据写入器，将单条数据写入数据源
+type DataWriter func(ctx context.Context, key string, value interface{}) error
+
+// BatchWriter 批量数据写入器，将多条数据写入数据源
+type BatchWriter func(ctx context.Context, items map[string]interface{}) error
+
+// WriteThrough Write-Through模式，同步写入数据源和缓存
+type WriteThrough struct {
+	cache  Cache
+	writer DataWriter
+	ttl    time.Duration
+}
+
+// NewWriteThrough 创建Write-Through模式缓存
+func NewWriteThrough(cache Cache, writer DataWriter, ttl time.Duration) *WriteThrough {
+	return &WriteThrough{
+		cache:  cache,
+		writer: writer,
+		ttl:    ttl,
+	}
+}
+
+// Write 先写数据源，成功后写缓存；数据源写入失败时缓存保持不变
+// 缓存写入失败时删除该键，下次读取从数据源加载，删除也失败时返回错误
+func (wt *WriteThrough) Write(ctx context.Context, key string, value interface{}) error {
+	if err := wt.writer(ctx, key, value); err != nil {
+		return err
+	}
+
+	if err := wt.cache.SetObject(ctx, key, value, wt.ttl); err != nil {
+		if delErr := wt.cache.Delete(ctx, key); delErr != nil {
+			return fmt.Errorf("写入缓存失败且无法删除旧值: %w", errors.Join(err, delErr))
+		}
+	}
+	return nil
+}
+
+// Close 实现 WriteStrategy 接口，Write-Through没有待写入的数据
+func (wt *WriteThrough) Close(ctx context.Context) error {
+	return nil
+}
+
+// WriteBehindConfig Write-Behind模式配置
+type WriteBehindConfig struct {
+	// BatchSize 单次批量写入的最大条数，待写入数据达到该数量时立即写入
+	BatchSize int
+	// FlushInterval 定时写入数据源的间隔
+	FlushInterval time.Duration
+	// TTL 缓存过期时间
+	TTL time.Duration
+	// OnError 后台写入数据源失败时调用，为空时记录警告日志
+	OnError func(err error)
+}
+
+// DefaultWriteBehindConfig 默认Write-Behind模式配置
+var DefaultWriteBehindConfig = WriteBehindConfig{
+	BatchSize:     100,
+	FlushInterval: time.Second,
+	TTL:           time.Hour,
+}
+
+// WriteBehind Write-Behind模式，同步写缓存，异步批量写入数据源
+// 同一键在写入数据源前多次写入时只保留最新值；写入数据源失败的数据保留到下次写入时重试
+type WriteBehind struct {
+	cache  Cache
+	writer BatchWriter
+	config WriteBehindConfig
+
+	mu      sync.Mutex
+	pending map[string]interface{} // 尚未写入数据源的数据
+	closed  bool
+
+	flushMu sync.Mutex    // 保证同一时间只有一次写入数据源
+	full    chan struct{} // 待写入数据达到BatchSize时通知后台写入
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewWriteBehind 创建Write-Behind模式缓存并启动后台写入，关闭时需调用 Close
+func NewWriteBehind(cache Cache, writer BatchWriter, config *WriteBehindConfig) *WriteBehind {
+	if config == nil {
+		config = &DefaultWriteBehindConfig
+	}
+	cfg := *config
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultWriteBehindConfig.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultWriteBehindConfig.FlushInterval
+	}
+
+	wb := &WriteBehind{
+		cache:   cache,
+		writer:  writer,
+		config:  cfg,
+		pending: make(map[string]interface{}),
+		full:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go wb.run()
+	return wb
+}
+
+// Write 写入缓存并加入待写入队列，缓存写入失败时不加入队列
+func (wb *WriteBehind) Write(ctx context.Context, key string, value interface{}) error {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	if wb.closed {
+		return ErrWriterClosed
+	}
+	if err := wb.cache.SetObject(ctx, key, value, wb.config.TTL); err != nil {
+		return err
+	}
+
+	wb.pending[key] = value
+	if len(wb.pending) >= wb.config.BatchSize {
+		select {
+		case wb.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Pending 返回尚未写入数据源的数据条数
+func (wb *WriteBehind) Pending() int {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	return len(wb.pending)
+}
+
+// Flush 立即将待写入数据按BatchSize分批写入数据源
+// 写入失败的批次重新加入队列（期间有新值的键除外），返回所有失败批次的错误
+func (wb *WriteBehind) Flush(ctx context.Context) error {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+
+	wb.mu.Lock()
+	items := wb.pending
+	wb.pending = make(map[string]interface{})
+	wb.mu.Unlock()
+
+	var errs []error
+	batch := make(map[string]interface{}, min(len(items), wb.config.BatchSize))
+	writeBatch := func() {
+		if err := wb.writer(ctx, batch); err != nil {
+			errs = append(errs, err)
+			wb.requeue(batch)
+		}
+		batch = make(map[string]interface{}, wb.config.BatchSize)
+	}
+
+	for key, value := range items {
+		batch[key] = value
+		if len(batch) == wb.config.BatchSize {
+			writeBatch()
+		}
+	}
+	if len(batch) > 0 {
+		writeBatch()
+	}
+	return errors.Join(errs...)
+}
+
+// requeue 将写入失败的数据重新加入队列，已有新值的键保留新值
+func (wb *WriteBehind) requeue(items map[string]interface{}) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	for key, value := range items {
+		if _, ok := wb.pending[key]; !ok {
+			wb.pending[key] = value
+		}
+	}
+}
+
+// Close 停止后台写入，并将剩余数据写入数据源；之后的 Write 返回 ErrWriterClosed
+// 应在应用关闭时、数据库连接关闭前调用
+func (wb *WriteBehind) Close(ctx context.Context) error {
+	wb.mu.Lock()
+	if wb.closed {
+		wb.mu.Unlock()
+		return nil
+	}
+	wb.closed = true
+	wb.mu.Unlock()
+
+	close(wb.stop)
+	select {
+	case <-wb.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return wb.Flush(ctx)
+}
+
+// run 后台定时或在待写入数据达到BatchSize时写入数据源
+func (wb *WriteBehind) run() {
+	defer close(wb.done)
+
+	ticker := time.NewTicker(wb.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-wb.full:
+		case <-wb.stop:
+			return
+		}
+
+		if err := wb.Flush(context.Background()); err != nil {
+			wb.reportError(err)
+		}
+	}
+}
+
+// reportError 报告后台写入数据源的错误
+func (wb *WriteBehind) reportError(err error) {
+	if wb.config.OnError != nil {
+		wb.config.OnError(err)
+		return
+	}
+	slog.Warn("Write-Behind写入数据源失败，将在下次写入时重试", "error", err)
+}
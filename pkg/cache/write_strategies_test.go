@@ -0,0 +1,231 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore 记录写入数据的测试数据源
+type memoryStore struct {
+	mu      sync.Mutex
+	items   map[string]interface{}
+	batches []int // 每次批量写入的条数
+	err     error // 不为空时写入失败
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{items: make(map[string]interface{})}
+}
+
+func (s *memoryStore) write(ctx context.Context, key string, value interface{}) error {
+	return s.writeBatch(ctx, map[string]interface{}{key: value})
+}
+
+func (s *memoryStore) writeBatch(ctx context.Context, items map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	for key, value := range items {
+		s.items[key] = value
+	}
+	s.batches = append(s.batches, len(items))
+	return nil
+}
+
+func (s *memoryStore) get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.items[key]
+	return value, ok
+}
+
+func (s *memoryStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.items)
+}
+
+func (s *memoryStore) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+func TestWriteThrough(t *testing.T) {
+	ctx := context.Background()
+
+	// 写入后数据源和缓存一致
+	t.Run("Consistent", func(t *testing.T) {
+		c := newTestCache(t)
+		store := newMemoryStore()
+		var wt WriteStrategy = NewWriteThrough(c, store.write, time.Minute)
+
+		item := &testItem{ID: 1, Name: "item"}
+		require.NoError(t, wt.Write(ctx, "item:1", item))
+
+		stored, ok := store.get("item:1")
+		require.True(t, ok)
+		assert.Equal(t, item, stored)
+
+		var cached testItem
+		require.NoError(t, c.GetObject(ctx, "item:1", &cached))
+		assert.Equal(t, *item, cached)
+
+		require.NoError(t, wt.Close(ctx))
+	})
+
+	// 数据源写入失败时不更新缓存
+	t.Run("StoreError", func(t *testing.T) {
+		c := newTestCache(t)
+		require.NoError(t, c.SetObject(ctx, "item:1", &testItem{ID: 1, Name: "old"}, time.Minute))
+
+		store := newMemoryStore()
+		store.setErr(errors.New("db down"))
+		wt := NewWriteThrough(c, store.write, time.Minute)
+
+		assert.EqualError(t, wt.Write(ctx, "item:1", &testItem{ID: 1, Name: "new"}), "db down")
+
+		var cached testItem
+		require.NoError(t, c.GetObject(ctx, "item:1", &cached))
+		assert.Equal(t, "old", cached.Name)
+	})
+
+	// 缓存不可用时数据源仍然写入，删除旧值也失败时返回错误
+	t.Run("CacheError", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		c, err := NewCache(Options{RedisAddress: mr.Addr()})
+		require.NoError(t, err)
+		mr.Close()
+
+		store := newMemoryStore()
+		wt := NewWriteThrough(c, store.write, time.Minute)
+
+		assert.Error(t, wt.Write(ctx, "item:1", &testItem{ID: 1}))
+		_, ok := store.get("item:1")
+		assert.True(t, ok)
+	})
+}
+
+func TestWriteBehind(t *testing.T) {
+	ctx := context.Background()
+
+	// 写入立即更新缓存，数据源按间隔异步写入
+	t.Run("EventualFlush", func(t *testing.T) {
+		c := newTestCache(t)
+		store := newMemoryStore()
+		wb := NewWriteBehind(c, store.writeBatch, &WriteBehindConfig{BatchSize: 100, FlushInterval: 20 * time.Millisecond, TTL: time.Minute})
+		defer wb.Close(ctx)
+
+		item := &testItem{ID: 1, Name: "item"}
+		require.NoError(t, wb.Write(ctx, "item:1", item))
+
+		var cached testItem
+		require.NoError(t, c.GetObject(ctx, "item:1", &cached))
+		assert.Equal(t, *item, cached)
+
+		assert.Eventually(t, func() bool {
+			stored, ok := store.get("item:1")
+			return ok && stored == item
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 0, wb.Pending())
+	})
+
+	// 待写入数据达到BatchSize时立即写入，同一键只保留最新值
+	t.Run("Batching", func(t *testing.T) {
+		store := newMemoryStore()
+		wb := NewWriteBehind(newTestCache(t), store.writeBatch, &WriteBehindConfig{BatchSize: 3, FlushInterval: time.Hour})
+		defer wb.Close(ctx)
+
+		require.NoError(t, wb.Write(ctx, "a", 1))
+		require.NoError(t, wb.Write(ctx, "a", 2))
+		require.NoError(t, wb.Write(ctx, "b", 1))
+		assert.Equal(t, 2, wb.Pending())
+
+		require.NoError(t, wb.Write(ctx, "c", 1))
+		assert.Eventually(t, func() bool { return store.len() == 3 }, time.Second, 5*time.Millisecond)
+
+		value, _ := store.get("a")
+		assert.Equal(t, 2, value)
+	})
+
+	// 手动写入时按BatchSize分批
+	t.Run("FlushInBatches", func(t *testing.T) {
+		store := newMemoryStore()
+		wb := NewWriteBehind(newTestCache(t), store.writeBatch, &WriteBehindConfig{BatchSize: 2, FlushInterval: time.Hour})
+		defer wb.Close(ctx)
+
+		// 先阻止后台写入，使所有数据在一次Flush中写入
+		wb.flushMu.Lock()
+		for _, key := range []string{"a", "b", "c", "d", "e"} {
+			require.NoError(t, wb.Write(ctx, key, key))
+		}
+		wb.flushMu.Unlock()
+
+		require.NoError(t, wb.Flush(ctx))
+		assert.Equal(t, 5, store.len())
+		for _, size := range store.batches {
+			assert.LessOrEqual(t, size, 2)
+		}
+	})
+
+	// 写入失败时报告错误，数据保留到下次写入时重试
+	t.Run("RetryOnError", func(t *testing.T) {
+		store := newMemoryStore()
+		store.setErr(errors.New("db down"))
+
+		reported := make(chan error, 10)
+		wb := NewWriteBehind(newTestCache(t), store.writeBatch, &WriteBehindConfig{
+			BatchSize:     100,
+			FlushInterval: 10 * time.Millisecond,
+			OnError: func(err error) {
+				select {
+				case reported <- err:
+				default:
+				}
+			},
+		})
+		defer wb.Close(ctx)
+
+		require.NoError(t, wb.Write(ctx, "a", 1))
+		select {
+		case err := <-reported:
+			assert.EqualError(t, err, "db down")
+		case <-time.After(time.Second):
+			t.Fatal("未报告写入错误")
+		}
+		assert.Equal(t, 1, wb.Pending())
+
+		store.setErr(nil)
+		assert.Eventually(t, func() bool { return store.len() == 1 }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 0, wb.Pending())
+	})
+
+	// 关闭时写入剩余数据，之后拒绝写入
+	t.Run("FlushOnClose", func(t *testing.T) {
+		store := newMemoryStore()
+		var wb WriteStrategy = NewWriteBehind(newTestCache(t), store.writeBatch, &WriteBehindConfig{BatchSize: 100, FlushInterval: time.Hour})
+
+		require.NoError(t, wb.Write(ctx, "a", 1))
+		require.NoError(t, wb.Write(ctx, "b", 2))
+		assert.Equal(t, 0, store.len())
+
+		require.NoError(t, wb.Close(ctx))
+		assert.Equal(t, 2, store.len())
+
+		assert.ErrorIs(t, wb.Write(ctx, "c", 3), ErrWriterClosed)
+		assert.NoError(t, wb.Close(ctx))
+	})
+}
@@ -0,0 +1,242 @@
+package errors
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState 断路器状态
+type CircuitState int
+
+const (
+	// StateClosed 关闭状态（正常）
+	StateClosed CircuitState = iota
+	// StateOpen 打开状态（熔断）
+	StateOpen
+	// StateHalfOpen 半开状态（尝试恢复）
+	StateHalfOpen
+)
+
+// String 返回状态名称，用于日志和健康检查
+func (s CircuitState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig 断路器配置
+type CircuitBreakerConfig struct {
+	MaxFailures      int           // 连续失败多少次后打开
+	ResetTimeout     time.Duration // 打开后经过多久进入半开状态
+	HalfOpenRequests int           // 半开状态允许同时进行的试探请求数，全部成功后关闭
+
+	// OnStateChange 状态切换时调用，可为空；在释放锁后调用，回调中可以读取断路器状态
+	// 打开状态超时后在下一个请求到达时切换为半开
+	OnStateChange func(from, to CircuitState)
+}
+
+// DefaultCircuitBreakerConfig 默认断路器配置
+var DefaultCircuitBreakerConfig = CircuitBreakerConfig{
+	MaxFailures:      5,
+	ResetTimeout:     30 * time.Second,
+	HalfOpenRequests: 1,
+}
+
+// CircuitBreaker 断路器，可被多个goroutine并发使用
+// 关闭状态下连续失败达到 MaxFailures 次后打开，打开期间请求直接失败；
+// 经过 ResetTimeout 后进入半开状态，放行 HalfOpenRequests 个试探请求，
+// 全部成功则关闭，任一失败则重新打开
+type CircuitBreaker struct {
+	mu sync.Mutex
+
+	maxFailures      int
+	resetTimeout     time.Duration
+	halfOpenRequests int
+
+	state           CircuitState
+	generation      uint64 // 每次状态切换加一，用于丢弃切换前发出的请求结果
+	failures        int
+	lastFailureTime time.Time
+	halfOpenPending int // 半开状态下已放行但尚未返回结果的请求数
+	halfOpenPassed  int // 半开状态下已成功的请求数
+
+	onStateChange func(from, to CircuitState)
+	transitions   []stateTransition // 持有锁期间发生的状态切换，释放锁后通知
+}
+
+// stateTransition 一次状态切换
+type stateTransition struct {
+	from, to CircuitState
+}
+
+// NewCircuitBreaker 创建断路器，半开状态只放行一个试探请求
+func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
+	return NewCircuitBreakerWithConfig(&CircuitBreakerConfig{
+		MaxFailures:      maxFailures,
+		ResetTimeout:     resetTimeout,
+		HalfOpenRequests: 1,
+	})
+}
+
+// NewCircuitBreakerWithConfig 按配置创建断路器，config为空时使用默认配置，为0的字段使用默认值
+func NewCircuitBreakerWithConfig(config *CircuitBreakerConfig) *CircuitBreaker {
+	if config == nil {
+		config = &DefaultCircuitBreakerConfig
+	}
+
+	cb := &CircuitBreaker{
+		maxFailures:      config.MaxFailures,
+		resetTimeout:     config.ResetTimeout,
+		halfOpenRequests: config.HalfOpenRequests,
+		state:            StateClosed,
+		onStateChange:    config.OnStateChange,
+	}
+	if cb.maxFailures <= 0 {
+		cb.maxFailures = DefaultCircuitBreakerConfig.MaxFailures
+	}
+	if cb.resetTimeout <= 0 {
+		cb.resetTimeout = DefaultCircuitBreakerConfig.ResetTimeout
+	}
+	if cb.halfOpenRequests <= 0 {
+		cb.halfOpenRequests = DefaultCircuitBreakerConfig.HalfOpenRequests
+	}
+	return cb
+}
+
+// Execute 执行函数（带断路器保护），fn返回的任何错误都计为失败
+func (cb *CircuitBreaker) Execute(fn RetryableFunc) error {
+	done, err := cb.Allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	done(err)
+	return err
+}
+
+// Allow 判断是否放行请求，不放行时返回 *CircuitOpenError
+// 放行时返回的done必须在请求结束后调用一次，传入nil表示成功，非nil表示失败
+func (cb *CircuitBreaker) Allow() (done func(err error), err error) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if cb.state == StateOpen {
+		if time.Since(cb.lastFailureTime) < cb.resetTimeout {
+			return nil, &CircuitOpenError{ResetAt: cb.lastFailureTime.Add(cb.resetTimeout)}
+		}
+		// 尝试进入半开状态
+		cb.setState(StateHalfOpen)
+	}
+
+	if cb.state == StateHalfOpen {
+		// 试探请求已满，在结果返回前其他请求继续快速失败
+		if cb.halfOpenPending+cb.halfOpenPassed >= cb.halfOpenRequests {
+			return nil, &CircuitOpenError{ResetAt: cb.lastFailureTime.Add(cb.resetTimeout)}
+		}
+		cb.halfOpenPending++
+	}
+
+	generation := cb.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { cb.record(generation, err) })
+	}, nil
+}
+
+// State 返回当前状态，打开状态超过 ResetTimeout 后视为半开
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen && time.Since(cb.lastFailureTime) >= cb.resetTimeout {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// Failures 返回关闭状态下当前的连续失败次数
+func (cb *CircuitBreaker) Failures() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.failures
+}
+
+// record 记录请求结果，状态已在请求期间切换时忽略该结果
+func (cb *CircuitBreaker) record(generation uint64, err error) {
+	cb.mu.Lock()
+	defer cb.unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case StateClosed:
+		if err == nil {
+			cb.failures = 0
+			return
+		}
+		cb.failures++
+		cb.lastFailureTime = time.Now()
+		if cb.failures >= cb.maxFailures {
+			cb.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		cb.halfOpenPending--
+		if err != nil {
+			cb.lastFailureTime = time.Now()
+			cb.setState(StateOpen)
+			return
+		}
+		cb.halfOpenPassed++
+		if cb.halfOpenPassed >= cb.halfOpenRequests {
+			cb.setState(StateClosed)
+		}
+	}
+}
+
+// unlock 释放锁，并依次通知持有锁期间发生的状态切换
+func (cb *CircuitBreaker) unlock() {
+	transitions := cb.transitions
+	cb.transitions = nil
+	cb.mu.Unlock()
+
+	if cb.onStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		cb.onStateChange(t.from, t.to)
+	}
+}
+
+// setState 切换状态并重置计数，调用方需持有锁
+func (cb *CircuitBreaker) setState(state CircuitState) {
+	if cb.onStateChange != nil {
+		cb.transitions = append(cb.transitions, stateTransition{from: cb.state, to: state})
+	}
+	cb.state = state
+	cb.generation++
+	cb.halfOpenPending = 0
+	cb.halfOpenPassed = 0
+	if state == StateClosed {
+		cb.failures = 0
+	}
+}
+
+// CircuitOpenError 断路器打开错误
+type CircuitOpenError struct {
+	ResetAt time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open, will reset at %v", e.ResetAt)
+}
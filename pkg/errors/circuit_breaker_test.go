@@ -0,0 +1,231 @@
+package errors
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend unavailable")
+
+// tripBreaker 连续失败使断路器打开
+func tripBreaker(t *testing.T, cb *CircuitBreaker, failures int) {
+	t.Helper()
+	for i := 0; i < failures; i++ {
+		require.ErrorIs(t, cb.Execute(func() error { return errBackend }), errBackend)
+	}
+	require.Equal(t, StateOpen, cb.State())
+}
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	// 连续失败达到阈值后打开，打开期间不执行函数
+	t.Run("ClosedToOpen", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute)
+		tripBreaker(t, cb, 3)
+
+		called := false
+		err := cb.Execute(func() error { called = true; return nil })
+		var openErr *CircuitOpenError
+		require.ErrorAs(t, err, &openErr)
+		assert.False(t, called)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), openErr.ResetAt, time.Second)
+	})
+
+	// 成功会清零连续失败次数
+	t.Run("SuccessResetsFailures", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, time.Minute)
+		for i := 0; i < 2; i++ {
+			_ = cb.Execute(func() error { return errBackend })
+		}
+		require.NoError(t, cb.Execute(func() error { return nil }))
+		assert.Equal(t, 0, cb.Failures())
+
+		_ = cb.Execute(func() error { return errBackend })
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	// 超过重置时间后进入半开，试探成功则关闭
+	t.Run("HalfOpenToClosed", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, 20*time.Millisecond)
+		tripBreaker(t, cb, 1)
+
+		time.Sleep(30 * time.Millisecond)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		require.NoError(t, cb.Execute(func() error { return nil }))
+		assert.Equal(t, StateClosed, cb.State())
+		assert.Equal(t, 0, cb.Failures())
+	})
+
+	// 半开状态下试探失败立即重新打开
+	t.Run("HalfOpenToOpen", func(t *testing.T) {
+		cb := NewCircuitBreaker(3, 20*time.Millisecond)
+		tripBreaker(t, cb, 3)
+
+		time.Sleep(30 * time.Millisecond)
+		require.ErrorIs(t, cb.Execute(func() error { return errBackend }), errBackend)
+		assert.Equal(t, StateOpen, cb.State())
+
+		var openErr *CircuitOpenError
+		assert.ErrorAs(t, cb.Execute(func() error { return nil }), &openErr)
+	})
+
+	// 需要多个试探请求成功才关闭
+	t.Run("HalfOpenRequests", func(t *testing.T) {
+		cb := NewCircuitBreakerWithConfig(&CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: 20 * time.Millisecond, HalfOpenRequests: 2})
+		tripBreaker(t, cb, 1)
+		time.Sleep(30 * time.Millisecond)
+
+		require.NoError(t, cb.Execute(func() error { return nil }))
+		assert.Equal(t, StateHalfOpen, cb.State())
+		require.NoError(t, cb.Execute(func() error { return nil }))
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	// 状态切换前放行的请求结果被忽略
+	t.Run("StaleResultIgnored", func(t *testing.T) {
+		cb := NewCircuitBreaker(1, 20*time.Millisecond)
+		done, err := cb.Allow()
+		require.NoError(t, err)
+
+		tripBreaker(t, cb, 1)
+		time.Sleep(30 * time.Millisecond)
+		probe, err := cb.Allow()
+		require.NoError(t, err)
+
+		// 关闭状态时放行的请求失败，不影响半开状态的试探
+		done(errBackend)
+		assert.Equal(t, StateHalfOpen, cb.State())
+
+		probe(nil)
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	// 状态切换依次通知，回调中可以读取状态
+	t.Run("OnStateChange", func(t *testing.T) {
+		var transitions []string
+		var cb *CircuitBreaker
+		cb = NewCircuitBreakerWithConfig(&CircuitBreakerConfig{
+			MaxFailures:  1,
+			ResetTimeout: 20 * time.Millisecond,
+			OnStateChange: func(from, to CircuitState) {
+				assert.Equal(t, to, cb.State())
+				transitions = append(transitions, from.String()+"->"+to.String())
+			},
+		})
+
+		tripBreaker(t, cb, 1)
+		time.Sleep(30 * time.Millisecond)
+		require.NoError(t, cb.Execute(func() error { return nil }))
+
+		assert.Equal(t, []string{"closed->open", "open->half_open", "half_open->closed"}, transitions)
+	})
+
+	t.Run("StateString", func(t *testing.T) {
+		assert.Equal(t, "closed", StateClosed.String())
+		assert.Equal(t, "open", StateOpen.String())
+		assert.Equal(t, "half_open", StateHalfOpen.String())
+	})
+}
+
+func TestCircuitBreaker_Concurrency(t *testing.T) {
+	const workers = 50
+
+	// 并发失败时断路器打开，之后的请求均被拒绝
+	t.Run("ConcurrentFailuresOpen", func(t *testing.T) {
+		cb := NewCircuitBreaker(10, time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = cb.Execute(func() error { return errBackend })
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, StateOpen, cb.State())
+		var openErr *CircuitOpenError
+		assert.ErrorAs(t, cb.Execute(func() error { return nil }), &openErr)
+	})
+
+	// 半开状态下并发请求只放行配置数量的试探请求
+	t.Run("HalfOpenLimitsProbes", func(t *testing.T) {
+		cb := NewCircuitBreakerWithConfig(&CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: 20 * time.Millisecond, HalfOpenRequests: 3})
+		tripBreaker(t, cb, 1)
+		time.Sleep(30 * time.Millisecond)
+
+		release := make(chan struct{})
+		var executed, rejected atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := cb.Execute(func() error {
+					executed.Add(1)
+					<-release
+					return nil
+				})
+				var openErr *CircuitOpenError
+				if errors.As(err, &openErr) {
+					rejected.Add(1)
+				}
+			}()
+		}
+
+		// 等待其余请求全部被拒绝后再放行试探请求
+		require.Eventually(t, func() bool { return rejected.Load() == workers-3 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(3), executed.Load())
+		assert.Equal(t, StateClosed, cb.State())
+	})
+
+	// 并发混合成功与失败时状态保持一致，状态切换回调并发调用无数据竞争
+	t.Run("MixedResults", func(t *testing.T) {
+		var opened atomic.Int32
+		cb := NewCircuitBreakerWithConfig(&CircuitBreakerConfig{
+			MaxFailures:      5,
+			ResetTimeout:     time.Millisecond,
+			HalfOpenRequests: 2,
+			OnStateChange: func(from, to CircuitState) {
+				if to == StateOpen {
+					opened.Add(1)
+				}
+			},
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_ = cb.Execute(func() error {
+						if (i+j)%10 != 0 {
+							return errBackend
+						}
+						return nil
+					})
+					_ = cb.State()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		// 数据库恢复后断路器最终关闭
+		require.Eventually(t, func() bool {
+			_ = cb.Execute(func() error { return nil })
+			return cb.State() == StateClosed
+		}, time.Second, 2*time.Millisecond)
+		assert.Positive(t, opened.Load())
+	})
+}
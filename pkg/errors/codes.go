@@ -0,0 +1,57 @@
+package errors
+
+// Code 业务错误码，比错误类型更细，客户端可据此分支处理和本地化
+// 取值一经发布保持稳定，不随提示文案变化
+type Code string
+
+// 用户相关错误码
+const (
+	// CodeUserEmailTaken 邮箱已被其他用户注册
+	CodeUserEmailTaken Code = "USER_EMAIL_TAKEN"
+	// CodeUserEmailDuplicateInBatch 批量创建时同一邮箱在请求中出现多次
+	CodeUserEmailDuplicateInBatch Code = "USER_EMAIL_DUPLICATE_IN_BATCH"
+	// CodeUserVersionConflict 用户已被其他请求修改，客户端需重新读取后再更新
+	CodeUserVersionConflict Code = "USER_VERSION_CONFLICT"
+	// CodeUserBulkLimitExceeded 批量创建的用户数超过上限
+	CodeUserBulkLimitExceeded Code = "USER_BULK_LIMIT_EXCEEDED"
+	// CodeAvatarTooLarge 头像文件超过大小上限
+	CodeAvatarTooLarge Code = "AVATAR_TOO_LARGE"
+	// CodeAvatarTypeNotAllowed 头像文件类型不在允许列表中
+	CodeAvatarTypeNotAllowed Code = "AVATAR_TYPE_NOT_ALLOWED"
+	// CodePasswordTooWeak 密码不满足强度策略
+	CodePasswordTooWeak Code = "PASSWORD_TOO_WEAK"
+)
+
+// 认证相关错误码
+const (
+	// CodeAuthInvalidCredentials 邮箱或密码错误
+	CodeAuthInvalidCredentials Code = "AUTH_INVALID_CREDENTIALS"
+	// CodeAuthAccountLocked 登录失败次数过多，账户已临时锁定
+	CodeAuthAccountLocked Code = "AUTH_ACCOUNT_LOCKED"
+	// CodeAuthTokenInvalid 访问令牌无效或已过期
+	CodeAuthTokenInvalid Code = "AUTH_TOKEN_INVALID"
+	// CodeAuthRefreshTokenInvalid 刷新令牌无效或已过期
+	CodeAuthRefreshTokenInvalid Code = "AUTH_REFRESH_TOKEN_INVALID"
+	// CodeAuthRefreshTokenRevoked 刷新令牌已被撤销
+	CodeAuthRefreshTokenRevoked Code = "AUTH_REFRESH_TOKEN_REVOKED"
+	// CodeAuthEmailNotVerified 邮箱未验证，配置要求验证后才能登录
+	CodeAuthEmailNotVerified Code = "AUTH_EMAIL_NOT_VERIFIED"
+	// CodeAuthVerificationTokenInvalid 邮箱验证令牌无效
+	CodeAuthVerificationTokenInvalid Code = "AUTH_VERIFICATION_TOKEN_INVALID"
+	// CodeAuthVerificationTokenExpired 邮箱验证令牌已过期
+	CodeAuthVerificationTokenExpired Code = "AUTH_VERIFICATION_TOKEN_EXPIRED"
+	// CodeAuthResetTokenInvalid 密码重置令牌无效或已使用
+	CodeAuthResetTokenInvalid Code = "AUTH_RESET_TOKEN_INVALID"
+	// CodeAuthResetTokenExpired 密码重置令牌已过期
+	CodeAuthResetTokenExpired Code = "AUTH_RESET_TOKEN_EXPIRED"
+)
+
+// 请求相关错误码
+const (
+	// CodeIdempotencyKeyReused 幂等键已用于不同的请求
+	CodeIdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED"
+	// CodeIdempotencyInProgress 相同幂等键的请求正在处理中
+	CodeIdempotencyInProgress Code = "IDEMPOTENCY_IN_PROGRESS"
+	// CodeAPIVersionUnsupported Accept 请求头中的API版本不受支持
+	CodeAPIVersionUnsupported Code = "API_VERSION_UNSUPPORTED"
+)
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -25,13 +26,25 @@ const (
 	ErrorTypeBadRequest ErrorType = "BAD_REQUEST"
 	// ErrorTypeConflict 资源冲突
 	ErrorTypeConflict ErrorType = "CONFLICT"
+	// ErrorTypeTooManyRequests 请求过于频繁
+	ErrorTypeTooManyRequests ErrorType = "TOO_MANY_REQUESTS"
+	// ErrorTypeTimeout 请求处理超时
+	ErrorTypeTimeout ErrorType = "TIMEOUT"
+	// ErrorTypeServiceUnavailable 依赖服务暂时不可用
+	ErrorTypeServiceUnavailable ErrorType = "SERVICE_UNAVAILABLE"
+	// ErrorTypeNotAcceptable 无法提供请求要求的响应格式
+	ErrorTypeNotAcceptable ErrorType = "NOT_ACCEPTABLE"
+	// ErrorTypePayloadTooLarge 请求体超过大小限制
+	ErrorTypePayloadTooLarge ErrorType = "PAYLOAD_TOO_LARGE"
 )
 
 // Error 结构化错误
 type Error struct {
-	Type    ErrorType `json:"type"`
-	Message string    `json:"message"`
-	Err     error     `json:"-"`
+	Type    ErrorType    `json:"type"`
+	Code    Code         `json:"code,omitempty"` // 业务错误码，可选
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"` // 字段校验错误，仅验证错误包含
+	Err     error        `json:"-"`
 }
 
 // Error 实现标准error接口
@@ -45,6 +58,12 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// WithCode 设置业务错误码并返回错误本身，便于在构造时链式调用
+func (e *Error) WithCode(code Code) *Error {
+	e.Code = code
+	return e
+}
+
 // StatusCode 返回对应的HTTP状态码
 func (e *Error) StatusCode() int {
 	switch e.Type {
@@ -62,6 +81,16 @@ func (e *Error) StatusCode() int {
 		return http.StatusBadRequest
 	case ErrorTypeConflict:
 		return http.StatusConflict
+	case ErrorTypeTooManyRequests:
+		return http.StatusTooManyRequests
+	case ErrorTypeTimeout:
+		return http.StatusGatewayTimeout
+	case ErrorTypeServiceUnavailable:
+		return http.StatusServiceUnavailable
+	case ErrorTypeNotAcceptable:
+		return http.StatusNotAcceptable
+	case ErrorTypePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
 	default:
 		return http.StatusInternalServerError
 	}
@@ -76,9 +105,11 @@ func New(errType ErrorType, message string, err error) *Error {
 	}
 }
 
-// ValidationError 创建验证错误
+// ValidationError 创建验证错误，err为validator校验错误时解析出各字段的失败原因
 func ValidationError(message string, err error) *Error {
-	return New(ErrorTypeValidation, message, err)
+	e := New(ErrorTypeValidation, message, err)
+	e.Fields = ParseFieldErrors(err)
+	return e
 }
 
 // NotFoundError 创建未找到错误
@@ -111,14 +142,41 @@ func ConflictError(message string, err error) *Error {
 	return New(ErrorTypeConflict, message, err)
 }
 
+// TooManyRequestsError 创建请求过于频繁错误
+func TooManyRequestsError(message string, err error) *Error {
+	return New(ErrorTypeTooManyRequests, message, err)
+}
+
+// TimeoutError 创建请求处理超时错误
+func TimeoutError(message string, err error) *Error {
+	return New(ErrorTypeTimeout, message, err)
+}
+
+// ServiceUnavailableError 创建依赖服务暂时不可用错误
+func ServiceUnavailableError(message string, err error) *Error {
+	return New(ErrorTypeServiceUnavailable, message, err)
+}
+
+// NotAcceptableError 创建无法提供请求格式的错误
+func NotAcceptableError(message string, err error) *Error {
+	return New(ErrorTypeNotAcceptable, message, err)
+}
+
+// PayloadTooLargeError 创建请求体过大错误
+func PayloadTooLargeError(message string, err error) *Error {
+	return New(ErrorTypePayloadTooLarge, message, err)
+}
+
 // AsError 尝试将标准error转换为自定义Error类型
+// 与 RespondError 使用相同的规则，错误链中任意一层为*Error时都返回该错误
 func AsError(err error) *Error {
 	if err == nil {
 		return nil
 	}
 
-	// 如果err已经是*Error类型，则直接返回
-	if e, ok := err.(*Error); ok {
+	// 如果错误链中包含*Error类型，则直接返回
+	var e *Error
+	if errors.As(err, &e) {
 		return e
 	}
 
@@ -130,7 +188,7 @@ func AsError(err error) *Error {
 func RecoverPanic(source string) {
 	if r := recover(); r != nil {
 		// 生产环境只记录必要信息
-		slog.Error("panic recovered", 
+		slog.Error("panic recovered",
 			"source", source,
 			"error", fmt.Sprintf("%v", r))
 	}
@@ -139,10 +197,10 @@ func RecoverPanic(source string) {
 // RecoverPanicWithCallback 从panic中恢复，并执行回调函数
 func RecoverPanicWithCallback(source string, callback func(err interface{})) {
 	if r := recover(); r != nil {
-		slog.Error("panic recovered", 
+		slog.Error("panic recovered",
 			"source", source,
 			"error", fmt.Sprintf("%v", r))
-		
+
 		if callback != nil {
 			callback(r)
 		}
@@ -0,0 +1,100 @@
+package errors
+
+// 支持的语言
+const (
+	LocaleZH = "zh"
+	LocaleEN = "en"
+)
+
+// DefaultLocale 默认语言，错误信息按该语言编写，请求未指定或不支持的语言时使用
+const DefaultLocale = LocaleZH
+
+// codeMessages 按语言和业务错误码索引的错误信息
+var codeMessages = map[string]map[Code]string{
+	LocaleZH: {
+		CodeUserEmailTaken:               "邮箱已被注册",
+		CodeUserEmailDuplicateInBatch:    "邮箱在本批次中重复",
+		CodeUserVersionConflict:          "用户已被其他请求修改，请刷新后重试",
+		CodeUserBulkLimitExceeded:        "批量创建的用户数超过上限",
+		CodeAvatarTooLarge:               "头像文件过大",
+		CodeAvatarTypeNotAllowed:         "不支持的头像文件类型",
+		CodePasswordTooWeak:              "密码强度不足",
+		CodeAuthInvalidCredentials:       "邮箱或密码错误",
+		CodeAuthAccountLocked:            "登录失败次数过多，账户已临时锁定，请稍后重试",
+		CodeAuthTokenInvalid:             "无效的访问令牌",
+		CodeAuthRefreshTokenInvalid:      "无效的刷新令牌",
+		CodeAuthRefreshTokenRevoked:      "刷新令牌已被撤销",
+		CodeAuthEmailNotVerified:         "邮箱未验证，请先完成邮箱验证",
+		CodeAuthVerificationTokenInvalid: "无效的邮箱验证令牌",
+		CodeAuthVerificationTokenExpired: "邮箱验证令牌已过期",
+		CodeAuthResetTokenInvalid:        "无效的密码重置令牌",
+		CodeAuthResetTokenExpired:        "密码重置令牌已过期",
+		CodeIdempotencyKeyReused:         "幂等键已用于不同的请求",
+		CodeIdempotencyInProgress:        "相同幂等键的请求正在处理中",
+		CodeAPIVersionUnsupported:        "不支持请求的API版本",
+	},
+	LocaleEN: {
+		CodeUserEmailTaken:               "Email is already registered",
+		CodeUserEmailDuplicateInBatch:    "Email appears more than once in this batch",
+		CodeUserVersionConflict:          "User was modified by another request, please reload and retry",
+		CodeUserBulkLimitExceeded:        "Too many users in a single bulk request",
+		CodeAvatarTooLarge:               "Avatar file is too large",
+		CodeAvatarTypeNotAllowed:         "Avatar file type is not allowed",
+		CodePasswordTooWeak:              "Password is too weak",
+		CodeAuthInvalidCredentials:       "Invalid email or password",
+		CodeAuthAccountLocked:            "Too many failed login attempts, the account is temporarily locked",
+		CodeAuthTokenInvalid:             "Invalid access token",
+		CodeAuthRefreshTokenInvalid:      "Invalid refresh token",
+		CodeAuthRefreshTokenRevoked:      "Refresh token has been revoked",
+		CodeAuthEmailNotVerified:         "Email address is not verified",
+		CodeAuthVerificationTokenInvalid: "Invalid email verification token",
+		CodeAuthVerificationTokenExpired: "Email verification token has expired",
+		CodeAuthResetTokenInvalid:        "Invalid or already used password reset token",
+		CodeAuthResetTokenExpired:        "Password reset token has expired",
+		CodeIdempotencyKeyReused:         "Idempotency key was already used for a different request",
+		CodeIdempotencyInProgress:        "A request with the same idempotency key is still in progress",
+		CodeAPIVersionUnsupported:        "The requested API version is not supported",
+	},
+}
+
+// typeMessages 没有业务错误码时按错误类型使用的通用信息，默认语言直接使用原始信息
+var typeMessages = map[string]map[ErrorType]string{
+	LocaleEN: {
+		ErrorTypeValidation:         "Validation failed",
+		ErrorTypeNotFound:           "Resource not found",
+		ErrorTypeUnauthorized:       "Unauthorized",
+		ErrorTypeForbidden:          "Forbidden",
+		ErrorTypeInternal:           "Internal server error",
+		ErrorTypeBadRequest:         "Bad request",
+		ErrorTypeConflict:           "Resource conflict",
+		ErrorTypeTooManyRequests:    "Too many requests",
+		ErrorTypeTimeout:            "Request timed out",
+		ErrorTypeServiceUnavailable: "Service temporarily unavailable",
+		ErrorTypeNotAcceptable:      "Not acceptable",
+		ErrorTypePayloadTooLarge:    "Payload too large",
+	},
+}
+
+// IsSupportedLocale 是否为支持的语言
+func IsSupportedLocale(locale string) bool {
+	_, ok := codeMessages[locale]
+	return ok
+}
+
+// LocalizedMessage 返回指定语言的错误信息
+// 优先按业务错误码查找；没有错误码时非默认语言使用错误类型的通用信息，默认语言保留原始信息
+func (e *Error) LocalizedMessage(locale string) string {
+	if !IsSupportedLocale(locale) {
+		locale = DefaultLocale
+	}
+	if msg, ok := codeMessages[locale][e.Code]; ok && e.Code != "" {
+		return msg
+	}
+	if locale == DefaultLocale {
+		return e.Message
+	}
+	if msg, ok := typeMessages[locale][e.Type]; ok {
+		return msg
+	}
+	return e.Message
+}
@@ -2,10 +2,16 @@ package errors
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"net"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // RetryConfig 重试配置
@@ -91,6 +97,11 @@ func RetryWithContext(ctx context.Context, fn RetryableWithContextFunc, config *
 	}
 }
 
+// Delay 返回第attempt次重试（从0开始计数）前的等待时间
+func (c *RetryConfig) Delay(attempt int) time.Duration {
+	return calculateDelay(attempt, c)
+}
+
 // calculateDelay 计算重试延迟
 func calculateDelay(attempt int, config *RetryConfig) time.Duration {
 	// 指数退避
@@ -125,13 +136,92 @@ func (e *RetryError) Unwrap() error {
 }
 
 // IsRetryable 判断错误是否可重试
+// 超时、服务暂时不可用和请求过于频繁的应用错误、context.DeadlineExceeded、网络超时和连接被拒绝或重置、
+// Redis的临时错误（加载数据、只读副本、集群不可用、连接池超时等）可以重试；
+// 验证、不存在、冲突等由请求本身决定结果的应用错误、调用方取消以及其他未知错误不重试
 func IsRetryable(err error) bool {
-	// 如果是HTTP状态码相关的错误，根据状态码判断
-	// 5xx错误、429、408可以重试
-	// 这里简化处理，实际使用时可根据具体错误类型判断
-	
-	// 默认某些错误可重试
-	return false
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// 应用错误的类型明确时按类型判断，内部错误继续检查底层原因
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		switch appErr.Type {
+		case ErrorTypeTimeout, ErrorTypeServiceUnavailable, ErrorTypeTooManyRequests:
+			return true
+		case ErrorTypeInternal:
+			if appErr.Err == nil {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	return isRedisTransient(err)
+}
+
+// redisTransientPrefixes Redis服务端返回的临时错误前缀
+var redisTransientPrefixes = []string{"LOADING", "READONLY", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"}
+
+// isRedisTransient 判断是否为Redis的临时错误，redis.Nil 等业务结果不重试
+func isRedisTransient(err error) bool {
+	if errors.Is(err, redis.Nil) || errors.Is(err, redis.ErrClosed) {
+		return false
+	}
+
+	for _, prefix := range redisTransientPrefixes {
+		if redis.HasErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) && redisErr.Error() == "ERR max number of clients reached" {
+		return true
+	}
+
+	// 连接池等待超时，go-redis未导出该错误
+	return strings.Contains(err.Error(), "redis: connection pool timeout")
+}
+
+// RetryIfAny 组合多个判断函数，任一返回true时重试
+// 例如在默认判断的基础上增加自定义的可重试错误：RetryIfAny(IsRetryable, isLockTimeout)
+func RetryIfAny(predicates ...func(error) bool) func(error) bool {
+	return func(err error) bool {
+		for _, predicate := range predicates {
+			if predicate(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryIfAll 组合多个判断函数，全部返回true时才重试
+// 例如从默认判断中排除特定错误：RetryIfAll(IsRetryable, func(err error) bool { return !errors.Is(err, errQuota) })
+func RetryIfAll(predicates ...func(error) bool) func(error) bool {
+	return func(err error) bool {
+		for _, predicate := range predicates {
+			if !predicate(err) {
+				return false
+			}
+		}
+		return len(predicates) > 0
+	}
 }
 
 // ExponentialBackoff 指数退避重试
@@ -172,92 +262,3 @@ func RetryWithFixedDelay(fn RetryableFunc, delay time.Duration, attempts int) er
 	}
 	return Retry(fn, config)
 }
-
-// CircuitBreaker 断路器
-type CircuitBreaker struct {
-	maxFailures      int
-	resetTimeout     time.Duration
-	halfOpenRequests int
-	
-	failures         int
-	lastFailureTime  time.Time
-	state            CircuitState
-}
-
-// CircuitState 断路器状态
-type CircuitState int
-
-const (
-	// StateClosed 关闭状态（正常）
-	StateClosed CircuitState = iota
-	// StateOpen 打开状态（熔断）
-	StateOpen
-	// StateHalfOpen 半开状态（尝试恢复）
-	StateHalfOpen
-)
-
-// NewCircuitBreaker 创建断路器
-func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
-	return &CircuitBreaker{
-		maxFailures:      maxFailures,
-		resetTimeout:     resetTimeout,
-		halfOpenRequests: 1,
-		state:           StateClosed,
-	}
-}
-
-// Execute 执行函数（带断路器保护）
-func (cb *CircuitBreaker) Execute(fn RetryableFunc) error {
-	// 检查断路器状态
-	if cb.state == StateOpen {
-		if time.Since(cb.lastFailureTime) < cb.resetTimeout {
-			return &CircuitOpenError{
-				ResetAt: cb.lastFailureTime.Add(cb.resetTimeout),
-			}
-		}
-		// 尝试进入半开状态
-		cb.state = StateHalfOpen
-		cb.halfOpenRequests = 1
-	}
-
-	// 执行函数
-	err := fn()
-	
-	if err != nil {
-		cb.recordFailure()
-		return err
-	}
-	
-	cb.recordSuccess()
-	return nil
-}
-
-// recordFailure 记录失败
-func (cb *CircuitBreaker) recordFailure() {
-	cb.failures++
-	cb.lastFailureTime = time.Now()
-	
-	if cb.failures >= cb.maxFailures {
-		cb.state = StateOpen
-	}
-}
-
-// recordSuccess 记录成功
-func (cb *CircuitBreaker) recordSuccess() {
-	if cb.state == StateHalfOpen {
-		cb.halfOpenRequests--
-		if cb.halfOpenRequests <= 0 {
-			cb.state = StateClosed
-			cb.failures = 0
-		}
-	}
-}
-
-// CircuitOpenError 断路器打开错误
-type CircuitOpenError struct {
-	ResetAt time.Time
-}
-
-func (e *CircuitOpenError) Error() string {
-	return fmt.Sprintf("circuit breaker is open, will reset at %v", e.ResetAt)
-}
\ No newline at end of file
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutNetError 模拟网络超时错误
+type timeoutNetError struct{ timeout bool }
+
+func (e timeoutNetError) Error() string   { return "i/o timeout" }
+func (e timeoutNetError) Timeout() bool   { return e.timeout }
+func (e timeoutNetError) Temporary() bool { return e.timeout }
+
+// redisReplyError 使用miniredis返回指定的Redis服务端错误
+func redisReplyError(t *testing.T, reply string) error {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	mr.SetError(reply)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+
+	err := rdb.Get(context.Background(), "key").Err()
+	require.Error(t, err)
+	return err
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"Nil", nil, false},
+		// 应用错误
+		{"Timeout", TimeoutError("请求处理超时", nil), true},
+		{"ServiceUnavailable", ServiceUnavailableError("数据库暂时不可用", nil), true},
+		{"TooManyRequests", TooManyRequestsError("请求过于频繁", nil), true},
+		{"Validation", ValidationError("输入数据验证失败", nil), false},
+		{"NotFound", NotFoundError("用户", nil), false},
+		{"Conflict", ConflictError("邮箱已被注册", nil), false},
+		{"BadRequest", BadRequestError("无效的请求", nil), false},
+		{"Unauthorized", UnauthorizedError("未认证", nil), false},
+		// 不可重试的应用错误即使包装了超时也不重试
+		{"ValidationWrappingDeadline", ValidationError("输入数据验证失败", context.DeadlineExceeded), false},
+		// 内部错误按底层原因判断
+		{"InternalWithoutCause", InternalError("内部错误", nil), false},
+		{"InternalWrappingDeadline", InternalError("获取用户失败", context.DeadlineExceeded), true},
+		{"WrappedServiceUnavailable", fmt.Errorf("调用失败: %w", ServiceUnavailableError("数据库暂时不可用", nil)), true},
+		// 上下文
+		{"DeadlineExceeded", context.DeadlineExceeded, true},
+		{"WrappedDeadlineExceeded", fmt.Errorf("查询: %w", context.DeadlineExceeded), true},
+		{"Canceled", context.Canceled, false},
+		// 网络错误
+		{"NetTimeout", &net.OpError{Op: "read", Net: "tcp", Err: timeoutNetError{timeout: true}}, true},
+		{"NetNonTimeout", timeoutNetError{timeout: false}, false},
+		{"ConnectionRefused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"ConnectionReset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+		// Redis
+		{"RedisNil", redis.Nil, false},
+		{"RedisClosed", redis.ErrClosed, false},
+		{"RedisPoolTimeout", errors.New("redis: connection pool timeout"), true},
+		// 其他未知错误
+		{"Plain", errors.New("boom"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.retryable, IsRetryable(tc.err))
+		})
+	}
+
+	// Redis服务端返回的临时错误可以重试，命令错误不重试
+	t.Run("RedisReplies", func(t *testing.T) {
+		assert.True(t, IsRetryable(redisReplyError(t, "LOADING Redis is loading the dataset in memory")))
+		assert.True(t, IsRetryable(redisReplyError(t, "READONLY You can't write against a read only replica")))
+		assert.True(t, IsRetryable(redisReplyError(t, "CLUSTERDOWN The cluster is down")))
+		assert.True(t, IsRetryable(redisReplyError(t, "ERR max number of clients reached")))
+		assert.False(t, IsRetryable(redisReplyError(t, "WRONGTYPE Operation against a key holding the wrong kind of value")))
+	})
+}
+
+func TestRetryPredicates(t *testing.T) {
+	errLockTimeout := errors.New("lock timeout")
+	errQuota := TooManyRequestsError("配额已用完", nil)
+	isLockTimeout := func(err error) bool { return errors.Is(err, errLockTimeout) }
+	notQuota := func(err error) bool { return !errors.Is(err, errQuota) }
+
+	// 任一判断函数返回true即重试
+	t.Run("Any", func(t *testing.T) {
+		retryIf := RetryIfAny(IsRetryable, isLockTimeout)
+		assert.True(t, retryIf(errLockTimeout))
+		assert.True(t, retryIf(context.DeadlineExceeded))
+		assert.False(t, retryIf(NotFoundError("用户", nil)))
+		assert.False(t, RetryIfAny()(errLockTimeout))
+	})
+
+	// 全部判断函数返回true才重试
+	t.Run("All", func(t *testing.T) {
+		retryIf := RetryIfAll(IsRetryable, notQuota)
+		assert.False(t, retryIf(errQuota))
+		assert.True(t, retryIf(TooManyRequestsError("请求过于频繁", nil)))
+		assert.False(t, RetryIfAll()(context.DeadlineExceeded))
+	})
+
+	// 默认重试策略按分类决定是否重试
+	t.Run("WithRetry", func(t *testing.T) {
+		attempts := 0
+		err := RetryWithFixedDelay(func() error {
+			attempts++
+			return ServiceUnavailableError("数据库暂时不可用", nil)
+		}, time.Millisecond, 3)
+		var retryErr *RetryError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 3, attempts)
+
+		attempts = 0
+		err = RetryWithFixedDelay(func() error {
+			attempts++
+			return NotFoundError("用户", nil)
+		}, time.Millisecond, 3)
+		appErr := AsError(err)
+		assert.Equal(t, ErrorTypeNotFound, appErr.Type)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段名
+	Tag     string `json:"tag"`     // 未通过的校验规则
+	Message string `json:"message"` // 可读的失败原因
+}
+
+// ParseFieldErrors 将validator的校验错误转换为字段错误列表，非校验错误返回nil
+func ParseFieldErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Message: fieldErrorMessage(fe),
+		})
+	}
+	return fields
+}
+
+// fieldErrorMessage 根据校验规则生成可读的失败原因
+func fieldErrorMessage(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+
+	switch fe.Tag() {
+	case "required":
+		return "不能为空"
+	case "email":
+		return "必须是有效的邮箱地址"
+	case "url":
+		return "必须是有效的URL"
+	case "min":
+		if isString {
+			return fmt.Sprintf("长度不能小于%s", fe.Param())
+		}
+		return fmt.Sprintf("不能小于%s", fe.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("长度不能大于%s", fe.Param())
+		}
+		return fmt.Sprintf("不能大于%s", fe.Param())
+	case "len":
+		return fmt.Sprintf("长度必须为%s", fe.Param())
+	case "gte":
+		return fmt.Sprintf("必须大于或等于%s", fe.Param())
+	case "lte":
+		return fmt.Sprintf("必须小于或等于%s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("必须大于%s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("必须小于%s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("必须是以下值之一: %s", fe.Param())
+	case "eqfield":
+		return fmt.Sprintf("必须与%s一致", fe.Param())
+	default:
+		return fmt.Sprintf("未通过%s校验", fe.Tag())
+	}
+}
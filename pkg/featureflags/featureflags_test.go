@@ -0,0 +1,146 @@
+package featureflags
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+func userCtx(id string) context.Context {
+	return logger.WithUserID(context.Background(), id)
+}
+
+func TestMemoryProvider_Boolean(t *testing.T) {
+	p := NewMemoryProvider(Set{
+		"on":   {Enabled: true},
+		"off":  {Enabled: false},
+		"beta": {Enabled: false, Users: []string{"42"}},
+	})
+	ctx := context.Background()
+
+	assert.True(t, p.IsEnabled(ctx, "on"))
+	assert.False(t, p.IsEnabled(ctx, "off"))
+	assert.False(t, p.IsEnabled(ctx, "missing"))
+
+	// 指定用户始终开启
+	assert.True(t, p.IsEnabled(userCtx("42"), "beta"))
+	assert.False(t, p.IsEnabled(userCtx("43"), "beta"))
+	assert.False(t, p.IsEnabled(ctx, "beta"))
+
+	// 替换后未包含的开关被删除
+	p.Replace(Set{"off": {Enabled: true}})
+	assert.False(t, p.IsEnabled(ctx, "on"))
+	assert.True(t, p.IsEnabled(ctx, "off"))
+}
+
+// percent 返回灰度百分比的指针
+func percent(p int) *int {
+	return &p
+}
+
+func TestFlag_PercentageRollout(t *testing.T) {
+	flag := Flag{Enabled: true, Percentage: percent(30)}
+
+	// 同一用户的结果固定
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		first := flag.EnabledFor("rollout", id)
+		for range 5 {
+			assert.Equal(t, first, flag.EnabledFor("rollout", id))
+		}
+	}
+
+	// 开启比例接近配置的百分比
+	enabled := 0
+	for i := range 10000 {
+		if flag.EnabledFor("rollout", strconv.Itoa(i)) {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 3000, enabled, 300)
+
+	// 增大百分比时已开启的用户保持开启
+	wider := Flag{Enabled: true, Percentage: percent(60)}
+	for i := range 1000 {
+		id := strconv.Itoa(i)
+		if flag.EnabledFor("rollout", id) {
+			assert.True(t, wider.EnabledFor("rollout", id), "用户 %s", id)
+		}
+	}
+
+	// 不同开关的分桶相互独立
+	differs := false
+	for i := range 100 {
+		id := strconv.Itoa(i)
+		if flag.EnabledFor("rollout", id) != flag.EnabledFor("other", id) {
+			differs = true
+			break
+		}
+	}
+	assert.True(t, differs)
+
+	// 未认证的请求不在灰度范围内，关闭的开关不灰度
+	assert.False(t, flag.EnabledFor("rollout", ""))
+	assert.False(t, Flag{Percentage: percent(100)}.EnabledFor("rollout", "1"))
+	assert.True(t, Flag{Enabled: true, Percentage: percent(100)}.EnabledFor("rollout", ""))
+}
+
+func TestFlag_ZeroPercentage(t *testing.T) {
+	// 0%不对任何用户开启，指定用户仍然开启
+	flag := Flag{Enabled: true, Percentage: percent(0), Users: []string{"42"}}
+	for i := range 1000 {
+		assert.False(t, flag.EnabledFor("rollout", "user-"+strconv.Itoa(i)))
+	}
+	assert.False(t, flag.EnabledFor("rollout", ""))
+	assert.True(t, flag.EnabledFor("rollout", "42"))
+
+	// 未设置百分比时对所有请求开启
+	assert.True(t, Flag{Enabled: true}.EnabledFor("rollout", "1"))
+	assert.True(t, Flag{Enabled: true}.EnabledFor("rollout", ""))
+}
+
+func TestRedisProvider(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defaults := NewMemoryProvider(Set{"a": {Enabled: true}, "b": {Enabled: false}})
+	p := NewRedisProvider(client, "", defaults)
+	ctx := context.Background()
+
+	// Redis中没有时使用默认开关
+	assert.True(t, p.IsEnabled(ctx, "a"))
+	assert.False(t, p.IsEnabled(ctx, "b"))
+
+	// Redis中的开关覆盖默认值
+	require.NoError(t, p.Set(ctx, "a", Flag{Enabled: false}))
+	require.NoError(t, p.Set(ctx, "c", Flag{Enabled: true, Users: []string{"5"}}))
+	assert.False(t, p.IsEnabled(ctx, "a"))
+	assert.True(t, p.IsEnabled(ctx, "c"))
+
+	flags, err := p.Flags(ctx)
+	require.NoError(t, err)
+	assert.Len(t, flags, 3)
+	assert.False(t, flags.IsEnabled(ctx, "a"))
+	assert.Equal(t, []string{"5"}, flags["c"].Users)
+
+	// 删除后恢复默认值
+	require.NoError(t, p.Delete(ctx, "a"))
+	assert.True(t, p.IsEnabled(ctx, "a"))
+
+	// 格式无效的开关被忽略
+	mr.HSet(DefaultRedisKey, "b", "not json")
+	assert.False(t, p.IsEnabled(ctx, "b"))
+
+	// Redis不可用时使用默认开关
+	mr.Close()
+	assert.True(t, p.IsEnabled(ctx, "a"))
+	flags, err = p.Flags(ctx)
+	assert.Error(t, err)
+	assert.True(t, flags.IsEnabled(ctx, "a"))
+}
@@ -0,0 +1,49 @@
+package featureflags
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+// MemoryProvider 内存中的功能开关，通常由配置文件提供，配置变更时通过 Replace 整体替换
+type MemoryProvider struct {
+	mu    sync.RWMutex
+	flags Set
+}
+
+// NewMemoryProvider 创建内存功能开关，flags会被复制
+func NewMemoryProvider(flags Set) *MemoryProvider {
+	return &MemoryProvider{flags: maps.Clone(flags)}
+}
+
+// IsEnabled 实现 FlagProvider
+func (p *MemoryProvider) IsEnabled(ctx context.Context, name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.flags.IsEnabled(ctx, name)
+}
+
+// Flags 实现 FlagProvider，返回的集合可被调用方修改
+func (p *MemoryProvider) Flags(ctx context.Context) (Set, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return maps.Clone(p.flags), nil
+}
+
+// Set 设置单个开关
+func (p *MemoryProvider) Set(name string, flag Flag) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.flags == nil {
+		p.flags = Set{}
+	}
+	p.flags[name] = flag
+}
+
+// Replace 替换所有开关，配置重新加载时调用，未包含的开关被删除
+func (p *MemoryProvider) Replace(flags Set) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags = maps.Clone(flags)
+}
@@ -0,0 +1,86 @@
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisKey Redis中保存功能开关的哈希键，字段为开关名，值为 Flag 的JSON
+const DefaultRedisKey = "feature_flags"
+
+// RedisProvider 保存在Redis哈希中的功能开关，多个实例共享，修改后立即对所有实例生效
+// Redis中的开关覆盖defaults中的同名开关，Redis中没有的开关使用defaults；读取Redis失败时只使用defaults
+type RedisProvider struct {
+	client   *redis.Client
+	key      string
+	defaults FlagProvider
+}
+
+// NewRedisProvider 创建Redis功能开关，key为空时使用 DefaultRedisKey，defaults为空时没有默认开关
+func NewRedisProvider(client *redis.Client, key string, defaults FlagProvider) *RedisProvider {
+	if key == "" {
+		key = DefaultRedisKey
+	}
+	if defaults == nil {
+		defaults = NewMemoryProvider(nil)
+	}
+	return &RedisProvider{client: client, key: key, defaults: defaults}
+}
+
+// IsEnabled 实现 FlagProvider
+func (p *RedisProvider) IsEnabled(ctx context.Context, name string) bool {
+	data, err := p.client.HGet(ctx, p.key, name).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.Warn("读取功能开关失败，使用默认值", "flag", name, "error", err)
+		}
+		return p.defaults.IsEnabled(ctx, name)
+	}
+
+	var flag Flag
+	if err := json.Unmarshal(data, &flag); err != nil {
+		slog.Warn("功能开关格式无效，使用默认值", "flag", name, "error", err)
+		return p.defaults.IsEnabled(ctx, name)
+	}
+	return Set{name: flag}.IsEnabled(ctx, name)
+}
+
+// Flags 实现 FlagProvider，读取Redis失败时返回默认开关和错误
+func (p *RedisProvider) Flags(ctx context.Context) (Set, error) {
+	flags, err := p.defaults.Flags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := p.client.HGetAll(ctx, p.key).Result()
+	if err != nil {
+		return flags, err
+	}
+	for name, data := range values {
+		var flag Flag
+		if err := json.Unmarshal([]byte(data), &flag); err != nil {
+			slog.Warn("功能开关格式无效，使用默认值", "flag", name, "error", err)
+			continue
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// Set 在Redis中设置开关，覆盖默认值
+func (p *RedisProvider) Set(ctx context.Context, name string, flag Flag) error {
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+	return p.client.HSet(ctx, p.key, name, data).Err()
+}
+
+// Delete 删除Redis中的开关，恢复使用默认值
+func (p *RedisProvider) Delete(ctx context.Context, name string) error {
+	return p.client.HDel(ctx, p.key, name).Err()
+}
@@ -0,0 +1,263 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// 透传给下游的追踪请求头
+const (
+	HeaderTraceID        = "X-Trace-ID"
+	HeaderRequestID      = "X-Request-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+// maxDrainBytes 重试前读取并丢弃的响应体上限，读完的连接才能复用
+const maxDrainBytes = 64 << 10
+
+// Config 客户端配置
+type Config struct {
+	// 单次请求（每次重试分别计时）的超时时间
+	Timeout time.Duration
+	// 重试策略，MaxAttempts为总尝试次数；RetryIf为空时使用 errors.IsRetryable 判断传输错误
+	Retry apperrors.RetryConfig
+	// 断路器配置，为空时不启用断路器
+	CircuitBreaker *apperrors.CircuitBreakerConfig
+	// 底层传输，为空时使用 http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+// DefaultConfig 默认客户端配置
+var DefaultConfig = Config{
+	Timeout: 10 * time.Second,
+	Retry: apperrors.RetryConfig{
+		MaxAttempts:     3,
+		InitialDelay:    200 * time.Millisecond,
+		MaxDelay:        5 * time.Second,
+		Multiplier:      2.0,
+		RandomizeFactor: 0.1,
+		RetryIf:         apperrors.IsRetryable,
+	},
+	CircuitBreaker: &apperrors.DefaultCircuitBreakerConfig,
+}
+
+// Client 带重试、断路器和追踪透传的HTTP客户端，可被多个goroutine并发使用
+// 断路器按客户端统计，每个下游服务应使用单独的客户端
+type Client struct {
+	http    *http.Client
+	timeout time.Duration
+	retry   apperrors.RetryConfig
+	breaker *apperrors.CircuitBreaker
+}
+
+// New 创建HTTP客户端，config为空时使用默认配置
+func New(config *Config) *Client {
+	if config == nil {
+		config = &DefaultConfig
+	}
+
+	retry := config.Retry
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 1
+	}
+	if retry.RetryIf == nil {
+		retry.RetryIf = apperrors.IsRetryable
+	}
+
+	c := &Client{
+		http:    &http.Client{Transport: config.Transport},
+		timeout: config.Timeout,
+		retry:   retry,
+	}
+	if config.CircuitBreaker != nil {
+		c.breaker = apperrors.NewCircuitBreakerWithConfig(config.CircuitBreaker)
+	}
+	return c
+}
+
+// CircuitBreaker 返回客户端的断路器，未启用时返回nil
+func (c *Client) CircuitBreaker() *apperrors.CircuitBreaker {
+	return c.breaker
+}
+
+// Do 发送请求，请求上下文使用ctx，并透传ctx中的链路追踪ID、请求ID和追踪上下文
+// 幂等请求（GET、HEAD、OPTIONS、PUT、DELETE，或带 Idempotency-Key 的请求）在传输错误、5xx和429时按重试策略重试，
+// 响应带 Retry-After 时至少等待该时长，超过最大延迟时不再重试；带请求体的请求需要设置 GetBody 才能重试。
+// 重试用尽后返回最后一次的响应，传输错误重试用尽时返回 *errors.RetryError；断路器打开时返回 *errors.CircuitOpenError
+func (c *Client) Do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	var lastErr error
+	for attempt := 0; attempt < c.retry.MaxAttempts; attempt++ {
+		resp, err := c.attempt(ctx, req, attempt > 0)
+		last := attempt == c.retry.MaxAttempts-1
+		if err != nil {
+			var openErr *apperrors.CircuitOpenError
+			if !retryable || errors.As(err, &openErr) || ctx.Err() != nil || !c.retry.RetryIf(err) {
+				return nil, err
+			}
+			lastErr = err
+			if last {
+				break
+			}
+			if err := sleep(ctx, c.retry.Delay(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if !retryable || !retryableStatus(resp.StatusCode) || last {
+			return resp, nil
+		}
+
+		// 服务端要求的等待时间超过最大延迟时直接返回响应，由调用方决定如何处理
+		delay := c.retry.Delay(attempt)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if retryAfter > c.retry.MaxDelay {
+				return resp, nil
+			}
+			delay = max(delay, retryAfter)
+		}
+
+		drain(resp.Body)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, &apperrors.RetryError{LastError: lastErr, Attempts: c.retry.MaxAttempts}
+}
+
+// attempt 发送一次请求，超时从发送开始计算，直到响应体关闭；rewind为true时通过 GetBody 重建请求体
+func (c *Client) attempt(ctx context.Context, req *http.Request, rewind bool) (*http.Response, error) {
+	var body io.ReadCloser
+	if rewind && req.GetBody != nil {
+		var err error
+		if body, err = req.GetBody(); err != nil {
+			return nil, fmt.Errorf("重建请求体失败: %w", err)
+		}
+	}
+
+	var done func(error)
+	if c.breaker != nil {
+		var err error
+		if done, err = c.breaker.Allow(); err != nil {
+			return nil, err
+		}
+	}
+
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	out := req.Clone(attemptCtx)
+	if body != nil {
+		out.Body = body
+	}
+	injectTraceHeaders(attemptCtx, out.Header)
+
+	resp, err := c.http.Do(out)
+	if done != nil {
+		switch {
+		case err != nil && ctx.Err() == nil:
+			done(err)
+		case err == nil && resp.StatusCode >= http.StatusInternalServerError:
+			done(fmt.Errorf("服务端返回状态码 %d", resp.StatusCode))
+		default:
+			done(nil)
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// injectTraceHeaders 写入链路追踪ID、请求ID和W3C/B3追踪上下文，已设置的请求头不覆盖
+func injectTraceHeaders(ctx context.Context, header http.Header) {
+	if traceID := logger.GetTraceID(ctx); traceID != "" && header.Get(HeaderTraceID) == "" {
+		header.Set(HeaderTraceID, traceID)
+	}
+	if requestID := logger.GetRequestID(ctx); requestID != "" && header.Get(HeaderRequestID) == "" {
+		header.Set(HeaderRequestID, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// isIdempotent 请求是否可以安全重试
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// retryableStatus 5xx和429可以重试
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// parseRetryAfter 解析 Retry-After，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleep 等待指定时间，上下文结束时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drain 读取并关闭响应体，使连接可以复用
+func drain(body io.ReadCloser) {
+	_, _ = io.CopyN(io.Discard, body, maxDrainBytes)
+	_ = body.Close()
+}
+
+// cancelOnClose 响应体关闭时取消单次请求的超时上下文
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close 关闭响应体并释放上下文
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,245 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/logger"
+)
+
+// newTestClient 创建重试间隔很短的客户端
+func newTestClient(breaker *apperrors.CircuitBreakerConfig) *Client {
+	return New(&Config{
+		Timeout: time.Second,
+		Retry: apperrors.RetryConfig{
+			MaxAttempts:  3,
+			InitialDelay: time.Millisecond,
+			MaxDelay:     2 * time.Second,
+			Multiplier:   1,
+		},
+		CircuitBreaker: breaker,
+	})
+}
+
+// flakyServer 前failures次请求返回status，之后返回200
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// readBody 读取并关闭响应体
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestClient_Retry(t *testing.T) {
+	ctx := context.Background()
+
+	// 503后重试成功
+	t.Run("ServiceUnavailableThenOK", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "ok:", readBody(t, resp))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	// 按 Retry-After 等待后重试
+	t.Run("HonorsRetryAfter", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+		start := time.Now()
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+		assert.Equal(t, int32(2), calls.Load())
+		resp.Body.Close()
+	})
+
+	// Retry-After 超过最大延迟时直接返回响应
+	t.Run("RetryAfterTooLong", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, http.Header{"Retry-After": {"60"}})
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+		resp.Body.Close()
+	})
+
+	// 重试用尽后返回最后一次响应
+	t.Run("Exhausted", func(t *testing.T) {
+		srv, calls := flakyServer(t, 10, http.StatusBadGateway, nil)
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Equal(t, int32(3), calls.Load())
+		resp.Body.Close()
+	})
+
+	// 非幂等请求不重试，带幂等键时重试并重新发送请求体
+	t.Run("Idempotency", func(t *testing.T) {
+		srv, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+		req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+		resp.Body.Close()
+
+		req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+		req.Header.Set(HeaderIdempotencyKey, "key-1")
+		resp, err = newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, "ok:payload", readBody(t, resp))
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	// 客户端错误不重试
+	t.Run("ClientErrorNotRetried", func(t *testing.T) {
+		srv, calls := flakyServer(t, 10, http.StatusNotFound, nil)
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+		resp, err := newTestClient(nil).Do(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, int32(1), calls.Load())
+		resp.Body.Close()
+	})
+
+	// 连接失败重试用尽后返回 RetryError
+	t.Run("TransportError", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		url := srv.URL
+		srv.Close()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+
+		_, err := newTestClient(nil).Do(ctx, req)
+		var retryErr *apperrors.RetryError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 3, retryErr.Attempts)
+	})
+}
+
+func TestClient_Timeout(t *testing.T) {
+	// 单次请求超时后重试，第二次请求正常返回
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client := New(&Config{
+		Timeout: 50 * time.Millisecond,
+		Retry:   apperrors.RetryConfig{MaxAttempts: 2, InitialDelay: time.Millisecond, MaxDelay: time.Second, Multiplier: 1},
+	})
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+	resp, err := client.Do(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", readBody(t, resp))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_TraceHeaders(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	ctx := logger.WithRequestID(logger.WithTraceID(context.Background(), "trace-1"), "req-1")
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+
+	resp, err := newTestClient(nil).Do(ctx, req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "trace-1", header.Get(HeaderTraceID))
+	assert.Equal(t, "req-1", header.Get(HeaderRequestID))
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	srv, calls := flakyServer(t, 10, http.StatusInternalServerError, nil)
+	client := New(&Config{
+		Timeout:        time.Second,
+		Retry:          apperrors.RetryConfig{MaxAttempts: 1},
+		CircuitBreaker: &apperrors.CircuitBreakerConfig{MaxFailures: 2, ResetTimeout: time.Minute},
+	})
+
+	// 连续两次5xx后断路器打开，之后的请求不再发送
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		resp, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, apperrors.StateOpen, client.CircuitBreaker().State())
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := client.Do(context.Background(), req)
+	var openErr *apperrors.CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := parseRetryAfter("3")
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, 5*time.Second, d, float64(time.Second))
+
+	_, ok = parseRetryAfter("")
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+	_, ok = parseRetryAfter("-1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,77 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+)
+
+// 黑名单缓存键前缀
+const (
+	tokenBlacklistPrefix  = "blacklist:"        // 按jti记录已撤销的访问令牌
+	familyBlacklistPrefix = "family_blacklist:" // 按令牌家族记录已撤销的会话
+)
+
+// Blacklist 已撤销访问令牌的黑名单，保存在缓存中，记录保留到令牌过期为止
+// 按jti而不是完整令牌记录，缓存键长度固定；缺少jti的令牌（升级前签发）只能通过令牌家族撤销
+// 缓存不可用时为nil，nil的 Blacklist 不记录任何令牌，检查时总是返回未撤销
+type Blacklist struct {
+	cache  cache.Cache
+	leeway time.Duration
+}
+
+// NewBlacklist 创建黑名单，leeway为校验令牌时容忍的时钟偏差（Config.LeewayDuration），
+// 令牌在过期后的容忍时间内仍能通过校验，记录需多保留相同时长；缓存不可用（nil或 cache.NullCache）时返回nil
+func NewBlacklist(c cache.Cache, leeway time.Duration) *Blacklist {
+	if !cache.Available(c) {
+		return nil
+	}
+	return &Blacklist{cache: c, leeway: leeway}
+}
+
+// Revoke 撤销单个访问令牌，记录保留到令牌过期
+func (b *Blacklist) Revoke(ctx context.Context, claims *Claims) error {
+	if b == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	ttl := time.Until(claims.ExpiresAt.Time) + b.leeway
+	if ttl <= 0 {
+		return nil
+	}
+	return b.cache.SetObject(ctx, tokenBlacklistPrefix+claims.ID, true, ttl)
+}
+
+// RevokeFamily 撤销令牌家族内的所有访问令牌，ttl应不小于访问令牌的有效期
+func (b *Blacklist) RevokeFamily(ctx context.Context, familyID string, ttl time.Duration) error {
+	if b == nil || familyID == "" {
+		return nil
+	}
+	return b.cache.SetObject(ctx, familyBlacklistPrefix+familyID, true, ttl+b.leeway)
+}
+
+// IsRevoked 判断访问令牌本身或其所属的令牌家族是否已被撤销
+// 缓存读取失败时记录警告并视为未撤销，避免缓存故障导致所有请求认证失败
+func (b *Blacklist) IsRevoked(ctx context.Context, claims *Claims) bool {
+	if b == nil {
+		return false
+	}
+	return b.contains(ctx, tokenBlacklistPrefix, claims.ID) || b.contains(ctx, familyBlacklistPrefix, claims.FamilyID)
+}
+
+// contains 判断黑名单中是否存在指定记录
+func (b *Blacklist) contains(ctx context.Context, prefix, id string) bool {
+	if id == "" {
+		return false
+	}
+	_, err := b.cache.Get(ctx, prefix+id)
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, cache.ErrNotFound) {
+		slog.Warn("查询令牌黑名单失败", "key", prefix+id, "error", err)
+	}
+	return false
+}
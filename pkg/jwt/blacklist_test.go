@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/cache"
+)
+
+func TestBlacklist(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	c, err := cache.NewCache(cache.Options{RedisAddress: mr.Addr()})
+	require.NoError(t, err)
+	blacklist := NewBlacklist(c, 0)
+	config := newTestConfig()
+
+	parse := func(familyID string) *Claims {
+		token, err := GenerateAccessToken(1, "user", familyID, config)
+		require.NoError(t, err)
+		claims, err := ParseToken(token, config)
+		require.NoError(t, err)
+		return claims
+	}
+
+	// 按jti撤销，记录保留到令牌过期
+	t.Run("Revoke", func(t *testing.T) {
+		claims, other := parse("family"), parse("family")
+		require.NotEmpty(t, claims.ID)
+		require.NotEqual(t, claims.ID, other.ID)
+
+		require.NoError(t, blacklist.Revoke(ctx, claims))
+		assert.True(t, blacklist.IsRevoked(ctx, claims))
+		assert.False(t, blacklist.IsRevoked(ctx, other))
+		assert.InDelta(t, config.AccessTokenExp.Seconds(), mr.TTL(tokenBlacklistPrefix+claims.ID).Seconds(), 5)
+
+		mr.FastForward(config.AccessTokenExp)
+		assert.False(t, blacklist.IsRevoked(ctx, claims))
+	})
+
+	// 撤销令牌家族
+	t.Run("RevokeFamily", func(t *testing.T) {
+		require.NoError(t, blacklist.RevokeFamily(ctx, "revoked", time.Minute))
+		assert.True(t, blacklist.IsRevoked(ctx, parse("revoked")))
+		assert.False(t, blacklist.IsRevoked(ctx, parse("")))
+	})
+
+	// 缓存不可用时为nil，不记录也不拒绝
+	t.Run("Unavailable", func(t *testing.T) {
+		none := NewBlacklist(cache.NewNullCache(), 0)
+		assert.Nil(t, none)
+		claims := parse("family")
+		assert.NoError(t, none.Revoke(ctx, claims))
+		assert.False(t, none.IsRevoked(ctx, claims))
+	})
+}
@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+)
+
+// JWK JSON Web Key（RFC 7517），仅包含RSA公钥字段
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK 将RSA公钥转换为JWK
+func NewJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: AlgorithmRS256,
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// JWKS 返回用于公开分发的公钥集合，HS256时为空集合
+func (c *Config) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if c.algorithm() != AlgorithmRS256 {
+		return jwks
+	}
+
+	for _, key := range c.PublicKeys() {
+		jwks.Keys = append(jwks.Keys, NewJWK(key.KeyID, key.Key))
+	}
+	return jwks
+}
+
+// Thumbprint 计算RSA公钥的JWK指纹（RFC 7638），用作默认kid
+func Thumbprint(key *rsa.PublicKey) string {
+	// 指纹要求成员按字典序排列且不含空白
+	data, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -1,73 +1,366 @@
 package jwt
 
 import (
+	"crypto/rsa"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+// 支持的签名算法
+const (
+	AlgorithmHS256 = "HS256" // HMAC共享密钥签名（默认）
+	AlgorithmRS256 = "RS256" // RSA非对称签名，验证方只需持有公钥
 )
 
 // Config JWT配置
 type Config struct {
-	Secret          string        // JWT密钥
+	Secret          string        // JWT密钥（HS256）
 	AccessTokenExp  time.Duration // 访问令牌过期时间
 	RefreshTokenExp time.Duration // 刷新令牌过期时间
 	Issuer          string        // 签发者
+	Audience        string        // 访问令牌的受众，不为空时写入aud并在解析时校验
+	LeewayDuration  time.Duration // 校验exp、nbf和iat时容忍的时钟偏差，应对服务器之间的时钟差异
+
+	// 各角色拥有的权限范围，签发访问令牌时按角色写入scope声明
+	RoleScopes map[string][]string
+
+	Algorithm      string // 签名算法，HS256 或 RS256，为空时使用 HS256
+	PrivateKeyFile string // RSA私钥PEM文件路径（RS256签名）
+	PublicKeyFile  string // RSA公钥PEM文件路径（RS256验证）
+	KeyID          string // 当前密钥的kid，为空时使用公钥指纹
+
+	// 轮换前的公钥PEM文件路径，仅用于验证轮换前签发、尚未过期的令牌
+	PreviousPublicKeyFiles []string
+
+	PrivateKey         *rsa.PrivateKey // RSA私钥，仅签发令牌时需要
+	PublicKey          *rsa.PublicKey  // RSA公钥，验证令牌时需要
+	PreviousPublicKeys []PublicKey     // 轮换前的公钥
+}
+
+// PublicKey 带kid的RSA公钥
+type PublicKey struct {
+	KeyID string
+	Key   *rsa.PublicKey
 }
 
 // Claims 自定义JWT声明
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID   uint   `json:"user_id"`
+	Role     string `json:"role"`
+	FamilyID string `json:"fid,omitempty"`   // 所属刷新令牌家族ID
+	Scope    string `json:"scope,omitempty"` // 权限范围，以空格分隔
+	jwt.RegisteredClaims
+}
+
+// Scopes 返回令牌拥有的权限范围
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope 判断令牌是否拥有指定权限范围
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+// RefreshClaims 刷新令牌声明
+// RegisteredClaims.ID 为令牌唯一标识(jti)，Subject 为用户ID
+type RefreshClaims struct {
+	FamilyID string `json:"fid"` // 令牌家族ID，同一次登录轮换产生的刷新令牌属于同一家族
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken 生成访问令牌
-func GenerateAccessToken(userID uint, role string, config *Config) (string, error) {
+// UserID 从Subject中解析用户ID
+func (c *RefreshClaims) UserID() (uint, error) {
+	return subjectUserID(c.Subject)
+}
+
+// ErrTokenExpired 令牌已过期，可用 errors.Is 判断
+var ErrTokenExpired = jwt.ErrTokenExpired
+
+// ScopeIntrospect 调用令牌内省接口所需的权限范围，授予网关等受信任的客户端
+const ScopeIntrospect = "auth:introspect"
+
+// AudienceEmailVerification 邮箱验证令牌的受众，带该受众的令牌不能作为访问令牌使用
+const AudienceEmailVerification = "email_verification"
+
+// EmailVerificationClaims 邮箱验证令牌声明
+// Subject 为用户ID，Email 为签发时的邮箱，邮箱修改后旧令牌失效
+type EmailVerificationClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// UserID 从Subject中解析用户ID
+func (c *EmailVerificationClaims) UserID() (uint, error) {
+	return subjectUserID(c.Subject)
+}
+
+// AudiencePasswordReset 密码重置令牌的受众，带该受众的令牌不能作为访问令牌使用
+const AudiencePasswordReset = "password_reset"
+
+// restrictedAudiences 一次性用途令牌的受众，与访问令牌使用相同的密钥签名
+var restrictedAudiences = []string{AudienceEmailVerification, AudiencePasswordReset}
+
+// PasswordResetClaims 密码重置令牌声明
+// Subject 为用户ID，PasswordFingerprint 为签发时密码哈希的指纹，密码修改后旧令牌失效
+type PasswordResetClaims struct {
+	PasswordFingerprint string `json:"pwf"`
+	jwt.RegisteredClaims
+}
+
+// UserID 从Subject中解析用户ID
+func (c *PasswordResetClaims) UserID() (uint, error) {
+	return subjectUserID(c.Subject)
+}
+
+// subjectUserID 将Subject解析为用户ID
+func subjectUserID(subject string) (uint, error) {
+	userID, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("无效的用户ID: %w", err)
+	}
+	return uint(userID), nil
+}
+
+// LoadKeys 从PEM文件加载RSA密钥
+// 只配置私钥时从私钥推导公钥；只配置公钥时仅能验证令牌
+func (c *Config) LoadKeys() error {
+	if c.PrivateKeyFile != "" {
+		data, err := os.ReadFile(c.PrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("读取RSA私钥失败: %w", err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("解析RSA私钥失败: %w", err)
+		}
+		c.PrivateKey = key
+		c.PublicKey = &key.PublicKey
+	}
+
+	if c.PublicKeyFile != "" {
+		data, err := os.ReadFile(c.PublicKeyFile)
+		if err != nil {
+			return fmt.Errorf("读取RSA公钥失败: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("解析RSA公钥失败: %w", err)
+		}
+		c.PublicKey = key
+	}
+
+	for _, path := range c.PreviousPublicKeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取轮换前的RSA公钥失败: %w", err)
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("解析轮换前的RSA公钥失败: %w", err)
+		}
+		c.PreviousPublicKeys = append(c.PreviousPublicKeys, PublicKey{
+			KeyID: Thumbprint(key),
+			Key:   key,
+		})
+	}
+
+	return nil
+}
+
+// PublicKeys 返回所有可用于验证的RSA公钥，当前公钥在前
+func (c *Config) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(c.PreviousPublicKeys)+1)
+	if c.PublicKey != nil {
+		keys = append(keys, PublicKey{KeyID: c.keyID(), Key: c.PublicKey})
+	}
+	return append(keys, c.PreviousPublicKeys...)
+}
+
+// keyID 返回当前密钥的kid
+func (c *Config) keyID() string {
+	if c.KeyID != "" {
+		return c.KeyID
+	}
+	if c.PublicKey != nil {
+		return Thumbprint(c.PublicKey)
+	}
+	return ""
+}
+
+// scopeFor 返回角色拥有的权限范围，多个角色以逗号分隔，结果去重并以空格连接
+func (c *Config) scopeFor(role string) string {
+	var scopes []string
+	for _, r := range strings.Split(role, ",") {
+		for _, scope := range c.RoleScopes[strings.TrimSpace(r)] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+// algorithm 返回配置的签名算法
+func (c *Config) algorithm() string {
+	if c.Algorithm == "" {
+		return AlgorithmHS256
+	}
+	return c.Algorithm
+}
+
+// signingMethod 返回签名方法
+func (c *Config) signingMethod() (jwt.SigningMethod, error) {
+	switch c.algorithm() {
+	case AlgorithmHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", c.Algorithm)
+	}
+}
+
+// signingKey 返回签名密钥
+func (c *Config) signingKey() (interface{}, error) {
+	switch c.algorithm() {
+	case AlgorithmHS256:
+		return []byte(c.Secret), nil
+	case AlgorithmRS256:
+		if c.PrivateKey == nil {
+			return nil, fmt.Errorf("未配置RSA私钥，无法签发令牌")
+		}
+		return c.PrivateKey, nil
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", c.Algorithm)
+	}
+}
+
+// verificationKey 根据令牌头部的kid返回验证密钥
+func (c *Config) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch c.algorithm() {
+	case AlgorithmHS256:
+		return []byte(c.Secret), nil
+	case AlgorithmRS256:
+		if c.PublicKey == nil && len(c.PreviousPublicKeys) == 0 {
+			return nil, fmt.Errorf("未配置RSA公钥，无法验证令牌")
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if c.PublicKey == nil {
+				return nil, fmt.Errorf("令牌缺少kid")
+			}
+			return c.PublicKey, nil
+		}
+		for _, key := range c.PublicKeys() {
+			if key.KeyID == kid {
+				return key.Key, nil
+			}
+		}
+		return nil, fmt.Errorf("未知的密钥ID: %s", kid)
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", c.Algorithm)
+	}
+}
+
+// sign 使用配置的算法签名令牌
+func sign(claims jwt.Claims, config *Config) (string, error) {
+	method, err := config.signingMethod()
+	if err != nil {
+		return "", err
+	}
+	key, err := config.signingKey()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid := config.keyID(); kid != "" && config.algorithm() == AlgorithmRS256 {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(key)
+}
+
+// parse 使用配置的算法解析令牌，alg与配置不一致的令牌被拒绝，opts为额外的校验选项
+func parse(tokenString string, claims jwt.Claims, config *Config, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods([]string{config.algorithm()}))
+	if config.LeewayDuration > 0 {
+		opts = append(opts, jwt.WithLeeway(config.LeewayDuration))
+	}
+	return jwt.ParseWithClaims(tokenString, claims, config.verificationKey, opts...)
+}
+
+// GenerateAccessToken 生成访问令牌，权限范围由配置中角色对应的权限范围决定
+// 每个令牌带有随机的jti，登出时按jti加入黑名单
+func GenerateAccessToken(userID uint, role, familyID string, config *Config) (string, error) {
+	jti, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return "", fmt.Errorf("生成令牌ID失败: %w", err)
+	}
+
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:   userID,
+		Role:     role,
+		FamilyID: familyID,
+		Scope:    config.scopeFor(role),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.AccessTokenExp)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 			Issuer:    config.Issuer,
+			ID:        jti,
 		},
 	}
+	if config.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{config.Audience}
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.Secret))
+	return sign(claims, config)
 }
 
 // GenerateRefreshToken 生成刷新令牌
-func GenerateRefreshToken(userID uint, config *Config) (string, error) {
-	claims := jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.RefreshTokenExp)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		NotBefore: jwt.NewNumericDate(time.Now()),
-		Issuer:    config.Issuer,
-		Subject:   fmt.Sprintf("%d", userID),
+func GenerateRefreshToken(userID uint, familyID, jti string, config *Config) (string, error) {
+	claims := RefreshClaims{
+		FamilyID: familyID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(config.RefreshTokenExp)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    config.Issuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			ID:        jti,
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.Secret))
+	return sign(claims, config)
 }
 
-// ParseToken 解析并验证访问令牌
-func ParseToken(tokenString string, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("非预期的签名方法: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
+// ParseToken 解析并验证访问令牌，配置了受众时令牌的aud必须包含该受众
+func ParseToken(tokenString string, config *Config) (*Claims, error) {
+	var opts []jwt.ParserOption
+	if config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(config.Audience))
+	}
+	token, err := parse(tokenString, &Claims{}, config, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		// 邮箱验证和密码重置令牌使用相同的密钥签名，不能当作访问令牌
+		for _, aud := range restrictedAudiences {
+			if slices.Contains(claims.Audience, aud) {
+				return nil, fmt.Errorf("无效的令牌")
+			}
+		}
 		return claims, nil
 	}
 
@@ -75,32 +368,101 @@ func ParseToken(tokenString string, secret string) (*Claims, error) {
 }
 
 // ParseRefreshToken 解析并验证刷新令牌
-func ParseRefreshToken(tokenString string, secret string) (uint, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("非预期的签名方法: %v", token.Header["alg"])
+func ParseRefreshToken(tokenString string, config *Config) (*RefreshClaims, error) {
+	token, err := parse(tokenString, &RefreshClaims{}, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid {
+		if claims.ID == "" || claims.FamilyID == "" {
+			return nil, fmt.Errorf("刷新令牌缺少jti或家族ID")
 		}
-		return []byte(secret), nil
-	})
+		if _, err := claims.UserID(); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
 
+	return nil, fmt.Errorf("无效的令牌")
+}
+
+// GenerateEmailVerificationToken 生成邮箱验证令牌，exp为有效期
+func GenerateEmailVerificationToken(userID uint, email string, exp time.Duration, config *Config) (string, error) {
+	claims := EmailVerificationClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    config.Issuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			Audience:  jwt.ClaimStrings{AudienceEmailVerification},
+		},
+	}
+
+	return sign(claims, config)
+}
+
+// ParseEmailVerificationToken 解析并验证邮箱验证令牌，过期时返回的错误包含 ErrTokenExpired
+func ParseEmailVerificationToken(tokenString string, config *Config) (*EmailVerificationClaims, error) {
+	token, err := parse(tokenString, &EmailVerificationClaims{}, config, jwt.WithAudience(AudienceEmailVerification))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
-		// 从Subject中获取用户ID
-		userID, err := strconv.ParseUint(claims.Subject, 10, 32)
-		if err != nil {
-			return 0, fmt.Errorf("无效的用户ID: %w", err)
+	if claims, ok := token.Claims.(*EmailVerificationClaims); ok && token.Valid {
+		if claims.Email == "" {
+			return nil, fmt.Errorf("邮箱验证令牌缺少邮箱")
+		}
+		if _, err := claims.UserID(); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("无效的令牌")
+}
+
+// GeneratePasswordResetToken 生成密码重置令牌，fingerprint为当前密码哈希的指纹，exp为有效期
+func GeneratePasswordResetToken(userID uint, fingerprint string, exp time.Duration, config *Config) (string, error) {
+	claims := PasswordResetClaims{
+		PasswordFingerprint: fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    config.Issuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			Audience:  jwt.ClaimStrings{AudiencePasswordReset},
+		},
+	}
+
+	return sign(claims, config)
+}
+
+// ParsePasswordResetToken 解析并验证密码重置令牌，过期时返回的错误包含 ErrTokenExpired
+func ParsePasswordResetToken(tokenString string, config *Config) (*PasswordResetClaims, error) {
+	token, err := parse(tokenString, &PasswordResetClaims{}, config, jwt.WithAudience(AudiencePasswordReset))
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*PasswordResetClaims); ok && token.Valid {
+		if claims.PasswordFingerprint == "" {
+			return nil, fmt.Errorf("密码重置令牌缺少密码指纹")
 		}
-		return uint(userID), nil
+		if _, err := claims.UserID(); err != nil {
+			return nil, err
+		}
+		return claims, nil
 	}
 
-	return 0, fmt.Errorf("无效的令牌")
+	return nil, fmt.Errorf("无效的令牌")
 }
 
 // ValidateToken 验证令牌是否有效
-func ValidateToken(tokenString string, secret string) bool {
-	_, err := ParseToken(tokenString, secret)
+func ValidateToken(tokenString string, config *Config) bool {
+	_, err := ParseToken(tokenString, config)
 	return err == nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,394 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRSAKeyFiles 生成RSA密钥对并写入PEM文件，返回私钥和公钥文件路径
+func writeRSAKeyFiles(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	require.NoError(t, os.WriteFile(privatePath, privatePEM, 0o600))
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	require.NoError(t, os.WriteFile(publicPath, publicPEM, 0o644))
+
+	return privatePath, publicPath
+}
+
+func newTestConfig() *Config {
+	return &Config{
+		Secret:          "test-secret",
+		AccessTokenExp:  15 * time.Minute,
+		RefreshTokenExp: time.Hour,
+		Issuer:          "test",
+	}
+}
+
+func TestHS256(t *testing.T) {
+	config := newTestConfig()
+
+	// 默认使用HS256
+	t.Run("DefaultAlgorithm", func(t *testing.T) {
+		token, err := GenerateAccessToken(1, "admin", "family", config)
+		require.NoError(t, err)
+
+		claims, err := ParseToken(token, config)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
+		assert.Equal(t, "admin", claims.Role)
+		assert.Equal(t, "family", claims.FamilyID)
+	})
+
+	// 密钥不一致时验证失败
+	t.Run("WrongSecret", func(t *testing.T) {
+		token, err := GenerateAccessToken(1, "admin", "", config)
+		require.NoError(t, err)
+
+		other := newTestConfig()
+		other.Secret = "other-secret"
+		_, err = ParseToken(token, other)
+		assert.Error(t, err)
+	})
+}
+
+func TestRS256(t *testing.T) {
+	privatePath, publicPath := writeRSAKeyFiles(t)
+
+	signer := newTestConfig()
+	signer.Algorithm = AlgorithmRS256
+	signer.PrivateKeyFile = privatePath
+	require.NoError(t, signer.LoadKeys())
+
+	// 验证方只持有公钥
+	verifier := newTestConfig()
+	verifier.Secret = ""
+	verifier.Algorithm = AlgorithmRS256
+	verifier.PublicKeyFile = publicPath
+	require.NoError(t, verifier.LoadKeys())
+	require.Nil(t, verifier.PrivateKey)
+
+	// 使用私钥签发，公钥验证访问令牌
+	t.Run("AccessTokenVerifiedWithPublicKey", func(t *testing.T) {
+		token, err := GenerateAccessToken(42, "user", "family", signer)
+		require.NoError(t, err)
+
+		claims, err := ParseToken(token, verifier)
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), claims.UserID)
+		assert.Equal(t, "user", claims.Role)
+		assert.True(t, ValidateToken(token, verifier))
+	})
+
+	// 使用私钥签发，公钥验证刷新令牌
+	t.Run("RefreshTokenVerifiedWithPublicKey", func(t *testing.T) {
+		token, err := GenerateRefreshToken(42, "family", "jti", signer)
+		require.NoError(t, err)
+
+		claims, err := ParseRefreshToken(token, verifier)
+		require.NoError(t, err)
+		userID, err := claims.UserID()
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), userID)
+		assert.Equal(t, "jti", claims.ID)
+	})
+
+	// 仅持有公钥时无法签发令牌
+	t.Run("SignWithoutPrivateKey", func(t *testing.T) {
+		_, err := GenerateAccessToken(42, "user", "", verifier)
+		assert.Error(t, err)
+	})
+
+	// alg与配置不一致的令牌被拒绝
+	t.Run("RejectAlgorithmMismatch", func(t *testing.T) {
+		hsToken, err := GenerateAccessToken(42, "admin", "", newTestConfig())
+		require.NoError(t, err)
+		_, err = ParseToken(hsToken, verifier)
+		assert.Error(t, err)
+
+		rsToken, err := GenerateAccessToken(42, "admin", "", signer)
+		require.NoError(t, err)
+		_, err = ParseToken(rsToken, newTestConfig())
+		assert.Error(t, err)
+	})
+}
+
+func TestRS256KeyRotation(t *testing.T) {
+	oldPrivatePath, oldPublicPath := writeRSAKeyFiles(t)
+	newPrivatePath, _ := writeRSAKeyFiles(t)
+
+	oldSigner := newTestConfig()
+	oldSigner.Algorithm = AlgorithmRS256
+	oldSigner.PrivateKeyFile = oldPrivatePath
+	require.NoError(t, oldSigner.LoadKeys())
+
+	// 轮换后使用新私钥签发，旧公钥仅用于验证
+	rotated := newTestConfig()
+	rotated.Algorithm = AlgorithmRS256
+	rotated.PrivateKeyFile = newPrivatePath
+	rotated.PreviousPublicKeyFiles = []string{oldPublicPath}
+	require.NoError(t, rotated.LoadKeys())
+
+	oldToken, err := GenerateAccessToken(1, "user", "", oldSigner)
+	require.NoError(t, err)
+	newToken, err := GenerateAccessToken(2, "user", "", rotated)
+	require.NoError(t, err)
+
+	// 轮换前签发的令牌仍可通过kid找到旧公钥
+	t.Run("VerifyPreviousKey", func(t *testing.T) {
+		claims, err := ParseToken(oldToken, rotated)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
+	})
+
+	t.Run("VerifyCurrentKey", func(t *testing.T) {
+		claims, err := ParseToken(newToken, rotated)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), claims.UserID)
+	})
+
+	// 旧配置不认识新密钥的kid
+	t.Run("UnknownKeyID", func(t *testing.T) {
+		_, err := ParseToken(newToken, oldSigner)
+		assert.Error(t, err)
+	})
+
+	t.Run("PublicKeys", func(t *testing.T) {
+		keys := rotated.PublicKeys()
+		require.Len(t, keys, 2)
+		assert.Equal(t, Thumbprint(rotated.PublicKey), keys[0].KeyID)
+		assert.Equal(t, Thumbprint(oldSigner.PublicKey), keys[1].KeyID)
+	})
+}
+
+func TestLoadKeys(t *testing.T) {
+	// 只配置私钥时从私钥推导公钥
+	t.Run("DerivePublicKey", func(t *testing.T) {
+		privatePath, _ := writeRSAKeyFiles(t)
+		config := &Config{Algorithm: AlgorithmRS256, PrivateKeyFile: privatePath}
+
+		require.NoError(t, config.LoadKeys())
+		assert.NotNil(t, config.PrivateKey)
+		assert.Equal(t, &config.PrivateKey.PublicKey, config.PublicKey)
+	})
+
+	// 文件不存在
+	t.Run("MissingFile", func(t *testing.T) {
+		config := &Config{Algorithm: AlgorithmRS256, PublicKeyFile: filepath.Join(t.TempDir(), "missing.pem")}
+		assert.Error(t, config.LoadKeys())
+	})
+
+	// 非PEM内容
+	t.Run("InvalidPEM", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "invalid.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+
+		config := &Config{Algorithm: AlgorithmRS256, PrivateKeyFile: path}
+		assert.Error(t, config.LoadKeys())
+	})
+}
+
+func TestEmailVerificationToken(t *testing.T) {
+	config := newTestConfig()
+
+	// 生成的令牌可解析出用户ID和邮箱
+	t.Run("RoundTrip", func(t *testing.T) {
+		token, err := GenerateEmailVerificationToken(7, "a@example.com", time.Hour, config)
+		require.NoError(t, err)
+
+		claims, err := ParseEmailVerificationToken(token, config)
+		require.NoError(t, err)
+		userID, err := claims.UserID()
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), userID)
+		assert.Equal(t, "a@example.com", claims.Email)
+	})
+
+	// 过期的令牌返回 ErrTokenExpired
+	t.Run("Expired", func(t *testing.T) {
+		token, err := GenerateEmailVerificationToken(7, "a@example.com", -time.Minute, config)
+		require.NoError(t, err)
+
+		_, err = ParseEmailVerificationToken(token, config)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	// 验证令牌与访问令牌不能互用
+	t.Run("NotInterchangeable", func(t *testing.T) {
+		token, err := GenerateEmailVerificationToken(7, "a@example.com", time.Hour, config)
+		require.NoError(t, err)
+		_, err = ParseToken(token, config)
+		assert.Error(t, err)
+
+		access, err := GenerateAccessToken(7, "user", "family", config)
+		require.NoError(t, err)
+		_, err = ParseEmailVerificationToken(access, config)
+		assert.Error(t, err)
+	})
+}
+
+func TestPasswordResetToken(t *testing.T) {
+	config := newTestConfig()
+
+	// 生成的令牌可解析出用户ID和密码指纹
+	t.Run("RoundTrip", func(t *testing.T) {
+		token, err := GeneratePasswordResetToken(7, "fp", time.Hour, config)
+		require.NoError(t, err)
+
+		claims, err := ParsePasswordResetToken(token, config)
+		require.NoError(t, err)
+		userID, err := claims.UserID()
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), userID)
+		assert.Equal(t, "fp", claims.PasswordFingerprint)
+	})
+
+	// 过期的令牌返回 ErrTokenExpired
+	t.Run("Expired", func(t *testing.T) {
+		token, err := GeneratePasswordResetToken(7, "fp", -time.Minute, config)
+		require.NoError(t, err)
+
+		_, err = ParsePasswordResetToken(token, config)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+
+	// 重置令牌不能作为访问令牌或邮箱验证令牌使用
+	t.Run("NotInterchangeable", func(t *testing.T) {
+		token, err := GeneratePasswordResetToken(7, "fp", time.Hour, config)
+		require.NoError(t, err)
+		_, err = ParseToken(token, config)
+		assert.Error(t, err)
+		_, err = ParseEmailVerificationToken(token, config)
+		assert.Error(t, err)
+
+		verification, err := GenerateEmailVerificationToken(7, "a@example.com", time.Hour, config)
+		require.NoError(t, err)
+		_, err = ParsePasswordResetToken(verification, config)
+		assert.Error(t, err)
+	})
+}
+
+func TestAudienceAndScope(t *testing.T) {
+	config := newTestConfig()
+	config.Audience = "api"
+	config.RoleScopes = map[string][]string{
+		"user":  {"users:read"},
+		"admin": {"users:read", "users:write"},
+	}
+
+	// 按角色写入权限范围，多个角色的权限范围合并去重
+	t.Run("Scopes", func(t *testing.T) {
+		token, err := GenerateAccessToken(1, "user,admin", "family", config)
+		require.NoError(t, err)
+
+		claims, err := ParseToken(token, config)
+		require.NoError(t, err)
+		assert.Equal(t, "users:read users:write", claims.Scope)
+		assert.True(t, claims.HasScope("users:write"))
+		assert.False(t, claims.HasScope("users:delete"))
+		assert.Equal(t, []string{"api"}, []string(claims.Audience))
+	})
+
+	// 未配置权限范围的角色不写入scope
+	t.Run("UnknownRole", func(t *testing.T) {
+		token, err := GenerateAccessToken(1, "guest", "family", config)
+		require.NoError(t, err)
+
+		claims, err := ParseToken(token, config)
+		require.NoError(t, err)
+		assert.Empty(t, claims.Scopes())
+	})
+
+	// 受众不匹配或缺少受众的令牌被拒绝
+	t.Run("AudienceMismatch", func(t *testing.T) {
+		other := newTestConfig()
+		other.Audience = "admin-api"
+		token, err := GenerateAccessToken(1, "user", "family", other)
+		require.NoError(t, err)
+		_, err = ParseToken(token, config)
+		assert.Error(t, err)
+
+		token, err = GenerateAccessToken(1, "user", "family", newTestConfig())
+		require.NoError(t, err)
+		_, err = ParseToken(token, config)
+		assert.Error(t, err)
+	})
+}
+
+func TestLeeway(t *testing.T) {
+	// notBefore 签发nbf在offset之后的访问令牌，模拟签发方时钟比验证方快
+	notBefore := func(t *testing.T, offset time.Duration) string {
+		t.Helper()
+		now := time.Now()
+		token, err := sign(Claims{
+			UserID: 1,
+			Role:   "user",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(now.Add(offset)),
+				NotBefore: jwt.NewNumericDate(now.Add(offset)),
+			},
+		}, newTestConfig())
+		require.NoError(t, err)
+		return token
+	}
+
+	config := newTestConfig()
+	config.LeewayDuration = 30 * time.Second
+
+	// nbf在容忍范围内的令牌通过校验
+	t.Run("WithinLeeway", func(t *testing.T) {
+		claims, err := ParseToken(notBefore(t, 10*time.Second), config)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), claims.UserID)
+	})
+
+	// 超出容忍范围的令牌被拒绝
+	t.Run("OutsideLeeway", func(t *testing.T) {
+		_, err := ParseToken(notBefore(t, time.Minute), config)
+		assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	})
+
+	// 未配置容忍时间时不容忍任何偏差
+	t.Run("NoLeeway", func(t *testing.T) {
+		_, err := ParseToken(notBefore(t, 10*time.Second), newTestConfig())
+		assert.ErrorIs(t, err, jwt.ErrTokenNotValidYet)
+	})
+
+	// 刚过期的令牌在容忍范围内仍然有效
+	t.Run("ExpiredWithinLeeway", func(t *testing.T) {
+		expired := newTestConfig()
+		expired.AccessTokenExp = -10 * time.Second
+		token, err := GenerateAccessToken(1, "user", "", expired)
+		require.NoError(t, err)
+
+		_, err = ParseToken(token, config)
+		assert.NoError(t, err)
+		_, err = ParseToken(token, newTestConfig())
+		assert.ErrorIs(t, err, ErrTokenExpired)
+	})
+}
@@ -0,0 +1,205 @@
+// Package lock 基于Redis的分布式锁，用于多实例部署时只允许一个实例执行的任务（缓存预热、定时任务等）
+package lock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/vadxq/go-rest-starter/pkg/utils"
+)
+
+// ErrNotAcquired 锁已被其他持有者占用
+var ErrNotAcquired = errors.New("lock: not acquired")
+
+// ErrInvalidTTL 锁的过期时间必须大于0
+var ErrInvalidTTL = errors.New("lock: ttl must be positive")
+
+// ErrNotHeld 锁已过期或被其他持有者获取，释放或续期失败
+var ErrNotHeld = errors.New("lock: not held")
+
+// DefaultKeyPrefix 锁在Redis中的键前缀
+const DefaultKeyPrefix = "lock:"
+
+// releaseScript 令牌一致时删除锁，避免删除其他持有者在本锁过期后获取的锁
+//
+// KEYS[1] 锁键
+// ARGV[1] 令牌
+//
+// 返回删除的键数量
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// renewScript 令牌一致时重置锁的过期时间
+//
+// KEYS[1] 锁键
+// ARGV[1] 令牌
+// ARGV[2] 过期时间（毫秒）
+//
+// 续期成功返回1，锁已不属于该令牌时返回0
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Locker 分布式锁管理器
+type Locker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewLocker 创建分布式锁管理器，锁键为 DefaultKeyPrefix 加上 Acquire 的key
+func NewLocker(client *redis.Client) *Locker {
+	return &Locker{client: client, prefix: DefaultKeyPrefix}
+}
+
+// Acquire 尝试获取锁，不等待；锁已被占用时返回 ErrNotAcquired
+// 获取成功后每隔 ttl/3 自动续期，持有者崩溃时锁在ttl后过期；使用完毕需调用 Release
+func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+
+	token, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	redisKey := l.prefix + key
+	ok, err := l.client.SetNX(ctx, redisKey, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotAcquired
+	}
+
+	lock := &Lock{
+		client: l.client,
+		key:    redisKey,
+		token:  token,
+		ttl:    ttl,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	go lock.heartbeat()
+	return lock, nil
+}
+
+// Lock 已获取的分布式锁
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+	ttl    time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{} // 通知停止续期
+	done     chan struct{} // 续期协程已退出
+	lost     chan struct{} // 锁已丢失
+}
+
+// Key 返回锁在Redis中的键
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Lost 返回锁丢失时关闭的通道，持有者应在收到通知后停止需要互斥的工作
+// 续期时发现锁已被其他持有者获取，或超过ttl未能续期成功时视为丢失
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Release 停止续期并释放锁，锁已丢失时返回 ErrNotHeld；可重复调用
+func (l *Lock) Release(ctx context.Context) error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+
+	deleted, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// ReleaseAfter 停止续期，锁在d后过期而不是立即释放，期间其他实例仍不能获取
+// 用于防止时钟偏差的实例重复执行刚完成的工作；d<=0时立即释放，锁已丢失时返回 ErrNotHeld
+func (l *Lock) ReleaseAfter(ctx context.Context, d time.Duration) error {
+	if d.Milliseconds() <= 0 {
+		return l.Release(ctx)
+	}
+
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, d.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if renewed == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// heartbeat 定期续期，直到释放或锁丢失
+func (l *Lock) heartbeat() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(max(l.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+
+	renewed := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+
+		err := l.renew()
+		switch {
+		case err == nil:
+			renewed = time.Now()
+			continue
+		case errors.Is(err, ErrNotHeld):
+		case time.Since(renewed) < l.ttl:
+			// 网络错误时在锁过期前继续重试
+			slog.Warn("分布式锁续期失败，稍后重试", "key", l.key, "error", err)
+			continue
+		}
+
+		slog.Warn("分布式锁已丢失", "key", l.key, "error", err)
+		close(l.lost)
+		return
+	}
+}
+
+// renew 重置锁的过期时间
+func (l *Lock) renew() error {
+	ctx, cancel := context.WithTimeout(context.Background(), max(l.ttl/3, time.Millisecond))
+	defer cancel()
+
+	renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if renewed == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
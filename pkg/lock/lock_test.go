@@ -0,0 +1,155 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLocker(t *testing.T) (*Locker, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewLocker(client), mr
+}
+
+func TestLocker_AcquireRelease(t *testing.T) {
+	ctx := context.Background()
+	locker, mr := newTestLocker(t)
+
+	lock, err := locker.Acquire(ctx, "warmup", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "lock:warmup", lock.Key())
+	assert.True(t, mr.Exists("lock:warmup"))
+	assert.Equal(t, time.Minute, mr.TTL("lock:warmup"))
+
+	require.NoError(t, lock.Release(ctx))
+	assert.False(t, mr.Exists("lock:warmup"))
+
+	// 释放后可以重新获取
+	lock, err = locker.Acquire(ctx, "warmup", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release(ctx))
+
+	// 重复释放返回 ErrNotHeld
+	assert.ErrorIs(t, lock.Release(ctx), ErrNotHeld)
+
+	_, err = locker.Acquire(ctx, "warmup", 0)
+	assert.ErrorIs(t, err, ErrInvalidTTL)
+}
+
+func TestLocker_Contention(t *testing.T) {
+	ctx := context.Background()
+	locker, _ := newTestLocker(t)
+
+	lock, err := locker.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+	defer lock.Release(ctx)
+
+	// 锁被占用时第二次获取失败
+	_, err = locker.Acquire(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	// 不同的键互不影响
+	other, err := locker.Acquire(ctx, "other-job", time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, other.Release(ctx))
+}
+
+func TestLock_SafeRelease(t *testing.T) {
+	ctx := context.Background()
+	locker, mr := newTestLocker(t)
+
+	lock, err := locker.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	// 锁过期后被其他持有者获取
+	mr.FastForward(time.Minute)
+	other, err := locker.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	// 释放过期的锁不删除其他持有者的锁
+	assert.ErrorIs(t, lock.Release(ctx), ErrNotHeld)
+	assert.True(t, mr.Exists("lock:job"))
+
+	require.NoError(t, other.Release(ctx))
+	assert.False(t, mr.Exists("lock:job"))
+}
+
+func TestLock_ReleaseAfter(t *testing.T) {
+	ctx := context.Background()
+	locker, mr := newTestLocker(t)
+
+	lock, err := locker.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	// 停止续期，锁在指定时间后过期，期间不能被获取
+	require.NoError(t, lock.ReleaseAfter(ctx, 10*time.Second))
+	assert.Equal(t, 10*time.Second, mr.TTL("lock:job"))
+	_, err = locker.Acquire(ctx, "job", time.Minute)
+	assert.ErrorIs(t, err, ErrNotAcquired)
+
+	mr.FastForward(10 * time.Second)
+	other, err := locker.Acquire(ctx, "job", time.Minute)
+	require.NoError(t, err)
+
+	// 锁已被其他持有者获取时不修改其过期时间
+	assert.ErrorIs(t, lock.ReleaseAfter(ctx, time.Second), ErrNotHeld)
+	assert.Equal(t, time.Minute, mr.TTL("lock:job"))
+
+	// 不大于0时立即释放
+	require.NoError(t, other.ReleaseAfter(ctx, 0))
+	assert.False(t, mr.Exists("lock:job"))
+}
+
+func TestLock_Heartbeat(t *testing.T) {
+	ctx := context.Background()
+
+	// 持有期间自动续期
+	t.Run("Renew", func(t *testing.T) {
+		locker, mr := newTestLocker(t)
+
+		lock, err := locker.Acquire(ctx, "job", 150*time.Millisecond)
+		require.NoError(t, err)
+		defer lock.Release(ctx)
+
+		mr.FastForward(100 * time.Millisecond)
+		assert.Eventually(t, func() bool {
+			return mr.TTL("lock:job") == 150*time.Millisecond
+		}, time.Second, 5*time.Millisecond)
+
+		select {
+		case <-lock.Lost():
+			t.Fatal("锁不应丢失")
+		default:
+		}
+	})
+
+	// 续期时发现锁已被其他持有者获取，通知持有者
+	t.Run("Lost", func(t *testing.T) {
+		locker, mr := newTestLocker(t)
+
+		lock, err := locker.Acquire(ctx, "job", 150*time.Millisecond)
+		require.NoError(t, err)
+
+		require.NoError(t, mr.Set("lock:job", "someone-else"))
+
+		select {
+		case <-lock.Lost():
+		case <-time.After(time.Second):
+			t.Fatal("未通知锁丢失")
+		}
+
+		assert.ErrorIs(t, lock.Release(ctx), ErrNotHeld)
+		got, err := mr.Get("lock:job")
+		require.NoError(t, err)
+		assert.Equal(t, "someone-else", got)
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"os"
 	"path/filepath"
 	"runtime"
 	"time"
@@ -30,15 +29,26 @@ type StructuredLogger struct {
 	ctx    context.Context
 }
 
+// 日志输出格式
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string `yaml:"level" json:"level"`             // 日志级别: debug, info, warn, error
+	Format     string `yaml:"format" json:"format"`           // 输出格式: json, text，空则为json
 	File       string `yaml:"file" json:"file"`               // 日志文件路径，空则输出到控制台
 	Console    bool   `yaml:"console" json:"console"`         // 是否输出到控制台
-	MaxSize    int    `yaml:"max_size" json:"max_size"`       // 文件最大大小(MB)
-	MaxBackups int    `yaml:"max_backups" json:"max_backups"` // 保留的备份文件数
-	MaxAge     int    `yaml:"max_age" json:"max_age"`         // 保留的天数
+	MaxSize    int    `yaml:"max_size" json:"max_size"`       // 文件最大大小(MB)，超过后滚动，<=0时为100
+	MaxBackups int    `yaml:"max_backups" json:"max_backups"` // 保留的备份文件数，0为不限制
+	MaxAge     int    `yaml:"max_age" json:"max_age"`         // 保留的天数，0为不限制
 	Compress   bool   `yaml:"compress" json:"compress"`       // 是否压缩
+
+	RedactKeys []string `yaml:"redact_keys" json:"redact_keys"` // 额外的敏感字段，内置的 DefaultSensitiveKeys 始终脱敏
+
+	Sampling SamplingConfig `yaml:"sampling" json:"sampling"` // 高频日志采样，默认不采样
 }
 
 // ContextKey 上下文键类型
@@ -57,39 +67,21 @@ const (
 func NewLogger(config *LogConfig) (*StructuredLogger, error) {
 	level := parseLevel(config.Level)
 
-	var writers []io.Writer
-
-	// 控制台输出
-	if config.Console || config.File == "" {
-		writers = append(writers, os.Stdout)
-	}
-
-	// 文件输出
-	if config.File != "" {
-		file, err := createLogFile(config.File)
-		if err != nil {
-			return nil, err
-		}
-		writers = append(writers, file)
-	}
-
-	// 如果没有配置任何输出，默认输出到控制台
-	if len(writers) == 0 {
-		writers = append(writers, os.Stdout)
+	writer, err := NewWriter(config)
+	if err != nil {
+		return nil, err
 	}
 
-	var writer io.Writer
-	if len(writers) == 1 {
-		writer = writers[0]
-	} else {
-		writer = io.MultiWriter(writers...)
-	}
+	redactor := NewRedactor(config.RedactKeys...)
 
 	// 创建handler
-	handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{
+	handler, err := NewHandler(writer, config.Format, &slog.HandlerOptions{
 		Level:     level,
 		AddSource: true,
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// 敏感字段脱敏
+			a = redactor.ReplaceAttr(groups, a)
+
 			// 格式化时间
 			if a.Key == slog.TimeKey {
 				return slog.String("timestamp", a.Value.Time().Format(time.RFC3339))
@@ -108,8 +100,11 @@ func NewLogger(config *LogConfig) (*StructuredLogger, error) {
 			return a
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	logger := slog.New(handler)
+	logger := slog.New(NewSamplingHandler(handler, config.Sampling))
 
 	return &StructuredLogger{
 		logger: logger,
@@ -117,6 +112,29 @@ func NewLogger(config *LogConfig) (*StructuredLogger, error) {
 	}, nil
 }
 
+// NewHandler 按格式创建 slog.Handler，format为空时使用JSON格式
+func NewHandler(w io.Writer, format string, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case "", FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("不支持的日志格式: %s", format)
+	}
+}
+
+// New 使用指定的 slog.Handler 创建日志记录器，handler为空时使用 slog 的默认处理器
+func New(handler slog.Handler) *StructuredLogger {
+	if handler == nil {
+		handler = slog.Default().Handler()
+	}
+	return &StructuredLogger{
+		logger: slog.New(handler),
+		ctx:    context.Background(),
+	}
+}
+
 // Default 创建默认日志记录器
 func Default() *StructuredLogger {
 	logger, _ := NewLogger(&LogConfig{
@@ -126,40 +144,29 @@ func Default() *StructuredLogger {
 	return logger
 }
 
-// parseLevel 解析日志级别
-func parseLevel(level string) slog.Level {
+// Levels 支持的日志级别名称
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel 解析日志级别名称（debug、info、warn、error），不支持的名称返回错误
+func ParseLevel(level string) (slog.Level, error) {
 	switch level {
 	case "debug":
-		return slog.LevelDebug
+		return slog.LevelDebug, nil
 	case "info":
-		return slog.LevelInfo
+		return slog.LevelInfo, nil
 	case "warn":
-		return slog.LevelWarn
+		return slog.LevelWarn, nil
 	case "error":
-		return slog.LevelError
+		return slog.LevelError, nil
 	default:
-		return slog.LevelInfo
+		return slog.LevelInfo, fmt.Errorf("不支持的日志级别: %s", level)
 	}
 }
 
-// createLogFile 创建日志文件
-func createLogFile(filename string) (*os.File, error) {
-	// 添加日期到文件名
-	dir := filepath.Dir(filename)
-	base := filepath.Base(filename)
-	ext := filepath.Ext(base)
-	nameWithoutExt := base[:len(base)-len(ext)]
-	
-	// 创建带日期的文件名
-	dateStr := time.Now().Format("2006-01-02")
-	newFilename := filepath.Join(dir, nameWithoutExt+"-"+dateStr+ext)
-	
-	// 确保目录存在
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, err
-	}
-
-	return os.OpenFile(newFilename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+// parseLevel 解析日志级别，不支持的名称使用Info级别
+func parseLevel(level string) slog.Level {
+	l, _ := ParseLevel(level)
+	return l
 }
 
 // Debug 输出调试级别日志
@@ -337,32 +344,3 @@ func LoggerMiddleware(logger Logger) func(next http.Handler) http.Handler {
 		})
 	}
 }
-
-// RecoveryMiddleware 恢复中间件
-func RecoveryMiddleware(logger Logger) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					// 获取堆栈信息
-					buf := make([]byte, 4096)
-					n := runtime.Stack(buf, false)
-					stackTrace := string(buf[:n])
-
-					// 记录panic
-					logger.WithContext(r.Context()).Error("panic recovered",
-						"error", err,
-						"stack_trace", stackTrace,
-						"method", r.Method,
-						"path", r.URL.Path,
-					)
-
-					// 返回500错误
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-				}
-			}()
-
-			next.ServeHTTP(w, r)
-		})
-	}
-}
\ No newline at end of file
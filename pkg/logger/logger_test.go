@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler(t *testing.T) {
+	// 默认和json格式输出可解析的JSON
+	t.Run("JSON", func(t *testing.T) {
+		for _, format := range []string{"", FormatJSON} {
+			var buf bytes.Buffer
+			h, err := NewHandler(&buf, format, nil)
+			require.NoError(t, err)
+
+			slog.New(h).Info("用户登录", "user_id", 1)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, "用户登录", entry["msg"])
+			assert.Equal(t, float64(1), entry["user_id"])
+		}
+	})
+
+	// text格式输出 key=value
+	t.Run("Text", func(t *testing.T) {
+		var buf bytes.Buffer
+		h, err := NewHandler(&buf, FormatText, nil)
+		require.NoError(t, err)
+
+		slog.New(h).Info("用户登录", "user_id", 1)
+
+		assert.Contains(t, buf.String(), "msg=用户登录")
+		assert.Contains(t, buf.String(), "user_id=1")
+		assert.False(t, json.Valid(buf.Bytes()))
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := NewHandler(&bytes.Buffer{}, "xml", nil)
+		assert.Error(t, err)
+
+		_, err = NewLogger(&LogConfig{Level: "info", Format: "xml", Console: true})
+		assert.Error(t, err)
+	})
+}
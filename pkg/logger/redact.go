@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue 敏感字段替换后的值
+const RedactedValue = "***"
+
+// DefaultSensitiveKeys 内置的敏感字段，属性名或查询参数名包含其中任一项（不区分大小写）即脱敏
+var DefaultSensitiveKeys = []string{"password", "authorization", "token", "secret"}
+
+// queryAttrKeys 值为查询字符串（true）或可能带查询字符串的URL（false）的属性名
+var queryAttrKeys = map[string]bool{
+	"query":       true,
+	"path":        false,
+	"url":         false,
+	"uri":         false,
+	"request_uri": false,
+}
+
+// Redactor 日志脱敏器，替换敏感属性的值并清除URL中的敏感查询参数
+type Redactor struct {
+	keys []string
+}
+
+// NewRedactor 创建脱敏器，keys为额外的敏感字段，内置的 DefaultSensitiveKeys 始终生效
+func NewRedactor(keys ...string) *Redactor {
+	r := &Redactor{}
+	for _, key := range append(DefaultSensitiveKeys, keys...) {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			r.keys = append(r.keys, key)
+		}
+	}
+	return r
+}
+
+// IsSensitive 字段名是否需要脱敏
+func (r *Redactor) IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReplaceAttr 可用作 slog.HandlerOptions.ReplaceAttr，分组内的属性同样会经过该函数
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.IsSensitive(a.Key) {
+		return slog.String(a.Key, RedactedValue)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if rawQuery, ok := queryAttrKeys[strings.ToLower(a.Key)]; ok {
+			if rawQuery {
+				return slog.String(a.Key, r.RedactQuery(a.Value.String()))
+			}
+			return slog.String(a.Key, r.RedactURL(a.Value.String()))
+		}
+	case slog.KindAny:
+		if v, ok := r.redactValue(a.Value.Any()); ok {
+			return slog.Any(a.Key, v)
+		}
+	}
+	return a
+}
+
+// redactValue 对map和请求头类型的值脱敏，返回脱敏后的副本；其他类型返回false
+func (r *Redactor) redactValue(v any) (any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			if r.IsSensitive(k) {
+				out[k] = RedactedValue
+			} else if redacted, ok := r.redactValue(val); ok {
+				out[k] = redacted
+			} else {
+				out[k] = val
+			}
+		}
+		return out, true
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			if redacted, ok := r.redactValue(val); ok {
+				out[i] = redacted
+			} else {
+				out[i] = val
+			}
+		}
+		return out, true
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for k, val := range v {
+			if r.IsSensitive(k) {
+				val = RedactedValue
+			}
+			out[k] = val
+		}
+		return out, true
+	case http.Header:
+		return http.Header(r.redactValues(v)), true
+	case url.Values:
+		return url.Values(r.redactValues(v)), true
+	}
+	return nil, false
+}
+
+// RedactJSON 替换JSON文档中敏感字段的值，嵌套对象和数组中的字段同样脱敏
+// data不是有效的JSON时返回false
+func (r *Redactor) RedactJSON(data []byte) ([]byte, bool) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, false
+	}
+	if redacted, ok := r.redactValue(v); ok {
+		v = redacted
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// redactValues 对多值map脱敏
+func (r *Redactor) redactValues(v map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(v))
+	for k, vals := range v {
+		if r.IsSensitive(k) {
+			vals = []string{RedactedValue}
+		}
+		out[k] = vals
+	}
+	return out
+}
+
+// RedactQuery 替换查询字符串中敏感参数的值，保持其余参数的原始顺序和编码
+func (r *Redactor) RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		name, _, hasValue := strings.Cut(part, "=")
+		if !hasValue {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if r.IsSensitive(name) {
+			parts[i] = part[:strings.IndexByte(part, '=')+1] + RedactedValue
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// RedactURL 替换URL或请求URI中敏感查询参数的值
+func (r *Redactor) RedactURL(value string) string {
+	base, rawQuery, ok := strings.Cut(value, "?")
+	if !ok {
+		return value
+	}
+	return base + "?" + r.RedactQuery(rawQuery)
+}
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedactedLogger 创建输出JSON到缓冲区的脱敏日志器
+func newRedactedLogger(keys ...string) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: NewRedactor(keys...).ReplaceAttr})
+	return slog.New(h), &buf
+}
+
+// decodeEntry 解析单条JSON日志
+func decodeEntry(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestRedactor(t *testing.T) {
+	// 敏感属性替换为***，不区分大小写并匹配包含敏感字段的属性名
+	t.Run("Attributes", func(t *testing.T) {
+		log, buf := newRedactedLogger()
+		log.Info("登录", "password", "secret123", "Authorization", "Bearer abc", "refresh_token", "rt", "email", "a@example.com")
+
+		entry := decodeEntry(t, buf)
+		assert.Equal(t, RedactedValue, entry["password"])
+		assert.Equal(t, RedactedValue, entry["Authorization"])
+		assert.Equal(t, RedactedValue, entry["refresh_token"])
+		assert.Equal(t, "a@example.com", entry["email"])
+		assert.NotContains(t, buf.String(), "secret123")
+	})
+
+	// 分组、With 和 map 值中的敏感字段同样脱敏
+	t.Run("Nested", func(t *testing.T) {
+		log, buf := newRedactedLogger()
+		log.With("client_secret", "cs").Info("请求",
+			slog.Group("body", "name", "张三", "password", "p1"),
+			"params", map[string]any{"new_password": "p2", "page": 1},
+			"headers", http.Header{"Authorization": {"Bearer abc"}, "Accept": {"*/*"}},
+		)
+
+		entry := decodeEntry(t, buf)
+		assert.Equal(t, RedactedValue, entry["client_secret"])
+		assert.Equal(t, map[string]any{"name": "张三", "password": RedactedValue}, entry["body"])
+		assert.Equal(t, map[string]any{"new_password": RedactedValue, "page": float64(1)}, entry["params"])
+		assert.Equal(t, map[string]any{"Authorization": []any{RedactedValue}, "Accept": []any{"*/*"}}, entry["headers"])
+	})
+
+	// 查询字符串和URL中的敏感参数脱敏
+	t.Run("Query", func(t *testing.T) {
+		log, buf := newRedactedLogger()
+		log.Info("请求完成", "query", "page=1&access_token=abc&redirect=/home", "path", "/api/v1/reset?token=xyz&id=2")
+
+		entry := decodeEntry(t, buf)
+		assert.Equal(t, "page=1&access_token=***&redirect=/home", entry["query"])
+		assert.Equal(t, "/api/v1/reset?token=***&id=2", entry["path"])
+	})
+
+	// 配置的额外字段与内置字段同时生效
+	t.Run("ConfiguredKeys", func(t *testing.T) {
+		r := NewRedactor("OTP", " ")
+		assert.True(t, r.IsSensitive("otp_code"))
+		assert.True(t, r.IsSensitive("password"))
+		assert.False(t, r.IsSensitive("name"))
+		assert.Equal(t, "otp=***&a=1", r.RedactQuery("otp=123&a=1"))
+	})
+
+	// JSON文档中嵌套对象和数组里的敏感字段
+	t.Run("JSON", func(t *testing.T) {
+		r := NewRedactor()
+		out, ok := r.RedactJSON([]byte(`{"email":"a@example.com","password":"p1","users":[{"name":"张三","password":"p2"}]}`))
+		require.True(t, ok)
+		assert.JSONEq(t, `{"email":"a@example.com","password":"***","users":[{"name":"张三","password":"***"}]}`, string(out))
+
+		_, ok = r.RedactJSON([]byte(`{"password":"p1`))
+		assert.False(t, ok)
+	})
+
+	// NewLogger 默认脱敏
+	t.Run("NewLogger", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		l, err := NewLogger(&LogConfig{Level: "info", File: path})
+		require.NoError(t, err)
+		w, _ := NewFileWriter(&LogConfig{File: path})
+		t.Cleanup(func() { w.Close() })
+
+		l.Info("创建用户", "password", "secret123")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, RedactedValue, decodeEntry(t, bytes.NewBuffer(content))["password"])
+	})
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// 同一路径的日志文件只由一个 lumberjack.Logger 写入，多个实例各自滚动会互相覆盖备份
+var (
+	rotatingMu    sync.Mutex
+	rotatingFiles = make(map[string]*lumberjack.Logger)
+)
+
+// NewFileWriter 创建按 MaxSize/MaxBackups/MaxAge/Compress 滚动的日志文件写入器
+// 文件超过 MaxSize（MB，<=0时为100）后重命名为带时间戳的备份并重新创建，相同路径返回同一个写入器
+func NewFileWriter(config *LogConfig) (io.WriteCloser, error) {
+	path, err := filepath.Abs(config.File)
+	if err != nil {
+		return nil, fmt.Errorf("解析日志文件路径失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+
+	rotatingMu.Lock()
+	defer rotatingMu.Unlock()
+
+	if w, ok := rotatingFiles[path]; ok {
+		return w, nil
+	}
+
+	w := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    config.MaxSize,
+		MaxBackups: config.MaxBackups,
+		MaxAge:     config.MaxAge,
+		Compress:   config.Compress,
+		LocalTime:  true,
+	}
+	rotatingFiles[path] = w
+	return w, nil
+}
+
+// NewWriter 按配置创建日志输出：控制台、滚动文件或两者同时输出
+func NewWriter(config *LogConfig) (io.Writer, error) {
+	var writers []io.Writer
+
+	// 控制台输出
+	if config.Console || config.File == "" {
+		writers = append(writers, os.Stdout)
+	}
+
+	// 文件输出
+	if config.File != "" {
+		file, err := NewFileWriter(config)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, file)
+	}
+
+	if len(writers) == 1 {
+		return writers[0], nil
+	}
+	return io.MultiWriter(writers...), nil
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileWriter(t *testing.T) {
+	// 写入超过MaxSize后滚动出带时间戳的备份文件
+	t.Run("RollsOverPastMaxSize", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs", "app.log")
+		w, err := NewFileWriter(&LogConfig{File: path, MaxSize: 1, MaxBackups: 2})
+		require.NoError(t, err)
+		t.Cleanup(func() { w.Close() })
+
+		line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+		for i := 0; i < 1100; i++ {
+			_, err := w.Write(line)
+			require.NoError(t, err)
+		}
+
+		backups, err := filepath.Glob(filepath.Join(filepath.Dir(path), "app-*.log"))
+		require.NoError(t, err)
+		assert.Len(t, backups, 1)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Less(t, info.Size(), int64(1<<20))
+	})
+
+	// 相同路径共享同一个写入器
+	t.Run("SharedPerPath", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		w1, err := NewFileWriter(&LogConfig{File: path})
+		require.NoError(t, err)
+		t.Cleanup(func() { w1.Close() })
+
+		w2, err := NewFileWriter(&LogConfig{File: path})
+		require.NoError(t, err)
+		assert.Same(t, w1, w2)
+	})
+
+	// NewLogger 写入配置的日志文件
+	t.Run("NewLogger", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		l, err := NewLogger(&LogConfig{Level: "info", File: path})
+		require.NoError(t, err)
+		w, _ := NewFileWriter(&LogConfig{File: path})
+		t.Cleanup(func() { w.Close() })
+
+		l.Info("服务启动")
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "服务启动")
+	})
+}
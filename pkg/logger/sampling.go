@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingConfig 日志采样配置，只对 Info 及以下级别生效，Warn 和 Error 始终记录
+// 每个统计周期内相同级别和消息的日志先记录 Initial 条，之后每 Thereafter 条记录 1 条
+type SamplingConfig struct {
+	Initial    int           `yaml:"initial" json:"initial"`       // 每个周期内完整记录的条数，<=0时为1
+	Thereafter int           `yaml:"thereafter" json:"thereafter"` // 超过Initial后每N条记录1条，<=0时不采样
+	Tick       time.Duration `yaml:"tick" json:"tick"`             // 统计周期，<=0时为1秒
+}
+
+// Enabled 是否启用采样
+func (c SamplingConfig) Enabled() bool {
+	return c.Thereafter > 0
+}
+
+// samplingHandler 按消息采样的 slog.Handler
+type samplingHandler struct {
+	next       slog.Handler
+	initial    uint64
+	thereafter uint64
+	tick       time.Duration
+	counters   *sampleCounters // WithAttrs/WithGroup 派生的处理器共享计数
+}
+
+// sampleCounters 各消息在当前周期内的计数，每个周期开始时清空，只保留当前周期出现过的消息
+type sampleCounters struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[sampleKey]uint64
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+// NewSamplingHandler 为处理器增加采样，未启用采样时直接返回原处理器
+func NewSamplingHandler(next slog.Handler, config SamplingConfig) slog.Handler {
+	if !config.Enabled() {
+		return next
+	}
+
+	h := &samplingHandler{
+		next:       next,
+		initial:    uint64(max(config.Initial, 1)),
+		thereafter: uint64(config.Thereafter),
+		tick:       config.Tick,
+		counters:   &sampleCounters{counts: make(map[sampleKey]uint64)},
+	}
+	if h.tick <= 0 {
+		h.tick = time.Second
+	}
+	return h
+}
+
+// Enabled 由下一级处理器决定是否记录该级别
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle 按采样规则决定是否交给下一级处理器
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn || h.sample(r.Level, r.Message, r.Time) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs 派生处理器，与原处理器共享采样计数
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	return &clone
+}
+
+// WithGroup 派生处理器，与原处理器共享采样计数
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	return &clone
+}
+
+// sample 记录一次出现并返回是否需要输出
+func (h *samplingHandler) sample(level slog.Level, msg string, now time.Time) bool {
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	h.counters.mu.Lock()
+	defer h.counters.mu.Unlock()
+
+	// 新周期开始时丢弃上一周期的计数，消息内容不固定时计数也不会无限增长
+	if now.Sub(h.counters.windowStart) >= h.tick {
+		h.counters.windowStart = now
+		clear(h.counters.counts)
+	}
+
+	key := sampleKey{level: level, msg: msg}
+	n := h.counters.counts[key] + 1
+	h.counters.counts[key] = n
+
+	if n <= h.initial {
+		return true
+	}
+	return (n-h.initial)%h.thereafter == 0
+}
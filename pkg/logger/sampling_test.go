@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSampledLogger 创建输出到缓冲区的采样日志器
+func newSampledLogger(cfg SamplingConfig) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(NewSamplingHandler(h, cfg)), &buf
+}
+
+// countLines 统计包含指定内容的日志行数
+func countLines(buf *bytes.Buffer, substr string) int {
+	n := 0
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSamplingHandler(t *testing.T) {
+	cfg := SamplingConfig{Initial: 2, Thereafter: 3, Tick: time.Minute}
+
+	// 先记录Initial条，之后每Thereafter条记录1条
+	t.Run("DropsRepeatedMessages", func(t *testing.T) {
+		log, buf := newSampledLogger(cfg)
+		for i := 0; i < 10; i++ {
+			log.Info("缓存命中", "i", i)
+		}
+		// 第1、2、5、8条
+		assert.Equal(t, 4, countLines(buf, "缓存命中"))
+	})
+
+	// 不同消息和不同级别分别计数，WithAttrs派生的日志器共享计数
+	t.Run("PerMessage", func(t *testing.T) {
+		log, buf := newSampledLogger(cfg)
+		child := log.With("component", "cache")
+		for i := 0; i < 3; i++ {
+			log.Info("消息A")
+			child.Info("消息A")
+			log.Debug("消息B")
+		}
+		assert.Equal(t, 3, countLines(buf, "消息A"))
+		assert.Equal(t, 2, countLines(buf, "消息B"))
+	})
+
+	// Warn及以上级别不采样
+	t.Run("WarnNeverDropped", func(t *testing.T) {
+		log, buf := newSampledLogger(cfg)
+		for i := 0; i < 10; i++ {
+			log.Warn("慢查询")
+			log.Error("查询失败")
+		}
+		assert.Equal(t, 10, countLines(buf, "慢查询"))
+		assert.Equal(t, 10, countLines(buf, "查询失败"))
+	})
+
+	// 新周期重新计数
+	t.Run("ResetsAfterTick", func(t *testing.T) {
+		log, buf := newSampledLogger(SamplingConfig{Initial: 1, Thereafter: 100, Tick: 20 * time.Millisecond})
+		log.Info("心跳")
+		log.Info("心跳")
+		time.Sleep(30 * time.Millisecond)
+		log.Info("心跳")
+		assert.Equal(t, 2, countLines(buf, "心跳"))
+	})
+
+	// 新周期清空上一周期的计数，消息各不相同时计数不会无限增长
+	t.Run("PrunesPreviousWindow", func(t *testing.T) {
+		h := NewSamplingHandler(slog.NewTextHandler(&bytes.Buffer{}, nil), SamplingConfig{Initial: 1, Thereafter: 100, Tick: time.Second}).(*samplingHandler)
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			h.sample(slog.LevelInfo, fmt.Sprintf("用户 %d 登录", i), start)
+		}
+		assert.Len(t, h.counters.counts, 100)
+
+		assert.True(t, h.sample(slog.LevelInfo, "用户 0 登录", start.Add(time.Second)))
+		assert.Len(t, h.counters.counts, 1)
+	})
+
+	// 未启用采样时返回原处理器
+	t.Run("Disabled", func(t *testing.T) {
+		h := slog.NewTextHandler(&bytes.Buffer{}, nil)
+		assert.Same(t, h, NewSamplingHandler(h, SamplingConfig{Initial: 2}))
+	})
+}
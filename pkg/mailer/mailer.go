@@ -0,0 +1,146 @@
+// Package mailer 邮件发送，提供SMTP实现和基于模板的邮件构建
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// 邮件错误
+var (
+	// ErrInvalidMessage 邮件内容无效，如没有收件人、地址格式错误或模板渲染失败，重试也不会成功
+	ErrInvalidMessage = errors.New("无效的邮件")
+)
+
+// Message 邮件
+type Message struct {
+	From    string   // 发件人，为空时使用发送器配置的发件人
+	To      []string // 收件人
+	Subject string
+	Text    string // 纯文本正文
+	HTML    string // HTML正文，与Text同时存在时以 multipart/alternative 发送
+}
+
+// Mailer 邮件发送接口，实现可被多个goroutine并发使用
+type Mailer interface {
+	// Send 发送邮件，邮件内容无效时返回的错误包含 ErrInvalidMessage
+	Send(ctx context.Context, msg *Message) error
+}
+
+// IsPermanent 判断发送错误是否为永久性错误，永久性错误重试也不会成功
+// 邮件内容无效和SMTP服务器返回的5xx响应（如收件人不存在）为永久性错误
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrInvalidMessage) {
+		return true
+	}
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
+
+// invalidMessage 包装为 ErrInvalidMessage
+func invalidMessage(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidMessage, fmt.Sprintf(format, args...))
+}
+
+// envelope 解析发件人和收件人地址，返回信封地址和邮件头中的地址
+func envelope(from string, to []string) (*mail.Address, []*mail.Address, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, nil, invalidMessage("发件人地址无效 %q: %v", from, err)
+	}
+	if len(to) == 0 {
+		return nil, nil, invalidMessage("没有收件人")
+	}
+
+	recipients := make([]*mail.Address, 0, len(to))
+	for _, addr := range to {
+		recipient, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, nil, invalidMessage("收件人地址无效 %q: %v", addr, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return sender, recipients, nil
+}
+
+// encode 将邮件编码为RFC 5322格式，主题按RFC 2047编码，正文使用quoted-printable编码
+func encode(msg *Message, from *mail.Address, to []*mail.Address, now time.Time) ([]byte, error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, invalidMessage("主题不能包含换行符")
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, invalidMessage("正文为空")
+	}
+
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	// 只有一种正文时直接写入，否则按 multipart/alternative 写入，HTML在后表示优先展示
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		header("Content-Type", contentType+"; charset=UTF-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", `multipart/alternative; boundary="`+mw.Boundary()+`"`)
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeQuotedPrintable 以quoted-printable编码写入正文
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := qw.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qw.Close()
+}
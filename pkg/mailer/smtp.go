@@ -0,0 +1,173 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTP连接的TLS模式
+const (
+	// TLSStartTLS 以明文连接后通过STARTTLS升级，服务器不支持STARTTLS时拒绝发送，通常用于587端口
+	TLSStartTLS = "starttls"
+	// TLSImplicit 连接时即使用TLS，通常用于465端口
+	TLSImplicit = "tls"
+	// TLSNone 不使用TLS，仅用于本地测试或内网中继
+	TLSNone = "none"
+)
+
+// ErrStartTLSUnsupported starttls模式下服务器不支持STARTTLS，为避免以明文发送凭据和邮件内容而拒绝发送
+var ErrStartTLSUnsupported = errors.New("SMTP服务器不支持STARTTLS")
+
+// SMTPConfig SMTP发送配置
+type SMTPConfig struct {
+	Host     string // SMTP服务器地址
+	Port     int    // 端口，为0时使用 DefaultSMTPConfig.Port
+	Username string // 用户名，为空时不认证
+	Password string
+	From     string        // 默认发件人，如 "Go Rest Starter <noreply@example.com>"
+	TLS      string        // TLS模式，starttls、tls或none，为空时使用starttls
+	Timeout  time.Duration // 单封邮件的连接和发送超时，为0时使用 DefaultSMTPConfig.Timeout
+}
+
+// DefaultSMTPConfig 默认SMTP配置
+var DefaultSMTPConfig = SMTPConfig{
+	Port:    587,
+	TLS:     TLSStartTLS,
+	Timeout: 10 * time.Second,
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件，每封邮件使用单独的连接
+type SMTPMailer struct {
+	config SMTPConfig
+	now    func() time.Time
+}
+
+// NewSMTPMailer 创建SMTP邮件发送器，未配置服务器地址或发件人时返回错误
+func NewSMTPMailer(config *SMTPConfig) (*SMTPMailer, error) {
+	if config == nil || config.Host == "" {
+		return nil, errors.New("未配置SMTP服务器地址")
+	}
+	if config.From == "" {
+		return nil, errors.New("未配置发件人")
+	}
+
+	cfg := *config
+	if cfg.Port == 0 {
+		cfg.Port = DefaultSMTPConfig.Port
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = DefaultSMTPConfig.TLS
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSMTPConfig.Timeout
+	}
+	switch cfg.TLS {
+	case TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("不支持的SMTP TLS模式: %s", cfg.TLS)
+	}
+
+	return &SMTPMailer{config: cfg, now: time.Now}, nil
+}
+
+// Send 实现 Mailer，上下文的截止时间早于配置的超时时使用上下文的截止时间
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	from := msg.From
+	if from == "" {
+		from = m.config.From
+	}
+	sender, recipients, err := envelope(from, msg.To)
+	if err != nil {
+		return err
+	}
+	data, err := encode(msg, sender, recipients, m.now())
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.config.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	client, err := m.dial(ctx, deadline)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return ErrStartTLSUnsupported
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS失败: %w", err)
+		}
+	}
+	if m.config.Username != "" {
+		// PlainAuth 只在TLS连接或本机地址上发送密码
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP认证失败: %w", err)
+		}
+	}
+
+	if err := client.Mail(sender.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL命令失败: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return fmt.Errorf("SMTP RCPT命令失败 %s: %w", recipient.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA命令失败: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("写入邮件内容失败: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP服务器拒绝邮件: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial 连接SMTP服务器，连接的读写在deadline后超时
+func (m *SMTPMailer) dial(ctx context.Context, deadline time.Time) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("连接SMTP服务器失败: %w", err)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.config.TLS == TLSImplicit {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: m.config.Host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("SMTP TLS握手失败: %w", err)
+		}
+		conn = tlsConn
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("SMTP握手失败: %w", err)
+	}
+	return client, nil
+}
@@ -0,0 +1,265 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedMail 测试SMTP服务器收到的邮件
+type receivedMail struct {
+	auth string // AUTH PLAIN 解码后的凭据
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer 只实现发送流程所需命令的SMTP服务器，rejectRcpt中的收件人返回550
+type fakeSMTPServer struct {
+	addr       string
+	rejectRcpt map[string]bool
+
+	mu       sync.Mutex
+	received []receivedMail
+}
+
+// newFakeSMTPServer 在本机随机端口启动测试SMTP服务器
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTPServer{addr: ln.Addr().String(), rejectRcpt: map[string]bool{}}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// serve 处理一个SMTP会话
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var current receivedMail
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			current.auth = string(decoded)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			current.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if s.rejectRcpt[rcpt] {
+				reply("550 5.1.1 No such user")
+				continue
+			}
+			current.to = append(current.to, rcpt)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			current.data = data.String()
+			s.mu.Lock()
+			s.received = append(s.received, current)
+			s.mu.Unlock()
+			current = receivedMail{}
+			reply("250 OK queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// messages 已收到的邮件
+func (s *fakeSMTPServer) messages() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.received...)
+}
+
+// newTestMailer 创建连接到测试服务器的发送器，测试服务器不支持TLS
+func newTestMailer(t *testing.T, server *fakeSMTPServer) *SMTPMailer {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(server.addr)
+	require.NoError(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	m, err := NewSMTPMailer(&SMTPConfig{
+		Host:     host,
+		Port:     portNum,
+		Username: "user",
+		Password: "secret",
+		From:     "Go Rest Starter <noreply@example.com>",
+		TLS:      TLSNone,
+		Timeout:  5 * time.Second,
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestSMTPMailer(t *testing.T) {
+	ctx := context.Background()
+
+	// 认证后发送包含纯文本和HTML正文的邮件，中文主题按RFC 2047编码
+	t.Run("Send", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		m := newTestMailer(t, server)
+
+		err := m.Send(ctx, &Message{
+			To:      []string{"张三 <zhangsan@example.com>"},
+			Subject: "请验证您的邮箱",
+			Text:    "点击链接完成验证：https://app.example.com/verify?token=abc\n.以点开头的行",
+			HTML:    `<p>点击<a href="https://app.example.com/verify?token=abc">链接</a>完成验证</p>`,
+		})
+		require.NoError(t, err)
+
+		received := server.messages()
+		require.Len(t, received, 1)
+		assert.Equal(t, "\x00user\x00secret", received[0].auth)
+		assert.Equal(t, "noreply@example.com", received[0].from)
+		assert.Equal(t, []string{"zhangsan@example.com"}, received[0].to)
+
+		parsed, err := mail.ReadMessage(strings.NewReader(received[0].data))
+		require.NoError(t, err)
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, "请验证您的邮箱", subject)
+		assert.Contains(t, parsed.Header.Get("From"), "noreply@example.com")
+
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/alternative", mediaType)
+
+		// multipart.Reader 自动解码quoted-printable
+		mr := multipart.NewReader(parsed.Body, params["boundary"])
+		var bodies []string
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			body, err := io.ReadAll(part)
+			require.NoError(t, err)
+			bodies = append(bodies, string(body))
+		}
+		require.Len(t, bodies, 2)
+		// 纯文本正文的换行按RFC 5322转换为CRLF，以点开头的行经过点填充后还原
+		assert.Equal(t, "点击链接完成验证：https://app.example.com/verify?token=abc\r\n.以点开头的行", bodies[0])
+		assert.Contains(t, bodies[1], `<a href="https://app.example.com/verify?token=abc">`)
+	})
+
+	// 服务器拒绝收件人时返回永久性错误
+	t.Run("RejectedRecipient", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		server.rejectRcpt["missing@example.com"] = true
+		m := newTestMailer(t, server)
+
+		err := m.Send(ctx, &Message{To: []string{"missing@example.com"}, Subject: "Hi", Text: "hello"})
+		require.Error(t, err)
+		assert.True(t, IsPermanent(err))
+		assert.Empty(t, server.messages())
+	})
+
+	// starttls模式下服务器不支持STARTTLS时不以明文认证和发送
+	t.Run("StartTLSUnsupported", func(t *testing.T) {
+		server := newFakeSMTPServer(t)
+		m := newTestMailer(t, server)
+		m.config.TLS = TLSStartTLS
+
+		err := m.Send(ctx, &Message{To: []string{"a@example.com"}, Subject: "Hi", Text: "hello"})
+		assert.ErrorIs(t, err, ErrStartTLSUnsupported)
+		assert.Empty(t, server.messages())
+	})
+
+	// 地址无效或主题包含换行符时不连接服务器
+	t.Run("InvalidMessage", func(t *testing.T) {
+		m, err := NewSMTPMailer(&SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@example.com"})
+		require.NoError(t, err)
+
+		err = m.Send(ctx, &Message{To: []string{"not an address"}, Subject: "Hi", Text: "hello"})
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+		err = m.Send(ctx, &Message{To: []string{"a@example.com"}, Subject: "Hi\r\nBcc: b@example.com", Text: "hello"})
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+		err = m.Send(ctx, &Message{Subject: "Hi", Text: "hello"})
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+	})
+
+	// 无法连接时为临时性错误，可以重试
+	t.Run("ConnectionRefused", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := ln.Addr().(*net.TCPAddr)
+		ln.Close()
+
+		m, err := NewSMTPMailer(&SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "noreply@example.com", TLS: TLSNone})
+		require.NoError(t, err)
+		err = m.Send(ctx, &Message{To: []string{"a@example.com"}, Subject: "Hi", Text: "hello"})
+		require.Error(t, err)
+		assert.False(t, IsPermanent(err))
+	})
+
+	// 配置校验
+	t.Run("Config", func(t *testing.T) {
+		_, err := NewSMTPMailer(&SMTPConfig{From: "noreply@example.com"})
+		assert.Error(t, err)
+		_, err = NewSMTPMailer(&SMTPConfig{Host: "smtp.example.com"})
+		assert.Error(t, err)
+		_, err = NewSMTPMailer(&SMTPConfig{Host: "smtp.example.com", From: "noreply@example.com", TLS: "ssl"})
+		assert.Error(t, err)
+	})
+}
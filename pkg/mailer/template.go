@@ -0,0 +1,143 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Template 邮件模板定义，使用 text/template 语法，HTML正文按 html/template 规则自动转义
+type Template struct {
+	Subject string
+	Text    string // 纯文本正文模板，可为空
+	HTML    string // HTML正文模板，可为空，但不能与Text同时为空
+}
+
+// Renderer 按名称渲染模板文件，templates.Engine 实现该接口
+type Renderer interface {
+	Render(w io.Writer, name string, data interface{}) error
+	Has(name string) bool
+}
+
+// renderFunc 渲染邮件的一部分
+type renderFunc func(w io.Writer, data interface{}) error
+
+// parsedTemplate 解析后的邮件模板，正文为nil表示没有该正文
+type parsedTemplate struct {
+	subject renderFunc
+	text    renderFunc
+	html    renderFunc
+}
+
+// Templates 按名称注册的邮件模板，可被多个goroutine并发使用
+type Templates struct {
+	mu        sync.RWMutex
+	templates map[string]*parsedTemplate
+}
+
+// NewTemplates 创建空的邮件模板集合
+func NewTemplates() *Templates {
+	return &Templates{templates: make(map[string]*parsedTemplate)}
+}
+
+// Register 解析并注册模板，同名模板已存在时覆盖
+func (t *Templates) Register(name string, def Template) error {
+	if def.Text == "" && def.HTML == "" {
+		return fmt.Errorf("邮件模板 %s 没有正文", name)
+	}
+
+	parsed := &parsedTemplate{}
+	subject, err := texttemplate.New(name + ".subject").Option("missingkey=error").Parse(def.Subject)
+	if err != nil {
+		return fmt.Errorf("解析邮件模板 %s 的主题失败: %w", name, err)
+	}
+	parsed.subject = subject.Execute
+	if def.Text != "" {
+		text, err := texttemplate.New(name + ".text").Option("missingkey=error").Parse(def.Text)
+		if err != nil {
+			return fmt.Errorf("解析邮件模板 %s 的纯文本正文失败: %w", name, err)
+		}
+		parsed.text = text.Execute
+	}
+	if def.HTML != "" {
+		html, err := htmltemplate.New(name + ".html").Option("missingkey=error").Parse(def.HTML)
+		if err != nil {
+			return fmt.Errorf("解析邮件模板 %s 的HTML正文失败: %w", name, err)
+		}
+		parsed.html = html.Execute
+	}
+
+	t.set(name, parsed)
+	return nil
+}
+
+// Load 注册由r中的模板文件组成的邮件模板：file+".subject.txt" 为主题，
+// file+".txt" 和 file+".html" 为纯文本和HTML正文，两者至少存在一个；每次构建邮件时由r渲染
+func (t *Templates) Load(name string, r Renderer, file string) error {
+	subject := file + ".subject.txt"
+	if !r.Has(subject) {
+		return fmt.Errorf("邮件模板 %s 缺少主题文件 %s", name, subject)
+	}
+
+	render := func(file string) renderFunc {
+		return func(w io.Writer, data interface{}) error { return r.Render(w, file, data) }
+	}
+	parsed := &parsedTemplate{subject: render(subject)}
+	if r.Has(file + ".txt") {
+		parsed.text = render(file + ".txt")
+	}
+	if r.Has(file + ".html") {
+		parsed.html = render(file + ".html")
+	}
+	if parsed.text == nil && parsed.html == nil {
+		return fmt.Errorf("邮件模板 %s 没有正文", name)
+	}
+
+	t.set(name, parsed)
+	return nil
+}
+
+// set 注册解析后的模板，同名模板已存在时覆盖
+func (t *Templates) set(name string, parsed *parsedTemplate) {
+	t.mu.Lock()
+	t.templates[name] = parsed
+	t.mu.Unlock()
+}
+
+// Build 使用data渲染模板，生成发送给to的邮件；模板不存在或渲染失败时返回的错误包含 ErrInvalidMessage
+func (t *Templates) Build(name string, to []string, data interface{}) (*Message, error) {
+	t.mu.RLock()
+	parsed, ok := t.templates[name]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, invalidMessage("邮件模板 %s 不存在", name)
+	}
+
+	msg := &Message{To: to}
+	var buf bytes.Buffer
+	if err := parsed.subject(&buf, data); err != nil {
+		return nil, invalidMessage("渲染邮件模板 %s 的主题失败: %v", name, err)
+	}
+	// 主题只能是一行
+	msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	if parsed.text != nil {
+		buf.Reset()
+		if err := parsed.text(&buf, data); err != nil {
+			return nil, invalidMessage("渲染邮件模板 %s 的纯文本正文失败: %v", name, err)
+		}
+		msg.Text = buf.String()
+	}
+	if parsed.html != nil {
+		buf.Reset()
+		if err := parsed.html(&buf, data); err != nil {
+			return nil, invalidMessage("渲染邮件模板 %s 的HTML正文失败: %v", name, err)
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
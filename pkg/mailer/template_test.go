@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	templateengine "github.com/vadxq/go-rest-starter/pkg/templates"
+)
+
+func TestTemplates(t *testing.T) {
+	templates := NewTemplates()
+	require.NoError(t, templates.Register("welcome", Template{
+		Subject: "欢迎，{{.Name}}",
+		Text:    "你好 {{.Name}}，请访问 {{.URL}}",
+		HTML:    `<p>你好 {{.Name}}，请访问 <a href="{{.URL}}">链接</a></p>`,
+	}))
+
+	data := struct{ Name, URL string }{Name: "<张三>", URL: "https://app.example.com/verify?token=a&lang=zh"}
+
+	// 渲染主题和正文，HTML正文自动转义
+	t.Run("Build", func(t *testing.T) {
+		msg, err := templates.Build("welcome", []string{"a@example.com"}, data)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a@example.com"}, msg.To)
+		assert.Equal(t, "欢迎，<张三>", msg.Subject)
+		assert.Equal(t, "你好 <张三>，请访问 https://app.example.com/verify?token=a&lang=zh", msg.Text)
+		assert.Contains(t, msg.HTML, "你好 &lt;张三&gt;")
+		assert.Contains(t, msg.HTML, `href="https://app.example.com/verify?token=a&amp;lang=zh"`)
+	})
+
+	// 模板不存在或缺少字段时返回 ErrInvalidMessage
+	t.Run("Errors", func(t *testing.T) {
+		_, err := templates.Build("missing", []string{"a@example.com"}, data)
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+
+		_, err = templates.Build("welcome", []string{"a@example.com"}, map[string]string{"Name": "张三"})
+		assert.ErrorIs(t, err, ErrInvalidMessage)
+		assert.True(t, IsPermanent(err))
+	})
+
+	// 主题中的换行符合并为空格，避免注入邮件头
+	t.Run("SingleLineSubject", func(t *testing.T) {
+		require.NoError(t, templates.Register("multiline", Template{Subject: "第一行\n{{.Name}}", Text: "hi"}))
+		msg, err := templates.Build("multiline", []string{"a@example.com"}, data)
+		require.NoError(t, err)
+		assert.Equal(t, "第一行 <张三>", msg.Subject)
+	})
+
+	// 没有正文或语法错误的模板不能注册
+	t.Run("Register", func(t *testing.T) {
+		assert.Error(t, templates.Register("empty", Template{Subject: "hi"}))
+		assert.Error(t, templates.Register("broken", Template{Subject: "{{.Name", Text: "hi"}))
+	})
+
+	// 从模板引擎加载主题和正文文件
+	t.Run("Load", func(t *testing.T) {
+		engine, err := templateengine.New(fstest.MapFS{
+			"email/welcome.subject.txt": {Data: []byte("欢迎，{{.Name}}\n")},
+			"email/welcome.html":        {Data: []byte(`<p>你好 {{.Name}}</p>`)},
+		}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, templates.Load("file", engine, "email/welcome"))
+		msg, err := templates.Build("file", []string{"a@example.com"}, data)
+		require.NoError(t, err)
+		assert.Equal(t, "欢迎，<张三>", msg.Subject)
+		assert.Empty(t, msg.Text)
+		assert.Equal(t, "<p>你好 &lt;张三&gt;</p>", msg.HTML)
+
+		assert.Error(t, templates.Load("missing", engine, "email/missing"))
+	})
+}
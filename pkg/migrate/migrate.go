@@ -0,0 +1,188 @@
+// Package migrate 按版本顺序执行数据库迁移，已执行的版本记录在 schema_migrations 表中
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFileName 迁移文件名不符合 <版本号>_<名称>.up.sql 格式
+var ErrInvalidFileName = errors.New("migrate: invalid migration file name")
+
+// ErrDuplicateVersion 存在重复的迁移版本号
+var ErrDuplicateVersion = errors.New("migrate: duplicate migration version")
+
+// advisoryLockKey 执行迁移时持有的PostgreSQL事务级咨询锁，避免多个实例同时启动时重复执行
+const advisoryLockKey int64 = 0x6d696772617465
+
+// createTableSQL 创建迁移记录表
+const createTableSQL = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// fileNamePattern 迁移文件名格式，如 0001_init.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.up\.sql$`)
+
+// Migration 单个数据库迁移
+type Migration struct {
+	// 版本号，决定执行顺序，必须唯一
+	Version int64
+	// 迁移名称，用于记录和日志
+	Name string
+	// 执行迁移，与迁移记录在同一事务中提交
+	Up func(ctx context.Context, tx *sql.Tx) error
+}
+
+// String 返回迁移的完整名称，如 0001_init
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// SQL 返回执行指定SQL语句的迁移函数，语句可以包含多条以分号分隔的命令
+func SQL(statements string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, statements)
+		return err
+	}
+}
+
+// FromFS 读取目录下的 <版本号>_<名称>.up.sql 文件，按版本号升序返回迁移列表
+// 不以 .up.sql 结尾的文件会被忽略
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".up.sql") {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidFileName, entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+
+		migrations = append(migrations, Migration{
+			Version: version,
+			Name:    matches[2],
+			Up:      SQL(string(content)),
+		})
+	}
+
+	if err := sortMigrations(migrations); err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// sortMigrations 按版本号升序排列，存在重复版本号时返回 ErrDuplicateVersion
+func sortMigrations(migrations []Migration) error {
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return fmt.Errorf("%w: %d", ErrDuplicateVersion, migrations[i].Version)
+		}
+	}
+	return nil
+}
+
+// Runner 迁移执行器
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// NewRunner 创建迁移执行器，迁移按版本号排序，logger为空时使用默认日志器
+func NewRunner(db *sql.DB, migrations []Migration, logger *slog.Logger) (*Runner, error) {
+	sorted := append([]Migration(nil), migrations...)
+	if err := sortMigrations(sorted); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Runner{
+		db:         db,
+		migrations: sorted,
+		logger:     logger,
+	}, nil
+}
+
+// Up 按版本号顺序执行尚未执行的迁移，返回本次执行的迁移
+// 每个迁移在独立事务中执行，失败时回滚并停止执行后续迁移
+func (r *Runner) Up(ctx context.Context) ([]Migration, error) {
+	if _, err := r.db.ExecContext(ctx, createTableSQL); err != nil {
+		return nil, fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+
+	var applied []Migration
+	for _, m := range r.migrations {
+		ok, err := r.apply(ctx, m)
+		if err != nil {
+			return applied, fmt.Errorf("执行迁移 %s 失败: %w", m, err)
+		}
+		if ok {
+			r.logger.Info("数据库迁移已执行", "migration", m.String())
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// apply 在事务中执行单个迁移并写入迁移记录，已执行过的迁移返回false
+func (r *Runner) apply(ctx context.Context, m Migration) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 持有咨询锁后再检查记录，其他实例会等待当前迁移提交
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", advisoryLockKey); err != nil {
+		return false, fmt.Errorf("获取迁移锁失败: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", m.Version).Scan(&exists); err != nil {
+		return false, fmt.Errorf("查询迁移记录失败: %w", err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	if err := m.Up(ctx, tx); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+		return false, fmt.Errorf("写入迁移记录失败: %w", err)
+	}
+
+	return true, tx.Commit()
+}
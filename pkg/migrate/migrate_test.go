@@ -0,0 +1,133 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFS(t *testing.T) {
+	// 按版本号排序，忽略非 .up.sql 文件
+	t.Run("Sorted", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app/0002_add_index.up.sql":   {Data: []byte("CREATE INDEX idx ON t(c);")},
+			"app/0001_init.up.sql":        {Data: []byte("CREATE TABLE t (c INT);")},
+			"app/0002_add_index.down.sql": {Data: []byte("DROP INDEX idx;")},
+			"app/README.md":               {Data: []byte("docs")},
+		}
+
+		migrations, err := FromFS(fsys, "app")
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "init", migrations[0].Name)
+		assert.Equal(t, "0001_init", migrations[0].String())
+		assert.Equal(t, int64(2), migrations[1].Version)
+		assert.Equal(t, "add_index", migrations[1].Name)
+	})
+
+	t.Run("InvalidFileName", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app/init.up.sql": {Data: []byte("SELECT 1;")},
+		}
+
+		_, err := FromFS(fsys, "app")
+		assert.ErrorIs(t, err, ErrInvalidFileName)
+	})
+
+	t.Run("DuplicateVersion", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"app/0001_init.up.sql":  {Data: []byte("SELECT 1;")},
+			"app/01_another.up.sql": {Data: []byte("SELECT 2;")},
+		}
+
+		_, err := FromFS(fsys, "app")
+		assert.ErrorIs(t, err, ErrDuplicateVersion)
+	})
+}
+
+func TestRunner_Up(t *testing.T) {
+	ctx := context.Background()
+
+	lockSQL := regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")
+	existsSQL := regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)")
+	insertSQL := regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")
+
+	newRunner := func(t *testing.T, migrations ...Migration) (*Runner, sqlmock.Sqlmock) {
+		t.Helper()
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		runner, err := NewRunner(db, migrations, nil)
+		require.NoError(t, err)
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		return runner, mock
+	}
+
+	// 跳过已执行的迁移，只执行新的迁移并写入记录
+	t.Run("SkipsApplied", func(t *testing.T) {
+		runner, mock := newRunner(t,
+			Migration{Version: 2, Name: "second", Up: SQL("CREATE TABLE second (id INT)")},
+			Migration{Version: 1, Name: "first", Up: SQL("CREATE TABLE first (id INT)")},
+		)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(lockSQL).WithArgs(advisoryLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(existsSQL).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(lockSQL).WithArgs(advisoryLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(existsSQL).WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE second (id INT)")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertSQL).WithArgs(int64(2), "second").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		applied, err := runner.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, int64(2), applied[0].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 迁移失败时回滚且不再执行后续迁移
+	t.Run("FailureStops", func(t *testing.T) {
+		errBoom := errors.New("syntax error")
+		var secondCalled bool
+		runner, mock := newRunner(t,
+			Migration{Version: 1, Name: "broken", Up: func(ctx context.Context, tx *sql.Tx) error { return errBoom }},
+			Migration{Version: 2, Name: "second", Up: func(ctx context.Context, tx *sql.Tx) error {
+				secondCalled = true
+				return nil
+			}},
+		)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(lockSQL).WithArgs(advisoryLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(existsSQL).WithArgs(int64(1)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectRollback()
+
+		applied, err := runner.Up(ctx)
+		assert.ErrorIs(t, err, errBoom)
+		assert.Contains(t, err.Error(), "0001_broken")
+		assert.Empty(t, applied)
+		assert.False(t, secondCalled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DuplicateVersion", func(t *testing.T) {
+		_, err := NewRunner(nil, []Migration{
+			{Version: 1, Name: "a", Up: SQL("SELECT 1")},
+			{Version: 1, Name: "b", Up: SQL("SELECT 2")},
+		}, nil)
+		assert.ErrorIs(t, err, ErrDuplicateVersion)
+	})
+}
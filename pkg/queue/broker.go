@@ -0,0 +1,121 @@
+package queue
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBrokerBufferSize 每个订阅者默认缓冲的消息数
+const DefaultBrokerBufferSize = 16
+
+// Broker 进程内的消息广播器，将主题消息分发给当前进程的所有订阅者，用于SSE等实时推送
+//
+// 通过 Attach 订阅队列主题后，投递到本实例的队列消息会转发给订阅者。
+// 队列的每条消息只投递给一个实例，多实例部署时订阅者只能收到由本实例消费的消息；
+// 没有订阅者时消息被确认后丢弃，不会为之后连接的订阅者保留。
+// 订阅者处理过慢、缓冲区已满时丢弃发给它的新消息，不阻塞其他订阅者和队列消费。
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*subscriber]struct{}
+	bufferSize  int
+}
+
+// subscriber 广播订阅者
+type subscriber struct {
+	ch     chan *Message
+	topics []string
+	once   sync.Once
+}
+
+// NewBroker 创建广播器，bufferSize<=0时使用 DefaultBrokerBufferSize
+func NewBroker(bufferSize int) *Broker {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBrokerBufferSize
+	}
+
+	return &Broker{
+		subscribers: make(map[string]map[*subscriber]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe 订阅一个或多个主题，返回接收消息的通道和取消订阅的函数
+// 取消订阅后通道被关闭，取消函数可以重复调用
+func (b *Broker) Subscribe(topics ...string) (<-chan *Message, func()) {
+	sub := &subscriber{
+		ch:     make(chan *Message, b.bufferSize),
+		topics: append([]string(nil), topics...),
+	}
+
+	b.mu.Lock()
+	for _, topic := range sub.topics {
+		subs, ok := b.subscribers[topic]
+		if !ok {
+			subs = make(map[*subscriber]struct{})
+			b.subscribers[topic] = subs
+		}
+		subs[sub] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return sub.ch, func() { b.unsubscribe(sub) }
+}
+
+// unsubscribe 移除订阅者并关闭其通道
+func (b *Broker) unsubscribe(sub *subscriber) {
+	sub.once.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		for _, topic := range sub.topics {
+			subs := b.subscribers[topic]
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(b.subscribers, topic)
+			}
+		}
+		// 持有写锁时关闭，保证 Publish 不会向已关闭的通道发送
+		close(sub.ch)
+	})
+}
+
+// Publish 将消息发送给订阅了消息主题的所有订阅者，缓冲区已满的订阅者不会收到该消息
+// 返回成功送达的订阅者数
+func (b *Broker) Publish(msg *Message) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	delivered := 0
+	for sub := range b.subscribers[msg.Topic] {
+		select {
+		case sub.ch <- msg:
+			delivered++
+		default:
+		}
+	}
+	return delivered
+}
+
+// SubscriberCount 主题当前的订阅者数
+func (b *Broker) SubscriberCount(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers[topic])
+}
+
+// Handle 队列消息处理器，将消息转发给订阅者，总是返回nil
+func (b *Broker) Handle(ctx context.Context, msg *Message) error {
+	b.Publish(msg)
+	return nil
+}
+
+// Attach 订阅队列的主题，将投递到本实例的消息转发给广播器的订阅者
+func (b *Broker) Attach(ctx context.Context, q Queue, topics ...string) error {
+	for _, topic := range topics {
+		if err := q.Subscribe(ctx, topic, b.Handle, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
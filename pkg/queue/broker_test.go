@@ -0,0 +1,80 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	// 消息只发给订阅了该主题的订阅者，一个订阅者可以订阅多个主题
+	t.Run("FanOut", func(t *testing.T) {
+		b := NewBroker(0)
+		orders, cancelOrders := b.Subscribe("orders")
+		defer cancelOrders()
+		all, cancelAll := b.Subscribe("orders", "emails")
+		defer cancelAll()
+
+		assert.Equal(t, 2, b.Publish(&Message{ID: "1", Topic: "orders"}))
+		assert.Equal(t, 1, b.Publish(&Message{ID: "2", Topic: "emails"}))
+		assert.Equal(t, 0, b.Publish(&Message{ID: "3", Topic: "other"}))
+
+		assert.Equal(t, "1", (<-orders).ID)
+		assert.Equal(t, "1", (<-all).ID)
+		assert.Equal(t, "2", (<-all).ID)
+		assert.Empty(t, orders)
+	})
+
+	// 取消订阅后通道关闭，主题不再保留订阅者，重复取消不报错
+	t.Run("Unsubscribe", func(t *testing.T) {
+		b := NewBroker(0)
+		ch, cancel := b.Subscribe("orders", "emails")
+		assert.Equal(t, 1, b.SubscriberCount("orders"))
+
+		cancel()
+		cancel()
+		_, ok := <-ch
+		assert.False(t, ok)
+		assert.Zero(t, b.SubscriberCount("orders"))
+		assert.Zero(t, b.SubscriberCount("emails"))
+		assert.Zero(t, b.Publish(&Message{Topic: "orders"}))
+	})
+
+	// 缓冲区已满时丢弃新消息，不阻塞发布方
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		b := NewBroker(1)
+		ch, cancel := b.Subscribe("orders")
+		defer cancel()
+
+		assert.Equal(t, 1, b.Publish(&Message{ID: "1", Topic: "orders"}))
+		assert.Equal(t, 0, b.Publish(&Message{ID: "2", Topic: "orders"}))
+		assert.Equal(t, "1", (<-ch).ID)
+	})
+}
+
+func TestBroker_Attach(t *testing.T) {
+	ctx := context.Background()
+	rq, _ := newTestQueue(t)
+
+	b := NewBroker(0)
+	require.NoError(t, b.Attach(ctx, rq, "notifications"))
+	ch, cancel := b.Subscribe("notifications")
+	defer cancel()
+
+	// 发布到队列的消息转发给订阅者
+	require.NoError(t, rq.Publish(ctx, "notifications", map[string]string{"status": "done"}))
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "notifications", msg.Topic)
+		var payload map[string]string
+		require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+		assert.Equal(t, "done", payload["status"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("订阅者没有收到队列消息")
+	}
+}
@@ -3,310 +3,784 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+	"github.com/vadxq/go-rest-starter/pkg/utils"
 )
 
 // Message 队列消息
 type Message struct {
-	ID        string          `json:"id"`
-	Topic     string          `json:"topic"`
-	Payload   json.RawMessage `json:"payload"`
-	Timestamp time.Time       `json:"timestamp"`
-	Retries   int             `json:"retries"`
-	MaxRetries int            `json:"max_retries"`
+	ID            string          `json:"id"`
+	Topic         string          `json:"topic"`
+	SchemaVersion int             `json:"schema_version,omitempty"` // 负载的结构版本，0表示未指定版本
+	Payload       json.RawMessage `json:"payload"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Retries       int             `json:"retries"`
+	MaxRetries    int             `json:"max_retries"`
+}
+
+// DeadLetterMessage 死信消息，记录失败原因和时间
+type DeadLetterMessage struct {
+	Message
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
 }
 
+// ErrMessageNotFound 消息不存在
+var ErrMessageNotFound = errors.New("queue: message not found")
+
+// ErrUnsupportedVersion 没有处理器支持消息的结构版本
+var ErrUnsupportedVersion = errors.New("queue: unsupported schema version")
+
+// ErrQueueClosed 队列已关闭
+var ErrQueueClosed = errors.New("queue: closed")
+
+// UnknownVersionPolicy 没有处理器支持消息的结构版本时的处理策略
+type UnknownVersionPolicy int
+
+const (
+	// UnknownVersionDeadLetter 发送到死信队列，升级消费者后可重新投递（默认）
+	UnknownVersionDeadLetter UnknownVersionPolicy = iota
+	// UnknownVersionSkip 确认并丢弃消息
+	UnknownVersionSkip
+)
+
 // Handler 消息处理器
 type Handler func(ctx context.Context, msg *Message) error
 
+// SubscribeOptions 主题订阅选项
+type SubscribeOptions struct {
+	// Concurrency 该主题同时处理的消息数，<=0时使用队列创建时的maxWorkers
+	Concurrency int
+	// Timeout 单条消息的处理超时
+	Timeout time.Duration
+	// VisibilityTimeout 消息被领取后未确认的最长时间，超过后由恢复协程重新投递；
+	// 应大于 Timeout，否则处理中的消息可能被重复投递
+	VisibilityTimeout time.Duration
+	// Retry 重试策略，MaxAttempts为最大重试次数，超过后进入死信队列；
+	// RetryIf返回false的错误不再重试，直接进入死信队列
+	Retry apperrors.RetryConfig
+	// Versions 本次订阅的处理器接受的结构版本，为空时接受所有版本；
+	// 与其他选项不同，同一主题的每次订阅可以指定各自的版本
+	Versions []int
+	// UnknownVersion 没有处理器接受消息的结构版本时的处理策略
+	UnknownVersion UnknownVersionPolicy
+}
+
+// DefaultSubscribeOptions 默认订阅选项
+var DefaultSubscribeOptions = SubscribeOptions{
+	Timeout:           30 * time.Second,
+	VisibilityTimeout: 5 * time.Minute,
+	Retry: apperrors.RetryConfig{
+		MaxAttempts:     3,
+		InitialDelay:    2 * time.Second,
+		MaxDelay:        time.Minute,
+		Multiplier:      2.0,
+		RandomizeFactor: 0.1,
+	},
+}
+
 // Queue 队列接口
 type Queue interface {
 	// Publish 发布消息
 	Publish(ctx context.Context, topic string, payload interface{}) error
-	// Subscribe 订阅主题
-	Subscribe(ctx context.Context, topic string, handler Handler) error
+	// PublishTyped 发布带结构版本的消息
+	PublishTyped(ctx context.Context, topic string, version int, payload interface{}) error
+	// Subscribe 订阅主题，opts为空时使用 DefaultSubscribeOptions
+	// 同一主题多次订阅时共用首次订阅的选项（Versions除外），每条消息依次交给所有接受其版本的处理器
+	Subscribe(ctx context.Context, topic string, handler Handler, opts *SubscribeOptions) error
 	// PublishDelayed 发布延迟消息
 	PublishDelayed(ctx context.Context, topic string, payload interface{}, delay time.Duration) error
-	// Close 关闭队列
+	// ListDeadLetters 列出主题的死信消息，按失败时间倒序，limit<=0时返回全部
+	ListDeadLetters(ctx context.Context, topic string, limit int) ([]DeadLetterMessage, error)
+	// RequeueDeadLetter 将死信消息重置重试次数后重新投递到原主题
+	RequeueDeadLetter(ctx context.Context, topic, messageID string) error
+	// Ping 检查队列是否可用，队列已关闭或底层存储不可达时返回错误
+	Ping(ctx context.Context) error
+	// Shutdown 停止领取新消息，等待处理中的消息完成后关闭队列；
+	// ctx到期时取消仍未完成的处理器，未确认的消息放回主题队列重新投递
+	Shutdown(ctx context.Context) error
+	// Close 关闭队列，最多等待 DefaultShutdownTimeout
 	Close() error
 }
 
-// RedisQueue Redis队列实现
+// DefaultShutdownTimeout Close 等待处理中消息完成的最长时间
+const DefaultShutdownTimeout = 30 * time.Second
+
+// subscription 主题订阅，每个主题拥有独立的工作池和重试策略
+type subscription struct {
+	handlers []registration
+	options  SubscribeOptions
+	workers  chan struct{}
+}
+
+// registration 处理器及其接受的结构版本
+type registration struct {
+	handler  Handler
+	versions []int
+}
+
+// accepts 判断处理器是否接受该结构版本
+func (r registration) accepts(version int) bool {
+	if len(r.versions) == 0 {
+		return true
+	}
+	for _, v := range r.versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// RedisQueue Redis队列实现，提供至少一次（at-least-once）投递语义
+//
+// 消费者通过 BRPOPLPUSH 将消息原子地从主题队列移入自己的处理中列表，
+// 并记录领取时间；消息处理完成（成功、进入延迟重试或死信队列）后才从处理中列表确认删除。
+// 进程在处理过程中崩溃时消息仍保留在处理中列表，恢复协程会将超过可见性超时
+// 仍未确认的消息放回主题队列重新投递，因此处理器需要保证幂等。
+// 关闭时先停止领取，等待处理中的消息完成，再将未确认的消息立即放回主题队列。
 type RedisQueue struct {
-	client      *redis.Client
-	handlers    map[string][]Handler
-	mu          sync.RWMutex
-	workerPool  chan struct{}
-	ctx         context.Context
-	cancel      context.CancelFunc
-	wg          sync.WaitGroup
+	client        *redis.Client
+	consumerID    string
+	subscriptions map[string]*subscription
+	mu            sync.RWMutex
+	maxWorkers    int
+	// ctx 控制消费、恢复和延迟投递协程，关闭时首先取消
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// handlerCtx 处理器使用的上下文，等待处理中的消息超时后才取消
+	handlerCtx     context.Context
+	cancelHandlers context.CancelFunc
+	inflight       sync.WaitGroup
 }
 
-// NewRedisQueue 创建Redis队列
+// 延迟队列键
+const delayedQueueKey = "delayed_queue"
+
+// NewRedisQueue 创建Redis队列，maxWorkers为订阅未指定并发数时每个主题的默认并发数
 func NewRedisQueue(client *redis.Client, maxWorkers int) Queue {
 	ctx, cancel := context.WithCancel(context.Background())
-	
+	handlerCtx, cancelHandlers := context.WithCancel(context.Background())
+
+	if maxWorkers <= 0 {
+		maxWorkers = 1
+	}
+
+	consumerID, err := utils.GenerateRandomString(8)
+	if err != nil {
+		consumerID = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
 	rq := &RedisQueue{
-		client:     client,
-		handlers:   make(map[string][]Handler),
-		workerPool: make(chan struct{}, maxWorkers),
-		ctx:        ctx,
-		cancel:     cancel,
-	}
-	
-	// 初始化工作池
-	for i := 0; i < maxWorkers; i++ {
-		rq.workerPool <- struct{}{}
-	}
-	
+		client:         client,
+		consumerID:     consumerID,
+		subscriptions:  make(map[string]*subscription),
+		maxWorkers:     maxWorkers,
+		ctx:            ctx,
+		cancel:         cancel,
+		handlerCtx:     handlerCtx,
+		cancelHandlers: cancelHandlers,
+	}
+
 	// 启动延迟消息处理器
 	rq.wg.Add(1)
 	go rq.processDelayedMessages()
-	
+
 	return rq
 }
 
 // Publish 发布消息
 func (rq *RedisQueue) Publish(ctx context.Context, topic string, payload interface{}) error {
+	msg, err := newMessage(topic, payload)
+	if err != nil {
+		return err
+	}
+
+	return rq.enqueue(ctx, msg)
+}
+
+// PublishTyped 发布带结构版本的消息，消费者可按版本选择处理器
+func (rq *RedisQueue) PublishTyped(ctx context.Context, topic string, version int, payload interface{}) error {
+	if version <= 0 {
+		return fmt.Errorf("invalid schema version: %d", version)
+	}
+
+	msg, err := newMessage(topic, payload)
+	if err != nil {
+		return err
+	}
+	msg.SchemaVersion = version
+
+	return rq.enqueue(ctx, msg)
+}
+
+// newMessage 创建消息
+func newMessage(topic string, payload interface{}) (*Message, error) {
 	// 序列化payload
 	data, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-	
-	// 创建消息
-	msg := &Message{
-		ID:        generateMessageID(),
-		Topic:     topic,
-		Payload:   data,
-		Timestamp: time.Now(),
-		Retries:   0,
-		MaxRetries: 3,
-	}
-	
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	return &Message{
+		ID:         generateMessageID(),
+		Topic:      topic,
+		Payload:    data,
+		Timestamp:  time.Now(),
+		Retries:    0,
+		MaxRetries: DefaultSubscribeOptions.Retry.MaxAttempts,
+	}, nil
+}
+
+// enqueue 将消息推入主题队列
+func (rq *RedisQueue) enqueue(ctx context.Context, msg *Message) error {
 	// 序列化消息
 	msgData, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
-	
+
 	// 发布到Redis
-	key := fmt.Sprintf("queue:%s", topic)
-	if err := rq.client.LPush(ctx, key, msgData).Err(); err != nil {
+	if err := rq.client.LPush(ctx, queueKey(msg.Topic), msgData).Err(); err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
-	
+
 	return nil
 }
 
 // Subscribe 订阅主题
-func (rq *RedisQueue) Subscribe(ctx context.Context, topic string, handler Handler) error {
-	// 注册处理器
+func (rq *RedisQueue) Subscribe(ctx context.Context, topic string, handler Handler, opts *SubscribeOptions) error {
 	rq.mu.Lock()
-	rq.handlers[topic] = append(rq.handlers[topic], handler)
-	rq.mu.Unlock()
-	
-	// 启动消费者
-	rq.wg.Add(1)
-	go rq.consume(topic)
-	
+	defer rq.mu.Unlock()
+
+	reg := registration{handler: handler}
+	if opts != nil {
+		reg.versions = append([]int(nil), opts.Versions...)
+	}
+
+	// 已订阅的主题只追加处理器
+	if sub, ok := rq.subscriptions[topic]; ok {
+		sub.handlers = append(sub.handlers, reg)
+		return nil
+	}
+
+	options := DefaultSubscribeOptions
+	if opts != nil {
+		options = *opts
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = rq.maxWorkers
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultSubscribeOptions.Timeout
+	}
+	if options.VisibilityTimeout <= 0 {
+		options.VisibilityTimeout = DefaultSubscribeOptions.VisibilityTimeout
+	}
+
+	sub := &subscription{
+		handlers: []registration{reg},
+		options:  options,
+		workers:  make(chan struct{}, options.Concurrency),
+	}
+	rq.subscriptions[topic] = sub
+
+	// 启动消费者和未确认消息的恢复协程
+	rq.wg.Add(2)
+	go rq.consume(topic, sub)
+	go rq.reclaimLoop(topic, sub)
+
 	return nil
 }
 
 // consume 消费消息
-func (rq *RedisQueue) consume(topic string) {
+func (rq *RedisQueue) consume(topic string, sub *subscription) {
 	defer rq.wg.Done()
-	
-	key := fmt.Sprintf("queue:%s", topic)
-	
+
+	key := queueKey(topic)
+	processing := processingKey(topic, rq.consumerID)
+
 	for {
+		// 获取工作令牌，主题的并发数达到上限时等待
 		select {
 		case <-rq.ctx.Done():
 			return
-		default:
-			// 从队列中获取消息（阻塞1秒）
-			result, err := rq.client.BRPop(rq.ctx, time.Second, key).Result()
-			if err != nil {
-				if err == redis.Nil {
-					continue // 超时，继续等待
-				}
-				// 记录错误并继续
-				continue
+		case sub.workers <- struct{}{}:
+		}
+
+		// 将消息原子地移入处理中列表（阻塞1秒），确认前不会丢失
+		data, err := rq.client.BRPopLPush(rq.ctx, key, processing, time.Second).Result()
+		if err != nil {
+			<-sub.workers
+			// 超时或出错，继续等待
+			continue
+		}
+
+		// 记录领取时间并登记处理中列表，供恢复协程判断是否超时
+		rq.client.TxPipelined(rq.ctx, func(pipe redis.Pipeliner) error {
+			pipe.ZAdd(rq.ctx, claimedAtKey(processing), redis.Z{
+				Score:  float64(time.Now().UnixMilli()),
+				Member: data,
+			})
+			pipe.SAdd(rq.ctx, consumersKey(topic), processing)
+			return nil
+		})
+
+		// 异步处理消息，关闭时等待其完成
+		rq.inflight.Add(1)
+		go func(data string) {
+			defer rq.inflight.Done()
+			defer func() {
+				<-sub.workers // 归还工作令牌
+			}()
+
+			// 反序列化消息，无法解析的消息直接确认丢弃，避免反复投递
+			var msg Message
+			if err := json.Unmarshal([]byte(data), &msg); err != nil {
+				rq.ack(processing, data)
+				return
 			}
-			
-			if len(result) < 2 {
-				continue
+
+			// 处理消息，未能妥善处置时不确认，等待恢复协程重新投递
+			if rq.processMessage(sub, &msg) {
+				rq.ack(processing, data)
 			}
-			
-			// 获取工作令牌
-			<-rq.workerPool
-			
-			// 异步处理消息
-			go func(data string) {
-				defer func() {
-					rq.workerPool <- struct{}{} // 归还工作令牌
-				}()
-				
-				// 反序列化消息
-				var msg Message
-				if err := json.Unmarshal([]byte(data), &msg); err != nil {
-					return
-				}
-				
-				// 处理消息
-				rq.processMessage(&msg)
-			}(result[1])
-		}
+		}(data)
 	}
 }
 
-// processMessage 处理消息
-func (rq *RedisQueue) processMessage(msg *Message) {
+// ack 确认消息，从处理中列表删除
+// 使用独立的上下文，保证队列关闭时正在处理的消息仍能完成确认
+func (rq *RedisQueue) ack(processing, data string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rq.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LRem(ctx, processing, 1, data)
+		pipe.ZRem(ctx, claimedAtKey(processing), data)
+		return nil
+	})
+}
+
+// processMessage 处理消息，任一处理器失败时按主题的重试策略重试
+// 返回消息是否已被妥善处置（处理成功、进入延迟重试或死信队列），可以确认
+func (rq *RedisQueue) processMessage(sub *subscription, msg *Message) bool {
 	rq.mu.RLock()
-	handlers := rq.handlers[msg.Topic]
+	handlers := sub.handlers
 	rq.mu.RUnlock()
-	
-	for _, handler := range handlers {
-		ctx, cancel := context.WithTimeout(rq.ctx, 30*time.Second)
+
+	retry := sub.options.Retry
+	msg.MaxRetries = retry.MaxAttempts
+
+	// 只交给接受该结构版本的处理器
+	accepted := make([]Handler, 0, len(handlers))
+	for _, reg := range handlers {
+		if reg.accepts(msg.SchemaVersion) {
+			accepted = append(accepted, reg.handler)
+		}
+	}
+	if len(accepted) == 0 {
+		if sub.options.UnknownVersion == UnknownVersionSkip {
+			return true
+		}
+		return rq.sendToDeadLetter(msg, fmt.Errorf("%w: %d", ErrUnsupportedVersion, msg.SchemaVersion)) == nil
+	}
+
+	for _, handler := range accepted {
+		ctx, cancel := context.WithTimeout(rq.handlerCtx, sub.options.Timeout)
 		err := handler(ctx, msg)
 		cancel()
-		
-		if err != nil {
-			// 处理失败，重试
-			if msg.Retries < msg.MaxRetries {
-				msg.Retries++
-				rq.retryMessage(msg)
-			} else {
-				// 超过最大重试次数，发送到死信队列
-				rq.sendToDeadLetter(msg, err)
+
+		if err == nil {
+			continue
+		}
+
+		// 可重试且未超过最大重试次数时延迟重试，否则发送到死信队列
+		retryable := retry.RetryIf == nil || retry.RetryIf(err)
+		if retryable && msg.Retries < retry.MaxAttempts {
+			delay := retry.Delay(msg.Retries)
+			msg.Retries++
+			if err := rq.scheduleMessage(rq.handlerCtx, msg, delay); err == nil {
+				return true
 			}
 		}
+		return rq.sendToDeadLetter(msg, err) == nil
 	}
-}
 
-// retryMessage 重试消息
-func (rq *RedisQueue) retryMessage(msg *Message) {
-	// 计算重试延迟（指数退避）
-	delay := time.Duration(msg.Retries) * time.Second * 2
-	
-	// 发布延迟消息
-	rq.PublishDelayed(rq.ctx, msg.Topic, msg, delay)
+	return true
 }
 
 // sendToDeadLetter 发送到死信队列
-func (rq *RedisQueue) sendToDeadLetter(msg *Message, err error) {
-	deadLetterKey := fmt.Sprintf("dead_letter:%s", msg.Topic)
-	
+func (rq *RedisQueue) sendToDeadLetter(msg *Message, err error) error {
 	// 添加错误信息
-	type DeadLetterMessage struct {
-		*Message
-		Error     string    `json:"error"`
-		FailedAt  time.Time `json:"failed_at"`
-	}
-	
 	dlMsg := &DeadLetterMessage{
-		Message:  msg,
+		Message:  *msg,
 		Error:    err.Error(),
 		FailedAt: time.Now(),
 	}
-	
+
 	data, _ := json.Marshal(dlMsg)
-	rq.client.LPush(rq.ctx, deadLetterKey, data)
+	return rq.client.LPush(rq.handlerCtx, deadLetterKey(msg.Topic), data).Err()
+}
+
+// queueKey 主题队列键
+func queueKey(topic string) string {
+	return fmt.Sprintf("queue:%s", topic)
+}
+
+// processingKey 消费者的处理中列表键
+func processingKey(topic, consumerID string) string {
+	return fmt.Sprintf("queue:%s:processing:%s", topic, consumerID)
+}
+
+// claimedAtKey 处理中消息的领取时间（有序集合，分数为领取时间毫秒数）
+func claimedAtKey(processing string) string {
+	return processing + ":claimed_at"
+}
+
+// consumersKey 主题的处理中列表登记集合，恢复协程据此找到崩溃消费者遗留的消息
+func consumersKey(topic string) string {
+	return fmt.Sprintf("queue:%s:consumers", topic)
+}
+
+// deadLetterKey 死信队列键
+func deadLetterKey(topic string) string {
+	return fmt.Sprintf("dead_letter:%s", topic)
+}
+
+// reclaimScript 将处理中列表里超过可见性超时仍未确认的消息放回主题队列
+//
+// KEYS[1] 处理中列表
+// KEYS[2] 领取时间有序集合
+// KEYS[3] 主题队列
+// KEYS[4] 处理中列表登记集合
+// ARGV[1] 当前时间（毫秒）
+// ARGV[2] 可见性超时（毫秒）
+// ARGV[3] 是否为当前消费者自己的列表（1/0）
+//
+// 返回重新投递的消息数
+var reclaimScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local visibility = tonumber(ARGV[2])
+local reclaimed = 0
+
+for _, item in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local claimed = redis.call('ZSCORE', KEYS[2], item)
+	if not claimed then
+		-- 消费者在记录领取时间前崩溃，从现在开始计时
+		redis.call('ZADD', KEYS[2], now, item)
+	elseif now - tonumber(claimed) >= visibility then
+		redis.call('LREM', KEYS[1], 1, item)
+		redis.call('ZREM', KEYS[2], item)
+		-- 放到队列出队端，优先重新投递
+		redis.call('RPUSH', KEYS[3], item)
+		reclaimed = reclaimed + 1
+	end
+end
+
+-- 清理其他消费者遗留的空列表
+if ARGV[3] == '0' and redis.call('LLEN', KEYS[1]) == 0 then
+	redis.call('DEL', KEYS[2])
+	redis.call('SREM', KEYS[4], KEYS[1])
+end
+
+return reclaimed
+`)
+
+// requeueScript 将处理中列表里的全部消息放回主题队列，用于关闭时交还未完成的消息
+//
+// KEYS[1] 处理中列表
+// KEYS[2] 领取时间有序集合
+// KEYS[3] 主题队列
+// KEYS[4] 处理中列表登记集合
+//
+// 返回重新投递的消息数
+var requeueScript = redis.NewScript(`
+local items = redis.call('LRANGE', KEYS[1], 0, -1)
+
+-- 处理中列表头部是最新领取的消息，依次放到队列出队端，最早领取的最先重新投递
+for _, item in ipairs(items) do
+	redis.call('RPUSH', KEYS[3], item)
+end
+
+redis.call('DEL', KEYS[1], KEYS[2])
+redis.call('SREM', KEYS[4], KEYS[1])
+
+return #items
+`)
+
+// reclaimLoop 定期检查主题所有处理中列表，重新投递超过可见性超时仍未确认的消息
+func (rq *RedisQueue) reclaimLoop(topic string, sub *subscription) {
+	defer rq.wg.Done()
+
+	interval := sub.options.VisibilityTimeout / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rq.ctx.Done():
+			return
+		case <-ticker.C:
+			rq.reclaim(rq.ctx, topic, sub.options.VisibilityTimeout)
+		}
+	}
+}
+
+// reclaim 重新投递主题中超时未确认的消息，返回重新投递的消息数
+func (rq *RedisQueue) reclaim(ctx context.Context, topic string, visibility time.Duration) (int, error) {
+	lists, err := rq.client.SMembers(ctx, consumersKey(topic)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list processing queues: %w", err)
+	}
+
+	own := processingKey(topic, rq.consumerID)
+	total := 0
+	for _, list := range lists {
+		self := "0"
+		if list == own {
+			self = "1"
+		}
+
+		n, err := reclaimScript.Run(ctx, rq.client,
+			[]string{list, claimedAtKey(list), queueKey(topic), consumersKey(topic)},
+			time.Now().UnixMilli(), visibility.Milliseconds(), self,
+		).Int()
+		if err != nil {
+			return total, fmt.Errorf("failed to reclaim messages: %w", err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+// ListDeadLetters 列出主题的死信消息，按失败时间倒序，limit<=0时返回全部
+func (rq *RedisQueue) ListDeadLetters(ctx context.Context, topic string, limit int) ([]DeadLetterMessage, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit - 1)
+	}
+
+	items, err := rq.client.LRange(ctx, deadLetterKey(topic), 0, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	messages := make([]DeadLetterMessage, 0, len(items))
+	for _, item := range items {
+		var dlMsg DeadLetterMessage
+		if err := json.Unmarshal([]byte(item), &dlMsg); err != nil {
+			continue // 跳过无法解析的消息
+		}
+		messages = append(messages, dlMsg)
+	}
+
+	return messages, nil
+}
+
+// RequeueDeadLetter 将死信消息重置重试次数后重新投递到原主题
+func (rq *RedisQueue) RequeueDeadLetter(ctx context.Context, topic, messageID string) error {
+	key := deadLetterKey(topic)
+
+	items, err := rq.client.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	for _, item := range items {
+		var dlMsg DeadLetterMessage
+		if err := json.Unmarshal([]byte(item), &dlMsg); err != nil || dlMsg.ID != messageID {
+			continue
+		}
+
+		// 先从死信队列移除，移除失败说明已被其他调用方重新投递
+		removed, err := rq.client.LRem(ctx, key, 1, item).Result()
+		if err != nil {
+			return fmt.Errorf("failed to remove dead letter: %w", err)
+		}
+		if removed == 0 {
+			return ErrMessageNotFound
+		}
+
+		msg := dlMsg.Message
+		msg.Topic = topic
+		msg.Retries = 0
+		if err := rq.enqueue(ctx, &msg); err != nil {
+			// 投递失败时放回死信队列，避免消息丢失
+			rq.client.LPush(ctx, key, item)
+			return err
+		}
+		return nil
+	}
+
+	return ErrMessageNotFound
 }
 
 // PublishDelayed 发布延迟消息
 func (rq *RedisQueue) PublishDelayed(ctx context.Context, topic string, payload interface{}, delay time.Duration) error {
-	// 序列化payload
-	data, err := json.Marshal(payload)
+	msg, err := newMessage(topic, payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
-	}
-	
-	// 创建消息
-	msg := &Message{
-		ID:        generateMessageID(),
-		Topic:     topic,
-		Payload:   data,
-		Timestamp: time.Now(),
+		return err
 	}
-	
+
+	return rq.scheduleMessage(ctx, msg, delay)
+}
+
+// scheduleMessage 将消息加入延迟队列（有序集合，分数为到期时间毫秒数），保留重试次数
+func (rq *RedisQueue) scheduleMessage(ctx context.Context, msg *Message, delay time.Duration) error {
 	// 序列化消息
 	msgData, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
-	
-	// 添加到延迟队列（使用有序集合）
-	score := float64(time.Now().Add(delay).Unix())
-	key := "delayed_queue"
-	if err := rq.client.ZAdd(ctx, key, redis.Z{
+
+	score := float64(time.Now().Add(delay).UnixMilli())
+	if err := rq.client.ZAdd(ctx, delayedQueueKey, redis.Z{
 		Score:  score,
 		Member: msgData,
 	}).Err(); err != nil {
 		return fmt.Errorf("failed to publish delayed message: %w", err)
 	}
-	
+
 	return nil
 }
 
 // processDelayedMessages 处理延迟消息
 func (rq *RedisQueue) processDelayedMessages() {
 	defer rq.wg.Done()
-	
-	ticker := time.NewTicker(time.Second)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
-	
+
 	for {
 		select {
 		case <-rq.ctx.Done():
 			return
 		case <-ticker.C:
-			// 获取到期的消息
-			now := float64(time.Now().Unix())
-			key := "delayed_queue"
-			
 			// 获取所有到期的消息
-			messages, err := rq.client.ZRangeByScore(rq.ctx, key, &redis.ZRangeBy{
+			now := time.Now().UnixMilli()
+			messages, err := rq.client.ZRangeByScore(rq.ctx, delayedQueueKey, &redis.ZRangeBy{
 				Min: "0",
-				Max: fmt.Sprintf("%f", now),
+				Max: fmt.Sprintf("%d", now),
 			}).Result()
-			
 			if err != nil {
 				continue
 			}
-			
+
 			for _, msgData := range messages {
+				// 先从延迟队列中删除，删除成功才投递，避免多实例重复投递
+				removed, err := rq.client.ZRem(rq.ctx, delayedQueueKey, msgData).Result()
+				if err != nil || removed == 0 {
+					continue
+				}
+
 				// 反序列化消息
 				var msg Message
 				if err := json.Unmarshal([]byte(msgData), &msg); err != nil {
 					continue
 				}
-				
-				// 发布到正常队列
-				if err := rq.Publish(rq.ctx, msg.Topic, msg.Payload); err != nil {
-					continue
+
+				// 投递到正常队列
+				if err := rq.enqueue(rq.ctx, &msg); err != nil {
+					// 投递失败时放回延迟队列，下次再试
+					rq.client.ZAdd(rq.ctx, delayedQueueKey, redis.Z{Score: float64(now), Member: msgData})
 				}
-				
-				// 从延迟队列中删除
-				rq.client.ZRem(rq.ctx, key, msgData)
 			}
 		}
 	}
 }
 
-// Close 关闭队列
-func (rq *RedisQueue) Close() error {
+// Shutdown 优雅关闭队列
+// 先停止领取新消息，再等待处理中的消息完成；ctx到期时取消处理器的上下文，
+// 最后将处理中列表里未确认的消息放回主题队列，无需等待可见性超时即可由其他实例处理
+func (rq *RedisQueue) Shutdown(ctx context.Context) error {
 	rq.cancel()
 	rq.wg.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		rq.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("queue: shutdown timed out waiting for in-flight messages: %w", ctx.Err())
+	}
+	rq.cancelHandlers()
+
+	if _, requeueErr := rq.requeueInflight(); requeueErr != nil {
+		err = errors.Join(err, requeueErr)
+	}
+	return err
+}
+
+// Ping 检查队列是否可用：已关闭时返回 ErrQueueClosed，否则检查Redis连接
+func (rq *RedisQueue) Ping(ctx context.Context) error {
+	if rq.ctx.Err() != nil {
+		return ErrQueueClosed
+	}
+	if err := rq.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("queue: ping redis: %w", err)
+	}
 	return nil
 }
 
+// Close 关闭队列，最多等待 DefaultShutdownTimeout
+func (rq *RedisQueue) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+
+	return rq.Shutdown(ctx)
+}
+
+// requeueInflight 将本消费者所有处理中列表的消息放回主题队列，返回重新投递的消息数
+// 使用独立的上下文，队列的上下文此时已取消
+func (rq *RedisQueue) requeueInflight() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rq.mu.RLock()
+	topics := make([]string, 0, len(rq.subscriptions))
+	for topic := range rq.subscriptions {
+		topics = append(topics, topic)
+	}
+	rq.mu.RUnlock()
+
+	total := 0
+	for _, topic := range topics {
+		processing := processingKey(topic, rq.consumerID)
+		n, err := requeueScript.Run(ctx, rq.client,
+			[]string{processing, claimedAtKey(processing), queueKey(topic), consumersKey(topic)},
+		).Int()
+		if err != nil {
+			return total, fmt.Errorf("failed to requeue in-flight messages: %w", err)
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
 // generateMessageID 生成消息ID
 func generateMessageID() string {
 	return fmt.Sprintf("%d-%d", time.Now().UnixNano(), time.Now().Nanosecond())
 }
-
@@ -0,0 +1,482 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// newTestQueue 创建使用miniredis的队列
+func newTestQueue(t *testing.T) (*RedisQueue, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	rq := NewRedisQueue(client, 1).(*RedisQueue)
+	t.Cleanup(func() { rq.Close() })
+	return rq, client
+}
+
+func TestRedisQueue_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	rq, client := newTestQueue(t)
+
+	for _, id := range []string{"msg-1", "msg-2", "msg-3"} {
+		rq.sendToDeadLetter(&Message{
+			ID:         id,
+			Topic:      "email",
+			Payload:    json.RawMessage(`{"to":"test@example.com"}`),
+			Retries:    3,
+			MaxRetries: 3,
+		}, errors.New("smtp unavailable"))
+	}
+
+	// 列出死信消息，最新失败的在前
+	t.Run("List", func(t *testing.T) {
+		messages, err := rq.ListDeadLetters(ctx, "email", 0)
+		require.NoError(t, err)
+		require.Len(t, messages, 3)
+		assert.Equal(t, "msg-3", messages[0].ID)
+		assert.Equal(t, "smtp unavailable", messages[0].Error)
+		assert.Equal(t, 3, messages[0].Retries)
+		assert.False(t, messages[0].FailedAt.IsZero())
+
+		limited, err := rq.ListDeadLetters(ctx, "email", 2)
+		require.NoError(t, err)
+		assert.Len(t, limited, 2)
+
+		empty, err := rq.ListDeadLetters(ctx, "other", 10)
+		require.NoError(t, err)
+		assert.Empty(t, empty)
+	})
+
+	// 重新投递后从死信队列移除，重试次数归零
+	t.Run("Requeue", func(t *testing.T) {
+		require.NoError(t, rq.RequeueDeadLetter(ctx, "email", "msg-2"))
+
+		messages, err := rq.ListDeadLetters(ctx, "email", 0)
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		for _, msg := range messages {
+			assert.NotEqual(t, "msg-2", msg.ID)
+		}
+
+		items, err := client.LRange(ctx, "queue:email", 0, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		var msg Message
+		require.NoError(t, json.Unmarshal([]byte(items[0]), &msg))
+		assert.Equal(t, "msg-2", msg.ID)
+		assert.Equal(t, "email", msg.Topic)
+		assert.Zero(t, msg.Retries)
+		assert.JSONEq(t, `{"to":"test@example.com"}`, string(msg.Payload))
+	})
+
+	// 消息不存在
+	t.Run("RequeueNotFound", func(t *testing.T) {
+		err := rq.RequeueDeadLetter(ctx, "email", "msg-2")
+		assert.ErrorIs(t, err, ErrMessageNotFound)
+	})
+}
+
+func TestRedisQueue_SubscribeOptions(t *testing.T) {
+	ctx := context.Background()
+
+	// maxRetries=1 的主题重试一次后进入死信队列
+	t.Run("DeadLetterAfterMaxRetries", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		var calls atomic.Int32
+		opts := &SubscribeOptions{
+			Concurrency: 1,
+			Timeout:     time.Second,
+			Retry: apperrors.RetryConfig{
+				MaxAttempts:  1,
+				InitialDelay: 10 * time.Millisecond,
+				MaxDelay:     10 * time.Millisecond,
+				Multiplier:   1,
+			},
+		}
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			calls.Add(1)
+			return errors.New("smtp unavailable")
+		}, opts))
+		require.NoError(t, rq.Publish(ctx, "email", map[string]string{"to": "test@example.com"}))
+
+		var messages []DeadLetterMessage
+		require.Eventually(t, func() bool {
+			var err error
+			messages, err = rq.ListDeadLetters(ctx, "email", 0)
+			return err == nil && len(messages) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, 1, messages[0].Retries)
+		assert.Equal(t, 1, messages[0].MaxRetries)
+		assert.Equal(t, "smtp unavailable", messages[0].Error)
+	})
+
+	// RetryIf 判定为不可重试的错误直接进入死信队列
+	t.Run("NonRetryableError", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		errPermanent := errors.New("invalid address")
+		var calls atomic.Int32
+		opts := DefaultSubscribeOptions
+		opts.Retry.RetryIf = func(err error) bool { return !errors.Is(err, errPermanent) }
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			calls.Add(1)
+			return errPermanent
+		}, &opts))
+		require.NoError(t, rq.Publish(ctx, "email", "payload"))
+
+		require.Eventually(t, func() bool {
+			messages, err := rq.ListDeadLetters(ctx, "email", 0)
+			return err == nil && len(messages) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	// 每个主题的并发数互不影响且不超过配置值
+	t.Run("Concurrency", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		var active, peak, done atomic.Int32
+		handler := func(ctx context.Context, msg *Message) error {
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			active.Add(-1)
+			done.Add(1)
+			return nil
+		}
+		require.NoError(t, rq.Subscribe(ctx, "report", handler, &SubscribeOptions{Concurrency: 3}))
+
+		for i := 0; i < 9; i++ {
+			require.NoError(t, rq.Publish(ctx, "report", i))
+		}
+
+		require.Eventually(t, func() bool { return done.Load() == 9 }, 5*time.Second, 20*time.Millisecond)
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+		assert.Greater(t, peak.Load(), int32(1))
+	})
+}
+
+func TestRedisQueue_AtLeastOnce(t *testing.T) {
+	ctx := context.Background()
+
+	// 处理成功后从处理中列表确认删除
+	t.Run("AckOnSuccess", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+
+		var done atomic.Int32
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			done.Add(1)
+			return nil
+		}, nil))
+		require.NoError(t, rq.Publish(ctx, "email", "payload"))
+
+		processing := processingKey("email", rq.consumerID)
+		require.Eventually(t, func() bool {
+			n, err := client.LLen(ctx, processing).Result()
+			return err == nil && n == 0 && done.Load() == 1
+		}, 5*time.Second, 20*time.Millisecond)
+
+		n, err := client.ZCard(ctx, claimedAtKey(processing)).Result()
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	// 处理器一直不返回（未确认），超过可见性超时后消息被重新投递
+	t.Run("RedeliverUnacked", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		block := make(chan struct{})
+		t.Cleanup(func() { close(block) })
+
+		var calls atomic.Int32
+		ids := make(chan string, 2)
+		opts := &SubscribeOptions{
+			Concurrency:       2,
+			Timeout:           50 * time.Millisecond,
+			VisibilityTimeout: 200 * time.Millisecond,
+		}
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			ids <- msg.ID
+			if calls.Add(1) == 1 {
+				<-block // 模拟卡死的处理器，忽略上下文取消
+			}
+			return nil
+		}, opts))
+		require.NoError(t, rq.Publish(ctx, "email", "payload"))
+
+		require.Eventually(t, func() bool { return calls.Load() == 2 }, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, <-ids, <-ids)
+	})
+
+	// 崩溃消费者遗留在处理中列表的消息由其他消费者接管
+	t.Run("ReclaimFromCrashedConsumer", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+
+		// 模拟已崩溃的消费者：消息已领取但从未确认
+		msg, err := newMessage("email", "payload")
+		require.NoError(t, err)
+		data, err := json.Marshal(msg)
+		require.NoError(t, err)
+
+		crashed := processingKey("email", "crashed")
+		require.NoError(t, client.LPush(ctx, crashed, data).Err())
+		require.NoError(t, client.ZAdd(ctx, claimedAtKey(crashed), redis.Z{
+			Score:  float64(time.Now().Add(-time.Minute).UnixMilli()),
+			Member: data,
+		}).Err())
+		require.NoError(t, client.SAdd(ctx, consumersKey("email"), crashed).Err())
+
+		received := make(chan string, 1)
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, m *Message) error {
+			received <- m.ID
+			return nil
+		}, &SubscribeOptions{VisibilityTimeout: 100 * time.Millisecond}))
+
+		select {
+		case id := <-received:
+			assert.Equal(t, msg.ID, id)
+		case <-time.After(5 * time.Second):
+			t.Fatal("遗留消息未被重新投递")
+		}
+
+		// 空的遗留列表从登记集合中移除
+		require.Eventually(t, func() bool {
+			ok, err := client.SIsMember(ctx, consumersKey("email"), crashed).Result()
+			return err == nil && !ok
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestRedisQueue_Shutdown(t *testing.T) {
+	ctx := context.Background()
+
+	// 关闭时等待处理中的慢处理器完成并确认消息
+	t.Run("WaitsForInFlight", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+
+		started := make(chan struct{})
+		var finished atomic.Bool
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			close(started)
+			select {
+			case <-time.After(300 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			finished.Store(true)
+			return nil
+		}, nil))
+		require.NoError(t, rq.Publish(ctx, "email", "payload"))
+
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("消息未被处理")
+		}
+
+		require.NoError(t, rq.Close())
+		assert.True(t, finished.Load())
+
+		processing := processingKey("email", rq.consumerID)
+		n, err := client.LLen(ctx, processing).Result()
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		n, err = client.LLen(ctx, queueKey("email")).Result()
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	// 等待超时后取消处理器，未完成的消息放回主题队列
+	t.Run("TimeoutRequeues", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+
+		started := make(chan struct{})
+		require.NoError(t, rq.Subscribe(ctx, "email", func(ctx context.Context, msg *Message) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}, &SubscribeOptions{Timeout: time.Minute}))
+		require.NoError(t, rq.Publish(ctx, "email", "payload"))
+
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("消息未被处理")
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		err := rq.Shutdown(shutdownCtx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		processing := processingKey("email", rq.consumerID)
+		n, err := client.LLen(ctx, processing).Result()
+		require.NoError(t, err)
+		assert.Zero(t, n)
+
+		items, err := client.LRange(ctx, queueKey("email"), 0, -1).Result()
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+
+		var msg Message
+		require.NoError(t, json.Unmarshal([]byte(items[0]), &msg))
+		assert.Equal(t, "email", msg.Topic)
+
+		ok, err := client.SIsMember(ctx, consumersKey("email"), processing).Result()
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
+
+func TestRedisQueue_SchemaVersions(t *testing.T) {
+	ctx := context.Background()
+
+	type userCreatedV1 struct {
+		Name string `json:"name"`
+	}
+	type userCreatedV2 struct {
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	}
+
+	// 同一主题发布v1和v2，各自交给对应版本的处理器
+	t.Run("RouteByVersion", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		v1 := make(chan userCreatedV1, 1)
+		v2 := make(chan userCreatedV2, 1)
+		require.NoError(t, rq.Subscribe(ctx, "user.created", func(ctx context.Context, msg *Message) error {
+			var payload userCreatedV1
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			v1 <- payload
+			return nil
+		}, &SubscribeOptions{Versions: []int{1}}))
+		require.NoError(t, rq.Subscribe(ctx, "user.created", func(ctx context.Context, msg *Message) error {
+			var payload userCreatedV2
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			v2 <- payload
+			return nil
+		}, &SubscribeOptions{Versions: []int{2}}))
+
+		require.NoError(t, rq.PublishTyped(ctx, "user.created", 1, userCreatedV1{Name: "Ada Lovelace"}))
+		require.NoError(t, rq.PublishTyped(ctx, "user.created", 2, userCreatedV2{FirstName: "Ada", LastName: "Lovelace"}))
+
+		for i := 0; i < 2; i++ {
+			select {
+			case payload := <-v1:
+				assert.Equal(t, "Ada Lovelace", payload.Name)
+			case payload := <-v2:
+				assert.Equal(t, "Ada", payload.FirstName)
+				assert.Equal(t, "Lovelace", payload.LastName)
+			case <-time.After(5 * time.Second):
+				t.Fatal("消息未被处理")
+			}
+		}
+		assert.Empty(t, v1)
+		assert.Empty(t, v2)
+	})
+
+	// 没有处理器支持的版本默认进入死信队列
+	t.Run("UnknownVersionDeadLetter", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+
+		var calls atomic.Int32
+		require.NoError(t, rq.Subscribe(ctx, "user.created", func(ctx context.Context, msg *Message) error {
+			calls.Add(1)
+			return nil
+		}, &SubscribeOptions{Versions: []int{1}}))
+		require.NoError(t, rq.PublishTyped(ctx, "user.created", 2, userCreatedV2{FirstName: "Ada"}))
+
+		var messages []DeadLetterMessage
+		require.Eventually(t, func() bool {
+			var err error
+			messages, err = rq.ListDeadLetters(ctx, "user.created", 0)
+			return err == nil && len(messages) == 1
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, 2, messages[0].SchemaVersion)
+		assert.Contains(t, messages[0].Error, ErrUnsupportedVersion.Error())
+		assert.Zero(t, calls.Load())
+	})
+
+	// 配置跳过策略时确认并丢弃不支持的版本
+	t.Run("UnknownVersionSkip", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+
+		handled := make(chan int, 2)
+		require.NoError(t, rq.Subscribe(ctx, "user.created", func(ctx context.Context, msg *Message) error {
+			handled <- msg.SchemaVersion
+			return nil
+		}, &SubscribeOptions{Concurrency: 1, Versions: []int{1}, UnknownVersion: UnknownVersionSkip}))
+		require.NoError(t, rq.PublishTyped(ctx, "user.created", 2, userCreatedV2{FirstName: "Ada"}))
+		require.NoError(t, rq.PublishTyped(ctx, "user.created", 1, userCreatedV1{Name: "Ada"}))
+
+		select {
+		case version := <-handled:
+			assert.Equal(t, 1, version)
+		case <-time.After(5 * time.Second):
+			t.Fatal("消息未被处理")
+		}
+
+		require.Eventually(t, func() bool {
+			n, err := client.LLen(ctx, processingKey("user.created", rq.consumerID)).Result()
+			return err == nil && n == 0
+		}, 5*time.Second, 20*time.Millisecond)
+		messages, err := rq.ListDeadLetters(ctx, "user.created", 0)
+		require.NoError(t, err)
+		assert.Empty(t, messages)
+	})
+
+	// 版本号必须为正数
+	t.Run("InvalidVersion", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+		assert.Error(t, rq.PublishTyped(ctx, "user.created", 0, "payload"))
+	})
+}
+
+func TestRedisQueue_Ping(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Healthy", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+		assert.NoError(t, rq.Ping(ctx))
+	})
+
+	// Redis不可达时返回错误
+	t.Run("RedisDown", func(t *testing.T) {
+		rq, client := newTestQueue(t)
+		require.NoError(t, client.Close())
+		assert.Error(t, rq.Ping(ctx))
+	})
+
+	// 关闭后返回 ErrQueueClosed
+	t.Run("Closed", func(t *testing.T) {
+		rq, _ := newTestQueue(t)
+		require.NoError(t, rq.Shutdown(ctx))
+		assert.ErrorIs(t, rq.Ping(ctx), ErrQueueClosed)
+	})
+}
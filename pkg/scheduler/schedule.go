@@ -0,0 +1,201 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 任务的执行时间表
+type Schedule interface {
+	// Next 返回晚于t的下一次执行时间，没有下一次执行时间时返回零值
+	Next(t time.Time) time.Time
+}
+
+// 预定义的时间表
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse 解析时间表，支持以下格式：
+//   - 标准5段cron表达式「分 时 日 月 周」，每段支持 *、数字、范围 a-b、步长 */n 和 a-b/n、逗号分隔的列表，
+//     周的取值为0-6（0为周日，7也表示周日）；日和周都不以 * 开头时满足其一即执行
+//   - @yearly、@monthly、@weekly、@daily、@hourly 等预定义时间表
+//   - @every <间隔>，如 @every 30s，从上次执行结束开始计算间隔
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if interval, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(interval))
+		if err != nil {
+			return nil, fmt.Errorf("无效的间隔 %q: %w", interval, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("间隔必须大于0: %q", interval)
+		}
+		return everySchedule(d), nil
+	}
+
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron表达式需要5段（分 时 日 月 周），当前为%d段: %q", len(fields), spec)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("分钟 %w", err)
+	}
+	if s.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("小时 %w", err)
+	}
+	if s.dom, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("日 %w", err)
+	}
+	if s.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("月 %w", err)
+	}
+	if s.dow, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("周 %w", err)
+	}
+	// 7和0都表示周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = strings.HasPrefix(fields[2], "*")
+	s.dowAny = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// MustParse 解析时间表，格式无效时panic，用于固定的时间表
+func MustParse(spec string) Schedule {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField 解析cron表达式的一段，返回取值的位集合
+func parseField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		start, end := lo, hi
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if start, err = parseValue(from, lo, hi); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseValue(to, lo, hi); err != nil {
+					return 0, err
+				}
+				if end < start {
+					return 0, fmt.Errorf("范围无效: %q", rng)
+				}
+			} else if hasStep {
+				// a/n 表示从a开始到最大值
+				end = hi
+			}
+		}
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("步长无效: %q", stepStr)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// parseValue 解析单个取值并检查范围
+func parseValue(s string, lo, hi int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("取值无效: %q", s)
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("取值 %d 超出范围 %d-%d", v, lo, hi)
+	}
+	return v, nil
+}
+
+// cronSchedule cron表达式时间表，各段为取值的位集合
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// 查找下一次执行时间的最大范围，超过后认为表达式永远不会匹配（如2月30日）
+const maxSearchYears = 5
+
+// Next 实现 Schedule，按t所在时区计算
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay 判断日期是否匹配，日和周都有限制时满足其一即可
+func (s *cronSchedule) matchDay(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// has 判断位集合是否包含v
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+// everySchedule 固定间隔时间表
+type everySchedule time.Duration
+
+// Next 实现 Schedule
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
@@ -0,0 +1,233 @@
+// Package scheduler 定时任务调度，按cron表达式周期执行任务，多实例部署时通过分布式锁保证同一任务只有一个实例执行
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/vadxq/go-rest-starter/pkg/lock"
+)
+
+// ErrDuplicateJob 同名任务已注册
+var ErrDuplicateJob = errors.New("scheduler: duplicate job")
+
+// Job 定时任务，ctx在调度器停止时取消
+type Job func(ctx context.Context) error
+
+// Config 调度器配置
+type Config struct {
+	// LockTTL 分布式锁的过期时间，持有期间自动续期；实例崩溃后锁在该时间后释放
+	LockTTL time.Duration
+	// Timeout 单次执行的超时，<=0时不限制
+	Timeout time.Duration
+}
+
+// DefaultConfig 默认调度器配置
+var DefaultConfig = Config{
+	LockTTL: 30 * time.Second,
+	Timeout: 10 * time.Minute,
+}
+
+// entry 已注册的任务
+type entry struct {
+	name     string
+	schedule Schedule
+	job      Job
+}
+
+// Scheduler 定时任务调度器
+// 同一任务的执行不会重叠：上一次执行结束后才计算下一次执行时间，错过的执行不补跑
+// 配置了分布式锁时，执行完成后锁保留到接近下一次执行时间，时钟落后的实例不会重复执行同一次调度
+type Scheduler struct {
+	config Config
+	locker *lock.Locker // 为空时不加锁，适用于单实例部署
+
+	mu      sync.Mutex
+	entries []*entry
+	ctx     context.Context // 启动后有效，停止时取消
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewScheduler 创建调度器，locker为空时每个实例都会执行任务
+func NewScheduler(locker *lock.Locker, config *Config) *Scheduler {
+	if config == nil {
+		config = &DefaultConfig
+	}
+	cfg := *config
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = DefaultConfig.LockTTL
+	}
+
+	return &Scheduler{config: cfg, locker: locker}
+}
+
+// Register 注册任务，spec格式见 Parse；name在多个实例间标识同一任务，也用于日志
+// 调度器启动后注册的任务立即开始调度
+func (s *Scheduler) Register(name, spec string, job Job) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("定时任务 %s 的时间表无效: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.name == name {
+			return fmt.Errorf("%w: %s", ErrDuplicateJob, name)
+		}
+	}
+
+	e := &entry{name: name, schedule: schedule, job: job}
+	s.entries = append(s.entries, e)
+	if s.ctx != nil && s.ctx.Err() == nil {
+		s.startEntry(e)
+	}
+	return nil
+}
+
+// Len 返回已注册的任务数量
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Start 开始调度所有任务，重复调用无效
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, e := range s.entries {
+		s.startEntry(e)
+	}
+}
+
+// Stop 停止调度，取消执行中任务的上下文并等待其结束，ctx到期时不再等待
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待定时任务结束超时: %w", ctx.Err())
+	}
+}
+
+// startEntry 启动任务的调度协程，调用方需持有 s.mu
+func (s *Scheduler) startEntry(e *entry) {
+	s.wg.Add(1)
+	go s.loop(s.ctx, e)
+}
+
+// loop 按时间表等待并执行任务，直到调度器停止
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.wg.Done()
+
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			slog.Warn("定时任务没有下一次执行时间，停止调度", "job", e.name)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		s.run(ctx, e)
+	}
+}
+
+// run 执行一次任务，配置了分布式锁时只有获取到锁的实例执行
+func (s *Scheduler) run(ctx context.Context, e *entry) {
+	if s.locker != nil {
+		l, err := s.locker.Acquire(ctx, "scheduler:"+e.name, s.config.LockTTL)
+		if err != nil {
+			if !errors.Is(err, lock.ErrNotAcquired) {
+				slog.Warn("获取定时任务锁失败，跳过本次执行", "job", e.name, "error", err)
+			}
+			return
+		}
+		defer func() {
+			if err := l.ReleaseAfter(context.Background(), lockHold(e.schedule.Next(time.Now()))); err != nil {
+				slog.Warn("释放定时任务锁失败", "job", e.name, "error", err)
+			}
+		}()
+
+		// 锁丢失后其他实例可能开始执行，取消本次执行
+		lockCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-l.Lost():
+				cancel()
+			case <-lockCtx.Done():
+			}
+		}()
+		ctx = lockCtx
+	}
+
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	if err := runJob(ctx, e.job); err != nil {
+		slog.Error("定时任务执行失败", "job", e.name, "error", err, "duration", time.Since(start))
+		return
+	}
+	slog.Debug("定时任务执行完成", "job", e.name, "duration", time.Since(start))
+}
+
+// maxLockHoldMargin 执行完成后保留锁时，在下一次执行时间之前提前释放的最大余量
+const maxLockHoldMargin = time.Second
+
+// lockHold 返回执行完成后锁的保留时间：保留到下一次执行时间之前，
+// 提前释放的余量为 maxLockHoldMargin 与剩余时间一半中的较小值，保证本实例在下一次执行时能重新获取锁
+// 没有下一次执行时间时立即释放
+func lockHold(next time.Time) time.Duration {
+	if next.IsZero() {
+		return 0
+	}
+	until := time.Until(next)
+	return until - min(maxLockHoldMargin, until/2)
+}
+
+// runJob 执行任务，将panic转换为错误
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return job(ctx)
+}
@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vadxq/go-rest-starter/pkg/lock"
+)
+
+func TestScheduler_RunAndStop(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("tick", "@every 10ms", func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	assert.Equal(t, 1, s.Len())
+
+	// 启动前不执行
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), runs.Load())
+
+	s.Start()
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+
+	// 停止后不再执行
+	require.NoError(t, s.Stop(context.Background()))
+	stopped := runs.Load()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestScheduler_Register(t *testing.T) {
+	s := NewScheduler(nil, nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	require.NoError(t, s.Register("job", "*/5 * * * *", noop))
+	assert.ErrorIs(t, s.Register("job", "@hourly", noop), ErrDuplicateJob)
+	assert.Error(t, s.Register("invalid", "* * *", noop))
+	assert.Equal(t, 1, s.Len())
+
+	// 启动后注册的任务立即开始调度
+	s.Start()
+	defer s.Stop(context.Background())
+
+	ran := make(chan struct{}, 1)
+	require.NoError(t, s.Register("late", "@every 10ms", func(ctx context.Context) error {
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+		return nil
+	}))
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("启动后注册的任务未执行")
+	}
+}
+
+func TestScheduler_RecoverPanic(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	var runs atomic.Int32
+	require.NoError(t, s.Register("panic", "@every 10ms", func(ctx context.Context) error {
+		runs.Add(1)
+		panic("boom")
+	}))
+	s.Start()
+	defer s.Stop(context.Background())
+
+	// panic不会中断调度
+	assert.Eventually(t, func() bool { return runs.Load() >= 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_StopCancelsRunningJob(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	started := make(chan struct{})
+	var cancelled atomic.Bool
+	require.NoError(t, s.Register("long", "@every 10ms", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		cancelled.Store(true)
+		return ctx.Err()
+	}))
+	s.Start()
+
+	<-started
+	require.NoError(t, s.Stop(context.Background()))
+	assert.True(t, cancelled.Load())
+}
+
+func TestScheduler_StopTimeout(t *testing.T) {
+	s := NewScheduler(nil, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	require.NoError(t, s.Register("stuck", "@every 10ms", func(ctx context.Context) error {
+		close(started)
+		<-release // 忽略取消
+		return nil
+	}))
+	s.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, s.Stop(context.Background()))
+}
+
+// newRealtimeRedis 启动miniredis并按实际经过的时间推进其时钟，使锁的过期时间生效
+func newRealtimeRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	done := make(chan struct{})
+	t.Cleanup(func() { close(done) })
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case now := <-ticker.C:
+				mr.FastForward(now.Sub(last))
+				last = now
+			case <-done:
+				return
+			}
+		}
+	}()
+	return mr, client
+}
+
+// skewedSchedule 与实际时钟对齐的固定间隔时间表，offset模拟实例的时钟落后
+type skewedSchedule struct {
+	interval, offset time.Duration
+}
+
+func (s skewedSchedule) Next(t time.Time) time.Time {
+	return t.Add(-s.offset).Truncate(s.interval).Add(s.interval + s.offset)
+}
+
+func TestScheduler_DistributedLock(t *testing.T) {
+	mr, client := newRealtimeRedis(t)
+
+	// 两个实例调度同一任务，同一时间只有一个实例执行
+	var running, maxRunning, runs atomic.Int32
+	job := func(ctx context.Context) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		runs.Add(1)
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	}
+
+	instances := []*Scheduler{
+		NewScheduler(lock.NewLocker(client), nil),
+		NewScheduler(lock.NewLocker(client), nil),
+	}
+	for _, s := range instances {
+		require.NoError(t, s.Register("cleanup", "@every 5ms", job))
+		s.Start()
+	}
+
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, 2*time.Second, 5*time.Millisecond)
+	for _, s := range instances {
+		require.NoError(t, s.Stop(context.Background()))
+	}
+
+	assert.Equal(t, int32(1), maxRunning.Load())
+	// 锁在保留时间后过期
+	assert.Eventually(t, func() bool { return !mr.Exists("lock:scheduler:cleanup") }, time.Second, 5*time.Millisecond)
+}
+
+func TestScheduler_ClockSkew(t *testing.T) {
+	_, client := newRealtimeRedis(t)
+
+	// 第二个实例的时钟落后30ms，在前一个实例执行完成后才到达同一次调度
+	const interval = 100 * time.Millisecond
+	var mu sync.Mutex
+	runs := map[time.Time]int{}
+	job := func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs[time.Now().Truncate(interval)]++
+		return nil
+	}
+
+	var instances []*Scheduler
+	for _, offset := range []time.Duration{0, 30 * time.Millisecond} {
+		s := NewScheduler(lock.NewLocker(client), nil)
+		s.entries = append(s.entries, &entry{name: "report", schedule: skewedSchedule{interval: interval, offset: offset}, job: job})
+		s.Start()
+		instances = append(instances, s)
+	}
+
+	time.Sleep(5*interval + interval/2)
+	for _, s := range instances {
+		require.NoError(t, s.Stop(context.Background()))
+	}
+
+	// 每次调度只执行一次
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, len(runs), 4)
+	for tick, n := range runs {
+		assert.Equal(t, 1, n, tick)
+	}
+}
+
+func TestLockHold(t *testing.T) {
+	assert.Zero(t, lockHold(time.Time{}))
+
+	// 保留到下一次执行前，余量不超过1秒
+	assert.InDelta(t, float64(59*time.Second), float64(lockHold(time.Now().Add(time.Minute))), float64(10*time.Millisecond))
+	assert.InDelta(t, float64(50*time.Millisecond), float64(lockHold(time.Now().Add(100*time.Millisecond))), float64(10*time.Millisecond))
+}
+
+func TestRunJob(t *testing.T) {
+	assert.NoError(t, runJob(context.Background(), func(ctx context.Context) error { return nil }))
+
+	errJob := errors.New("failed")
+	assert.ErrorIs(t, runJob(context.Background(), func(ctx context.Context) error { return errJob }), errJob)
+
+	err := runJob(context.Background(), func(ctx context.Context) error { panic("boom") })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "panic: boom")
+}
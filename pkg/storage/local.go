@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 本地签名地址的查询参数
+const (
+	paramExpires   = "expires"
+	paramSignature = "signature"
+)
+
+// LocalConfig 本地磁盘存储配置
+type LocalConfig struct {
+	Dir     string // 存储根目录，为空时使用 DefaultLocalConfig.Dir
+	BaseURL string // 访问地址前缀，如 /uploads 或 https://cdn.example.com，为空时使用 DefaultLocalConfig.BaseURL
+	// SigningKey 签名地址使用的HMAC密钥，为空时 SignedURL 返回 ErrSigningNotConfigured
+	// 配置后 Handler 只提供带有效签名的访问，文件不再公开
+	SigningKey string
+}
+
+// DefaultLocalConfig 默认本地存储配置
+var DefaultLocalConfig = LocalConfig{
+	Dir:     "uploads",
+	BaseURL: "/uploads",
+}
+
+// LocalStorage 将文件保存到本地目录
+type LocalStorage struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+	now        func() time.Time
+}
+
+// NewLocalStorage 创建本地存储，目录不存在时自动创建，config为空时使用默认配置
+func NewLocalStorage(config *LocalConfig) (*LocalStorage, error) {
+	if config == nil {
+		config = &DefaultLocalConfig
+	}
+
+	s := &LocalStorage{dir: config.Dir, baseURL: config.BaseURL, signingKey: []byte(config.SigningKey), now: time.Now}
+	if s.dir == "" {
+		s.dir = DefaultLocalConfig.Dir
+	}
+	if s.baseURL == "" {
+		s.baseURL = DefaultLocalConfig.BaseURL
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	return s, nil
+}
+
+// Dir 存储根目录
+func (s *LocalStorage) Dir() string {
+	return s.dir
+}
+
+// Put 先写入同目录下的临时文件再重命名，读取方不会看到写了一半的文件
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("创建存储目录失败: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, &contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	return joinURL(s.baseURL, key), nil
+}
+
+// Get 打开文件，文件不存在时返回 ErrNotFound
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return f, nil
+}
+
+// Delete 删除文件，文件不存在时不返回错误
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// SignedURL 生成带过期时间和HMAC签名的访问地址，由 Handler 校验
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if err := validateExpiry(expires); err != nil {
+		return "", err
+	}
+	if len(s.signingKey) == 0 {
+		return "", ErrSigningNotConfigured
+	}
+
+	expiresAt := strconv.FormatInt(s.now().Add(expires).Unix(), 10)
+	query := url.Values{
+		paramExpires:   {expiresAt},
+		paramSignature: {s.signature(key, expiresAt)},
+	}
+	return joinURL(s.baseURL, key) + "?" + query.Encode(), nil
+}
+
+// Verify 校验签名地址的查询参数，签名不匹配或已过期时返回 ErrInvalidSignature
+func (s *LocalStorage) Verify(key string, query url.Values) error {
+	if len(s.signingKey) == 0 {
+		return ErrSigningNotConfigured
+	}
+
+	expiresAt := query.Get(paramExpires)
+	unix, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(query.Get(paramSignature)), []byte(s.signature(key, expiresAt))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Handler 提供存储目录中文件访问的处理器，请求路径为文件键，挂载到子路径时需配合 http.StripPrefix 使用
+// 配置了签名密钥时所有请求都需携带有效签名，缺少签名、签名无效或已过期时返回403；不列出目录内容
+func (s *LocalStorage) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 以.开头的路径段是写入中的临时文件或隐藏文件，不对外提供
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if validateKey(key) != nil || strings.Contains("/"+key, "/.") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if len(s.signingKey) > 0 {
+			if err := s.Verify(key, r.URL.Query()); err != nil {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+		}
+
+		f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+	})
+}
+
+// signature 计算文件键和过期时间的HMAC签名
+func (s *LocalStorage) signature(key, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expiresAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// contextReader 上下文结束后停止读取，避免客户端断开后继续写入大文件
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodyBytes 读取对象存储错误响应的上限
+const maxErrorBodyBytes = 4 << 10
+
+// AWS Signature V4 相关常量
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// S3Config S3兼容对象存储配置，适用于AWS S3、MinIO、Cloudflare R2等
+type S3Config struct {
+	Endpoint        string       // 服务地址，如 https://s3.us-east-1.amazonaws.com 或 http://localhost:9000
+	Region          string       // 区域，为空时使用 us-east-1
+	Bucket          string       // 存储桶
+	AccessKeyID     string       // 访问密钥ID
+	SecretAccessKey string       // 访问密钥
+	PathStyle       bool         // 使用 endpoint/bucket/key 形式的地址，MinIO等不支持虚拟主机形式时开启
+	BaseURL         string       // 公开访问地址前缀，如CDN地址，为空时使用对象地址
+	HTTPClient      *http.Client // 为空时使用 http.DefaultClient
+}
+
+// S3Storage 将文件保存到S3兼容的对象存储，请求使用AWS Signature V4签名
+type S3Storage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
+	baseURL   string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Storage 创建S3存储
+func NewS3Storage(config *S3Config) (*S3Storage, error) {
+	if config == nil || config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("S3存储需要配置服务地址和存储桶")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3存储需要配置访问密钥")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("无效的S3服务地址: %s", config.Endpoint)
+	}
+
+	s := &S3Storage{
+		endpoint:  endpoint,
+		region:    config.Region,
+		bucket:    config.Bucket,
+		accessKey: config.AccessKeyID,
+		secretKey: config.SecretAccessKey,
+		pathStyle: config.PathStyle,
+		baseURL:   config.BaseURL,
+		client:    config.HTTPClient,
+		now:       time.Now,
+	}
+	if s.region == "" {
+		s.region = "us-east-1"
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	return s, nil
+}
+
+// Put 上传对象，文件内容读入内存后计算签名所需的哈希，只适合头像这类小文件
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("创建上传请求失败: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if err := s.do(req, body, http.StatusOK); err != nil {
+		return "", fmt.Errorf("上传文件失败: %w", err)
+	}
+
+	if s.baseURL != "" {
+		return joinURL(s.baseURL, key), nil
+	}
+	return s.objectURL(key), nil
+}
+
+// Get 下载对象，对象不存在时返回 ErrNotFound
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建下载请求失败: %w", err)
+	}
+
+	resp, err := s.send(req, nil, http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// SignedURL 生成预签名的对象下载地址，签名放在查询参数中，持有地址即可在有效期内访问
+// 预签名地址直接访问对象存储，不使用 BaseURL
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if err := validateExpiry(expires); err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s.objectURL(key))
+	if err != nil {
+		return "", fmt.Errorf("生成对象地址失败: %w", err)
+	}
+	return s.presign(u, expires, s.now()), nil
+}
+
+// Delete 删除对象，对象不存在时S3同样返回成功
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return fmt.Errorf("创建删除请求失败: %w", err)
+	}
+
+	if err := s.do(req, nil, http.StatusNoContent, http.StatusOK); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	return nil
+}
+
+// objectURL 对象地址，按配置使用路径形式或虚拟主机形式
+func (s *S3Storage) objectURL(key string) string {
+	u := *s.endpoint
+	path := strings.TrimRight(u.Path, "/")
+	if s.pathStyle {
+		path += "/" + escapePath(s.bucket)
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path, u.RawPath = "", ""
+	return u.String() + path + "/" + escapePath(key)
+}
+
+// do 签名并发送请求，响应状态码不在expected中时返回包含响应内容的错误
+func (s *S3Storage) do(req *http.Request, body []byte, expected ...int) error {
+	resp, err := s.send(req, body, expected...)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// send 签名并发送请求，状态码在expected中时返回响应，由调用方关闭响应体
+func (s *S3Storage) send(req *http.Request, body []byte, expected ...int) (*http.Response, error) {
+	s.sign(req, body, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(expected, resp.StatusCode) {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, strings.TrimSpace(string(detail)))
+	}
+	return nil, fmt.Errorf("对象存储返回状态码 %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// sign 按AWS Signature V4为请求添加 Authorization 头，签名覆盖Host和请求上已设置的全部请求头
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	signedHeaders, signature := s.signature(req.Method, req.URL, headers, payloadHash, now)
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, s.accessKey, s.scope(now), signedHeaders, signature))
+}
+
+// presign 生成预签名的GET地址，签名参数放在查询字符串中，只签名Host请求头
+func (s *S3Storage) presign(u *url.URL, expires time.Duration, now time.Time) string {
+	now = now.UTC()
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", signAlgorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	u.RawQuery = canonicalQuery(query)
+
+	_, signature := s.signature(http.MethodGet, u, map[string]string{"host": u.Host}, unsignedPayload, now)
+	u.RawQuery += "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+// signature 计算规范请求的签名，返回签名的请求头列表和十六进制签名
+func (s *S3Storage) signature(method string, u *url.URL, headers map[string]string, payloadHash string, now time.Time) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		u.EscapedPath(),
+		canonicalQuery(u.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		now.Format(amzDateFormat),
+		s.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	day := now.Format("20060102")
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), day)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// scope 签名凭证范围
+func (s *S3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// canonicalQuery 按参数名排序并转义的查询字符串
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath 逐段转义路径，保留分隔符/
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode 按RFC 3986转义，只保留非保留字符，与签名规范要求一致
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
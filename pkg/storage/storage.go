@@ -0,0 +1,93 @@
+// Package storage 文件存储，支持本地磁盘和S3兼容的对象存储
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// 支持的存储驱动
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// 存储错误
+var (
+	// ErrInvalidKey 文件键为空、以/开头或包含 . 和 .. 路径段
+	ErrInvalidKey = errors.New("无效的文件键")
+	// ErrNotFound 文件不存在
+	ErrNotFound = errors.New("文件不存在")
+	// ErrSigningNotConfigured 未配置签名密钥，无法生成签名地址
+	ErrSigningNotConfigured = errors.New("未配置签名密钥")
+	// ErrInvalidSignature 签名地址无效或已过期
+	ErrInvalidSignature = errors.New("签名无效或已过期")
+)
+
+// MaxSignedURLExpiry 签名地址的最长有效期，与S3预签名地址的上限一致
+const MaxSignedURLExpiry = 7 * 24 * time.Hour
+
+// Storage 文件存储接口，实现可被多个goroutine并发使用
+type Storage interface {
+	// Put 保存文件并返回公开访问地址，键已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (string, error)
+	// Get 读取文件，调用方负责关闭返回的读取器；文件不存在时返回 ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// SignedURL 生成在expires后失效的访问地址，用于临时访问不公开的文件
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// Config 存储配置
+type Config struct {
+	Driver string // 存储驱动，local或s3，为空时使用local
+	Local  LocalConfig
+	S3     S3Config
+}
+
+// New 按驱动创建存储，config为空时使用默认本地存储配置
+func New(config *Config) (Storage, error) {
+	if config == nil {
+		return NewLocalStorage(nil)
+	}
+
+	switch config.Driver {
+	case "", DriverLocal:
+		return NewLocalStorage(&config.Local)
+	case DriverS3:
+		return NewS3Storage(&config.S3)
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", config.Driver)
+	}
+}
+
+// validateKey 校验文件键，键使用/分隔路径段，不能逃逸出存储根目录
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// validateExpiry 校验签名地址有效期
+func validateExpiry(expires time.Duration) error {
+	if expires <= 0 || expires > MaxSignedURLExpiry {
+		return fmt.Errorf("签名地址有效期必须在0到%s之间: %s", MaxSignedURLExpiry, expires)
+	}
+	return nil
+}
+
+// joinURL 拼接访问地址前缀和文件键，键的每个路径段分别转义
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + escapePath(key)
+}
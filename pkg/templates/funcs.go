@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultFuncs 所有模板可用的辅助函数
+// 不提供将字符串标记为安全HTML的函数，HTML模板中的数据始终按上下文转义
+var DefaultFuncs = map[string]interface{}{
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"join":       strings.Join,
+	"default":    defaultValue,
+	"formatTime": formatTime,
+}
+
+// defaultValue 值为空时返回def，用法 {{.Name | default "用户"}}
+func defaultValue(def, value interface{}) interface{} {
+	if value == nil {
+		return def
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return def
+	}
+	return value
+}
+
+// formatTime 按布局格式化时间，零值返回空字符串，用法 {{formatTime .ExpiresAt "2006-01-02 15:04"}}
+func formatTime(t interface{}, layout string) (string, error) {
+	switch v := t.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return "", nil
+		}
+		return v.Format(layout), nil
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return "", nil
+		}
+		return v.Format(layout), nil
+	default:
+		return "", fmt.Errorf("formatTime 不支持的类型 %T", t)
+	}
+}
@@ -0,0 +1,256 @@
+// Package templates 从文件系统（通常是 embed.FS）加载并缓存 html/template 和 text/template 模板
+//
+// 以 .html 或 .htm 结尾的文件按 html/template 解析，输出按上下文自动转义，其他文件按 text/template 解析。
+// 模板名称为文件相对于根目录的路径，如 "email/verification.html"。
+// 公共模板目录（默认 "partials"）中的文件与每个同类模板一起解析，可通过 {{template "文件名"}} 或其中的 {{define}} 引用。
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// ErrNotFound 模板不存在
+var ErrNotFound = errors.New("模板不存在")
+
+// Config 模板引擎配置
+type Config struct {
+	// Partials 公共模板目录，为空时使用 DefaultConfig.Partials；目录不存在时没有公共模板
+	Partials string
+	// Funcs 额外的模板函数，与 DefaultFuncs 合并，同名时覆盖默认函数
+	Funcs map[string]interface{}
+	// Reload 每次渲染时重新读取并解析模板，用于开发时修改模板文件后立即生效，不应在生产环境开启
+	Reload bool
+}
+
+// DefaultConfig 默认模板引擎配置
+var DefaultConfig = Config{
+	Partials: "partials",
+}
+
+// executor 解析后的模板
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// Engine 模板引擎，可被多个goroutine并发使用
+type Engine struct {
+	fsys   fs.FS
+	config Config
+	funcs  map[string]interface{}
+	cache  map[string]executor // 创建时解析的模板，开启 Reload 时只用于 Names
+}
+
+// New 创建模板引擎并解析fsys中的全部模板，任一模板解析失败时返回错误
+func New(fsys fs.FS, config *Config) (*Engine, error) {
+	if config == nil {
+		config = &DefaultConfig
+	}
+	cfg := *config
+	if cfg.Partials == "" {
+		cfg.Partials = DefaultConfig.Partials
+	}
+
+	funcs := make(map[string]interface{}, len(DefaultFuncs)+len(cfg.Funcs))
+	for name, fn := range DefaultFuncs {
+		funcs[name] = fn
+	}
+	for name, fn := range cfg.Funcs {
+		funcs[name] = fn
+	}
+
+	e := &Engine{fsys: fsys, config: cfg, funcs: funcs}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// load 解析全部模板
+func (e *Engine) load() error {
+	names, err := e.names()
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[string]executor, len(names))
+	for _, name := range names {
+		tmpl, err := e.parse(name)
+		if err != nil {
+			return err
+		}
+		cache[name] = tmpl
+	}
+
+	e.cache = cache
+	return nil
+}
+
+// names 列出可渲染的模板，不包括公共模板
+func (e *Engine) names() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(e.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p == e.config.Partials {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		names = append(names, p)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取模板目录失败: %w", err)
+	}
+	return names, nil
+}
+
+// partials 列出与name同类的公共模板
+func (e *Engine) partials(name string) ([]string, error) {
+	entries, err := fs.ReadDir(e.fsys, e.config.Partials)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取公共模板目录失败: %w", err)
+	}
+
+	var partials []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isHTML(entry.Name()) == isHTML(name) {
+			partials = append(partials, path.Join(e.config.Partials, entry.Name()))
+		}
+	}
+	return partials, nil
+}
+
+// parse 解析模板及其公共模板
+func (e *Engine) parse(name string) (executor, error) {
+	files, err := e.partials(name)
+	if err != nil {
+		return nil, err
+	}
+	files = append([]string{name}, files...)
+
+	sources := make([]string, len(files))
+	for i, file := range files {
+		data, err := fs.ReadFile(e.fsys, file)
+		if errors.Is(err, fs.ErrNotExist) && i == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取模板 %s 失败: %w", file, err)
+		}
+		sources[i] = string(data)
+	}
+
+	// 主模板解析为根模板，公共模板以文件名命名
+	if isHTML(name) {
+		tmpl := htmltemplate.New(name).Funcs(e.funcs).Option("missingkey=error")
+		for i, file := range files {
+			t := tmpl
+			if i > 0 {
+				t = tmpl.New(path.Base(file))
+			}
+			if _, err := t.Parse(sources[i]); err != nil {
+				return nil, fmt.Errorf("解析模板 %s 失败: %w", file, err)
+			}
+		}
+		return tmpl, nil
+	}
+
+	tmpl := texttemplate.New(name).Funcs(e.funcs).Option("missingkey=error")
+	for i, file := range files {
+		t := tmpl
+		if i > 0 {
+			t = tmpl.New(path.Base(file))
+		}
+		if _, err := t.Parse(sources[i]); err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %w", file, err)
+		}
+	}
+	return tmpl, nil
+}
+
+// lookup 返回解析后的模板，开启 Reload 时重新解析
+func (e *Engine) lookup(name string) (executor, error) {
+	if e.config.Reload && !e.isPartial(name) {
+		return e.parse(name)
+	}
+
+	tmpl, ok := e.cache[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return tmpl, nil
+}
+
+// Render 使用data渲染模板并写入w，渲染失败时不写入任何内容
+func (e *Engine) Render(w io.Writer, name string, data interface{}) error {
+	tmpl, err := e.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("渲染模板 %s 失败: %w", name, err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// RenderString 使用data渲染模板并返回结果
+func (e *Engine) RenderString(name string, data interface{}) (string, error) {
+	var buf strings.Builder
+	if err := e.Render(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Has 模板是否存在，开启 Reload 时检查文件系统
+func (e *Engine) Has(name string) bool {
+	if e.config.Reload {
+		info, err := fs.Stat(e.fsys, name)
+		return err == nil && !info.IsDir() && !e.isPartial(name)
+	}
+
+	_, ok := e.cache[name]
+	return ok
+}
+
+// Names 按名称排序的全部模板，不包括创建后新增的模板文件
+func (e *Engine) Names() []string {
+	names := make([]string, 0, len(e.cache))
+	for name := range e.cache {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isPartial 是否为公共模板目录中的文件
+func (e *Engine) isPartial(name string) bool {
+	return strings.HasPrefix(name, e.config.Partials+"/")
+}
+
+// isHTML 按扩展名判断是否为HTML模板
+func isHTML(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm":
+		return true
+	}
+	return false
+}
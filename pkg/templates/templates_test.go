@@ -0,0 +1,122 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFS 测试模板，包含HTML和纯文本公共模板
+var testFS = fstest.MapFS{
+	"email/welcome.html":   {Data: []byte(`{{template "header.html" .}}<p>你好 {{.Name | default "用户"}}，请访问 <a href="{{.URL}}">链接</a></p>`)},
+	"email/welcome.txt":    {Data: []byte(`你好 {{.Name | default "用户"}}，请访问 {{.URL}}{{template "footer"}}`)},
+	"email/expires.txt":    {Data: []byte(`有效期至 {{formatTime .ExpiresAt "2006-01-02 15:04"}}`)},
+	"partials/header.html": {Data: []byte(`<h1>{{upper .Site}}</h1>`)},
+	"partials/footer.txt":  {Data: []byte("{{define \"footer\"}}\n-- go-rest-starter{{end}}")},
+}
+
+func TestEngine(t *testing.T) {
+	engine, err := New(testFS, nil)
+	require.NoError(t, err)
+
+	data := map[string]interface{}{"Name": "<张三>", "URL": "https://app.example.com/verify?token=a&lang=zh", "Site": "starter"}
+
+	// HTML模板引用公共模板，数据按上下文自动转义
+	t.Run("RenderHTML", func(t *testing.T) {
+		out, err := engine.RenderString("email/welcome.html", data)
+		require.NoError(t, err)
+		assert.Equal(t, `<h1>STARTER</h1><p>你好 &lt;张三&gt;，请访问 <a href="https://app.example.com/verify?token=a&amp;lang=zh">链接</a></p>`, out)
+	})
+
+	// 纯文本模板不转义，可使用公共模板中定义的模板
+	t.Run("RenderText", func(t *testing.T) {
+		out, err := engine.RenderString("email/welcome.txt", data)
+		require.NoError(t, err)
+		assert.Equal(t, "你好 <张三>，请访问 https://app.example.com/verify?token=a&lang=zh\n-- go-rest-starter", out)
+
+		out, err = engine.RenderString("email/welcome.txt", map[string]interface{}{"Name": "", "URL": "x"})
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(out, "你好 用户，"))
+
+		out, err = engine.RenderString("email/expires.txt", map[string]interface{}{"ExpiresAt": time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)})
+		require.NoError(t, err)
+		assert.Equal(t, "有效期至 2026-01-02 03:04", out)
+	})
+
+	// 公共模板不能直接渲染，不存在的模板返回 ErrNotFound
+	t.Run("Names", func(t *testing.T) {
+		assert.Equal(t, []string{"email/expires.txt", "email/welcome.html", "email/welcome.txt"}, engine.Names())
+		assert.True(t, engine.Has("email/welcome.html"))
+		assert.False(t, engine.Has("partials/header.html"))
+
+		_, err := engine.RenderString("email/missing.html", data)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	// 缺少字段时渲染失败，且不写入部分内容
+	t.Run("MissingKey", func(t *testing.T) {
+		var out strings.Builder
+		err := engine.Render(&out, "email/welcome.html", map[string]interface{}{"Site": "starter"})
+		assert.Error(t, err)
+		assert.Empty(t, out.String())
+	})
+
+	// 语法错误的模板在创建时返回错误
+	t.Run("ParseError", func(t *testing.T) {
+		_, err := New(fstest.MapFS{"broken.html": {Data: []byte(`{{.Name`)}}, nil)
+		assert.Error(t, err)
+	})
+
+	// 额外的模板函数可以覆盖默认函数
+	t.Run("Funcs", func(t *testing.T) {
+		custom, err := New(fstest.MapFS{"hi.txt": {Data: []byte(`{{upper .}}`)}}, &Config{
+			Funcs: map[string]interface{}{"upper": func(s string) string { return "[" + s + "]" }},
+		})
+		require.NoError(t, err)
+		out, err := custom.RenderString("hi.txt", "x")
+		require.NoError(t, err)
+		assert.Equal(t, "[x]", out)
+	})
+}
+
+func TestEngine_Reload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hello.txt")
+	require.NoError(t, os.WriteFile(file, []byte(`你好 {{.}}`), 0o644))
+
+	// 开启 Reload 时修改模板文件后立即生效
+	t.Run("Enabled", func(t *testing.T) {
+		engine, err := New(os.DirFS(dir), &Config{Reload: true})
+		require.NoError(t, err)
+
+		out, err := engine.RenderString("hello.txt", "张三")
+		require.NoError(t, err)
+		assert.Equal(t, "你好 张三", out)
+
+		require.NoError(t, os.WriteFile(file, []byte(`再见 {{.}}`), 0o644))
+		out, err = engine.RenderString("hello.txt", "张三")
+		require.NoError(t, err)
+		assert.Equal(t, "再见 张三", out)
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "new.txt"), []byte(`新模板`), 0o644))
+		assert.True(t, engine.Has("new.txt"))
+	})
+
+	// 默认使用创建时解析的模板
+	t.Run("Disabled", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(file, []byte(`你好 {{.}}`), 0o644))
+		engine, err := New(os.DirFS(dir), nil)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(file, []byte(`再见 {{.}}`), 0o644))
+		out, err := engine.RenderString("hello.txt", "张三")
+		require.NoError(t, err)
+		assert.Equal(t, "你好 张三", out)
+	})
+}
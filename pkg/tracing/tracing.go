@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Config 链路追踪配置
+type Config struct {
+	// 是否启用追踪并导出span，未启用时仍会生成和透传追踪上下文
+	Enabled bool
+	// 服务名称，作为span资源的 service.name
+	ServiceName string
+	// OTLP HTTP 接收端地址，如 "localhost:4318"
+	Endpoint string
+	// 是否使用HTTP明文连接接收端
+	Insecure bool
+	// 采样比例（0-1），上游已采样的请求始终跟随上游决定
+	SampleRatio float64
+}
+
+// DefaultConfig 默认追踪配置
+var DefaultConfig = Config{
+	ServiceName: "go-rest-starter",
+	Endpoint:    "localhost:4318",
+	Insecure:    true,
+	SampleRatio: 1,
+}
+
+// ShutdownFunc 刷新未导出的span并关闭导出器
+type ShutdownFunc func(ctx context.Context) error
+
+// Propagator 返回追踪上下文传播器，支持W3C traceparent/baggage，并兼容B3请求头
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+		b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)),
+	)
+}
+
+// Init 初始化全局 TracerProvider 和传播器，config为空时使用默认配置
+// 未启用时使用不导出的 TracerProvider，仅生成和透传追踪上下文
+func Init(ctx context.Context, config *Config) (ShutdownFunc, error) {
+	if config == nil {
+		config = &DefaultConfig
+	}
+
+	otel.SetTextMapPropagator(Propagator())
+	if !config.Enabled {
+		// 不导出span，但仍为每个请求生成新的span ID，保证透传给下游的traceparent正确
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())))
+		otel.SetTracerProvider(provider)
+		return provider.Shutdown, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("创建OTLP导出器失败: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(config.ServiceName)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建追踪资源失败: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
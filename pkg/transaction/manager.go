@@ -5,10 +5,32 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// PostgreSQL 并发冲突的SQLSTATE，事务回滚后重新执行通常可以成功
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
 )
 
+// DefaultRetryConfig 事务因序列化失败或死锁回滚时的默认重试策略，MaxAttempts 为包括首次在内的总执行次数
+var DefaultRetryConfig = apperrors.RetryConfig{
+	MaxAttempts:     3,
+	InitialDelay:    20 * time.Millisecond,
+	MaxDelay:        500 * time.Millisecond,
+	Multiplier:      2.0,
+	RandomizeFactor: 0.2,
+	RetryIf:         IsRetryable,
+}
+
 // Manager 事务管理器接口
 type Manager interface {
 	// Execute 执行事务
@@ -18,16 +40,30 @@ type Manager interface {
 }
 
 // TxFunc 事务函数类型
+// 事务因序列化失败或死锁回滚时会重新执行整个函数，函数除数据库操作外不应有副作用（如发送消息、修改共享状态），
+// 或者副作用可以安全地重复执行
 type TxFunc func(ctx context.Context, tx *gorm.DB) error
 
 // GormTransactionManager GORM事务管理器
 type GormTransactionManager struct {
-	db *gorm.DB
+	db    *gorm.DB
+	retry apperrors.RetryConfig
 }
 
-// NewGormTransactionManager 创建GORM事务管理器
-func NewGormTransactionManager(db *gorm.DB) Manager {
-	return &GormTransactionManager{db: db}
+// NewGormTransactionManager 创建GORM事务管理器，retry为空时使用 DefaultRetryConfig，
+// 未设置 RetryIf 时使用 IsRetryable，MaxAttempts 为1时不重试
+func NewGormTransactionManager(db *gorm.DB, retry *apperrors.RetryConfig) Manager {
+	if retry == nil {
+		retry = &DefaultRetryConfig
+	}
+	cfg := *retry
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultRetryConfig.MaxAttempts
+	}
+	if cfg.RetryIf == nil {
+		cfg.RetryIf = IsRetryable
+	}
+	return &GormTransactionManager{db: db, retry: cfg}
 }
 
 // Execute 执行事务
@@ -35,8 +71,22 @@ func (m *GormTransactionManager) Execute(ctx context.Context, fn TxFunc) error {
 	return m.ExecuteWithOptions(ctx, nil, fn)
 }
 
-// ExecuteWithOptions 使用选项执行事务
+// ExecuteWithOptions 使用选项执行事务，序列化失败或死锁时按重试策略退避后重新执行整个事务
+// 重试次数用尽时返回的错误包装最后一次的错误
 func (m *GormTransactionManager) ExecuteWithOptions(ctx context.Context, opts *sql.TxOptions, fn TxFunc) error {
+	attempt := 0
+	return apperrors.RetryWithContext(ctx, func(ctx context.Context) error {
+		attempt++
+		err := m.execute(ctx, opts, fn)
+		if err != nil && attempt < m.retry.MaxAttempts && m.retry.RetryIf(err) {
+			slog.WarnContext(ctx, "事务因并发冲突回滚，准备重试", "attempt", attempt, "error", err)
+		}
+		return err
+	}, &m.retry)
+}
+
+// execute 执行一次事务
+func (m *GormTransactionManager) execute(ctx context.Context, opts *sql.TxOptions, fn TxFunc) error {
 	// 开始事务
 	tx := m.db.WithContext(ctx)
 	if opts != nil {
@@ -74,6 +124,23 @@ func (m *GormTransactionManager) ExecuteWithOptions(ctx context.Context, opts *s
 	return nil
 }
 
+// IsRetryable 判断事务错误是否为PostgreSQL的序列化失败（40001）或死锁（40P01），
+// 这类错误在事务回滚后重新执行通常可以成功；错误可能来自事务中的语句或提交
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
+
+// LockForUpdate 在事务中按主键id查询记录并加行锁（SELECT ... FOR UPDATE）
+// 锁在事务提交或回滚时释放，其他事务对同一行的加锁查询和更新会等待，
+// 用于“读取-修改-写回”的场景避免并发更新丢失；tx必须是事务连接，否则锁在语句结束后立即释放
+func LockForUpdate(ctx context.Context, tx *gorm.DB, dest interface{}, id uint) error {
+	return tx.WithContext(ctx).Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).First(dest, "id = ?", id).Error
+}
+
 // RunInTransaction 在事务中运行函数（简化版）
 func RunInTransaction(db *gorm.DB, fn func(*gorm.DB) error) error {
 	tx := db.Begin()
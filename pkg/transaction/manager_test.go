@@ -0,0 +1,138 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// testRetryConfig 测试使用的短间隔重试策略
+var testRetryConfig = apperrors.RetryConfig{
+	MaxAttempts:  3,
+	InitialDelay: time.Millisecond,
+	MaxDelay:     time.Millisecond,
+	Multiplier:   1,
+}
+
+// newTestManager 创建使用sqlmock的事务管理器
+func newTestManager(t *testing.T, retry *apperrors.RetryConfig) (Manager, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+		Logger:                 logger.Default.LogMode(logger.Silent),
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+
+	return NewGormTransactionManager(db, retry), mock
+}
+
+// updateBalance 事务函数，更新一个账户余额
+func updateBalance(ctx context.Context, tx *gorm.DB) error {
+	return tx.Exec("UPDATE accounts SET balance = balance - 1 WHERE id = ?", 1).Error
+}
+
+func TestGormTransactionManager_Retry(t *testing.T) {
+	ctx := context.Background()
+	serializationFailure := &pgconn.PgError{Code: "40001", Message: "could not serialize access due to concurrent update"}
+
+	// 序列化失败时回滚并重新执行整个事务，第二次成功
+	t.Run("SerializationFailureThenSuccess", func(t *testing.T) {
+		manager, mock := newTestManager(t, &testRetryConfig)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnError(serializationFailure)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		calls := 0
+		err := manager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			calls++
+			return updateBalance(ctx, tx)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 提交时检测到的死锁同样重试
+	t.Run("DeadlockOnCommit", func(t *testing.T) {
+		manager, mock := newTestManager(t, &testRetryConfig)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit().WillReturnError(&pgconn.PgError{Code: "40P01", Message: "deadlock detected"})
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, manager.Execute(ctx, updateBalance))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 重试次数用尽时返回包装最后一次错误的 RetryError
+	t.Run("Exhausted", func(t *testing.T) {
+		manager, mock := newTestManager(t, &testRetryConfig)
+		for i := 0; i < testRetryConfig.MaxAttempts; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE accounts`).WillReturnError(serializationFailure)
+			mock.ExpectRollback()
+		}
+
+		err := manager.Execute(ctx, updateBalance)
+		var retryErr *apperrors.RetryError
+		require.ErrorAs(t, err, &retryErr)
+		assert.Equal(t, 3, retryErr.Attempts)
+		assert.True(t, IsRetryable(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// 其他错误不重试，原样返回
+	t.Run("OtherErrors", func(t *testing.T) {
+		manager, mock := newTestManager(t, &testRetryConfig)
+		uniqueViolation := &pgconn.PgError{Code: "23505"}
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnError(uniqueViolation)
+		mock.ExpectRollback()
+
+		err := manager.Execute(ctx, updateBalance)
+		assert.Same(t, uniqueViolation, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		errBusiness := errors.New("余额不足")
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		err = manager.Execute(ctx, func(ctx context.Context, tx *gorm.DB) error { return errBusiness })
+		assert.Same(t, errBusiness, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	// MaxAttempts 为1时不重试
+	t.Run("Disabled", func(t *testing.T) {
+		manager, mock := newTestManager(t, &apperrors.RetryConfig{MaxAttempts: 1})
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE accounts`).WillReturnError(serializationFailure)
+		mock.ExpectRollback()
+
+		err := manager.Execute(ctx, updateBalance)
+		assert.True(t, IsRetryable(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码哈希算法
+const (
+	// HashAlgorithmBcrypt bcrypt，哈希以 $2a$/$2b$/$2y$ 开头
+	HashAlgorithmBcrypt = "bcrypt"
+	// HashAlgorithmArgon2id Argon2id，哈希以 $argon2id$ 开头（PHC字符串格式）
+	HashAlgorithmArgon2id = "argon2id"
+)
+
+// argon2idPrefix Argon2id哈希前缀
+const argon2idPrefix = "$argon2id$"
+
+// ErrPasswordMismatch 密码与哈希不匹配
+var ErrPasswordMismatch = errors.New("password: hash and password do not match")
+
+// ErrUnknownHashAlgorithm 无法根据前缀识别哈希算法
+var ErrUnknownHashAlgorithm = errors.New("password: unknown hash algorithm")
+
+// ErrInvalidHash 哈希格式错误
+var ErrInvalidHash = errors.New("password: invalid hash format")
+
+// PasswordHasher 密码哈希器
+type PasswordHasher interface {
+	// Hash 计算密码哈希，结果带算法前缀，可直接存储
+	Hash(password string) (string, error)
+	// Verify 校验密码，不匹配时返回 ErrPasswordMismatch
+	Verify(hash, password string) error
+	// NeedsRehash 哈希的算法或参数是否与当前配置不一致，需要用当前配置重新计算
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idParams Argon2id参数
+type Argon2idParams struct {
+	// 内存开销（KiB）
+	Memory uint32
+	// 迭代次数
+	Iterations uint32
+	// 并行度
+	Parallelism uint8
+	// 盐长度（字节）
+	SaltLength uint32
+	// 哈希长度（字节）
+	KeyLength uint32
+}
+
+// DefaultArgon2idParams 默认Argon2id参数，参考 RFC 9106 的推荐配置
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHashConfig 密码哈希配置
+type PasswordHashConfig struct {
+	// 新密码使用的算法，HashAlgorithmBcrypt 或 HashAlgorithmArgon2id
+	Algorithm string
+	// bcrypt计算成本，为0时使用 bcrypt.DefaultCost
+	BcryptCost int
+	// Argon2id参数，为0的字段使用 DefaultArgon2idParams 中的值
+	Argon2id Argon2idParams
+}
+
+// DefaultPasswordHashConfig 默认密码哈希配置
+var DefaultPasswordHashConfig = PasswordHashConfig{
+	Algorithm:  HashAlgorithmBcrypt,
+	BcryptCost: bcrypt.DefaultCost,
+	Argon2id:   DefaultArgon2idParams,
+}
+
+// DefaultPasswordHasher 使用默认配置的密码哈希器
+var DefaultPasswordHasher PasswordHasher = &passwordHasher{
+	primary:  BcryptHasher{Cost: bcrypt.DefaultCost},
+	bcrypt:   BcryptHasher{Cost: bcrypt.DefaultCost},
+	argon2id: Argon2idHasher{Params: DefaultArgon2idParams},
+}
+
+// NewPasswordHasher 按配置创建密码哈希器，config为空时使用默认配置
+// 新密码使用配置的算法计算哈希，校验时按哈希前缀选择算法，切换算法后已存储的旧哈希仍可校验
+func NewPasswordHasher(config *PasswordHashConfig) (PasswordHasher, error) {
+	if config == nil {
+		config = &DefaultPasswordHashConfig
+	}
+
+	cost := config.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt成本必须在%d到%d之间，当前为%d", bcrypt.MinCost, bcrypt.MaxCost, cost)
+	}
+
+	params := config.Argon2id
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultArgon2idParams.SaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultArgon2idParams.KeyLength
+	}
+
+	h := &passwordHasher{
+		bcrypt:   BcryptHasher{Cost: cost},
+		argon2id: Argon2idHasher{Params: params},
+	}
+	switch config.Algorithm {
+	case "", HashAlgorithmBcrypt:
+		h.primary = h.bcrypt
+	case HashAlgorithmArgon2id:
+		h.primary = h.argon2id
+	default:
+		return nil, fmt.Errorf("不支持的密码哈希算法: %s", config.Algorithm)
+	}
+	return h, nil
+}
+
+// passwordHasher 使用主算法计算哈希，按前缀选择算法校验
+type passwordHasher struct {
+	primary  PasswordHasher
+	bcrypt   BcryptHasher
+	argon2id Argon2idHasher
+}
+
+// Hash 使用主算法计算哈希
+func (h *passwordHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+// Verify 按哈希前缀选择算法校验密码
+func (h *passwordHasher) Verify(hash, password string) error {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return h.argon2id.Verify(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return h.bcrypt.Verify(hash, password)
+	default:
+		return ErrUnknownHashAlgorithm
+	}
+}
+
+// NeedsRehash 哈希不是主算法计算的，或主算法参数已变化时需要重新计算
+func (h *passwordHasher) NeedsRehash(hash string) bool {
+	return h.primary.NeedsRehash(hash)
+}
+
+// BcryptHasher bcrypt密码哈希器
+type BcryptHasher struct {
+	// 计算成本
+	Cost int
+}
+
+// Hash 计算bcrypt哈希
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify 校验bcrypt哈希，成本从哈希中读取
+func (h BcryptHasher) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+// NeedsRehash 哈希不是bcrypt格式或成本与配置不一致时需要重新计算
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher Argon2id密码哈希器
+type Argon2idHasher struct {
+	// 计算哈希时使用的参数
+	Params Argon2idParams
+}
+
+// Hash 计算Argon2id哈希，格式为 $argon2id$v=19$m=65536,t=3,p=2$<盐>$<哈希>
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version,
+		h.Params.Memory, h.Params.Iterations, h.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify 校验Argon2id哈希，参数从哈希中读取，不受当前配置影响
+func (h Argon2idHasher) Verify(hash, password string) error {
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// NeedsRehash 哈希不是Argon2id格式或参数与配置不一致时需要重新计算
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := parseArgon2idHash(hash)
+	return err != nil || params != h.Params
+}
+
+// parseArgon2idHash 解析PHC格式的Argon2id哈希，返回计算参数、盐和哈希值
+func parseArgon2idHash(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrInvalidHash, version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2idParams 测试使用的低开销Argon2id参数
+var fastArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func TestPasswordHasher(t *testing.T) {
+	const password = "S3cure-Passphrase"
+
+	bcryptHasher, err := NewPasswordHasher(&PasswordHashConfig{Algorithm: HashAlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+	require.NoError(t, err)
+	argon2idHasher, err := NewPasswordHasher(&PasswordHashConfig{Algorithm: HashAlgorithmArgon2id, BcryptCost: bcrypt.MinCost, Argon2id: fastArgon2idParams})
+	require.NoError(t, err)
+
+	t.Run("Bcrypt", func(t *testing.T) {
+		hash, err := bcryptHasher.Hash(password)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$2a$"))
+
+		cost, err := bcrypt.Cost([]byte(hash))
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.MinCost, cost)
+
+		assert.NoError(t, bcryptHasher.Verify(hash, password))
+		assert.ErrorIs(t, bcryptHasher.Verify(hash, "wrong-password"), ErrPasswordMismatch)
+	})
+
+	t.Run("Argon2id", func(t *testing.T) {
+		hash, err := argon2idHasher.Hash(password)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+		// 每次哈希使用随机盐
+		other, err := argon2idHasher.Hash(password)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, other)
+
+		assert.NoError(t, argon2idHasher.Verify(hash, password))
+		assert.ErrorIs(t, argon2idHasher.Verify(hash, "wrong-password"), ErrPasswordMismatch)
+	})
+
+	// 切换到Argon2id后，旧的bcrypt哈希仍可校验
+	t.Run("BcryptAfterSwitch", func(t *testing.T) {
+		oldHash, err := bcryptHasher.Hash(password)
+		require.NoError(t, err)
+
+		assert.NoError(t, argon2idHasher.Verify(oldHash, password))
+		assert.ErrorIs(t, argon2idHasher.Verify(oldHash, "wrong-password"), ErrPasswordMismatch)
+
+		// 切换回bcrypt后，Argon2id哈希同样可校验
+		newHash, err := argon2idHasher.Hash(password)
+		require.NoError(t, err)
+		assert.NoError(t, bcryptHasher.Verify(newHash, password))
+	})
+
+	// Argon2id参数从哈希中读取，调整配置后已存储的哈希仍可校验
+	t.Run("Argon2idParamsChanged", func(t *testing.T) {
+		hash, err := argon2idHasher.Hash(password)
+		require.NoError(t, err)
+
+		tuned, err := NewPasswordHasher(&PasswordHashConfig{
+			Algorithm: HashAlgorithmArgon2id,
+			Argon2id:  Argon2idParams{Memory: 2048, Iterations: 2, Parallelism: 1},
+		})
+		require.NoError(t, err)
+		assert.NoError(t, tuned.Verify(hash, password))
+	})
+
+	// 算法或参数与当前配置不一致时需要重新计算
+	t.Run("NeedsRehash", func(t *testing.T) {
+		bcryptHash, err := bcryptHasher.Hash(password)
+		require.NoError(t, err)
+		argon2idHash, err := argon2idHasher.Hash(password)
+		require.NoError(t, err)
+
+		assert.False(t, bcryptHasher.NeedsRehash(bcryptHash))
+		assert.True(t, bcryptHasher.NeedsRehash(argon2idHash))
+		assert.False(t, argon2idHasher.NeedsRehash(argon2idHash))
+		assert.True(t, argon2idHasher.NeedsRehash(bcryptHash))
+
+		higherCost, err := NewPasswordHasher(&PasswordHashConfig{Algorithm: HashAlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+		require.NoError(t, err)
+		assert.True(t, higherCost.NeedsRehash(bcryptHash))
+
+		tuned, err := NewPasswordHasher(&PasswordHashConfig{Algorithm: HashAlgorithmArgon2id, Argon2id: Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1}})
+		require.NoError(t, err)
+		assert.True(t, tuned.NeedsRehash(argon2idHash))
+	})
+
+	t.Run("UnknownAlgorithm", func(t *testing.T) {
+		assert.ErrorIs(t, argon2idHasher.Verify("plaintext", password), ErrUnknownHashAlgorithm)
+	})
+
+	t.Run("InvalidHash", func(t *testing.T) {
+		assert.ErrorIs(t, argon2idHasher.Verify("$argon2id$v=19$m=1024$salt", password), ErrInvalidHash)
+		assert.ErrorIs(t, argon2idHasher.Verify("$argon2id$v=19$m=1024,t=1,p=1$!!!$!!!", password), ErrInvalidHash)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := NewPasswordHasher(&PasswordHashConfig{Algorithm: "md5"})
+		assert.Error(t, err)
+
+		_, err = NewPasswordHasher(&PasswordHashConfig{Algorithm: HashAlgorithmBcrypt, BcryptCost: bcrypt.MaxCost + 1})
+		assert.Error(t, err)
+	})
+
+	// 未配置时使用bcrypt默认成本
+	t.Run("Default", func(t *testing.T) {
+		hasher, err := NewPasswordHasher(nil)
+		require.NoError(t, err)
+
+		hash, err := hasher.Hash(password)
+		require.NoError(t, err)
+		cost, err := bcrypt.Cost([]byte(hash))
+		require.NoError(t, err)
+		assert.Equal(t, bcrypt.DefaultCost, cost)
+		assert.NoError(t, DefaultPasswordHasher.Verify(hash, password))
+	})
+}
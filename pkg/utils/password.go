@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// PasswordPolicy 密码强度策略
+type PasswordPolicy struct {
+	// 最小长度（按字符计）
+	MinLength int
+	// 是否要求包含大写字母
+	RequireUppercase bool
+	// 是否要求包含小写字母
+	RequireLowercase bool
+	// 是否要求包含数字
+	RequireDigit bool
+	// 是否要求包含特殊字符
+	RequireSymbol bool
+	// 额外禁止使用的密码，与内置的常见密码列表一起生效，不区分大小写
+	Denylist []string
+}
+
+// DefaultPasswordPolicy 默认密码强度策略
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:        8,
+	RequireUppercase: true,
+	RequireLowercase: true,
+	RequireDigit:     true,
+}
+
+// commonPasswords 内置的常见弱密码列表
+var commonPasswords = map[string]struct{}{
+	"123456": {}, "1234567": {}, "12345678": {}, "123456789": {}, "1234567890": {},
+	"111111": {}, "000000": {}, "123123": {}, "654321": {}, "666666": {}, "888888": {},
+	"password": {}, "password1": {}, "password123": {}, "passw0rd": {}, "p@ssw0rd": {},
+	"qwerty": {}, "qwerty123": {}, "qwertyuiop": {}, "abc123": {}, "abc12345": {},
+	"admin": {}, "admin123": {}, "administrator": {}, "root": {}, "welcome": {}, "welcome1": {},
+	"letmein": {}, "iloveyou": {}, "monkey": {}, "dragon": {}, "sunshine": {}, "princess": {},
+	"football": {}, "baseball": {}, "master": {}, "superman": {}, "trustno1": {}, "changeme": {},
+	"1q2w3e4r": {}, "1qaz2wsx": {}, "zaq12wsx": {}, "aa123456": {}, "a123456": {},
+}
+
+// ValidatePasswordStrength 按策略校验密码强度，未通过时返回包含各项失败原因的验证错误
+func ValidatePasswordStrength(pw string, policy PasswordPolicy) error {
+	var fields []apperrors.FieldError
+	fail := func(tag, message string) {
+		fields = append(fields, apperrors.FieldError{Field: "password", Tag: tag, Message: message})
+	}
+
+	if utf8.RuneCountInString(pw) < policy.MinLength {
+		fail("min", fmt.Sprintf("长度不能小于%d", policy.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUpper {
+		fail("uppercase", "必须包含大写字母")
+	}
+	if policy.RequireLowercase && !hasLower {
+		fail("lowercase", "必须包含小写字母")
+	}
+	if policy.RequireDigit && !hasDigit {
+		fail("digit", "必须包含数字")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		fail("symbol", "必须包含特殊字符")
+	}
+
+	if isCommonPassword(pw, policy.Denylist) {
+		fail("common", "密码过于常见，请更换")
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	err := apperrors.ValidationError("密码强度不足", nil).WithCode(apperrors.CodePasswordTooWeak)
+	err.Fields = fields
+	return err
+}
+
+// isCommonPassword 检查密码是否在内置列表或额外禁止列表中
+func isCommonPassword(pw string, denylist []string) bool {
+	lower := strings.ToLower(pw)
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	for _, denied := range denylist {
+		if strings.ToLower(denied) == lower {
+			return true
+		}
+	}
+	return false
+}
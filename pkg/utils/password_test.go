@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apperrors "github.com/vadxq/go-rest-starter/pkg/errors"
+)
+
+// failedTags 返回密码校验未通过的规则
+func failedTags(t *testing.T, err error) []string {
+	t.Helper()
+
+	appErr, ok := err.(*apperrors.Error)
+	require.True(t, ok)
+	assert.Equal(t, apperrors.ErrorTypeValidation, appErr.Type)
+
+	tags := make([]string, 0, len(appErr.Fields))
+	for _, field := range appErr.Fields {
+		assert.Equal(t, "password", field.Field)
+		assert.NotEmpty(t, field.Message)
+		tags = append(tags, field.Tag)
+	}
+	return tags
+}
+
+func TestValidatePasswordStrength(t *testing.T) {
+	// 弱密码返回所有未满足的规则
+	t.Run("Weak", func(t *testing.T) {
+		cases := map[string][]string{
+			"abc":          {"min", "uppercase", "digit"},
+			"alllowercase": {"uppercase", "digit"},
+			"ALLUPPER123":  {"lowercase"},
+			"NoDigitsHere": {"digit"},
+		}
+		for pw, want := range cases {
+			err := ValidatePasswordStrength(pw, DefaultPasswordPolicy)
+			require.Error(t, err, pw)
+			assert.Equal(t, want, failedTags(t, err), pw)
+		}
+	})
+
+	// 常见密码即使满足字符要求也被拒绝，不区分大小写
+	t.Run("Common", func(t *testing.T) {
+		policy := PasswordPolicy{MinLength: 6}
+		for _, pw := range []string{"password123", "Password123", "QWERTY123", "12345678"} {
+			err := ValidatePasswordStrength(pw, policy)
+			require.Error(t, err, pw)
+			assert.Equal(t, []string{"common"}, failedTags(t, err), pw)
+		}
+
+		// 配置的额外禁止列表同样生效
+		policy.Denylist = []string{"CompanyName2024"}
+		err := ValidatePasswordStrength("companyname2024", policy)
+		assert.Equal(t, []string{"common"}, failedTags(t, err))
+	})
+
+	// 满足策略的强密码通过校验
+	t.Run("Strong", func(t *testing.T) {
+		policy := DefaultPasswordPolicy
+		policy.RequireSymbol = true
+		for _, pw := range []string{"S3cure-Passphrase", "Tr0ub4dor&3", "密码Abc123!"} {
+			assert.NoError(t, ValidatePasswordStrength(pw, policy), pw)
+		}
+
+		// 缺少特殊字符
+		err := ValidatePasswordStrength("S3curePassphrase", policy)
+		assert.Equal(t, []string{"symbol"}, failedTags(t, err))
+	})
+
+	// 长度按字符计算而非字节
+	t.Run("MinLengthCountsRunes", func(t *testing.T) {
+		policy := PasswordPolicy{MinLength: 4}
+		assert.Error(t, ValidatePasswordStrength("密码", policy))
+		assert.NoError(t, ValidatePasswordStrength("安全密码", policy))
+	})
+}
@@ -0,0 +1,21 @@
+#!/bin/bash
+
+# 根据 api/proto 下的 proto 定义生成 gRPC 代码
+# 需要 buf、protoc-gen-go 和 protoc-gen-go-grpc，可通过以下命令安装：
+#   go install github.com/bufbuild/buf/cmd/buf@latest
+#   go install google.golang.org/protobuf/cmd/protoc-gen-go@latest
+#   go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@latest
+
+export PATH="$PATH:$(go env GOPATH)/bin"
+
+for cmd in buf protoc-gen-go protoc-gen-go-grpc; do
+    if ! command -v "$cmd" &> /dev/null; then
+        echo "未找到 $cmd 命令，请先安装"
+        exit 1
+    fi
+done
+
+cd "$(dirname "$0")/../api/proto" || exit 1
+buf generate
+
+echo "gRPC代码已生成到 api/proto/ 目录"
@@ -0,0 +1,19 @@
+// Package web 内置的邮件和页面模板，随程序一起编译
+package web
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed templates
+var files embed.FS
+
+// Templates 返回内置模板的文件系统，根目录为 templates 目录
+func Templates() fs.FS {
+	sub, err := fs.Sub(files, "templates")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}